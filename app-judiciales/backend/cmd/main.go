@@ -9,6 +9,8 @@ import (
 	"expedientes-backend/internal/models"
 	"expedientes-backend/internal/repository"
 	"expedientes-backend/internal/services"
+	"expedientes-backend/internal/storage"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -68,18 +70,91 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	profileRepo := repository.NewProfileRepository(db.GetMongoDB())
 	expedienteRepo := repository.NewExpedienteRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	breakGlassRepo := repository.NewBreakGlassRepository(db)
+	archivoRepo := repository.NewArchivoRepository(db)
+	estadoRepo := repository.NewEstadoRepository(db)
+	tomoRepo := repository.NewTomoRepository(db)
+	carreraRepo := repository.NewCarreraRepository(db)
+	reconciliacionRepo := repository.NewReconciliacionRepository(db)
+	prestamoRepo := repository.NewPrestamoRepository(db)
+	inventarioRepo := repository.NewInventarioRepository(db)
+	documentoRepo := repository.NewDocumentoRepository(db)
+	integridadRepo := repository.NewIntegridadRepository(db)
+	textoRepo := repository.NewTextoRepository(db)
+	ingestaRepo := repository.NewIngestaRepository(db)
+	movimientoRepo := repository.NewMovimientoRepository(db)
+	notificacionRepo := repository.NewNotificacionRepository(db)
+	dependenciaRepo := repository.NewDependenciaRepository(db)
 
 	// Initialize services
 	authService := services.NewAuthService(userRepo, profileRepo, cfg.JWTSecret, cfg.JWTExpiration)
 	profileService := services.NewProfileService(profileRepo)
 	userService := services.NewUserServiceWithServices(userRepo, profileService)
-	expedienteService := services.NewExpedienteService(expedienteRepo)
+	ubicacionEngine := services.NewUbicacionEngine(services.UbicacionRules{
+		Particulas:  cfg.UbicacionParticulas,
+		EneSeparada: cfg.UbicacionEneSeparada,
+	})
+	cipValidator, err := services.NewCIPValidator(services.CIPRules{
+		Categorias: map[models.CategoriaGrado]services.CIPRegla{
+			models.CategoriaOficiales: {Longitud: cfg.CIPLongitudOficiales, Patron: cfg.CIPPatronOficiales},
+			models.CategoriaTcoSsoo:   {Longitud: cfg.CIPLongitudTcoSsoo, Patron: cfg.CIPPatronTcoSsoo},
+			models.CategoriaTropa:     {Longitud: cfg.CIPLongitudTropa, Patron: cfg.CIPPatronTropa},
+		},
+		DigitoVerificador: cfg.CIPDigitoVerificador,
+	})
+	if err != nil {
+		log.Fatal("Invalid CIP rules:", err)
+	}
+	expedienteService := services.NewExpedienteService(expedienteRepo, auditRepo, ubicacionEngine, cipValidator)
+
+	// Supervisor alerts use email only when an SMTP host is configured
+	var mailer services.Mailer
+	smtpMailer := services.NewSMTPMailer(cfg.EmailHost, cfg.EmailPort, cfg.EmailUsername, cfg.EmailPassword, cfg.EmailFrom)
+	if smtpMailer.Enabled() {
+		mailer = smtpMailer
+	}
+	supervisorNotifier := services.NewSupervisorNotifier(mailer, cfg.BreakGlassSupervisors, cfg.BreakGlassWebhookURL)
+	breakGlassService := services.NewBreakGlassService(breakGlassRepo, expedienteRepo, auditRepo, supervisorNotifier, cfg.BreakGlassDuration)
+	archivoService := services.NewArchivoService(archivoRepo, expedienteRepo)
+	rebalanceoService := services.NewRebalanceoService(archivoRepo, expedienteRepo, auditRepo)
+	estadoService := services.NewEstadoService(estadoRepo, expedienteRepo)
+	tomoService := services.NewTomoService(tomoRepo, expedienteRepo, estadoService)
+	carreraService := services.NewCarreraService(carreraRepo, expedienteRepo, auditRepo, cipValidator)
+	reconciliacionService := services.NewReconciliacionService(reconciliacionRepo, expedienteRepo, auditRepo, carreraService, cipValidator)
+	duplicadoService := services.NewDuplicadoService(expedienteRepo, tomoRepo, estadoRepo, carreraRepo, prestamoRepo, documentoRepo, movimientoRepo, notificacionRepo, auditRepo)
+	etiquetaService := services.NewEtiquetaService(expedienteService, tomoRepo, archivoRepo)
+	prestamoService := services.NewPrestamoService(prestamoRepo, expedienteRepo, tomoRepo, archivoRepo, dependenciaRepo, estadoService, tomoService)
+	inventarioService := services.NewInventarioService(inventarioRepo, archivoRepo, expedienteRepo, tomoRepo, auditRepo)
+	digitalizacionService := services.NewDigitalizacionService(expedienteRepo, tomoRepo, tomoService, userRepo, archivoRepo, auditRepo)
+
+	// Attached documents are stored under UPLOAD_PATH or in an S3-compatible bucket
+	documentoStorage, err := newDocumentoStorage(cfg)
+	if err != nil {
+		log.Fatal("Invalid document storage:", err)
+	}
+	textoService := services.NewTextoService(documentoRepo, textoRepo, expedienteService, documentoStorage)
+	documentoService := services.NewDocumentoService(documentoRepo, expedienteRepo, expedienteService, textoService, auditRepo, documentoStorage, cfg.MaxUploadSize, cfg.S3PresignDuration)
+	movimientoService := services.NewMovimientoService(movimientoRepo, expedienteRepo, documentoRepo, auditRepo)
+	notificacionService := services.NewNotificacionService(notificacionRepo, movimientoRepo, expedienteRepo, auditRepo, mailer)
+	dependenciaService := services.NewDependenciaService(dependenciaRepo, expedienteRepo, prestamoRepo, userRepo, auditRepo)
+	integridadService := services.NewIntegridadService(documentoRepo, integridadRepo, expedienteService, auditRepo, documentoStorage)
+	ingestaService, err := services.NewIngestaService(documentoService, documentoRepo, expedienteRepo, tomoRepo, tomoService, digitalizacionService, cipValidator, ingestaRepo, auditRepo, services.IngestaConfig{
+		Directorio: cfg.IngestDir,
+		Cuarentena: cfg.IngestQuarantineDir,
+		Patron:     cfg.IngestPattern,
+		Intervalo:  cfg.IngestInterval,
+	})
+	if err != nil {
+		log.Fatal("Invalid ingest configuration:", err)
+	}
 
 	// Set profile repository for middleware permission checking
 	middleware.SetProfileRepository(profileRepo)
+	middleware.SetBreakGlassRepository(breakGlassRepo)
 
 	// Initialize database
-	if err := initializeDatabase(ctx, db, profileRepo, profileService, userService); err != nil {
+	if err := initializeDatabase(ctx, db, profileRepo, expedienteRepo, archivoRepo, estadoRepo, profileService, userService); err != nil {
 		log.Fatalf("❌ Failed to initialize database: %v", err)
 	}
 
@@ -88,6 +163,25 @@ func main() {
 	userHandler := handlers.NewUserHandler(userService)
 	profileHandler := handlers.NewProfileHandler(profileService)
 	expedienteHandler := handlers.NewExpedienteHandler(expedienteService)
+	breakGlassHandler := handlers.NewBreakGlassHandler(breakGlassService)
+	archivoHandler := handlers.NewArchivoHandler(archivoService, expedienteService)
+	rebalanceoHandler := handlers.NewRebalanceoHandler(rebalanceoService)
+	estadoHandler := handlers.NewEstadoHandler(estadoService)
+	tomoHandler := handlers.NewTomoHandler(tomoService)
+	carreraHandler := handlers.NewCarreraHandler(carreraService)
+	reconciliacionHandler := handlers.NewReconciliacionHandler(reconciliacionService)
+	duplicadoHandler := handlers.NewDuplicadoHandler(duplicadoService)
+	etiquetaHandler := handlers.NewEtiquetaHandler(etiquetaService)
+	prestamoHandler := handlers.NewPrestamoHandler(prestamoService)
+	inventarioHandler := handlers.NewInventarioHandler(inventarioService)
+	digitalizacionHandler := handlers.NewDigitalizacionHandler(digitalizacionService)
+	movimientoHandler := handlers.NewMovimientoHandler(movimientoService)
+	notificacionHandler := handlers.NewNotificacionHandler(notificacionService)
+	dependenciaHandler := handlers.NewDependenciaHandler(dependenciaService)
+	documentoHandler := handlers.NewDocumentoHandler(documentoService)
+	integridadHandler := handlers.NewIntegridadHandler(integridadService)
+	textoHandler := handlers.NewTextoHandler(textoService)
+	ingestaHandler := handlers.NewIngestaHandler(ingestaService)
 	docsHandler := handlers.NewDocsHandler()
 
 	// Set Gin mode
@@ -240,12 +334,36 @@ func main() {
 
 			// Expediente routes - Permission-based access control
 			expedientes := protected.Group("/expedientes")
+			expedientes.Use(middleware.LoadAccessScope())
 			{
 				// Read access - Users with read permission
 				expedientes.GET(PathHome, logEndpoint("📂 EXPEDIENTES-LIST", "Consulta lista de expedientes"), middleware.RequirePermission(models.PermissionExpedienteRead), expedienteHandler.GetExpedientes)
 				expedientes.GET(PathVariableId, logEndpoint("📄 EXPEDIENTE-GET", "Consulta expediente específico"), middleware.RequirePermission(models.PermissionExpedienteRead), expedienteHandler.GetExpediente)
 				expedientes.GET("/search", logEndpoint("🔍 EXPEDIENTES-SEARCH", "Búsqueda de expedientes"), middleware.RequirePermission(models.PermissionExpedienteRead), expedienteHandler.SearchExpedientes)
 				expedientes.GET("/division", logEndpoint("📂 EXPEDIENTES-DIVISION", "Expedientes por división"), middleware.RequirePermission(models.PermissionExpedienteRead), expedienteHandler.GetExpedientesByDivision)
+				expedientes.GET("/estados/transiciones", logEndpoint("🔄 EXPEDIENTES-TRANSITIONS", "Transiciones de estado permitidas"), middleware.RequirePermission(models.PermissionExpedienteRead), estadoHandler.GetTransiciones)
+				expedientes.GET("/:id/estado/historial", logEndpoint("🕓 EXPEDIENTE-STATUS-HISTORY", "Historial de estados del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), estadoHandler.GetHistorial)
+				expedientes.GET("/:id/tomos", logEndpoint("📚 EXPEDIENTE-TOMOS", "Tomos del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), tomoHandler.GetTomos)
+				expedientes.GET("/:id/carrera", logEndpoint("🎖️ EXPEDIENTE-CAREER", "Historial de carrera del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), carreraHandler.GetCarrera)
+				expedientes.GET("/duplicados", logEndpoint("👥 EXPEDIENTES-DUPLICATES", "Detección de expedientes duplicados"), middleware.RequirePermission(models.PermissionExpedienteRead), duplicadoHandler.GetDuplicados)
+				expedientes.GET("/prestamos", logEndpoint("📠 EXPEDIENTES-LOANS", "Carpetas prestadas"), middleware.RequirePermission(models.PermissionExpedienteRead), prestamoHandler.GetPrestamosAbiertos)
+				expedientes.GET("/:id/prestamos", logEndpoint("📠 EXPEDIENTE-LOANS", "Historial de préstamos del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), prestamoHandler.GetPrestamos)
+				expedientes.GET("/:id/documentos", logEndpoint("📎 EXPEDIENTE-DOCUMENTS", "Documentos adjuntos del expediente"), middleware.RequirePermission(models.PermissionDocumentoRead), documentoHandler.GetDocumentos)
+				expedientes.GET("/:id/documentos/:documentoId", logEndpoint("📎 DOCUMENT-DOWNLOAD", "Descarga de documento adjunto"), middleware.RequirePermission(models.PermissionDocumentoRead), documentoHandler.DescargarDocumento)
+				expedientes.GET("/:id/documentos/:documentoId/enlace", logEndpoint("📎 DOCUMENT-LINK", "Enlace temporal de descarga de documento"), middleware.RequirePermission(models.PermissionDocumentoRead), documentoHandler.EnlaceDocumento)
+				expedientes.GET("/:id/documentos/:documentoId/versiones", logEndpoint("📎 DOCUMENT-VERSIONS", "Versiones de documento adjunto"), middleware.RequirePermission(models.PermissionDocumentoRead), documentoHandler.GetVersiones)
+				expedientes.GET("/:id/documentos/:documentoId/versiones/:version", logEndpoint("📎 DOCUMENT-VERSION-DOWNLOAD", "Descarga de versión de documento adjunto"), middleware.RequirePermission(models.PermissionDocumentoRead), documentoHandler.DescargarVersion)
+				expedientes.GET("/:id/documentos/integridad", logEndpoint("🔏 DOCUMENT-FIXITY", "Estado de integridad de los documentos del expediente"), middleware.RequirePermission(models.PermissionDocumentoRead), integridadHandler.GetIntegridadExpediente)
+				expedientes.GET("/documentos/buscar", logEndpoint("🔎 DOCUMENT-SEARCH", "Búsqueda de texto en documentos adjuntos"), middleware.RequirePermission(models.PermissionDocumentoRead), textoHandler.BuscarTexto)
+				expedientes.GET("/digitalizacion", logEndpoint("🖨️ EXPEDIENTES-DIGITIZATION", "Seguimiento de digitalización"), middleware.RequirePermission(models.PermissionExpedienteRead), digitalizacionHandler.SearchDigitalizacion)
+				expedientes.GET("/:id/digitalizacion", logEndpoint("🖨️ EXPEDIENTE-DIGITIZATION", "Digitalización del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), digitalizacionHandler.GetDigitalizacion)
+				expedientes.GET("/movimientos", logEndpoint("📜 EXPEDIENTES-MOVEMENTS", "Consulta de movimientos de expedientes"), middleware.RequirePermission(models.PermissionExpedienteRead), movimientoHandler.SearchMovimientos)
+				expedientes.GET("/:id/movimientos", logEndpoint("📜 EXPEDIENTE-MOVEMENTS", "Movimientos del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), movimientoHandler.GetMovimientos)
+				expedientes.GET("/:id/movimientos/:numero", logEndpoint("📜 EXPEDIENTE-MOVEMENT", "Consulta de movimiento del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), movimientoHandler.GetMovimiento)
+				expedientes.GET("/:id/notificaciones", logEndpoint("📨 EXPEDIENTE-NOTIFICATIONS", "Notificaciones del expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), notificacionHandler.GetNotificaciones)
+				expedientes.GET("/:id/movimientos/:numero/notificaciones", logEndpoint("📨 MOVEMENT-NOTIFICATIONS", "Notificaciones del movimiento"), middleware.RequirePermission(models.PermissionExpedienteRead), notificacionHandler.GetNotificacionesMovimiento)
+				expedientes.GET("/notificaciones/pendientes", logEndpoint("📨 NOTIFICATIONS-PENDING", "Reporte de notificaciones pendientes"), middleware.RequirePermission(models.PermissionExpedienteRead), notificacionHandler.GetPendientes)
+				expedientes.GET("/carrera/eventos", logEndpoint("🎖️ EXPEDIENTES-CAREER-REPORT", "Reporte de eventos de carrera"), middleware.RequirePermission(models.PermissionExpedienteRead), carreraHandler.SearchEventos)
 
 				// Export (only system admin)
 				expedientes.GET("/export", logEndpoint("📤 EXPEDIENTES-EXPORT", "Exportar expedientes (Excel)"), middleware.RequirePermission(models.PermissionSystemAdmin), expedienteHandler.ExportExpedientesExcel)
@@ -254,7 +372,38 @@ func main() {
 				expedientes.POST(PathHome, logEndpoint("➕ EXPEDIENTE-CREATE", "Creación de nuevo expediente"), middleware.RequirePermission(models.PermissionExpedienteCreate), expedienteHandler.CreateExpediente)
 				expedientes.POST("/bulk-import", logEndpoint("📂 EXPEDIENTES-BULK-IMPORT", "Importación masiva desde Excel"), middleware.RequirePermission(models.PermissionExpedienteCreate), expedienteHandler.BulkImportExpedientes)
 				expedientes.PUT(PathVariableId, logEndpoint("✏️ EXPEDIENTE-UPDATE", "Actualización de expediente"), middleware.RequirePermission(models.PermissionExpedienteUpdate), expedienteHandler.UpdateExpediente)
-				expedientes.PUT("/:id/estado", logEndpoint("🔄 EXPEDIENTE-STATUS", "Cambio estado expediente"), middleware.RequirePermission(models.PermissionExpedienteUpdate), expedienteHandler.UpdateEstado)
+				// Each transition is guarded by its own configured permission
+				expedientes.PUT("/:id/estado", logEndpoint("🔄 EXPEDIENTE-STATUS", "Cambio estado expediente"), middleware.RequirePermission(models.PermissionExpedienteRead), estadoHandler.CambiarEstado)
+				expedientes.PUT("/:id/tomos/:tomoId/estado", logEndpoint("🔄 TOMO-STATUS", "Cambio estado de tomo"), middleware.RequirePermission(models.PermissionExpedienteRead), tomoHandler.CambiarEstadoTomo)
+				expedientes.POST("/escaneo", logEndpoint("📠 EXPEDIENTE-SCAN", "Préstamo o devolución por escaneo"), middleware.RequirePermission(models.PermissionExpedienteRead), prestamoHandler.Escanear)
+				expedientes.POST("/:id/tomos", logEndpoint("📚 TOMO-CREATE", "Creación de tomo"), middleware.RequirePermission(models.PermissionExpedienteUpdate), tomoHandler.CreateTomo)
+				expedientes.PUT("/:id/tomos/:tomoId", logEndpoint("📚 TOMO-UPDATE", "Actualización de tomo"), middleware.RequirePermission(models.PermissionExpedienteUpdate), tomoHandler.UpdateTomo)
+				expedientes.DELETE("/:id/tomos/:tomoId", logEndpoint("📚 TOMO-DELETE", "Eliminación de tomo"), middleware.RequirePermission(models.PermissionExpedienteUpdate), tomoHandler.DeleteTomo)
+				expedientes.POST("/:id/documentos", logEndpoint("📎 DOCUMENT-UPLOAD", "Carga de documentos adjuntos"), middleware.RequirePermission(models.PermissionDocumentoUpload), documentoHandler.SubirDocumentos)
+				expedientes.DELETE("/:id/documentos/:documentoId", logEndpoint("📎 DOCUMENT-DELETE", "Eliminación de documento adjunto"), middleware.RequirePermission(models.PermissionDocumentoDelete), documentoHandler.EliminarDocumento)
+				expedientes.POST("/:id/documentos/:documentoId/versiones", logEndpoint("📎 DOCUMENT-VERSION-UPLOAD", "Carga de nueva versión de documento adjunto"), middleware.RequirePermission(models.PermissionDocumentoUpload), documentoHandler.SubirVersion)
+				expedientes.POST("/:id/documentos/:documentoId/versiones/:version/restaurar", logEndpoint("📎 DOCUMENT-VERSION-RESTORE", "Restauración de versión de documento adjunto"), middleware.RequirePermission(models.PermissionDocumentoUpload), documentoHandler.RestaurarVersion)
+				expedientes.PUT("/:id/documentos/:documentoId/confidencial", logEndpoint("🔒 DOCUMENT-CONFIDENTIAL", "Marcado de documento confidencial"), middleware.RequirePermission(models.PermissionDocumentoConfidential), documentoHandler.SetConfidencial)
+				expedientes.PUT("/:id/digitalizacion", logEndpoint("🖨️ EXPEDIENTE-DIGITIZATION-UPDATE", "Actualización de digitalización"), middleware.RequirePermission(models.PermissionDigitalizacionUpdate), digitalizacionHandler.ActualizarDigitalizacion)
+				expedientes.PUT("/:id/digitalizacion/asignacion", logEndpoint("🖨️ EXPEDIENTE-DIGITIZATION-ASSIGN", "Asignación de operador de digitalización"), middleware.RequirePermission(models.PermissionDigitalizacionAssign), digitalizacionHandler.AsignarDigitalizacion)
+				expedientes.POST("/:id/movimientos", logEndpoint("📜 EXPEDIENTE-MOVEMENT-CREATE", "Registro de movimiento del expediente"), middleware.RequirePermission(models.PermissionMovimientoCreate), movimientoHandler.RegistrarMovimiento)
+				expedientes.POST("/:id/movimientos/:numero/notificaciones", logEndpoint("📨 MOVEMENT-NOTIFICATION-CREATE", "Registro de notificación del movimiento"), middleware.RequirePermission(models.PermissionNotificacionManage), notificacionHandler.RegistrarNotificacion)
+				expedientes.PUT("/:id/notificaciones/:notificacionId/estado", logEndpoint("📨 NOTIFICATION-STATUS", "Cambio de estado de notificación"), middleware.RequirePermission(models.PermissionNotificacionManage), notificacionHandler.ActualizarEstado)
+				expedientes.POST("/:id/notificaciones/:notificacionId/reenviar", logEndpoint("📨 NOTIFICATION-RESEND", "Reenvío de notificación por email"), middleware.RequirePermission(models.PermissionNotificacionManage), notificacionHandler.ReenviarNotificacion)
+				expedientes.PUT("/:id/dependencia", logEndpoint("🏛️ EXPEDIENTE-DEPENDENCIA", "Asignación de dependencia del expediente"), middleware.RequirePermission(models.PermissionExpedienteUpdate), dependenciaHandler.AsignarDependencia)
+				expedientes.POST("/:id/carrera", logEndpoint("🎖️ EXPEDIENTE-CAREER-EVENT", "Registro de evento de carrera"), middleware.RequirePermission(models.PermissionExpedienteUpdate), carreraHandler.RegistrarEvento)
+				expedientes.POST("/carrera/resoluciones", logEndpoint("🎖️ CAREER-RESOLUTION-PREVIEW", "Previsualización de lote de resolución"), middleware.RequirePermission(models.PermissionExpedienteManage), carreraHandler.PrevisualizarResolucion)
+				expedientes.GET("/carrera/resoluciones/:id", logEndpoint("🎖️ CAREER-RESOLUTION-GET", "Consulta lote de resolución"), middleware.RequirePermission(models.PermissionExpedienteManage), carreraHandler.GetResolucion)
+				expedientes.POST("/carrera/resoluciones/:id/aplicar", logEndpoint("🎖️ CAREER-RESOLUTION-APPLY", "Aplicación de lote de resolución"), middleware.RequirePermission(models.PermissionExpedienteManage), carreraHandler.AplicarResolucion)
+				expedientes.POST("/etiquetas", logEndpoint("🏷️ EXPEDIENTES-LABELS", "Etiquetas de carpetas seleccionadas"), middleware.RequirePermission(models.PermissionExpedienteRead), etiquetaHandler.GenerarEtiquetas)
+				expedientes.POST("/merge", logEndpoint("🔗 EXPEDIENTES-MERGE", "Fusión de expedientes duplicados"), middleware.RequirePermission(models.PermissionExpedienteManage), duplicadoHandler.MergeExpedientes)
+				expedientes.POST("/reconciliacion", logEndpoint("📋 ROSTER-RECONCILE", "Reconciliación con nómina de personal"), middleware.RequirePermission(models.PermissionExpedienteManage), reconciliacionHandler.Reconciliar)
+				expedientes.GET("/reconciliacion/:id", logEndpoint("📋 ROSTER-RECONCILE-GET", "Consulta reconciliación de nómina"), middleware.RequirePermission(models.PermissionExpedienteManage), reconciliacionHandler.GetReconciliacion)
+				expedientes.GET("/reconciliacion/:id/excel", logEndpoint("📤 ROSTER-RECONCILE-EXPORT", "Exportar reconciliación de nómina (Excel)"), middleware.RequirePermission(models.PermissionExpedienteManage), reconciliacionHandler.ExportReconciliacionExcel)
+				expedientes.POST("/reconciliacion/:id/aplicar", logEndpoint("📋 ROSTER-RECONCILE-APPLY", "Aplicación de discrepancias de nómina"), middleware.RequirePermission(models.PermissionExpedienteManage), reconciliacionHandler.AplicarDiscrepancias)
+				expedientes.POST("/:id/break-glass", logEndpoint("🚨 EXPEDIENTE-BREAK-GLASS", "Solicitud de acceso de emergencia"), middleware.RequirePermission(models.PermissionExpedienteBreakGlass), breakGlassHandler.RequestAccess)
+				expedientes.GET("/break-glass/activos", logEndpoint("🚨 BREAK-GLASS-ACTIVE", "Accesos de emergencia activos"), middleware.RequirePermission(models.PermissionExpedienteBreakGlass), breakGlassHandler.GetActiveGrants)
+				expedientes.PUT("/:id/clasificacion", logEndpoint("🔒 EXPEDIENTE-CLASSIFY", "Cambio clasificación expediente"), middleware.RequirePermission(models.PermissionExpedienteClassify), expedienteHandler.UpdateClasificacion)
 
 				// Delete access - Users with delete permission
 				expedientes.DELETE(PathVariableId, logEndpoint("🗑️ EXPEDIENTE-DELETE", "Eliminación de expediente"), middleware.RequirePermission(models.PermissionExpedienteDelete), expedienteHandler.DeleteExpediente)
@@ -262,8 +411,59 @@ func main() {
 
 			// Dashboard routes - Permission-based access control
 			dashboard := protected.Group("/dashboard")
+			dashboard.Use(middleware.LoadAccessScope())
 			{
 				dashboard.GET("/stats", logEndpoint("📊 DASHBOARD-STATS", "Estadísticas del dashboard"), middleware.RequirePermission(models.PermissionDashboardStats), expedienteHandler.GetDashboardStats)
+				dashboard.GET("/digitalizacion", logEndpoint("🖨️ DASHBOARD-DIGITIZATION", "Avance de digitalización"), middleware.RequirePermission(models.PermissionDashboardStats), digitalizacionHandler.GetResumen)
+			}
+
+			// Archive layout routes - shelves, divisions and occupancy
+			archivo := protected.Group("/archivo")
+			archivo.Use(middleware.LoadAccessScope())
+			{
+				archivo.GET("/estantes", logEndpoint("🗄️ ARCHIVO-ESTANTES", "Estantes y ocupación por división"), middleware.RequirePermission(models.PermissionArchivoRead), archivoHandler.GetEstantes)
+				archivo.GET("/estantes/:id", logEndpoint("🗄️ ARCHIVO-ESTANTE-GET", "Consulta estante específico"), middleware.RequirePermission(models.PermissionArchivoRead), archivoHandler.GetEstante)
+				archivo.GET("/estantes/:id/etiquetas", logEndpoint("🏷️ ARCHIVO-ESTANTE-LABELS", "Etiquetas de cabecera de estante"), middleware.RequirePermission(models.PermissionArchivoRead), etiquetaHandler.GetEtiquetasEstante)
+				archivo.POST("/estantes", logEndpoint("➕ ARCHIVO-ESTANTE-CREATE", "Creación de estante"), middleware.RequirePermission(models.PermissionArchivoManage), archivoHandler.CreateEstante)
+				archivo.PUT("/estantes/:id", logEndpoint("✏️ ARCHIVO-ESTANTE-UPDATE", "Actualización de estante"), middleware.RequirePermission(models.PermissionArchivoManage), archivoHandler.UpdateEstante)
+				archivo.DELETE("/estantes/:id", logEndpoint("🗑️ ARCHIVO-ESTANTE-DELETE", "Eliminación de estante"), middleware.RequirePermission(models.PermissionArchivoManage), archivoHandler.DeleteEstante)
+
+				archivo.GET("/divisiones", logEndpoint("🗄️ ARCHIVO-DIVISIONES", "Consulta divisiones"), middleware.RequirePermission(models.PermissionArchivoRead), archivoHandler.GetDivisiones)
+				archivo.GET("/divisiones/:id", logEndpoint("🗄️ ARCHIVO-DIVISION-GET", "Consulta división específica"), middleware.RequirePermission(models.PermissionArchivoRead), archivoHandler.GetDivision)
+				archivo.GET("/divisiones/:id/expedientes", logEndpoint("📂 ARCHIVO-DIVISION-EXPEDIENTES", "Expedientes de una división"), middleware.RequirePermission(models.PermissionExpedienteRead), archivoHandler.GetDivisionExpedientes)
+				archivo.GET("/divisiones/:id/etiquetas", logEndpoint("🏷️ ARCHIVO-DIVISION-LABELS", "Etiquetas de carpetas de una división"), middleware.RequirePermission(models.PermissionExpedienteRead), etiquetaHandler.GetEtiquetasDivision)
+				archivo.POST("/divisiones", logEndpoint("➕ ARCHIVO-DIVISION-CREATE", "Creación de división"), middleware.RequirePermission(models.PermissionArchivoManage), archivoHandler.CreateDivision)
+				archivo.PUT("/divisiones/:id", logEndpoint("✏️ ARCHIVO-DIVISION-UPDATE", "Actualización de división"), middleware.RequirePermission(models.PermissionArchivoManage), archivoHandler.UpdateDivision)
+				archivo.DELETE("/divisiones/:id", logEndpoint("🗑️ ARCHIVO-DIVISION-DELETE", "Eliminación de división"), middleware.RequirePermission(models.PermissionArchivoManage), archivoHandler.DeleteDivision)
+
+				// Rebalancing planner
+				archivo.POST("/rebalanceo", logEndpoint("⚖️ ARCHIVO-REBALANCEO", "Generación de plan de rebalanceo"), middleware.RequirePermission(models.PermissionArchivoManage), rebalanceoHandler.GenerarPlan)
+				archivo.GET("/rebalanceo/:id", logEndpoint("⚖️ ARCHIVO-REBALANCEO-GET", "Consulta plan de rebalanceo"), middleware.RequirePermission(models.PermissionArchivoManage), rebalanceoHandler.GetPlan)
+				archivo.GET("/rebalanceo/:id/excel", logEndpoint("📤 ARCHIVO-REBALANCEO-EXCEL", "Descarga plan de rebalanceo (Excel)"), middleware.RequirePermission(models.PermissionArchivoManage), rebalanceoHandler.ExportPlanExcel)
+				archivo.POST("/rebalanceo/:id/aplicar", logEndpoint("✅ ARCHIVO-REBALANCEO-APLICAR", "Aplicación de plan de rebalanceo"), middleware.RequirePermission(models.PermissionArchivoManage), rebalanceoHandler.AplicarPlan)
+
+				// Physical inventory sessions
+				archivo.GET("/inventarios", logEndpoint("📋 ARCHIVO-INVENTARIOS", "Consulta inventarios físicos"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.GetInventarios)
+				archivo.POST("/inventarios", logEndpoint("📋 ARCHIVO-INVENTARIO-CREATE", "Inicio de inventario físico"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.CreateInventario)
+				archivo.GET("/inventarios/:id", logEndpoint("📋 ARCHIVO-INVENTARIO-GET", "Consulta inventario físico"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.GetInventario)
+				archivo.POST("/inventarios/:id/lecturas", logEndpoint("📠 ARCHIVO-INVENTARIO-SCAN", "Lecturas de inventario físico"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.RegistrarLecturas)
+				archivo.POST("/inventarios/:id/pausar", logEndpoint("⏸️ ARCHIVO-INVENTARIO-PAUSE", "Pausa de inventario físico"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.PausarInventario)
+				archivo.POST("/inventarios/:id/reanudar", logEndpoint("▶️ ARCHIVO-INVENTARIO-RESUME", "Reanudación de inventario físico"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.ReanudarInventario)
+				archivo.POST("/inventarios/:id/cerrar", logEndpoint("✅ ARCHIVO-INVENTARIO-CLOSE", "Cierre de inventario físico"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.CerrarInventario)
+				archivo.GET("/inventarios/:id/reporte", logEndpoint("📋 ARCHIVO-INVENTARIO-REPORT", "Reporte de inventario físico"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.GetReporte)
+				archivo.GET("/inventarios/:id/excel", logEndpoint("📤 ARCHIVO-INVENTARIO-EXCEL", "Exportar reporte de inventario (Excel)"), middleware.RequirePermission(models.PermissionArchivoManage), inventarioHandler.ExportReporteExcel)
+			}
+
+			// Juzgados and offices catalog
+			dependencias := protected.Group("/dependencias")
+			dependencias.Use(middleware.LoadAccessScope())
+			{
+				dependencias.GET(PathHome, logEndpoint("🏛️ DEPENDENCIAS-LIST", "Consulta catálogo de dependencias"), middleware.RequirePermission(models.PermissionExpedienteRead), dependenciaHandler.GetDependencias)
+				dependencias.GET("/tenencia", logEndpoint("🏛️ DEPENDENCIAS-HOLDINGS", "Expedientes en poder de cada dependencia"), middleware.RequirePermission(models.PermissionExpedienteRead), dependenciaHandler.GetTenencia)
+				dependencias.GET(PathVariableId, logEndpoint("🏛️ DEPENDENCIA-GET", "Consulta dependencia específica"), middleware.RequirePermission(models.PermissionExpedienteRead), dependenciaHandler.GetDependencia)
+				dependencias.POST(PathHome, logEndpoint("➕ DEPENDENCIA-CREATE", "Creación de dependencia"), middleware.RequirePermission(models.PermissionDependenciaManage), dependenciaHandler.CreateDependencia)
+				dependencias.PUT(PathVariableId, logEndpoint("✏️ DEPENDENCIA-UPDATE", "Actualización de dependencia"), middleware.RequirePermission(models.PermissionDependenciaManage), dependenciaHandler.UpdateDependencia)
+				dependencias.DELETE(PathVariableId, logEndpoint("🗑️ DEPENDENCIA-DELETE", "Eliminación de dependencia"), middleware.RequirePermission(models.PermissionDependenciaManage), dependenciaHandler.DeleteDependencia)
 			}
 
 			// System admin only routes
@@ -271,6 +471,16 @@ func main() {
 			admin.Use(middleware.RequirePermission(models.PermissionSystemAdmin))
 			{
 				admin.GET("/profiles", logEndpoint("🔧 ADMIN-PROFILES", "Administración de perfiles"), profileHandler.GetProfiles)
+				admin.GET("/break-glass", logEndpoint("🚨 ADMIN-BREAK-GLASS", "Cola de revisión de accesos de emergencia"), breakGlassHandler.ListForReview)
+				admin.PUT("/break-glass/:id/revision", logEndpoint("✅ ADMIN-BREAK-GLASS-REVIEW", "Revisión de acceso de emergencia"), breakGlassHandler.SignOff)
+				admin.POST("/expedientes/reordenar", logEndpoint("🔢 ADMIN-REORDER", "Recálculo del orden de archivo"), expedienteHandler.Reordenar)
+				admin.POST("/estados/transiciones", logEndpoint("🔄 ADMIN-TRANSITION-CREATE", "Creación de transición de estado"), estadoHandler.CreateTransicion)
+				admin.PUT("/estados/transiciones/:id", logEndpoint("🔄 ADMIN-TRANSITION-UPDATE", "Actualización de transición de estado"), estadoHandler.UpdateTransicion)
+				admin.DELETE("/estados/transiciones/:id", logEndpoint("🔄 ADMIN-TRANSITION-DELETE", "Eliminación de transición de estado"), estadoHandler.DeleteTransicion)
+				admin.GET("/accesos-clasificados", logEndpoint("🔒 ADMIN-CLASSIFIED-ACCESS", "Registro de accesos a expedientes clasificados"), expedienteHandler.GetClassifiedAccessLog)
+				admin.POST("/documentos/integridad/verificar", logEndpoint("🔏 ADMIN-FIXITY", "Verificación de integridad de documentos adjuntos"), integridadHandler.VerificarIntegridad)
+				admin.POST("/documentos/texto/indexar", logEndpoint("🔎 ADMIN-TEXT-INDEX", "Extracción de texto de documentos adjuntos"), textoHandler.IndexarTexto)
+				admin.GET("/ingesta", logEndpoint("📥 ADMIN-INGEST-LOG", "Registro de la ingesta de digitalizaciones"), ingestaHandler.GetIngestas)
 			}
 		}
 	}
@@ -294,9 +504,24 @@ func main() {
 	log.Printf("   - Permissions: /api/v1/permissions")
 	log.Printf("   - Expedientes: /api/v1/expedientes/*")
 	log.Printf("   - Dashboard: /api/v1/dashboard/*")
+	log.Printf("   - Archivo: /api/v1/archivo/*")
+	log.Printf("   - Dependencias: /api/v1/dependencias/*")
 	log.Printf("   - Admin: /api/v1/admin/*")
 	log.Println("================================================")
 
+	// Background text extraction, periodic fixity check and hot-folder ingest of the attached files
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go textoService.Iniciar(jobCtx)
+	if cfg.FixityInterval > 0 {
+		log.Printf("🔏 Verificación de integridad de documentos cada %s", cfg.FixityInterval)
+		go integridadService.Iniciar(jobCtx, cfg.FixityInterval)
+	}
+	if ingestaService.Activa() {
+		log.Printf("📥 Ingesta de digitalizaciones desde %s cada %s", cfg.IngestDir, cfg.IngestInterval)
+		go ingestaService.Iniciar(jobCtx)
+	}
+
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start server: %v", err)
@@ -309,6 +534,7 @@ func main() {
 	<-quit
 	log.Println("🛑 Shutting down server...")
 	log.Println("📊 Cerrando conexiones activas...")
+	stopJobs()
 
 	// Create a deadline to wait for
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -324,7 +550,29 @@ func main() {
 }
 
 // initializeDatabase creates indexes and initializes system profiles and users
-func initializeDatabase(ctx context.Context, db *database.Database, profileRepo *repository.ProfileRepository, profileService *services.ProfileService, userService *services.UserService) error {
+// newDocumentoStorage creates the attachment storage driver selected by STORAGE_DRIVER
+func newDocumentoStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case "local":
+		return storage.NewLocalStorage(cfg.UploadPath)
+	case "s3":
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:       cfg.S3Endpoint,
+			PublicEndpoint: cfg.S3PublicEndpoint,
+			Region:         cfg.S3Region,
+			Bucket:         cfg.S3Bucket,
+			AccessKey:      cfg.S3AccessKey,
+			SecretKey:      cfg.S3SecretKey,
+			PathStyle:      cfg.S3PathStyle,
+			SSE:            cfg.S3SSE,
+			KMSKeyID:       cfg.S3KMSKeyID,
+		})
+	default:
+		return nil, fmt.Errorf("STORAGE_DRIVER desconocido: %q (use local o s3)", cfg.StorageDriver)
+	}
+}
+
+func initializeDatabase(ctx context.Context, db *database.Database, profileRepo *repository.ProfileRepository, expedienteRepo *repository.ExpedienteRepository, archivoRepo *repository.ArchivoRepository, estadoRepo *repository.EstadoRepository, profileService *services.ProfileService, userService *services.UserService) error {
 	log.Println("🔧 Initializing database...")
 
 	// Create all database indexes (users, expedientes, profiles)
@@ -342,6 +590,25 @@ func initializeDatabase(ctx context.Context, db *database.Database, profileRepo
 		return err
 	}
 
+	// Initialize the archive layout on first run
+	if err := archivoRepo.InitializeDefaultLayout(ctx); err != nil {
+		return err
+	}
+
+	// Initialize the expediente lifecycle on first run
+	if err := estadoRepo.InitializeDefaultTransiciones(ctx); err != nil {
+		return err
+	}
+
+	// Number the expedientes created before registration numbers existed
+	numerados, err := expedienteRepo.InitializeNumeroRegistro(ctx)
+	if err != nil {
+		return err
+	}
+	if numerados > 0 {
+		log.Printf("🔢 Números de registro asignados a %d expedientes existentes", numerados)
+	}
+
 	// Initialize system user (admin)
 	if err := userService.InitializeSystemUser(ctx); err != nil {
 		return err
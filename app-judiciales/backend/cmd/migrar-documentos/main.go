@@ -0,0 +1,158 @@
+// Command migrar-documentos copies the attachments stored by the local driver under
+// UPLOAD_PATH, every version included, to the S3-compatible bucket configured in S3_*,
+// keeping their keys. Each copy
+// is read back from the bucket and its SHA-256 compared with the local file; files already
+// in the bucket with the same checksum are skipped, so the command can be re-run. Local
+// files are never deleted. It only writes when run with -aplicar.
+package main
+
+import (
+	"errors"
+	"expedientes-backend/internal/config"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/storage"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	aplicar := flag.Bool("aplicar", false, "copiar los archivos al bucket (por defecto solo se reporta lo pendiente)")
+	flag.Parse()
+
+	cfg := config.Load()
+
+	db, err := database.Connect(cfg.MongoDBURI, cfg.MongoDBDatabase)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Disconnect()
+
+	origen, err := storage.NewLocalStorage(cfg.UploadPath)
+	if err != nil {
+		log.Fatal("Invalid local storage:", err)
+	}
+	destino, err := storage.NewS3Storage(storage.S3Config{
+		Endpoint:       cfg.S3Endpoint,
+		PublicEndpoint: cfg.S3PublicEndpoint,
+		Region:         cfg.S3Region,
+		Bucket:         cfg.S3Bucket,
+		AccessKey:      cfg.S3AccessKey,
+		SecretKey:      cfg.S3SecretKey,
+		PathStyle:      cfg.S3PathStyle,
+		SSE:            cfg.S3SSE,
+		KMSKeyID:       cfg.S3KMSKeyID,
+	})
+	if err != nil {
+		log.Fatal("Invalid S3 storage:", err)
+	}
+
+	documentos, err := repository.NewDocumentoRepository(db).GetAll()
+	if err != nil {
+		log.Fatal("Failed to read documentos:", err)
+	}
+
+	var archivos, migrados, yaMigrados, pendientes, fallidos int
+	for i := range documentos {
+		for _, archivo := range archivosDocumento(&documentos[i]) {
+			archivos++
+			estado, err := migrar(origen, destino, archivo, *aplicar)
+			if err != nil {
+				fallidos++
+				fmt.Printf("❌ %-48s %v\n", archivo.Clave, err)
+				continue
+			}
+			switch estado {
+			case "migrado":
+				migrados++
+			case "ya_migrado":
+				yaMigrados++
+			case "pendiente":
+				pendientes++
+			}
+			fmt.Printf("%-12s %-48s %s\n", estado, archivo.Clave, archivo.Nombre)
+		}
+	}
+
+	fmt.Printf("\nDocumentos revisados: %d\n", len(documentos))
+	fmt.Printf("Archivos revisados:   %d\n", archivos)
+	fmt.Printf("Ya en el bucket:      %d\n", yaMigrados)
+	if *aplicar {
+		fmt.Printf("Migrados:             %d\n", migrados)
+	} else {
+		fmt.Printf("Pendientes:           %d\n", pendientes)
+	}
+	fmt.Printf("Con errores:          %d\n", fallidos)
+
+	if !*aplicar && pendientes > 0 {
+		fmt.Println("ℹ️  Simulación: ejecute con -aplicar para copiar los archivos")
+	}
+	if *aplicar && fallidos == 0 {
+		fmt.Println("✅ Todos los archivos están en el bucket con su checksum verificado; configure STORAGE_DRIVER=s3")
+	}
+	if fallidos > 0 {
+		os.Exit(1)
+	}
+}
+
+// archivosDocumento lists the stored files of an attachment once each; a restored version
+// shares its file with the version it came from
+func archivosDocumento(documento *models.Documento) []models.VersionDocumento {
+	if len(documento.Versiones) == 0 {
+		return []models.VersionDocumento{{Nombre: documento.Nombre, ContentType: documento.ContentType, Clave: documento.Clave}}
+	}
+
+	vistos := make(map[string]bool, len(documento.Versiones))
+	archivos := make([]models.VersionDocumento, 0, len(documento.Versiones))
+	for _, version := range documento.Versiones {
+		if vistos[version.Clave] {
+			continue
+		}
+		vistos[version.Clave] = true
+		archivos = append(archivos, version)
+	}
+	return archivos
+}
+
+// migrar copies one stored file to the bucket unless it is already there, and verifies the copy
+func migrar(origen *storage.LocalStorage, destino *storage.S3Storage, documento models.VersionDocumento, aplicar bool) (string, error) {
+	checksumLocal, tamano, err := storage.Checksum(origen, documento.Clave)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return "", errors.New("no existe el archivo local")
+		}
+		return "", err
+	}
+
+	checksumRemoto, _, err := storage.Checksum(destino, documento.Clave)
+	switch {
+	case err == nil && checksumRemoto == checksumLocal:
+		return "ya_migrado", nil
+	case err != nil && !errors.Is(err, storage.ErrNotFound):
+		return "", err
+	case !aplicar:
+		return "pendiente", nil
+	}
+
+	archivo, err := origen.Get(documento.Clave)
+	if err != nil {
+		return "", err
+	}
+	err = destino.Put(documento.Clave, archivo, tamano, documento.ContentType)
+	archivo.Close()
+	if err != nil {
+		return "", err
+	}
+
+	checksumRemoto, tamanoRemoto, err := storage.Checksum(destino, documento.Clave)
+	if err != nil {
+		return "", fmt.Errorf("no se pudo verificar la copia: %w", err)
+	}
+	if checksumRemoto != checksumLocal || tamanoRemoto != tamano {
+		return "", fmt.Errorf("checksum distinto tras copiar (local %s, bucket %s)", checksumLocal, checksumRemoto)
+	}
+	return "migrado", nil
+}
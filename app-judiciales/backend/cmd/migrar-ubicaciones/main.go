@@ -0,0 +1,62 @@
+// Command migrar-ubicaciones recalculates the ubicación of every stored expediente with
+// the rules configured in UBICACION_PARTICULAS and UBICACION_ENE_SEPARADA and reports
+// the records that change. It only writes when run with -aplicar.
+package main
+
+import (
+	"expedientes-backend/internal/config"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"flag"
+	"fmt"
+	"log"
+)
+
+func main() {
+	aplicar := flag.Bool("aplicar", false, "guardar las ubicaciones recalculadas (por defecto solo se reportan)")
+	flag.Parse()
+
+	cfg := config.Load()
+
+	db, err := database.Connect(cfg.MongoDBURI, cfg.MongoDBDatabase)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Disconnect()
+
+	ubicacionEngine := services.NewUbicacionEngine(services.UbicacionRules{
+		Particulas:  cfg.UbicacionParticulas,
+		EneSeparada: cfg.UbicacionEneSeparada,
+	})
+	cipValidator, err := services.NewCIPValidator(services.CIPRules{
+		Categorias: map[models.CategoriaGrado]services.CIPRegla{
+			models.CategoriaOficiales: {Longitud: cfg.CIPLongitudOficiales, Patron: cfg.CIPPatronOficiales},
+			models.CategoriaTcoSsoo:   {Longitud: cfg.CIPLongitudTcoSsoo, Patron: cfg.CIPPatronTcoSsoo},
+			models.CategoriaTropa:     {Longitud: cfg.CIPLongitudTropa, Patron: cfg.CIPPatronTropa},
+		},
+		DigitoVerificador: cfg.CIPDigitoVerificador,
+	})
+	if err != nil {
+		log.Fatal("Invalid CIP rules:", err)
+	}
+	expedienteService := services.NewExpedienteService(repository.NewExpedienteRepository(db), repository.NewAuditRepository(db), ubicacionEngine, cipValidator)
+
+	resultado, err := expedienteService.RecalcularUbicaciones(*aplicar, "migrar-ubicaciones")
+	if err != nil {
+		log.Fatal("Failed to recalculate ubicaciones:", err)
+	}
+
+	for _, cambio := range resultado.Cambios {
+		fmt.Printf("%-12s %-4s -> %-4s %s\n", cambio.CIP, cambio.Anterior, cambio.Nueva, cambio.ApellidosNombres)
+	}
+
+	fmt.Printf("\nExpedientes revisados: %d\n", resultado.Revisados)
+	fmt.Printf("Ubicaciones a cambiar: %d\n", len(resultado.Cambios))
+	if resultado.Aplicado {
+		fmt.Println("✅ Cambios aplicados")
+	} else if len(resultado.Cambios) > 0 {
+		fmt.Println("ℹ️  Simulación: ejecute con -aplicar para guardar los cambios")
+	}
+}
@@ -0,0 +1,72 @@
+// Command revisar-cips checks every stored CIP against the rules configured in the CIP_*
+// variables. It lists the CIPs that only need normalising and those that break the rules
+// and must be corrected by hand. It only writes when run with -aplicar.
+package main
+
+import (
+	"expedientes-backend/internal/config"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"flag"
+	"fmt"
+	"log"
+)
+
+func main() {
+	aplicar := flag.Bool("aplicar", false, "guardar los CIP normalizados (por defecto solo se reportan)")
+	flag.Parse()
+
+	cfg := config.Load()
+
+	db, err := database.Connect(cfg.MongoDBURI, cfg.MongoDBDatabase)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Disconnect()
+
+	ubicacionEngine := services.NewUbicacionEngine(services.UbicacionRules{
+		Particulas:  cfg.UbicacionParticulas,
+		EneSeparada: cfg.UbicacionEneSeparada,
+	})
+	cipValidator, err := services.NewCIPValidator(services.CIPRules{
+		Categorias: map[models.CategoriaGrado]services.CIPRegla{
+			models.CategoriaOficiales: {Longitud: cfg.CIPLongitudOficiales, Patron: cfg.CIPPatronOficiales},
+			models.CategoriaTcoSsoo:   {Longitud: cfg.CIPLongitudTcoSsoo, Patron: cfg.CIPPatronTcoSsoo},
+			models.CategoriaTropa:     {Longitud: cfg.CIPLongitudTropa, Patron: cfg.CIPPatronTropa},
+		},
+		DigitoVerificador: cfg.CIPDigitoVerificador,
+	})
+	if err != nil {
+		log.Fatal("Invalid CIP rules:", err)
+	}
+	expedienteService := services.NewExpedienteService(repository.NewExpedienteRepository(db), repository.NewAuditRepository(db), ubicacionEngine, cipValidator)
+
+	resultado, err := expedienteService.RevisarCIPs(*aplicar, "revisar-cips")
+	if err != nil {
+		log.Fatal("Failed to review CIPs:", err)
+	}
+
+	if len(resultado.Normalizables) > 0 {
+		fmt.Println("CIP a normalizar:")
+		for _, revision := range resultado.Normalizables {
+			fmt.Printf("  %-14s -> %-14s %-8s %s\n", revision.CIP, revision.Canonico, revision.Grado, revision.ApellidosNombres)
+		}
+	}
+	if len(resultado.Invalidos) > 0 {
+		fmt.Println("CIP que no cumplen las reglas:")
+		for _, revision := range resultado.Invalidos {
+			fmt.Printf("  %-14s %-8s %-40s %s\n", revision.CIP, revision.Grado, revision.ApellidosNombres, revision.Error)
+		}
+	}
+
+	fmt.Printf("\nExpedientes revisados: %d\n", resultado.Revisados)
+	fmt.Printf("CIP a normalizar: %d\n", len(resultado.Normalizables))
+	fmt.Printf("CIP a corregir manualmente: %d\n", len(resultado.Invalidos))
+	if resultado.Aplicado {
+		fmt.Println("✅ CIP normalizados")
+	} else if len(resultado.Normalizables) > 0 {
+		fmt.Println("ℹ️  Simulación: ejecute con -aplicar para guardar los CIP normalizados")
+	}
+}
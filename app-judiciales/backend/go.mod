@@ -10,6 +10,7 @@ require (
 	github.com/xuri/excelize/v2 v2.10.0
 	go.mongodb.org/mongo-driver v1.12.1
 	golang.org/x/crypto v0.43.0
+	golang.org/x/text v0.30.0
 	golang.org/x/time v0.3.0
 	gopkg.in/yaml.v2 v2.4.0
 )
@@ -48,7 +49,6 @@ require (
 	golang.org/x/net v0.46.0 // indirect
 	golang.org/x/sync v0.17.0 // indirect
 	golang.org/x/sys v0.37.0 // indirect
-	golang.org/x/text v0.30.0 // indirect
 	google.golang.org/protobuf v1.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
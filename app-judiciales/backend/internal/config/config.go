@@ -32,14 +32,55 @@ type Config struct {
 	EmailPort     int
 	EmailUsername string
 	EmailPassword string
+	EmailFrom     string // Sender address; defaults to EmailUsername
 
 	// Upload Configuration
 	MaxUploadSize int64
 	UploadPath    string
 
+	// Attachment storage: "local" keeps files in UploadPath, "s3" in an S3-compatible bucket
+	StorageDriver     string
+	S3Endpoint        string
+	S3PublicEndpoint  string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKey       string
+	S3SecretKey       string
+	S3PathStyle       bool
+	S3SSE             string
+	S3KMSKeyID        string
+	S3PresignDuration time.Duration // Zero streams downloads through the API
+
+	// Interval of the attachment fixity check; zero disables it
+	FixityInterval time.Duration
+
+	// Hot-folder ingest of the scanning stations; an empty IngestDir disables it
+	IngestDir           string
+	IngestQuarantineDir string // Defaults to a "cuarentena" folder inside IngestDir
+	IngestPattern       string // Filename regexp with a cip group and an optional tomo group
+	IngestInterval      time.Duration
+
 	// Rate Limiting
 	RateLimitRequests int
 	RateLimitWindow   int
+
+	// Break-glass emergency access
+	BreakGlassDuration    time.Duration
+	BreakGlassSupervisors []string
+	BreakGlassWebhookURL  string
+
+	// Ubicación rules
+	UbicacionParticulas  []string
+	UbicacionEneSeparada bool
+
+	// CIP rules per personnel category
+	CIPLongitudOficiales int
+	CIPLongitudTcoSsoo   int
+	CIPLongitudTropa     int
+	CIPPatronOficiales   string
+	CIPPatronTcoSsoo     string
+	CIPPatronTropa       string
+	CIPDigitoVerificador bool
 }
 
 func Load() *Config {
@@ -65,12 +106,52 @@ func Load() *Config {
 		EmailPort:     parseInt(getEnvOrDefault("EMAIL_PORT", "587")),
 		EmailUsername: getEnvOrDefault("EMAIL_USERNAME", ""),
 		EmailPassword: getEnvOrDefault("EMAIL_PASSWORD", ""),
+		EmailFrom:     getEnvOrDefault("EMAIL_FROM", os.Getenv("EMAIL_USERNAME")),
 
 		MaxUploadSize: parseInt64(getEnvOrDefault("MAX_UPLOAD_SIZE", "10485760")), // 10MB
 		UploadPath:    getEnvOrDefault("UPLOAD_PATH", "./uploads"),
 
+		StorageDriver:     getEnvOrDefault("STORAGE_DRIVER", "local"),
+		S3Endpoint:        getEnvOrDefault("S3_ENDPOINT", ""),
+		S3PublicEndpoint:  getEnvOrDefault("S3_PUBLIC_ENDPOINT", ""),
+		S3Region:          getEnvOrDefault("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnvOrDefault("S3_BUCKET", ""),
+		S3AccessKey:       getEnvOrDefault("S3_ACCESS_KEY", ""),
+		S3SecretKey:       getEnvOrDefault("S3_SECRET_KEY", ""),
+		S3PathStyle:       parseBool(getEnvOrDefault("S3_PATH_STYLE", "false")),
+		S3SSE:             getEnvOrDefault("S3_SSE", ""),
+		S3KMSKeyID:        getEnvOrDefault("S3_SSE_KMS_KEY_ID", ""),
+		S3PresignDuration: parseS3PresignDuration(getEnvOrDefault("S3_PRESIGN_DURATION", "5m")),
+
+		FixityInterval: parseDuration(getEnvOrDefault("FIXITY_INTERVAL", "24h")),
+
+		IngestDir:           getEnvOrDefault("INGEST_DIR", ""),
+		IngestQuarantineDir: getEnvOrDefault("INGEST_QUARANTINE_DIR", ""),
+		IngestPattern:       getEnvOrDefault("INGEST_PATTERN", `(?i)^(?P<cip>[0-9](?:[0-9 .-]*[0-9])?)(?:_tomo(?P<tomo>[0-9]+))?\.(pdf|jpe?g|png)$`),
+		IngestInterval:      parseDuration(getEnvOrDefault("INGEST_INTERVAL", "30s")),
+
 		RateLimitRequests: parseInt(getEnvOrDefault("RATE_LIMIT_REQUESTS", "1000")),
 		RateLimitWindow:   parseInt(getEnvOrDefault("RATE_LIMIT_WINDOW", "3600")),
+
+		BreakGlassDuration:    parseBreakGlassDuration(getEnvOrDefault("BREAK_GLASS_DURATION", "30m")),
+		BreakGlassSupervisors: parseStringSlice(getEnvOrDefault("BREAK_GLASS_SUPERVISORS", "")),
+		BreakGlassWebhookURL:  getEnvOrDefault("BREAK_GLASS_WEBHOOK_URL", ""),
+
+		UbicacionParticulas:  parseStringSlice(getEnvOrDefault("UBICACION_PARTICULAS", "DE,DEL,LA,LAS,LOS")),
+		UbicacionEneSeparada: parseBool(getEnvOrDefault("UBICACION_ENE_SEPARADA", "true")),
+
+		CIPLongitudOficiales: parseInt(getEnvOrDefault("CIP_LONGITUD_OFICIALES", "8")),
+		CIPLongitudTcoSsoo:   parseInt(getEnvOrDefault("CIP_LONGITUD_TCO_SSOO", "8")),
+		CIPLongitudTropa:     parseInt(getEnvOrDefault("CIP_LONGITUD_TROPA", "8")),
+		CIPPatronOficiales:   getEnvOrDefault("CIP_PATRON_OFICIALES", "^[0-9]+$"),
+		CIPPatronTcoSsoo:     getEnvOrDefault("CIP_PATRON_TCO_SSOO", "^[0-9]+$"),
+		CIPPatronTropa:       getEnvOrDefault("CIP_PATRON_TROPA", "^[0-9]+$"),
+		CIPDigitoVerificador: parseBool(getEnvOrDefault("CIP_DIGITO_VERIFICADOR", "false")),
+	}
+
+	// Without a sender, servers reject the mail or it arrives with an empty From header
+	if config.EmailHost != "" && config.EmailFrom == "" {
+		log.Fatalf("EMAIL_HOST is set but neither EMAIL_FROM nor EMAIL_USERNAME gives a sender address")
 	}
 
 	return config
@@ -109,6 +190,15 @@ func parseInt64(s string) int64 {
 	return value
 }
 
+func parseBool(s string) bool {
+	value, err := strconv.ParseBool(s)
+	if err != nil {
+		log.Printf("Error parsing bool %s: %v", s, err)
+		return false
+	}
+	return value
+}
+
 func parseDuration(s string) time.Duration {
 	duration, err := time.ParseDuration(s)
 	if err != nil {
@@ -118,6 +208,30 @@ func parseDuration(s string) time.Duration {
 	return duration
 }
 
+// parseBreakGlassDuration refuses to start with an invalid emergency access window, since
+// the 24h fallback of parseDuration would silently turn a short window into a full day
+func parseBreakGlassDuration(s string) time.Duration {
+	duration, err := time.ParseDuration(s)
+	if err != nil || duration <= 0 {
+		log.Fatalf("Invalid BREAK_GLASS_DURATION %q: must be a positive duration such as 30m", s)
+	}
+	return duration
+}
+
+// maxS3PresignDuration caps how long a signed download link stays valid once it leaves the backend
+const maxS3PresignDuration = time.Hour
+
+// parseS3PresignDuration refuses to start with an invalid or long-lived signed URL expiry, since
+// a link cannot be revoked and keeps working after the user loses access to the expediente.
+// Zero is accepted and turns signed links off.
+func parseS3PresignDuration(s string) time.Duration {
+	duration, err := time.ParseDuration(s)
+	if err != nil || duration < 0 || (duration > 0 && duration < time.Second) || duration > maxS3PresignDuration {
+		log.Fatalf("Invalid S3_PRESIGN_DURATION %q: must be 0 or a duration between 1s and %s, such as 5m", s, maxS3PresignDuration)
+	}
+	return duration
+}
+
 func parseStringSlice(s string) []string {
 	if s == "" {
 		return []string{}
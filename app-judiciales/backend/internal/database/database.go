@@ -135,7 +135,25 @@ func (db *Database) CreateIndexes() error {
 			Keys: bson.D{{Key: "created_at", Value: -1}},
 		},
 		{
-			Keys: bson.D{{Key: "orden", Value: 1}},
+			Keys: bson.D{{Key: "orden", Value: 1}, {Key: "apellidos_nombres", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "numero_registro", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "clasificacion", Value: 1}},
+		},
+		// Digitisation work list by state and by operator
+		{
+			Keys: bson.D{{Key: "digitalizacion.estado", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "digitalizacion.operador_id", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "dependencia_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
 		},
 	}
 
@@ -147,5 +165,244 @@ func (db *Database) CreateIndexes() error {
 		log.Printf("✅ Expedientes indexes created successfully")
 	}
 
+	// Audit collections indexes
+	auditIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "recurso_id", Value: 1}, {Key: "timestamp", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "usuario_id", Value: 1}},
+		},
+	}
+
+	for _, name := range []string{"audit_logs", "classified_access_logs"} {
+		if _, err := db.Collection(name).Indexes().CreateMany(ctx, auditIndexes); err != nil {
+			log.Printf("⚠️ Warning: Failed to create %s indexes: %v", name, err)
+		}
+	}
+
+	// Break-glass accesses indexes
+	breakGlassIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "usuario_id", Value: 1}, {Key: "expira_en", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "revisado", Value: 1}, {Key: "otorgado_en", Value: -1}},
+		},
+	}
+
+	if _, err := db.Collection("break_glass_accesses").Indexes().CreateMany(ctx, breakGlassIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create break_glass_accesses indexes: %v", err)
+	}
+
+	// Archive catalog indexes
+	estantesIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "numero", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("estantes").Indexes().CreateMany(ctx, estantesIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create estantes indexes: %v", err)
+	}
+
+	divisionesIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "estante_id", Value: 1}, {Key: "numero", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("divisiones").Indexes().CreateMany(ctx, divisionesIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create divisiones indexes: %v", err)
+	}
+
+	// Expediente state machine indexes
+	transicionesIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "desde", Value: 1}, {Key: "hasta", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("estado_transiciones").Indexes().CreateMany(ctx, transicionesIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create estado_transiciones indexes: %v", err)
+	}
+
+	historialIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expediente_id", Value: 1}, {Key: "fecha", Value: -1}},
+		},
+	}
+
+	if _, err := db.Collection("estado_historial").Indexes().CreateMany(ctx, historialIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create estado_historial indexes: %v", err)
+	}
+
+	// Movements register: correlative numbering per expediente and cross-expediente queries
+	movimientosIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expediente_id", Value: 1}, {Key: "numero", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "fecha", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "tipo", Value: 1}, {Key: "fecha", Value: -1}},
+		},
+	}
+
+	if _, err := db.Collection("movimientos").Indexes().CreateMany(ctx, movimientosIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create movimientos indexes: %v", err)
+	}
+
+	// Notificaciones indexes
+	notificacionesIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expediente_id", Value: 1}, {Key: "movimiento_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "estado", Value: 1}, {Key: "created_at", Value: 1}},
+		},
+	}
+
+	if _, err := db.Collection("notificaciones").Indexes().CreateMany(ctx, notificacionesIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create notificaciones indexes: %v", err)
+	}
+
+	// Tomos indexes
+	tomosIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expediente_id", Value: 1}, {Key: "numero", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := db.Collection("tomos").Indexes().CreateMany(ctx, tomosIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create tomos indexes: %v", err)
+	}
+
+	// Career events indexes
+	carreraIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expediente_id", Value: 1}, {Key: "fecha_efectiva", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "fecha_efectiva", Value: 1}, {Key: "grado_nuevo", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "lote_id", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	}
+
+	if _, err := db.Collection("eventos_carrera").Indexes().CreateMany(ctx, carreraIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create eventos_carrera indexes: %v", err)
+	}
+
+	// Loans indexes
+	prestamosIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expediente_id", Value: 1}, {Key: "salida", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "devolucion", Value: 1}, {Key: "salida", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "dependencia_id", Value: 1}, {Key: "devolucion", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+	}
+
+	if _, err := db.Collection("prestamos").Indexes().CreateMany(ctx, prestamosIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create prestamos indexes: %v", err)
+	}
+
+	// Dependencias catalog indexes
+	dependenciasIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "codigo", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "activa", Value: 1}, {Key: "codigo", Value: 1}},
+		},
+	}
+
+	if _, err := db.Collection("dependencias").Indexes().CreateMany(ctx, dependenciasIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create dependencias indexes: %v", err)
+	}
+
+	// Inventory session indexes
+	inventariosIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "estado", Value: 1}, {Key: "creado_en", Value: -1}},
+		},
+	}
+
+	if _, err := db.Collection("inventarios").Indexes().CreateMany(ctx, inventariosIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create inventarios indexes: %v", err)
+	}
+
+	// Attached document indexes
+	documentosIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "expediente_id", Value: 1}, {Key: "subido_en", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "expediente_id", Value: 1}, {Key: "origen", Value: 1}, {Key: "tomo_id", Value: 1}},
+		},
+	}
+
+	if _, err := db.Collection("documentos").Indexes().CreateMany(ctx, documentosIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create documentos indexes: %v", err)
+	}
+
+	// Attachment fixity failure indexes
+	fallosIntegridadIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "documento_id", Value: 1}, {Key: "detectado_en", Value: -1}},
+		},
+	}
+
+	if _, err := db.Collection("fallos_integridad").Indexes().CreateMany(ctx, fallosIntegridadIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create fallos_integridad indexes: %v", err)
+	}
+
+	// Extracted attachment text indexes
+	paginasDocumentoIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "documento_id", Value: 1}, {Key: "version", Value: 1}, {Key: "pagina", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "texto", Value: "text"}},
+			Options: options.Index().SetDefaultLanguage("spanish"),
+		},
+	}
+
+	if _, err := db.Collection("paginas_documento").Indexes().CreateMany(ctx, paginasDocumentoIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create paginas_documento indexes: %v", err)
+	}
+
+	// Hot-folder ingest log indexes
+	ingestasIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "procesado_en", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "estado", Value: 1}, {Key: "procesado_en", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "cip", Value: 1}, {Key: "procesado_en", Value: -1}},
+		},
+	}
+
+	if _, err := db.Collection("ingestas").Indexes().CreateMany(ctx, ingestasIndexes); err != nil {
+		log.Printf("⚠️ Warning: Failed to create ingestas indexes: %v", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,109 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// DOCX returns the text of a Word document split into pages. A DOCX file has no fixed
+// pages; they are cut at explicit page breaks and at the page breaks Word recorded the last
+// time it laid the document out, which match the printed pages.
+func DOCX(data []byte) ([]string, error) {
+	archivo, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalid
+	}
+
+	var documento *zip.File
+	for _, f := range archivo.File {
+		if f.Name == "word/document.xml" {
+			documento = f
+			break
+		}
+	}
+	if documento == nil {
+		return nil, ErrInvalid
+	}
+
+	contenido, err := documento.Open()
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	defer contenido.Close()
+
+	var paginas []string
+	var pagina strings.Builder
+	cortar := func() {
+		// Un salto manual seguido del salto que Word registró al paginar es un solo corte
+		if strings.TrimSpace(pagina.String()) == "" && len(paginas) > 0 {
+			return
+		}
+		paginas = append(paginas, limpiar(pagina.String()))
+		pagina.Reset()
+	}
+
+	decoder := xml.NewDecoder(contenido)
+	enTexto := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalid
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				enTexto = true
+			case "tab":
+				pagina.WriteString("\t")
+			case "cr":
+				pagina.WriteString("\n")
+			case "br":
+				if atributo(t, "type") == "page" {
+					cortar()
+				} else {
+					pagina.WriteString("\n")
+				}
+			case "lastRenderedPageBreak":
+				cortar()
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				enTexto = false
+			case "p":
+				pagina.WriteString("\n")
+			case "tc":
+				pagina.WriteString("\t")
+			}
+		case xml.CharData:
+			if enTexto {
+				pagina.Write(t)
+			}
+		}
+	}
+	cortar()
+
+	// Un salto al final del documento deja una página vacía que no se imprime
+	for len(paginas) > 1 && paginas[len(paginas)-1] == "" {
+		paginas = paginas[:len(paginas)-1]
+	}
+	return paginas, nil
+}
+
+// atributo returns the value of an attribute of an element regardless of its namespace
+func atributo(elemento xml.StartElement, nombre string) string {
+	for _, a := range elemento.Attr {
+		if a.Name.Local == nombre {
+			return a.Value
+		}
+	}
+	return ""
+}
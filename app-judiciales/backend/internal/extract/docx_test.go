@@ -0,0 +1,130 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// docxPrueba builds a Word document whose body holds the given paragraphs and tables
+func docxPrueba(t *testing.T, cuerpo string) []byte {
+	t.Helper()
+	return zipPrueba(t, map[string]string{
+		"[Content_Types].xml": `<?xml version="1.0" encoding="UTF-8"?><Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"/>`,
+		"word/document.xml": `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+			`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+			cuerpo + `<w:sectPr/></w:body></w:document>`,
+	})
+}
+
+func zipPrueba(t *testing.T, archivos map[string]string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for nombre, contenido := range archivos {
+		w, err := zw.Create(nombre)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(contenido)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// parrafo returns a paragraph with one run per text
+func parrafo(textos ...string) string {
+	var b strings.Builder
+	b.WriteString("<w:p>")
+	for _, texto := range textos {
+		b.WriteString(`<w:r><w:t xml:space="preserve">` + texto + "</w:t></w:r>")
+	}
+	b.WriteString("</w:p>")
+	return b.String()
+}
+
+func TestDOCX(t *testing.T) {
+	tests := []struct {
+		nombre string
+		cuerpo string
+		want   []string
+	}{
+		{
+			nombre: "párrafos y runs",
+			cuerpo: parrafo("RESOLUCIÓN ", "N.º 123-2024") + parrafo("Se ", "resuelve:") + "<w:p/>",
+			want:   []string{"RESOLUCIÓN N.º 123-2024\nSe resuelve:"},
+		},
+		{
+			nombre: "tabulaciones, saltos de línea y tablas",
+			cuerpo: `<w:p><w:r><w:t>Grado</w:t><w:tab/><w:t>Mayor</w:t><w:br/><w:t>CIP</w:t><w:cr/><w:t>00012345</w:t></w:r></w:p>` +
+				`<w:tbl><w:tr><w:tc>` + parrafo("Fecha") + `</w:tc><w:tc>` + parrafo("01/02/2024") + `</w:tc></w:tr></w:tbl>`,
+			want: []string{"Grado Mayor\nCIP\n00012345\nFecha\n01/02/2024"},
+		},
+		{
+			nombre: "texto fuera de w:t",
+			cuerpo: `<w:p><w:r><w:instrText>PAGE</w:instrText><w:t>visible</w:t></w:r></w:p>`,
+			want:   []string{"visible"},
+		},
+		{
+			nombre: "salto de página manual",
+			cuerpo: parrafo("Página uno") + `<w:p><w:r><w:br w:type="page"/></w:r></w:p>` + parrafo("Página dos"),
+			want:   []string{"Página uno", "Página dos"},
+		},
+		{
+			nombre: "salto manual seguido del registrado por Word",
+			cuerpo: parrafo("Uno") + `<w:p><w:r><w:br w:type="page"/></w:r></w:p>` +
+				`<w:p><w:r><w:lastRenderedPageBreak/><w:t>Dos</w:t></w:r></w:p>` +
+				`<w:p><w:r><w:lastRenderedPageBreak/><w:t>Tres</w:t></w:r></w:p>`,
+			want: []string{"Uno", "Dos", "Tres"},
+		},
+		{
+			nombre: "salto al final del documento",
+			cuerpo: parrafo("Única") + `<w:p><w:r><w:br w:type="page"/></w:r></w:p>`,
+			want:   []string{"Única"},
+		},
+		{
+			nombre: "palabra cortada con guion",
+			cuerpo: parrafo("expe-") + parrafo("diente"),
+			want:   []string{"expediente"},
+		},
+		{
+			nombre: "documento vacío",
+			cuerpo: "",
+			want:   []string{""},
+		},
+	}
+	for _, tt := range tests {
+		paginas, err := DOCX(docxPrueba(t, tt.cuerpo))
+		if err != nil {
+			t.Errorf("%s: DOCX: %v", tt.nombre, err)
+			continue
+		}
+		if !slices.Equal(paginas, tt.want) {
+			t.Errorf("%s: DOCX = %q, want %q", tt.nombre, paginas, tt.want)
+		}
+	}
+}
+
+func TestDOCXInvalido(t *testing.T) {
+	tests := []struct {
+		nombre string
+		data   []byte
+	}{
+		{"vacío", nil},
+		{"no es un zip", []byte("%PDF-1.4\n")},
+		{"sin word/document.xml", zipPrueba(t, map[string]string{"word/styles.xml": "<w:styles/>"})},
+		{"XML dañado", zipPrueba(t, map[string]string{"word/document.xml": "<w:document><w:body><w:p>"})},
+	}
+	for _, tt := range tests {
+		if _, err := DOCX(tt.data); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: DOCX error = %v, want %v", tt.nombre, err, ErrInvalid)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Package extract reads the text layer of PDF and DOCX files page by page, in pure Go and
+// without external tools. It does not do OCR: scanned pages without a text layer come back
+// empty.
+package extract
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Extraction errors
+var (
+	ErrEncrypted = errors.New("el archivo está cifrado")
+	ErrInvalid   = errors.New("el archivo está dañado o no tiene un formato válido")
+)
+
+// ligaduras spells out the typographic ligatures some fonts map their glyphs to
+var ligaduras = strings.NewReplacer(
+	"ﬀ", "ff",
+	"ﬁ", "fi",
+	"ﬂ", "fl",
+	"ﬃ", "ffi",
+	"ﬄ", "ffl",
+	"\u00ad", "", // Guion opcional
+)
+
+// limpiar normalises the text of a page: ligatures spelled out, control characters
+// dropped, runs of blanks collapsed and empty lines removed
+func limpiar(texto string) string {
+	texto = ligaduras.Replace(texto)
+
+	var lineas []string
+	for _, linea := range strings.Split(texto, "\n") {
+		campos := strings.FieldsFunc(linea, func(r rune) bool {
+			return unicode.IsSpace(r) || unicode.IsControl(r)
+		})
+		if len(campos) == 0 {
+			continue
+		}
+		linea = strings.Join(campos, " ")
+
+		// Una palabra cortada con guion al final de la línea se vuelve a unir para poder buscarla
+		if n := len(lineas); n > 0 && cortada(lineas[n-1], linea) {
+			lineas[n-1] = strings.TrimSuffix(lineas[n-1], "-") + linea
+			continue
+		}
+		lineas = append(lineas, linea)
+	}
+	return strings.Join(lineas, "\n")
+}
+
+// cortada reports whether a line ends in a word split by a hyphen that the next line finishes
+func cortada(linea, siguiente string) bool {
+	if !strings.HasSuffix(linea, "-") {
+		return false
+	}
+	anterior, _ := utf8.DecodeLastRuneInString(strings.TrimSuffix(linea, "-"))
+	primera, _ := utf8.DecodeRuneInString(siguiente)
+	return unicode.IsLower(anterior) && unicode.IsLower(primera)
+}
@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Base encodings of simple fonts. Fonts without /Encoding are read as WinAnsi, which is what
+// most producers use for Latin text.
+var (
+	winAnsi  = tablaCodificacion(charmap.Windows1252)
+	macRoman = tablaCodificacion(charmap.Macintosh)
+)
+
+func tablaCodificacion(cm *charmap.Charmap) [256]rune {
+	var tabla [256]rune
+	for i := range tabla {
+		if r := cm.DecodeByte(byte(i)); r != '\uFFFD' {
+			tabla[i] = r
+		}
+	}
+	return tabla
+}
+
+// nombresGlifos maps the Adobe glyph names used in /Differences and CMaps to text. Names of
+// a single character and uniXXXX names are read directly.
+var nombresGlifos = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$",
+	"percent": "%", "ampersand": "&", "quotesingle": "'", "quoteright": "’", "quoteleft": "‘",
+	"parenleft": "(", "parenright": ")", "asterisk": "*", "plus": "+", "comma": ",",
+	"hyphen": "-", "minus": "-", "period": ".", "slash": "/", "colon": ":", "semicolon": ";",
+	"less": "<", "equal": "=", "greater": ">", "question": "?", "at": "@",
+	"bracketleft": "[", "backslash": "\\", "bracketright": "]", "asciicircum": "^",
+	"underscore": "_", "grave": "`", "braceleft": "{", "bar": "|", "braceright": "}",
+	"asciitilde": "~",
+	"zero":       "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"Aacute": "Á", "aacute": "á", "Agrave": "À", "agrave": "à", "Acircumflex": "Â", "acircumflex": "â",
+	"Adieresis": "Ä", "adieresis": "ä", "Atilde": "Ã", "atilde": "ã", "Aring": "Å", "aring": "å",
+	"Eacute": "É", "eacute": "é", "Egrave": "È", "egrave": "è", "Ecircumflex": "Ê", "ecircumflex": "ê",
+	"Edieresis": "Ë", "edieresis": "ë",
+	"Iacute": "Í", "iacute": "í", "Igrave": "Ì", "igrave": "ì", "Icircumflex": "Î", "icircumflex": "î",
+	"Idieresis": "Ï", "idieresis": "ï", "dotlessi": "ı",
+	"Oacute": "Ó", "oacute": "ó", "Ograve": "Ò", "ograve": "ò", "Ocircumflex": "Ô", "ocircumflex": "ô",
+	"Odieresis": "Ö", "odieresis": "ö", "Otilde": "Õ", "otilde": "õ", "Oslash": "Ø", "oslash": "ø",
+	"Uacute": "Ú", "uacute": "ú", "Ugrave": "Ù", "ugrave": "ù", "Ucircumflex": "Û", "ucircumflex": "û",
+	"Udieresis": "Ü", "udieresis": "ü", "Yacute": "Ý", "yacute": "ý", "ydieresis": "ÿ",
+	"Ntilde": "Ñ", "ntilde": "ñ", "Ccedilla": "Ç", "ccedilla": "ç", "germandbls": "ß",
+	"AE": "Æ", "ae": "æ", "OE": "Œ", "oe": "œ",
+	"exclamdown": "¡", "questiondown": "¿", "ordfeminine": "ª", "ordmasculine": "º",
+	"degree": "°", "section": "§", "paragraph": "¶", "copyright": "©", "registered": "®",
+	"trademark": "™", "bullet": "•", "periodcentered": "·", "ellipsis": "…",
+	"endash": "–", "emdash": "—", "quotedblleft": "“", "quotedblright": "”",
+	"quotesinglbase": "‚", "quotedblbase": "„", "guillemotleft": "«", "guillemotright": "»",
+	"guilsinglleft": "‹", "guilsinglright": "›", "dagger": "†", "daggerdbl": "‡",
+	"sterling": "£", "yen": "¥", "Euro": "€", "cent": "¢", "currency": "¤",
+	"multiply": "×", "divide": "÷", "plusminus": "±", "fraction": "⁄", "onehalf": "½",
+	"onequarter": "¼", "threequarters": "¾", "acute": "´", "dieresis": "¨", "cedilla": "¸",
+	"circumflex": "ˆ", "tilde": "˜", "macron": "¯", "brokenbar": "¦", "logicalnot": "¬",
+	"mu": "µ", "nbspace": " ", "sfthyphen": "",
+	"fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl",
+}
+
+// glyphText returns the text of a glyph name, or "" when it is unknown
+func glyphText(nombre string) string {
+	// Variantes como "a.sc" o "one.oldstyle" se leen como el glifo base
+	if i := strings.IndexByte(nombre, '.'); i > 0 {
+		nombre = nombre[:i]
+	}
+	if texto, ok := nombresGlifos[nombre]; ok {
+		return texto
+	}
+	if len(nombre) == 1 {
+		return nombre
+	}
+
+	var hexa string
+	switch {
+	case strings.HasPrefix(nombre, "uni") && len(nombre) >= 7:
+		hexa = nombre[3:7]
+	case strings.HasPrefix(nombre, "u") && len(nombre) >= 5 && len(nombre) <= 7:
+		hexa = nombre[1:]
+	default:
+		return ""
+	}
+	if v, err := strconv.ParseUint(hexa, 16, 32); err == nil && v <= 0x10FFFF {
+		return string(rune(v))
+	}
+	return ""
+}
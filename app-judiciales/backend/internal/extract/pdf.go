@@ -0,0 +1,552 @@
+package extract
+
+import (
+	"bytes"
+	"compress/lzw"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// PDF object types
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfArray   []interface{}
+	pdfDict    map[pdfName]interface{}
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		data []byte // Still encoded
+	}
+)
+
+// maxProfundidad bounds the nesting of objects, page trees and form XObjects so a damaged or
+// malicious file cannot recurse forever
+const maxProfundidad = 32
+
+// PDF returns the text layer of every page of a PDF file, in page order. The objects are
+// located by scanning the file instead of trusting its cross-reference table, so files with
+// a broken table still open.
+func PDF(data []byte) (paginas []string, err error) {
+	// Un archivo dañado no debe tumbar el proceso que indexa
+	defer func() {
+		if r := recover(); r != nil {
+			paginas, err = nil, fmt.Errorf("%w: %v", ErrInvalid, r)
+		}
+	}()
+
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return nil, ErrInvalid
+	}
+
+	r := newPDFReader(data)
+	if r.encrypted() {
+		return nil, ErrEncrypted
+	}
+
+	catalogo := r.catalog()
+	if catalogo == nil {
+		return nil, ErrInvalid
+	}
+
+	for _, pagina := range r.pages(catalogo) {
+		paginas = append(paginas, limpiar(r.pageText(pagina)))
+	}
+	if len(paginas) == 0 {
+		return nil, ErrInvalid
+	}
+	return paginas, nil
+}
+
+// PaginasPDF returns the number of pages of a PDF file without reading their content.
+// Encrypted files are counted too: the page tree is not encrypted.
+func PaginasPDF(data []byte) (n int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			n, err = 0, fmt.Errorf("%w: %v", ErrInvalid, r)
+		}
+	}()
+
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF-")) {
+		return 0, ErrInvalid
+	}
+
+	r := newPDFReader(data)
+	catalogo := r.catalog()
+	if catalogo == nil {
+		return 0, ErrInvalid
+	}
+	if n = len(r.pages(catalogo)); n == 0 {
+		return 0, ErrInvalid
+	}
+	return n, nil
+}
+
+// pdfReader resolves the objects of a PDF file
+type pdfReader struct {
+	data    []byte
+	offsets map[int]objLocation
+	cache   map[int]interface{}
+	fonts   map[interface{}]*pdfFont
+	trailer pdfDict
+}
+
+// objLocation is where an object is defined: at an offset of the file or inside an object
+// stream. orden keeps the definition that appears last, as incremental updates do.
+type objLocation struct {
+	offset int
+	stream int // Object stream that holds it, or zero
+	orden  int
+}
+
+var (
+	objPattern     = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	trailerPattern = regexp.MustCompile(`trailer\s*<<`)
+)
+
+func newPDFReader(data []byte) *pdfReader {
+	r := &pdfReader{
+		data:    data,
+		offsets: make(map[int]objLocation),
+		cache:   make(map[int]interface{}),
+		fonts:   make(map[interface{}]*pdfFont),
+	}
+
+	for _, m := range objPattern.FindAllSubmatchIndex(data, -1) {
+		// El número no puede ser la cola de otro número
+		if m[0] > 0 && isRegular(data[m[0]-1]) {
+			continue
+		}
+		num, err := strconv.Atoi(string(data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		r.offsets[num] = objLocation{offset: m[0], orden: m[0]}
+	}
+
+	// Objetos comprimidos en object streams (PDF 1.5 o posterior)
+	directos := make(map[int]objLocation, len(r.offsets))
+	for num, loc := range r.offsets {
+		directos[num] = loc
+	}
+	for num, loc := range directos {
+		if stream, ok := r.object(num).(*pdfStream); ok && stream.dict["Type"] == pdfName("ObjStm") {
+			r.indexObjectStream(num, stream, loc.orden)
+		}
+	}
+	// Un objeto redefinido dentro de un object stream pudo quedar leído con su versión anterior
+	r.cache = make(map[int]interface{})
+
+	// El último trailer manda; los archivos con xref stream lo guardan en su diccionario
+	if ms := trailerPattern.FindAllIndex(data, -1); len(ms) > 0 {
+		l := &lexer{data: data, pos: ms[len(ms)-1][0] + len("trailer")}
+		r.trailer, _ = l.object(0).(pdfDict)
+	}
+	if r.trailer == nil {
+		ultimo := -1
+		for num, loc := range r.offsets {
+			if stream, ok := r.object(num).(*pdfStream); ok && stream.dict["Type"] == pdfName("XRef") && loc.orden > ultimo {
+				r.trailer, ultimo = stream.dict, loc.orden
+			}
+		}
+	}
+
+	return r
+}
+
+// indexObjectStream registers the objects held in an object stream
+func (r *pdfReader) indexObjectStream(num int, stream *pdfStream, orden int) {
+	data, err := r.decode(stream)
+	if err != nil {
+		return
+	}
+	n, _ := r.resolve(stream.dict["N"]).(int)
+	first, _ := r.resolve(stream.dict["First"]).(int)
+
+	l := &lexer{data: data}
+	for i := 0; i < n; i++ {
+		objNum, ok1 := l.next().(int)
+		offset, ok2 := l.next().(int)
+		if !ok1 || !ok2 {
+			return
+		}
+		if actual, ok := r.offsets[objNum]; ok && actual.orden > orden {
+			continue
+		}
+		r.offsets[objNum] = objLocation{offset: first + offset, stream: num, orden: orden}
+	}
+}
+
+// encrypted reports whether the document is protected by the standard security handler
+func (r *pdfReader) encrypted() bool {
+	return r.trailer != nil && r.trailer["Encrypt"] != nil
+}
+
+// catalog returns the document catalog, looking for it among the objects when the trailer
+// does not point to it
+func (r *pdfReader) catalog() pdfDict {
+	if r.trailer != nil {
+		if catalogo, ok := r.resolve(r.trailer["Root"]).(pdfDict); ok {
+			return catalogo
+		}
+	}
+	for num := range r.offsets {
+		if dict, ok := r.object(num).(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			return dict
+		}
+	}
+	return nil
+}
+
+// object returns the object with the given number, or nil when it does not exist
+func (r *pdfReader) object(num int) interface{} {
+	if obj, ok := r.cache[num]; ok {
+		return obj
+	}
+	// Marca en curso: una referencia circular se resuelve como nula
+	r.cache[num] = nil
+
+	loc, ok := r.offsets[num]
+	if !ok {
+		return nil
+	}
+
+	var obj interface{}
+	if loc.stream != 0 {
+		if stream, ok := r.object(loc.stream).(*pdfStream); ok {
+			if data, err := r.decode(stream); err == nil && loc.offset < len(data) {
+				l := &lexer{data: data, pos: loc.offset}
+				obj = l.object(0)
+			}
+		}
+	} else {
+		obj = r.parseIndirect(loc.offset)
+	}
+
+	r.cache[num] = obj
+	return obj
+}
+
+// parseIndirect reads "num gen obj ... endobj" at an offset of the file
+func (r *pdfReader) parseIndirect(offset int) interface{} {
+	l := &lexer{data: r.data, pos: offset}
+	l.next()
+	l.next()
+	if l.next() != pdfKeyword("obj") {
+		return nil
+	}
+
+	obj := l.object(0)
+	dict, ok := obj.(pdfDict)
+	if !ok {
+		return obj
+	}
+
+	// Un diccionario seguido de "stream" es el encabezado de un stream
+	if l.next() != pdfKeyword("stream") {
+		return dict
+	}
+	pos := l.pos
+	if pos < len(r.data) && r.data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(r.data) && r.data[pos] == '\n' {
+		pos++
+	}
+
+	return &pdfStream{dict: dict, data: r.streamData(dict, pos)}
+}
+
+// streamData returns the raw bytes of a stream starting at pos, using /Length when it is
+// consistent with the file and searching for "endstream" otherwise
+func (r *pdfReader) streamData(dict pdfDict, pos int) []byte {
+	if length, ok := r.resolve(dict["Length"]).(int); ok && length >= 0 && pos+length <= len(r.data) {
+		resto := bytes.TrimLeft(r.data[pos+length:], " \t\r\n\f\x00")
+		if bytes.HasPrefix(resto, []byte("endstream")) {
+			return r.data[pos : pos+length]
+		}
+	}
+
+	fin := bytes.Index(r.data[pos:], []byte("endstream"))
+	if fin < 0 {
+		return r.data[pos:]
+	}
+	data := r.data[pos : pos+fin]
+	// El salto de línea anterior a endstream no es parte de los datos
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	data = bytes.TrimSuffix(data, []byte("\r"))
+	return data
+}
+
+// resolve follows indirect references
+func (r *pdfReader) resolve(obj interface{}) interface{} {
+	for i := 0; i < maxProfundidad; i++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		obj = r.object(ref.num)
+	}
+	return nil
+}
+
+// dict resolves an object expected to be a dictionary; a stream yields its dictionary
+func (r *pdfReader) dict(obj interface{}) pdfDict {
+	switch v := r.resolve(obj).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// number resolves an object expected to be a number
+func (r *pdfReader) number(obj interface{}) (float64, bool) {
+	switch v := r.resolve(obj).(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// pages returns the page dictionaries in order, with the inherited resources filled in
+func (r *pdfReader) pages(catalogo pdfDict) []pdfDict {
+	var paginas []pdfDict
+	visitados := make(map[interface{}]bool)
+
+	var recorrer func(nodo interface{}, recursos interface{}, profundidad int)
+	recorrer = func(nodo interface{}, recursos interface{}, profundidad int) {
+		if profundidad > maxProfundidad {
+			return
+		}
+		if ref, ok := nodo.(pdfRef); ok {
+			if visitados[ref] {
+				return
+			}
+			visitados[ref] = true
+		}
+		dict := r.dict(nodo)
+		if dict == nil {
+			return
+		}
+		if propios, ok := dict["Resources"]; ok {
+			recursos = propios
+		}
+
+		kids, esArbol := r.resolve(dict["Kids"]).(pdfArray)
+		if !esArbol || dict["Type"] == pdfName("Page") {
+			pagina := make(pdfDict, len(dict)+1)
+			for k, v := range dict {
+				pagina[k] = v
+			}
+			pagina["Resources"] = recursos
+			paginas = append(paginas, pagina)
+			return
+		}
+		for _, kid := range kids {
+			recorrer(kid, recursos, profundidad+1)
+		}
+	}
+
+	recorrer(catalogo["Pages"], nil, 0)
+	return paginas
+}
+
+// decode applies the filters of a stream
+func (r *pdfReader) decode(stream *pdfStream) ([]byte, error) {
+	var filtros []interface{}
+	switch f := r.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filtros = []interface{}{f}
+	case pdfArray:
+		filtros = f
+	}
+	var parametros []interface{}
+	switch p := r.resolve(stream.dict["DecodeParms"]).(type) {
+	case pdfDict:
+		parametros = []interface{}{p}
+	case pdfArray:
+		parametros = p
+	}
+
+	data := stream.data
+	for i, filtro := range filtros {
+		var params pdfDict
+		if i < len(parametros) {
+			params = r.dict(parametros[i])
+		}
+		var err error
+		if data, err = r.applyFilter(r.resolve(filtro), params, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// errFiltro is returned for image codecs, which never hold text
+var errFiltro = errors.New("filtro de stream no soportado")
+
+func (r *pdfReader) applyFilter(filtro interface{}, params pdfDict, data []byte) ([]byte, error) {
+	var out []byte
+	var err error
+	switch filtro {
+	case pdfName("FlateDecode"), pdfName("Fl"):
+		var zr io.ReadCloser
+		if zr, err = zlib.NewReader(bytes.NewReader(data)); err != nil {
+			return nil, err
+		}
+		out, err = io.ReadAll(zr)
+		zr.Close()
+		// Muchos generadores truncan el final del stream comprimido
+		if err != nil && len(out) > 0 {
+			err = nil
+		}
+	case pdfName("LZWDecode"), pdfName("LZW"):
+		lr := lzw.NewReader(bytes.NewReader(data), lzw.MSB, 8)
+		out, err = io.ReadAll(lr)
+		lr.Close()
+		if err != nil && len(out) > 0 {
+			err = nil
+		}
+	case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+		out, err = decodeASCIIHex(data)
+	case pdfName("ASCII85Decode"), pdfName("A85"):
+		out, err = decodeASCII85(data)
+	case pdfName("RunLengthDecode"), pdfName("RL"):
+		out = decodeRunLength(data)
+	default:
+		return nil, errFiltro
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if predictor, _ := r.number(params["Predictor"]); predictor >= 10 {
+		columnas, ok := r.number(params["Columns"])
+		if !ok {
+			columnas = 1
+		}
+		colores, ok := r.number(params["Colors"])
+		if !ok {
+			colores = 1
+		}
+		bits, ok := r.number(params["BitsPerComponent"])
+		if !ok {
+			bits = 8
+		}
+		return decodePNGPredictor(out, int(columnas), int(colores*bits+7)/8)
+	}
+	return out, nil
+}
+
+func decodeASCIIHex(data []byte) ([]byte, error) {
+	var limpio []byte
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isWhite(c) {
+			limpio = append(limpio, c)
+		}
+	}
+	if len(limpio)%2 == 1 {
+		limpio = append(limpio, '0')
+	}
+	out := make([]byte, len(limpio)/2)
+	_, err := hex.Decode(out, limpio)
+	return out, err
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if fin := bytes.Index(data, []byte("~>")); fin >= 0 {
+		data = data[:fin]
+	}
+	return io.ReadAll(ascii85.NewDecoder(bytes.NewReader(data)))
+}
+
+func decodeRunLength(data []byte) []byte {
+	var out []byte
+	for i := 0; i < len(data); {
+		n := int(data[i])
+		i++
+		switch {
+		case n == 128:
+			return out
+		case n < 128:
+			fin := min(i+n+1, len(data))
+			out = append(out, data[i:fin]...)
+			i = fin
+		case i < len(data):
+			out = append(out, bytes.Repeat(data[i:i+1], 257-n)...)
+			i++
+		}
+	}
+	return out
+}
+
+// decodePNGPredictor reverses the PNG row filters used with /Predictor 10 to 15
+func decodePNGPredictor(data []byte, columnas, bytesPorPixel int) ([]byte, error) {
+	ancho := (columnas*bytesPorPixel*8 + 7) / 8
+	if bytesPorPixel < 1 || ancho < 1 {
+		return nil, ErrInvalid
+	}
+
+	var out []byte
+	anterior := make([]byte, ancho)
+	for i := 0; i+1+ancho <= len(data); i += 1 + ancho {
+		tipo := data[i]
+		fila := append([]byte(nil), data[i+1:i+1+ancho]...)
+		for j := range fila {
+			var izquierda, arriba, diagonal byte
+			if j >= bytesPorPixel {
+				izquierda = fila[j-bytesPorPixel]
+				diagonal = anterior[j-bytesPorPixel]
+			}
+			arriba = anterior[j]
+			switch tipo {
+			case 1:
+				fila[j] += izquierda
+			case 2:
+				fila[j] += arriba
+			case 3:
+				fila[j] += byte((int(izquierda) + int(arriba)) / 2)
+			case 4:
+				fila[j] += paeth(izquierda, arriba, diagonal)
+			}
+		}
+		out = append(out, fila...)
+		anterior = fila
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,293 @@
+package extract
+
+import (
+	"strconv"
+)
+
+// lexer tokenizes PDF syntax, both the object syntax of the file and the operators of
+// content streams
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isWhite(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isRegular(c byte) bool {
+	return !isWhite(c) && !isDelimiter(c)
+}
+
+// skipSpace skips whitespace and comments
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isWhite(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// next returns the next token: a number (int or float64), pdfName, pdfString or pdfKeyword
+// (operators and the delimiters << >> [ ]). It returns nil at the end of the data.
+func (l *lexer) next() interface{} {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+
+	c := l.data[l.pos]
+	switch c {
+	case '(':
+		l.pos++
+		return l.literalString()
+	case '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return pdfKeyword("<<")
+		}
+		l.pos++
+		return l.hexString()
+	case '>':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return pdfKeyword(">>")
+		}
+		l.pos++
+		return pdfKeyword(">")
+	case '[', ']', '{', '}', ')':
+		l.pos++
+		return pdfKeyword(string(c))
+	case '/':
+		l.pos++
+		return l.name()
+	}
+
+	inicio := l.pos
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		l.pos++
+	}
+	token := string(l.data[inicio:l.pos])
+	if esNumero(token) {
+		if n, err := strconv.Atoi(token); err == nil {
+			return n
+		}
+		if f, err := strconv.ParseFloat(token, 64); err == nil {
+			return f
+		}
+		return 0
+	}
+	return pdfKeyword(token)
+}
+
+func esNumero(token string) bool {
+	if token == "" {
+		return false
+	}
+	digitos := false
+	for i := 0; i < len(token); i++ {
+		switch c := token[i]; {
+		case c >= '0' && c <= '9':
+			digitos = true
+		case c == '.':
+		case (c == '+' || c == '-') && i == 0:
+		default:
+			return false
+		}
+	}
+	return digitos
+}
+
+func (l *lexer) literalString() pdfString {
+	var out []byte
+	nivel := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			nivel++
+		case ')':
+			nivel--
+			if nivel == 0 {
+				return out
+			}
+		case '\r':
+			// Los saltos de línea dentro de un string se leen como \n
+			if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+				l.pos++
+			}
+			c = '\n'
+		case '\\':
+			if l.pos >= len(l.data) {
+				return out
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func (l *lexer) hexString() pdfString {
+	var out []byte
+	var alto byte
+	mitad := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := hexValue(c)
+		if !ok {
+			continue
+		}
+		if mitad {
+			out = append(out, alto<<4|v)
+		} else {
+			alto = v
+		}
+		mitad = !mitad
+	}
+	if mitad {
+		out = append(out, alto<<4)
+	}
+	return out
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (l *lexer) name() pdfName {
+	var out []byte
+	for l.pos < len(l.data) && isRegular(l.data[l.pos]) {
+		c := l.data[l.pos]
+		l.pos++
+		if c == '#' && l.pos+1 < len(l.data) {
+			alto, ok1 := hexValue(l.data[l.pos])
+			bajo, ok2 := hexValue(l.data[l.pos+1])
+			if ok1 && ok2 {
+				c = alto<<4 | bajo
+				l.pos += 2
+			}
+		}
+		out = append(out, c)
+	}
+	return pdfName(out)
+}
+
+// object reads a complete object: dictionaries and arrays are built, "num gen R" becomes a
+// reference and true, false and null their values. Operators come back as pdfKeyword.
+func (l *lexer) object(profundidad int) interface{} {
+	token := l.next()
+	if profundidad > maxProfundidad {
+		return nil
+	}
+
+	switch t := token.(type) {
+	case pdfKeyword:
+		switch t {
+		case "<<":
+			dict := pdfDict{}
+			for {
+				clave := l.next()
+				if clave == nil || clave == pdfKeyword(">>") {
+					return dict
+				}
+				nombre, ok := clave.(pdfName)
+				if !ok {
+					continue
+				}
+				valor := l.object(profundidad + 1)
+				if valor == pdfKeyword(">>") {
+					return dict
+				}
+				dict[nombre] = valor
+			}
+		case "[":
+			array := pdfArray{}
+			for {
+				valor := l.object(profundidad + 1)
+				if valor == nil && l.pos >= len(l.data) || valor == pdfKeyword("]") {
+					return array
+				}
+				array = append(array, valor)
+			}
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return t
+	case int:
+		// Una referencia indirecta: "12 0 R"
+		guardado := l.pos
+		if gen, ok := l.next().(int); ok {
+			if l.next() == pdfKeyword("R") {
+				return pdfRef{num: t, gen: gen}
+			}
+		}
+		l.pos = guardado
+		return t
+	}
+	return token
+}
@@ -0,0 +1,263 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const helvetica = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>"
+
+// pdfPrueba assembles a PDF file from the bodies of its objects, numbered from 1, with a
+// cross-reference table and a trailer whose root is object 1
+func pdfPrueba(trailer string, objetos ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objetos))
+	for i, objeto := range objetos {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, objeto)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objetos)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R %s>>\nstartxref\n%d\n%%%%EOF\n", len(objetos)+1, trailer, xref)
+	return b.Bytes()
+}
+
+// pdfUnaPagina builds a one-page PDF whose font F1 is object 4 and whose content is object 5.
+// Extra objects are numbered from 6; the page names object 6 as its XObject X1.
+func pdfUnaPagina(fuente, contenido string, extra ...string) []byte {
+	objetos := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 4 0 R >> /XObject << /X1 6 0 R >> >> /Contents 5 0 R >>",
+		fuente,
+		streamPDF("", contenido),
+	}
+	return pdfPrueba("", append(objetos, extra...)...)
+}
+
+// streamPDF returns a stream object with the extra dictionary entries given
+func streamPDF(dict, data string) string {
+	return fmt.Sprintf("<< %s /Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func comprimir(data string) string {
+	var b bytes.Buffer
+	zw := zlib.NewWriter(&b)
+	zw.Write([]byte(data))
+	zw.Close()
+	return b.String()
+}
+
+// pdfObjectStream builds a PDF 1.5 file whose catalog, page tree and page live in a
+// compressed object stream and whose trailer is a cross-reference stream
+func pdfObjectStream(contenido string) []byte {
+	comprimidos := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+	}
+	var indice, cuerpo strings.Builder
+	for i, objeto := range comprimidos {
+		fmt.Fprintf(&indice, "%d %d ", i+1, cuerpo.Len())
+		cuerpo.WriteString(objeto + "\n")
+	}
+	objStm := comprimir(indice.String() + cuerpo.String())
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n")
+	for i, objeto := range []string{
+		helvetica,
+		streamPDF("/Filter /FlateDecode", comprimir(contenido)),
+		streamPDF(fmt.Sprintf("/Type /ObjStm /N 3 /First %d /Filter /FlateDecode", indice.Len()), objStm),
+		streamPDF("/Type /XRef /Size 8 /Root 1 0 R /W [1 2 1]", ""),
+	} {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+4, objeto)
+	}
+	b.WriteString("startxref\n0\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestPDF(t *testing.T) {
+	cmap := "/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n" +
+		"1 begincodespacerange <0000> <FFFF> endcodespacerange\n" +
+		"1 beginbfchar <0005> <00F1> endbfchar\n" +
+		"1 beginbfrange <0001> <0003> <0041> endbfrange\n" +
+		"endcmap CMapName currentdict /CMap defineresource pop end end"
+	ascii85Flate := func(data string) string {
+		comprimido := comprimir(data)
+		out := make([]byte, ascii85.MaxEncodedLen(len(comprimido)))
+		return string(out[:ascii85.Encode(out, []byte(comprimido))]) + "~>"
+	}
+
+	tests := []struct {
+		nombre string
+		pdf    []byte
+		want   []string
+	}{
+		{
+			nombre: "texto y saltos de línea",
+			pdf:    pdfUnaPagina(helvetica, "BT /F1 12 Tf 72 720 Td (Hola mundo) Tj 0 -14 Td (Segunda l\\355nea \\(2\\)) Tj ET"),
+			want:   []string{"Hola mundo\nSegunda línea (2)"},
+		},
+		{
+			nombre: "TJ con ajustes de espaciado",
+			pdf:    pdfUnaPagina(helvetica, "BT /F1 12 Tf 72 720 Td [(Ex) -50 (pediente) -1000 (N) 10 (\\272 5)] TJ ET"),
+			want:   []string{"Expediente Nº 5"},
+		},
+		{
+			nombre: "interlineado y palabra cortada con guion",
+			pdf:    pdfUnaPagina(helvetica, "BT /F1 10 Tf 14 TL 72 720 Td (Este docu-) Tj T* (mento sigue) Tj (en otra) ' ET"),
+			want:   []string{"Este documento sigue\nen otra"},
+		},
+		{
+			nombre: "Differences",
+			pdf:    pdfUnaPagina("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /BaseEncoding /WinAnsiEncoding /Differences [65 /eacute /ntilde 90 /fi /uni00D1] >> >>", "BT /F1 12 Tf 72 720 Td (ABZ[) Tj ET"),
+			want:   []string{"éñfiÑ"},
+		},
+		{
+			nombre: "Type0 con ToUnicode",
+			pdf: pdfUnaPagina(
+				"<< /Type /Font /Subtype /Type0 /BaseFont /Fuente /Encoding /Identity-H /DescendantFonts [6 0 R] /ToUnicode 7 0 R >>",
+				"BT /F1 11 Tf 72 720 Td <0001000200030005> Tj ET",
+				"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /Fuente /DW 1000 /W [1 [600 600 600] 5 5 500] >>",
+				streamPDF("", cmap),
+			),
+			want: []string{"ABCñ"},
+		},
+		{
+			nombre: "form XObject",
+			pdf: pdfUnaPagina(helvetica, "q 1 0 0 1 50 50 cm /X1 Do Q",
+				streamPDF("/Type /XObject /Subtype /Form /BBox [0 0 200 50] /Resources << /Font << /F1 4 0 R >> >>", "BT /F1 12 Tf 0 0 Td (Dentro del formulario) Tj ET"),
+			),
+			want: []string{"Dentro del formulario"},
+		},
+		{
+			nombre: "imagen en línea",
+			pdf:    pdfUnaPagina(helvetica, "q BI /W 2 /H 1 /BPC 8 /CS /G ID \x00\xff EI Q BT /F1 12 Tf 72 720 Td (Tras la imagen) Tj ET"),
+			want:   []string{"Tras la imagen"},
+		},
+		{
+			nombre: "página escaneada sin capa de texto",
+			pdf:    pdfUnaPagina(helvetica, "q 612 0 0 792 0 0 cm /Im1 Do Q"),
+			want:   []string{""},
+		},
+		{
+			nombre: "dos páginas comprimidas con recursos heredados",
+			pdf: pdfPrueba("",
+				"<< /Type /Catalog /Pages 2 0 R >>",
+				"<< /Type /Pages /Kids [3 0 R] /Count 2 /Resources << /Font << /F1 6 0 R >> >> >>",
+				"<< /Type /Pages /Parent 2 0 R /Kids [4 0 R 5 0 R] /Count 2 >>",
+				"<< /Type /Page /Parent 3 0 R /Contents 7 0 R >>",
+				"<< /Type /Page /Parent 3 0 R /Contents [8 0 R 9 0 R] >>",
+				helvetica,
+				streamPDF("/Filter /FlateDecode", comprimir("BT /F1 12 Tf 72 720 Td (P\\341gina uno) Tj ET")),
+				streamPDF("/Filter [/ASCII85Decode /FlateDecode]", ascii85Flate("BT /F1 12 Tf 72 720 Td (P\\341gina dos) Tj ET")),
+				streamPDF("/Filter /ASCIIHexDecode", fmt.Sprintf("%X>", "BT /F1 12 Tf 72 700 Td (contin\\372a) Tj ET")),
+			),
+			want: []string{"Página uno", "Página dos\ncontinúa"},
+		},
+		{
+			nombre: "object stream",
+			pdf:    pdfObjectStream("BT /F1 12 Tf 72 720 Td (Comprimido) Tj ET"),
+			want:   []string{"Comprimido"},
+		},
+	}
+	for _, tt := range tests {
+		paginas, err := PDF(tt.pdf)
+		if err != nil {
+			t.Errorf("%s: PDF: %v", tt.nombre, err)
+			continue
+		}
+		if !slices.Equal(paginas, tt.want) {
+			t.Errorf("%s: PDF = %q, want %q", tt.nombre, paginas, tt.want)
+		}
+		if n, err := PaginasPDF(tt.pdf); err != nil || n != len(tt.want) {
+			t.Errorf("%s: PaginasPDF = %d, %v, want %d", tt.nombre, n, err, len(tt.want))
+		}
+	}
+}
+
+func TestPDFInvalido(t *testing.T) {
+	cifrado := pdfPrueba("/Encrypt 6 0 R ",
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 5 0 R >>",
+		streamPDF("", "\x8a\x11\x02"),
+		"<< /Filter /Standard /V 2 /R 3 /O <00> /U <00> /P -4 >>",
+	)
+	if _, err := PDF(cifrado); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("PDF cifrado: error = %v, want %v", err, ErrEncrypted)
+	}
+	// El árbol de páginas no se cifra, así que las páginas se cuentan igual
+	if n, err := PaginasPDF(cifrado); err != nil || n != 2 {
+		t.Errorf("PaginasPDF cifrado = %d, %v, want 2", n, err)
+	}
+
+	tests := []struct {
+		nombre string
+		pdf    []byte
+	}{
+		{"vacío", nil},
+		{"no es un PDF", []byte("PK\x03\x04 no es un PDF")},
+		{"solo la cabecera", []byte("%PDF-1.7\n")},
+		{"sin páginas", pdfPrueba("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [] /Count 0 >>")},
+		{"árbol de páginas circular", pdfPrueba("", "<< /Type /Catalog /Pages 2 0 R >>", "<< /Type /Pages /Kids [2 0 R] /Count 1 >>")},
+	}
+	for _, tt := range tests {
+		if _, err := PDF(tt.pdf); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: PDF error = %v, want %v", tt.nombre, err, ErrInvalid)
+		}
+		if _, err := PaginasPDF(tt.pdf); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: PaginasPDF error = %v, want %v", tt.nombre, err, ErrInvalid)
+		}
+	}
+}
+
+func TestDecodeRunLength(t *testing.T) {
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte{2, 'a', 'b', 'c', 254, 'x', 128, 'z'}, "abcxxx"},
+		{[]byte{0, 'a', 255, 'b'}, "abb"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := string(decodeRunLength(tt.data)); got != tt.want {
+			t.Errorf("decodeRunLength(%v) = %q, want %q", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestDecodePNGPredictor(t *testing.T) {
+	// Three rows of three one-byte pixels, filtered with None, Sub, Up, Average and Paeth
+	data := []byte{
+		0, 10, 20, 30,
+		1, 5, 1, 1,
+		2, 1, 1, 1,
+		3, 8, 1, 2,
+		4, 1, 1, 1,
+	}
+	want := []byte{
+		10, 20, 30,
+		5, 6, 7,
+		6, 7, 8,
+		11, 10, 11,
+		12, 12, 13,
+	}
+	got, err := decodePNGPredictor(data, 3, 1)
+	if err != nil || !bytes.Equal(got, want) {
+		t.Errorf("decodePNGPredictor = %v, %v, want %v", got, err, want)
+	}
+}
@@ -0,0 +1,565 @@
+package extract
+
+import (
+	"math"
+	"strings"
+)
+
+// matrix is a PDF transformation matrix [a b c d e f]
+type matrix [6]float64
+
+var identidad = matrix{1, 0, 0, 1, 0, 0}
+
+// mul returns m × n
+func (m matrix) mul(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+func traslacion(x, y float64) matrix {
+	return matrix{1, 0, 0, 1, x, y}
+}
+
+// textState follows the text operators of a content stream and writes the text they show,
+// adding a space or a line break where the position of the text jumps
+type textState struct {
+	r   *pdfReader
+	out strings.Builder
+
+	ctm  matrix
+	pila []matrix
+
+	fuente            *pdfFont
+	tamano            float64
+	espacioCaracteres float64 // Tc
+	espacioPalabras   float64 // Tw
+	escala            float64 // Tz / 100
+	interlineado      float64 // TL
+	tm, tlm           matrix
+
+	// Fin del último texto mostrado, en el espacio de la página
+	finX, finY, finAlto float64
+	hayTexto            bool
+}
+
+// pageText returns the text shown by a page and the form XObjects it draws
+func (r *pdfReader) pageText(pagina pdfDict) string {
+	var contenido []byte
+	switch c := r.resolve(pagina["Contents"]).(type) {
+	case *pdfStream:
+		contenido, _ = r.decode(c)
+	case pdfArray:
+		for _, parte := range c {
+			if stream, ok := r.resolve(parte).(*pdfStream); ok {
+				if data, err := r.decode(stream); err == nil {
+					contenido = append(contenido, data...)
+					contenido = append(contenido, '\n')
+				}
+			}
+		}
+	}
+
+	ts := &textState{r: r, ctm: identidad, escala: 1, tm: identidad, tlm: identidad}
+	ts.run(contenido, r.dict(pagina["Resources"]), 0)
+	return ts.out.String()
+}
+
+// run interprets a content stream
+func (ts *textState) run(contenido []byte, recursos pdfDict, profundidad int) {
+	if profundidad > 8 {
+		return
+	}
+
+	l := &lexer{data: contenido}
+	var operandos []interface{}
+	for {
+		token := l.object(0)
+		if token == nil && l.pos >= len(l.data) {
+			return
+		}
+		op, esOperador := token.(pdfKeyword)
+		if !esOperador || op == "[" || op == "<<" {
+			operandos = append(operandos, token)
+			continue
+		}
+
+		ts.operador(op, operandos, recursos, l, profundidad)
+		operandos = operandos[:0]
+	}
+}
+
+func (ts *textState) operador(op pdfKeyword, operandos []interface{}, recursos pdfDict, l *lexer, profundidad int) {
+	numero := func(i int) float64 {
+		if i < len(operandos) {
+			if v, ok := ts.r.number(operandos[i]); ok {
+				return v
+			}
+		}
+		return 0
+	}
+	ultimo := func() interface{} {
+		if len(operandos) == 0 {
+			return nil
+		}
+		return operandos[len(operandos)-1]
+	}
+
+	switch op {
+	case "q":
+		ts.pila = append(ts.pila, ts.ctm)
+	case "Q":
+		if n := len(ts.pila); n > 0 {
+			ts.ctm, ts.pila = ts.pila[n-1], ts.pila[:n-1]
+		}
+	case "cm":
+		if len(operandos) == 6 {
+			ts.ctm = matrix{numero(0), numero(1), numero(2), numero(3), numero(4), numero(5)}.mul(ts.ctm)
+		}
+	case "BT":
+		ts.tm, ts.tlm = identidad, identidad
+	case "Tf":
+		if len(operandos) == 2 {
+			nombre, _ := operandos[0].(pdfName)
+			ts.fuente = ts.r.font(ts.r.dict(recursos["Font"])[nombre])
+			ts.tamano = numero(1)
+		}
+	case "Tc":
+		ts.espacioCaracteres = numero(0)
+	case "Tw":
+		ts.espacioPalabras = numero(0)
+	case "Tz":
+		ts.escala = numero(0) / 100
+	case "TL":
+		ts.interlineado = numero(0)
+	case "Td":
+		ts.tlm = traslacion(numero(0), numero(1)).mul(ts.tlm)
+		ts.tm = ts.tlm
+	case "TD":
+		ts.interlineado = -numero(1)
+		ts.tlm = traslacion(numero(0), numero(1)).mul(ts.tlm)
+		ts.tm = ts.tlm
+	case "Tm":
+		if len(operandos) == 6 {
+			ts.tlm = matrix{numero(0), numero(1), numero(2), numero(3), numero(4), numero(5)}
+			ts.tm = ts.tlm
+		}
+	case "T*":
+		ts.siguienteLinea()
+	case "Tj":
+		if s, ok := ultimo().(pdfString); ok {
+			ts.mostrar(s)
+		}
+	case "'":
+		ts.siguienteLinea()
+		if s, ok := ultimo().(pdfString); ok {
+			ts.mostrar(s)
+		}
+	case "\"":
+		if len(operandos) == 3 {
+			ts.espacioPalabras, ts.espacioCaracteres = numero(0), numero(1)
+		}
+		ts.siguienteLinea()
+		if s, ok := ultimo().(pdfString); ok {
+			ts.mostrar(s)
+		}
+	case "TJ":
+		array, _ := ultimo().(pdfArray)
+		for _, elemento := range array {
+			switch v := elemento.(type) {
+			case pdfString:
+				ts.mostrar(v)
+			case int, float64:
+				ajuste, _ := ts.r.number(v)
+				ts.avanzar(-ajuste / 1000 * ts.tamano * ts.escala)
+			}
+		}
+	case "Do":
+		nombre, _ := ultimo().(pdfName)
+		xobjetos := ts.r.dict(recursos["XObject"])
+		if form, ok := ts.r.resolve(xobjetos[nombre]).(*pdfStream); ok && form.dict["Subtype"] == pdfName("Form") {
+			data, err := ts.r.decode(form)
+			if err != nil {
+				return
+			}
+			propios := ts.r.dict(form.dict["Resources"])
+			if propios == nil {
+				propios = recursos
+			}
+			guardado := ts.ctm
+			if m, ok := ts.r.resolve(form.dict["Matrix"]).(pdfArray); ok && len(m) == 6 {
+				var fm matrix
+				for i := range fm {
+					fm[i], _ = ts.r.number(m[i])
+				}
+				ts.ctm = fm.mul(ts.ctm)
+			}
+			ts.run(data, propios, profundidad+1)
+			ts.ctm = guardado
+		}
+	case "BI":
+		// Imagen en línea: sus datos binarios terminan en "EI" entre espacios
+		for {
+			token := l.next()
+			if token == nil || token == pdfKeyword("ID") {
+				break
+			}
+		}
+		l.pos++
+		for l.pos+2 < len(l.data) {
+			if isWhite(l.data[l.pos]) && l.data[l.pos+1] == 'E' && l.data[l.pos+2] == 'I' &&
+				(l.pos+3 == len(l.data) || !isRegular(l.data[l.pos+3])) {
+				l.pos += 3
+				return
+			}
+			l.pos++
+		}
+		l.pos = len(l.data)
+	}
+}
+
+func (ts *textState) siguienteLinea() {
+	ts.tlm = traslacion(0, -ts.interlineado).mul(ts.tlm)
+	ts.tm = ts.tlm
+}
+
+// avanzar moves the text position along the line by tx text space units
+func (ts *textState) avanzar(tx float64) {
+	ts.tm = traslacion(tx, 0).mul(ts.tm)
+}
+
+// mostrar writes the text of a string and advances the position by its glyphs
+func (ts *textState) mostrar(s pdfString) {
+	if ts.fuente == nil {
+		return
+	}
+
+	// Posición y alto del texto en el espacio de la página
+	m := ts.tm.mul(ts.ctm)
+	x, y := m[4], m[5]
+	alto := ts.tamano * math.Hypot(m[2], m[3])
+	if alto == 0 {
+		alto = 1
+	}
+
+	if ts.hayTexto {
+		referencia := math.Max(alto, ts.finAlto)
+		switch dy, dx := math.Abs(y-ts.finY), x-ts.finX; {
+		case dy > referencia*0.5:
+			ts.separar('\n')
+		case dx > referencia*0.15 || dx < -referencia:
+			ts.separar(' ')
+		}
+	}
+
+	ts.fuente.glyphs(s, func(texto string, ancho float64, espacio bool) {
+		ts.out.WriteString(texto)
+		tx := ancho/1000*ts.tamano + ts.espacioCaracteres
+		if espacio {
+			tx += ts.espacioPalabras
+		}
+		ts.avanzar(tx * ts.escala)
+	})
+
+	fin := ts.tm.mul(ts.ctm)
+	ts.finX, ts.finY, ts.finAlto = fin[4], fin[5], alto
+	ts.hayTexto = true
+}
+
+// separar writes a space or line break unless the text already ends in one
+func (ts *textState) separar(c byte) {
+	actual := ts.out.String()
+	if actual == "" {
+		return
+	}
+	switch actual[len(actual)-1] {
+	case '\n':
+		return
+	case ' ':
+		if c == ' ' {
+			return
+		}
+	}
+	ts.out.WriteByte(c)
+}
+
+// pdfFont turns the character codes of a font into text and glyph widths
+type pdfFont struct {
+	compuesta     bool              // Type0 fonts use two-byte codes
+	toUnicode     map[uint32]string // Keyed by code length and code
+	codificacion  [256]string       // Simple fonts
+	anchos        map[int]float64   // Glyph widths in thousandths of the font size
+	anchoFaltante float64
+}
+
+// font builds the decoder of a font dictionary, cached by reference
+func (r *pdfReader) font(obj interface{}) *pdfFont {
+	clave := obj
+	if _, ok := obj.(pdfRef); !ok {
+		clave = nil
+	}
+	if clave != nil {
+		if f, ok := r.fonts[clave]; ok {
+			return f
+		}
+	}
+
+	dict := r.dict(obj)
+	if dict == nil {
+		return nil
+	}
+	f := &pdfFont{anchos: make(map[int]float64)}
+
+	subtipo, _ := r.resolve(dict["Subtype"]).(pdfName)
+	if subtipo == "Type0" {
+		f.compuesta = true
+		f.anchoFaltante = 1000
+		if descendientes, ok := r.resolve(dict["DescendantFonts"]).(pdfArray); ok && len(descendientes) > 0 {
+			r.cidWidths(f, r.dict(descendientes[0]))
+		}
+	} else {
+		r.simpleEncoding(f, dict)
+		r.simpleWidths(f, dict, subtipo)
+	}
+
+	if stream, ok := r.resolve(dict["ToUnicode"]).(*pdfStream); ok {
+		if data, err := r.decode(stream); err == nil {
+			f.toUnicode = parseCMap(data)
+		}
+	}
+
+	if clave != nil {
+		r.fonts[clave] = f
+	}
+	return f
+}
+
+// simpleEncoding fills the code to text table of a one-byte font from its base encoding
+// and /Differences
+func (r *pdfReader) simpleEncoding(f *pdfFont, dict pdfDict) {
+	base := winAnsi
+	var diferencias pdfArray
+	switch e := r.resolve(dict["Encoding"]).(type) {
+	case pdfName:
+		if e == "MacRomanEncoding" {
+			base = macRoman
+		}
+	case pdfDict:
+		if r.resolve(e["BaseEncoding"]) == pdfName("MacRomanEncoding") {
+			base = macRoman
+		}
+		diferencias, _ = r.resolve(e["Differences"]).(pdfArray)
+	}
+
+	for i := range f.codificacion {
+		f.codificacion[i] = string(base[i])
+	}
+	codigo := 0
+	for _, d := range diferencias {
+		switch v := r.resolve(d).(type) {
+		case int:
+			codigo = v
+		case pdfName:
+			if codigo >= 0 && codigo < 256 {
+				f.codificacion[codigo] = glyphText(string(v))
+			}
+			codigo++
+		}
+	}
+}
+
+// simpleWidths reads /FirstChar and /Widths; the standard fonts may omit them
+func (r *pdfReader) simpleWidths(f *pdfFont, dict pdfDict, subtipo pdfName) {
+	anchos, ok := r.resolve(dict["Widths"]).(pdfArray)
+	if !ok {
+		f.anchoFaltante = 500
+		return
+	}
+
+	// Las fuentes Type3 miden sus glifos con su propia matriz
+	factor := 1.0
+	if subtipo == "Type3" {
+		if m, ok := r.resolve(dict["FontMatrix"]).(pdfArray); ok && len(m) > 0 {
+			if v, ok := r.number(m[0]); ok {
+				factor = v * 1000
+			}
+		}
+	}
+
+	primero, _ := r.number(dict["FirstChar"])
+	for i, a := range anchos {
+		if v, ok := r.number(a); ok {
+			f.anchos[int(primero)+i] = v * factor
+		}
+	}
+	if descriptor := r.dict(dict["FontDescriptor"]); descriptor != nil {
+		f.anchoFaltante, _ = r.number(descriptor["MissingWidth"])
+	}
+}
+
+// cidWidths reads /DW and /W of a descendant CID font
+func (r *pdfReader) cidWidths(f *pdfFont, dict pdfDict) {
+	if dict == nil {
+		return
+	}
+	if dw, ok := r.number(dict["DW"]); ok {
+		f.anchoFaltante = dw
+	}
+
+	w, _ := r.resolve(dict["W"]).(pdfArray)
+	for i := 0; i+1 < len(w); {
+		primero, ok := r.number(w[i])
+		if !ok {
+			return
+		}
+		if lista, ok := r.resolve(w[i+1]).(pdfArray); ok {
+			for j, a := range lista {
+				if v, ok := r.number(a); ok {
+					f.anchos[int(primero)+j] = v
+				}
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(w) {
+			return
+		}
+		ultimo, _ := r.number(w[i+1])
+		ancho, _ := r.number(w[i+2])
+		for c := int(primero); c <= int(ultimo) && c-int(primero) < 65536; c++ {
+			f.anchos[c] = ancho
+		}
+		i += 3
+	}
+}
+
+// glyphs calls fn with the text, width and word-space flag of each code of a string
+func (f *pdfFont) glyphs(s pdfString, fn func(texto string, ancho float64, espacio bool)) {
+	largo := 1
+	if f.compuesta {
+		largo = 2
+	}
+
+	for i := 0; i+largo <= len(s); i += largo {
+		var codigo uint32
+		for _, b := range s[i : i+largo] {
+			codigo = codigo<<8 | uint32(b)
+		}
+
+		texto, ok := f.toUnicode[uint32(largo)<<24|codigo]
+		if !ok && !f.compuesta {
+			texto = f.codificacion[codigo]
+		}
+
+		ancho, ok := f.anchos[int(codigo)]
+		if !ok {
+			ancho = f.anchoFaltante
+		}
+		fn(texto, ancho, largo == 1 && codigo == ' ')
+	}
+}
+
+// parseCMap reads the bfchar and bfrange mappings of a ToUnicode CMap
+func parseCMap(data []byte) map[uint32]string {
+	mapa := make(map[uint32]string)
+	clave := func(codigo pdfString) (uint32, bool) {
+		if len(codigo) == 0 || len(codigo) > 3 {
+			return 0, false
+		}
+		var v uint32
+		for _, b := range codigo {
+			v = v<<8 | uint32(b)
+		}
+		return uint32(len(codigo))<<24 | v, true
+	}
+
+	l := &lexer{data: data}
+	for {
+		token := l.next()
+		if token == nil {
+			return mapa
+		}
+		switch token {
+		case pdfKeyword("beginbfchar"):
+			for {
+				origen, ok := l.object(0).(pdfString)
+				if !ok {
+					break
+				}
+				destino := l.object(0)
+				if k, ok := clave(origen); ok {
+					mapa[k] = cmapText(destino)
+				}
+			}
+		case pdfKeyword("beginbfrange"):
+			for {
+				desde, ok := l.object(0).(pdfString)
+				if !ok {
+					break
+				}
+				hasta, _ := l.object(0).(pdfString)
+				destino := l.object(0)
+
+				inicio, ok1 := clave(desde)
+				fin, ok2 := clave(hasta)
+				if !ok1 || !ok2 || fin < inicio || fin-inicio > 65535 {
+					continue
+				}
+				for k := inicio; k <= fin; k++ {
+					switch d := destino.(type) {
+					case pdfString:
+						// El último código UTF-16 se incrementa a lo largo del rango
+						texto := append(pdfString(nil), d...)
+						if n := len(texto); n >= 2 {
+							v := uint16(texto[n-2])<<8 | uint16(texto[n-1])
+							v += uint16(k - inicio)
+							texto[n-2], texto[n-1] = byte(v>>8), byte(v)
+						}
+						mapa[k] = utf16Text(texto)
+					case pdfArray:
+						if i := int(k - inicio); i < len(d) {
+							mapa[k] = cmapText(d[i])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// cmapText decodes a CMap destination: UTF-16BE bytes or a glyph name
+func cmapText(destino interface{}) string {
+	switch d := destino.(type) {
+	case pdfString:
+		return utf16Text(d)
+	case pdfName:
+		return glyphText(string(d))
+	}
+	return ""
+}
+
+// utf16Text decodes UTF-16BE text, dropping unpaired surrogates
+func utf16Text(b []byte) string {
+	var out []rune
+	for i := 0; i+1 < len(b); i += 2 {
+		u := rune(b[i])<<8 | rune(b[i+1])
+		if u >= 0xD800 && u < 0xDC00 && i+3 < len(b) {
+			bajo := rune(b[i+2])<<8 | rune(b[i+3])
+			if bajo >= 0xDC00 && bajo < 0xE000 {
+				out = append(out, 0x10000+(u-0xD800)<<10+(bajo-0xDC00))
+				i += 2
+				continue
+			}
+		}
+		if u >= 0xD800 && u < 0xE000 {
+			continue
+		}
+		out = append(out, u)
+	}
+	return string(out)
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArchivoHandler handles the shelf and division catalog endpoints
+type ArchivoHandler struct {
+	service           *services.ArchivoService
+	expedienteService *services.ExpedienteService
+}
+
+// NewArchivoHandler creates a new archivo handler
+func NewArchivoHandler(service *services.ArchivoService, expedienteService *services.ExpedienteService) *ArchivoHandler {
+	return &ArchivoHandler{
+		service:           service,
+		expedienteService: expedienteService,
+	}
+}
+
+// archivoErrorStatus maps catalog errors to HTTP status codes
+func archivoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrEstanteNotFound),
+		errors.Is(err, repository.ErrDivisionNotFound),
+		errors.Is(err, repository.ErrPlanNotFound),
+		err.Error() == ErrInvalidIDFormat:
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrEstanteExists),
+		errors.Is(err, repository.ErrDivisionExists),
+		errors.Is(err, services.ErrEstanteConDivisiones),
+		errors.Is(err, services.ErrRangoSolapado),
+		errors.Is(err, repository.ErrPlanAplicado),
+		errors.Is(err, services.ErrPlanDesactualizado):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrRangoInvalido),
+		errors.Is(err, services.ErrGradoInvalido):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondArchivoError writes a catalog error response
+func respondArchivoError(c *gin.Context, err error) {
+	c.JSON(archivoErrorStatus(err), gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// GetEstantes returns every shelf with the occupancy of its divisions
+func (h *ArchivoHandler) GetEstantes(c *gin.Context) {
+	estantes, err := h.service.GetOcupacion()
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    estantes,
+	})
+}
+
+// GetEstante returns a single shelf
+func (h *ArchivoHandler) GetEstante(c *gin.Context) {
+	estante, err := h.service.GetEstante(c.Param("id"))
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    estante,
+	})
+}
+
+// CreateEstante creates a new shelf
+func (h *ArchivoHandler) CreateEstante(c *gin.Context) {
+	var req models.CreateEstanteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	estante, err := h.service.CreateEstante(&req)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    estante,
+	})
+}
+
+// UpdateEstante updates a shelf
+func (h *ArchivoHandler) UpdateEstante(c *gin.Context) {
+	var req models.UpdateEstanteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	estante, err := h.service.UpdateEstante(c.Param("id"), &req)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    estante,
+	})
+}
+
+// DeleteEstante deletes an empty shelf
+func (h *ArchivoHandler) DeleteEstante(c *gin.Context) {
+	if err := h.service.DeleteEstante(c.Param("id")); err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Estante eliminado",
+	})
+}
+
+// GetDivisiones returns the divisions of the catalog, optionally filtered by estante_id
+func (h *ArchivoHandler) GetDivisiones(c *gin.Context) {
+	divisiones, err := h.service.GetDivisiones(c.Query("estante_id"))
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    divisiones,
+	})
+}
+
+// GetDivision returns a single division
+func (h *ArchivoHandler) GetDivision(c *gin.Context) {
+	division, err := h.service.GetDivision(c.Param("id"))
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    division,
+	})
+}
+
+// CreateDivision creates a new division
+func (h *ArchivoHandler) CreateDivision(c *gin.Context) {
+	var req models.CreateDivisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	division, err := h.service.CreateDivision(&req)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    division,
+	})
+}
+
+// UpdateDivision updates a division
+func (h *ArchivoHandler) UpdateDivision(c *gin.Context) {
+	var req models.UpdateDivisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	division, err := h.service.UpdateDivision(c.Param("id"), &req)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    division,
+	})
+}
+
+// DeleteDivision deletes a division
+func (h *ArchivoHandler) DeleteDivision(c *gin.Context) {
+	if err := h.service.DeleteDivision(c.Param("id")); err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "División eliminada",
+	})
+}
+
+// GetDivisionExpedientes returns the expedientes stored in a catalog division
+func (h *ArchivoHandler) GetDivisionExpedientes(c *gin.Context) {
+	division, err := h.service.GetDivision(c.Param("id"))
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	expedientes, err := h.expedienteService.GetExpedientesInDivision(division, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"expedientes": expedientes,
+			"total":       len(expedientes),
+			"division":    division.Rango(),
+		},
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BreakGlassHandler handles emergency access endpoints
+type BreakGlassHandler struct {
+	service *services.BreakGlassService
+}
+
+// NewBreakGlassHandler creates a new break-glass handler
+func NewBreakGlassHandler(service *services.BreakGlassService) *BreakGlassHandler {
+	return &BreakGlassHandler{service: service}
+}
+
+// RequestAccess grants temporary emergency access to an expediente
+func (h *BreakGlassHandler) RequestAccess(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.BreakGlassRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	access, err := h.service.RequestAccess(id, req.Justificacion, scope)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, services.ErrBreakGlassInnecesario) {
+			statusCode = http.StatusConflict
+		} else if errors.Is(err, services.ErrBreakGlassJustificacion) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "acceso de emergencia otorgado; los supervisores han sido notificados",
+		"data":    access,
+	})
+}
+
+// GetActiveGrants lists the caller's open emergency grants
+func (h *BreakGlassHandler) GetActiveGrants(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	accesses, err := h.service.GetActiveGrants(scope.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    accesses,
+	})
+}
+
+// ListForReview lists emergency accesses for the admin review queue
+func (h *BreakGlassHandler) ListForReview(c *gin.Context) {
+	page := 1
+	limit := 20
+	if parsed, err := strconv.Atoi(c.Query("page")); err == nil && parsed > 0 {
+		page = parsed
+	}
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 && parsed <= 100 {
+		limit = parsed
+	}
+	pendientes := c.DefaultQuery("pendientes", "true") == "true"
+
+	accesses, total, err := h.service.ListForReview(pendientes, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"accesos": accesses,
+			"total":   total,
+			"page":    page,
+			"limit":   limit,
+		},
+	})
+}
+
+// SignOff marks an emergency access as reviewed
+func (h *BreakGlassHandler) SignOff(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.BreakGlassReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	access, err := h.service.SignOff(id, req.Observacion, scope)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrBreakGlassNotFound) || err.Error() == ErrInvalidIDFormat {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, repository.ErrBreakGlassRevisado) {
+			statusCode = http.StatusConflict
+		} else if errors.Is(err, services.ErrBreakGlassAutorrevision) {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    access,
+	})
+}
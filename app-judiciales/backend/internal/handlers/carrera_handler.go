@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CarreraHandler handles the career history of the people behind expedientes
+type CarreraHandler struct {
+	service *services.CarreraService
+}
+
+// NewCarreraHandler creates a new carrera handler
+func NewCarreraHandler(service *services.CarreraService) *CarreraHandler {
+	return &CarreraHandler{
+		service: service,
+	}
+}
+
+// respondCarreraError writes a career error response with the matching status
+func respondCarreraError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat,
+		errors.Is(err, repository.ErrLoteNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, services.ErrFechaEfectivaInvalida),
+		errors.Is(err, services.ErrGradoInvalido),
+		errors.Is(err, services.ErrSituacionInvalida),
+		errors.Is(err, services.ErrEventoSinCambios),
+		errors.Is(err, services.ErrArchivoResolucion),
+		errors.Is(err, services.ErrLoteSinCambios):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, repository.ErrCarreraCambiada),
+		errors.Is(err, repository.ErrLoteAplicado),
+		errors.Is(err, services.ErrLoteConErrores),
+		errors.Is(err, services.ErrLoteDesactualizado):
+		statusCode = http.StatusConflict
+	}
+
+	c.JSON(statusCode, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// RegistrarEvento records a promotion or change of situación of an expediente
+func (h *CarreraHandler) RegistrarEvento(c *gin.Context) {
+	var req models.CreateEventoCarreraRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	evento, err := h.service.RegistrarEvento(c.Param("id"), &req, scope)
+	if err != nil {
+		respondCarreraError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    evento,
+	})
+}
+
+// GetCarrera returns the career of an expediente, optionally as of a past date (?fecha=YYYY-MM-DD)
+func (h *CarreraHandler) GetCarrera(c *gin.Context) {
+	var fecha time.Time
+	if value := c.Query("fecha"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "fecha must use the YYYY-MM-DD format",
+			})
+			return
+		}
+		fecha = parsed
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	carrera, err := h.service.GetCarrera(c.Param("id"), fecha, scope)
+	if err != nil {
+		respondCarreraError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    carrera,
+	})
+}
+
+// SearchEventos returns the career events matching the report filters
+func (h *CarreraHandler) SearchEventos(c *gin.Context) {
+	var params models.EventoCarreraSearchParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	eventos, err := h.service.SearchEventos(params, scope)
+	if err != nil {
+		respondCarreraError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"eventos": eventos,
+			"total":   len(eventos),
+		},
+	})
+}
+
+// PrevisualizarResolucion uploads a resolution file (Excel or CSV) and returns the stored
+// batch with every row matched against the current expedientes
+func (h *CarreraHandler) PrevisualizarResolucion(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Archivo requerido. Use el campo 'file' para subir el archivo Excel o CSV",
+		})
+		return
+	}
+
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".xlsx", ".xls", ".csv":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "El archivo debe ser de tipo Excel (.xlsx o .xls) o CSV",
+		})
+		return
+	}
+
+	// Validate file size (max 10MB)
+	if file.Size > 10*1024*1024 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "El archivo no puede ser mayor a 10MB",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	// Optional defaults for files that leave the date or resolution columns empty
+	lote, err := h.service.PrevisualizarLote(file, c.PostForm("fecha_efectiva"), c.PostForm("resolucion"), scope)
+	if err != nil {
+		respondCarreraError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    lote,
+	})
+}
+
+// GetResolucion returns a resolution batch
+func (h *CarreraHandler) GetResolucion(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	lote, err := h.service.GetLote(c.Param("id"), scope)
+	if err != nil {
+		respondCarreraError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    lote,
+	})
+}
+
+// AplicarResolucion applies every changed row of a resolution batch
+func (h *CarreraHandler) AplicarResolucion(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	lote, err := h.service.AplicarLote(c.Param("id"), scope)
+	if err != nil {
+		respondCarreraError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Lote de resolución aplicado",
+		"data":    lote,
+	})
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DependenciaHandler handles the catalog of juzgados and offices
+type DependenciaHandler struct {
+	service *services.DependenciaService
+}
+
+// NewDependenciaHandler creates a new dependencia handler
+func NewDependenciaHandler(service *services.DependenciaService) *DependenciaHandler {
+	return &DependenciaHandler{
+		service: service,
+	}
+}
+
+// respondDependenciaError writes a dependencia error response with its code
+func respondDependenciaError(c *gin.Context, err error) {
+	var status int
+	var code string
+	switch {
+	case errors.Is(err, repository.ErrDependenciaNotFound):
+		status, code = http.StatusNotFound, "DEPENDENCIA_NOT_FOUND"
+	case err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat:
+		status, code = http.StatusNotFound, "EXPEDIENTE_NOT_FOUND"
+	case errors.Is(err, repository.ErrDependenciaExists):
+		status, code = http.StatusConflict, "DEPENDENCIA_DUPLICADA"
+	case errors.Is(err, services.ErrDependenciaEnUso):
+		status, code = http.StatusConflict, "DEPENDENCIA_EN_USO"
+	case errors.Is(err, services.ErrDependenciaInactiva):
+		status, code = http.StatusConflict, "DEPENDENCIA_INACTIVA"
+	case errors.Is(err, services.ErrDependenciaVacia):
+		status, code = http.StatusBadRequest, "SOLICITUD_INVALIDA"
+	case errors.Is(err, services.ErrCompetenciaInvalida):
+		status, code = http.StatusBadRequest, "COMPETENCIA_INVALIDA"
+	case errors.Is(err, services.ErrPersonalInvalido):
+		status, code = http.StatusBadRequest, "USUARIO_INVALIDO"
+	default:
+		status, code = http.StatusInternalServerError, "ERROR_INTERNO"
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// GetDependencias returns the catalog ordered by code; ?activas=1 lists only the active ones
+func (h *DependenciaHandler) GetDependencias(c *gin.Context) {
+	dependencias, err := h.service.GetDependencias(c.Query("activas") == "1")
+	if err != nil {
+		respondDependenciaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dependencias,
+	})
+}
+
+// GetDependencia returns a single dependencia
+func (h *DependenciaHandler) GetDependencia(c *gin.Context) {
+	dependencia, err := h.service.GetDependencia(c.Param("id"))
+	if err != nil {
+		respondDependenciaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dependencia,
+	})
+}
+
+// CreateDependencia creates a new dependencia
+func (h *DependenciaHandler) CreateDependencia(c *gin.Context) {
+	var req models.CreateDependenciaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	dependencia, err := h.service.CreateDependencia(&req)
+	if err != nil {
+		respondDependenciaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    dependencia,
+		"message": "Dependencia creada exitosamente",
+	})
+}
+
+// UpdateDependencia updates a dependencia, including its active state
+func (h *DependenciaHandler) UpdateDependencia(c *gin.Context) {
+	var req models.UpdateDependenciaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	dependencia, err := h.service.UpdateDependencia(c.Param("id"), &req)
+	if err != nil {
+		respondDependenciaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dependencia,
+		"message": "Dependencia actualizada exitosamente",
+	})
+}
+
+// DeleteDependencia deletes a dependencia that was never used
+func (h *DependenciaHandler) DeleteDependencia(c *gin.Context) {
+	if err := h.service.DeleteDependencia(c.Param("id")); err != nil {
+		respondDependenciaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Dependencia eliminada",
+	})
+}
+
+// GetTenencia reports, per dependencia, the expedientes assigned to it and the folders it has on loan
+func (h *DependenciaHandler) GetTenencia(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	tenencia, err := h.service.GetTenencia(scope)
+	if err != nil {
+		respondDependenciaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tenencia,
+	})
+}
+
+// AsignarDependencia assigns an expediente to a dependencia or removes its assignment
+func (h *DependenciaHandler) AsignarDependencia(c *gin.Context) {
+	var req models.AsignarDependenciaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	expediente, err := h.service.AsignarExpediente(c.Param("id"), &req, scope)
+	if err != nil {
+		respondDependenciaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    expediente,
+		"message": "Dependencia del expediente actualizada exitosamente",
+	})
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DigitalizacionHandler handles the digitisation progress of expedientes
+type DigitalizacionHandler struct {
+	service *services.DigitalizacionService
+}
+
+// NewDigitalizacionHandler creates a new digitalizacion handler
+func NewDigitalizacionHandler(service *services.DigitalizacionService) *DigitalizacionHandler {
+	return &DigitalizacionHandler{
+		service: service,
+	}
+}
+
+// respondDigitalizacionError writes a digitisation error response with its code
+func respondDigitalizacionError(c *gin.Context, err error) {
+	var status int
+	var code string
+	switch {
+	case err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat:
+		status, code = http.StatusNotFound, "EXPEDIENTE_NOT_FOUND"
+	case errors.Is(err, repository.ErrTomoNotFound):
+		status, code = http.StatusNotFound, "TOMO_NOT_FOUND"
+	case errors.Is(err, services.ErrDigitalizacionEstado):
+		status, code = http.StatusBadRequest, "ESTADO_DIGITALIZACION_INVALIDO"
+	case errors.Is(err, services.ErrDigitalizacionSinCambios):
+		status, code = http.StatusBadRequest, "SIN_CAMBIOS"
+	case errors.Is(err, services.ErrDigitalizacionTomo):
+		status, code = http.StatusBadRequest, "TOMO_REQUERIDO"
+	case errors.Is(err, services.ErrOperadorInvalido):
+		status, code = http.StatusBadRequest, "OPERADOR_INVALIDO"
+	case errors.Is(err, services.ErrDigitalizacionTransicion):
+		status, code = http.StatusConflict, "TRANSICION_NO_PERMITIDA"
+	case errors.Is(err, services.ErrDigitalizacionDiscrepancia):
+		status, code = http.StatusConflict, "DISCREPANCIA_PAGINAS"
+	case errors.Is(err, repository.ErrDigitalizacionCambiada):
+		status, code = http.StatusConflict, "DIGITALIZACION_DESACTUALIZADA"
+	case errors.Is(err, services.ErrDigitalizacionPermiso):
+		status, code = http.StatusForbidden, "PERMISO_VERIFICACION"
+	case errors.Is(err, services.ErrDigitalizacionVerificador):
+		status, code = http.StatusForbidden, "VERIFICADOR_INVALIDO"
+	default:
+		status, code = http.StatusInternalServerError, "ERROR_INTERNO"
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// SearchDigitalizacion lists the digitisation work list in filing order, optionally filtered
+// by estado, operator, grado or page mismatch
+func (h *DigitalizacionHandler) SearchDigitalizacion(c *gin.Context) {
+	var params models.DigitalizacionSearchParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 || params.Limit > 100 {
+		params.Limit = 20
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	expedientes, total, err := h.service.SearchDigitalizacion(params, scope)
+	if err != nil {
+		respondDigitalizacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"expedientes": expedientes,
+			"total":       total,
+			"page":        params.Page,
+			"limit":       params.Limit,
+		},
+	})
+}
+
+// GetDigitalizacion returns the digitisation progress of an expediente and its tomos
+func (h *DigitalizacionHandler) GetDigitalizacion(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	progreso, err := h.service.GetDigitalizacion(c.Param("id"), scope)
+	if err != nil {
+		respondDigitalizacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progreso,
+	})
+}
+
+// ActualizarDigitalizacion changes the digitisation state of an expediente or records its
+// digitised pages
+func (h *DigitalizacionHandler) ActualizarDigitalizacion(c *gin.Context) {
+	var req models.UpdateDigitalizacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	progreso, err := h.service.ActualizarDigitalizacion(c.Param("id"), &req, scope)
+	if err != nil {
+		respondDigitalizacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progreso,
+		"message": "Digitalización actualizada exitosamente",
+	})
+}
+
+// AsignarDigitalizacion assigns an expediente to a digitisation operator
+func (h *DigitalizacionHandler) AsignarDigitalizacion(c *gin.Context) {
+	var req models.AsignarDigitalizacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	progreso, err := h.service.Asignar(c.Param("id"), &req, scope)
+	if err != nil {
+		respondDigitalizacionError(c, err)
+		return
+	}
+
+	message := "Operador asignado exitosamente"
+	if req.OperadorID == "" {
+		message = "Asignación retirada exitosamente"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progreso,
+		"message": message,
+	})
+}
+
+// GetResumen returns the digitisation section of the dashboard
+func (h *DigitalizacionHandler) GetResumen(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	resumen, err := h.service.GetResumen(scope)
+	if err != nil {
+		respondDigitalizacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resumen,
+	})
+}
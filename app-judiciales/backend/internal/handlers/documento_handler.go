@@ -0,0 +1,360 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"expedientes-backend/internal/storage"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentoHandler handles the files attached to expedientes
+type DocumentoHandler struct {
+	service *services.DocumentoService
+}
+
+// NewDocumentoHandler creates a new documento handler
+func NewDocumentoHandler(service *services.DocumentoService) *DocumentoHandler {
+	return &DocumentoHandler{
+		service: service,
+	}
+}
+
+// respondDocumentoError writes an attachment error response with the matching status and code
+func respondDocumentoError(c *gin.Context, err error) {
+	status, code := documentoErrorStatus(err)
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// documentoErrorStatus maps an attachment error to its HTTP status and error code
+func documentoErrorStatus(err error) (status int, code string) {
+	switch {
+	case err.Error() == ErrExpedienteNotFound:
+		status, code = http.StatusNotFound, "EXPEDIENTE_NOT_FOUND"
+	case errors.Is(err, repository.ErrDocumentoNotFound), err.Error() == ErrInvalidIDFormat:
+		status, code = http.StatusNotFound, "DOCUMENTO_NOT_FOUND"
+	case errors.Is(err, storage.ErrNotFound):
+		status, code = http.StatusNotFound, "ARCHIVO_NO_DISPONIBLE"
+	case errors.Is(err, services.ErrDocumentosCantidad):
+		status, code = http.StatusBadRequest, "CANTIDAD_ARCHIVOS"
+	case errors.Is(err, services.ErrDocumentoFormato):
+		status, code = http.StatusBadRequest, "FORMATO_NO_PERMITIDO"
+	case errors.Is(err, services.ErrDocumentoTamano):
+		status, code = http.StatusRequestEntityTooLarge, "ARCHIVO_MUY_GRANDE"
+	case errors.Is(err, services.ErrEnlaceNoDisponible):
+		status, code = http.StatusNotImplemented, "ENLACE_NO_DISPONIBLE"
+	case errors.Is(err, services.ErrVersionNotFound):
+		status, code = http.StatusNotFound, "VERSION_NOT_FOUND"
+	case errors.Is(err, services.ErrVersionActual):
+		status, code = http.StatusConflict, "VERSION_ACTUAL"
+	case errors.Is(err, repository.ErrDocumentoCambiado):
+		status, code = http.StatusConflict, "DOCUMENTO_DESACTUALIZADO"
+	case errors.Is(err, services.ErrConfidencialPermiso):
+		status, code = http.StatusForbidden, "PERMISO_CONFIDENCIAL"
+	case errors.Is(err, services.ErrVerificacionEnCurso):
+		status, code = http.StatusConflict, "VERIFICACION_EN_CURSO"
+	case errors.Is(err, services.ErrIndexacionEnCurso):
+		status, code = http.StatusConflict, "INDEXACION_EN_CURSO"
+	default:
+		status, code = http.StatusInternalServerError, "ERROR_INTERNO"
+	}
+	return status, code
+}
+
+// SubirDocumentos attaches up to five files, sent in the 'files' field, to an expediente
+func (h *DocumentoHandler) SubirDocumentos(c *gin.Context) {
+	// Límite del cuerpo completo: los archivos más un margen para los campos del formulario
+	limite := h.service.MaxTamano()*models.MaxDocumentosPorCarga + 1024*1024
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limite)
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondDocumentoError(c, services.ErrDocumentoTamano)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Archivos requeridos. Use el campo 'files' para subir hasta 5 archivos",
+		})
+		return
+	}
+	defer form.RemoveAll()
+
+	var req models.SubirDocumentosRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documentos, err := h.service.SubirDocumentos(c.Param("id"), form.File["files"], &req, scope)
+	if err != nil && len(documentos) > 0 {
+		// La carga se interrumpió: los archivos ya guardados quedan adjuntos y se informan
+		status, code := documentoErrorStatus(err)
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    code,
+			"message": fmt.Sprintf("%d de %d documentos adjuntados antes del error", len(documentos), len(form.File["files"])),
+			"data":    documentos,
+		})
+		return
+	}
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("%d documentos adjuntados", len(documentos)),
+		"data":    documentos,
+	})
+}
+
+// GetDocumentos returns the attachments of an expediente, newest first
+func (h *DocumentoHandler) GetDocumentos(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documentos, err := h.service.GetDocumentos(c.Param("id"), scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    documentos,
+	})
+}
+
+// DescargarDocumento downloads an attachment; ?vista=1 shows PDFs and images inline for preview
+func (h *DocumentoHandler) DescargarDocumento(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documento, contenido, err := h.service.AbrirDocumento(c.Param("id"), c.Param("documentoId"), scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+	defer contenido.Close()
+
+	enviarArchivo(c, documento.Nombre, documento.Formato, documento.Tamano, contenido)
+}
+
+// enviarArchivo streams a stored file with its original name; ?vista=1 shows PDFs and images inline
+func enviarArchivo(c *gin.Context, nombre string, formato models.FormatoDocumento, tamano int64, contenido io.Reader) {
+	disposicion := "attachment"
+	if c.Query("vista") == "1" && formato.Visualizable() {
+		disposicion = "inline"
+	}
+
+	c.DataFromReader(http.StatusOK, tamano, formato.ContentType(), contenido, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposicion, map[string]string{"filename": nombre}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// EnlaceDocumento returns a temporary download URL of an attachment in the object store;
+// ?vista=1 makes PDFs and images open inline
+func (h *DocumentoHandler) EnlaceDocumento(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	enlace, err := h.service.EnlaceDocumento(c.Param("id"), c.Param("documentoId"), c.Query("vista") == "1", scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    enlace,
+	})
+}
+
+// EliminarDocumento removes an attachment and its file
+func (h *DocumentoHandler) EliminarDocumento(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.EliminarDocumento(c.Param("id"), c.Param("documentoId"), scope); err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Documento eliminado",
+	})
+}
+
+// SubirVersion uploads a new version of an attachment in the 'file' field
+func (h *DocumentoHandler) SubirVersion(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.service.MaxTamano()+1024*1024)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondDocumentoError(c, services.ErrDocumentoTamano)
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Archivo requerido. Use el campo 'file' para subir la nueva versión",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documento, err := h.service.SubirVersion(c.Param("id"), c.Param("documentoId"), file, scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Versión %d guardada", documento.Version),
+		"data":    documento,
+	})
+}
+
+// GetVersiones returns the versions of an attachment, newest first
+func (h *DocumentoHandler) GetVersiones(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	versiones, err := h.service.GetVersiones(c.Param("id"), c.Param("documentoId"), scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versiones,
+	})
+}
+
+// parseVersion reads the version number of the route
+func parseVersion(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		respondDocumentoError(c, services.ErrVersionNotFound)
+		return 0, false
+	}
+	return version, true
+}
+
+// DescargarVersion downloads a version of an attachment; ?vista=1 shows PDFs and images inline
+func (h *DocumentoHandler) DescargarVersion(c *gin.Context) {
+	numero, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	version, contenido, err := h.service.AbrirVersion(c.Param("id"), c.Param("documentoId"), numero, scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+	defer contenido.Close()
+
+	enviarArchivo(c, version.Nombre, version.Formato, version.Tamano, contenido)
+}
+
+// RestaurarVersion makes a prior version of an attachment current again
+func (h *DocumentoHandler) RestaurarVersion(c *gin.Context) {
+	numero, ok := parseVersion(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documento, err := h.service.RestaurarVersion(c.Param("id"), c.Param("documentoId"), numero, scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Versión %d restaurada como versión %d", numero, documento.Version),
+		"data":    documento,
+	})
+}
+
+// SetConfidencial marks or unmarks an attachment as confidential
+func (h *DocumentoHandler) SetConfidencial(c *gin.Context) {
+	var req models.ConfidencialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documento, err := h.service.SetConfidencial(c.Param("id"), c.Param("documentoId"), *req.Confidencial, scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    documento,
+	})
+}
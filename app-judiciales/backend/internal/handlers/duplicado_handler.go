@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DuplicadoHandler handles the detection and merge of duplicate expedientes
+type DuplicadoHandler struct {
+	service *services.DuplicadoService
+}
+
+// NewDuplicadoHandler creates a new duplicado handler
+func NewDuplicadoHandler(service *services.DuplicadoService) *DuplicadoHandler {
+	return &DuplicadoHandler{
+		service: service,
+	}
+}
+
+// respondDuplicadoError writes a duplicate detection or merge error response with the matching status
+func respondDuplicadoError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat:
+		statusCode = http.StatusNotFound
+	case errors.Is(err, services.ErrUmbralInvalido),
+		errors.Is(err, services.ErrMergeMismoExpediente):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, services.ErrMergeFuera),
+		errors.Is(err, services.ErrMergeTomos):
+		statusCode = http.StatusConflict
+	}
+
+	c.JSON(statusCode, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// GetDuplicados returns the pairs of likely duplicate expedientes (?umbral=0.85)
+func (h *DuplicadoHandler) GetDuplicados(c *gin.Context) {
+	umbral := services.UmbralDuplicadosDefecto
+	if value := c.Query("umbral"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			respondDuplicadoError(c, services.ErrUmbralInvalido)
+			return
+		}
+		umbral = parsed
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	pares, err := h.service.DetectarDuplicados(umbral, scope)
+	if err != nil {
+		respondDuplicadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"duplicados": pares,
+			"total":      len(pares),
+			"umbral":     umbral,
+		},
+	})
+}
+
+// MergeExpedientes merges duplicate expedientes into a surviving one
+func (h *DuplicadoHandler) MergeExpedientes(c *gin.Context) {
+	var req models.MergeExpedientesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	resultado, err := h.service.Merge(&req, scope)
+	if err != nil {
+		respondDuplicadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Expedientes fusionados",
+		"data":    resultado,
+	})
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EstadoHandler handles the lifecycle state machine of expedientes
+type EstadoHandler struct {
+	service *services.EstadoService
+}
+
+// NewEstadoHandler creates a new estado handler
+func NewEstadoHandler(service *services.EstadoService) *EstadoHandler {
+	return &EstadoHandler{
+		service: service,
+	}
+}
+
+// estadoErrorCode maps state machine errors to an HTTP status and a stable error code
+func estadoErrorCode(err error) (int, string) {
+	switch {
+	case err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat:
+		return http.StatusNotFound, "EXPEDIENTE_NOT_FOUND"
+	case errors.Is(err, repository.ErrTransicionNotFound):
+		return http.StatusNotFound, "TRANSICION_NOT_FOUND"
+	case errors.Is(err, services.ErrEstadoInvalido):
+		return http.StatusBadRequest, "ESTADO_INVALIDO"
+	case errors.Is(err, services.ErrJustificacionRequerida):
+		return http.StatusBadRequest, "JUSTIFICACION_REQUERIDA"
+	case errors.Is(err, services.ErrPermisoInvalido):
+		return http.StatusBadRequest, "PERMISO_INVALIDO"
+	case errors.Is(err, services.ErrTransicionNoPermitida):
+		return http.StatusConflict, "TRANSICION_NO_PERMITIDA"
+	case errors.Is(err, repository.ErrEstadoCambiado):
+		return http.StatusConflict, "ESTADO_DESACTUALIZADO"
+	case errors.Is(err, repository.ErrTransicionExists):
+		return http.StatusConflict, "TRANSICION_EXISTENTE"
+	case errors.Is(err, services.ErrPermisoTransicion):
+		return http.StatusForbidden, "PERMISO_TRANSICION"
+	default:
+		return http.StatusInternalServerError, "ERROR_INTERNO"
+	}
+}
+
+// respondEstadoError writes a state machine error response with its code
+func respondEstadoError(c *gin.Context, err error) {
+	status, code := estadoErrorCode(err)
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// CambiarEstado moves an expediente to another lifecycle state
+func (h *EstadoHandler) CambiarEstado(c *gin.Context) {
+	var req models.UpdateEstadoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	cambio, err := h.service.CambiarEstado(c.Param("id"), &req, scope)
+	if err != nil {
+		respondEstadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "estado updated successfully",
+		"data":    cambio,
+	})
+}
+
+// GetHistorial returns the state history of an expediente
+func (h *EstadoHandler) GetHistorial(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	historial, err := h.service.GetHistorial(c.Param("id"), scope)
+	if err != nil {
+		respondEstadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    historial,
+	})
+}
+
+// GetTransiciones returns the configured state machine
+func (h *EstadoHandler) GetTransiciones(c *gin.Context) {
+	transiciones, err := h.service.GetTransiciones()
+	if err != nil {
+		respondEstadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"estados":      models.EstadosExpediente(),
+			"transiciones": transiciones,
+		},
+	})
+}
+
+// CreateTransicion allows a new state transition
+func (h *EstadoHandler) CreateTransicion(c *gin.Context) {
+	var req models.CreateTransicionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	transicion, err := h.service.CreateTransicion(&req)
+	if err != nil {
+		respondEstadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    transicion,
+	})
+}
+
+// UpdateTransicion changes the permission guarding a state transition
+func (h *EstadoHandler) UpdateTransicion(c *gin.Context) {
+	var req models.UpdateTransicionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	transicion, err := h.service.UpdateTransicion(c.Param("id"), &req)
+	if err != nil {
+		respondEstadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    transicion,
+	})
+}
+
+// DeleteTransicion removes a state transition
+func (h *EstadoHandler) DeleteTransicion(c *gin.Context) {
+	if err := h.service.DeleteTransicion(c.Param("id")); err != nil {
+		respondEstadoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Transición eliminada",
+	})
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EtiquetaHandler handles the printable labels of folders and shelves
+type EtiquetaHandler struct {
+	service *services.EtiquetaService
+}
+
+// NewEtiquetaHandler creates a new etiqueta handler
+func NewEtiquetaHandler(service *services.EtiquetaService) *EtiquetaHandler {
+	return &EtiquetaHandler{
+		service: service,
+	}
+}
+
+// respondEtiquetaError writes a label generation error response with the matching status
+func respondEtiquetaError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, repository.ErrEstanteNotFound),
+		errors.Is(err, repository.ErrDivisionNotFound),
+		errors.Is(err, services.ErrSinEtiquetas):
+		statusCode = http.StatusNotFound
+	case err.Error() == ErrInvalidIDFormat,
+		errors.Is(err, services.ErrFormatoEtiqueta),
+		errors.Is(err, services.ErrSimbologiaEtiqueta):
+		statusCode = http.StatusBadRequest
+	}
+
+	c.JSON(statusCode, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// sendEtiquetas writes a generated label file as a download
+func sendEtiquetas(c *gin.Context, documento *models.DocumentoEtiquetas) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", documento.Nombre))
+	c.Header("X-Total-Etiquetas", fmt.Sprintf("%d", documento.Etiquetas))
+	c.Data(http.StatusOK, documento.ContentType, documento.Contenido)
+}
+
+// GenerarEtiquetas returns the folder labels of the selected expedientes
+func (h *EtiquetaHandler) GenerarEtiquetas(c *gin.Context) {
+	var req models.EtiquetasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documento, err := h.service.EtiquetasExpedientes(&req, scope)
+	if err != nil {
+		respondEtiquetaError(c, err)
+		return
+	}
+
+	sendEtiquetas(c, documento)
+}
+
+// GetEtiquetasDivision returns the folder labels of every expediente in a division (?formato=pdf&simbologia=code128)
+func (h *EtiquetaHandler) GetEtiquetasDivision(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	documento, err := h.service.EtiquetasDivision(c.Param("id"), models.FormatoEtiqueta(c.Query("formato")), models.SimbologiaEtiqueta(c.Query("simbologia")), scope)
+	if err != nil {
+		respondEtiquetaError(c, err)
+		return
+	}
+
+	sendEtiquetas(c, documento)
+}
+
+// GetEtiquetasEstante returns one shelf-end label per division of a shelf (?formato=pdf&simbologia=code128)
+func (h *EtiquetaHandler) GetEtiquetasEstante(c *gin.Context) {
+	documento, err := h.service.EtiquetasEstante(c.Param("id"), models.FormatoEtiqueta(c.Query("formato")), models.SimbologiaEtiqueta(c.Query("simbologia")))
+	if err != nil {
+		respondEtiquetaError(c, err)
+		return
+	}
+
+	sendEtiquetas(c, documento)
+}
@@ -750,7 +750,12 @@ func (h *ExpedienteHandler) GetExpedientes(c *gin.Context) {
 		}
 	}
 
-	expedientes, total, err := h.service.GetAll(page, limit, sortBy, sortOrder)
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	expedientes, total, err := h.service.GetAll(page, limit, sortBy, sortOrder, scope)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -778,7 +783,12 @@ func (h *ExpedienteHandler) GetExpedientes(c *gin.Context) {
 func (h *ExpedienteHandler) GetExpediente(c *gin.Context) {
 	id := c.Param("id")
 
-	expediente, err := h.service.GetByID(id)
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	expediente, err := h.service.GetByID(id, scope)
 	if err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat {
@@ -807,23 +817,15 @@ func (h *ExpedienteHandler) CreateExpediente(c *gin.Context) {
 		return
 	}
 
-	// Get user ID from context (set by auth middleware)
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   ErrUserNotAuthenticated,
-		})
+	scope, ok := getAccessScope(c)
+	if !ok {
 		return
 	}
+	userObjID := scope.UserID
 
-	userObjID, err := primitive.ObjectIDFromHex(userID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   ErrInvalidUserID,
-		})
-		return
+	clasificacion := req.Clasificacion
+	if clasificacion == "" {
+		clasificacion = models.ClasificacionPublico
 	}
 
 	expediente := &models.Expediente{
@@ -833,16 +835,20 @@ func (h *ExpedienteHandler) CreateExpediente(c *gin.Context) {
 		SituacionMilitar: req.SituacionMilitar,
 		CIP:              req.CIP,
 		Ubicacion:        req.Ubicacion,
-		Orden:            req.Orden,
 		Ano:              req.Ano,
+		Clasificacion:    clasificacion,
 		CreatedBy:        userObjID,
 		UpdatedBy:        userObjID,
 	}
 
-	if err := h.service.Create(expediente); err != nil {
+	if err := h.service.Create(expediente, scope); err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "expediente with this CIP already exists" {
 			statusCode = http.StatusConflict
+		} else if errors.Is(err, services.ErrClasificacionSuperior) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, services.ErrCIPInvalido) {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, gin.H{
 			"success": false,
@@ -868,12 +874,12 @@ func (h *ExpedienteHandler) UpdateExpediente(c *gin.Context) {
 		return
 	}
 
-	userObjID, err := h.getUserIDFromContext(c)
-	if err != nil {
-		return // Error already handled in helper
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
 	}
 
-	updates := h.buildUpdateMap(&req, userObjID)
+	updates := h.buildUpdateMap(&req, scope.UserID)
 	if len(updates) == 1 { // Only updatedBy
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -882,11 +888,11 @@ func (h *ExpedienteHandler) UpdateExpediente(c *gin.Context) {
 		return
 	}
 
-	if err := h.updateExpedienteInService(c, id, updates); err != nil {
+	if err := h.updateExpedienteInService(c, id, updates, scope); err != nil {
 		return // Error already handled in helper
 	}
 
-	h.respondWithUpdatedExpediente(c, id)
+	h.respondWithUpdatedExpediente(c, id, scope)
 }
 
 // getUserIDFromContext extracts and validates user ID from gin context
@@ -912,6 +918,49 @@ func (h *ExpedienteHandler) getUserIDFromContext(c *gin.Context) (primitive.Obje
 	return userObjID, nil
 }
 
+// getAccessScope builds the caller's access scope from gin context, responding with an error if the user is missing
+func getAccessScope(c *gin.Context) (models.AccessScope, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   ErrUserNotAuthenticated,
+		})
+		return models.AccessScope{}, false
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   ErrInvalidUserID,
+		})
+		return models.AccessScope{}, false
+	}
+
+	scope := models.AccessScope{
+		UserID:    userObjID,
+		IP:        c.ClientIP(),
+		Clearance: models.ClasificacionPublico,
+	}
+	if email, ok := c.Get("userEmail"); ok {
+		scope.Email, _ = email.(string)
+	}
+	if clearance, ok := c.Get("userClearance"); ok {
+		if value, ok := clearance.(models.Clasificacion); ok {
+			scope.Clearance = value
+		}
+	}
+	if grants, ok := c.Get("userBreakGlass"); ok {
+		scope.BreakGlass, _ = grants.([]models.BreakGlassGrant)
+	}
+	if permissions, ok := c.Get("userPermissions"); ok {
+		scope.Permissions, _ = permissions.([]models.Permission)
+	}
+
+	return scope, true
+}
+
 // buildUpdateMap builds the updates map from request
 func (h *ExpedienteHandler) buildUpdateMap(req *models.UpdateExpedienteRequest, userObjID primitive.ObjectID) map[string]interface{} {
 	updates := make(map[string]interface{})
@@ -937,9 +986,6 @@ func (h *ExpedienteHandler) buildUpdateMap(req *models.UpdateExpedienteRequest,
 	if req.Ubicacion != nil {
 		updates["ubicacion"] = *req.Ubicacion
 	}
-	if req.Orden != nil {
-		updates["orden"] = *req.Orden
-	}
 	if req.Ano != nil {
 		updates["ano"] = *req.Ano
 	}
@@ -949,13 +995,15 @@ func (h *ExpedienteHandler) buildUpdateMap(req *models.UpdateExpedienteRequest,
 }
 
 // updateExpedienteInService updates expediente using service
-func (h *ExpedienteHandler) updateExpedienteInService(c *gin.Context, id string, updates map[string]interface{}) error {
-	if err := h.service.Update(id, updates); err != nil {
+func (h *ExpedienteHandler) updateExpedienteInService(c *gin.Context, id string, updates map[string]interface{}, scope models.AccessScope) error {
+	if err := h.service.Update(id, updates, scope); err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == ErrExpedienteNotFound {
 			statusCode = http.StatusNotFound
-		} else if err.Error() == "expediente with this CIP already exists" {
+		} else if err.Error() == "expediente with this CIP already exists" || errors.Is(err, services.ErrEstadoRequiereTransicion) || errors.Is(err, services.ErrPaginasPorTomos) || errors.Is(err, services.ErrCarreraRequiereEvento) {
 			statusCode = http.StatusConflict
+		} else if errors.Is(err, services.ErrCIPInvalido) {
+			statusCode = http.StatusBadRequest
 		}
 		c.JSON(statusCode, gin.H{
 			"success": false,
@@ -967,8 +1015,8 @@ func (h *ExpedienteHandler) updateExpedienteInService(c *gin.Context, id string,
 }
 
 // respondWithUpdatedExpediente sends response with updated expediente data
-func (h *ExpedienteHandler) respondWithUpdatedExpediente(c *gin.Context, id string) {
-	expediente, err := h.service.GetByID(id)
+func (h *ExpedienteHandler) respondWithUpdatedExpediente(c *gin.Context, id string, scope models.AccessScope) {
+	expediente, err := h.service.GetByID(id, scope)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -985,17 +1033,12 @@ func (h *ExpedienteHandler) respondWithUpdatedExpediente(c *gin.Context, id stri
 func (h *ExpedienteHandler) DeleteExpediente(c *gin.Context) {
 	id := c.Param("id")
 
-	// Get user ID from context
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   ErrUserNotAuthenticated,
-		})
+	scope, ok := getAccessScope(c)
+	if !ok {
 		return
 	}
 
-	if err := h.service.Delete(id, userID.(string)); err != nil {
+	if err := h.service.Delete(id, scope); err != nil {
 		statusCode := http.StatusInternalServerError
 		if err.Error() == "expediente not found or already deleted" || err.Error() == ErrInvalidIDFormat {
 			statusCode = http.StatusNotFound
@@ -1012,55 +1055,20 @@ func (h *ExpedienteHandler) DeleteExpediente(c *gin.Context) {
 		"message": "expediente deleted successfully",
 	})
 }
-func (h *ExpedienteHandler) UpdateEstado(c *gin.Context) {
-	id := c.Param("id")
-
-	var req struct {
-		Estado models.EstadoExpediente `json:"estado" binding:"required,oneof=dentro fuera"`
-	}
+func (h *ExpedienteHandler) SearchExpedientes(c *gin.Context) {
+	var params models.ExpedienteSearchParams
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := c.ShouldBindQuery(&params); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"error":   err.Error(),
 		})
 		return
 	}
-
-	// Get user ID from context
-	userID, exists := c.Get("userID")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"success": false,
-			"error":   ErrUserNotAuthenticated,
-		})
-		return
-	}
-
-	if err := h.service.UpdateEstado(id, req.Estado, userID.(string)); err != nil {
-		statusCode := http.StatusInternalServerError
-		if err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat {
-			statusCode = http.StatusNotFound
-		}
-		c.JSON(statusCode, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "estado updated successfully",
-	})
-}
-func (h *ExpedienteHandler) SearchExpedientes(c *gin.Context) {
-	var params models.ExpedienteSearchParams
-
-	if err := c.ShouldBindQuery(&params); err != nil {
+	if params.DependenciaID != "" && !primitive.IsValidObjectID(params.DependenciaID) {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"error":   err.Error(),
+			"error":   "dependencia_id inválido",
 		})
 		return
 	}
@@ -1079,7 +1087,12 @@ func (h *ExpedienteHandler) SearchExpedientes(c *gin.Context) {
 		params.SortOrder = "asc"
 	}
 
-	expedientes, total, err := h.service.Search(params)
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	expedientes, total, err := h.service.Search(params, scope)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -1116,7 +1129,12 @@ func (h *ExpedienteHandler) GetExpedientesByDivision(c *gin.Context) {
 		return
 	}
 
-	expedientes, err := h.service.GetExpedientesByDivision(divisionRange)
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	expedientes, err := h.service.GetExpedientesByDivision(divisionRange, scope)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -1138,7 +1156,12 @@ func (h *ExpedienteHandler) GetExpedientesByDivision(c *gin.Context) {
 // ExportExpedientesExcel exports all expedientes (minimal fields) as an Excel file
 func (h *ExpedienteHandler) ExportExpedientesExcel(c *gin.Context) {
 	// Only authorized users reach this point (route protected by middleware)
-	records, err := h.service.ExportAll()
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	records, err := h.service.ExportAll(scope)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": err.Error()})
 		return
@@ -1161,7 +1184,7 @@ func (h *ExpedienteHandler) ExportExpedientesExcel(c *gin.Context) {
 	f.SetActiveSheet(index)
 
 	// Set headers
-	headers := []string{"Grado", "CIP", "ApellidosNombres", "NumeroPaginas", "Ano"}
+	headers := []string{"Grado", "CIP", "ApellidosNombres", "NumeroPaginas", "Ano", "Clasificacion"}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, header)
@@ -1184,6 +1207,7 @@ func (h *ExpedienteHandler) ExportExpedientesExcel(c *gin.Context) {
 		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), record.ApellidosNombres)
 		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), record.NumeroPaginas)
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), record.Ano)
+		f.SetCellValue(sheetName, fmt.Sprintf("F%d", row), string(record.Clasificacion))
 	}
 
 	// Auto-fit columns
@@ -1308,8 +1332,13 @@ func generateImportSummaryMessage(result *models.BulkImportResult) string {
 func (h *ExpedienteHandler) GetDashboardStats(c *gin.Context) {
 	log.Printf("📊 Getting dashboard statistics...")
 
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
 	// Get dashboard statistics
-	stats, err := h.service.GetDashboardStats()
+	stats, err := h.service.GetDashboardStats(scope)
 	if err != nil {
 		log.Printf("❌ Error getting dashboard stats: %v", err)
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
@@ -1334,3 +1363,93 @@ func (h *ExpedienteHandler) GetDashboardStats(c *gin.Context) {
 		Data:    stats,
 	})
 }
+
+// UpdateClasificacion changes the classification of an expediente
+func (h *ExpedienteHandler) UpdateClasificacion(c *gin.Context) {
+	id := c.Param("id")
+
+	var req models.UpdateClasificacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.UpdateClasificacion(id, req, scope); err != nil {
+		statusCode := http.StatusInternalServerError
+		if err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, services.ErrClasificacionSuperior) {
+			statusCode = http.StatusForbidden
+		} else if errors.Is(err, services.ErrClasificacionJustificacion) {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	h.respondWithUpdatedExpediente(c, id, scope)
+}
+
+// Reordenar recomputes the filing order of the whole archive and reports the expedientes that moved
+func (h *ExpedienteHandler) Reordenar(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	resultado, err := h.service.Reordenar(scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resultado,
+	})
+}
+
+// GetClassifiedAccessLog lists the separate access log for classified expedientes
+func (h *ExpedienteHandler) GetClassifiedAccessLog(c *gin.Context) {
+	page := 1
+	limit := 20
+	if parsed, err := strconv.Atoi(c.Query("page")); err == nil && parsed > 0 {
+		page = parsed
+	}
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 && parsed <= 100 {
+		limit = parsed
+	}
+
+	entries, total, err := h.service.GetClassifiedAccessLog(c.Query("expediente_id"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"registros": entries,
+			"total":     total,
+			"page":      page,
+			"limit":     limit,
+		},
+	})
+}
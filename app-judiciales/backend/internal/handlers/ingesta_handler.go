@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IngestaHandler handles the log of the hot-folder ingest
+type IngestaHandler struct {
+	service *services.IngestaService
+}
+
+// NewIngestaHandler creates a new ingesta handler
+func NewIngestaHandler(service *services.IngestaService) *IngestaHandler {
+	return &IngestaHandler{
+		service: service,
+	}
+}
+
+// GetIngestas lists the files processed by the ingest, newest first, optionally filtered by
+// estado or CIP
+func (h *IngestaHandler) GetIngestas(c *gin.Context) {
+	page := 1
+	limit := 20
+	if parsed, err := strconv.Atoi(c.Query("page")); err == nil && parsed > 0 {
+		page = parsed
+	}
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 && parsed <= 100 {
+		limit = parsed
+	}
+
+	estado := models.EstadoIngesta(c.Query("estado"))
+	if estado != "" && !models.ValidEstadoIngesta(estado) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Estado inválido. Use importado, nueva_version, duplicado o cuarentena",
+		})
+		return
+	}
+
+	ingestas, total, err := h.service.GetIngestas(estado, strings.TrimSpace(c.Query("cip")), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"ingestas": ingestas,
+			"total":    total,
+			"page":     page,
+			"limit":    limit,
+			"activa":   h.service.Activa(),
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntegridadHandler handles the fixity checks of attached files
+type IntegridadHandler struct {
+	service *services.IntegridadService
+}
+
+// NewIntegridadHandler creates a new integridad handler
+func NewIntegridadHandler(service *services.IntegridadService) *IntegridadHandler {
+	return &IntegridadHandler{
+		service: service,
+	}
+}
+
+// GetIntegridadExpediente returns the integrity status of the attachments of an expediente
+func (h *IntegridadHandler) GetIntegridadExpediente(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	integridad, err := h.service.GetIntegridadExpediente(c.Param("id"), scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    integridad,
+	})
+}
+
+// VerificarIntegridad starts a fixity run over every stored file without waiting for it
+func (h *IntegridadHandler) VerificarIntegridad(c *gin.Context) {
+	if err := h.service.VerificarEnSegundoPlano(); err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Verificación de integridad iniciada; el resultado queda en el estado de cada expediente",
+	})
+}
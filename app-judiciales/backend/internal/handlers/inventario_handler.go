@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// InventarioHandler handles the physical inventory sessions of the archive
+type InventarioHandler struct {
+	service *services.InventarioService
+}
+
+// NewInventarioHandler creates a new inventario handler
+func NewInventarioHandler(service *services.InventarioService) *InventarioHandler {
+	return &InventarioHandler{
+		service: service,
+	}
+}
+
+// respondInventarioError writes an inventory error response with the matching status
+func respondInventarioError(c *gin.Context, err error) {
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, repository.ErrInventarioNotFound),
+		errors.Is(err, repository.ErrEstanteNotFound),
+		errors.Is(err, repository.ErrDivisionNotFound):
+		statusCode = http.StatusNotFound
+	case err.Error() == ErrInvalidIDFormat,
+		errors.Is(err, services.ErrAlcanceInventario),
+		errors.Is(err, services.ErrInventarioSinDivisiones),
+		errors.Is(err, services.ErrDivisionRequerida),
+		errors.Is(err, services.ErrDivisionFueraInventario):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, repository.ErrInventarioEstado):
+		statusCode = http.StatusConflict
+	}
+
+	c.JSON(statusCode, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// CreateInventario starts an inventory session over a shelf or a division
+func (h *InventarioHandler) CreateInventario(c *gin.Context) {
+	var req models.CreateInventarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	inventario, err := h.service.CrearInventario(&req, scope)
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    inventario,
+	})
+}
+
+// GetInventarios returns the inventory sessions, newest first (?estado=abierto)
+func (h *InventarioHandler) GetInventarios(c *gin.Context) {
+	inventarios, err := h.service.GetInventarios(models.EstadoInventario(c.Query("estado")))
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    inventarios,
+	})
+}
+
+// GetInventario returns an inventory session with its scans
+func (h *InventarioHandler) GetInventario(c *gin.Context) {
+	inventario, err := h.service.GetInventario(c.Param("id"))
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    inventario,
+	})
+}
+
+// RegistrarLecturas records scanned codes in an open session
+func (h *InventarioHandler) RegistrarLecturas(c *gin.Context) {
+	var req models.LecturasInventarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	resultados, err := h.service.RegistrarLecturas(c.Param("id"), &req, scope)
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resultados,
+	})
+}
+
+// PausarInventario pauses an open session
+func (h *InventarioHandler) PausarInventario(c *gin.Context) {
+	inventario, err := h.service.PausarInventario(c.Param("id"))
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Inventario pausado",
+		"data":    inventario,
+	})
+}
+
+// ReanudarInventario resumes a paused session
+func (h *InventarioHandler) ReanudarInventario(c *gin.Context) {
+	inventario, err := h.service.ReanudarInventario(c.Param("id"))
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Inventario reanudado",
+		"data":    inventario,
+	})
+}
+
+// CerrarInventario closes a session and returns its final report
+func (h *InventarioHandler) CerrarInventario(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	reporte, err := h.service.CerrarInventario(c.Param("id"), scope)
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Inventario cerrado",
+		"data":    reporte,
+	})
+}
+
+// GetReporte returns the reconciliation report of a session
+func (h *InventarioHandler) GetReporte(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	reporte, err := h.service.GetReporte(c.Param("id"), "reporte_inventario", scope)
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    reporte,
+	})
+}
+
+// describirDivisionExcel formats a division of an inventory report for a spreadsheet cell
+func describirDivisionExcel(division *models.DivisionInventario) string {
+	if division == nil {
+		return ""
+	}
+	return fmt.Sprintf("Estante %d, división %d (%s)", division.Estante, division.Numero, division.Rango)
+}
+
+// ExportReporteExcel downloads the reconciliation report of a session as an Excel file
+func (h *InventarioHandler) ExportReporteExcel(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	reporte, err := h.service.GetReporte(c.Param("id"), "exportacion_reporte_inventario", scope)
+	if err != nil {
+		respondInventarioError(c, err)
+		return
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Error closing Excel file: %v", err)
+		}
+	}()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#CCE5FF"}, Pattern: 1},
+	})
+	if err != nil {
+		headerStyle = 0
+	}
+
+	sheetName := "Hallazgos"
+	if err := f.SetSheetName(f.GetSheetName(0), sheetName); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating Excel sheet"})
+		return
+	}
+	rows := make([][]interface{}, len(reporte.Hallazgos))
+	for i, hallazgo := range reporte.Hallazgos {
+		tomo := interface{}("")
+		if hallazgo.Tomo > 0 {
+			tomo = hallazgo.Tomo
+		}
+		rows[i] = []interface{}{string(hallazgo.Tipo), hallazgo.Codigo, hallazgo.CIP, hallazgo.ApellidosNombres,
+			string(hallazgo.Grado), tomo, hallazgo.Ubicacion, string(hallazgo.Estado),
+			describirDivisionExcel(hallazgo.DivisionLeida), describirDivisionExcel(hallazgo.DivisionEsperada)}
+	}
+	writeExcelSheet(f, sheetName, headerStyle, []string{"Tipo", "Codigo", "CIP", "ApellidosNombres", "Grado", "Tomo",
+		"Ubicacion", "Estado", "DivisionLeida", "DivisionEsperada"}, rows)
+
+	sheetName = "Resumen"
+	if _, err := f.NewSheet(sheetName); err == nil {
+		resumen := reporte.Resumen
+		writeExcelSheet(f, sheetName, headerStyle, []string{"Inventario", "Estado", "Esperados", "Leidos", "Encontrados",
+			"Faltantes", "MalUbicados", "Desconocidos", "FueraPresentes", "Generado"}, [][]interface{}{{
+			reporte.Descripcion, string(reporte.Estado), resumen.Esperados, resumen.Leidos, resumen.Encontrados,
+			resumen.Faltantes, resumen.MalUbicados, resumen.Desconocidos, resumen.FueraPresentes,
+			reporte.GeneradoEn.Format("2006-01-02 15:04:05"),
+		}})
+	}
+
+	filename := fmt.Sprintf("inventario_%s_%s.xlsx", reporte.InventarioID.Hex(), reporte.GeneradoEn.Format("20060102_150405"))
+
+	// Set headers for Excel download
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Status(http.StatusOK)
+
+	if err := f.Write(c.Writer); err != nil {
+		log.Printf("Error writing Excel file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating Excel file"})
+		return
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MovimientoHandler handles the register of movements (actuaciones) of expedientes
+type MovimientoHandler struct {
+	service *services.MovimientoService
+}
+
+// NewMovimientoHandler creates a new movimiento handler
+func NewMovimientoHandler(service *services.MovimientoService) *MovimientoHandler {
+	return &MovimientoHandler{
+		service: service,
+	}
+}
+
+// respondMovimientoError writes a movement error response with its code
+func respondMovimientoError(c *gin.Context, err error) {
+	var status int
+	var code string
+	switch {
+	case err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat:
+		status, code = http.StatusNotFound, "EXPEDIENTE_NOT_FOUND"
+	case errors.Is(err, repository.ErrMovimientoNotFound):
+		status, code = http.StatusNotFound, "MOVIMIENTO_NOT_FOUND"
+	case errors.Is(err, services.ErrTipoMovimiento):
+		status, code = http.StatusBadRequest, "TIPO_MOVIMIENTO_INVALIDO"
+	case errors.Is(err, services.ErrUsuarioFiltro):
+		status, code = http.StatusBadRequest, "USUARIO_INVALIDO"
+	case errors.Is(err, services.ErrMovimientoCorregido):
+		status, code = http.StatusBadRequest, "MOVIMIENTO_CORREGIDO_INEXISTENTE"
+	case errors.Is(err, services.ErrDocumentoMovimiento):
+		status, code = http.StatusBadRequest, "DOCUMENTO_INVALIDO"
+	case errors.Is(err, repository.ErrNumeracionOcupada):
+		status, code = http.StatusConflict, "NUMERACION_OCUPADA"
+	default:
+		status, code = http.StatusInternalServerError, "ERROR_INTERNO"
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// bindMovimientoSearch reads the movement filters and pagination from the query string
+func bindMovimientoSearch(c *gin.Context) (models.MovimientoSearchParams, bool) {
+	var params models.MovimientoSearchParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return params, false
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 || params.Limit > 100 {
+		params.Limit = 20
+	}
+	return params, true
+}
+
+// RegistrarMovimiento records a movement on an expediente
+func (h *MovimientoHandler) RegistrarMovimiento(c *gin.Context) {
+	var req models.CreateMovimientoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	movimiento, err := h.service.Registrar(c.Param("id"), &req, scope)
+	if err != nil {
+		respondMovimientoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    movimiento,
+		"message": "Movimiento registrado exitosamente",
+	})
+}
+
+// GetMovimientos lists the movements of an expediente in numbering order, optionally
+// filtered by tipo, date range or registering user
+func (h *MovimientoHandler) GetMovimientos(c *gin.Context) {
+	params, ok := bindMovimientoSearch(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	movimientos, total, err := h.service.GetMovimientos(c.Param("id"), params, scope)
+	if err != nil {
+		respondMovimientoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"movimientos": movimientos,
+			"total":       total,
+			"page":        params.Page,
+			"limit":       params.Limit,
+		},
+	})
+}
+
+// GetMovimiento returns a movement of an expediente by its number
+func (h *MovimientoHandler) GetMovimiento(c *gin.Context) {
+	numero, err := strconv.Atoi(c.Param("numero"))
+	if err != nil || numero < 1 {
+		respondMovimientoError(c, repository.ErrMovimientoNotFound)
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	movimiento, err := h.service.GetMovimiento(c.Param("id"), numero, scope)
+	if err != nil {
+		respondMovimientoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    movimiento,
+	})
+}
+
+// SearchMovimientos lists the movements of every readable expediente, newest first
+func (h *MovimientoHandler) SearchMovimientos(c *gin.Context) {
+	params, ok := bindMovimientoSearch(c)
+	if !ok {
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	movimientos, total, err := h.service.SearchMovimientos(params, scope)
+	if err != nil {
+		respondMovimientoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"movimientos": movimientos,
+			"total":       total,
+			"page":        params.Page,
+			"limit":       params.Limit,
+		},
+	})
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificacionHandler handles the notifications of the movements of expedientes
+type NotificacionHandler struct {
+	service *services.NotificacionService
+}
+
+// NewNotificacionHandler creates a new notificacion handler
+func NewNotificacionHandler(service *services.NotificacionService) *NotificacionHandler {
+	return &NotificacionHandler{
+		service: service,
+	}
+}
+
+// respondNotificacionError writes a notification error response with its code
+func respondNotificacionError(c *gin.Context, err error) {
+	var status int
+	var code string
+	switch {
+	case err.Error() == ErrExpedienteNotFound || err.Error() == ErrInvalidIDFormat:
+		status, code = http.StatusNotFound, "EXPEDIENTE_NOT_FOUND"
+	case errors.Is(err, repository.ErrMovimientoNotFound):
+		status, code = http.StatusNotFound, "MOVIMIENTO_NOT_FOUND"
+	case errors.Is(err, repository.ErrNotificacionNotFound):
+		status, code = http.StatusNotFound, "NOTIFICACION_NOT_FOUND"
+	case errors.Is(err, services.ErrMetodoNotificacion):
+		status, code = http.StatusBadRequest, "METODO_NOTIFICACION_INVALIDO"
+	case errors.Is(err, services.ErrEmailNotificacion):
+		status, code = http.StatusBadRequest, "EMAIL_REQUERIDO"
+	case errors.Is(err, services.ErrFechaNotificacion):
+		status, code = http.StatusBadRequest, "FECHA_INVALIDA"
+	case errors.Is(err, services.ErrReenvioNotificacion):
+		status, code = http.StatusBadRequest, "REENVIO_INVALIDO"
+	case errors.Is(err, repository.ErrNotificacionCambiada):
+		status, code = http.StatusConflict, "NOTIFICACION_NO_PENDIENTE"
+	case errors.Is(err, services.ErrEmailNoDisponible):
+		status, code = http.StatusNotImplemented, "EMAIL_NO_CONFIGURADO"
+	default:
+		status, code = http.StatusInternalServerError, "ERROR_INTERNO"
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// numeroMovimiento reads the movement number of the path, writing the not found response
+// when it is not a positive number
+func numeroMovimiento(c *gin.Context) (int, bool) {
+	numero, err := strconv.Atoi(c.Param("numero"))
+	if err != nil || numero < 1 {
+		respondNotificacionError(c, repository.ErrMovimientoNotFound)
+		return 0, false
+	}
+	return numero, true
+}
+
+// RegistrarNotificacion records a notification of a movement. Email notifications are sent
+// in the background.
+func (h *NotificacionHandler) RegistrarNotificacion(c *gin.Context) {
+	numero, ok := numeroMovimiento(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateNotificacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	notificacion, err := h.service.Registrar(c.Param("id"), numero, &req, scope)
+	if err != nil {
+		respondNotificacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    notificacion,
+		"message": "Notificación registrada exitosamente",
+	})
+}
+
+// GetNotificacionesMovimiento lists the notifications of a movement
+func (h *NotificacionHandler) GetNotificacionesMovimiento(c *gin.Context) {
+	numero, ok := numeroMovimiento(c)
+	if !ok {
+		return
+	}
+	h.listar(c, numero)
+}
+
+// GetNotificaciones lists the notifications of every movement of an expediente
+func (h *NotificacionHandler) GetNotificaciones(c *gin.Context) {
+	h.listar(c, 0)
+}
+
+func (h *NotificacionHandler) listar(c *gin.Context, numero int) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	notificaciones, err := h.service.GetNotificaciones(c.Param("id"), numero, scope)
+	if err != nil {
+		respondNotificacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notificaciones,
+	})
+}
+
+// ActualizarEstado marks a pending notification as delivered or returned
+func (h *NotificacionHandler) ActualizarEstado(c *gin.Context) {
+	var req models.UpdateEstadoNotificacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	notificacion, err := h.service.ActualizarEstado(c.Param("id"), c.Param("notificacionId"), &req, scope)
+	if err != nil {
+		respondNotificacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    notificacion,
+		"message": "Estado de la notificación actualizado exitosamente",
+	})
+}
+
+// ReenviarNotificacion sends a pending email notification again
+func (h *NotificacionHandler) ReenviarNotificacion(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	notificacion, err := h.service.Reenviar(c.Param("id"), c.Param("notificacionId"), scope)
+	if err != nil {
+		respondNotificacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"data":    notificacion,
+		"message": "Reenvío de la notificación en curso",
+	})
+}
+
+// GetPendientes reports the notifications pending for at least dias days (7 by default)
+// across the readable expedientes, oldest first
+func (h *NotificacionHandler) GetPendientes(c *gin.Context) {
+	var params models.NotificacionPendienteParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 || params.Limit > 100 {
+		params.Limit = 20
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	notificaciones, total, err := h.service.GetPendientes(params, scope)
+	if err != nil {
+		respondNotificacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"notificaciones": notificaciones,
+			"total":          total,
+			"page":           params.Page,
+			"limit":          params.Limit,
+		},
+	})
+}
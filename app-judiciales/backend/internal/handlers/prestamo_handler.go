@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrestamoHandler handles folder loans registered by scanning labels
+type PrestamoHandler struct {
+	service *services.PrestamoService
+}
+
+// NewPrestamoHandler creates a new prestamo handler
+func NewPrestamoHandler(service *services.PrestamoService) *PrestamoHandler {
+	return &PrestamoHandler{
+		service: service,
+	}
+}
+
+// respondPrestamoError writes a loan error response with its code
+func respondPrestamoError(c *gin.Context, err error) {
+	var status int
+	var code string
+	switch {
+	case errors.Is(err, repository.ErrTomoNotFound):
+		status, code = http.StatusNotFound, "TOMO_NOT_FOUND"
+	case errors.Is(err, repository.ErrDivisionNotFound):
+		status, code = http.StatusNotFound, "DIVISION_NOT_FOUND"
+	case errors.Is(err, repository.ErrDependenciaNotFound):
+		status, code = http.StatusNotFound, "DEPENDENCIA_NOT_FOUND"
+	case errors.Is(err, services.ErrDependenciaInactiva):
+		status, code = http.StatusConflict, "DEPENDENCIA_INACTIVA"
+	case errors.Is(err, services.ErrCodigoInvalido):
+		status, code = http.StatusBadRequest, "CODIGO_INVALIDO"
+	case errors.Is(err, services.ErrPrestatarioRequerido):
+		status, code = http.StatusBadRequest, "PRESTATARIO_REQUERIDO"
+	case errors.Is(err, services.ErrPrestamoAjeno):
+		status, code = http.StatusConflict, "PRESTAMO_AJENO"
+	default:
+		status, code = estadoErrorCode(err)
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// Escanear lends or returns the folder behind a scanned label code
+func (h *PrestamoHandler) Escanear(c *gin.Context) {
+	var req models.EscaneoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	resultado, err := h.service.Escanear(&req, scope)
+	if err != nil {
+		respondPrestamoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    resultado,
+	})
+}
+
+// GetPrestamos returns the loan history of an expediente
+func (h *PrestamoHandler) GetPrestamos(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	prestamos, err := h.service.GetPrestamos(c.Param("id"), scope)
+	if err != nil {
+		respondPrestamoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    prestamos,
+	})
+}
+
+// GetPrestamosAbiertos returns the folders currently on loan, oldest first, optionally only
+// those held by the dependencia_id of the query
+func (h *PrestamoHandler) GetPrestamosAbiertos(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	prestamos, err := h.service.GetPrestamosAbiertos(c.Query("dependencia_id"), scope)
+	if err != nil {
+		respondPrestamoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    prestamos,
+	})
+}
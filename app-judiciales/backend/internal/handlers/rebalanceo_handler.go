@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"expedientes-backend/internal/services"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// RebalanceoHandler handles the shelf rebalancing planner endpoints
+type RebalanceoHandler struct {
+	service *services.RebalanceoService
+}
+
+// NewRebalanceoHandler creates a new rebalancing handler
+func NewRebalanceoHandler(service *services.RebalanceoService) *RebalanceoHandler {
+	return &RebalanceoHandler{service: service}
+}
+
+// GenerarPlan proposes new division ranges from the current occupancy
+func (h *RebalanceoHandler) GenerarPlan(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GenerarPlan(scope)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// GetPlan returns a rebalancing plan
+func (h *RebalanceoHandler) GetPlan(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GetPlan(c.Param("id"), "plan_rebalanceo", scope)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// AplicarPlan updates the division ranges of a plan and records the relocations
+func (h *RebalanceoHandler) AplicarPlan(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.AplicarPlan(c.Param("id"), scope)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": fmt.Sprintf("Plan aplicado: %d expedientes reubicados", len(plan.Movimientos)),
+		"data":    plan,
+	})
+}
+
+// ExportPlanExcel downloads a rebalancing plan with its divisions and move list
+func (h *RebalanceoHandler) ExportPlanExcel(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.service.GetPlan(c.Param("id"), "exportacion_plan_rebalanceo", scope)
+	if err != nil {
+		respondArchivoError(c, err)
+		return
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Error closing Excel file: %v", err)
+		}
+	}()
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#CCE5FF"}, Pattern: 1},
+	})
+
+	// Divisions sheet
+	divisionesSheet := "Divisiones"
+	f.SetSheetName("Sheet1", divisionesSheet)
+	divisionHeaders := []string{"Division", "RangoActual", "RangoNuevo", "Capacidad", "Unidad", "ExpedientesActual", "ExpedientesNuevo", "OcupacionActual%", "OcupacionNueva%"}
+	writePlanHeaders(f, divisionesSheet, divisionHeaders, headerStyle)
+	for i, division := range plan.Divisiones {
+		row := i + 2
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("A%d", row), division.Numero)
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("B%d", row), division.RangoInicioActual+"–"+division.RangoFinActual)
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("C%d", row), division.RangoInicioNuevo+"–"+division.RangoFinNuevo)
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("D%d", row), division.Capacidad)
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("E%d", row), string(division.UnidadCapacidad))
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("F%d", row), division.ExpedientesActual)
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("G%d", row), division.ExpedientesNuevo)
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("H%d", row), division.PorcentajeActual)
+		f.SetCellValue(divisionesSheet, fmt.Sprintf("I%d", row), division.PorcentajeNuevo)
+	}
+
+	// Moves sheet
+	movimientosSheet := "Movimientos"
+	if _, err := f.NewSheet(movimientosSheet); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating Excel sheet"})
+		return
+	}
+	movimientoHeaders := []string{"CIP", "Grado", "ApellidosNombres", "Ubicacion", "DivisionOrigen", "DivisionDestino"}
+	writePlanHeaders(f, movimientosSheet, movimientoHeaders, headerStyle)
+	for i, movimiento := range plan.Movimientos {
+		row := i + 2
+		f.SetCellValue(movimientosSheet, fmt.Sprintf("A%d", row), movimiento.CIP)
+		f.SetCellValue(movimientosSheet, fmt.Sprintf("B%d", row), string(movimiento.Grado))
+		f.SetCellValue(movimientosSheet, fmt.Sprintf("C%d", row), movimiento.ApellidosNombres)
+		f.SetCellValue(movimientosSheet, fmt.Sprintf("D%d", row), movimiento.Ubicacion)
+		f.SetCellValue(movimientosSheet, fmt.Sprintf("E%d", row), movimiento.DivisionOrigen)
+		f.SetCellValue(movimientosSheet, fmt.Sprintf("F%d", row), movimiento.DivisionDestino)
+	}
+
+	filename := fmt.Sprintf("rebalanceo_%s_%s.xlsx", plan.ID.Hex(), plan.CreadoEn.Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Status(http.StatusOK)
+
+	if err := f.Write(c.Writer); err != nil {
+		log.Printf("Error writing Excel file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating Excel file"})
+		return
+	}
+}
+
+// writePlanHeaders writes a styled header row and sets the column widths of a sheet
+func writePlanHeaders(f *excelize.File, sheet string, headers []string, style int) {
+	for i, header := range headers {
+		f.SetCellValue(sheet, fmt.Sprintf("%c1", 'A'+i), header)
+	}
+	last := fmt.Sprintf("%c", 'A'+len(headers)-1)
+	f.SetCellStyle(sheet, "A1", last+"1", style)
+	f.SetColWidth(sheet, "A", last, 18)
+}
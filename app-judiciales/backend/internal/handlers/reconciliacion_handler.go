@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+// ReconciliacionHandler handles the reconciliation of personnel rosters with the archive
+type ReconciliacionHandler struct {
+	service *services.ReconciliacionService
+}
+
+// NewReconciliacionHandler creates a new reconciliacion handler
+func NewReconciliacionHandler(service *services.ReconciliacionService) *ReconciliacionHandler {
+	return &ReconciliacionHandler{
+		service: service,
+	}
+}
+
+// respondReconciliacionError writes a reconciliation error response; applying shares the career errors
+func respondReconciliacionError(c *gin.Context, err error) {
+	statusCode := 0
+	switch {
+	case errors.Is(err, repository.ErrReconciliacionNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, services.ErrArchivoNomina),
+		errors.Is(err, services.ErrDiscrepanciaNoAplicable):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, services.ErrDiscrepanciaAplicada):
+		statusCode = http.StatusConflict
+	default:
+		respondCarreraError(c, err)
+		return
+	}
+
+	c.JSON(statusCode, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
+// Reconciliar uploads a personnel roster (Excel or CSV) and returns its discrepancies with the archive
+func (h *ReconciliacionHandler) Reconciliar(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Archivo requerido. Use el campo 'file' para subir la nómina en Excel o CSV",
+		})
+		return
+	}
+
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".xlsx", ".xls", ".csv":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "El archivo debe ser de tipo Excel (.xlsx o .xls) o CSV",
+		})
+		return
+	}
+
+	// Validate file size (max 10MB)
+	if file.Size > 10*1024*1024 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "El archivo no puede ser mayor a 10MB",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	reconciliacion, err := h.service.Reconciliar(file, scope)
+	if err != nil {
+		respondReconciliacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    reconciliacion,
+	})
+}
+
+// GetReconciliacion returns a reconciliation report
+func (h *ReconciliacionHandler) GetReconciliacion(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	reconciliacion, err := h.service.GetReconciliacion(c.Param("id"), "reconciliacion", scope)
+	if err != nil {
+		respondReconciliacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    reconciliacion,
+	})
+}
+
+// ExportReconciliacionExcel downloads a reconciliation report as an Excel file
+func (h *ReconciliacionHandler) ExportReconciliacionExcel(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	reconciliacion, err := h.service.GetReconciliacion(c.Param("id"), "exportacion_reconciliacion", scope)
+	if err != nil {
+		respondReconciliacionError(c, err)
+		return
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Printf("Error closing Excel file: %v", err)
+		}
+	}()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#CCE5FF"}, Pattern: 1},
+	})
+	if err != nil {
+		headerStyle = 0
+	}
+
+	sheetName := "Discrepancias"
+	if err := f.SetSheetName(f.GetSheetName(0), sheetName); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error creating Excel sheet"})
+		return
+	}
+	rows := make([][]interface{}, len(reconciliacion.Discrepancias))
+	for i, d := range reconciliacion.Discrepancias {
+		aplicada := ""
+		if d.LoteID != nil {
+			aplicada = d.LoteID.Hex()
+		}
+		fila := interface{}("")
+		if d.Fila > 0 {
+			fila = d.Fila
+		}
+		rows[i] = []interface{}{string(d.Tipo), d.CIP, d.ApellidosNombres, fila, string(d.GradoArchivo), string(d.GradoNomina),
+			string(d.SituacionArchivo), string(d.SituacionNomina), aplicada}
+	}
+	writeExcelSheet(f, sheetName, headerStyle, []string{"Tipo", "CIP", "ApellidosNombres", "FilaNomina", "GradoArchivo",
+		"GradoNomina", "SituacionArchivo", "SituacionNomina", "LoteAplicado"}, rows)
+
+	if len(reconciliacion.FilasInvalidas) > 0 {
+		sheetName = "FilasInvalidas"
+		if _, err := f.NewSheet(sheetName); err == nil {
+			rows = make([][]interface{}, len(reconciliacion.FilasInvalidas))
+			for i, fila := range reconciliacion.FilasInvalidas {
+				rows[i] = []interface{}{fila.Fila, fila.CIP, fila.Error}
+			}
+			writeExcelSheet(f, sheetName, headerStyle, []string{"FilaNomina", "CIP", "Error"}, rows)
+		}
+	}
+
+	filename := fmt.Sprintf("reconciliacion_%s_%s.xlsx", reconciliacion.ID.Hex(), reconciliacion.CreadoEn.Format("20060102_150405"))
+
+	// Set headers for Excel download
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Status(http.StatusOK)
+
+	if err := f.Write(c.Writer); err != nil {
+		log.Printf("Error writing Excel file: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": "Error generating Excel file"})
+		return
+	}
+}
+
+// writeExcelSheet writes a header row and the data rows to a sheet
+func writeExcelSheet(f *excelize.File, sheetName string, headerStyle int, headers []string, rows [][]interface{}) {
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	if headerStyle != 0 {
+		lastHeader, _ := excelize.CoordinatesToCellName(len(headers), 1)
+		f.SetCellStyle(sheetName, "A1", lastHeader, headerStyle)
+	}
+
+	for r, row := range rows {
+		for col, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(col+1, r+2) // Start from row 2 (after headers)
+			f.SetCellValue(sheetName, cell, value)
+		}
+	}
+
+	lastCol, _ := excelize.ColumnNumberToName(len(headers))
+	f.SetColWidth(sheetName, "A", lastCol, 18)
+}
+
+// AplicarDiscrepancias applies the selected discrepancies as career events
+func (h *ReconciliacionHandler) AplicarDiscrepancias(c *gin.Context) {
+	var req models.AplicarReconciliacionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	lote, err := h.service.AplicarDiscrepancias(c.Param("id"), &req, scope)
+	if err != nil {
+		respondReconciliacionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Discrepancias aplicadas",
+		"data":    lote,
+	})
+}
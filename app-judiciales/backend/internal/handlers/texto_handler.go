@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TextoHandler handles the full-text search inside attached files
+type TextoHandler struct {
+	service *services.TextoService
+}
+
+// NewTextoHandler creates a new texto handler
+func NewTextoHandler(service *services.TextoService) *TextoHandler {
+	return &TextoHandler{
+		service: service,
+	}
+}
+
+// BuscarTexto searches the extracted text of the attachments within the user's access scope
+func (h *TextoHandler) BuscarTexto(c *gin.Context) {
+	var params models.BusquedaTextoParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	resultados, total, err := h.service.Buscar(&params, scope)
+	if err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	totalPages := int(total) / params.Limit
+	if int(total)%params.Limit > 0 {
+		totalPages++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"resultados":  resultados,
+			"total":       total,
+			"page":        params.Page,
+			"limit":       params.Limit,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+// IndexarTexto starts the text extraction of the pending attachments, or of all of them with
+// ?todos=true, without waiting for it
+func (h *TextoHandler) IndexarTexto(c *gin.Context) {
+	todos := c.Query("todos") == "true"
+	if err := h.service.IndexarEnSegundoPlano(todos); err != nil {
+		respondDocumentoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"message": "Extracción de texto iniciada; el estado queda en cada documento",
+	})
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TomoHandler handles the physical volumes of expedientes
+type TomoHandler struct {
+	service *services.TomoService
+}
+
+// NewTomoHandler creates a new tomo handler
+func NewTomoHandler(service *services.TomoService) *TomoHandler {
+	return &TomoHandler{
+		service: service,
+	}
+}
+
+// respondTomoError writes a tomo error response with its code; state changes share the estado codes
+func respondTomoError(c *gin.Context, err error) {
+	var status int
+	var code string
+	switch {
+	case errors.Is(err, repository.ErrTomoNotFound):
+		status, code = http.StatusNotFound, "TOMO_NOT_FOUND"
+	case errors.Is(err, repository.ErrTomoExists):
+		status, code = http.StatusConflict, "TOMO_EXISTENTE"
+	case errors.Is(err, services.ErrRangoPaginasInvalido):
+		status, code = http.StatusBadRequest, "RANGO_PAGINAS_INVALIDO"
+	case errors.Is(err, services.ErrRangoPaginasSolapado):
+		status, code = http.StatusConflict, "RANGO_PAGINAS_SOLAPADO"
+	case errors.Is(err, services.ErrTomoFuera):
+		status, code = http.StatusConflict, "TOMO_FUERA"
+	default:
+		status, code = estadoErrorCode(err)
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"code":    code,
+	})
+}
+
+// GetTomos returns the tomos of an expediente
+func (h *TomoHandler) GetTomos(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	tomos, err := h.service.GetTomos(c.Param("id"), scope)
+	if err != nil {
+		respondTomoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tomos,
+	})
+}
+
+// CreateTomo adds a tomo to an expediente
+func (h *TomoHandler) CreateTomo(c *gin.Context) {
+	var req models.CreateTomoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	tomo, err := h.service.CreateTomo(c.Param("id"), &req, scope)
+	if err != nil {
+		respondTomoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    tomo,
+	})
+}
+
+// UpdateTomo changes the page range or location of a tomo
+func (h *TomoHandler) UpdateTomo(c *gin.Context) {
+	var req models.UpdateTomoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	tomo, err := h.service.UpdateTomo(c.Param("id"), c.Param("tomoId"), &req, scope)
+	if err != nil {
+		respondTomoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    tomo,
+	})
+}
+
+// DeleteTomo removes a tomo from an expediente
+func (h *TomoHandler) DeleteTomo(c *gin.Context) {
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTomo(c.Param("id"), c.Param("tomoId"), scope); err != nil {
+		respondTomoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tomo eliminado",
+	})
+}
+
+// CambiarEstadoTomo moves a tomo to another lifecycle state
+func (h *TomoHandler) CambiarEstadoTomo(c *gin.Context) {
+	var req models.UpdateEstadoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"code":    "SOLICITUD_INVALIDA",
+		})
+		return
+	}
+
+	scope, ok := getAccessScope(c)
+	if !ok {
+		return
+	}
+
+	cambio, err := h.service.CambiarEstadoTomo(c.Param("id"), c.Param("tomoId"), &req, scope)
+	if err != nil {
+		respondTomoError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "estado updated successfully",
+		"data":    cambio,
+	})
+}
@@ -26,11 +26,19 @@ const (
 // Global repository for permission checking
 var profileRepository *repository.ProfileRepository
 
+// Global repository for loading emergency access grants
+var breakGlassRepository *repository.BreakGlassRepository
+
 // SetProfileRepository sets the profile repository for permission checking
 func SetProfileRepository(repo *repository.ProfileRepository) {
 	profileRepository = repo
 }
 
+// SetBreakGlassRepository sets the repository used to load active break-glass grants
+func SetBreakGlassRepository(repo *repository.BreakGlassRepository) {
+	breakGlassRepository = repo
+}
+
 type Claims struct {
 	UserID    string   `json:"user_id"`
 	Email     string   `json:"email"`
@@ -236,6 +244,99 @@ func RequirePermission(permission models.Permission) gin.HandlerFunc {
 	}
 }
 
+// LoadAccessScope middleware resolves the clearance and permissions of the user's profile and
+// any active break-glass grants, and stores them in context
+func LoadAccessScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Skip for OPTIONS requests (CORS preflight)
+		if c.Request.Method == "OPTIONS" {
+			c.Next()
+			return
+		}
+
+		profile := loadUserProfile(c)
+		c.Set("userClearance", resolveClearance(profile))
+		c.Set("userPermissions", resolvePermissions(profile))
+		c.Set("userBreakGlass", resolveBreakGlassGrants(c))
+		c.Next()
+	}
+}
+
+// resolveBreakGlassGrants loads the user's emergency grants whose window is still open
+func resolveBreakGlassGrants(c *gin.Context) []models.BreakGlassGrant {
+	userIDStr, exists := c.Get("userID")
+	if !exists || breakGlassRepository == nil {
+		return nil
+	}
+
+	userID, err := primitive.ObjectIDFromHex(userIDStr.(string))
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	accesses, err := breakGlassRepository.GetActiveForUser(ctx, userID)
+	if err != nil {
+		log.Printf("Error loading break-glass grants for user %s: %v", userID.Hex(), err)
+		return nil
+	}
+
+	grants := make([]models.BreakGlassGrant, 0, len(accesses))
+	for _, access := range accesses {
+		grants = append(grants, models.BreakGlassGrant{
+			AccessID:     access.ID,
+			ExpedienteID: access.ExpedienteID,
+			ExpiraEn:     access.ExpiraEn,
+		})
+	}
+
+	return grants
+}
+
+// loadUserProfile loads the profile of the authenticated user, or nil on any failure
+func loadUserProfile(c *gin.Context) *models.Profile {
+	userProfileIDStr, exists := c.Get("userProfileID")
+	if !exists || userProfileIDStr == "" || profileRepository == nil {
+		return nil
+	}
+
+	profileID, err := primitive.ObjectIDFromHex(userProfileIDStr.(string))
+	if err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	profile, err := profileRepository.GetProfileByID(ctx, profileID)
+	if err != nil || profile == nil {
+		log.Printf("Error loading profile %s: %v", profileID.Hex(), err)
+		return nil
+	}
+
+	return profile
+}
+
+// resolveClearance returns the profile clearance, falling back to public access on any failure
+func resolveClearance(profile *models.Profile) models.Clasificacion {
+	if profile == nil || !profile.Clearance.IsValid() {
+		return models.ClasificacionPublico
+	}
+
+	return profile.Clearance
+}
+
+// resolvePermissions returns the permissions of an active profile
+func resolvePermissions(profile *models.Profile) []models.Permission {
+	if profile == nil || !profile.Active {
+		return nil
+	}
+
+	return profile.Permissions
+}
+
 // checkUserPermission checks if a user profile has a specific permission
 func checkUserPermission(profileID primitive.ObjectID, requiredPermission models.Permission) (bool, error) {
 	if profileRepository == nil {
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccessScope describes who is reading expedientes and which records they may see
+type AccessScope struct {
+	UserID    primitive.ObjectID `json:"user_id"`
+	Email     string             `json:"email"`
+	IP        string             `json:"ip"`
+	Clearance Clasificacion      `json:"clearance"`
+
+	// BreakGlass lists active emergency grants that widen the scope to specific expedientes
+	BreakGlass []BreakGlassGrant `json:"break_glass,omitempty"`
+
+	// Permissions of the caller's profile, for checks that depend on the data being changed
+	Permissions []Permission `json:"permissions,omitempty"`
+}
+
+// HasPermission reports whether the caller holds a permission; system:admin holds them all
+func (s AccessScope) HasPermission(permission Permission) bool {
+	for _, p := range s.Permissions {
+		if p == permission || p == PermissionSystemAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemAccessScope returns an unrestricted scope for internal operations
+// (uniqueness checks, recalculations) that are not user reads
+func SystemAccessScope() AccessScope {
+	return AccessScope{Clearance: ClasificacionSecreto}
+}
+
+// CanRead checks if the scope allows reading a record with the given classification
+func (s AccessScope) CanRead(clasificacion Clasificacion) bool {
+	return clasificacion.Nivel() <= s.Clearance.Nivel()
+}
+
+// CanReadExpediente checks if the scope allows reading an expediente, either through its
+// clearance or through an emergency grant
+func (s AccessScope) CanReadExpediente(expedienteID primitive.ObjectID, clasificacion Clasificacion) bool {
+	_, breakGlass := s.BreakGlassFor(expedienteID)
+	return breakGlass || s.CanRead(clasificacion)
+}
+
+// BreakGlassFor returns the emergency grant covering an expediente, if any
+func (s AccessScope) BreakGlassFor(expedienteID primitive.ObjectID) (BreakGlassGrant, bool) {
+	for _, grant := range s.BreakGlass {
+		if grant.ExpedienteID == expedienteID {
+			return grant, true
+		}
+	}
+	return BreakGlassGrant{}, false
+}
+
+// BreakGlassExpedienteIDs returns the expedientes reachable through emergency grants
+func (s AccessScope) BreakGlassExpedienteIDs() []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(s.BreakGlass))
+	for _, grant := range s.BreakGlass {
+		ids = append(ids, grant.ExpedienteID)
+	}
+	return ids
+}
+
+// WithoutBreakGlass returns the scope limited to the caller's regular clearance.
+// Emergency grants only allow reading, so write paths must use this scope.
+func (s AccessScope) WithoutBreakGlass() AccessScope {
+	s.BreakGlass = nil
+	return s
+}
+
+// Audit actions recorded for classified expedientes
+const (
+	AccionLecturaClasificada  = "lectura_clasificada"
+	AccionCambioClasificacion = "cambio_clasificacion"
+	RecursoExpediente         = "expediente"
+)
+
+// IdentidadReservada replaces the CIP and name of expedientes above the caller's clearance in
+// reports that still count them
+const IdentidadReservada = "RESERVADO"
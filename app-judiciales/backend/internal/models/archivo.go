@@ -0,0 +1,210 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UnidadCapacidad represents the unit in which a division's capacity is measured
+type UnidadCapacidad string
+
+const (
+	UnidadCarpetas    UnidadCapacidad = "carpetas"
+	UnidadCentimetros UnidadCapacidad = "cm"
+)
+
+// CentimetrosPorHoja approximates the thickness of one page when measuring linear occupancy
+const CentimetrosPorHoja = 0.01
+
+// Estante represents a physical shelf in the archive
+type Estante struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Numero      int                `json:"numero" bson:"numero"`
+	Nombre      string             `json:"nombre" bson:"nombre"`
+	Descripcion string             `json:"descripcion" bson:"descripcion"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Division represents a section of a shelf holding the expedientes whose ubicación falls in a letter range
+type Division struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	EstanteID       primitive.ObjectID `json:"estante_id" bson:"estante_id"`
+	Numero          int                `json:"numero" bson:"numero"`
+	RangoInicio     string             `json:"rango_inicio" bson:"rango_inicio"`
+	RangoFin        string             `json:"rango_fin" bson:"rango_fin"`
+	Capacidad       int                `json:"capacidad" bson:"capacidad"`
+	UnidadCapacidad UnidadCapacidad    `json:"unidad_capacidad" bson:"unidad_capacidad"`
+	Grados          []Grado            `json:"grados" bson:"grados"`
+	Situacion       SituacionMilitar   `json:"situacion,omitempty" bson:"situacion,omitempty"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Rango returns the letter range in the "AA–AM" form used by the division endpoint
+func (d *Division) Rango() string {
+	return d.RangoInicio + "–" + d.RangoFin
+}
+
+// Contiene reports whether an ubicación falls in the division's letter range
+func (d *Division) Contiene(ubicacion string) bool {
+	return CompararUbicacion(ubicacion, d.RangoInicio) >= 0 && CompararUbicacion(ubicacion, d.RangoFin) <= 0
+}
+
+// Almacena reports whether a folder with the given ubicación, grado and situación belongs in the division
+func (d *Division) Almacena(ubicacion string, grado Grado, situacion SituacionMilitar) bool {
+	if !d.Contiene(ubicacion) {
+		return false
+	}
+	if d.Situacion != "" && d.Situacion != situacion {
+		return false
+	}
+	if len(d.Grados) == 0 {
+		return true
+	}
+	for _, g := range d.Grados {
+		if g == grado {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseRango splits a range in the "AA–AM" form into its start and end letters
+func ParseRango(rango string) (string, string, bool) {
+	parts := strings.Split(rango, "–")
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// AlfabetoArchivo is the filing order of ubicación letters: Ñ files between N and O
+const AlfabetoArchivo = "ABCDEFGHIJKLMNÑOPQRSTUVWXYZ"
+
+// CompararUbicacion compares two ubicación codes in archive order, returning -1, 0 or 1.
+// It matches the Spanish collation used for the range queries in MongoDB.
+func CompararUbicacion(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	for i := 0; i < len(ra) && i < len(rb); i++ {
+		pa, pb := posicionArchivo(ra[i]), posicionArchivo(rb[i])
+		if pa != pb {
+			if pa < pb {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(ra) < len(rb):
+		return -1
+	case len(ra) > len(rb):
+		return 1
+	}
+	return 0
+}
+
+// posicionArchivo returns the filing position of a letter; runes outside the alphabet sort after it
+func posicionArchivo(r rune) int {
+	for i, letra := range []rune(AlfabetoArchivo) {
+		if letra == r {
+			return i
+		}
+	}
+	return len(AlfabetoArchivo) + int(r)
+}
+
+// CreateEstanteRequest represents the request to create a shelf
+type CreateEstanteRequest struct {
+	Numero      int    `json:"numero" binding:"required,min=1"`
+	Nombre      string `json:"nombre" binding:"required,max=100"`
+	Descripcion string `json:"descripcion" binding:"max=500"`
+}
+
+// UpdateEstanteRequest represents the request to update a shelf
+type UpdateEstanteRequest struct {
+	Numero      *int    `json:"numero,omitempty" binding:"omitempty,min=1"`
+	Nombre      *string `json:"nombre,omitempty" binding:"omitempty,max=100"`
+	Descripcion *string `json:"descripcion,omitempty" binding:"omitempty,max=500"`
+}
+
+// CreateDivisionRequest represents the request to create a division
+type CreateDivisionRequest struct {
+	EstanteID       string           `json:"estante_id" binding:"required"`
+	Numero          int              `json:"numero" binding:"required,min=1"`
+	RangoInicio     string           `json:"rango_inicio" binding:"required,max=10"`
+	RangoFin        string           `json:"rango_fin" binding:"required,max=10"`
+	Capacidad       int              `json:"capacidad" binding:"required,min=1"`
+	UnidadCapacidad UnidadCapacidad  `json:"unidad_capacidad" binding:"omitempty,oneof=carpetas cm"`
+	Grados          []Grado          `json:"grados"`
+	Situacion       SituacionMilitar `json:"situacion" binding:"omitempty,oneof=Actividad Retiro"`
+}
+
+// UpdateDivisionRequest represents the request to update a division
+type UpdateDivisionRequest struct {
+	EstanteID       *string           `json:"estante_id,omitempty"`
+	Numero          *int              `json:"numero,omitempty" binding:"omitempty,min=1"`
+	RangoInicio     *string           `json:"rango_inicio,omitempty" binding:"omitempty,max=10"`
+	RangoFin        *string           `json:"rango_fin,omitempty" binding:"omitempty,max=10"`
+	Capacidad       *int              `json:"capacidad,omitempty" binding:"omitempty,min=1"`
+	UnidadCapacidad *UnidadCapacidad  `json:"unidad_capacidad,omitempty" binding:"omitempty,oneof=carpetas cm"`
+	Grados          *[]Grado          `json:"grados,omitempty"`
+	Situacion       *SituacionMilitar `json:"situacion,omitempty" binding:"omitempty,oneof=Actividad Retiro"`
+}
+
+// OcupacionDivision holds the raw counts of the expedientes stored in a division
+type OcupacionDivision struct {
+	Expedientes int64 `json:"expedientes" bson:"expedientes"`
+	Paginas     int64 `json:"paginas" bson:"paginas"`
+}
+
+// DivisionOcupacion represents a division with its current occupancy
+type DivisionOcupacion struct {
+	Division
+	Rango      string  `json:"rango"`
+	Ocupado    float64 `json:"ocupado"`    // Expressed in the division's capacity unit
+	Porcentaje float64 `json:"porcentaje"` // Ocupado relative to capacity
+	OcupacionDivision
+}
+
+// EstanteOcupacion represents a shelf with the occupancy of each of its divisions
+type EstanteOcupacion struct {
+	Estante
+	Divisiones  []DivisionOcupacion `json:"divisiones"`
+	Expedientes int64               `json:"expedientes"`
+}
+
+// ExpedienteUbicacion holds the fields of an expediente needed to place it on a shelf
+type ExpedienteUbicacion struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id"`
+	CIP              string             `json:"cip" bson:"cip"`
+	ApellidosNombres string             `json:"apellidos_nombres" bson:"apellidos_nombres"`
+	Grado            Grado              `json:"grado" bson:"grado"`
+	SituacionMilitar SituacionMilitar   `json:"situacion_militar" bson:"situacion_militar"`
+	Ubicacion        string             `json:"ubicacion" bson:"ubicacion"`
+	NumeroPaginas    int                `json:"numero_paginas" bson:"numero_paginas"`
+	Estado           EstadoExpediente   `json:"estado" bson:"estado"`
+	Tomos            int                `json:"tomos" bson:"tomos,omitempty"`
+	Clasificacion    Clasificacion      `json:"clasificacion" bson:"clasificacion"`
+}
+
+// CambioUbicacion is an expediente whose stored ubicación differs from the one the current rules produce
+type CambioUbicacion struct {
+	ExpedienteID     primitive.ObjectID `json:"expediente_id"`
+	CIP              string             `json:"cip"`
+	ApellidosNombres string             `json:"apellidos_nombres"`
+	Anterior         string             `json:"anterior"`
+	Nueva            string             `json:"nueva"`
+}
+
+// RecalculoUbicaciones summarises a recalculation of the stored ubicaciones
+type RecalculoUbicaciones struct {
+	Revisados int               `json:"revisados"`
+	Cambios   []CambioUbicacion `json:"cambios"`
+	Aplicado  bool              `json:"aplicado"`
+}
+
+// Audit action for an ubicación rewritten by the recalculation
+const AccionRecalculoUbicacion = "recalculo_ubicacion"
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BreakGlassAccess represents a temporary emergency grant to read a specific expediente
+type BreakGlassAccess struct {
+	ID            primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UsuarioID     primitive.ObjectID  `json:"usuario_id" bson:"usuario_id"`
+	UsuarioEmail  string              `json:"usuario_email" bson:"usuario_email"`
+	ExpedienteID  primitive.ObjectID  `json:"expediente_id" bson:"expediente_id"`
+	CIP           string              `json:"cip" bson:"cip"`
+	Clasificacion Clasificacion       `json:"clasificacion" bson:"clasificacion"`
+	Justificacion string              `json:"justificacion" bson:"justificacion"`
+	IP            string              `json:"ip" bson:"ip"`
+	OtorgadoEn    time.Time           `json:"otorgado_en" bson:"otorgado_en"`
+	ExpiraEn      time.Time           `json:"expira_en" bson:"expira_en"`
+	Revisado      bool                `json:"revisado" bson:"revisado"`
+	RevisadoPor   *primitive.ObjectID `json:"revisado_por,omitempty" bson:"revisado_por,omitempty"`
+	RevisadoEn    *time.Time          `json:"revisado_en,omitempty" bson:"revisado_en,omitempty"`
+	Observacion   string              `json:"observacion,omitempty" bson:"observacion,omitempty"`
+	Lecturas      int64               `json:"lecturas" bson:"-"`
+}
+
+// Activo reports whether the grant is still within its time window
+func (b *BreakGlassAccess) Activo() bool {
+	return time.Now().Before(b.ExpiraEn)
+}
+
+// BreakGlassGrant is the part of an active emergency grant carried in the caller's access scope
+type BreakGlassGrant struct {
+	AccessID     primitive.ObjectID `json:"access_id"`
+	ExpedienteID primitive.ObjectID `json:"expediente_id"`
+	ExpiraEn     time.Time          `json:"expira_en"`
+}
+
+// BreakGlassRequest represents the request for emergency access to an expediente
+type BreakGlassRequest struct {
+	Justificacion string `json:"justificacion" binding:"required,min=20"`
+}
+
+// BreakGlassReviewRequest represents the supervisor sign-off of an emergency access
+type BreakGlassReviewRequest struct {
+	Observacion string `json:"observacion" binding:"max=1000"`
+}
+
+// Audit actions for emergency access
+const (
+	AccionBreakGlassSolicitud = "break_glass_solicitud"
+	AccionBreakGlassRevision  = "break_glass_revision"
+)
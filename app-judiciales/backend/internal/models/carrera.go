@@ -0,0 +1,128 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventoCarrera records a change of grado or situación militar of the person behind an
+// expediente. The grado and situación stored on the expediente are the values of its latest event.
+type EventoCarrera struct {
+	ID                primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ExpedienteID      primitive.ObjectID  `json:"expediente_id" bson:"expediente_id"`
+	FechaEfectiva     time.Time           `json:"fecha_efectiva" bson:"fecha_efectiva"`
+	Resolucion        string              `json:"resolucion" bson:"resolucion"`
+	GradoAnterior     Grado               `json:"grado_anterior" bson:"grado_anterior"`
+	GradoNuevo        Grado               `json:"grado_nuevo" bson:"grado_nuevo"`
+	SituacionAnterior SituacionMilitar    `json:"situacion_anterior" bson:"situacion_anterior"`
+	SituacionNueva    SituacionMilitar    `json:"situacion_nueva" bson:"situacion_nueva"`
+	Observaciones     string              `json:"observaciones,omitempty" bson:"observaciones,omitempty"`
+	LoteID            *primitive.ObjectID `json:"lote_id,omitempty" bson:"lote_id,omitempty"` // Resolution batch the event was applied from
+	UsuarioID         primitive.ObjectID  `json:"usuario_id" bson:"usuario_id"`
+	Usuario           string              `json:"usuario" bson:"usuario"`
+	CreatedAt         time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// CambiaGrado reports whether the event changed the grado
+func (e *EventoCarrera) CambiaGrado() bool {
+	return e.GradoAnterior != e.GradoNuevo
+}
+
+// CambiaSituacion reports whether the event changed the situación militar
+func (e *EventoCarrera) CambiaSituacion() bool {
+	return e.SituacionAnterior != e.SituacionNueva
+}
+
+// CreateEventoCarreraRequest represents the request for recording a career event
+type CreateEventoCarreraRequest struct {
+	FechaEfectiva    string            `json:"fecha_efectiva" binding:"required,datetime=2006-01-02"`
+	Resolucion       string            `json:"resolucion" binding:"required,max=100"`
+	Grado            *Grado            `json:"grado,omitempty"`             // Unchanged when omitted
+	SituacionMilitar *SituacionMilitar `json:"situacion_militar,omitempty"` // Unchanged when omitted
+	Observaciones    string            `json:"observaciones,omitempty" binding:"max=1000"`
+}
+
+// CarreraExpediente is the career of the person behind an expediente as of a date
+type CarreraExpediente struct {
+	Fecha            time.Time        `json:"fecha"`
+	Grado            Grado            `json:"grado"`
+	SituacionMilitar SituacionMilitar `json:"situacion_militar"`
+	Eventos          []EventoCarrera  `json:"eventos"`
+}
+
+// Career event kinds used to filter reports
+const (
+	CambioCarreraGrado     = "grado"
+	CambioCarreraSituacion = "situacion"
+)
+
+// EventoCarreraSearchParams represents the filters of the career events report
+type EventoCarreraSearchParams struct {
+	FechaInicio time.Time        `form:"fecha_inicio" time_format:"2006-01-02" time_utc:"1"`
+	FechaFin    time.Time        `form:"fecha_fin" time_format:"2006-01-02" time_utc:"1"` // Inclusive
+	Cambio      string           `form:"cambio" binding:"omitempty,oneof=grado situacion"`
+	Grados      []Grado          `form:"grado"`     // New grado of the event
+	Situacion   SituacionMilitar `form:"situacion"` // New situación of the event
+}
+
+// EventoCarreraReporte is a career event with the identification of its expediente
+type EventoCarreraReporte struct {
+	EventoCarrera    `bson:",inline"`
+	CIP              string `json:"cip" bson:"cip"`
+	ApellidosNombres string `json:"apellidos_nombres" bson:"apellidos_nombres"`
+}
+
+// ResultadoFila classifies a row of a resolution file
+type ResultadoFila string
+
+const (
+	FilaCambio         ResultadoFila = "cambio"
+	FilaSinCambios     ResultadoFila = "sin_cambios"
+	FilaCIPDesconocido ResultadoFila = "cip_desconocido"
+	FilaDuplicada      ResultadoFila = "duplicada"
+	FilaInvalida       ResultadoFila = "invalida"
+)
+
+// FilaResolucion is a row of a resolution file matched against the current expediente
+type FilaResolucion struct {
+	Fila             int                 `json:"fila" bson:"fila"`
+	CIP              string              `json:"cip" bson:"cip"`
+	ExpedienteID     *primitive.ObjectID `json:"expediente_id,omitempty" bson:"expediente_id,omitempty"`
+	ApellidosNombres string              `json:"apellidos_nombres,omitempty" bson:"apellidos_nombres,omitempty"`
+	GradoActual      Grado               `json:"grado_actual,omitempty" bson:"grado_actual,omitempty"`
+	GradoNuevo       Grado               `json:"grado_nuevo,omitempty" bson:"grado_nuevo,omitempty"`
+	SituacionActual  SituacionMilitar    `json:"situacion_actual,omitempty" bson:"situacion_actual,omitempty"`
+	SituacionNueva   SituacionMilitar    `json:"situacion_nueva,omitempty" bson:"situacion_nueva,omitempty"`
+	FechaEfectiva    *time.Time          `json:"fecha_efectiva,omitempty" bson:"fecha_efectiva,omitempty"`
+	Resolucion       string              `json:"resolucion" bson:"resolucion"`
+	Resultado        ResultadoFila       `json:"resultado" bson:"resultado"`
+	Error            string              `json:"error,omitempty" bson:"error,omitempty"`
+
+	// Clasificacion is read from the expediente each time the batch is served, so a batch
+	// previewed at a higher clearance never shows the row to a lower one
+	Clasificacion Clasificacion `json:"clasificacion,omitempty" bson:"-"`
+}
+
+// ResumenLote counts the rows of a resolution batch by result
+type ResumenLote struct {
+	Cambios         int `json:"cambios" bson:"cambios"`
+	SinCambios      int `json:"sin_cambios" bson:"sin_cambios"`
+	CIPDesconocidos int `json:"cip_desconocidos" bson:"cip_desconocidos"`
+	Duplicadas      int `json:"duplicadas" bson:"duplicadas"`
+	Invalidas       int `json:"invalidas" bson:"invalidas"`
+}
+
+// LoteResolucion is a resolution file of promotions and retirements previewed against
+// the current expedientes. Applying it records a career event for every changed row.
+type LoteResolucion struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Archivo     string              `json:"archivo" bson:"archivo"`
+	Estado      EstadoPlan          `json:"estado" bson:"estado"`
+	Filas       []FilaResolucion    `json:"filas" bson:"filas"`
+	Resumen     ResumenLote         `json:"resumen" bson:"resumen"`
+	CreadoPor   primitive.ObjectID  `json:"creado_por" bson:"creado_por"`
+	CreadoEn    time.Time           `json:"creado_en" bson:"creado_en"`
+	AplicadoPor *primitive.ObjectID `json:"aplicado_por,omitempty" bson:"aplicado_por,omitempty"`
+	AplicadoEn  *time.Time          `json:"aplicado_en,omitempty" bson:"aplicado_en,omitempty"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TipoDependencia distinguishes courts from the other offices that hold expedientes
+type TipoDependencia string
+
+const (
+	DependenciaJuzgado TipoDependencia = "juzgado"
+	DependenciaOficina TipoDependencia = "oficina"
+)
+
+// Competencia is a matter a juzgado hears
+type Competencia string
+
+const (
+	CompetenciaCivil     Competencia = "civil"
+	CompetenciaPenal     Competencia = "penal"
+	CompetenciaLaboral   Competencia = "laboral"
+	CompetenciaFamilia   Competencia = "familia"
+	CompetenciaComercial Competencia = "comercial"
+)
+
+// ValidCompetencia reports whether a competencia exists
+func ValidCompetencia(competencia Competencia) bool {
+	switch competencia {
+	case CompetenciaCivil, CompetenciaPenal, CompetenciaLaboral, CompetenciaFamilia, CompetenciaComercial:
+		return true
+	}
+	return false
+}
+
+// Dependencia is a juzgado or office that expedientes are assigned to and lent to
+type Dependencia struct {
+	ID           primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	Codigo       string                `json:"codigo" bson:"codigo"` // Unique, upper case
+	Nombre       string                `json:"nombre" bson:"nombre"`
+	Tipo         TipoDependencia       `json:"tipo" bson:"tipo"`
+	Competencias []Competencia         `json:"competencias" bson:"competencias"`
+	Personal     []PersonalDependencia `json:"personal" bson:"personal"`
+	Direccion    string                `json:"direccion,omitempty" bson:"direccion,omitempty"`
+	Telefono     string                `json:"telefono,omitempty" bson:"telefono,omitempty"`
+	Email        string                `json:"email,omitempty" bson:"email,omitempty"`
+	Activa       bool                  `json:"activa" bson:"activa"` // Inactive dependencias keep their history but take no new assignments or loans
+	CreatedAt    time.Time             `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at" bson:"updated_at"`
+}
+
+// PersonalDependencia is a user assigned to a dependencia
+type PersonalDependencia struct {
+	UsuarioID primitive.ObjectID `json:"usuario_id" bson:"usuario_id"`
+	Usuario   string             `json:"usuario" bson:"usuario"` // Email of the user
+	Nombre    string             `json:"nombre" bson:"nombre"`
+	Cargo     string             `json:"cargo" bson:"cargo"` // Juez, secretario, especialista...
+}
+
+// PersonalDependenciaRequest assigns a user to a dependencia
+type PersonalDependenciaRequest struct {
+	UsuarioID string `json:"usuario_id" binding:"required"`
+	Cargo     string `json:"cargo" binding:"required,max=100"`
+}
+
+// CreateDependenciaRequest represents the request to create a dependencia
+type CreateDependenciaRequest struct {
+	Codigo       string                       `json:"codigo" binding:"required,max=30"`
+	Nombre       string                       `json:"nombre" binding:"required,max=200"`
+	Tipo         TipoDependencia              `json:"tipo" binding:"required,oneof=juzgado oficina"`
+	Competencias []Competencia                `json:"competencias,omitempty" binding:"max=10"`
+	Personal     []PersonalDependenciaRequest `json:"personal,omitempty" binding:"max=100,dive"`
+	Direccion    string                       `json:"direccion,omitempty" binding:"max=500"`
+	Telefono     string                       `json:"telefono,omitempty" binding:"max=30"`
+	Email        string                       `json:"email,omitempty" binding:"omitempty,email"`
+}
+
+// UpdateDependenciaRequest represents the request to update a dependencia. Competencias and
+// personal replace the current lists when present.
+type UpdateDependenciaRequest struct {
+	Codigo       *string                       `json:"codigo,omitempty" binding:"omitempty,max=30"`
+	Nombre       *string                       `json:"nombre,omitempty" binding:"omitempty,max=200"`
+	Tipo         *TipoDependencia              `json:"tipo,omitempty" binding:"omitempty,oneof=juzgado oficina"`
+	Competencias *[]Competencia                `json:"competencias,omitempty" binding:"omitempty,max=10"`
+	Personal     *[]PersonalDependenciaRequest `json:"personal,omitempty" binding:"omitempty,max=100,dive"`
+	Direccion    *string                       `json:"direccion,omitempty" binding:"omitempty,max=500"`
+	Telefono     *string                       `json:"telefono,omitempty" binding:"omitempty,max=30"`
+	Email        *string                       `json:"email,omitempty" binding:"omitempty,email"`
+	Activa       *bool                         `json:"activa,omitempty"`
+}
+
+// AsignarDependenciaRequest assigns an expediente to a dependencia; an empty ID unassigns it
+type AsignarDependenciaRequest struct {
+	DependenciaID string `json:"dependencia_id"`
+}
+
+// TenenciaDependencia is what a dependencia holds: the expedientes assigned to it and the
+// folders it has on loan
+type TenenciaDependencia struct {
+	Dependencia Dependencia `json:"dependencia"`
+	Asignados   int64       `json:"asignados"`
+	Prestados   int64       `json:"prestados"` // Open loans of expedientes or tomos
+}
+
+// Audit action for the assignment of an expediente to a dependencia
+const AccionDependenciaAsignacion = "dependencia_asignacion"
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EstadoDigitalizacion is the scanning progress of an expediente
+type EstadoDigitalizacion string
+
+const (
+	DigitalizacionPendiente  EstadoDigitalizacion = "pendiente"
+	DigitalizacionEnProceso  EstadoDigitalizacion = "en_proceso"
+	DigitalizacionCompleto   EstadoDigitalizacion = "completo"   // Every page scanned, awaiting review
+	DigitalizacionVerificado EstadoDigitalizacion = "verificado" // Reviewed against the physical folder
+)
+
+// transicionesDigitalizacion lists the states each digitisation state can move to
+var transicionesDigitalizacion = map[EstadoDigitalizacion][]EstadoDigitalizacion{
+	DigitalizacionPendiente:  {DigitalizacionEnProceso},
+	DigitalizacionEnProceso:  {DigitalizacionPendiente, DigitalizacionCompleto},
+	DigitalizacionCompleto:   {DigitalizacionEnProceso, DigitalizacionVerificado},
+	DigitalizacionVerificado: {DigitalizacionEnProceso},
+}
+
+// IsValid reports whether the digitisation state exists
+func (e EstadoDigitalizacion) IsValid() bool {
+	_, ok := transicionesDigitalizacion[e]
+	return ok
+}
+
+// PuedePasarA reports whether a digitisation state can move to another
+func (e EstadoDigitalizacion) PuedePasarA(hasta EstadoDigitalizacion) bool {
+	for _, estado := range transicionesDigitalizacion[e] {
+		if estado == hasta {
+			return true
+		}
+	}
+	return false
+}
+
+// Digitalizacion is the scanning progress of an expediente and the operator assigned to it.
+// The digitised pages are kept on the expediente and its tomos.
+type Digitalizacion struct {
+	Estado         EstadoDigitalizacion `json:"estado" bson:"estado"`
+	OperadorID     *primitive.ObjectID  `json:"operador_id,omitempty" bson:"operador_id,omitempty"`
+	Operador       string               `json:"operador,omitempty" bson:"operador,omitempty"` // Email of the operator
+	AsignadoEn     *time.Time           `json:"asignado_en,omitempty" bson:"asignado_en,omitempty"`
+	VerificadoPor  string               `json:"verificado_por,omitempty" bson:"verificado_por,omitempty"`
+	VerificadoEn   *time.Time           `json:"verificado_en,omitempty" bson:"verificado_en,omitempty"`
+	ActualizadoPor string               `json:"actualizado_por,omitempty" bson:"actualizado_por,omitempty"`
+	ActualizadoEn  *time.Time           `json:"actualizado_en,omitempty" bson:"actualizado_en,omitempty"`
+}
+
+// EstadoDigitalizacion returns the digitisation state of the expediente; expedientes never
+// touched by the digitisation project are pending
+func (e *Expediente) EstadoDigitalizacion() EstadoDigitalizacion {
+	if e.Digitalizacion == nil || e.Digitalizacion.Estado == "" {
+		return DigitalizacionPendiente
+	}
+	return e.Digitalizacion.Estado
+}
+
+// DiscrepanciaPaginas reports whether the digitised pages do not match the physical size: more
+// pages than the folder has, or a different count once the scan is marked as finished
+func DiscrepanciaPaginas(estado EstadoDigitalizacion, paginas, digitalizadas int) bool {
+	if digitalizadas > paginas {
+		return true
+	}
+	terminado := estado == DigitalizacionCompleto || estado == DigitalizacionVerificado
+	return terminado && digitalizadas != paginas
+}
+
+// UpdateDigitalizacionRequest changes the digitisation state of an expediente or records its
+// digitised pages. Expedientes split into tomos record the pages of one tomo at a time.
+type UpdateDigitalizacionRequest struct {
+	Estado        EstadoDigitalizacion `json:"estado,omitempty"`
+	Digitalizadas *int                 `json:"paginas_digitalizadas,omitempty" binding:"omitempty,min=0"`
+	Tomo          int                  `json:"tomo,omitempty" binding:"omitempty,min=1"` // Number of the tomo the pages belong to
+}
+
+// AsignarDigitalizacionRequest assigns an expediente to a digitisation operator; an empty
+// operador_id removes the assignment
+type AsignarDigitalizacionRequest struct {
+	OperadorID string `json:"operador_id"`
+}
+
+// DigitalizacionSearchParams filters the digitisation work list
+type DigitalizacionSearchParams struct {
+	Estado       EstadoDigitalizacion `form:"estado"`
+	OperadorID   string               `form:"operador_id"`
+	Grado        Grado                `form:"grado"`
+	Discrepancia bool                 `form:"discrepancia"` // Only expedientes with a page mismatch
+	Page         int                  `form:"page"`
+	Limit        int                  `form:"limit"`
+}
+
+// TomoDigitalizacion is the digitisation progress of one tomo
+type TomoDigitalizacion struct {
+	TomoID        primitive.ObjectID `json:"tomo_id"`
+	Numero        int                `json:"numero"`
+	NumeroPaginas int                `json:"numero_paginas"`
+	Digitalizadas int                `json:"paginas_digitalizadas"`
+	Discrepancia  bool               `json:"discrepancia"`
+}
+
+// ExpedienteDigitalizacion is the digitisation progress of an expediente
+type ExpedienteDigitalizacion struct {
+	ExpedienteID     primitive.ObjectID   `json:"expediente_id"`
+	CIP              string               `json:"cip"`
+	Grado            Grado                `json:"grado"`
+	ApellidosNombres string               `json:"apellidos_nombres"`
+	Ubicacion        string               `json:"ubicacion"`
+	NumeroPaginas    int                  `json:"numero_paginas"`
+	Digitalizadas    int                  `json:"paginas_digitalizadas"`
+	Tomos            int                  `json:"tomos"`
+	Estado           EstadoDigitalizacion `json:"estado"`
+	Digitalizacion   *Digitalizacion      `json:"digitalizacion,omitempty"`
+	Diferencia       int                  `json:"diferencia"` // Digitised minus physical pages
+	Discrepancia     bool                 `json:"discrepancia"`
+	DetalleTomos     []TomoDigitalizacion `json:"detalle_tomos,omitempty"`
+}
+
+// ProgresoDigitalizacion aggregates the digitisation progress of a group of expedientes
+type ProgresoDigitalizacion struct {
+	Expedientes   int     `json:"expedientes" bson:"expedientes"`
+	Pendientes    int     `json:"pendientes" bson:"pendientes"`
+	EnProceso     int     `json:"en_proceso" bson:"en_proceso"`
+	Completos     int     `json:"completos" bson:"completos"`
+	Verificados   int     `json:"verificados" bson:"verificados"`
+	Paginas       int     `json:"paginas" bson:"paginas"`
+	Digitalizadas int     `json:"paginas_digitalizadas" bson:"paginas_digitalizadas"` // Capped at the physical pages of each expediente
+	Porcentaje    float64 `json:"porcentaje" bson:"-"`                                // Digitised pages over physical pages
+	Discrepancias int     `json:"discrepancias" bson:"discrepancias"`
+}
+
+// DivisionDigitalizacion is the digitisation progress of the expedientes stored in a division
+type DivisionDigitalizacion struct {
+	DivisionID             primitive.ObjectID `json:"division_id"`
+	EstanteID              primitive.ObjectID `json:"estante_id"`
+	Numero                 int                `json:"numero"`
+	Rango                  string             `json:"rango"`
+	ProgresoDigitalizacion `bson:",inline"`
+}
+
+// GradoDigitalizacion is the digitisation progress of the expedientes of a grado
+type GradoDigitalizacion struct {
+	Grado                  Grado `json:"grado" bson:"_id"`
+	ProgresoDigitalizacion `bson:",inline"`
+}
+
+// OperadorDigitalizacion is the workload and progress of a digitisation operator
+type OperadorDigitalizacion struct {
+	OperadorID             primitive.ObjectID `json:"operador_id" bson:"_id"`
+	Operador               string             `json:"operador" bson:"operador"`
+	ProgresoDigitalizacion `bson:",inline"`
+}
+
+// ResumenDigitalizacion is the digitisation section of the dashboard
+type ResumenDigitalizacion struct {
+	General     ProgresoDigitalizacion   `json:"general"`
+	PorDivision []DivisionDigitalizacion `json:"por_division"`
+	PorGrado    []GradoDigitalizacion    `json:"por_grado"`
+	PorOperador []OperadorDigitalizacion `json:"por_operador"`
+	GeneradoEn  time.Time                `json:"generado_en"`
+}
+
+// Audit actions of the digitisation tracking
+const (
+	AccionDigitalizacionEstado     = "digitalizacion_estado"
+	AccionDigitalizacionPaginas    = "digitalizacion_paginas"
+	AccionDigitalizacionAsignacion = "digitalizacion_asignacion"
+)
@@ -0,0 +1,252 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FormatoDocumento is the file format of an attachment, detected from its content
+type FormatoDocumento string
+
+const (
+	FormatoDocumentoPDF  FormatoDocumento = "pdf"
+	FormatoDocumentoDOC  FormatoDocumento = "doc"
+	FormatoDocumentoDOCX FormatoDocumento = "docx"
+	FormatoDocumentoJPG  FormatoDocumento = "jpg"
+	FormatoDocumentoPNG  FormatoDocumento = "png"
+)
+
+// ContentType returns the MIME type a document of the format is served with
+func (f FormatoDocumento) ContentType() string {
+	switch f {
+	case FormatoDocumentoPDF:
+		return "application/pdf"
+	case FormatoDocumentoDOC:
+		return "application/msword"
+	case FormatoDocumentoDOCX:
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	case FormatoDocumentoJPG:
+		return "image/jpeg"
+	case FormatoDocumentoPNG:
+		return "image/png"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Visualizable reports whether browsers can preview documents of the format
+func (f FormatoDocumento) Visualizable() bool {
+	return f == FormatoDocumentoPDF || f == FormatoDocumentoJPG || f == FormatoDocumentoPNG
+}
+
+// ConTexto reports whether the text of documents of the format can be extracted for search
+func (f FormatoDocumento) ConTexto() bool {
+	return f == FormatoDocumentoPDF || f == FormatoDocumentoDOCX
+}
+
+// EstadoTexto is the state of the text extraction of an attachment
+type EstadoTexto string
+
+const (
+	TextoPendiente   EstadoTexto = "pendiente"
+	TextoExtraido    EstadoTexto = "extraido"
+	TextoSinTexto    EstadoTexto = "sin_texto"    // Scanned PDF without a text layer
+	TextoNoSoportado EstadoTexto = "no_soportado" // Images, DOC files and encrypted PDFs
+	TextoError       EstadoTexto = "error"        // Damaged file; retried by a full reindex
+)
+
+// TextoInicial returns the extraction state of a newly stored file of the format
+func TextoInicial(formato FormatoDocumento) EstadoTexto {
+	if formato.ConTexto() {
+		return TextoPendiente
+	}
+	return TextoNoSoportado
+}
+
+// MaxDocumentosPorCarga is the number of files accepted in a single upload
+const MaxDocumentosPorCarga = 5
+
+// EstadoIntegridad is the result of the last fixity check of a stored file
+type EstadoIntegridad string
+
+const (
+	IntegridadPendiente EstadoIntegridad = "pendiente" // Not verified since it was uploaded
+	IntegridadOK        EstadoIntegridad = "ok"
+	IntegridadAlterado  EstadoIntegridad = "alterado" // SHA-256 differs from the one recorded
+	IntegridadFaltante  EstadoIntegridad = "faltante" // File missing from the storage
+)
+
+// Gravedad orders integrity states so the worst one summarises a document or an expediente
+func (e EstadoIntegridad) Gravedad() int {
+	switch e {
+	case IntegridadOK:
+		return 0
+	case IntegridadAlterado, IntegridadFaltante:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// VersionDocumento is one stored file of an attachment. Versions are never deleted while the
+// attachment exists; restoring a version adds a new one that reuses its file.
+type VersionDocumento struct {
+	Version      int                `json:"version" bson:"version"`
+	Nombre       string             `json:"nombre" bson:"nombre"`
+	Formato      FormatoDocumento   `json:"formato" bson:"formato"`
+	ContentType  string             `json:"content_type" bson:"content_type"`
+	Tamano       int64              `json:"tamano" bson:"tamano"`
+	SHA256       string             `json:"sha256,omitempty" bson:"sha256,omitempty"`
+	Clave        string             `json:"-" bson:"clave"`
+	SubidoPor    primitive.ObjectID `json:"subido_por" bson:"subido_por"`
+	Usuario      string             `json:"usuario" bson:"usuario"`
+	SubidoEn     time.Time          `json:"subido_en" bson:"subido_en"`
+	RestauradaDe int                `json:"restaurada_de,omitempty" bson:"restaurada_de,omitempty"`
+	Integridad   EstadoIntegridad   `json:"integridad" bson:"integridad"`
+	VerificadoEn *time.Time         `json:"verificado_en,omitempty" bson:"verificado_en,omitempty"`
+}
+
+// Documento is a file attached to an expediente. The file fields describe its current version.
+type Documento struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ExpedienteID primitive.ObjectID `json:"expediente_id" bson:"expediente_id"`
+	Nombre       string             `json:"nombre" bson:"nombre"` // Original file name
+	Formato      FormatoDocumento   `json:"formato" bson:"formato"`
+	ContentType  string             `json:"content_type" bson:"content_type"`
+	Tamano       int64              `json:"tamano" bson:"tamano"`
+	SHA256       string             `json:"sha256,omitempty" bson:"sha256,omitempty"`
+	Tipo         string             `json:"tipo" bson:"tipo"` // Kind of document, e.g. resolución or oficio
+	Descripcion  string             `json:"descripcion,omitempty" bson:"descripcion,omitempty"`
+	Fecha        *time.Time         `json:"fecha,omitempty" bson:"fecha,omitempty"` // Date of the document itself
+	Clave        string             `json:"-" bson:"clave"`                         // Storage key
+	Confidencial bool               `json:"confidencial" bson:"confidencial"`       // Hidden without documento:confidential
+	SubidoPor    primitive.ObjectID `json:"subido_por" bson:"subido_por"`
+	Usuario      string             `json:"usuario" bson:"usuario"`
+	SubidoEn     time.Time          `json:"subido_en" bson:"subido_en"`
+
+	Version    int                `json:"version" bson:"version"` // Current version
+	Versiones  []VersionDocumento `json:"versiones" bson:"versiones"`
+	Integridad EstadoIntegridad   `json:"integridad" bson:"integridad"` // Worst state of its versions
+
+	Texto   EstadoTexto `json:"texto,omitempty" bson:"texto,omitempty"`     // Text extraction of the current version
+	Paginas int         `json:"paginas,omitempty" bson:"paginas,omitempty"` // Pages with text
+
+	Origen string              `json:"origen,omitempty" bson:"origen,omitempty"`   // OrigenIngesta for scans from the hot folder
+	TomoID *primitive.ObjectID `json:"tomo_id,omitempty" bson:"tomo_id,omitempty"` // Tomo a scan covers; none is the whole expediente
+}
+
+// SubirDocumentosRequest holds the metadata shared by the files of an upload
+type SubirDocumentosRequest struct {
+	Tipo         string `form:"tipo" binding:"required,max=100"`
+	Descripcion  string `form:"descripcion" binding:"max=1000"`
+	Fecha        string `form:"fecha" binding:"omitempty,datetime=2006-01-02"`
+	Confidencial bool   `form:"confidencial"`
+}
+
+// ConfidencialRequest marks or unmarks an attachment as confidential
+type ConfidencialRequest struct {
+	Confidencial *bool `json:"confidencial" binding:"required"`
+}
+
+// FalloIntegridad records a fixity check that found a file changed or missing
+type FalloIntegridad struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	DocumentoID    primitive.ObjectID `json:"documento_id" bson:"documento_id"`
+	ExpedienteID   primitive.ObjectID `json:"expediente_id" bson:"expediente_id"`
+	Version        int                `json:"version" bson:"version"`
+	Nombre         string             `json:"nombre" bson:"nombre"`
+	Estado         EstadoIntegridad   `json:"estado" bson:"estado"`
+	SHA256Esperado string             `json:"sha256_esperado" bson:"sha256_esperado"`
+	SHA256Obtenido string             `json:"sha256_obtenido,omitempty" bson:"sha256_obtenido,omitempty"`
+	DetectadoEn    time.Time          `json:"detectado_en" bson:"detectado_en"`
+}
+
+// IntegridadExpediente summarises the fixity of the attachments of an expediente
+type IntegridadExpediente struct {
+	ExpedienteID       primitive.ObjectID `json:"expediente_id"`
+	Estado             EstadoIntegridad   `json:"estado"` // Worst state of its files
+	Documentos         int                `json:"documentos"`
+	Versiones          int                `json:"versiones"`
+	Verificadas        int                `json:"verificadas"`
+	Pendientes         int                `json:"pendientes"`
+	Fallidas           int                `json:"fallidas"`
+	UltimaVerificacion *time.Time         `json:"ultima_verificacion,omitempty"`
+	Fallos             []FalloIntegridad  `json:"fallos"` // Most recent first
+}
+
+// ResultadoVerificacion summarises a fixity run over every stored file
+type ResultadoVerificacion struct {
+	Inicio     time.Time `json:"inicio"`
+	Fin        time.Time `json:"fin"`
+	Documentos int       `json:"documentos"`
+	Versiones  int       `json:"versiones"`
+	Correctas  int       `json:"correctas"`
+	Fallidas   int       `json:"fallidas"`
+	Errores    int       `json:"errores"`    // Storage errors; the file is checked again on the next run
+	LineaBase  int       `json:"linea_base"` // Files uploaded before versioning whose hash was recorded now
+}
+
+// PaginaDocumento holds the text of one page of an attachment version for full-text search
+type PaginaDocumento struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	DocumentoID primitive.ObjectID `json:"documento_id" bson:"documento_id"`
+	Version     int                `json:"version" bson:"version"`
+	Pagina      int                `json:"pagina" bson:"pagina"` // 1-based
+	Texto       string             `json:"texto" bson:"texto"`
+}
+
+// BusquedaTextoParams are the query parameters of a search inside attachments
+type BusquedaTextoParams struct {
+	Q     string `form:"q" binding:"required,min=2,max=200"` // Words, "exact phrases" and -excluded words
+	Page  int    `form:"page"`
+	Limit int    `form:"limit"`
+}
+
+// SegmentoTexto is a piece of a search snippet; the highlighted ones match the search
+type SegmentoTexto struct {
+	Texto     string `json:"texto"`
+	Resaltado bool   `json:"resaltado,omitempty"`
+}
+
+// ResultadoBusquedaTexto is a page of an attachment that matches a full-text search
+type ResultadoBusquedaTexto struct {
+	ExpedienteID     primitive.ObjectID `json:"expediente_id" bson:"expediente_id"`
+	CIP              string             `json:"cip" bson:"cip"`
+	Grado            Grado              `json:"grado" bson:"grado"`
+	ApellidosNombres string             `json:"apellidos_nombres" bson:"apellidos_nombres"`
+	Clasificacion    Clasificacion      `json:"clasificacion,omitempty" bson:"clasificacion,omitempty"`
+	DocumentoID      primitive.ObjectID `json:"documento_id" bson:"documento_id"`
+	Nombre           string             `json:"nombre" bson:"nombre"` // File name of the attachment
+	Tipo             string             `json:"tipo" bson:"tipo"`
+	Version          int                `json:"version" bson:"version"`
+	Pagina           int                `json:"pagina" bson:"pagina"`
+	Puntaje          float64            `json:"puntaje" bson:"puntaje"`
+	Fragmento        []SegmentoTexto    `json:"fragmento" bson:"-"`
+	Texto            string             `json:"-" bson:"texto"` // Page text the snippet is cut from
+}
+
+// ResultadoIndexacion summarises a text extraction run over stored attachments
+type ResultadoIndexacion struct {
+	Documentos  int `json:"documentos"`
+	Extraidos   int `json:"extraidos"`
+	SinTexto    int `json:"sin_texto"`
+	NoSoportado int `json:"no_soportado"`
+	Errores     int `json:"errores"`
+}
+
+// EnlaceDocumento is a temporary URL that downloads an attachment straight from the object store
+type EnlaceDocumento struct {
+	URL      string    `json:"url"`
+	ExpiraEn time.Time `json:"expira_en"`
+}
+
+// Audit actions for expediente attachments
+const (
+	AccionDocumentoSubida          = "documento_subida"
+	AccionDocumentoEliminacion     = "documento_eliminacion"
+	AccionDocumentoVersion         = "documento_version"
+	AccionDocumentoRestauracion    = "documento_restauracion"
+	AccionDocumentoConfidencial    = "documento_confidencial"
+	AccionDocumentoFalloIntegridad = "documento_fallo_integridad"
+)
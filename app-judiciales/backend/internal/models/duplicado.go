@@ -0,0 +1,41 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reasons two expedientes are reported as likely duplicates
+const (
+	MotivoDuplicadoCIP    = "cip"    // Same CIP once formatting is removed
+	MotivoDuplicadoNombre = "nombre" // Near-identical apellidos_nombres ignoring accents and word order
+)
+
+// ParDuplicado is a pair of expedientes that likely belong to the same person
+type ParDuplicado struct {
+	Expedientes []*Expediente `json:"expedientes"`
+	Similitud   float64       `json:"similitud"` // 1 for the same CIP or name, lower for near matches
+	Motivos     []string      `json:"motivos"`
+}
+
+// MergeExpedientesRequest represents the request for merging duplicate expedientes into one
+type MergeExpedientesRequest struct {
+	SupervivienteID string   `json:"superviviente_id" binding:"required"`
+	DuplicadoIDs    []string `json:"duplicado_ids" binding:"required,min=1"`
+	Justificacion   string   `json:"justificacion" binding:"required,max=1000"`
+}
+
+// ResultadoMerge describes what was moved into the surviving expediente
+type ResultadoMerge struct {
+	Superviviente  *Expediente          `json:"superviviente"`
+	Fusionados     []primitive.ObjectID `json:"fusionados"`
+	TomosMovidos   int64                `json:"tomos_movidos"`
+	CambiosEstado  int64                `json:"cambios_estado"`
+	EventosCarrera int64                `json:"eventos_carrera"`
+	Prestamos      int64                `json:"prestamos"`
+	Documentos     int64                `json:"documentos"`
+	Movimientos    int64                `json:"movimientos"`
+	Notificaciones int64                `json:"notificaciones"`
+}
+
+// Audit action for the merge of duplicate expedientes
+const AccionFusion = "fusion"
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransicionEstado is an allowed change of lifecycle state and the permission it requires
+type TransicionEstado struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Desde     EstadoExpediente   `json:"desde" bson:"desde"`
+	Hasta     EstadoExpediente   `json:"hasta" bson:"hasta"`
+	Permiso   Permission         `json:"permiso" bson:"permiso"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// CambioEstado is an entry of the state history of an expediente or one of its tomos
+type CambioEstado struct {
+	ID            primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ExpedienteID  primitive.ObjectID  `json:"expediente_id" bson:"expediente_id"`
+	TomoID        *primitive.ObjectID `json:"tomo_id,omitempty" bson:"tomo_id,omitempty"` // Set when the change applies to a single tomo
+	Tomo          int                 `json:"tomo,omitempty" bson:"tomo,omitempty"`
+	Desde         EstadoExpediente    `json:"desde" bson:"desde"`
+	Hasta         EstadoExpediente    `json:"hasta" bson:"hasta"`
+	Justificacion string              `json:"justificacion" bson:"justificacion"`
+	UsuarioID     primitive.ObjectID  `json:"usuario_id" bson:"usuario_id"`
+	Usuario       string              `json:"usuario" bson:"usuario"`
+	Fecha         time.Time           `json:"fecha" bson:"fecha"`
+}
+
+// UpdateEstadoRequest represents the request to move an expediente to another lifecycle state
+type UpdateEstadoRequest struct {
+	Estado        EstadoExpediente `json:"estado" binding:"required"`
+	Justificacion string           `json:"justificacion" binding:"required,max=1000"`
+}
+
+// CreateTransicionRequest represents the request to allow a new state transition
+type CreateTransicionRequest struct {
+	Desde   EstadoExpediente `json:"desde" binding:"required"`
+	Hasta   EstadoExpediente `json:"hasta" binding:"required"`
+	Permiso Permission       `json:"permiso" binding:"required"`
+}
+
+// UpdateTransicionRequest represents the request to change the permission guarding a transition
+type UpdateTransicionRequest struct {
+	Permiso Permission `json:"permiso" binding:"required"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FormatoEtiqueta is the output format of a label sheet
+type FormatoEtiqueta string
+
+const (
+	FormatoEtiquetaPDF FormatoEtiqueta = "pdf" // A4 sheets of adhesive labels
+	FormatoEtiquetaZPL FormatoEtiqueta = "zpl" // Thermal printers, one label per ^XA…^XZ block
+)
+
+// SimbologiaEtiqueta is the barcode printed on a label
+type SimbologiaEtiqueta string
+
+const (
+	SimbologiaCode128 SimbologiaEtiqueta = "code128"
+	SimbologiaQR      SimbologiaEtiqueta = "qr"
+)
+
+// Prefixes of the identifiers encoded in label barcodes. The rest of the identifier is the
+// hex ObjectID of the expediente, tomo or division, so it never changes with the printed data.
+const (
+	PrefijoEtiquetaExpediente = "E"
+	PrefijoEtiquetaTomo       = "T"
+	PrefijoEtiquetaDivision   = "D"
+)
+
+// CodigoEtiqueta returns the stable identifier printed in the barcode of a label
+func CodigoEtiqueta(prefijo string, id primitive.ObjectID) string {
+	return prefijo + id.Hex()
+}
+
+// ParseCodigoEtiqueta splits a scanned label code into its prefix and ObjectID. A bare hex
+// ObjectID is read as an expediente. The last return value is false for any other text.
+func ParseCodigoEtiqueta(codigo string) (string, primitive.ObjectID, bool) {
+	codigo = strings.TrimSpace(codigo)
+	prefijo := PrefijoEtiquetaExpediente
+	if len(codigo) == 25 {
+		prefijo, codigo = strings.ToUpper(codigo[:1]), codigo[1:]
+	}
+
+	switch prefijo {
+	case PrefijoEtiquetaExpediente, PrefijoEtiquetaTomo, PrefijoEtiquetaDivision:
+	default:
+		return "", primitive.NilObjectID, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(codigo)
+	if err != nil {
+		return "", primitive.NilObjectID, false
+	}
+	return prefijo, id, true
+}
+
+// EtiquetasRequest represents the request for the folder labels of selected expedientes
+type EtiquetasRequest struct {
+	ExpedienteIDs []string           `json:"expediente_ids" binding:"required,min=1,max=1000"`
+	Formato       FormatoEtiqueta    `json:"formato,omitempty" binding:"omitempty,oneof=pdf zpl"`
+	Simbologia    SimbologiaEtiqueta `json:"simbologia,omitempty" binding:"omitempty,oneof=code128 qr"`
+}
+
+// DocumentoEtiquetas is a generated label file ready to download
+type DocumentoEtiquetas struct {
+	Nombre      string
+	ContentType string
+	Contenido   []byte
+	Etiquetas   int
+}
@@ -28,6 +28,39 @@ const (
 	GradoTropa Grado = "TROPA"
 )
 
+// IsValid checks if the grado is one of the known ranks
+func (g Grado) IsValid() bool {
+	switch g {
+	case GradoGRAL, GradoCRL, GradoTTECRL, GradoMY, GradoCAP, GradoTTE, GradoSTTE,
+		GradoTCO, GradoSSOO, GradoEC, GradoTropa:
+		return true
+	}
+	return false
+}
+
+// CategoriaGrado groups the grados that share personnel rules, such as the CIP format
+type CategoriaGrado string
+
+const (
+	CategoriaOficiales CategoriaGrado = "oficiales"
+	CategoriaTcoSsoo   CategoriaGrado = "tco_ssoo"
+	CategoriaTropa     CategoriaGrado = "tropa"
+	CategoriaOtros     CategoriaGrado = "otros"
+)
+
+// Categoria returns the personnel category of the grado
+func (g Grado) Categoria() CategoriaGrado {
+	switch g {
+	case GradoGRAL, GradoCRL, GradoTTECRL, GradoMY, GradoCAP, GradoTTE, GradoSTTE:
+		return CategoriaOficiales
+	case GradoTCO, GradoSSOO:
+		return CategoriaTcoSsoo
+	case GradoTropa:
+		return CategoriaTropa
+	}
+	return CategoriaOtros
+}
+
 // SituacionMilitar represents military status
 type SituacionMilitar string
 
@@ -36,14 +69,85 @@ const (
 	SituacionRetiro    SituacionMilitar = "Retiro"
 )
 
+// IsValid checks if the situación is one of the known values
+func (s SituacionMilitar) IsValid() bool {
+	return s == SituacionActividad || s == SituacionRetiro
+}
+
 // EstadoExpediente represents the state of the record
 type EstadoExpediente string
 
 const (
-	EstadoDentro EstadoExpediente = "dentro"
-	EstadoFuera  EstadoExpediente = "fuera"
+	EstadoDentro              EstadoExpediente = "dentro"
+	EstadoFuera               EstadoExpediente = "fuera"
+	EstadoArchivadoDefinitivo EstadoExpediente = "archivado_definitivo"
+	EstadoEnDigitalizacion    EstadoExpediente = "en_digitalizacion"
+	EstadoExtraviado          EstadoExpediente = "extraviado"
+	EstadoTransferido         EstadoExpediente = "transferido"
+	EstadoEnRestauracion      EstadoExpediente = "en_restauracion"
 )
 
+// EstadosExpediente returns every lifecycle state of an expediente
+func EstadosExpediente() []EstadoExpediente {
+	return []EstadoExpediente{
+		EstadoDentro,
+		EstadoFuera,
+		EstadoArchivadoDefinitivo,
+		EstadoEnDigitalizacion,
+		EstadoExtraviado,
+		EstadoTransferido,
+		EstadoEnRestauracion,
+	}
+}
+
+// IsValid checks if the estado is one of the known lifecycle states
+func (e EstadoExpediente) IsValid() bool {
+	for _, estado := range EstadosExpediente() {
+		if e == estado {
+			return true
+		}
+	}
+	return false
+}
+
+// Clasificacion represents the security classification of a record
+type Clasificacion string
+
+const (
+	ClasificacionPublico   Clasificacion = "publico"
+	ClasificacionReservado Clasificacion = "reservado"
+	ClasificacionSecreto   Clasificacion = "secreto"
+)
+
+// Nivel returns the rank of the classification, higher means more restricted.
+// Records stored before classifications existed have an empty value and are public.
+func (c Clasificacion) Nivel() int {
+	switch c {
+	case ClasificacionReservado:
+		return 1
+	case ClasificacionSecreto:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// IsValid checks if the classification is one of the known levels
+func (c Clasificacion) IsValid() bool {
+	return c == ClasificacionPublico || c == ClasificacionReservado || c == ClasificacionSecreto
+}
+
+// ClasificacionesPermitidas returns the classifications readable with the given clearance
+func ClasificacionesPermitidas(clearance Clasificacion) []Clasificacion {
+	var permitidas []Clasificacion
+	for _, c := range []Clasificacion{ClasificacionPublico, ClasificacionReservado, ClasificacionSecreto} {
+		if c.Nivel() <= clearance.Nivel() {
+			permitidas = append(permitidas, c)
+		}
+	}
+	return permitidas
+}
+
 // Expediente represents a military/judicial personnel record
 type Expediente struct {
 	ID                 primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
@@ -52,18 +156,27 @@ type Expediente struct {
 	NumeroPaginas      int                 `json:"numero_paginas" bson:"numero_paginas" binding:"required,min=1" validate:"required,min=1"`
 	SituacionMilitar   SituacionMilitar    `json:"situacion_militar" bson:"situacion_militar" binding:"required" validate:"required,oneof=Actividad Retiro"`
 	CIP                string              `json:"cip" bson:"cip" binding:"required" validate:"required"`
-	Estado             EstadoExpediente    `json:"estado" bson:"estado" validate:"required,oneof=dentro fuera"`
+	Estado             EstadoExpediente    `json:"estado" bson:"estado" validate:"required,oneof=dentro fuera archivado_definitivo en_digitalizacion extraviado transferido en_restauracion"`
 	Ubicacion          string              `json:"ubicacion" bson:"ubicacion" binding:"required" validate:"required"`
 	Ano                int                 `json:"ano" bson:"ano" binding:"required,min=1900,max=2100" validate:"required,min=1900,max=2100"`
 	FechaRegistro      time.Time           `json:"fecha_registro" bson:"fecha_registro" validate:"required"`
 	FechaActualizacion time.Time           `json:"fecha_actualizacion" bson:"fecha_actualizacion" validate:"required"`
 	Orden              int                 `json:"orden" bson:"orden" binding:"required,min=1" validate:"required,min=1"`
+	NumeroRegistro     int64               `json:"numero_registro" bson:"numero_registro,omitempty"`
+	Clasificacion      Clasificacion       `json:"clasificacion" bson:"clasificacion"`
+	Tomos              int                 `json:"tomos" bson:"tomos,omitempty"`                                 // Number of physical volumes, 0 when not split
+	TomosFuera         int                 `json:"tomos_fuera" bson:"tomos_fuera,omitempty"`                     // Volumes away from their location
+	ParcialmenteFuera  bool                `json:"parcialmente_fuera" bson:"parcialmente_fuera,omitempty"`       // Some volumes are out while others remain
+	Digitalizadas      int                 `json:"paginas_digitalizadas" bson:"paginas_digitalizadas,omitempty"` // Digitised pages, from the ingested scans or recorded by hand
+	Digitalizacion     *Digitalizacion     `json:"digitalizacion,omitempty" bson:"digitalizacion,omitempty"`     // Digitisation progress; none is pendiente
+	DependenciaID      *primitive.ObjectID `json:"dependencia_id,omitempty" bson:"dependencia_id,omitempty"`     // Juzgado or office the expediente is assigned to
 	CreatedAt          time.Time           `json:"created_at" bson:"createdAt"`
 	UpdatedAt          time.Time           `json:"updated_at" bson:"updatedAt"`
 	CreatedBy          primitive.ObjectID  `json:"created_by" bson:"createdBy"`
 	UpdatedBy          primitive.ObjectID  `json:"updated_by" bson:"updatedBy"`
 	DeletedAt          *time.Time          `json:"deleted_at,omitempty" bson:"deletedAt,omitempty"`
 	DeletedBy          *primitive.ObjectID `json:"deleted_by,omitempty" bson:"deletedBy,omitempty"`
+	FusionadoEn        *primitive.ObjectID `json:"fusionado_en,omitempty" bson:"fusionado_en,omitempty"` // Surviving expediente it was merged into
 }
 
 // ExpedienteSearchParams represents search parameters for expedientes
@@ -77,6 +190,8 @@ type ExpedienteSearchParams struct {
 	Ubicacion        string           `form:"ubicacion"`
 	Orden            int              `form:"orden"`
 	Ano              int              `form:"ano"`
+	Clasificacion    Clasificacion    `form:"clasificacion"`
+	DependenciaID    string           `form:"dependencia_id"`
 	FechaInicio      time.Time        `form:"fecha_inicio"`
 	FechaFin         time.Time        `form:"fecha_fin"`
 	Page             int              `form:"page"`
@@ -93,8 +208,9 @@ type CreateExpedienteRequest struct {
 	SituacionMilitar SituacionMilitar `json:"situacion_militar" binding:"required" validate:"required,oneof=Actividad Retiro"`
 	CIP              string           `json:"cip" binding:"required" validate:"required"`
 	Ubicacion        string           `json:"ubicacion" binding:"required" validate:"required"`
-	Orden            int              `json:"orden" binding:"required,min=1" validate:"required,min=1"`
+	Orden            int              `json:"orden,omitempty" binding:"omitempty,min=1"` // Ignored: the filing order is computed by the server
 	Ano              int              `json:"ano" binding:"required,min=1900,max=2100" validate:"required,min=1900,max=2100"`
+	Clasificacion    Clasificacion    `json:"clasificacion,omitempty" binding:"omitempty,oneof=publico reservado secreto"`
 }
 
 // UpdateExpedienteRequest represents the request for updating an expediente
@@ -104,12 +220,17 @@ type UpdateExpedienteRequest struct {
 	NumeroPaginas    *int              `json:"numero_paginas,omitempty" validate:"omitempty,min=1"`
 	SituacionMilitar *SituacionMilitar `json:"situacion_militar,omitempty" validate:"omitempty,oneof=Actividad Retiro"`
 	CIP              *string           `json:"cip,omitempty"`
-	Estado           *EstadoExpediente `json:"estado,omitempty" validate:"omitempty,oneof=dentro fuera"`
+	Estado           *EstadoExpediente `json:"estado,omitempty" validate:"omitempty,oneof=dentro fuera archivado_definitivo en_digitalizacion extraviado transferido en_restauracion"` // Only accepted when unchanged; use the estado endpoint
 	Ubicacion        *string           `json:"ubicacion,omitempty"`
-	Orden            *int              `json:"orden,omitempty" validate:"omitempty,min=1"`
 	Ano              *int              `json:"ano,omitempty" validate:"omitempty,min=1900,max=2100"`
 }
 
+// UpdateClasificacionRequest represents the request for changing the classification of an expediente
+type UpdateClasificacionRequest struct {
+	Clasificacion Clasificacion `json:"clasificacion" binding:"required,oneof=publico reservado secreto"`
+	Justificacion string        `json:"justificacion"`
+}
+
 // ExpedienteResponse represents expediente response with metadata
 type ExpedienteResponse struct {
 	Expediente
@@ -137,11 +258,13 @@ type BulkImportExpediente struct {
 
 // ExpedienteExport represents a minimal set of fields used for exports
 type ExpedienteExport struct {
-	Grado            Grado  `bson:"grado" json:"grado"`
-	CIP              string `bson:"cip" json:"cip"`
-	ApellidosNombres string `bson:"apellidos_nombres" json:"apellidos_nombres"`
-	NumeroPaginas    int    `bson:"numero_paginas" json:"numero_paginas"`
-	Ano              int    `bson:"ano" json:"ano"`
+	ID               primitive.ObjectID `bson:"_id" json:"id"`
+	Grado            Grado              `bson:"grado" json:"grado"`
+	CIP              string             `bson:"cip" json:"cip"`
+	ApellidosNombres string             `bson:"apellidos_nombres" json:"apellidos_nombres"`
+	NumeroPaginas    int                `bson:"numero_paginas" json:"numero_paginas"`
+	Ano              int                `bson:"ano" json:"ano"`
+	Clasificacion    Clasificacion      `bson:"clasificacion" json:"clasificacion"`
 }
 
 // BulkImportResult represents the result of a bulk import operation
@@ -189,6 +312,8 @@ type ResumenGeneral struct {
 	PromedioPaginasPorExpediente float64 `json:"promedio_paginas_por_expediente"`
 	TotalPaginas                 int     `json:"total_paginas"`
 	UbicacionesUnicas            int     `json:"ubicaciones_unicas"`
+
+	ExpedientesPorEstado map[EstadoExpediente]int `json:"expedientes_por_estado"`
 }
 
 // GradoStats represents statistics by military rank
@@ -203,7 +328,7 @@ type GradoStats struct {
 	TotalPaginas int     `json:"total_paginas"`
 }
 
-// EstadoStats represents statistics by state (dentro/fuera)
+// EstadoStats represents statistics by lifecycle state
 type EstadoStats struct {
 	Estado       EstadoExpediente `json:"estado"`
 	Total        int              `json:"total"`
@@ -250,3 +375,61 @@ type RegistroAnual struct {
 	Total      int     `json:"total"`
 	Porcentaje float64 `json:"porcentaje"`
 }
+
+// ExpedienteOrden holds the fields of an expediente that determine its filing order
+type ExpedienteOrden struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id"`
+	NumeroRegistro   int64              `json:"numero_registro" bson:"numero_registro"`
+	CIP              string             `json:"cip" bson:"cip"`
+	ApellidosNombres string             `json:"apellidos_nombres" bson:"apellidos_nombres"`
+	Grado            Grado              `json:"grado" bson:"grado"`
+	SituacionMilitar SituacionMilitar   `json:"situacion_militar" bson:"situacion_militar"`
+	Orden            int                `json:"orden" bson:"orden"`
+	Posicion         *PosicionArchivo   `json:"-" bson:"posicion_archivo,omitempty"` // Position at the last reordering
+}
+
+// PosicionArchivo is the full filing sort key of an expediente: its orden, then its
+// surname, then its registration number
+type PosicionArchivo struct {
+	Orden            int    `json:"orden" bson:"orden"`
+	ApellidosNombres string `json:"apellidos_nombres" bson:"apellidos_nombres"`
+	NumeroRegistro   int64  `json:"numero_registro" bson:"numero_registro"`
+}
+
+// CambioOrden is an expediente whose place in the filing order changed since the previous
+// reordering, either because its orden changed or because its surname did
+type CambioOrden struct {
+	ExpedienteOrden
+	OrdenNuevo       int             `json:"orden_nuevo"`
+	PosicionAnterior PosicionArchivo `json:"posicion_anterior"`
+}
+
+// ReordenamientoResult summarises a recomputation of the filing order of the archive
+type ReordenamientoResult struct {
+	Revisados int           `json:"revisados"`
+	Movidos   []CambioOrden `json:"movidos"`
+}
+
+// Audit action for an expediente whose filing order was recomputed
+const AccionReordenamiento = "reordenamiento"
+
+// RevisionCIP is a stored CIP that does not follow the current CIP rules
+type RevisionCIP struct {
+	ExpedienteID     primitive.ObjectID `json:"expediente_id"`
+	CIP              string             `json:"cip"`
+	Grado            Grado              `json:"grado"`
+	ApellidosNombres string             `json:"apellidos_nombres"`
+	Canonico         string             `json:"canonico,omitempty"` // Canonical form, when the CIP only needs normalising
+	Error            string             `json:"error,omitempty"`    // Rule it breaks, when it cannot be normalised
+}
+
+// RevisionCIPs summarises a review of the stored CIPs against the current rules
+type RevisionCIPs struct {
+	Revisados     int           `json:"revisados"`
+	Normalizables []RevisionCIP `json:"normalizables"`
+	Invalidos     []RevisionCIP `json:"invalidos"`
+	Aplicado      bool          `json:"aplicado"`
+}
+
+// Audit action for a stored CIP rewritten in its canonical form
+const AccionNormalizacionCIP = "normalizacion_cip"
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EstadoIngesta is the outcome of a file picked up from the scanning hot folder
+type EstadoIngesta string
+
+const (
+	IngestaImportada    EstadoIngesta = "importado"     // Attached as a new document
+	IngestaNuevaVersion EstadoIngesta = "nueva_version" // Replaced the scan of the same tomo
+	IngestaDuplicada    EstadoIngesta = "duplicado"     // Same content as the current scan; discarded
+	IngestaCuarentena   EstadoIngesta = "cuarentena"    // Moved to the quarantine folder
+)
+
+// ValidEstadoIngesta reports whether an estado exists
+func ValidEstadoIngesta(estado EstadoIngesta) bool {
+	switch estado {
+	case IngestaImportada, IngestaNuevaVersion, IngestaDuplicada, IngestaCuarentena:
+		return true
+	}
+	return false
+}
+
+// OrigenIngesta marks the attachments created by the hot-folder ingest
+const OrigenIngesta = "ingesta"
+
+// TipoDocumentoDigitalizacion is the kind given to the scans attached by the ingest
+const TipoDocumentoDigitalizacion = "Digitalización"
+
+// Ingesta records one file processed by the hot-folder ingest
+type Ingesta struct {
+	ID           primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Archivo      string              `json:"archivo" bson:"archivo"` // Name in the hot folder
+	CIP          string              `json:"cip,omitempty" bson:"cip,omitempty"`
+	Tomo         int                 `json:"tomo,omitempty" bson:"tomo,omitempty"`
+	Estado       EstadoIngesta       `json:"estado" bson:"estado"`
+	Motivo       string              `json:"motivo,omitempty" bson:"motivo,omitempty"`         // Why it was quarantined
+	Cuarentena   string              `json:"cuarentena,omitempty" bson:"cuarentena,omitempty"` // Name in the quarantine folder
+	ExpedienteID *primitive.ObjectID `json:"expediente_id,omitempty" bson:"expediente_id,omitempty"`
+	DocumentoID  *primitive.ObjectID `json:"documento_id,omitempty" bson:"documento_id,omitempty"`
+	Version      int                 `json:"version,omitempty" bson:"version,omitempty"`
+	Paginas      int                 `json:"paginas,omitempty" bson:"paginas,omitempty"` // Digitised pages of the file
+	Tamano       int64               `json:"tamano" bson:"tamano"`
+	SHA256       string              `json:"sha256,omitempty" bson:"sha256,omitempty"`
+	ProcesadoEn  time.Time           `json:"procesado_en" bson:"procesado_en"`
+}
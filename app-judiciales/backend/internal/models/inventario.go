@@ -0,0 +1,134 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EstadoInventario represents the state of a physical inventory session
+type EstadoInventario string
+
+const (
+	InventarioAbierto EstadoInventario = "abierto" // Accepting scans
+	InventarioPausado EstadoInventario = "pausado" // Interrupted, can be resumed
+	InventarioCerrado EstadoInventario = "cerrado" // Finished; its report is frozen
+)
+
+// TipoHallazgo classifies a difference between the shelves and the database
+type TipoHallazgo string
+
+const (
+	HallazgoFaltante      TipoHallazgo = "faltante"       // Expected in the scope but not scanned
+	HallazgoMalUbicado    TipoHallazgo = "mal_ubicado"    // Scanned in a division it does not belong to
+	HallazgoDesconocido   TipoHallazgo = "desconocido"    // Scanned code that matches no expediente or tomo
+	HallazgoFueraPresente TipoHallazgo = "fuera_presente" // Scanned on the shelf while its estado is not dentro
+)
+
+// LecturaInventario is a folder scanned or typed in during an inventory session
+type LecturaInventario struct {
+	Codigo       string              `json:"codigo" bson:"codigo"`
+	DivisionID   primitive.ObjectID  `json:"division_id" bson:"division_id"` // Division the folder was found in
+	ExpedienteID *primitive.ObjectID `json:"expediente_id,omitempty" bson:"expediente_id,omitempty"`
+	TomoID       *primitive.ObjectID `json:"tomo_id,omitempty" bson:"tomo_id,omitempty"`
+	Fecha        time.Time           `json:"fecha" bson:"fecha"`
+	UsuarioID    primitive.ObjectID  `json:"usuario_id" bson:"usuario_id"`
+}
+
+// DivisionInventario identifies a division of the archive in an inventory report
+type DivisionInventario struct {
+	DivisionID primitive.ObjectID `json:"division_id" bson:"division_id"`
+	Estante    int                `json:"estante" bson:"estante"`
+	Numero     int                `json:"numero" bson:"numero"`
+	Rango      string             `json:"rango" bson:"rango"`
+}
+
+// Hallazgo is a folder whose physical presence or place differs from the database
+type Hallazgo struct {
+	Tipo             TipoHallazgo        `json:"tipo" bson:"tipo"`
+	Codigo           string              `json:"codigo,omitempty" bson:"codigo,omitempty"` // Scanned code, empty for missing folders
+	ExpedienteID     *primitive.ObjectID `json:"expediente_id,omitempty" bson:"expediente_id,omitempty"`
+	TomoID           *primitive.ObjectID `json:"tomo_id,omitempty" bson:"tomo_id,omitempty"`
+	Tomo             int                 `json:"tomo,omitempty" bson:"tomo,omitempty"`
+	CIP              string              `json:"cip,omitempty" bson:"cip,omitempty"`
+	ApellidosNombres string              `json:"apellidos_nombres,omitempty" bson:"apellidos_nombres,omitempty"`
+	Grado            Grado               `json:"grado,omitempty" bson:"grado,omitempty"`
+	Ubicacion        string              `json:"ubicacion,omitempty" bson:"ubicacion,omitempty"`
+	Estado           EstadoExpediente    `json:"estado,omitempty" bson:"estado,omitempty"`
+	DivisionLeida    *DivisionInventario `json:"division_leida,omitempty" bson:"division_leida,omitempty"`
+	DivisionEsperada *DivisionInventario `json:"division_esperada,omitempty" bson:"division_esperada,omitempty"` // Nil when no division stores it
+
+	// Clasificacion is read from the expediente each time the report is served, so a stored
+	// report never shows an identity the expediente's current classification hides
+	Clasificacion Clasificacion `json:"clasificacion,omitempty" bson:"-"`
+}
+
+// ResumenInventario counts the expected and scanned folders and the findings by kind
+type ResumenInventario struct {
+	Esperados      int `json:"esperados" bson:"esperados"`
+	Leidos         int `json:"leidos" bson:"leidos"`
+	Encontrados    int `json:"encontrados" bson:"encontrados"` // Expected folders scanned in their division
+	Faltantes      int `json:"faltantes" bson:"faltantes"`
+	MalUbicados    int `json:"mal_ubicados" bson:"mal_ubicados"`
+	Desconocidos   int `json:"desconocidos" bson:"desconocidos"`
+	FueraPresentes int `json:"fuera_presentes" bson:"fuera_presentes"`
+}
+
+// Inventario is a physical inventory session over a shelf or a single division
+type Inventario struct {
+	ID             primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	EstanteID      *primitive.ObjectID  `json:"estante_id,omitempty" bson:"estante_id,omitempty"`
+	DivisionID     *primitive.ObjectID  `json:"division_id,omitempty" bson:"division_id,omitempty"`
+	Divisiones     []DivisionInventario `json:"divisiones" bson:"divisiones"` // Divisions covered, as they were when the session started
+	Descripcion    string               `json:"descripcion" bson:"descripcion"`
+	Estado         EstadoInventario     `json:"estado" bson:"estado"`
+	Lecturas       []LecturaInventario  `json:"lecturas,omitempty" bson:"lecturas"`
+	TotalLecturas  int                  `json:"total_lecturas" bson:"total_lecturas"`
+	Resumen        *ResumenInventario   `json:"resumen,omitempty" bson:"resumen,omitempty"`     // Set when the session is closed
+	Hallazgos      []Hallazgo           `json:"hallazgos,omitempty" bson:"hallazgos,omitempty"` // Set when the session is closed
+	CreadoPor      primitive.ObjectID   `json:"creado_por" bson:"creado_por"`
+	CreadoEn       time.Time            `json:"creado_en" bson:"creado_en"`
+	ActualizadoEn  time.Time            `json:"actualizado_en" bson:"actualizado_en"`
+	CerradoPor     *primitive.ObjectID  `json:"cerrado_por,omitempty" bson:"cerrado_por,omitempty"`
+	CerradoEn      *time.Time           `json:"cerrado_en,omitempty" bson:"cerrado_en,omitempty"`
+	DivisionActual *primitive.ObjectID  `json:"division_actual,omitempty" bson:"division_actual,omitempty"` // Last division label scanned
+}
+
+// ReporteInventario compares what was scanned in a session with what the database expects there
+type ReporteInventario struct {
+	InventarioID primitive.ObjectID `json:"inventario_id"`
+	Descripcion  string             `json:"descripcion"`
+	Estado       EstadoInventario   `json:"estado"`
+	Resumen      ResumenInventario  `json:"resumen"`
+	Hallazgos    []Hallazgo         `json:"hallazgos"`
+	GeneradoEn   time.Time          `json:"generado_en"`
+}
+
+// CreateInventarioRequest represents the request for starting an inventory of a shelf or a division
+type CreateInventarioRequest struct {
+	EstanteID   string `json:"estante_id,omitempty"`
+	DivisionID  string `json:"division_id,omitempty"`
+	Descripcion string `json:"descripcion,omitempty" binding:"max=500"`
+}
+
+// LecturasInventarioRequest represents folders scanned or typed in during a session. A division
+// label code (D…) among the codes sets the division where the following folders are found.
+type LecturasInventarioRequest struct {
+	Codigos  []string `json:"codigos" binding:"required,min=1,max=1000,dive,max=100"`
+	Division string   `json:"division,omitempty" binding:"max=100"` // Division ID or label code; defaults to the last one scanned
+}
+
+// ResultadoLectura is the immediate outcome of a scanned code
+type ResultadoLectura struct {
+	Codigo           string              `json:"codigo"`
+	Division         *DivisionInventario `json:"division,omitempty"`          // Division the folder was recorded in
+	CambioDivision   bool                `json:"cambio_division"`             // The code was a division label
+	Hallazgos        []TipoHallazgo      `json:"hallazgos"`                   // Empty when the folder is where it belongs
+	DivisionEsperada *DivisionInventario `json:"division_esperada,omitempty"` // Where a misplaced folder belongs
+	Repetido         bool                `json:"repetido"`                    // Already scanned in this session
+	CIP              string              `json:"cip,omitempty"`
+	ApellidosNombres string              `json:"apellidos_nombres,omitempty"`
+	Ubicacion        string              `json:"ubicacion,omitempty"`
+	Estado           EstadoExpediente    `json:"estado,omitempty"`
+	Tomo             int                 `json:"tomo,omitempty"`
+}
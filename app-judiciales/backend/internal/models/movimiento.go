@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TipoMovimiento is the kind of actuación recorded on an expediente
+type TipoMovimiento string
+
+const (
+	MovimientoIngreso      TipoMovimiento = "ingreso"      // Ingreso de demanda
+	MovimientoActuacion    TipoMovimiento = "actuacion"    // Actuación judicial
+	MovimientoResolucion   TipoMovimiento = "resolucion"   // Resolución, auto o sentencia
+	MovimientoNotificacion TipoMovimiento = "notificacion" // Notificación
+	MovimientoAudiencia    TipoMovimiento = "audiencia"    // Audiencia
+	MovimientoArchivo      TipoMovimiento = "archivo"      // Archivo
+)
+
+// ValidTipoMovimiento reports whether a movement type exists
+func ValidTipoMovimiento(tipo TipoMovimiento) bool {
+	switch tipo {
+	case MovimientoIngreso, MovimientoActuacion, MovimientoResolucion, MovimientoNotificacion, MovimientoAudiencia, MovimientoArchivo:
+		return true
+	}
+	return false
+}
+
+// Movimiento is an actuación recorded on an expediente. Movements are numbered correlatively
+// per expediente and never change once stored; a mistake is corrected by recording a new
+// movement that references the number it corrects.
+type Movimiento struct {
+	ID           primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	ExpedienteID primitive.ObjectID   `json:"expediente_id" bson:"expediente_id"`
+	Numero       int                  `json:"numero" bson:"numero"`
+	Tipo         TipoMovimiento       `json:"tipo" bson:"tipo"`
+	Descripcion  string               `json:"descripcion" bson:"descripcion"`
+	DocumentoIDs []primitive.ObjectID `json:"documento_ids,omitempty" bson:"documento_ids,omitempty"` // Attachments of the expediente
+	CorrigeA     int                  `json:"corrige_a,omitempty" bson:"corrige_a,omitempty"`         // Number of the corrected movement
+	Fecha        time.Time            `json:"fecha" bson:"fecha"`
+	UsuarioID    primitive.ObjectID   `json:"usuario_id" bson:"usuario_id"`
+	Usuario      string               `json:"usuario" bson:"usuario"`
+	Origen       *OrigenMovimiento    `json:"origen,omitempty" bson:"origen,omitempty"`
+	CorregidoPor []int                `json:"corregido_por,omitempty" bson:"-"` // Numbers of the movements correcting this one
+}
+
+// OrigenMovimiento records where a movement was first numbered when a merge of duplicate
+// expedientes moved it to the surviving expediente
+type OrigenMovimiento struct {
+	ExpedienteID primitive.ObjectID `json:"expediente_id" bson:"expediente_id"`
+	Numero       int                `json:"numero" bson:"numero"`
+}
+
+// CreateMovimientoRequest represents the request for recording a movement
+type CreateMovimientoRequest struct {
+	Tipo         TipoMovimiento `json:"tipo" binding:"required"`
+	Descripcion  string         `json:"descripcion" binding:"required,max=5000"`
+	DocumentoIDs []string       `json:"documento_ids,omitempty" binding:"max=20"` // Documents already attached to the expediente
+	CorrigeA     int            `json:"corrige_a,omitempty" binding:"omitempty,min=1"`
+}
+
+// MovimientoSearchParams filters movements, of one expediente or across all of them
+type MovimientoSearchParams struct {
+	Tipo        TipoMovimiento `form:"tipo"`
+	FechaInicio time.Time      `form:"fecha_inicio" time_format:"2006-01-02" time_utc:"1"`
+	FechaFin    time.Time      `form:"fecha_fin" time_format:"2006-01-02" time_utc:"1"` // Inclusive
+	UsuarioID   string         `form:"usuario_id"`
+	Page        int            `form:"page"`
+	Limit       int            `form:"limit"`
+}
+
+// MovimientoReporte is a movement with the identification of its expediente
+type MovimientoReporte struct {
+	Movimiento       `bson:",inline"`
+	CIP              string `json:"cip" bson:"cip"`
+	ApellidosNombres string `json:"apellidos_nombres" bson:"apellidos_nombres"`
+}
+
+// Audit action for recording a movement
+const AccionMovimiento = "movimiento"
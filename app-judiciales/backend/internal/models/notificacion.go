@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MetodoNotificacion is how a notification reaches its recipient
+type MetodoNotificacion string
+
+const (
+	NotificacionCedula MetodoNotificacion = "cedula"
+	NotificacionEdicto MetodoNotificacion = "edicto"
+	NotificacionEmail  MetodoNotificacion = "email" // Sent by the backend through the configured mailer
+)
+
+// ValidMetodoNotificacion reports whether a notification method exists
+func ValidMetodoNotificacion(metodo MetodoNotificacion) bool {
+	switch metodo {
+	case NotificacionCedula, NotificacionEdicto, NotificacionEmail:
+		return true
+	}
+	return false
+}
+
+// EstadoNotificacion is the delivery status of a notification
+type EstadoNotificacion string
+
+const (
+	NotificacionPendiente EstadoNotificacion = "pendiente"
+	NotificacionEntregada EstadoNotificacion = "entregado"
+	NotificacionDevuelta  EstadoNotificacion = "devuelto"
+)
+
+// Notificacion is a legal notification of a movement to one of the parties. It starts pending
+// and ends delivered or returned.
+type Notificacion struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ExpedienteID    primitive.ObjectID `json:"expediente_id" bson:"expediente_id"`
+	MovimientoID    primitive.ObjectID `json:"movimiento_id" bson:"movimiento_id"`
+	Receptor        string             `json:"receptor" bson:"receptor"`
+	Direccion       string             `json:"direccion,omitempty" bson:"direccion,omitempty"` // Domicilio or publication of the edicto
+	Email           string             `json:"email,omitempty" bson:"email,omitempty"`
+	Metodo          MetodoNotificacion `json:"metodo" bson:"metodo"`
+	Estado          EstadoNotificacion `json:"estado" bson:"estado"`
+	FechaEnvio      *time.Time         `json:"fecha_envio,omitempty" bson:"fecha_envio,omitempty"` // Set once the mailer accepts an email notification
+	FechaEntrega    *time.Time         `json:"fecha_entrega,omitempty" bson:"fecha_entrega,omitempty"`
+	FechaDevolucion *time.Time         `json:"fecha_devolucion,omitempty" bson:"fecha_devolucion,omitempty"`
+	Observaciones   string             `json:"observaciones,omitempty" bson:"observaciones,omitempty"`
+	ErrorEnvio      string             `json:"error_envio,omitempty" bson:"error_envio,omitempty"` // Last mailer failure
+	UsuarioID       primitive.ObjectID `json:"usuario_id" bson:"usuario_id"`
+	Usuario         string             `json:"usuario" bson:"usuario"`
+	ActualizadoPor  string             `json:"actualizado_por,omitempty" bson:"actualizado_por,omitempty"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// CreateNotificacionRequest represents the request for registering a notification of a movement
+type CreateNotificacionRequest struct {
+	Receptor      string             `json:"receptor" binding:"required,max=200"`
+	Metodo        MetodoNotificacion `json:"metodo" binding:"required"`
+	Email         string             `json:"email,omitempty" binding:"omitempty,email"` // Required for the email method
+	Direccion     string             `json:"direccion,omitempty" binding:"max=500"`
+	FechaEnvio    string             `json:"fecha_envio,omitempty" binding:"omitempty,datetime=2006-01-02"` // Cédula and edicto; today when omitted
+	Observaciones string             `json:"observaciones,omitempty" binding:"max=2000"`
+}
+
+// UpdateEstadoNotificacionRequest records the delivery or return of a pending notification
+type UpdateEstadoNotificacionRequest struct {
+	Estado        EstadoNotificacion `json:"estado" binding:"required,oneof=entregado devuelto"`
+	Fecha         string             `json:"fecha,omitempty" binding:"omitempty,datetime=2006-01-02"` // Today when omitted
+	Observaciones string             `json:"observaciones,omitempty" binding:"max=2000"`
+}
+
+// NotificacionPendienteParams filters the report of overdue pending notifications
+type NotificacionPendienteParams struct {
+	Dias   *int               `form:"dias" binding:"omitempty,min=0,max=3650"` // Minimum days pending; 7 when omitted
+	Metodo MetodoNotificacion `form:"metodo"`
+	Page   int                `form:"page"`
+	Limit  int                `form:"limit"`
+}
+
+// NotificacionMovimiento is a notification with the number and type of its movement
+type NotificacionMovimiento struct {
+	Notificacion     `bson:",inline"`
+	MovimientoNumero int            `json:"movimiento_numero" bson:"movimiento_numero"`
+	MovimientoTipo   TipoMovimiento `json:"movimiento_tipo" bson:"movimiento_tipo"`
+}
+
+// NotificacionReporte is a pending notification in the overdue report, with the identification
+// of its expediente
+type NotificacionReporte struct {
+	NotificacionMovimiento `bson:",inline"`
+	CIP                    string `json:"cip" bson:"cip"`
+	ApellidosNombres       string `json:"apellidos_nombres" bson:"apellidos_nombres"`
+	DiasPendiente          int    `json:"dias_pendiente" bson:"-"` // Days since it was sent, or registered if not sent yet
+}
+
+// Audit actions of the notifications of movements
+const (
+	AccionNotificacionRegistro = "notificacion_registro"
+	AccionNotificacionEstado   = "notificacion_estado"
+)
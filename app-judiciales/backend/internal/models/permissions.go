@@ -20,11 +20,38 @@ const (
 	PermissionProfileWrite  Permission = "profile:write" // Backward compatibility
 
 	// Expediente permissions
-	PermissionExpedienteCreate Permission = "expediente:create"
-	PermissionExpedienteRead   Permission = "expediente:read"
-	PermissionExpedienteUpdate Permission = "expediente:update"
-	PermissionExpedienteDelete Permission = "expediente:delete"
-	PermissionExpedienteManage Permission = "expediente:manage" // Full expediente management
+	PermissionExpedienteCreate     Permission = "expediente:create"
+	PermissionExpedienteRead       Permission = "expediente:read"
+	PermissionExpedienteUpdate     Permission = "expediente:update"
+	PermissionExpedienteDelete     Permission = "expediente:delete"
+	PermissionExpedienteManage     Permission = "expediente:manage" // Full expediente management
+	PermissionExpedienteClassify   Permission = "expediente:classify"
+	PermissionExpedienteBreakGlass Permission = "expediente:break_glass" // Emergency access outside clearance
+
+	// Archive layout permissions
+	PermissionArchivoRead   Permission = "archivo:read"
+	PermissionArchivoManage Permission = "archivo:manage" // Create, update and delete estantes and divisiones
+
+	// Document attachment permissions
+	PermissionDocumentoRead   Permission = "documento:read"
+	PermissionDocumentoUpload Permission = "documento:upload"
+	PermissionDocumentoDelete Permission = "documento:delete"
+	// View, download and mark confidential attachments
+	PermissionDocumentoConfidential Permission = "documento:confidential"
+
+	// Digitisation project permissions
+	PermissionDigitalizacionUpdate Permission = "digitalizacion:update" // Record digitised pages and progress
+	PermissionDigitalizacionAssign Permission = "digitalizacion:assign" // Assign expedientes to operators
+	PermissionDigitalizacionVerify Permission = "digitalizacion:verify" // Mark finished scans as verified
+
+	// Record actuaciones on the movements register
+	PermissionMovimientoCreate Permission = "movimiento:create"
+
+	// Register notifications of movements and record their delivery
+	PermissionNotificacionManage Permission = "notificacion:manage"
+
+	// Manage the catalog of juzgados and offices
+	PermissionDependenciaManage Permission = "dependencia:manage"
 
 	// System permissions
 	PermissionSystemAdmin Permission = "system:admin"
@@ -209,6 +236,32 @@ func ValidPermissions() []Permission {
 		PermissionExpedienteUpdate,
 		PermissionExpedienteDelete,
 		PermissionExpedienteManage,
+		PermissionExpedienteClassify,
+		PermissionExpedienteBreakGlass,
+
+		// Archive layout permissions
+		PermissionArchivoRead,
+		PermissionArchivoManage,
+
+		// Document attachment permissions
+		PermissionDocumentoRead,
+		PermissionDocumentoUpload,
+		PermissionDocumentoDelete,
+		PermissionDocumentoConfidential,
+
+		// Digitisation project permissions
+		PermissionDigitalizacionUpdate,
+		PermissionDigitalizacionAssign,
+		PermissionDigitalizacionVerify,
+
+		// Movement permissions
+		PermissionMovimientoCreate,
+
+		// Notification permissions
+		PermissionNotificacionManage,
+
+		// Dependencia permissions
+		PermissionDependenciaManage,
 
 		// System permissions
 		PermissionSystemAdmin,
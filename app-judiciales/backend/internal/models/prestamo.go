@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccionEscaneo is what a scan at the loans desk does with a folder
+type AccionEscaneo string
+
+const (
+	AccionPrestamo   AccionEscaneo = "prestamo"   // The folder leaves the archive: dentro -> fuera
+	AccionDevolucion AccionEscaneo = "devolucion" // The folder comes back: fuera -> dentro
+)
+
+// Prestamo is a loan of an expediente, or of one of its tomos, to a borrower
+type Prestamo struct {
+	ID                  primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ExpedienteID        primitive.ObjectID  `json:"expediente_id" bson:"expediente_id"`
+	TomoID              *primitive.ObjectID `json:"tomo_id,omitempty" bson:"tomo_id,omitempty"` // Set when a single tomo was lent
+	Tomo                int                 `json:"tomo,omitempty" bson:"tomo,omitempty"`
+	Prestatario         string              `json:"prestatario" bson:"prestatario"`
+	DependenciaID       *primitive.ObjectID `json:"dependencia_id,omitempty" bson:"dependencia_id,omitempty"` // Dependencia the folder was lent to
+	Dependencia         string              `json:"dependencia,omitempty" bson:"dependencia,omitempty"`       // Name of the dependencia when lent
+	Salida              time.Time           `json:"salida" bson:"salida"`
+	UsuarioSalidaID     primitive.ObjectID  `json:"usuario_salida_id" bson:"usuario_salida_id"`
+	UsuarioSalida       string              `json:"usuario_salida" bson:"usuario_salida"`
+	Devolucion          *time.Time          `json:"devolucion,omitempty" bson:"devolucion,omitempty"` // Nil while the loan is open
+	UsuarioDevolucionID *primitive.ObjectID `json:"usuario_devolucion_id,omitempty" bson:"usuario_devolucion_id,omitempty"`
+	UsuarioDevolucion   string              `json:"usuario_devolucion,omitempty" bson:"usuario_devolucion,omitempty"`
+}
+
+// Abierto reports whether the folder has not been returned yet
+func (p *Prestamo) Abierto() bool {
+	return p.Devolucion == nil
+}
+
+// EscaneoRequest represents a folder scanned at the loans desk
+type EscaneoRequest struct {
+	Codigo      string        `json:"codigo" binding:"required,max=100"`                   // Label code (E…, T…) or expediente ID
+	Accion      AccionEscaneo `json:"accion" binding:"required,oneof=prestamo devolucion"` // What to do with the folder
+	Prestatario string        `json:"prestatario,omitempty" binding:"max=200"`             // Required to lend, unless a dependencia is given
+	Dependencia string        `json:"dependencia,omitempty" binding:"max=100"`             // Dependencia the folder is lent to: ID or código
+	Division    string        `json:"division,omitempty" binding:"max=100"`                // Division the folder is returned to: ID or shelf label code (D…)
+}
+
+// ResultadoEscaneo is the compact confirmation of a scan
+type ResultadoEscaneo struct {
+	Accion           AccionEscaneo       `json:"accion"`
+	ExpedienteID     primitive.ObjectID  `json:"expediente_id"`
+	TomoID           *primitive.ObjectID `json:"tomo_id,omitempty"`
+	Tomo             int                 `json:"tomo,omitempty"`
+	CIP              string              `json:"cip"`
+	Grado            Grado               `json:"grado"`
+	ApellidosNombres string              `json:"apellidos_nombres"`
+	Ubicacion        string              `json:"ubicacion"`
+	Estado           EstadoExpediente    `json:"estado"`
+	Repetido         bool                `json:"repetido"` // The folder was already in the requested state; nothing changed
+	Prestamo         *Prestamo           `json:"prestamo,omitempty"`
+	Advertencias     []string            `json:"advertencias"`
+}
+
+// PrestamoAbierto is an open loan with the expediente it belongs to
+type PrestamoAbierto struct {
+	Prestamo   Prestamo    `json:"prestamo"`
+	Expediente *Expediente `json:"expediente"`
+}
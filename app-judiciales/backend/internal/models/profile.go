@@ -16,6 +16,9 @@ type Profile struct {
 	// Direct permissions for the profile (simplified architecture)
 	Permissions []Permission `json:"permissions" bson:"permissions"`
 
+	// Clearance is the highest expediente classification users of this profile can read
+	Clearance Clasificacion `json:"clearance" bson:"clearance"`
+
 	Active    bool               `json:"active" bson:"active"`
 	IsSystem  bool               `json:"is_system" bson:"is_system"` // System profiles cannot be deleted
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
@@ -31,7 +34,8 @@ type ProfileResponse struct {
 	Slug        string             `json:"slug"`
 	Description string             `json:"description"`
 	Permissions []Permission       `json:"permissions"` // Direct permissions of the profile
-	Active      bool               `json:"active"`      // Profile status
+	Clearance   Clasificacion      `json:"clearance"`
+	Active      bool               `json:"active"` // Profile status
 	IsSystem    bool               `json:"is_system"`
 	CreatedAt   time.Time          `json:"created_at"`
 	UpdatedAt   time.Time          `json:"updated_at"`
@@ -45,6 +49,7 @@ func (p *Profile) ToProfileResponse() ProfileResponse {
 		Slug:        p.Slug,
 		Description: p.Description,
 		Permissions: p.Permissions,
+		Clearance:   p.Clearance,
 		Active:      p.Active,
 		IsSystem:    p.IsSystem,
 		CreatedAt:   p.CreatedAt,
@@ -54,18 +59,20 @@ func (p *Profile) ToProfileResponse() ProfileResponse {
 
 // CreateProfileRequest represents the request to create a new profile
 type CreateProfileRequest struct {
-	Name        string       `json:"name" binding:"required,min=3,max=100"`
-	Slug        string       `json:"slug" binding:"required,min=3,max=50,alphanum"`
-	Description string       `json:"description" binding:"max=500"`
-	Permissions []Permission `json:"permissions"` // Direct permissions for the profile
+	Name        string        `json:"name" binding:"required,min=3,max=100"`
+	Slug        string        `json:"slug" binding:"required,min=3,max=50,alphanum"`
+	Description string        `json:"description" binding:"max=500"`
+	Permissions []Permission  `json:"permissions"` // Direct permissions for the profile
+	Clearance   Clasificacion `json:"clearance" binding:"omitempty,oneof=publico reservado secreto"`
 }
 
 // UpdateProfileRequest represents the request to update a profile
 type UpdateProfileRequest struct {
-	Name        string       `json:"name" binding:"omitempty,min=3,max=100"`
-	Description string       `json:"description" binding:"omitempty,max=500"`
-	Permissions []Permission `json:"permissions"` // Direct permissions for the profile
-	Active      *bool        `json:"active"`
+	Name        string        `json:"name" binding:"omitempty,min=3,max=100"`
+	Description string        `json:"description" binding:"omitempty,max=500"`
+	Permissions []Permission  `json:"permissions"` // Direct permissions for the profile
+	Clearance   Clasificacion `json:"clearance" binding:"omitempty,oneof=publico reservado secreto"`
+	Active      *bool         `json:"active"`
 }
 
 // UpdatePermissionsRequest represents the request to update profile permissions
@@ -100,6 +107,32 @@ func GetAllPermissions() []PermissionList {
 		{Name: string(PermissionExpedienteCreate), Description: "Crear expedientes", Category: "expedientes"},
 		{Name: string(PermissionExpedienteUpdate), Description: "Actualizar expedientes", Category: "expedientes"},
 		{Name: string(PermissionExpedienteDelete), Description: "Eliminar expedientes", Category: "expedientes"},
+		{Name: string(PermissionExpedienteClassify), Description: "Cambiar clasificación de expedientes", Category: "expedientes"},
+		{Name: string(PermissionExpedienteBreakGlass), Description: "Solicitar acceso de emergencia a expedientes", Category: "expedientes"},
+
+		// Archive layout permissions
+		{Name: string(PermissionArchivoRead), Description: "Ver estantes y ocupación del archivo", Category: "archivo"},
+		{Name: string(PermissionArchivoManage), Description: "Gestionar estantes y divisiones del archivo", Category: "archivo"},
+
+		// Document attachment permissions
+		{Name: string(PermissionDocumentoRead), Description: "Ver y descargar documentos adjuntos", Category: "documentos"},
+		{Name: string(PermissionDocumentoUpload), Description: "Adjuntar documentos a expedientes", Category: "documentos"},
+		{Name: string(PermissionDocumentoDelete), Description: "Eliminar documentos adjuntos", Category: "documentos"},
+		{Name: string(PermissionDocumentoConfidential), Description: "Ver y marcar documentos confidenciales", Category: "documentos"},
+
+		// Digitisation project permissions
+		{Name: string(PermissionDigitalizacionUpdate), Description: "Registrar el avance de la digitalización", Category: "digitalizacion"},
+		{Name: string(PermissionDigitalizacionAssign), Description: "Asignar expedientes a operadores de digitalización", Category: "digitalizacion"},
+		{Name: string(PermissionDigitalizacionVerify), Description: "Verificar expedientes digitalizados", Category: "digitalizacion"},
+
+		// Movement permissions
+		{Name: string(PermissionMovimientoCreate), Description: "Registrar movimientos de expedientes", Category: "movimientos"},
+
+		// Notification permissions
+		{Name: string(PermissionNotificacionManage), Description: "Registrar notificaciones de movimientos y su entrega", Category: "movimientos"},
+
+		// Dependencia permissions
+		{Name: string(PermissionDependenciaManage), Description: "Gestionar el catálogo de juzgados y dependencias", Category: "dependencias"},
 
 		// System permissions
 		{Name: string(PermissionSystemAdmin), Description: "Administrador del sistema", Category: "system"},
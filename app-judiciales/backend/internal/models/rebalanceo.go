@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EstadoPlan represents the state of a plan that is proposed and later applied
+type EstadoPlan string
+
+const (
+	PlanPropuesto EstadoPlan = "propuesto"
+	PlanAplicado  EstadoPlan = "aplicado"
+)
+
+// RebalanceoDivision describes the current and proposed range of a division in a plan
+type RebalanceoDivision struct {
+	DivisionID        primitive.ObjectID `json:"division_id" bson:"division_id"`
+	Numero            int                `json:"numero" bson:"numero"`
+	Capacidad         int                `json:"capacidad" bson:"capacidad"`
+	UnidadCapacidad   UnidadCapacidad    `json:"unidad_capacidad" bson:"unidad_capacidad"`
+	RangoInicioActual string             `json:"rango_inicio_actual" bson:"rango_inicio_actual"`
+	RangoFinActual    string             `json:"rango_fin_actual" bson:"rango_fin_actual"`
+	RangoInicioNuevo  string             `json:"rango_inicio_nuevo" bson:"rango_inicio_nuevo"`
+	RangoFinNuevo     string             `json:"rango_fin_nuevo" bson:"rango_fin_nuevo"`
+	ExpedientesActual int64              `json:"expedientes_actual" bson:"expedientes_actual"`
+	ExpedientesNuevo  int64              `json:"expedientes_nuevo" bson:"expedientes_nuevo"`
+	PorcentajeActual  float64            `json:"porcentaje_actual" bson:"porcentaje_actual"`
+	PorcentajeNuevo   float64            `json:"porcentaje_nuevo" bson:"porcentaje_nuevo"`
+}
+
+// MovimientoPlan describes an expediente that changes division when a plan is applied
+type MovimientoPlan struct {
+	ExpedienteID     primitive.ObjectID `json:"expediente_id" bson:"expediente_id"`
+	CIP              string             `json:"cip" bson:"cip"`
+	ApellidosNombres string             `json:"apellidos_nombres" bson:"apellidos_nombres"`
+	Grado            Grado              `json:"grado" bson:"grado"`
+	Ubicacion        string             `json:"ubicacion" bson:"ubicacion"`
+	DivisionOrigen   int                `json:"division_origen" bson:"division_origen"` // 0 when no division covered the ubicación
+	DivisionDestino  int                `json:"division_destino" bson:"division_destino"`
+
+	// Clasificacion is read from the expediente each time the plan is served, so a plan never
+	// shows an identity the expediente's current classification hides
+	Clasificacion Clasificacion `json:"clasificacion" bson:"-"`
+}
+
+// PlanRebalanceo represents a proposal of new division ranges and the moves it implies
+type PlanRebalanceo struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Estado      EstadoPlan           `json:"estado" bson:"estado"`
+	Divisiones  []RebalanceoDivision `json:"divisiones" bson:"divisiones"`
+	Movimientos []MovimientoPlan     `json:"movimientos" bson:"movimientos"`
+	CreadoPor   primitive.ObjectID   `json:"creado_por" bson:"creado_por"`
+	CreadoEn    time.Time            `json:"creado_en" bson:"creado_en"`
+	AplicadoPor *primitive.ObjectID  `json:"aplicado_por,omitempty" bson:"aplicado_por,omitempty"`
+	AplicadoEn  *time.Time           `json:"aplicado_en,omitempty" bson:"aplicado_en,omitempty"`
+}
+
+// Audit action for the relocation of an expediente between divisions
+const AccionReubicacion = "reubicacion"
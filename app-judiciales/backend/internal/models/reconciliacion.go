@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TipoDiscrepancia classifies a difference between the personnel roster and the archive
+type TipoDiscrepancia string
+
+const (
+	DiscrepanciaSinExpediente TipoDiscrepancia = "sin_expediente"  // On the roster without an expediente
+	DiscrepanciaCarrera       TipoDiscrepancia = "carrera"         // Grado or situación differ from the roster
+	DiscrepanciaFueraNomina   TipoDiscrepancia = "fuera_de_nomina" // Expediente in Actividad missing from the roster
+)
+
+// Discrepancia is a difference between the roster and an expediente. Carrera and
+// fuera_de_nomina discrepancies can be applied as career events.
+type Discrepancia struct {
+	Tipo             TipoDiscrepancia    `json:"tipo" bson:"tipo"`
+	CIP              string              `json:"cip" bson:"cip"`
+	ApellidosNombres string              `json:"apellidos_nombres" bson:"apellidos_nombres"`
+	Fila             int                 `json:"fila,omitempty" bson:"fila,omitempty"` // Roster row, 0 when missing from the roster
+	ExpedienteID     *primitive.ObjectID `json:"expediente_id,omitempty" bson:"expediente_id,omitempty"`
+	GradoArchivo     Grado               `json:"grado_archivo,omitempty" bson:"grado_archivo,omitempty"`
+	GradoNomina      Grado               `json:"grado_nomina,omitempty" bson:"grado_nomina,omitempty"`
+	SituacionArchivo SituacionMilitar    `json:"situacion_archivo,omitempty" bson:"situacion_archivo,omitempty"`
+	SituacionNomina  SituacionMilitar    `json:"situacion_nomina,omitempty" bson:"situacion_nomina,omitempty"`
+	LoteID           *primitive.ObjectID `json:"lote_id,omitempty" bson:"lote_id,omitempty"` // Resolution batch it was applied with
+
+	// Clasificacion is read from the expediente each time the report is served, so a report
+	// built at a higher clearance never shows the expediente to a lower one
+	Clasificacion Clasificacion `json:"clasificacion,omitempty" bson:"-"`
+}
+
+// FilaNominaInvalida is a roster row that could not be read
+type FilaNominaInvalida struct {
+	Fila  int    `json:"fila" bson:"fila"`
+	CIP   string `json:"cip" bson:"cip"`
+	Error string `json:"error" bson:"error"`
+}
+
+// ResumenReconciliacion counts the roster rows and the discrepancies by kind
+type ResumenReconciliacion struct {
+	PersonalNomina int `json:"personal_nomina" bson:"personal_nomina"`
+	SinExpediente  int `json:"sin_expediente" bson:"sin_expediente"`
+	Carrera        int `json:"carrera" bson:"carrera"`
+	FueraNomina    int `json:"fuera_de_nomina" bson:"fuera_de_nomina"`
+	FilasInvalidas int `json:"filas_invalidas" bson:"filas_invalidas"`
+}
+
+// Reconciliacion is the comparison of a personnel roster with the expedientes
+type Reconciliacion struct {
+	ID             primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	Archivo        string                `json:"archivo" bson:"archivo"`
+	Discrepancias  []Discrepancia        `json:"discrepancias" bson:"discrepancias"`
+	FilasInvalidas []FilaNominaInvalida  `json:"filas_invalidas" bson:"filas_invalidas"`
+	Resumen        ResumenReconciliacion `json:"resumen" bson:"resumen"`
+	CreadoPor      primitive.ObjectID    `json:"creado_por" bson:"creado_por"`
+	CreadoEn       time.Time             `json:"creado_en" bson:"creado_en"`
+}
+
+// AplicarReconciliacionRequest selects the discrepancies to apply as career events, by CIP
+type AplicarReconciliacionRequest struct {
+	CIPs          []string `json:"cips" binding:"required,min=1"`
+	FechaEfectiva string   `json:"fecha_efectiva" binding:"required,datetime=2006-01-02"`
+	Resolucion    string   `json:"resolucion" binding:"required,max=100"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tomo is a physical volume of an expediente. Thick expedientes are split into
+// several tomos, each covering a page range with its own location and estado.
+type Tomo struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ExpedienteID  primitive.ObjectID `json:"expediente_id" bson:"expediente_id"`
+	Numero        int                `json:"numero" bson:"numero"`
+	PaginaDesde   int                `json:"pagina_desde" bson:"pagina_desde"`
+	PaginaHasta   int                `json:"pagina_hasta" bson:"pagina_hasta"`
+	NumeroPaginas int                `json:"numero_paginas" bson:"numero_paginas"`
+	Digitalizadas int                `json:"paginas_digitalizadas" bson:"paginas_digitalizadas,omitempty"` // Pages of its ingested scan or recorded by hand
+	Ubicacion     string             `json:"ubicacion" bson:"ubicacion"`
+	Estado        EstadoExpediente   `json:"estado" bson:"estado"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+	CreatedBy     primitive.ObjectID `json:"created_by" bson:"created_by"`
+	UpdatedBy     primitive.ObjectID `json:"updated_by" bson:"updated_by"`
+}
+
+// Fuera reports whether the tomo is away from its location
+func (t *Tomo) Fuera() bool {
+	return t.Estado != EstadoDentro
+}
+
+// ResumenTomos aggregates the tomos of an expediente
+type ResumenTomos struct {
+	Tomos         int `bson:"tomos"`
+	TomosFuera    int `bson:"tomos_fuera"`
+	NumeroPaginas int `bson:"numero_paginas"`
+	Digitalizadas int `bson:"paginas_digitalizadas"`
+}
+
+// CreateTomoRequest represents the request for adding a tomo to an expediente
+type CreateTomoRequest struct {
+	Numero      int    `json:"numero,omitempty" binding:"omitempty,min=1"` // Next free number when omitted
+	PaginaDesde int    `json:"pagina_desde" binding:"required,min=1"`
+	PaginaHasta int    `json:"pagina_hasta" binding:"required,min=1"`
+	Ubicacion   string `json:"ubicacion,omitempty" binding:"omitempty,max=50"` // Location of the expediente when omitted
+}
+
+// UpdateTomoRequest represents the request for updating a tomo
+type UpdateTomoRequest struct {
+	PaginaDesde *int    `json:"pagina_desde,omitempty" binding:"omitempty,min=1"`
+	PaginaHasta *int    `json:"pagina_hasta,omitempty" binding:"omitempty,min=1"`
+	Ubicacion   *string `json:"ubicacion,omitempty" binding:"omitempty,min=1,max=50"`
+}
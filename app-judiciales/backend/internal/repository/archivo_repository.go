@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Archivo catalog errors
+var (
+	ErrEstanteNotFound  = errors.New("estante not found")
+	ErrDivisionNotFound = errors.New("division not found")
+	ErrEstanteExists    = errors.New("ya existe un estante con ese número")
+	ErrDivisionExists   = errors.New("ya existe una división con ese número en el estante")
+	ErrPlanNotFound     = errors.New("plan de rebalanceo no encontrado")
+	ErrPlanAplicado     = errors.New("el plan de rebalanceo ya fue aplicado")
+)
+
+// ArchivoRepository handles the shelf and division catalog of the physical archive
+type ArchivoRepository struct {
+	db                   *database.Database
+	estantesCollection   *mongo.Collection
+	divisionesCollection *mongo.Collection
+	planesCollection     *mongo.Collection
+}
+
+// NewArchivoRepository creates a new archivo repository
+func NewArchivoRepository(db *database.Database) *ArchivoRepository {
+	return &ArchivoRepository{
+		db:                   db,
+		estantesCollection:   db.Collection("estantes"),
+		divisionesCollection: db.Collection("divisiones"),
+		planesCollection:     db.Collection("rebalanceo_planes"),
+	}
+}
+
+// CreateEstante stores a new shelf
+func (r *ArchivoRepository) CreateEstante(estante *models.Estante) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	estante.ID = primitive.NewObjectID()
+	estante.CreatedAt = now
+	estante.UpdatedAt = now
+
+	if _, err := r.estantesCollection.InsertOne(ctx, estante); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrEstanteExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetEstante retrieves a shelf by ID
+func (r *ArchivoRepository) GetEstante(id string) (*models.Estante, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var estante models.Estante
+	if err := r.estantesCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&estante); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrEstanteNotFound
+		}
+		return nil, err
+	}
+
+	return &estante, nil
+}
+
+// GetEstantes retrieves all shelves ordered by number
+func (r *ArchivoRepository) GetEstantes() ([]*models.Estante, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "numero", Value: 1}})
+	cursor, err := r.estantesCollection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	estantes := []*models.Estante{}
+	if err = cursor.All(ctx, &estantes); err != nil {
+		return nil, err
+	}
+
+	return estantes, nil
+}
+
+// UpdateEstante applies the given changes to a shelf
+func (r *ArchivoRepository) UpdateEstante(id string, updates map[string]interface{}) error {
+	return r.update(r.estantesCollection, id, updates, ErrEstanteNotFound, ErrEstanteExists)
+}
+
+// DeleteEstante removes a shelf
+func (r *ArchivoRepository) DeleteEstante(id string) error {
+	return r.delete(r.estantesCollection, id, ErrEstanteNotFound)
+}
+
+// CreateDivision stores a new division
+func (r *ArchivoRepository) CreateDivision(division *models.Division) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	division.ID = primitive.NewObjectID()
+	division.CreatedAt = now
+	division.UpdatedAt = now
+
+	if _, err := r.divisionesCollection.InsertOne(ctx, division); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDivisionExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetDivision retrieves a division by ID
+func (r *ArchivoRepository) GetDivision(id string) (*models.Division, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var division models.Division
+	if err := r.divisionesCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&division); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDivisionNotFound
+		}
+		return nil, err
+	}
+
+	return &division, nil
+}
+
+// GetDivisiones retrieves divisions ordered by shelf and number, optionally for a single shelf
+func (r *ArchivoRepository) GetDivisiones(estanteID *primitive.ObjectID) ([]*models.Division, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if estanteID != nil {
+		filter["estante_id"] = *estanteID
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "estante_id", Value: 1}, {Key: "numero", Value: 1}})
+	cursor, err := r.divisionesCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	divisiones := []*models.Division{}
+	if err = cursor.All(ctx, &divisiones); err != nil {
+		return nil, err
+	}
+
+	return divisiones, nil
+}
+
+// CountDivisiones counts the divisions of a shelf
+func (r *ArchivoRepository) CountDivisiones(estanteID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.divisionesCollection.CountDocuments(ctx, bson.M{"estante_id": estanteID})
+}
+
+// UpdateDivision applies the given changes to a division
+func (r *ArchivoRepository) UpdateDivision(id string, updates map[string]interface{}) error {
+	return r.update(r.divisionesCollection, id, updates, ErrDivisionNotFound, ErrDivisionExists)
+}
+
+// DeleteDivision removes a division
+func (r *ArchivoRepository) DeleteDivision(id string) error {
+	return r.delete(r.divisionesCollection, id, ErrDivisionNotFound)
+}
+
+// CreatePlan stores a new rebalancing plan
+func (r *ArchivoRepository) CreatePlan(plan *models.PlanRebalanceo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	plan.ID = primitive.NewObjectID()
+	_, err := r.planesCollection.InsertOne(ctx, plan)
+	return err
+}
+
+// GetPlan retrieves a rebalancing plan by ID
+func (r *ArchivoRepository) GetPlan(id string) (*models.PlanRebalanceo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var plan models.PlanRebalanceo
+	if err := r.planesCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&plan); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPlanNotFound
+		}
+		return nil, err
+	}
+
+	return &plan, nil
+}
+
+// MarkPlanAplicado moves a proposed plan to the applied state, failing if it was already applied
+func (r *ArchivoRepository) MarkPlanAplicado(id primitive.ObjectID, appliedBy primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"_id": id, "estado": models.PlanPropuesto}
+	update := bson.M{
+		"$set": bson.M{
+			"estado":       models.PlanAplicado,
+			"aplicado_por": appliedBy,
+			"aplicado_en":  now,
+		},
+	}
+
+	result, err := r.planesCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrPlanAplicado
+	}
+
+	return nil
+}
+
+// ReabrirPlan returns a plan whose application was undone to the proposed state
+func (r *ArchivoRepository) ReabrirPlan(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set":   bson.M{"estado": models.PlanPropuesto},
+		"$unset": bson.M{"aplicado_por": "", "aplicado_en": ""},
+	}
+
+	_, err := r.planesCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// update sets fields on a catalog document and refreshes its timestamp
+func (r *ArchivoRepository) update(collection *mongo.Collection, id string, updates map[string]interface{}, notFound, duplicate error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid ID format")
+	}
+
+	updates["updated_at"] = time.Now()
+	result, err := collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": updates})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return duplicate
+		}
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// delete removes a catalog document by ID
+func (r *ArchivoRepository) delete(collection *mongo.Collection, id string, notFound error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid ID format")
+	}
+
+	result, err := collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// defaultDivisionRanges is the layout of the two shelves of officers in activity
+// that the frontend used to hard-code
+var defaultDivisionRanges = [][]string{
+	// Estante 1
+	{"AA–AG", "AH–AQ", "AR–AZ", "BA–BM", "BN–BZ", "CA–CC", "CD–CJ", "CK–CZ", "DA–DM", "DN–DZ",
+		"EA–EM", "EN–EZ", "FA–FM", "FN–FZ", "GA–GM", "GN–GZ", "HA–HM", "HN–HZ", "IA–IZ", "JA–JZ"},
+	// Estante 2
+	{"KA–KZ", "LA–LM", "LN–LZ", "MA–MD", "ME–MM", "MN–MZ", "NA–NZ", "OA–OZ", "PA–PF", "PG–PZ",
+		"QA–QZ", "RA–RJ", "RK–RZ", "SA–SD", "SE–SZ", "TA–TZ", "UA–UZ", "VA–VD", "VE–VZ", "WA–ZZ"},
+}
+
+// InitializeDefaultLayout creates the original shelf layout when the catalog is empty
+func (r *ArchivoRepository) InitializeDefaultLayout(ctx context.Context) error {
+	count, err := r.estantesCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to count estantes: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	gradosOficiales := []models.Grado{
+		models.GradoSTTE, models.GradoTTE, models.GradoCAP, models.GradoMY,
+		models.GradoTTECRL, models.GradoCRL, models.GradoGRAL,
+	}
+
+	numeroDivision := 1
+	for i, rangos := range defaultDivisionRanges {
+		primero, _, _ := models.ParseRango(rangos[0])
+		_, ultimo, _ := models.ParseRango(rangos[len(rangos)-1])
+
+		estante := &models.Estante{
+			Numero:      i + 1,
+			Nombre:      fmt.Sprintf("Estante %d", i+1),
+			Descripcion: fmt.Sprintf("Oficiales en Actividad (%s-%s)", primero, ultimo),
+		}
+		if err := r.CreateEstante(estante); err != nil {
+			return fmt.Errorf("failed to create estante %d: %w", estante.Numero, err)
+		}
+
+		for _, rango := range rangos {
+			inicio, fin, _ := models.ParseRango(rango)
+			division := &models.Division{
+				EstanteID:       estante.ID,
+				Numero:          numeroDivision,
+				RangoInicio:     inicio,
+				RangoFin:        fin,
+				Capacidad:       100,
+				UnidadCapacidad: models.UnidadCarpetas,
+				Grados:          gradosOficiales,
+				Situacion:       models.SituacionActividad,
+			}
+			if err := r.CreateDivision(division); err != nil {
+				return fmt.Errorf("failed to create division %d: %w", division.Numero, err)
+			}
+			numeroDivision++
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// AuditRepository handles audit log persistence
+type AuditRepository struct {
+	db                   *database.Database
+	collection           *mongo.Collection
+	classifiedCollection *mongo.Collection
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *database.Database) *AuditRepository {
+	return &AuditRepository{
+		db:                   db,
+		collection:           db.Collection("audit_logs"),
+		classifiedCollection: db.Collection("classified_access_logs"),
+	}
+}
+
+// Log stores a general audit log entry
+func (r *AuditRepository) Log(entry *models.AuditLog) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// LogMany stores several general audit log entries at once
+func (r *AuditRepository) LogMany(entries []models.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, len(entries))
+	for i := range entries {
+		if entries[i].Timestamp.IsZero() {
+			entries[i].Timestamp = time.Now()
+		}
+		documents[i] = entries[i]
+	}
+
+	_, err := r.collection.InsertMany(ctx, documents)
+	return err
+}
+
+// LogClassifiedAccess stores access entries for classified expedientes in their own collection
+func (r *AuditRepository) LogClassifiedAccess(entries []models.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, len(entries))
+	for i := range entries {
+		if entries[i].Timestamp.IsZero() {
+			entries[i].Timestamp = time.Now()
+		}
+		documents[i] = entries[i]
+	}
+
+	_, err := r.classifiedCollection.InsertMany(ctx, documents)
+	return err
+}
+
+// GetClassifiedAccess retrieves classified access entries, optionally filtered by expediente
+func (r *AuditRepository) GetClassifiedAccess(recursoID string, page, limit int) ([]*models.AuditLog, int64, error) {
+	return r.find(r.classifiedCollection, recursoID, page, limit)
+}
+
+// CountBreakGlassReads counts the reads tagged with an emergency access grant
+func (r *AuditRepository) CountBreakGlassReads(breakGlassID string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.classifiedCollection.CountDocuments(ctx, bson.M{"detalles.break_glass_id": breakGlassID})
+}
+
+// GetByRecurso retrieves general audit entries for a resource
+func (r *AuditRepository) GetByRecurso(recursoID string, page, limit int) ([]*models.AuditLog, int64, error) {
+	return r.find(r.collection, recursoID, page, limit)
+}
+
+// find runs a paginated query on an audit collection sorted by newest first
+func (r *AuditRepository) find(collection *mongo.Collection, recursoID string, page, limit int) ([]*models.AuditLog, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if recursoID != "" {
+		filter["recurso_id"] = recursoID
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "timestamp", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.AuditLog
+	if err = cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Break-glass repository errors
+var (
+	ErrBreakGlassNotFound = errors.New("acceso de emergencia no encontrado")
+	ErrBreakGlassRevisado = errors.New("el acceso de emergencia ya fue revisado")
+)
+
+// BreakGlassRepository handles emergency access data operations
+type BreakGlassRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewBreakGlassRepository creates a new break-glass repository
+func NewBreakGlassRepository(db *database.Database) *BreakGlassRepository {
+	return &BreakGlassRepository{
+		db:         db,
+		collection: db.Collection("break_glass_accesses"),
+	}
+}
+
+// Create stores a new emergency access grant
+func (r *BreakGlassRepository) Create(access *models.BreakGlassAccess) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	access.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, access)
+	return err
+}
+
+// GetByID retrieves an emergency access by ID
+func (r *BreakGlassRepository) GetByID(id string) (*models.BreakGlassAccess, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var access models.BreakGlassAccess
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&access); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrBreakGlassNotFound
+		}
+		return nil, err
+	}
+
+	return &access, nil
+}
+
+// GetActiveForUser returns the grants of a user whose window has not expired
+func (r *BreakGlassRepository) GetActiveForUser(ctx context.Context, userID primitive.ObjectID) ([]models.BreakGlassAccess, error) {
+	filter := bson.M{
+		"usuario_id": userID,
+		"expira_en":  bson.M{"$gt": time.Now()},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var accesses []models.BreakGlassAccess
+	if err = cursor.All(ctx, &accesses); err != nil {
+		return nil, err
+	}
+
+	return accesses, nil
+}
+
+// GetActiveForUserAndExpediente returns the active grant of a user for an expediente, if any
+func (r *BreakGlassRepository) GetActiveForUserAndExpediente(userID, expedienteID primitive.ObjectID) (*models.BreakGlassAccess, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"usuario_id":    userID,
+		"expediente_id": expedienteID,
+		"expira_en":     bson.M{"$gt": time.Now()},
+	}
+
+	var access models.BreakGlassAccess
+	if err := r.collection.FindOne(ctx, filter).Decode(&access); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &access, nil
+}
+
+// List retrieves emergency accesses, newest first, optionally only those pending review
+func (r *BreakGlassRepository) List(pendientes bool, page, limit int) ([]*models.BreakGlassAccess, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if pendientes {
+		filter["revisado"] = false
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "otorgado_en", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var accesses []*models.BreakGlassAccess
+	if err = cursor.All(ctx, &accesses); err != nil {
+		return nil, 0, err
+	}
+
+	return accesses, total, nil
+}
+
+// SignOff marks an emergency access as reviewed by a supervisor. An access is reviewed only once.
+func (r *BreakGlassRepository) SignOff(id string, reviewedBy primitive.ObjectID, observacion string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid ID format")
+	}
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"revisado":     true,
+			"revisado_por": reviewedBy,
+			"revisado_en":  now,
+			"observacion":  observacion,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID, "revisado": false}, update)
+	if err != nil {
+		return err
+	}
+
+	// El servicio ya comprobó que existe: si no coincide, otro supervisor lo revisó antes
+	if result.MatchedCount == 0 {
+		return ErrBreakGlassRevisado
+	}
+
+	return nil
+}
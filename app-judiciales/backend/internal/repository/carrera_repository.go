@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Resolution batch errors
+var (
+	ErrLoteNotFound = errors.New("lote de resolución no encontrado")
+	ErrLoteAplicado = errors.New("el lote de resolución ya fue aplicado")
+)
+
+// CarreraRepository handles the career events of the people behind expedientes and the
+// resolution batches they are applied from
+type CarreraRepository struct {
+	db              *database.Database
+	collection      *mongo.Collection
+	lotesCollection *mongo.Collection
+}
+
+// NewCarreraRepository creates a new carrera repository
+func NewCarreraRepository(db *database.Database) *CarreraRepository {
+	return &CarreraRepository{
+		db:              db,
+		collection:      db.Collection("eventos_carrera"),
+		lotesCollection: db.Collection("lotes_resolucion"),
+	}
+}
+
+// ordenCronologico sorts career events by effective date, then by recording time
+var ordenCronologico = bson.D{{Key: "fecha_efectiva", Value: 1}, {Key: "created_at", Value: 1}}
+
+// Create stores a career event
+func (r *CarreraRepository) Create(evento *models.EventoCarrera) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	evento.ID = primitive.NewObjectID()
+	evento.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, evento)
+	return err
+}
+
+// CreateMany stores the career events of a resolution batch
+func (r *CarreraRepository) CreateMany(eventos []*models.EventoCarrera) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	docs := make([]interface{}, len(eventos))
+	for i, evento := range eventos {
+		evento.ID = primitive.NewObjectID()
+		evento.CreatedAt = now
+		docs[i] = evento
+	}
+
+	_, err := r.collection.InsertMany(ctx, docs)
+	return err
+}
+
+// DeleteByLote removes the career events applied from a resolution batch
+func (r *CarreraRepository) DeleteByLote(loteID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"lote_id": loteID})
+	return err
+}
+
+// Delete removes a career event
+func (r *CarreraRepository) Delete(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// GetByExpediente retrieves the career events of an expediente in chronological order
+func (r *CarreraRepository) GetByExpediente(expedienteID primitive.ObjectID) ([]models.EventoCarrera, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(ordenCronologico)
+	cursor, err := r.collection.Find(ctx, bson.M{"expediente_id": expedienteID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	eventos := []models.EventoCarrera{}
+	if err = cursor.All(ctx, &eventos); err != nil {
+		return nil, err
+	}
+
+	return eventos, nil
+}
+
+// GetUltimo retrieves the latest career event of an expediente, nil if it has none
+func (r *CarreraRepository) GetUltimo(expedienteID primitive.ObjectID) (*models.EventoCarrera, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "fecha_efectiva", Value: -1}, {Key: "created_at", Value: -1}})
+	var evento models.EventoCarrera
+	if err := r.collection.FindOne(ctx, bson.M{"expediente_id": expedienteID}, findOptions).Decode(&evento); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &evento, nil
+}
+
+// GetUltimasFechas returns the effective date of the latest career event of each expediente that has one
+func (r *CarreraRepository) GetUltimasFechas(expedienteIDs []primitive.ObjectID) (map[primitive.ObjectID]time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"expediente_id": bson.M{"$in": expedienteIDs}}},
+		{"$group": bson.M{"_id": "$expediente_id", "fecha": bson.M{"$max": "$fecha_efectiva"}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	fechas := make(map[primitive.ObjectID]time.Time)
+	for cursor.Next(ctx) {
+		var result struct {
+			ExpedienteID primitive.ObjectID `bson:"_id"`
+			Fecha        time.Time          `bson:"fecha"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			return nil, err
+		}
+		fechas[result.ExpedienteID] = result.Fecha
+	}
+
+	return fechas, cursor.Err()
+}
+
+// Search retrieves the career events matching the report filters, joined with the
+// expedientes readable within the scope
+func (r *CarreraRepository) Search(params models.EventoCarreraSearchParams, scope models.AccessScope) ([]models.EventoCarreraReporte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := bson.M{}
+	if !params.FechaInicio.IsZero() || !params.FechaFin.IsZero() {
+		fecha := bson.M{}
+		if !params.FechaInicio.IsZero() {
+			fecha["$gte"] = params.FechaInicio
+		}
+		if !params.FechaFin.IsZero() {
+			// The end date is a whole day
+			fecha["$lt"] = params.FechaFin.AddDate(0, 0, 1)
+		}
+		match["fecha_efectiva"] = fecha
+	}
+	switch params.Cambio {
+	case models.CambioCarreraGrado:
+		match["$expr"] = bson.M{"$ne": []string{"$grado_anterior", "$grado_nuevo"}}
+	case models.CambioCarreraSituacion:
+		match["$expr"] = bson.M{"$ne": []string{"$situacion_anterior", "$situacion_nueva"}}
+	}
+	if len(params.Grados) > 0 {
+		match["grado_nuevo"] = bson.M{"$in": params.Grados}
+	}
+	if params.Situacion != "" {
+		match["situacion_nueva"] = params.Situacion
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$lookup": bson.M{
+			"from":         "expedientes",
+			"localField":   "expediente_id",
+			"foreignField": "_id",
+			"pipeline": []bson.M{
+				{"$match": visibleFilter(scope)},
+				{"$project": bson.M{"cip": 1, "apellidos_nombres": 1}},
+			},
+			"as": "expediente",
+		}},
+		{"$unwind": "$expediente"},
+		{"$addFields": bson.M{
+			"cip":               "$expediente.cip",
+			"apellidos_nombres": "$expediente.apellidos_nombres",
+		}},
+		{"$project": bson.M{"expediente": 0}},
+		{"$sort": ordenCronologico},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	eventos := []models.EventoCarreraReporte{}
+	if err = cursor.All(ctx, &eventos); err != nil {
+		return nil, err
+	}
+
+	return eventos, nil
+}
+
+// CreateLote stores a previewed resolution batch
+func (r *CarreraRepository) CreateLote(lote *models.LoteResolucion) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	lote.ID = primitive.NewObjectID()
+	_, err := r.lotesCollection.InsertOne(ctx, lote)
+	return err
+}
+
+// GetLote retrieves a resolution batch by ID
+func (r *CarreraRepository) GetLote(id string) (*models.LoteResolucion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var lote models.LoteResolucion
+	if err := r.lotesCollection.FindOne(ctx, bson.M{"_id": objID}).Decode(&lote); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrLoteNotFound
+		}
+		return nil, err
+	}
+
+	return &lote, nil
+}
+
+// MarkLoteAplicado moves a proposed batch to the applied state, failing if it was already applied
+func (r *CarreraRepository) MarkLoteAplicado(id primitive.ObjectID, appliedBy primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "estado": models.PlanPropuesto}
+	update := bson.M{
+		"$set": bson.M{
+			"estado":       models.PlanAplicado,
+			"aplicado_por": appliedBy,
+			"aplicado_en":  time.Now(),
+		},
+	}
+
+	result, err := r.lotesCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrLoteAplicado
+	}
+
+	return nil
+}
+
+// ReabrirLote returns a batch whose application was undone to the proposed state
+func (r *CarreraRepository) ReabrirLote(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set":   bson.M{"estado": models.PlanPropuesto},
+		"$unset": bson.M{"aplicado_por": "", "aplicado_en": ""},
+	}
+
+	_, err := r.lotesCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// Reasignar moves the career events of an expediente to another one
+func (r *CarreraRepository) Reasignar(desde, hasta primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"expediente_id": desde}, bson.M{"$set": bson.M{"expediente_id": hasta}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"expedientes-backend/internal/database"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Sequence names stored in the contadores collection
+const (
+	SecuenciaExpedientes = "expedientes"
+)
+
+// CounterRepository hands out sequence numbers from atomic counters, one document per sequence
+type CounterRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewCounterRepository creates a new counter repository
+func NewCounterRepository(db *database.Database) *CounterRepository {
+	return &CounterRepository{
+		db:         db,
+		collection: db.Collection("contadores"),
+	}
+}
+
+// Reserve atomically reserves cantidad consecutive numbers of a sequence and returns the first one.
+// Concurrent callers always receive disjoint blocks.
+func (r *CounterRepository) Reserve(ctx context.Context, secuencia string, cantidad int) (int64, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var contador struct {
+		Valor int64 `bson:"valor"`
+	}
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": secuencia},
+		bson.M{"$inc": bson.M{"valor": int64(cantidad)}},
+		opts,
+	).Decode(&contador)
+	if err != nil {
+		return 0, err
+	}
+
+	return contador.Valor - int64(cantidad) + 1, nil
+}
+
+// EnsureAtLeast moves a sequence forward so that it never hands out a number up to valor again
+func (r *CounterRepository) EnsureAtLeast(ctx context.Context, secuencia string, valor int64) error {
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": secuencia},
+		bson.M{"$max": bson.M{"valor": valor}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Dependencia catalog errors
+var (
+	ErrDependenciaNotFound = errors.New("dependencia no encontrada")
+	ErrDependenciaExists   = errors.New("ya existe una dependencia con ese código")
+)
+
+// DependenciaRepository handles the catalog of juzgados and offices
+type DependenciaRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewDependenciaRepository creates a new dependencia repository
+func NewDependenciaRepository(db *database.Database) *DependenciaRepository {
+	return &DependenciaRepository{
+		db:         db,
+		collection: db.Collection("dependencias"),
+	}
+}
+
+// Create stores a new dependencia
+func (r *DependenciaRepository) Create(dependencia *models.Dependencia) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	dependencia.ID = primitive.NewObjectID()
+	dependencia.CreatedAt = now
+	dependencia.UpdatedAt = now
+
+	if _, err := r.collection.InsertOne(ctx, dependencia); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDependenciaExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByID retrieves a dependencia by ID; a malformed ID is not found
+func (r *DependenciaRepository) GetByID(id string) (*models.Dependencia, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrDependenciaNotFound
+	}
+
+	return r.findOne(bson.M{"_id": objID})
+}
+
+// GetByCodigo retrieves a dependencia by its code
+func (r *DependenciaRepository) GetByCodigo(codigo string) (*models.Dependencia, error) {
+	return r.findOne(bson.M{"codigo": codigo})
+}
+
+func (r *DependenciaRepository) findOne(filter bson.M) (*models.Dependencia, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var dependencia models.Dependencia
+	if err := r.collection.FindOne(ctx, filter).Decode(&dependencia); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDependenciaNotFound
+		}
+		return nil, err
+	}
+
+	return &dependencia, nil
+}
+
+// GetAll retrieves the dependencias ordered by code, optionally only the active ones
+func (r *DependenciaRepository) GetAll(soloActivas bool) ([]*models.Dependencia, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if soloActivas {
+		filter["activa"] = true
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "codigo", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	dependencias := []*models.Dependencia{}
+	if err = cursor.All(ctx, &dependencias); err != nil {
+		return nil, err
+	}
+
+	return dependencias, nil
+}
+
+// Update applies the given changes to a dependencia
+func (r *DependenciaRepository) Update(id string, updates map[string]interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrDependenciaNotFound
+	}
+
+	updates["updated_at"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objID}, bson.M{"$set": updates})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDependenciaExists
+		}
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDependenciaNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a dependencia
+func (r *DependenciaRepository) Delete(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrDependenciaNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Documento errors
+var (
+	ErrDocumentoNotFound = errors.New("documento no encontrado")
+	ErrDocumentoCambiado = errors.New("el documento cambió mientras se actualizaba; vuelva a consultarlo")
+)
+
+// DocumentoRepository handles the metadata of the files attached to expedientes
+type DocumentoRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewDocumentoRepository creates a new documento repository
+func NewDocumentoRepository(db *database.Database) *DocumentoRepository {
+	return &DocumentoRepository{
+		db:         db,
+		collection: db.Collection("documentos"),
+	}
+}
+
+// Create stores the metadata of an attachment; the ID must be set beforehand because it is part of the storage key
+func (r *DocumentoRepository) Create(documento *models.Documento) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if documento.ID.IsZero() {
+		documento.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, documento)
+	return err
+}
+
+// GetByID retrieves an attachment of an expediente
+func (r *DocumentoRepository) GetByID(expedienteID primitive.ObjectID, id string) (*models.Documento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var documento models.Documento
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID, "expediente_id": expedienteID}).Decode(&documento); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentoNotFound
+		}
+		return nil, err
+	}
+
+	return &documento, nil
+}
+
+// FindByID retrieves an attachment by its ObjectID regardless of its expediente
+func (r *DocumentoRepository) FindByID(id primitive.ObjectID) (*models.Documento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var documento models.Documento
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&documento); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentoNotFound
+		}
+		return nil, err
+	}
+
+	return &documento, nil
+}
+
+// FindEscaneo retrieves the latest scan the hot-folder ingest attached to a tomo of an
+// expediente, or to the whole expediente when tomoID is nil
+func (r *DocumentoRepository) FindEscaneo(expedienteID primitive.ObjectID, tomoID *primitive.ObjectID) (*models.Documento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"expediente_id": expedienteID, "origen": models.OrigenIngesta}
+	if tomoID != nil {
+		filter["tomo_id"] = *tomoID
+	} else {
+		filter["tomo_id"] = bson.M{"$exists": false}
+	}
+
+	var documento models.Documento
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "subido_en", Value: -1}})
+	if err := r.collection.FindOne(ctx, filter, findOptions).Decode(&documento); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDocumentoNotFound
+		}
+		return nil, err
+	}
+
+	return &documento, nil
+}
+
+// GetByExpediente retrieves the attachments of an expediente, newest first, leaving out the
+// confidential ones unless asked for
+func (r *DocumentoRepository) GetByExpediente(expedienteID primitive.ObjectID, incluirConfidenciales bool) ([]models.Documento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"expediente_id": expedienteID}
+	if !incluirConfidenciales {
+		filter["confidencial"] = bson.M{"$ne": true}
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "subido_en", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	documentos := []models.Documento{}
+	if err = cursor.All(ctx, &documentos); err != nil {
+		return nil, err
+	}
+
+	return documentos, nil
+}
+
+// GetAll retrieves the metadata of every attachment, oldest first
+func (r *DocumentoRepository) GetAll() ([]models.Documento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	documentos := []models.Documento{}
+	if err = cursor.All(ctx, &documentos); err != nil {
+		return nil, err
+	}
+
+	return documentos, nil
+}
+
+// ActualizarVersiones saves the current file and the versions of an attachment, provided it is
+// still at versionAnterior. Attachments stored before versioning have no version (0).
+func (r *DocumentoRepository) ActualizarVersiones(documento *models.Documento, versionAnterior int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, filtroVersion(documento.ID, versionAnterior), bson.M{"$set": bson.M{
+		"nombre":       documento.Nombre,
+		"formato":      documento.Formato,
+		"content_type": documento.ContentType,
+		"tamano":       documento.Tamano,
+		"sha256":       documento.SHA256,
+		"clave":        documento.Clave,
+		"version":      documento.Version,
+		"versiones":    documento.Versiones,
+		"integridad":   documento.Integridad,
+	}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDocumentoCambiado
+	}
+
+	return nil
+}
+
+// filtroVersion matches an attachment while it is at a version; attachments stored before
+// versioning have no version (0)
+func filtroVersion(id primitive.ObjectID, version int) bson.M {
+	if version == 0 {
+		return bson.M{"_id": id, "version": bson.M{"$in": bson.A{0, nil}}}
+	}
+	return bson.M{"_id": id, "version": version}
+}
+
+// SetTexto records the text extraction state of an attachment, provided it is still at the
+// extracted version
+func (r *DocumentoRepository) SetTexto(id primitive.ObjectID, version int, estado models.EstadoTexto, paginas int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, filtroVersion(id, version), bson.M{"$set": bson.M{
+		"texto":   estado,
+		"paginas": paginas,
+	}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDocumentoCambiado
+	}
+
+	return nil
+}
+
+// SetConfidencial marks or unmarks an attachment as confidential
+func (r *DocumentoRepository) SetConfidencial(id primitive.ObjectID, confidencial bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"confidencial": confidencial}})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDocumentoNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the metadata of an attachment
+func (r *DocumentoRepository) Delete(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return ErrDocumentoNotFound
+	}
+
+	return nil
+}
+
+// Reasignar moves the attachments of a merged expediente to the surviving one. The files keep
+// their storage keys.
+func (r *DocumentoRepository) Reasignar(desde, hasta primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"expediente_id": desde}, bson.M{"$set": bson.M{"expediente_id": hasta}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
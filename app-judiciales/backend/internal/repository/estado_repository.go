@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// State machine errors
+var (
+	ErrTransicionNotFound = errors.New("transición de estado no encontrada")
+	ErrTransicionExists   = errors.New("la transición de estado ya existe")
+)
+
+// EstadoRepository handles the configurable state machine and the state history of expedientes
+type EstadoRepository struct {
+	db                     *database.Database
+	transicionesCollection *mongo.Collection
+	historialCollection    *mongo.Collection
+}
+
+// NewEstadoRepository creates a new estado repository
+func NewEstadoRepository(db *database.Database) *EstadoRepository {
+	return &EstadoRepository{
+		db:                     db,
+		transicionesCollection: db.Collection("estado_transiciones"),
+		historialCollection:    db.Collection("estado_historial"),
+	}
+}
+
+// defaultTransiciones is the lifecycle configured on first run
+var defaultTransiciones = []models.TransicionEstado{
+	{Desde: models.EstadoDentro, Hasta: models.EstadoFuera, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoFuera, Hasta: models.EstadoDentro, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoDentro, Hasta: models.EstadoEnDigitalizacion, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoEnDigitalizacion, Hasta: models.EstadoDentro, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoDentro, Hasta: models.EstadoEnRestauracion, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoEnRestauracion, Hasta: models.EstadoDentro, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoDentro, Hasta: models.EstadoExtraviado, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoFuera, Hasta: models.EstadoExtraviado, Permiso: models.PermissionExpedienteUpdate},
+	{Desde: models.EstadoExtraviado, Hasta: models.EstadoDentro, Permiso: models.PermissionExpedienteManage},
+	{Desde: models.EstadoDentro, Hasta: models.EstadoArchivadoDefinitivo, Permiso: models.PermissionExpedienteManage},
+	{Desde: models.EstadoArchivadoDefinitivo, Hasta: models.EstadoDentro, Permiso: models.PermissionExpedienteManage},
+	{Desde: models.EstadoDentro, Hasta: models.EstadoTransferido, Permiso: models.PermissionExpedienteManage},
+	{Desde: models.EstadoArchivadoDefinitivo, Hasta: models.EstadoTransferido, Permiso: models.PermissionExpedienteManage},
+}
+
+// InitializeDefaultTransiciones stores the default lifecycle when no transition is configured
+func (r *EstadoRepository) InitializeDefaultTransiciones(ctx context.Context) error {
+	count, err := r.transicionesCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to count transiciones: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	for _, transicion := range defaultTransiciones {
+		transicion := transicion
+		if err := r.CreateTransicion(&transicion); err != nil {
+			return fmt.Errorf("failed to create transicion %s -> %s: %w", transicion.Desde, transicion.Hasta, err)
+		}
+	}
+
+	return nil
+}
+
+// CreateTransicion stores a new allowed transition
+func (r *EstadoRepository) CreateTransicion(transicion *models.TransicionEstado) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	transicion.ID = primitive.NewObjectID()
+	transicion.CreatedAt = now
+	transicion.UpdatedAt = now
+
+	if _, err := r.transicionesCollection.InsertOne(ctx, transicion); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrTransicionExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetTransicion retrieves a transition by ID
+func (r *EstadoRepository) GetTransicion(id string) (*models.TransicionEstado, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	return r.findTransicion(ctx, bson.M{"_id": objID})
+}
+
+// FindTransicion retrieves the transition between two states
+func (r *EstadoRepository) FindTransicion(desde, hasta models.EstadoExpediente) (*models.TransicionEstado, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.findTransicion(ctx, bson.M{"desde": desde, "hasta": hasta})
+}
+
+// findTransicion decodes a single transition
+func (r *EstadoRepository) findTransicion(ctx context.Context, filter bson.M) (*models.TransicionEstado, error) {
+	var transicion models.TransicionEstado
+	if err := r.transicionesCollection.FindOne(ctx, filter).Decode(&transicion); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTransicionNotFound
+		}
+		return nil, err
+	}
+
+	return &transicion, nil
+}
+
+// GetTransiciones retrieves every configured transition
+func (r *EstadoRepository) GetTransiciones() ([]*models.TransicionEstado, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "desde", Value: 1}, {Key: "hasta", Value: 1}})
+	cursor, err := r.transicionesCollection.Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	transiciones := []*models.TransicionEstado{}
+	if err = cursor.All(ctx, &transiciones); err != nil {
+		return nil, err
+	}
+
+	return transiciones, nil
+}
+
+// UpdateTransicionPermiso changes the permission guarding a transition
+func (r *EstadoRepository) UpdateTransicionPermiso(id string, permiso models.Permission) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid ID format")
+	}
+
+	result, err := r.transicionesCollection.UpdateOne(ctx,
+		bson.M{"_id": objID},
+		bson.M{"$set": bson.M{"permiso": permiso, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTransicionNotFound
+	}
+
+	return nil
+}
+
+// DeleteTransicion removes a transition
+func (r *EstadoRepository) DeleteTransicion(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return errors.New("invalid ID format")
+	}
+
+	result, err := r.transicionesCollection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrTransicionNotFound
+	}
+
+	return nil
+}
+
+// AddHistorial stores an entry of the state history of an expediente
+func (r *EstadoRepository) AddHistorial(cambio *models.CambioEstado) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cambio.ID = primitive.NewObjectID()
+	if cambio.Fecha.IsZero() {
+		cambio.Fecha = time.Now()
+	}
+
+	_, err := r.historialCollection.InsertOne(ctx, cambio)
+	return err
+}
+
+// GetHistorial retrieves the state history of an expediente, newest first
+func (r *EstadoRepository) GetHistorial(expedienteID primitive.ObjectID) ([]models.CambioEstado, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "fecha", Value: -1}})
+	cursor, err := r.historialCollection.Find(ctx, bson.M{"expediente_id": expedienteID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	historial := []models.CambioEstado{}
+	if err = cursor.All(ctx, &historial); err != nil {
+		return nil, err
+	}
+
+	return historial, nil
+}
+
+// ReasignarHistorial moves the state history of an expediente to another one
+func (r *EstadoRepository) ReasignarHistorial(desde, hasta primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := r.historialCollection.UpdateMany(ctx, bson.M{"expediente_id": desde}, bson.M{"$set": bson.M{"expediente_id": hasta}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -5,7 +5,6 @@ import (
 	"errors"
 	"expedientes-backend/internal/database"
 	"expedientes-backend/internal/models"
-	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,10 +13,17 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrEstadoCambiado is returned when an expediente left the expected state before a transition was stored
+var ErrEstadoCambiado = errors.New("el estado del expediente cambió mientras se procesaba la transición")
+
+// ErrCarreraCambiada is returned when the grado or situación of an expediente changed before a career event was stored
+var ErrCarreraCambiada = errors.New("el grado o la situación del expediente cambió mientras se registraba el evento")
+
 // ExpedienteRepository handles expediente data operations
 type ExpedienteRepository struct {
 	db         *database.Database
 	collection *mongo.Collection
+	counters   *CounterRepository
 }
 
 // NewExpedienteRepository creates a new expediente repository
@@ -25,7 +31,43 @@ func NewExpedienteRepository(db *database.Database) *ExpedienteRepository {
 	return &ExpedienteRepository{
 		db:         db,
 		collection: db.Collection("expedientes"),
+		counters:   NewCounterRepository(db),
+	}
+}
+
+// ordenArchivo sorts expedientes in filing order: the grado/situación key stored in orden,
+// then surname, then registration number so that ties are always resolved the same way
+func ordenArchivo(order int) bson.D {
+	return bson.D{
+		{Key: "orden", Value: order},
+		{Key: "apellidos_nombres", Value: order},
+		{Key: "numero_registro", Value: order},
+	}
+}
+
+// visibleFilter returns the base filter for non-deleted expedientes readable within the scope
+func visibleFilter(scope models.AccessScope) bson.M {
+	return bson.M{
+		"deletedAt": bson.M{"$exists": false},
+		"$and":      []bson.M{scopeClause(scope)},
+	}
+}
+
+// scopeClause restricts a query to the classifications allowed by the caller's clearance
+func scopeClause(scope models.AccessScope) bson.M {
+	// Records created before classifications existed have no field and are public
+	allowed := []interface{}{nil, ""}
+	for _, c := range models.ClasificacionesPermitidas(scope.Clearance) {
+		allowed = append(allowed, c)
 	}
+	clause := bson.M{"clasificacion": bson.M{"$in": allowed}}
+
+	// Active break-glass grants open specific expedientes regardless of classification
+	if ids := scope.BreakGlassExpedienteIDs(); len(ids) > 0 {
+		return bson.M{"$or": []bson.M{clause, {"_id": bson.M{"$in": ids}}}}
+	}
+
+	return clause
 }
 
 // Create creates a new expediente
@@ -38,6 +80,15 @@ func (r *ExpedienteRepository) Create(expediente *models.Expediente) error {
 	expediente.FechaRegistro = time.Now()
 	expediente.FechaActualizacion = time.Now()
 	expediente.Estado = models.EstadoDentro // Default state
+	if expediente.Clasificacion == "" {
+		expediente.Clasificacion = models.ClasificacionPublico
+	}
+
+	numero, err := r.counters.Reserve(ctx, SecuenciaExpedientes, 1)
+	if err != nil {
+		return err
+	}
+	expediente.NumeroRegistro = numero
 
 	result, err := r.collection.InsertOne(ctx, expediente)
 	if err != nil {
@@ -48,8 +99,8 @@ func (r *ExpedienteRepository) Create(expediente *models.Expediente) error {
 	return nil
 }
 
-// GetByID retrieves an expediente by ID
-func (r *ExpedienteRepository) GetByID(id string) (*models.Expediente, error) {
+// GetByID retrieves an expediente by ID within the caller's access scope
+func (r *ExpedienteRepository) GetByID(id string, scope models.AccessScope) (*models.Expediente, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -59,7 +110,8 @@ func (r *ExpedienteRepository) GetByID(id string) (*models.Expediente, error) {
 	}
 
 	var expediente models.Expediente
-	filter := bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}}
+	filter := visibleFilter(scope)
+	filter["_id"] = objID
 	err = r.collection.FindOne(ctx, filter).Decode(&expediente)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -71,12 +123,39 @@ func (r *ExpedienteRepository) GetByID(id string) (*models.Expediente, error) {
 	return &expediente, nil
 }
 
-// GetAll retrieves all expedientes with pagination
-func (r *ExpedienteRepository) GetAll(page, limit int, sortBy, sortOrder string) ([]*models.Expediente, int64, error) {
+// GetByIDs retrieves the expedientes readable within the scope with any of the given IDs, in
+// archive order. IDs that are not found or not readable are left out.
+func (r *ExpedienteRepository) GetByIDs(ids []primitive.ObjectID, scope models.AccessScope) ([]*models.Expediente, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := visibleFilter(scope)
+	filter["_id"] = bson.M{"$in": ids}
+
+	findOptions := options.Find()
+	findOptions.SetSort(ordenArchivo(1))
+	findOptions.SetCollation(ubicacionCollation)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var expedientes []*models.Expediente
+	if err = cursor.All(ctx, &expedientes); err != nil {
+		return nil, err
+	}
+
+	return expedientes, nil
+}
+
+// GetAll retrieves all expedientes visible within the scope with pagination
+func (r *ExpedienteRepository) GetAll(page, limit int, sortBy, sortOrder string, scope models.AccessScope) ([]*models.Expediente, int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	filter := visibleFilter(scope)
 
 	// Count total documents
 	total, err := r.collection.CountDocuments(ctx, filter)
@@ -91,14 +170,16 @@ func (r *ExpedienteRepository) GetAll(page, limit int, sortBy, sortOrder string)
 	findOptions.SetLimit(int64(limit))
 
 	// Sorting
-	if sortBy != "" {
+	if sortBy != "" && sortBy != "orden" {
 		order := 1
 		if sortOrder == "desc" {
 			order = -1
 		}
 		findOptions.SetSort(bson.D{{Key: sortBy, Value: order}})
+	} else if sortOrder == "desc" {
+		findOptions.SetSort(ordenArchivo(-1))
 	} else {
-		findOptions.SetSort(bson.D{{Key: "orden", Value: 1}}) // Default sort by orden
+		findOptions.SetSort(ordenArchivo(1)) // Default sort by filing order
 	}
 
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
@@ -115,12 +196,12 @@ func (r *ExpedienteRepository) GetAll(page, limit int, sortBy, sortOrder string)
 	return expedientes, total, nil
 }
 
-// Search searches expedientes with filters
-func (r *ExpedienteRepository) Search(params models.ExpedienteSearchParams) ([]*models.Expediente, int64, error) {
+// Search searches expedientes visible within the scope with filters
+func (r *ExpedienteRepository) Search(params models.ExpedienteSearchParams, scope models.AccessScope) ([]*models.Expediente, int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	filter := visibleFilter(scope)
 
 	// Apply search filters
 	// General search across apellidos_nombres and CIP
@@ -155,6 +236,16 @@ func (r *ExpedienteRepository) Search(params models.ExpedienteSearchParams) ([]*
 	if params.Ano > 0 {
 		filter["ano"] = params.Ano
 	}
+	if params.Clasificacion != "" {
+		filter["clasificacion"] = params.Clasificacion
+	}
+	if params.DependenciaID != "" {
+		dependenciaID, err := primitive.ObjectIDFromHex(params.DependenciaID)
+		if err != nil {
+			return nil, 0, errors.New("invalid ID format")
+		}
+		filter["dependencia_id"] = dependenciaID
+	}
 	if !params.FechaInicio.IsZero() || !params.FechaFin.IsZero() {
 		dateFilter := bson.M{}
 		if !params.FechaInicio.IsZero() {
@@ -196,7 +287,11 @@ func (r *ExpedienteRepository) Search(params models.ExpedienteSearchParams) ([]*
 	if params.SortOrder == "desc" {
 		order = -1
 	}
-	findOptions.SetSort(bson.D{{Key: sortBy, Value: order}})
+	if sortBy == "orden" {
+		findOptions.SetSort(ordenArchivo(order))
+	} else {
+		findOptions.SetSort(bson.D{{Key: sortBy, Value: order}})
+	}
 
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
@@ -212,13 +307,14 @@ func (r *ExpedienteRepository) Search(params models.ExpedienteSearchParams) ([]*
 	return expedientes, total, nil
 }
 
-// GetAllForExport returns all expedientes with only the fields required for export
-func (r *ExpedienteRepository) GetAllForExport() ([]models.ExpedienteExport, error) {
+// GetAllForExport returns all expedientes visible within the scope with only the fields required for export
+func (r *ExpedienteRepository) GetAllForExport(scope models.AccessScope) ([]models.ExpedienteExport, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	filter := visibleFilter(scope)
 	projection := bson.M{
+		"clasificacion":     1,
 		"grado":             1,
 		"cip":               1,
 		"apellidos_nombres": 1,
@@ -279,8 +375,9 @@ func (r *ExpedienteRepository) Update(id string, updates map[string]interface{})
 	return nil
 }
 
-// UpdateEstado updates the estado of an expediente
-func (r *ExpedienteRepository) UpdateEstado(id string, estado models.EstadoExpediente, updatedBy primitive.ObjectID) error {
+// UpdateEstado moves an expediente from one estado to another. The change only applies
+// if the expediente is still in the expected state, so concurrent transitions cannot both win.
+func (r *ExpedienteRepository) UpdateEstado(id string, desde, hasta models.EstadoExpediente, updatedBy primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -291,14 +388,220 @@ func (r *ExpedienteRepository) UpdateEstado(id string, estado models.EstadoExped
 
 	update := bson.M{
 		"$set": bson.M{
-			"estado":              estado,
+			"estado":              hasta,
 			"updatedAt":           time.Now(),
 			"fecha_actualizacion": time.Now(),
 			"updatedBy":           updatedBy,
 		},
 	}
 
-	filter := bson.M{"_id": objID, "deletedAt": bson.M{"$exists": false}}
+	filter := bson.M{"_id": objID, "estado": desde, "deletedAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrEstadoCambiado
+	}
+
+	return nil
+}
+
+// UpdateCarrera applies a career event to the current grado, situación and filing order of an
+// expediente. The change only applies if the expediente still has the values the event started from.
+func (r *ExpedienteRepository) UpdateCarrera(id primitive.ObjectID, evento *models.EventoCarrera, orden int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"grado":               evento.GradoNuevo,
+			"situacion_militar":   evento.SituacionNueva,
+			"orden":               orden,
+			"updatedAt":           time.Now(),
+			"fecha_actualizacion": time.Now(),
+			"updatedBy":           evento.UsuarioID,
+		},
+	}
+
+	filter := bson.M{
+		"_id":               id,
+		"grado":             evento.GradoAnterior,
+		"situacion_militar": evento.SituacionAnterior,
+		"deletedAt":         bson.M{"$exists": false},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrCarreraCambiada
+	}
+
+	return nil
+}
+
+// UpdateCarreras applies the career events of a resolution batch with their resulting filing
+// order. Each expediente is only updated if it still has the values its event started from;
+// the number of updated expedientes is returned so the caller can detect stale ones.
+func (r *ExpedienteRepository) UpdateCarreras(eventos []*models.EventoCarrera, ordenes map[primitive.ObjectID]int) (int64, error) {
+	if len(eventos) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(eventos))
+	for _, evento := range eventos {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"_id":               evento.ExpedienteID,
+				"grado":             evento.GradoAnterior,
+				"situacion_militar": evento.SituacionAnterior,
+				"deletedAt":         bson.M{"$exists": false},
+			}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"grado":               evento.GradoNuevo,
+				"situacion_militar":   evento.SituacionNueva,
+				"orden":               ordenes[evento.ExpedienteID],
+				"updatedAt":           now,
+				"fecha_actualizacion": now,
+				"updatedBy":           evento.UsuarioID,
+			}}))
+	}
+
+	result, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.MatchedCount, nil
+}
+
+// GetByCIPs retrieves the expedientes readable within the scope with any of the given CIPs
+func (r *ExpedienteRepository) GetByCIPs(cips []string, scope models.AccessScope) ([]*models.Expediente, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := visibleFilter(scope)
+	filter["cip"] = bson.M{"$in": cips}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var expedientes []*models.Expediente
+	if err = cursor.All(ctx, &expedientes); err != nil {
+		return nil, err
+	}
+
+	return expedientes, nil
+}
+
+// GetBySituacion retrieves the expedientes readable within the scope in a situación militar
+func (r *ExpedienteRepository) GetBySituacion(situacion models.SituacionMilitar, scope models.AccessScope) ([]*models.Expediente, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := visibleFilter(scope)
+	filter["situacion_militar"] = situacion
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.M{"cip": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var expedientes []*models.Expediente
+	if err = cursor.All(ctx, &expedientes); err != nil {
+		return nil, err
+	}
+
+	return expedientes, nil
+}
+
+// UpdateResumenTomos stores the aggregates of the tomos of an expediente. While it has tomos
+// its page count and digitised pages are the sums of theirs.
+func (r *ExpedienteRepository) UpdateResumenTomos(id primitive.ObjectID, resumen *models.ResumenTomos) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{
+		"tomos":              resumen.Tomos,
+		"tomos_fuera":        resumen.TomosFuera,
+		"parcialmente_fuera": resumen.TomosFuera > 0 && resumen.TomosFuera < resumen.Tomos,
+		"updatedAt":          time.Now(),
+	}
+	if resumen.Tomos > 0 {
+		set["numero_paginas"] = resumen.NumeroPaginas
+		set["paginas_digitalizadas"] = resumen.Digitalizadas
+	}
+
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("expediente not found")
+	}
+
+	return nil
+}
+
+// GetIdentidades retrieves the identifying fields of every expediente readable within the scope
+func (r *ExpedienteRepository) GetIdentidades(scope models.AccessScope) ([]*models.Expediente, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetProjection(bson.M{
+		"cip":               1,
+		"apellidos_nombres": 1,
+		"grado":             1,
+		"situacion_militar": 1,
+		"numero_paginas":    1,
+		"estado":            1,
+		"tomos":             1,
+		"ubicacion":         1,
+	})
+
+	cursor, err := r.collection.Find(ctx, visibleFilter(scope), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var expedientes []*models.Expediente
+	if err = cursor.All(ctx, &expedientes); err != nil {
+		return nil, err
+	}
+
+	return expedientes, nil
+}
+
+// MarkFusionado soft-deletes an expediente merged into another one, keeping a reference to it
+func (r *ExpedienteRepository) MarkFusionado(id, supervivienteID, deletedBy primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"fusionado_en": supervivienteID,
+			"deletedAt":    &now,
+			"deletedBy":    &deletedBy,
+			"updatedAt":    now,
+		},
+	}
+
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}
 	result, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
@@ -343,6 +646,25 @@ func (r *ExpedienteRepository) Delete(id string, deletedBy primitive.ObjectID) e
 	return nil
 }
 
+// SetDigitalizadas stores the digitised pages of an expediente without tomos
+func (r *ExpedienteRepository) SetDigitalizadas(id primitive.ObjectID, paginas int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}
+	set := bson.M{"paginas_digitalizadas": paginas, "updatedAt": time.Now()}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("expediente not found")
+	}
+
+	return nil
+}
+
 // GetByCIP retrieves an expediente by CIP
 func (r *ExpedienteRepository) GetByCIP(cip string) (*models.Expediente, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -366,15 +688,13 @@ func (r *ExpedienteRepository) BulkCreate(expedientes []models.Expediente) ([]pr
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Get the next available orden number
-	nextOrden, err := r.getNextOrdenNumber(ctx)
+	// Reserve a block of registration numbers for the whole batch
+	primero, err := r.counters.Reserve(ctx, SecuenciaExpedientes, len(expedientes))
 	if err != nil {
 		return nil, err
 	}
-
-	// Assign orden numbers to all expedientes
 	for i := range expedientes {
-		expedientes[i].Orden = nextOrden + i
+		expedientes[i].NumeroRegistro = primero + int64(i)
 	}
 
 	// Convert to interface slice for bulk insert
@@ -397,24 +717,6 @@ func (r *ExpedienteRepository) BulkCreate(expedientes []models.Expediente) ([]pr
 	return insertedIDs, nil
 }
 
-// getNextOrdenNumber gets the next available orden number
-func (r *ExpedienteRepository) getNextOrdenNumber(ctx context.Context) (int, error) {
-	// Find the highest orden number
-	opts := options.FindOne().SetSort(bson.M{"orden": -1})
-	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
-
-	var lastExpediente models.Expediente
-	err := r.collection.FindOne(ctx, filter, opts).Decode(&lastExpediente)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return 1, nil // First expediente
-		}
-		return 0, err
-	}
-
-	return lastExpediente.Orden + 1, nil
-}
-
 // CheckDuplicateCIPs checks if any CIPs already exist in the database
 func (r *ExpedienteRepository) CheckDuplicateCIPs(cips []string) ([]string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -447,52 +749,57 @@ func (r *ExpedienteRepository) CheckDuplicateCIPs(cips []string) ([]string, erro
 
 // Dashboard Statistics Methods
 
-// GetDashboardStats retrieves comprehensive dashboard statistics
-func (r *ExpedienteRepository) GetDashboardStats() (*models.DashboardStats, error) {
+// GetDashboardStats retrieves comprehensive dashboard statistics counting only expedientes visible within the scope
+func (r *ExpedienteRepository) GetDashboardStats(scope models.AccessScope) (*models.DashboardStats, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	match := visibleFilter(scope)
+
 	stats := &models.DashboardStats{
 		GeneradoEn: time.Now().UTC(),
 	}
 
 	// Get general summary
-	resumen, err := r.getResumenGeneral(ctx)
+	resumen, err := r.getResumenGeneral(ctx, match)
 	if err != nil {
 		return nil, err
 	}
 	stats.ResumenGeneral = *resumen
 
 	// Get statistics by grade
-	gradoStats, err := r.getEstadisticasPorGrado(ctx, resumen.TotalExpedientes)
+	gradoStats, err := r.getEstadisticasPorGrado(ctx, match, resumen.TotalExpedientes)
 	if err != nil {
 		return nil, err
 	}
 	stats.EstadisticasPorGrado = gradoStats
 
 	// Get statistics by state
-	estadoStats, err := r.getEstadisticasPorEstado(ctx, resumen.TotalExpedientes)
+	estadoStats, err := r.getEstadisticasPorEstado(ctx, match, resumen.TotalExpedientes)
 	if err != nil {
 		return nil, err
 	}
 	stats.EstadisticasPorEstado = estadoStats
+	for _, estado := range estadoStats {
+		stats.ResumenGeneral.ExpedientesPorEstado[estado.Estado] = estado.Total
+	}
 
 	// Get statistics by military situation
-	situacionStats, err := r.getEstadisticasPorSituacion(ctx, resumen.TotalExpedientes)
+	situacionStats, err := r.getEstadisticasPorSituacion(ctx, match, resumen.TotalExpedientes)
 	if err != nil {
 		return nil, err
 	}
 	stats.EstadisticasPorSituacion = situacionStats
 
 	// Get statistics by location
-	ubicacionStats, err := r.getEstadisticasPorUbicacion(ctx, resumen.TotalExpedientes)
+	ubicacionStats, err := r.getEstadisticasPorUbicacion(ctx, match, resumen.TotalExpedientes)
 	if err != nil {
 		return nil, err
 	}
 	stats.EstadisticasPorUbicacion = ubicacionStats
 
 	// Get temporal statistics
-	temporalStats, err := r.getEstadisticasTemporales(ctx)
+	temporalStats, err := r.getEstadisticasTemporales(ctx, match)
 	if err != nil {
 		return nil, err
 	}
@@ -502,9 +809,9 @@ func (r *ExpedienteRepository) GetDashboardStats() (*models.DashboardStats, erro
 }
 
 // getResumenGeneral calculates general summary statistics
-func (r *ExpedienteRepository) getResumenGeneral(ctx context.Context) (*models.ResumenGeneral, error) {
+func (r *ExpedienteRepository) getResumenGeneral(ctx context.Context, match bson.M) (*models.ResumenGeneral, error) {
 	pipeline := []bson.M{
-		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$match": match},
 		{"$group": bson.M{
 			"_id":                nil,
 			"total_expedientes":  bson.M{"$sum": 1},
@@ -571,13 +878,14 @@ func (r *ExpedienteRepository) getResumenGeneral(ctx context.Context) (*models.R
 		PromedioPaginasPorExpediente: result.PromedioPaginasPorExpediente,
 		TotalPaginas:                 result.TotalPaginas,
 		UbicacionesUnicas:            result.UbicacionesUnicas,
+		ExpedientesPorEstado:         make(map[models.EstadoExpediente]int),
 	}, nil
 }
 
 // getEstadisticasPorGrado calculates statistics by military grade
-func (r *ExpedienteRepository) getEstadisticasPorGrado(ctx context.Context, totalExpedientes int) ([]models.GradoStats, error) {
+func (r *ExpedienteRepository) getEstadisticasPorGrado(ctx context.Context, match bson.M, totalExpedientes int) ([]models.GradoStats, error) {
 	pipeline := []bson.M{
-		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$match": match},
 		{"$group": bson.M{
 			"_id":           "$grado",
 			"total":         bson.M{"$sum": 1},
@@ -632,9 +940,9 @@ func (r *ExpedienteRepository) getEstadisticasPorGrado(ctx context.Context, tota
 }
 
 // getEstadisticasPorEstado calculates statistics by state
-func (r *ExpedienteRepository) getEstadisticasPorEstado(ctx context.Context, totalExpedientes int) ([]models.EstadoStats, error) {
+func (r *ExpedienteRepository) getEstadisticasPorEstado(ctx context.Context, match bson.M, totalExpedientes int) ([]models.EstadoStats, error) {
 	pipeline := []bson.M{
-		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$match": match},
 		{"$group": bson.M{
 			"_id":           "$estado",
 			"total":         bson.M{"$sum": 1},
@@ -648,7 +956,8 @@ func (r *ExpedienteRepository) getEstadisticasPorEstado(ctx context.Context, tot
 	}
 	defer cursor.Close(ctx)
 
-	var estadoStats []models.EstadoStats
+	porEstado := make(map[models.EstadoExpediente]models.EstadoStats)
+	var desconocidos []models.EstadoExpediente
 	for cursor.Next(ctx) {
 		var result struct {
 			Estado       models.EstadoExpediente `bson:"_id"`
@@ -664,21 +973,34 @@ func (r *ExpedienteRepository) getEstadisticasPorEstado(ctx context.Context, tot
 			porcentaje = (float64(result.Total) / float64(totalExpedientes)) * 100
 		}
 
-		estadoStats = append(estadoStats, models.EstadoStats{
+		porEstado[result.Estado] = models.EstadoStats{
 			Estado:       result.Estado,
 			Total:        result.Total,
 			Porcentaje:   porcentaje,
 			TotalPaginas: result.TotalPaginas,
-		})
+		}
+		if !result.Estado.IsValid() {
+			desconocidos = append(desconocidos, result.Estado)
+		}
+	}
+
+	// Every lifecycle state is reported, including those without expedientes
+	estadoStats := make([]models.EstadoStats, 0, len(porEstado))
+	for _, estado := range append(models.EstadosExpediente(), desconocidos...) {
+		stat, ok := porEstado[estado]
+		if !ok {
+			stat = models.EstadoStats{Estado: estado}
+		}
+		estadoStats = append(estadoStats, stat)
 	}
 
 	return estadoStats, nil
 }
 
 // getEstadisticasPorSituacion calculates statistics by military situation
-func (r *ExpedienteRepository) getEstadisticasPorSituacion(ctx context.Context, totalExpedientes int) ([]models.SituacionStats, error) {
+func (r *ExpedienteRepository) getEstadisticasPorSituacion(ctx context.Context, match bson.M, totalExpedientes int) ([]models.SituacionStats, error) {
 	pipeline := []bson.M{
-		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$match": match},
 		{"$group": bson.M{
 			"_id":           "$situacion_militar",
 			"total":         bson.M{"$sum": 1},
@@ -720,9 +1042,9 @@ func (r *ExpedienteRepository) getEstadisticasPorSituacion(ctx context.Context,
 }
 
 // getEstadisticasPorUbicacion calculates statistics by location (top 10)
-func (r *ExpedienteRepository) getEstadisticasPorUbicacion(ctx context.Context, totalExpedientes int) ([]models.UbicacionStats, error) {
+func (r *ExpedienteRepository) getEstadisticasPorUbicacion(ctx context.Context, match bson.M, totalExpedientes int) ([]models.UbicacionStats, error) {
 	pipeline := []bson.M{
-		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$match": match},
 		{"$group": bson.M{
 			"_id":           "$ubicacion",
 			"total":         bson.M{"$sum": 1},
@@ -766,22 +1088,23 @@ func (r *ExpedienteRepository) getEstadisticasPorUbicacion(ctx context.Context,
 }
 
 // getEstadisticasTemporales calculates temporal statistics
-func (r *ExpedienteRepository) getEstadisticasTemporales(ctx context.Context) (*models.EstadisticasTemporales, error) {
+func (r *ExpedienteRepository) getEstadisticasTemporales(ctx context.Context, match bson.M) (*models.EstadisticasTemporales, error) {
 	// Get records from last 30 days
 	thirtyDaysAgo := time.Now().AddDate(0, 0, -30)
 
 	// Count records from last 30 days
-	countLast30, err := r.collection.CountDocuments(ctx, bson.M{
-		"createdAt": bson.M{"$gte": thirtyDaysAgo},
-		"deletedAt": bson.M{"$exists": false},
-	})
+	recentFilter := bson.M{"createdAt": bson.M{"$gte": thirtyDaysAgo}}
+	for key, value := range match {
+		recentFilter[key] = value
+	}
+	countLast30, err := r.collection.CountDocuments(ctx, recentFilter)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get monthly statistics
 	monthlyPipeline := []bson.M{
-		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$match": match},
 		{"$group": bson.M{
 			"_id": bson.M{
 				"year":  bson.M{"$year": "$createdAt"},
@@ -834,7 +1157,7 @@ func (r *ExpedienteRepository) getEstadisticasTemporales(ctx context.Context) (*
 
 	// Get yearly statistics
 	yearlyPipeline := []bson.M{
-		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$match": match},
 		{"$group": bson.M{
 			"_id":   bson.M{"$year": "$createdAt"},
 			"total": bson.M{"$sum": 1},
@@ -891,15 +1214,16 @@ func (r *ExpedienteRepository) getEstadisticasTemporales(ctx context.Context) (*
 	}, nil
 }
 
-// ExportAll retrieves all expedientes for export (minimal fields only)
-func (r *ExpedienteRepository) ExportAll() ([]models.ExpedienteExport, error) {
+// ExportAll retrieves all expedientes visible within the scope for export (minimal fields only)
+func (r *ExpedienteRepository) ExportAll(scope models.AccessScope) ([]models.ExpedienteExport, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	filter := bson.M{"deletedAt": bson.M{"$exists": false}}
+	filter := visibleFilter(scope)
 
 	// Project only the fields needed for export
 	projection := bson.M{
+		"clasificacion":     1,
 		"grado":             1,
 		"cip":               1,
 		"apellidos_nombres": 1,
@@ -909,7 +1233,7 @@ func (r *ExpedienteRepository) ExportAll() ([]models.ExpedienteExport, error) {
 
 	findOptions := options.Find()
 	findOptions.SetProjection(projection)
-	findOptions.SetSort(bson.D{{Key: "orden", Value: 1}}) // Order by orden
+	findOptions.SetSort(ordenArchivo(1)) // Order by filing order
 
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
@@ -929,34 +1253,107 @@ func (r *ExpedienteRepository) ExportAll() ([]models.ExpedienteExport, error) {
 	return exports, nil
 }
 
+// ubicacionCollation compares ubicación codes in Spanish archive order, so that Ñ falls
+// between N and O in the division range queries
+var ubicacionCollation = &options.Collation{Locale: "es", Strength: 1}
+
+// divisionClause matches the expedientes stored in a division by ubicación range, grado and situación
+func divisionClause(division *models.Division) bson.M {
+	clause := bson.M{
+		"ubicacion": bson.M{
+			"$gte": division.RangoInicio,
+			"$lte": division.RangoFin,
+		},
+	}
+	if len(division.Grados) > 0 {
+		clause["grado"] = bson.M{"$in": division.Grados}
+	}
+	if division.Situacion != "" {
+		clause["situacion_militar"] = division.Situacion
+	}
+	return clause
+}
+
 // GetByDivision obtiene expedientes por división específica (optimizado)
-func (r *ExpedienteRepository) GetByDivision(divisionRange string, grados []models.Grado, situacion models.SituacionMilitar) ([]*models.Expediente, error) {
+func (r *ExpedienteRepository) GetByDivision(division *models.Division, scope models.AccessScope) ([]*models.Expediente, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Parsear el rango (ej: "AA–AM")
-	parts := strings.Split(divisionRange, "–")
-	if len(parts) != 2 {
-		return []*models.Expediente{}, nil
+	// Construir filtro
+	filter := visibleFilter(scope)
+	for key, value := range divisionClause(division) {
+		filter[key] = value
 	}
 
-	start := strings.TrimSpace(parts[0])
-	end := strings.TrimSpace(parts[1])
+	// Opciones de búsqueda
+	findOptions := options.Find()
+	findOptions.SetSort(ordenArchivo(1))
+	findOptions.SetCollation(ubicacionCollation)
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var expedientes []*models.Expediente
+	if err = cursor.All(ctx, &expedientes); err != nil {
+		return nil, err
+	}
+
+	return expedientes, nil
+}
+
+// GetUbicacionesByDivision returns the placement fields of every expediente stored in a division,
+// sorted by ubicación. Like the occupancy counts it ignores the caller's clearance.
+func (r *ExpedienteRepository) GetUbicacionesByDivision(division *models.Division) ([]models.ExpedienteUbicacion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := divisionClause(division)
+	filter["deletedAt"] = bson.M{"$exists": false}
+
+	return r.findUbicaciones(ctx, filter)
+}
+
+// GetAllUbicaciones returns the placement fields of every expediente, sorted by ubicación
+func (r *ExpedienteRepository) GetAllUbicaciones() ([]models.ExpedienteUbicacion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	return r.findUbicaciones(ctx, bson.M{"deletedAt": bson.M{"$exists": false}})
+}
+
+// GetUbicacionesByIDs returns the placement fields of the given expedientes. Like the occupancy
+// counts it ignores the caller's clearance; deleted and merged expedientes are left out.
+func (r *ExpedienteRepository) GetUbicacionesByIDs(ids []primitive.ObjectID) ([]models.ExpedienteUbicacion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-	// Construir filtro
 	filter := bson.M{
+		"_id":       bson.M{"$in": ids},
 		"deletedAt": bson.M{"$exists": false},
-		"ubicacion": bson.M{
-			"$gte": start,
-			"$lte": end,
-		},
-		"grado":             bson.M{"$in": grados},
-		"situacion_militar": situacion,
 	}
 
-	// Opciones de búsqueda
+	return r.findUbicaciones(ctx, filter)
+}
+
+// findUbicaciones runs a placement query projected to ExpedienteUbicacion
+func (r *ExpedienteRepository) findUbicaciones(ctx context.Context, filter bson.M) ([]models.ExpedienteUbicacion, error) {
 	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{Key: "orden", Value: 1}, {Key: "apellidos_nombres", Value: 1}})
+	findOptions.SetProjection(bson.M{
+		"cip":               1,
+		"apellidos_nombres": 1,
+		"grado":             1,
+		"situacion_militar": 1,
+		"ubicacion":         1,
+		"numero_paginas":    1,
+		"estado":            1,
+		"tomos":             1,
+		"clasificacion":     1,
+	})
+	findOptions.SetSort(bson.D{{Key: "ubicacion", Value: 1}, {Key: "apellidos_nombres", Value: 1}})
+	findOptions.SetCollation(ubicacionCollation)
 
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
@@ -964,10 +1361,520 @@ func (r *ExpedienteRepository) GetByDivision(divisionRange string, grados []mode
 	}
 	defer cursor.Close(ctx)
 
-	var expedientes []*models.Expediente
+	ubicaciones := []models.ExpedienteUbicacion{}
+	if err = cursor.All(ctx, &ubicaciones); err != nil {
+		return nil, err
+	}
+
+	return ubicaciones, nil
+}
+
+// GetOrdenamiento returns the ordering fields of every expediente in registration order
+func (r *ExpedienteRepository) GetOrdenamiento() ([]models.ExpedienteOrden, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	findOptions := options.Find()
+	findOptions.SetProjection(bson.M{
+		"numero_registro":   1,
+		"cip":               1,
+		"apellidos_nombres": 1,
+		"grado":             1,
+		"situacion_militar": 1,
+		"orden":             1,
+		"posicion_archivo":  1,
+	})
+	findOptions.SetSort(bson.D{{Key: "numero_registro", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"deletedAt": bson.M{"$exists": false}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	expedientes := []models.ExpedienteOrden{}
 	if err = cursor.All(ctx, &expedientes); err != nil {
 		return nil, err
 	}
 
 	return expedientes, nil
 }
+
+// UpdateOrdenes sets the filing order and the filing position of many expedientes in a
+// single bulk write
+func (r *ExpedienteRepository) UpdateOrdenes(posiciones map[primitive.ObjectID]models.PosicionArchivo) error {
+	if len(posiciones) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	operaciones := make([]mongo.WriteModel, 0, len(posiciones))
+	for id, posicion := range posiciones {
+		operaciones = append(operaciones, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"orden":               posicion.Orden,
+				"posicion_archivo":    posicion,
+				"updatedAt":           now,
+				"fecha_actualizacion": now,
+			}}))
+	}
+
+	_, err := r.collection.BulkWrite(ctx, operaciones, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// InitializeNumeroRegistro aligns the expediente sequence with the numbers already stored and
+// numbers the records created before registration numbers existed, oldest first. It returns
+// how many records were numbered.
+func (r *ExpedienteRepository) InitializeNumeroRegistro(ctx context.Context) (int, error) {
+	var ultimo struct {
+		NumeroRegistro int64 `bson:"numero_registro"`
+	}
+	err := r.collection.FindOne(ctx,
+		bson.M{"numero_registro": bson.M{"$exists": true}},
+		options.FindOne().SetSort(bson.M{"numero_registro": -1}).SetProjection(bson.M{"numero_registro": 1}),
+	).Decode(&ultimo)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return 0, err
+	}
+	if err := r.counters.EnsureAtLeast(ctx, SecuenciaExpedientes, ultimo.NumeroRegistro); err != nil {
+		return 0, err
+	}
+
+	cursor, err := r.collection.Find(ctx,
+		bson.M{"numero_registro": bson.M{"$exists": false}},
+		options.Find().
+			SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+			SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var pendientes []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err = cursor.All(ctx, &pendientes); err != nil {
+		return 0, err
+	}
+	if len(pendientes) == 0 {
+		return 0, nil
+	}
+
+	primero, err := r.counters.Reserve(ctx, SecuenciaExpedientes, len(pendientes))
+	if err != nil {
+		return 0, err
+	}
+
+	operaciones := make([]mongo.WriteModel, len(pendientes))
+	for i, pendiente := range pendientes {
+		operaciones[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": pendiente.ID, "numero_registro": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": bson.M{"numero_registro": primero + int64(i)}})
+	}
+	if _, err := r.collection.BulkWrite(ctx, operaciones, options.BulkWrite().SetOrdered(false)); err != nil {
+		return 0, err
+	}
+
+	return len(pendientes), nil
+}
+
+// UpdateUbicaciones sets the ubicación of many expedientes in a single bulk write
+func (r *ExpedienteRepository) UpdateUbicaciones(ubicaciones map[primitive.ObjectID]string) error {
+	if len(ubicaciones) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	operaciones := make([]mongo.WriteModel, 0, len(ubicaciones))
+	for id, ubicacion := range ubicaciones {
+		operaciones = append(operaciones, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"ubicacion":           ubicacion,
+				"updatedAt":           now,
+				"fecha_actualizacion": now,
+			}}))
+	}
+
+	_, err := r.collection.BulkWrite(ctx, operaciones, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// UpdateCIPs sets the CIP of many expedientes in a single bulk write
+func (r *ExpedienteRepository) UpdateCIPs(cips map[primitive.ObjectID]string) error {
+	if len(cips) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	operaciones := make([]mongo.WriteModel, 0, len(cips))
+	for id, cip := range cips {
+		operaciones = append(operaciones, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"cip":                 cip,
+				"updatedAt":           now,
+				"fecha_actualizacion": now,
+			}}))
+	}
+
+	_, err := r.collection.BulkWrite(ctx, operaciones, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// CountByDivisions returns the number of expedientes and pages stored in each division.
+// Physical occupancy counts every record regardless of the caller's clearance.
+func (r *ExpedienteRepository) CountByDivisions(divisions []*models.Division) (map[primitive.ObjectID]models.OcupacionDivision, error) {
+	ocupacion := make(map[primitive.ObjectID]models.OcupacionDivision, len(divisions))
+	if len(divisions) == 0 {
+		return ocupacion, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	facets := bson.M{}
+	for _, division := range divisions {
+		facets[division.ID.Hex()] = []bson.M{
+			{"$match": divisionClause(division)},
+			{"$group": bson.M{
+				"_id":         nil,
+				"expedientes": bson.M{"$sum": 1},
+				"paginas":     bson.M{"$sum": "$numero_paginas"},
+			}},
+		}
+	}
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"deletedAt": bson.M{"$exists": false}}},
+		{"$facet": facets},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetCollation(ubicacionCollation))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []map[string][]models.OcupacionDivision
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	for _, division := range divisions {
+		if len(results) > 0 && len(results[0][division.ID.Hex()]) > 0 {
+			ocupacion[division.ID] = results[0][division.ID.Hex()][0]
+		} else {
+			ocupacion[division.ID] = models.OcupacionDivision{}
+		}
+	}
+
+	return ocupacion, nil
+}
+
+// ErrDigitalizacionCambiada is returned when the digitisation state of an expediente changed before an update was stored
+var ErrDigitalizacionCambiada = errors.New("el estado de digitalización del expediente cambió mientras se procesaba la actualización")
+
+// estadoDigitalizacionClause matches the expedientes in a digitisation state; expedientes
+// never touched by the digitisation project have no state and are pending
+func estadoDigitalizacionClause(estado models.EstadoDigitalizacion) bson.M {
+	if estado == models.DigitalizacionPendiente {
+		return bson.M{"digitalizacion.estado": bson.M{"$in": []interface{}{nil, estado}}}
+	}
+	return bson.M{"digitalizacion.estado": estado}
+}
+
+// Aggregation expressions mirroring Expediente.EstadoDigitalizacion and models.DiscrepanciaPaginas
+var (
+	estadoDigitalizacionExpr = bson.M{"$ifNull": []interface{}{"$digitalizacion.estado", models.DigitalizacionPendiente}}
+	paginasExpr              = bson.M{"$ifNull": []interface{}{"$numero_paginas", 0}}
+	digitalizadasExpr        = bson.M{"$ifNull": []interface{}{"$paginas_digitalizadas", 0}}
+	discrepanciaExpr         = bson.M{"$or": []interface{}{
+		bson.M{"$gt": []interface{}{digitalizadasExpr, paginasExpr}},
+		bson.M{"$and": []interface{}{
+			bson.M{"$in": []interface{}{estadoDigitalizacionExpr, []models.EstadoDigitalizacion{models.DigitalizacionCompleto, models.DigitalizacionVerificado}}},
+			bson.M{"$ne": []interface{}{digitalizadasExpr, paginasExpr}},
+		}},
+	}}
+)
+
+// progresoDigitalizacionGroup sums the digitisation progress of the expedientes grouped by id
+func progresoDigitalizacionGroup(id interface{}) bson.M {
+	contar := func(estado models.EstadoDigitalizacion) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": []interface{}{bson.M{"$eq": []interface{}{estadoDigitalizacionExpr, estado}}, 1, 0}}}
+	}
+	return bson.M{
+		"_id":                   id,
+		"expedientes":           bson.M{"$sum": 1},
+		"pendientes":            contar(models.DigitalizacionPendiente),
+		"en_proceso":            contar(models.DigitalizacionEnProceso),
+		"completos":             contar(models.DigitalizacionCompleto),
+		"verificados":           contar(models.DigitalizacionVerificado),
+		"paginas":               bson.M{"$sum": paginasExpr},
+		"paginas_digitalizadas": bson.M{"$sum": bson.M{"$min": []interface{}{digitalizadasExpr, paginasExpr}}},
+		"discrepancias":         bson.M{"$sum": bson.M{"$cond": []interface{}{discrepanciaExpr, 1, 0}}},
+	}
+}
+
+// UpdateDigitalizacion stores digitisation fields of an expediente, only if its digitisation
+// state is still the expected one. Keys are relative to the digitalizacion subdocument except
+// paginas_digitalizadas, which lives on the expediente.
+func (r *ExpedienteRepository) UpdateDigitalizacion(id primitive.ObjectID, desde models.EstadoDigitalizacion, campos bson.M, quitar []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{"updatedAt": time.Now()}
+	for key, value := range campos {
+		if key == "paginas_digitalizadas" {
+			set[key] = value
+		} else {
+			set["digitalizacion."+key] = value
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(quitar) > 0 {
+		unset := bson.M{}
+		for _, key := range quitar {
+			unset["digitalizacion."+key] = ""
+		}
+		update["$unset"] = unset
+	}
+
+	filter := estadoDigitalizacionClause(desde)
+	filter["_id"] = id
+	filter["deletedAt"] = bson.M{"$exists": false}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrDigitalizacionCambiada
+	}
+
+	return nil
+}
+
+// SearchDigitalizacion lists the expedientes of the digitisation work list in filing order
+func (r *ExpedienteRepository) SearchDigitalizacion(params models.DigitalizacionSearchParams, scope models.AccessScope) ([]*models.Expediente, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := visibleFilter(scope)
+	if params.Estado != "" {
+		for key, value := range estadoDigitalizacionClause(params.Estado) {
+			filter[key] = value
+		}
+	}
+	if params.OperadorID != "" {
+		operadorID, err := primitive.ObjectIDFromHex(params.OperadorID)
+		if err != nil {
+			return nil, 0, errors.New("invalid ID format")
+		}
+		filter["digitalizacion.operador_id"] = operadorID
+	}
+	if params.Grado != "" {
+		filter["grado"] = params.Grado
+	}
+	if params.Discrepancia {
+		filter["$expr"] = discrepanciaExpr
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64((params.Page - 1) * params.Limit))
+	findOptions.SetLimit(int64(params.Limit))
+	findOptions.SetSort(ordenArchivo(1))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var expedientes []*models.Expediente
+	if err = cursor.All(ctx, &expedientes); err != nil {
+		return nil, 0, err
+	}
+
+	return expedientes, total, nil
+}
+
+// ResumenDigitalizacion aggregates the digitisation progress of the expedientes readable
+// within the scope: overall, per division, per grado and per assigned operator
+func (r *ExpedienteRepository) ResumenDigitalizacion(divisions []*models.Division, scope models.AccessScope) (*models.ResumenDigitalizacion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	facets := bson.M{
+		"general": []bson.M{
+			{"$group": progresoDigitalizacionGroup(nil)},
+		},
+		"por_grado": []bson.M{
+			{"$group": progresoDigitalizacionGroup("$grado")},
+			{"$sort": bson.M{"expedientes": -1}},
+		},
+		"por_operador": []bson.M{
+			{"$match": bson.M{"digitalizacion.operador_id": bson.M{"$exists": true}}},
+			{"$group": func() bson.M {
+				group := progresoDigitalizacionGroup("$digitalizacion.operador_id")
+				group["operador"] = bson.M{"$first": "$digitalizacion.operador"}
+				return group
+			}()},
+			{"$sort": bson.M{"operador": 1}},
+		},
+	}
+	for _, division := range divisions {
+		facets[division.ID.Hex()] = []bson.M{
+			{"$match": divisionClause(division)},
+			{"$group": progresoDigitalizacionGroup(nil)},
+		}
+	}
+
+	pipeline := []bson.M{
+		{"$match": visibleFilter(scope)},
+		{"$facet": facets},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetCollation(ubicacionCollation))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []bson.Raw
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	// The fixed facets and the per-division facets share the single result document
+	var facetas struct {
+		General     []models.ProgresoDigitalizacion `bson:"general"`
+		PorGrado    []models.GradoDigitalizacion    `bson:"por_grado"`
+		PorOperador []models.OperadorDigitalizacion `bson:"por_operador"`
+	}
+	divisiones := map[string][]models.ProgresoDigitalizacion{}
+	if len(results) > 0 {
+		if err := bson.Unmarshal(results[0], &facetas); err != nil {
+			return nil, err
+		}
+		if err := bson.Unmarshal(results[0], &divisiones); err != nil {
+			return nil, err
+		}
+	}
+
+	resumen := &models.ResumenDigitalizacion{
+		PorDivision: make([]models.DivisionDigitalizacion, 0, len(divisions)),
+		PorGrado:    []models.GradoDigitalizacion{},
+		PorOperador: []models.OperadorDigitalizacion{},
+	}
+	if len(facetas.General) > 0 {
+		resumen.General = facetas.General[0]
+	}
+	if facetas.PorGrado != nil {
+		resumen.PorGrado = facetas.PorGrado
+	}
+	if facetas.PorOperador != nil {
+		resumen.PorOperador = facetas.PorOperador
+	}
+	for _, division := range divisions {
+		item := models.DivisionDigitalizacion{
+			DivisionID: division.ID,
+			EstanteID:  division.EstanteID,
+			Numero:     division.Numero,
+			Rango:      division.Rango(),
+		}
+		if progreso := divisiones[division.ID.Hex()]; len(progreso) > 0 {
+			item.ProgresoDigitalizacion = progreso[0]
+		}
+		resumen.PorDivision = append(resumen.PorDivision, item)
+	}
+
+	return resumen, nil
+}
+
+// SetDependencia assigns an expediente to a dependencia, or unassigns it when dependenciaID is nil
+func (r *ExpedienteRepository) SetDependencia(id primitive.ObjectID, dependenciaID *primitive.ObjectID, updatedBy primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$exists": false}}
+	set := bson.M{"updatedAt": time.Now(), "updatedBy": updatedBy}
+	update := bson.M{"$set": set}
+	if dependenciaID != nil {
+		set["dependencia_id"] = *dependenciaID
+	} else {
+		update["$unset"] = bson.M{"dependencia_id": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return errors.New("expediente not found")
+	}
+
+	return nil
+}
+
+// CountByDependencia counts the expedientes assigned to a dependencia, deleted ones included
+func (r *ExpedienteRepository) CountByDependencia(dependenciaID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.collection.CountDocuments(ctx, bson.M{"dependencia_id": dependenciaID})
+}
+
+// CountAsignadosPorDependencia counts the expedientes readable within the scope assigned to
+// each dependencia
+func (r *ExpedienteRepository) CountAsignadosPorDependencia(scope models.AccessScope) (map[primitive.ObjectID]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := visibleFilter(scope)
+	match["dependencia_id"] = bson.M{"$exists": true}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$group": bson.M{"_id": "$dependencia_id", "total": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Total int64              `bson:"total"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	conteos := make(map[primitive.ObjectID]int64, len(results))
+	for _, result := range results {
+		conteos[result.ID] = result.Total
+	}
+
+	return conteos, nil
+}
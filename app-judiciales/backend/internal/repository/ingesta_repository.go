@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IngestaRepository keeps the log of the files processed by the hot-folder ingest
+type IngestaRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewIngestaRepository creates a new ingesta repository
+func NewIngestaRepository(db *database.Database) *IngestaRepository {
+	return &IngestaRepository{
+		db:         db,
+		collection: db.Collection("ingestas"),
+	}
+}
+
+// Create records a processed file
+func (r *IngestaRepository) Create(ingesta *models.Ingesta) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ingesta.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, ingesta)
+	return err
+}
+
+// List retrieves the processed files, newest first, optionally only those with an estado or a CIP
+func (r *IngestaRepository) List(estado models.EstadoIngesta, cip string, page, limit int) ([]models.Ingesta, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if estado != "" {
+		filter["estado"] = estado
+	}
+	if cip != "" {
+		filter["cip"] = cip
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSort(bson.D{{Key: "procesado_en", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	ingestas := []models.Ingesta{}
+	if err = cursor.All(ctx, &ingestas); err != nil {
+		return nil, 0, err
+	}
+
+	return ingestas, total, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IntegridadRepository records the fixity check failures of attached files
+type IntegridadRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewIntegridadRepository creates a new integridad repository
+func NewIntegridadRepository(db *database.Database) *IntegridadRepository {
+	return &IntegridadRepository{
+		db:         db,
+		collection: db.Collection("fallos_integridad"),
+	}
+}
+
+// Create records a fixity failure
+func (r *IntegridadRepository) Create(fallo *models.FalloIntegridad) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	fallo.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, fallo)
+	return err
+}
+
+// GetByDocumentos retrieves the most recent failures of the given attachments
+func (r *IntegridadRepository) GetByDocumentos(documentoIDs []primitive.ObjectID, limit int64) ([]models.FalloIntegridad, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "detectado_en", Value: -1}}).SetLimit(limit)
+	cursor, err := r.collection.Find(ctx, bson.M{"documento_id": bson.M{"$in": documentoIDs}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	fallos := []models.FalloIntegridad{}
+	if err = cursor.All(ctx, &fallos); err != nil {
+		return nil, err
+	}
+
+	return fallos, nil
+}
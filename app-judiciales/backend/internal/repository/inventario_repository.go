@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Inventory errors
+var (
+	ErrInventarioNotFound = errors.New("inventario no encontrado")
+	ErrInventarioEstado   = errors.New("el inventario no está en un estado que permita esta operación")
+)
+
+// InventarioRepository handles the physical inventory sessions of the archive
+type InventarioRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewInventarioRepository creates a new inventario repository
+func NewInventarioRepository(db *database.Database) *InventarioRepository {
+	return &InventarioRepository{
+		db:         db,
+		collection: db.Collection("inventarios"),
+	}
+}
+
+// Create stores a new inventory session
+func (r *InventarioRepository) Create(inventario *models.Inventario) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	inventario.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, inventario)
+	return err
+}
+
+// GetByID retrieves an inventory session with its scans by ID
+func (r *InventarioRepository) GetByID(id string) (*models.Inventario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var inventario models.Inventario
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&inventario); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrInventarioNotFound
+		}
+		return nil, err
+	}
+
+	return &inventario, nil
+}
+
+// List retrieves the inventory sessions without their scans and findings, newest first,
+// optionally only those in the given state
+func (r *InventarioRepository) List(estado models.EstadoInventario) ([]models.Inventario, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if estado != "" {
+		filter["estado"] = estado
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "creado_en", Value: -1}}).
+		SetProjection(bson.M{"lecturas": 0, "hallazgos": 0})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	inventarios := []models.Inventario{}
+	if err = cursor.All(ctx, &inventarios); err != nil {
+		return nil, err
+	}
+
+	return inventarios, nil
+}
+
+// AgregarLecturas appends scans to an open session and remembers the division the operator is
+// working in, failing with ErrInventarioEstado if the session is paused or closed
+func (r *InventarioRepository) AgregarLecturas(id primitive.ObjectID, lecturas []models.LecturaInventario, divisionActual *primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	set := bson.M{"actualizado_en": time.Now()}
+	if divisionActual != nil {
+		set["division_actual"] = *divisionActual
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"total_lecturas": len(lecturas)},
+	}
+	if len(lecturas) > 0 {
+		update["$push"] = bson.M{"lecturas": bson.M{"$each": lecturas}}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "estado": models.InventarioAbierto}, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrInventarioEstado
+	}
+
+	return nil
+}
+
+// CambiarEstado moves a session between the open and paused states, failing with
+// ErrInventarioEstado if it is no longer in the expected state
+func (r *InventarioRepository) CambiarEstado(id primitive.ObjectID, desde, hasta models.EstadoInventario) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "estado": desde}
+	update := bson.M{"$set": bson.M{"estado": hasta, "actualizado_en": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrInventarioEstado
+	}
+
+	return nil
+}
+
+// Cerrar closes an open or paused session and stores its final report, failing with
+// ErrInventarioEstado if it was already closed
+func (r *InventarioRepository) Cerrar(id primitive.ObjectID, resumen models.ResumenInventario, hallazgos []models.Hallazgo, closedBy primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{"_id": id, "estado": bson.M{"$ne": models.InventarioCerrado}}
+	update := bson.M{
+		"$set": bson.M{
+			"estado":         models.InventarioCerrado,
+			"resumen":        resumen,
+			"hallazgos":      hallazgos,
+			"cerrado_por":    closedBy,
+			"cerrado_en":     now,
+			"actualizado_en": now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrInventarioEstado
+	}
+
+	return nil
+}
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Movement errors
+var (
+	ErrMovimientoNotFound = errors.New("movimiento no encontrado")
+	ErrNumeracionOcupada  = errors.New("no se pudo asignar el número correlativo del movimiento; intente nuevamente")
+)
+
+// intentosNumeracion bounds the retries of a movement insert that lost its number to a concurrent one
+const intentosNumeracion = 10
+
+// MovimientoRepository handles the append-only register of movements of expedientes
+type MovimientoRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewMovimientoRepository creates a new movimiento repository
+func NewMovimientoRepository(db *database.Database) *MovimientoRepository {
+	return &MovimientoRepository{
+		db:         db,
+		collection: db.Collection("movimientos"),
+	}
+}
+
+// Create stores a movement with the next correlative number of its expediente. The unique
+// index on expediente and number rejects an insert whose number a concurrent movement took
+// first, and the insert is retried with the following one. A number only exists once its
+// movement is stored, so the numbering has no gaps.
+func (r *MovimientoRepository) Create(movimiento *models.Movimiento) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	movimiento.ID = primitive.NewObjectID()
+	movimiento.Fecha = time.Now()
+
+	for intento := 0; intento < intentosNumeracion; intento++ {
+		ultimo, err := r.ultimoNumero(ctx, movimiento.ExpedienteID)
+		if err != nil {
+			return err
+		}
+		movimiento.Numero = ultimo + 1
+
+		_, err = r.collection.InsertOne(ctx, movimiento)
+		if err == nil {
+			return nil
+		}
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+
+	return ErrNumeracionOcupada
+}
+
+// UltimoNumero returns the number of the latest movement of an expediente, 0 if it has none
+func (r *MovimientoRepository) UltimoNumero(expedienteID primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.ultimoNumero(ctx, expedienteID)
+}
+
+func (r *MovimientoRepository) ultimoNumero(ctx context.Context, expedienteID primitive.ObjectID) (int, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "numero", Value: -1}}).
+		SetProjection(bson.M{"numero": 1})
+
+	var ultimo models.Movimiento
+	err := r.collection.FindOne(ctx, bson.M{"expediente_id": expedienteID}, opts).Decode(&ultimo)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	return ultimo.Numero, nil
+}
+
+// GetByID retrieves a movement by its ID
+func (r *MovimientoRepository) GetByID(id primitive.ObjectID) (*models.Movimiento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var movimiento models.Movimiento
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&movimiento)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrMovimientoNotFound
+		}
+		return nil, err
+	}
+
+	return &movimiento, nil
+}
+
+// GetByNumero retrieves a movement of an expediente by its number
+func (r *MovimientoRepository) GetByNumero(expedienteID primitive.ObjectID, numero int) (*models.Movimiento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var movimiento models.Movimiento
+	err := r.collection.FindOne(ctx, bson.M{"expediente_id": expedienteID, "numero": numero}).Decode(&movimiento)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrMovimientoNotFound
+		}
+		return nil, err
+	}
+
+	return &movimiento, nil
+}
+
+// filtroMovimientos builds the filter of the movement search parameters
+func filtroMovimientos(params models.MovimientoSearchParams) (bson.M, error) {
+	filter := bson.M{}
+	if params.Tipo != "" {
+		filter["tipo"] = params.Tipo
+	}
+	if !params.FechaInicio.IsZero() || !params.FechaFin.IsZero() {
+		fecha := bson.M{}
+		if !params.FechaInicio.IsZero() {
+			fecha["$gte"] = params.FechaInicio
+		}
+		if !params.FechaFin.IsZero() {
+			// The end date is a whole day
+			fecha["$lt"] = params.FechaFin.AddDate(0, 0, 1)
+		}
+		filter["fecha"] = fecha
+	}
+	if params.UsuarioID != "" {
+		usuarioID, err := primitive.ObjectIDFromHex(params.UsuarioID)
+		if err != nil {
+			return nil, errors.New("invalid ID format")
+		}
+		filter["usuario_id"] = usuarioID
+	}
+	return filter, nil
+}
+
+// GetByExpediente retrieves a page of the movements of an expediente in numbering order
+func (r *MovimientoRepository) GetByExpediente(expedienteID primitive.ObjectID, params models.MovimientoSearchParams) ([]models.Movimiento, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter, err := filtroMovimientos(params)
+	if err != nil {
+		return nil, 0, err
+	}
+	filter["expediente_id"] = expedienteID
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64((params.Page - 1) * params.Limit))
+	findOptions.SetLimit(int64(params.Limit))
+	findOptions.SetSort(bson.D{{Key: "numero", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	movimientos := []models.Movimiento{}
+	if err = cursor.All(ctx, &movimientos); err != nil {
+		return nil, 0, err
+	}
+
+	return movimientos, total, nil
+}
+
+// Correcciones returns, for each of the given movement numbers of an expediente, the numbers
+// of the movements that correct it
+func (r *MovimientoRepository) Correcciones(expedienteID primitive.ObjectID, numeros []int) (map[int][]int, error) {
+	correcciones := make(map[int][]int)
+	if len(numeros) == 0 {
+		return correcciones, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"expediente_id": expedienteID, "corrige_a": bson.M{"$in": numeros}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "numero", Value: 1}}).
+		SetProjection(bson.M{"numero": 1, "corrige_a": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var movimientos []models.Movimiento
+	if err = cursor.All(ctx, &movimientos); err != nil {
+		return nil, err
+	}
+	for _, m := range movimientos {
+		correcciones[m.CorrigeA] = append(correcciones[m.CorrigeA], m.Numero)
+	}
+
+	return correcciones, nil
+}
+
+// Search retrieves a page of the movements matching the filters across the expedientes
+// readable within the scope, newest first
+func (r *MovimientoRepository) Search(params models.MovimientoSearchParams, scope models.AccessScope) ([]models.MovimientoReporte, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match, err := filtroMovimientos(params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$lookup": bson.M{
+			"from":         "expedientes",
+			"localField":   "expediente_id",
+			"foreignField": "_id",
+			"pipeline": []bson.M{
+				{"$match": visibleFilter(scope)},
+				{"$project": bson.M{"cip": 1, "apellidos_nombres": 1}},
+			},
+			"as": "expediente",
+		}},
+		{"$unwind": "$expediente"},
+		{"$addFields": bson.M{
+			"cip":               "$expediente.cip",
+			"apellidos_nombres": "$expediente.apellidos_nombres",
+		}},
+		{"$project": bson.M{"expediente": 0}},
+		{"$sort": bson.D{{Key: "fecha", Value: -1}, {Key: "numero", Value: -1}}},
+		{"$facet": bson.M{
+			"total": []bson.M{{"$count": "total"}},
+			"movimientos": []bson.M{
+				{"$skip": (params.Page - 1) * params.Limit},
+				{"$limit": params.Limit},
+			},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total []struct {
+			Total int64 `bson:"total"`
+		} `bson:"total"`
+		Movimientos []models.MovimientoReporte `bson:"movimientos"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+
+	movimientos := []models.MovimientoReporte{}
+	var total int64
+	if len(results) > 0 {
+		if results[0].Movimientos != nil {
+			movimientos = results[0].Movimientos
+		}
+		if len(results[0].Total) > 0 {
+			total = results[0].Total[0].Total
+		}
+	}
+
+	return movimientos, total, nil
+}
+
+// Reasignar moves the movements of an expediente to another one, numbering them after its
+// last movement. Each moved movement keeps the expediente and number it was first recorded with.
+func (r *MovimientoRepository) Reasignar(desde, hasta primitive.ObjectID, offsetNumero int) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	update := []bson.M{
+		{"$set": bson.M{
+			"expediente_id": hasta,
+			"numero":        bson.M{"$add": []interface{}{"$numero", offsetNumero}},
+			"corrige_a": bson.M{"$cond": []interface{}{
+				bson.M{"$gt": []interface{}{"$corrige_a", 0}},
+				bson.M{"$add": []interface{}{"$corrige_a", offsetNumero}},
+				"$$REMOVE",
+			}},
+			"origen": bson.M{"$ifNull": []interface{}{"$origen", bson.M{
+				"expediente_id": "$expediente_id",
+				"numero":        "$numero",
+			}}},
+		}},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"expediente_id": desde}, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Notification errors
+var (
+	ErrNotificacionNotFound = errors.New("notificación no encontrada")
+	ErrNotificacionCambiada = errors.New("la notificación ya no está pendiente")
+)
+
+// NotificacionRepository handles the notifications of movements
+type NotificacionRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewNotificacionRepository creates a new notificacion repository
+func NewNotificacionRepository(db *database.Database) *NotificacionRepository {
+	return &NotificacionRepository{
+		db:         db,
+		collection: db.Collection("notificaciones"),
+	}
+}
+
+// Create stores a new notification
+func (r *NotificacionRepository) Create(notificacion *models.Notificacion) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	notificacion.ID = primitive.NewObjectID()
+	notificacion.CreatedAt = time.Now()
+	notificacion.UpdatedAt = notificacion.CreatedAt
+
+	_, err := r.collection.InsertOne(ctx, notificacion)
+	return err
+}
+
+// GetByID retrieves a notification of an expediente
+func (r *NotificacionRepository) GetByID(expedienteID primitive.ObjectID, id string) (*models.Notificacion, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotificacionNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var notificacion models.Notificacion
+	err = r.collection.FindOne(ctx, bson.M{"_id": objectID, "expediente_id": expedienteID}).Decode(&notificacion)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotificacionNotFound
+		}
+		return nil, err
+	}
+
+	return &notificacion, nil
+}
+
+// movimientoPipeline adds the number and type of the movement of each notification
+var movimientoPipeline = []bson.M{
+	{"$lookup": bson.M{
+		"from":         "movimientos",
+		"localField":   "movimiento_id",
+		"foreignField": "_id",
+		"pipeline":     []bson.M{{"$project": bson.M{"numero": 1, "tipo": 1}}},
+		"as":           "movimiento",
+	}},
+	{"$unwind": "$movimiento"},
+	{"$addFields": bson.M{
+		"movimiento_numero": "$movimiento.numero",
+		"movimiento_tipo":   "$movimiento.tipo",
+	}},
+	{"$project": bson.M{"movimiento": 0}},
+}
+
+// GetByExpediente retrieves the notifications of an expediente, or of one of its movements
+// when movimientoID is not zero, oldest first
+func (r *NotificacionRepository) GetByExpediente(expedienteID, movimientoID primitive.ObjectID) ([]models.NotificacionMovimiento, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := bson.M{"expediente_id": expedienteID}
+	if !movimientoID.IsZero() {
+		match["movimiento_id"] = movimientoID
+	}
+
+	pipeline := append([]bson.M{{"$match": match}}, movimientoPipeline...)
+	pipeline = append(pipeline, bson.M{"$sort": bson.D{{Key: "created_at", Value: 1}}})
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	notificaciones := []models.NotificacionMovimiento{}
+	if err = cursor.All(ctx, &notificaciones); err != nil {
+		return nil, err
+	}
+
+	return notificaciones, nil
+}
+
+// UpdateEstado applies the fields of a status change to a notification that is still pending.
+// It returns ErrNotificacionCambiada if the notification was delivered or returned meanwhile.
+func (r *NotificacionRepository) UpdateEstado(id primitive.ObjectID, campos bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	campos["updated_at"] = time.Now()
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "estado": models.NotificacionPendiente},
+		bson.M{"$set": campos},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotificacionCambiada
+	}
+
+	return nil
+}
+
+// RegistrarEnvio records the outcome of sending an email notification: the send date when
+// the mailer accepted it, or the error when it failed
+func (r *NotificacionRepository) RegistrarEnvio(id primitive.ObjectID, fecha time.Time, envioErr error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{}
+	if envioErr != nil {
+		update["$set"] = bson.M{"error_envio": envioErr.Error(), "updated_at": time.Now()}
+	} else {
+		update["$set"] = bson.M{"fecha_envio": fecha, "updated_at": time.Now()}
+		update["$unset"] = bson.M{"error_envio": ""}
+	}
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// Pendientes retrieves the notifications still pending since before the given date across the
+// expedientes readable within the scope, oldest first. A notification is pending since it was
+// sent, or since it was registered when it has not been sent yet.
+func (r *NotificacionRepository) Pendientes(antesDe time.Time, metodo models.MetodoNotificacion, page, limit int, scope models.AccessScope) ([]models.NotificacionReporte, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	match := bson.M{
+		"estado": models.NotificacionPendiente,
+		"$or": []bson.M{
+			{"fecha_envio": bson.M{"$lte": antesDe}},
+			{"fecha_envio": nil, "created_at": bson.M{"$lte": antesDe}},
+		},
+	}
+	if metodo != "" {
+		match["metodo"] = metodo
+	}
+
+	pipeline := []bson.M{
+		{"$match": match},
+		{"$lookup": bson.M{
+			"from":         "expedientes",
+			"localField":   "expediente_id",
+			"foreignField": "_id",
+			"pipeline": []bson.M{
+				{"$match": visibleFilter(scope)},
+				{"$project": bson.M{"cip": 1, "apellidos_nombres": 1}},
+			},
+			"as": "expediente",
+		}},
+		{"$unwind": "$expediente"},
+		{"$addFields": bson.M{
+			"cip":               "$expediente.cip",
+			"apellidos_nombres": "$expediente.apellidos_nombres",
+			"pendiente_desde":   bson.M{"$ifNull": []interface{}{"$fecha_envio", "$created_at"}},
+		}},
+		{"$project": bson.M{"expediente": 0}},
+	}
+	pipeline = append(pipeline, movimientoPipeline...)
+	pipeline = append(pipeline,
+		bson.M{"$sort": bson.D{{Key: "pendiente_desde", Value: 1}, {Key: "_id", Value: 1}}},
+		bson.M{"$facet": bson.M{
+			"total": []bson.M{{"$count": "total"}},
+			"notificaciones": []bson.M{
+				{"$skip": (page - 1) * limit},
+				{"$limit": limit},
+			},
+		}},
+	)
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		Total []struct {
+			Total int64 `bson:"total"`
+		} `bson:"total"`
+		Notificaciones []models.NotificacionReporte `bson:"notificaciones"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, 0, err
+	}
+
+	notificaciones := []models.NotificacionReporte{}
+	var total int64
+	if len(results) > 0 {
+		if results[0].Notificaciones != nil {
+			notificaciones = results[0].Notificaciones
+		}
+		if len(results[0].Total) > 0 {
+			total = results[0].Total[0].Total
+		}
+	}
+
+	return notificaciones, total, nil
+}
+
+// Reasignar moves the notifications of an expediente to another one. Their movements are
+// moved along with them, so the links stay valid.
+func (r *NotificacionRepository) Reasignar(desde, hasta primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(ctx,
+		bson.M{"expediente_id": desde},
+		bson.M{"$set": bson.M{"expediente_id": hasta, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
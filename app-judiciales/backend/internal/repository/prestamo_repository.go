@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Loan errors
+var (
+	ErrPrestamoNotFound = errors.New("préstamo no encontrado")
+	ErrPrestamoCerrado  = errors.New("el préstamo ya fue devuelto")
+)
+
+// PrestamoRepository handles the loans of expedientes and tomos
+type PrestamoRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewPrestamoRepository creates a new prestamo repository
+func NewPrestamoRepository(db *database.Database) *PrestamoRepository {
+	return &PrestamoRepository{
+		db:         db,
+		collection: db.Collection("prestamos"),
+	}
+}
+
+// Create stores a new open loan
+func (r *PrestamoRepository) Create(prestamo *models.Prestamo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prestamo.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, prestamo)
+	return err
+}
+
+// Delete removes a loan; it only undoes a loan whose estado change could not be applied
+func (r *PrestamoRepository) Delete(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// GetAbierto retrieves the open loan of an expediente, or of one of its tomos when tomoID is set
+func (r *PrestamoRepository) GetAbierto(expedienteID primitive.ObjectID, tomoID *primitive.ObjectID) (*models.Prestamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"expediente_id": expedienteID,
+		"devolucion":    bson.M{"$exists": false},
+		"tomo_id":       bson.M{"$exists": false},
+	}
+	if tomoID != nil {
+		filter["tomo_id"] = *tomoID
+	}
+
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "salida", Value: -1}})
+	var prestamo models.Prestamo
+	if err := r.collection.FindOne(ctx, filter, findOptions).Decode(&prestamo); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPrestamoNotFound
+		}
+		return nil, err
+	}
+
+	return &prestamo, nil
+}
+
+// Cerrar records the return of a loan. It only applies while the loan is open, so two
+// returns of the same folder cannot both close it.
+func (r *PrestamoRepository) Cerrar(prestamo *models.Prestamo, usuarioID primitive.ObjectID, usuario string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	devolucion := time.Now()
+	update := bson.M{"$set": bson.M{
+		"devolucion":            devolucion,
+		"usuario_devolucion_id": usuarioID,
+		"usuario_devolucion":    usuario,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": prestamo.ID, "devolucion": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrPrestamoCerrado
+	}
+
+	prestamo.Devolucion = &devolucion
+	prestamo.UsuarioDevolucionID = &usuarioID
+	prestamo.UsuarioDevolucion = usuario
+	return nil
+}
+
+// GetByExpediente retrieves the loans of an expediente and its tomos, most recent first
+func (r *PrestamoRepository) GetByExpediente(expedienteID primitive.ObjectID) ([]models.Prestamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "salida", Value: -1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"expediente_id": expedienteID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	prestamos := []models.Prestamo{}
+	if err = cursor.All(ctx, &prestamos); err != nil {
+		return nil, err
+	}
+
+	return prestamos, nil
+}
+
+// GetAbiertos retrieves every open loan, or those lent to a dependencia when dependenciaID is
+// set, oldest first
+func (r *PrestamoRepository) GetAbiertos(dependenciaID *primitive.ObjectID) ([]models.Prestamo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"devolucion": bson.M{"$exists": false}}
+	if dependenciaID != nil {
+		filter["dependencia_id"] = *dependenciaID
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "salida", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	prestamos := []models.Prestamo{}
+	if err = cursor.All(ctx, &prestamos); err != nil {
+		return nil, err
+	}
+
+	return prestamos, nil
+}
+
+// CountByDependencia counts the loans, open or returned, made to a dependencia
+func (r *PrestamoRepository) CountByDependencia(dependenciaID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return r.collection.CountDocuments(ctx, bson.M{"dependencia_id": dependenciaID})
+}
+
+// CountAbiertosPorDependencia counts the open loans of each dependencia, limited to the
+// expedientes readable within the scope
+func (r *PrestamoRepository) CountAbiertosPorDependencia(scope models.AccessScope) (map[primitive.ObjectID]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{
+			"devolucion":     bson.M{"$exists": false},
+			"dependencia_id": bson.M{"$exists": true},
+		}},
+		{"$lookup": bson.M{
+			"from":         "expedientes",
+			"localField":   "expediente_id",
+			"foreignField": "_id",
+			"pipeline": []bson.M{
+				{"$match": visibleFilter(scope)},
+				{"$project": bson.M{"_id": 1}},
+			},
+			"as": "expediente",
+		}},
+		{"$match": bson.M{"expediente": bson.M{"$ne": []interface{}{}}}},
+		{"$group": bson.M{"_id": "$dependencia_id", "total": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Total int64              `bson:"total"`
+	}
+	if err = cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	conteos := make(map[primitive.ObjectID]int64, len(results))
+	for _, result := range results {
+		conteos[result.ID] = result.Total
+	}
+
+	return conteos, nil
+}
+
+// Reasignar moves the loan history of a merged expediente to the surviving one
+func (r *PrestamoRepository) Reasignar(desde, hasta primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"expediente_id": desde}, bson.M{"$set": bson.M{"expediente_id": hasta}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -239,6 +239,26 @@ func (r *ProfileRepository) InitializeSystemProfiles(ctx context.Context) error
 				models.PermissionExpedienteUpdate,
 				models.PermissionExpedienteDelete,
 				models.PermissionExpedienteManage,
+				models.PermissionExpedienteClassify,
+				models.PermissionExpedienteBreakGlass,
+				// Archive layout permissions
+				models.PermissionArchivoRead,
+				models.PermissionArchivoManage,
+				// Document attachment permissions
+				models.PermissionDocumentoRead,
+				models.PermissionDocumentoUpload,
+				models.PermissionDocumentoDelete,
+				models.PermissionDocumentoConfidential,
+				// Digitisation project permissions
+				models.PermissionDigitalizacionUpdate,
+				models.PermissionDigitalizacionAssign,
+				models.PermissionDigitalizacionVerify,
+				// Movement permissions
+				models.PermissionMovimientoCreate,
+				// Notification permissions
+				models.PermissionNotificacionManage,
+				// Dependencia permissions
+				models.PermissionDependenciaManage,
 				// System permissions
 				models.PermissionSystemRead,
 				models.PermissionSystemAdmin,
@@ -247,8 +267,9 @@ func (r *ProfileRepository) InitializeSystemProfiles(ctx context.Context) error
 				models.PermissionDashboardStats,
 				models.PermissionDashboardExport,
 			},
-			IsSystem: true,
-			Active:   true,
+			Clearance: models.ClasificacionSecreto,
+			IsSystem:  true,
+			Active:    true,
 		},
 	}
 
@@ -281,6 +302,7 @@ func (r *ProfileRepository) UpdateSystemProfile(ctx context.Context, profile *mo
 	update := bson.M{
 		"$set": bson.M{
 			"permissions": profile.Permissions,
+			"clearance":   profile.Clearance,
 			"description": profile.Description,
 			"updated_at":  time.Now(),
 		},
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ErrReconciliacionNotFound is returned when a roster reconciliation does not exist
+var ErrReconciliacionNotFound = errors.New("reconciliación no encontrada")
+
+// ReconciliacionRepository handles the reconciliations of personnel rosters with the archive
+type ReconciliacionRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewReconciliacionRepository creates a new reconciliacion repository
+func NewReconciliacionRepository(db *database.Database) *ReconciliacionRepository {
+	return &ReconciliacionRepository{
+		db:         db,
+		collection: db.Collection("reconciliaciones"),
+	}
+}
+
+// Create stores a reconciliation report
+func (r *ReconciliacionRepository) Create(reconciliacion *models.Reconciliacion) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reconciliacion.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(ctx, reconciliacion)
+	return err
+}
+
+// GetByID retrieves a reconciliation report by ID
+func (r *ReconciliacionRepository) GetByID(id string) (*models.Reconciliacion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var reconciliacion models.Reconciliacion
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&reconciliacion); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReconciliacionNotFound
+		}
+		return nil, err
+	}
+
+	return &reconciliacion, nil
+}
+
+// MarkAplicadas records the resolution batch the discrepancies of the given CIPs were applied with
+func (r *ReconciliacionRepository) MarkAplicadas(id primitive.ObjectID, cips []string, loteID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"discrepancias.$[d].lote_id": loteID}}
+	updateOptions := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"d.cip": bson.M{"$in": cips}}},
+	})
+
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update, updateOptions)
+	return err
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TextoRepository handles the text extracted from the pages of attachments
+type TextoRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewTextoRepository creates a new texto repository
+func NewTextoRepository(db *database.Database) *TextoRepository {
+	return &TextoRepository{
+		db:         db,
+		collection: db.Collection("paginas_documento"),
+	}
+}
+
+// Reemplazar stores the text of the pages of an attachment version and removes the pages of
+// any other version. Pages are keyed by documento, version and number, so running it twice
+// for the same version leaves a single copy.
+func (r *TextoRepository) Reemplazar(documentoID primitive.ObjectID, version int, paginas map[int]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if len(paginas) > 0 {
+		operaciones := make([]mongo.WriteModel, 0, len(paginas))
+		for numero, texto := range paginas {
+			filtro := bson.M{"documento_id": documentoID, "version": version, "pagina": numero}
+			operaciones = append(operaciones, mongo.NewReplaceOneModel().
+				SetFilter(filtro).
+				SetReplacement(models.PaginaDocumento{
+					DocumentoID: documentoID,
+					Version:     version,
+					Pagina:      numero,
+					Texto:       texto,
+				}).
+				SetUpsert(true))
+		}
+		if _, err := r.collection.BulkWrite(ctx, operaciones, options.BulkWrite().SetOrdered(false)); err != nil {
+			return err
+		}
+	}
+
+	numeros := make([]int, 0, len(paginas))
+	for numero := range paginas {
+		numeros = append(numeros, numero)
+	}
+	_, err := r.collection.DeleteMany(ctx, bson.M{
+		"documento_id": documentoID,
+		"$or": []bson.M{
+			{"version": bson.M{"$ne": version}},
+			{"pagina": bson.M{"$nin": numeros}},
+		},
+	})
+	return err
+}
+
+// DeleteByDocumento removes the text of every page of an attachment
+func (r *TextoRepository) DeleteByDocumento(documentoID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := r.collection.DeleteMany(ctx, bson.M{"documento_id": documentoID})
+	return err
+}
+
+// Buscar runs a full-text search over the current version of the attachments readable
+// within the scope, best matches first. Confidential attachments are left out unless asked for.
+func (r *TextoRepository) Buscar(consulta string, scope models.AccessScope, incluirConfidenciales bool, page, limit int) ([]models.ResultadoBusquedaTexto, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Solo cuenta el texto de la versión vigente del documento
+	documentoMatch := bson.M{"$expr": bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{"$_id", "$$documento"}},
+		bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, "$$version"}},
+	}}}
+	if !incluirConfidenciales {
+		documentoMatch["confidencial"] = bson.M{"$ne": true}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$text": bson.M{"$search": consulta}}}},
+		{{Key: "$addFields", Value: bson.M{"puntaje": bson.M{"$meta": "textScore"}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "documentos",
+			"let":  bson.M{"documento": "$documento_id", "version": "$version"},
+			"pipeline": bson.A{
+				bson.M{"$match": documentoMatch},
+				bson.M{"$project": bson.M{"expediente_id": 1, "nombre": 1, "tipo": 1}},
+			},
+			"as": "documento",
+		}}},
+		{{Key: "$unwind", Value: "$documento"}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": "expedientes",
+			"let":  bson.M{"expediente": "$documento.expediente_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$expediente"}}}},
+				bson.M{"$match": visibleFilter(scope)},
+				bson.M{"$project": bson.M{"cip": 1, "grado": 1, "apellidos_nombres": 1, "clasificacion": 1}},
+			},
+			"as": "expediente",
+		}}},
+		{{Key: "$unwind", Value: "$expediente"}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{bson.M{"$count": "n"}},
+			"resultados": bson.A{
+				bson.M{"$sort": bson.D{{Key: "puntaje", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$skip": int64((page - 1) * limit)},
+				bson.M{"$limit": int64(limit)},
+				bson.M{"$project": bson.M{
+					"_id":               0,
+					"expediente_id":     "$expediente._id",
+					"cip":               "$expediente.cip",
+					"grado":             "$expediente.grado",
+					"apellidos_nombres": "$expediente.apellidos_nombres",
+					"clasificacion":     "$expediente.clasificacion",
+					"documento_id":      1,
+					"nombre":            "$documento.nombre",
+					"tipo":              "$documento.tipo",
+					"version":           1,
+					"pagina":            1,
+					"puntaje":           1,
+					"texto":             1,
+				}},
+			},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var salida []struct {
+		Total []struct {
+			N int64 `bson:"n"`
+		} `bson:"total"`
+		Resultados []models.ResultadoBusquedaTexto `bson:"resultados"`
+	}
+	if err := cursor.All(ctx, &salida); err != nil {
+		return nil, 0, err
+	}
+
+	resultados := []models.ResultadoBusquedaTexto{}
+	var total int64
+	if len(salida) > 0 {
+		resultados = append(resultados, salida[0].Resultados...)
+		if len(salida[0].Total) > 0 {
+			total = salida[0].Total[0].N
+		}
+	}
+
+	return resultados, total, nil
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/database"
+	"expedientes-backend/internal/models"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Tomo errors
+var (
+	ErrTomoNotFound = errors.New("tomo no encontrado")
+	ErrTomoExists   = errors.New("el expediente ya tiene un tomo con ese número")
+)
+
+// TomoRepository handles the physical volumes of expedientes
+type TomoRepository struct {
+	db         *database.Database
+	collection *mongo.Collection
+}
+
+// NewTomoRepository creates a new tomo repository
+func NewTomoRepository(db *database.Database) *TomoRepository {
+	return &TomoRepository{
+		db:         db,
+		collection: db.Collection("tomos"),
+	}
+}
+
+// Create stores a new tomo
+func (r *TomoRepository) Create(tomo *models.Tomo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	tomo.ID = primitive.NewObjectID()
+	tomo.CreatedAt = now
+	tomo.UpdatedAt = now
+
+	if _, err := r.collection.InsertOne(ctx, tomo); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrTomoExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByID retrieves a tomo of an expediente
+func (r *TomoRepository) GetByID(expedienteID primitive.ObjectID, id string) (*models.Tomo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, errors.New("invalid ID format")
+	}
+
+	var tomo models.Tomo
+	filter := bson.M{"_id": objID, "expediente_id": expedienteID}
+	if err := r.collection.FindOne(ctx, filter).Decode(&tomo); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTomoNotFound
+		}
+		return nil, err
+	}
+
+	return &tomo, nil
+}
+
+// FindByID retrieves a tomo by its own ID, without knowing its expediente
+func (r *TomoRepository) FindByID(id primitive.ObjectID) (*models.Tomo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var tomo models.Tomo
+	if err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&tomo); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTomoNotFound
+		}
+		return nil, err
+	}
+
+	return &tomo, nil
+}
+
+// GetByExpediente retrieves the tomos of an expediente ordered by number
+func (r *TomoRepository) GetByExpediente(expedienteID primitive.ObjectID) ([]models.Tomo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "numero", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"expediente_id": expedienteID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tomos := []models.Tomo{}
+	if err = cursor.All(ctx, &tomos); err != nil {
+		return nil, err
+	}
+
+	return tomos, nil
+}
+
+// GetByExpedientes retrieves the tomos of several expedientes grouped by expediente and ordered by number
+func (r *TomoRepository) GetByExpedientes(expedienteIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.Tomo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "expediente_id", Value: 1}, {Key: "numero", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{"expediente_id": bson.M{"$in": expedienteIDs}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tomos []models.Tomo
+	if err = cursor.All(ctx, &tomos); err != nil {
+		return nil, err
+	}
+
+	porExpediente := make(map[primitive.ObjectID][]models.Tomo)
+	for _, tomo := range tomos {
+		porExpediente[tomo.ExpedienteID] = append(porExpediente[tomo.ExpedienteID], tomo)
+	}
+	return porExpediente, nil
+}
+
+// GetByNumero retrieves a tomo of an expediente by its number
+func (r *TomoRepository) GetByNumero(expedienteID primitive.ObjectID, numero int) (*models.Tomo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var tomo models.Tomo
+	if err := r.collection.FindOne(ctx, bson.M{"expediente_id": expedienteID, "numero": numero}).Decode(&tomo); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTomoNotFound
+		}
+		return nil, err
+	}
+
+	return &tomo, nil
+}
+
+// Update modifies the page range and location of a tomo
+func (r *TomoRepository) Update(id primitive.ObjectID, updates bson.M) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	updates["updated_at"] = time.Now()
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrTomoNotFound
+	}
+
+	return nil
+}
+
+// UpdateEstado moves a tomo from one estado to another, only if it is still in the expected state
+func (r *TomoRepository) UpdateEstado(id primitive.ObjectID, desde, hasta models.EstadoExpediente, updatedBy primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$set": bson.M{
+			"estado":     hasta,
+			"updated_at": time.Now(),
+			"updated_by": updatedBy,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": id, "estado": desde}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrEstadoCambiado
+	}
+
+	return nil
+}
+
+// Delete removes a tomo
+func (r *TomoRepository) Delete(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrTomoNotFound
+	}
+
+	return nil
+}
+
+// Resumen counts the tomos of an expediente, those away from their location, their pages and
+// their digitised pages
+func (r *TomoRepository) Resumen(expedienteID primitive.ObjectID) (*models.ResumenTomos, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := []bson.M{
+		{"$match": bson.M{"expediente_id": expedienteID}},
+		{"$group": bson.M{
+			"_id":                   nil,
+			"tomos":                 bson.M{"$sum": 1},
+			"numero_paginas":        bson.M{"$sum": "$numero_paginas"},
+			"paginas_digitalizadas": bson.M{"$sum": "$paginas_digitalizadas"},
+			"tomos_fuera": bson.M{"$sum": bson.M{
+				"$cond": []interface{}{bson.M{"$ne": []interface{}{"$estado", models.EstadoDentro}}, 1, 0},
+			}},
+		}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	resumen := &models.ResumenTomos{}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(resumen); err != nil {
+			return nil, err
+		}
+	}
+
+	return resumen, cursor.Err()
+}
+
+// Reasignar moves every tomo of an expediente to another one, renumbering them and shifting
+// their page ranges so they follow the tomos and pages already there
+func (r *TomoRepository) Reasignar(desde, hasta primitive.ObjectID, offsetNumero, offsetPaginas int, updatedBy primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	update := []bson.M{{"$set": bson.M{
+		"expediente_id": hasta,
+		"numero":        bson.M{"$add": []interface{}{"$numero", offsetNumero}},
+		"pagina_desde":  bson.M{"$add": []interface{}{"$pagina_desde", offsetPaginas}},
+		"pagina_hasta":  bson.M{"$add": []interface{}{"$pagina_hasta", offsetPaginas}},
+		"updated_at":    time.Now(),
+		"updated_by":    updatedBy,
+	}}}
+
+	result, err := r.collection.UpdateMany(ctx, bson.M{"expediente_id": desde}, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
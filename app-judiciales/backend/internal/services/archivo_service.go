@@ -0,0 +1,331 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"math"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Archivo catalog errors
+var (
+	ErrRangoInvalido        = errors.New("el rango de la división es inválido: el inicio debe ser menor o igual al fin")
+	ErrRangoSolapado        = errors.New("el rango se superpone con otra división para los mismos grados y situación")
+	ErrGradoInvalido        = errors.New("grado inválido")
+	ErrEstanteConDivisiones = errors.New("el estante tiene divisiones; elimínelas o muévalas antes de eliminarlo")
+)
+
+// ArchivoService handles the shelf and division catalog of the physical archive
+type ArchivoService struct {
+	archivoRepo    *repository.ArchivoRepository
+	expedienteRepo *repository.ExpedienteRepository
+}
+
+// NewArchivoService creates a new archivo service
+func NewArchivoService(archivoRepo *repository.ArchivoRepository, expedienteRepo *repository.ExpedienteRepository) *ArchivoService {
+	return &ArchivoService{
+		archivoRepo:    archivoRepo,
+		expedienteRepo: expedienteRepo,
+	}
+}
+
+// GetOcupacion returns every shelf with the occupancy of its divisions
+func (s *ArchivoService) GetOcupacion() ([]models.EstanteOcupacion, error) {
+	estantes, err := s.archivoRepo.GetEstantes()
+	if err != nil {
+		return nil, err
+	}
+
+	divisiones, err := s.archivoRepo.GetDivisiones(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conteos, err := s.expedienteRepo.CountByDivisions(divisiones)
+	if err != nil {
+		return nil, err
+	}
+
+	porEstante := make(map[primitive.ObjectID][]*models.Division)
+	for _, division := range divisiones {
+		porEstante[division.EstanteID] = append(porEstante[division.EstanteID], division)
+	}
+
+	resultado := make([]models.EstanteOcupacion, 0, len(estantes))
+	for _, estante := range estantes {
+		item := models.EstanteOcupacion{
+			Estante:    *estante,
+			Divisiones: []models.DivisionOcupacion{},
+		}
+		for _, division := range porEstante[estante.ID] {
+			ocupacion := calcularOcupacion(division, conteos[division.ID])
+			item.Expedientes += ocupacion.Expedientes
+			item.Divisiones = append(item.Divisiones, ocupacion)
+		}
+		resultado = append(resultado, item)
+	}
+
+	return resultado, nil
+}
+
+// calcularOcupacion expresses the counts of a division in its capacity unit
+func calcularOcupacion(division *models.Division, conteo models.OcupacionDivision) models.DivisionOcupacion {
+	ocupado := float64(conteo.Expedientes)
+	if division.UnidadCapacidad == models.UnidadCentimetros {
+		ocupado = float64(conteo.Paginas) * models.CentimetrosPorHoja
+	}
+
+	porcentaje := 0.0
+	if division.Capacidad > 0 {
+		porcentaje = math.Round(ocupado/float64(division.Capacidad)*10000) / 100
+	}
+
+	return models.DivisionOcupacion{
+		Division:          *division,
+		Rango:             division.Rango(),
+		Ocupado:           math.Round(ocupado*100) / 100,
+		Porcentaje:        porcentaje,
+		OcupacionDivision: conteo,
+	}
+}
+
+// GetEstante returns a shelf by ID
+func (s *ArchivoService) GetEstante(id string) (*models.Estante, error) {
+	return s.archivoRepo.GetEstante(id)
+}
+
+// CreateEstante creates a new shelf
+func (s *ArchivoService) CreateEstante(req *models.CreateEstanteRequest) (*models.Estante, error) {
+	estante := &models.Estante{
+		Numero:      req.Numero,
+		Nombre:      strings.TrimSpace(req.Nombre),
+		Descripcion: strings.TrimSpace(req.Descripcion),
+	}
+
+	if err := s.archivoRepo.CreateEstante(estante); err != nil {
+		return nil, err
+	}
+
+	return estante, nil
+}
+
+// UpdateEstante updates a shelf
+func (s *ArchivoService) UpdateEstante(id string, req *models.UpdateEstanteRequest) (*models.Estante, error) {
+	updates := make(map[string]interface{})
+	if req.Numero != nil {
+		updates["numero"] = *req.Numero
+	}
+	if req.Nombre != nil {
+		updates["nombre"] = strings.TrimSpace(*req.Nombre)
+	}
+	if req.Descripcion != nil {
+		updates["descripcion"] = strings.TrimSpace(*req.Descripcion)
+	}
+
+	if err := s.archivoRepo.UpdateEstante(id, updates); err != nil {
+		return nil, err
+	}
+
+	return s.archivoRepo.GetEstante(id)
+}
+
+// DeleteEstante deletes a shelf that no longer has divisions
+func (s *ArchivoService) DeleteEstante(id string) error {
+	estante, err := s.archivoRepo.GetEstante(id)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.archivoRepo.CountDivisiones(estante.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEstanteConDivisiones
+	}
+
+	return s.archivoRepo.DeleteEstante(id)
+}
+
+// GetDivision returns a division by ID
+func (s *ArchivoService) GetDivision(id string) (*models.Division, error) {
+	return s.archivoRepo.GetDivision(id)
+}
+
+// GetDivisiones returns the divisions of the catalog, optionally for a single shelf
+func (s *ArchivoService) GetDivisiones(estanteID string) ([]*models.Division, error) {
+	if estanteID == "" {
+		return s.archivoRepo.GetDivisiones(nil)
+	}
+
+	estante, err := s.archivoRepo.GetEstante(estanteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.archivoRepo.GetDivisiones(&estante.ID)
+}
+
+// CreateDivision creates a new division after validating its range
+func (s *ArchivoService) CreateDivision(req *models.CreateDivisionRequest) (*models.Division, error) {
+	estante, err := s.archivoRepo.GetEstante(req.EstanteID)
+	if err != nil {
+		return nil, err
+	}
+
+	division := &models.Division{
+		EstanteID:       estante.ID,
+		Numero:          req.Numero,
+		RangoInicio:     normalizarRango(req.RangoInicio),
+		RangoFin:        normalizarRango(req.RangoFin),
+		Capacidad:       req.Capacidad,
+		UnidadCapacidad: req.UnidadCapacidad,
+		Grados:          req.Grados,
+		Situacion:       req.Situacion,
+	}
+	if division.UnidadCapacidad == "" {
+		division.UnidadCapacidad = models.UnidadCarpetas
+	}
+	if division.Grados == nil {
+		division.Grados = []models.Grado{}
+	}
+
+	if err := s.validateDivision(division); err != nil {
+		return nil, err
+	}
+
+	if err := s.archivoRepo.CreateDivision(division); err != nil {
+		return nil, err
+	}
+
+	return division, nil
+}
+
+// UpdateDivision updates a division, re-validating the resulting range
+func (s *ArchivoService) UpdateDivision(id string, req *models.UpdateDivisionRequest) (*models.Division, error) {
+	division, err := s.archivoRepo.GetDivision(id)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.EstanteID != nil {
+		estante, err := s.archivoRepo.GetEstante(*req.EstanteID)
+		if err != nil {
+			return nil, err
+		}
+		division.EstanteID = estante.ID
+		updates["estante_id"] = estante.ID
+	}
+	if req.Numero != nil {
+		division.Numero = *req.Numero
+		updates["numero"] = *req.Numero
+	}
+	if req.RangoInicio != nil {
+		division.RangoInicio = normalizarRango(*req.RangoInicio)
+		updates["rango_inicio"] = division.RangoInicio
+	}
+	if req.RangoFin != nil {
+		division.RangoFin = normalizarRango(*req.RangoFin)
+		updates["rango_fin"] = division.RangoFin
+	}
+	if req.Capacidad != nil {
+		division.Capacidad = *req.Capacidad
+		updates["capacidad"] = *req.Capacidad
+	}
+	if req.UnidadCapacidad != nil {
+		division.UnidadCapacidad = *req.UnidadCapacidad
+		updates["unidad_capacidad"] = *req.UnidadCapacidad
+	}
+	if req.Grados != nil {
+		division.Grados = *req.Grados
+		updates["grados"] = *req.Grados
+	}
+	if req.Situacion != nil {
+		division.Situacion = *req.Situacion
+		updates["situacion"] = *req.Situacion
+	}
+
+	if err := s.validateDivision(division); err != nil {
+		return nil, err
+	}
+
+	if err := s.archivoRepo.UpdateDivision(id, updates); err != nil {
+		return nil, err
+	}
+
+	return s.archivoRepo.GetDivision(id)
+}
+
+// DeleteDivision deletes a division
+func (s *ArchivoService) DeleteDivision(id string) error {
+	return s.archivoRepo.DeleteDivision(id)
+}
+
+// validateDivision checks the grados, the range order and that no other division
+// holding the same grados and situación covers any part of the range
+func (s *ArchivoService) validateDivision(division *models.Division) error {
+	for _, grado := range division.Grados {
+		if !grado.IsValid() {
+			return fmt.Errorf("%w: %s", ErrGradoInvalido, grado)
+		}
+	}
+
+	if division.RangoInicio == "" || division.RangoFin == "" || models.CompararUbicacion(division.RangoInicio, division.RangoFin) > 0 {
+		return ErrRangoInvalido
+	}
+
+	divisiones, err := s.archivoRepo.GetDivisiones(nil)
+	if err != nil {
+		return err
+	}
+
+	for _, other := range divisiones {
+		if other.ID == division.ID {
+			continue
+		}
+		if !divisionesSolapan(division, other) {
+			continue
+		}
+		return fmt.Errorf("%w: división %d (%s)", ErrRangoSolapado, other.Numero, other.Rango())
+	}
+
+	return nil
+}
+
+// normalizarRango trims and uppercases a range bound so it compares like the stored ubicación
+func normalizarRango(value string) string {
+	return strings.ToUpper(strings.TrimSpace(value))
+}
+
+// divisionesSolapan reports whether two divisions could claim the same expediente
+func divisionesSolapan(a, b *models.Division) bool {
+	if models.CompararUbicacion(a.RangoInicio, b.RangoFin) > 0 || models.CompararUbicacion(b.RangoInicio, a.RangoFin) > 0 {
+		return false
+	}
+	return situacionesSeCruzan(a.Situacion, b.Situacion) && gradosSeCruzan(a.Grados, b.Grados)
+}
+
+// situacionesSeCruzan reports whether two situación filters can match the same expediente
+func situacionesSeCruzan(a, b models.SituacionMilitar) bool {
+	return a == "" || b == "" || a == b
+}
+
+// gradosSeCruzan reports whether two grado filters can match the same expediente
+func gradosSeCruzan(a, b []models.Grado) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, ga := range a {
+		for _, gb := range b {
+			if ga == gb {
+				return true
+			}
+		}
+	}
+	return false
+}
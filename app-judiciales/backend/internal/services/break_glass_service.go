@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Break-glass errors
+var (
+	ErrBreakGlassInnecesario   = errors.New("el usuario ya tiene acceso a este expediente con su nivel de acceso")
+	ErrBreakGlassAutorrevision = errors.New("un acceso de emergencia debe revisarlo un supervisor distinto de quien lo solicitó")
+	ErrBreakGlassJustificacion = fmt.Errorf("la justificación debe tener al menos %d caracteres", minJustificacionBreakGlass)
+)
+
+// minJustificacionBreakGlass is the minimum length, in characters, of an emergency access justification
+const minJustificacionBreakGlass = 20
+
+// BreakGlassService handles emergency access to expedientes outside the user's clearance
+type BreakGlassService struct {
+	breakGlassRepo *repository.BreakGlassRepository
+	expedienteRepo *repository.ExpedienteRepository
+	auditRepo      *repository.AuditRepository
+	notifier       *SupervisorNotifier
+	duration       time.Duration
+}
+
+// NewBreakGlassService creates a new break-glass service
+func NewBreakGlassService(breakGlassRepo *repository.BreakGlassRepository, expedienteRepo *repository.ExpedienteRepository, auditRepo *repository.AuditRepository, notifier *SupervisorNotifier, duration time.Duration) *BreakGlassService {
+	return &BreakGlassService{
+		breakGlassRepo: breakGlassRepo,
+		expedienteRepo: expedienteRepo,
+		auditRepo:      auditRepo,
+		notifier:       notifier,
+		duration:       duration,
+	}
+}
+
+// RequestAccess grants the caller a short emergency window to read an expediente.
+// Supervisors are alerted and the grant stays in the review queue until signed off.
+func (s *BreakGlassService) RequestAccess(expedienteID string, justificacion string, scope models.AccessScope) (*models.BreakGlassAccess, error) {
+	// Los espacios no cuentan para el mínimo, y una letra acentuada cuenta como un carácter
+	justificacion = strings.TrimSpace(justificacion)
+	if utf8.RuneCountInString(justificacion) < minJustificacionBreakGlass {
+		return nil, ErrBreakGlassJustificacion
+	}
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, models.SystemAccessScope())
+	if err != nil {
+		return nil, err
+	}
+
+	if scope.CanRead(expediente.Clasificacion) {
+		return nil, ErrBreakGlassInnecesario
+	}
+
+	// Re-requesting while a window is open returns the same grant
+	existing, err := s.breakGlassRepo.GetActiveForUserAndExpediente(scope.UserID, expediente.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	now := time.Now()
+	access := &models.BreakGlassAccess{
+		UsuarioID:     scope.UserID,
+		UsuarioEmail:  scope.Email,
+		ExpedienteID:  expediente.ID,
+		CIP:           expediente.CIP,
+		Clasificacion: expediente.Clasificacion,
+		Justificacion: justificacion,
+		IP:            scope.IP,
+		OtorgadoEn:    now,
+		ExpiraEn:      now.Add(s.duration),
+	}
+
+	if err := s.breakGlassRepo.Create(access); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionBreakGlassSolicitud,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles: map[string]interface{}{
+			"break_glass_id": access.ID.Hex(),
+			"justificacion":  access.Justificacion,
+			"expira_en":      access.ExpiraEn,
+		},
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de break-glass: %v", err)
+	}
+
+	log.Printf("🚨 BREAK-GLASS otorgado a %s para expediente %s hasta %s", scope.Email, expediente.CIP, access.ExpiraEn.Format(time.RFC3339))
+	go s.notifier.NotifyBreakGlass(access)
+
+	return access, nil
+}
+
+// GetActiveGrants returns the emergency grants currently open for a user
+func (s *BreakGlassService) GetActiveGrants(userID primitive.ObjectID) ([]models.BreakGlassAccess, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.breakGlassRepo.GetActiveForUser(ctx, userID)
+}
+
+// ListForReview returns emergency accesses for the admin review queue with their tagged read counts
+func (s *BreakGlassService) ListForReview(pendientes bool, page, limit int) ([]*models.BreakGlassAccess, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	accesses, total, err := s.breakGlassRepo.List(pendientes, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, access := range accesses {
+		lecturas, err := s.auditRepo.CountBreakGlassReads(access.ID.Hex())
+		if err != nil {
+			log.Printf("⚠️ Error contando lecturas de break-glass %s: %v", access.ID.Hex(), err)
+			continue
+		}
+		access.Lecturas = lecturas
+	}
+
+	return accesses, total, nil
+}
+
+// SignOff closes an emergency access in the review queue. Nobody can review their own access.
+func (s *BreakGlassService) SignOff(id string, observacion string, scope models.AccessScope) (*models.BreakGlassAccess, error) {
+	access, err := s.breakGlassRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if access.UsuarioID == scope.UserID {
+		return nil, ErrBreakGlassAutorrevision
+	}
+
+	if err := s.breakGlassRepo.SignOff(id, scope.UserID, strings.TrimSpace(observacion)); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionBreakGlassRevision,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: access.ExpedienteID.Hex(),
+		IP:        scope.IP,
+		Detalles: map[string]interface{}{
+			"break_glass_id": access.ID.Hex(),
+			"observacion":    observacion,
+		},
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de revisión break-glass: %v", err)
+	}
+
+	return s.breakGlassRepo.GetByID(id)
+}
@@ -0,0 +1,647 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Career errors
+var (
+	ErrCarreraRequiereEvento = errors.New("el grado y la situación militar solo cambian registrando un evento de carrera")
+	ErrEventoSinCambios      = errors.New("el evento de carrera no cambia el grado ni la situación militar")
+	ErrSituacionInvalida     = errors.New("situación militar inválida")
+	ErrFechaEfectivaInvalida = errors.New("fecha efectiva inválida")
+	ErrArchivoResolucion     = errors.New("archivo de resolución inválido")
+	ErrLoteConErrores        = errors.New("el lote tiene filas inválidas; corrija el archivo y vuelva a cargarlo")
+	ErrLoteSinCambios        = errors.New("el lote no contiene cambios para aplicar")
+	ErrLoteDesactualizado    = errors.New("un expediente del lote cambió desde la previsualización; vuelva a cargar el archivo")
+)
+
+// fechaCarrera is the layout of career dates
+const fechaCarrera = "2006-01-02"
+
+// formatosFechaResolucion are the date layouts accepted in resolution files. The last one is
+// how Excel renders dates with its default format.
+var formatosFechaResolucion = []string{fechaCarrera, "02/01/2006", "2/1/2006", "01-02-06"}
+
+// columnasResolucion are the columns of a resolution file, in order
+var columnasResolucion = []string{"CIP", "Grado", "SituacionMilitar", "FechaEfectiva", "Resolucion"}
+
+// CarreraService records the career events of the people behind expedientes. The grado and
+// situación stored on an expediente are derived from its latest event.
+type CarreraService struct {
+	carreraRepo    *repository.CarreraRepository
+	expedienteRepo *repository.ExpedienteRepository
+	auditRepo      *repository.AuditRepository
+	cipValidator   *CIPValidator
+}
+
+// NewCarreraService creates a new carrera service
+func NewCarreraService(carreraRepo *repository.CarreraRepository, expedienteRepo *repository.ExpedienteRepository, auditRepo *repository.AuditRepository, cipValidator *CIPValidator) *CarreraService {
+	return &CarreraService{
+		carreraRepo:    carreraRepo,
+		expedienteRepo: expedienteRepo,
+		auditRepo:      auditRepo,
+		cipValidator:   cipValidator,
+	}
+}
+
+// RegistrarEvento records a promotion or a change of situación and applies it to the
+// expediente. Events are kept in chronological order, so one cannot be effective before
+// the latest recorded event nor in the future.
+func (s *CarreraService) RegistrarEvento(expedienteID string, req *models.CreateEventoCarreraRequest, scope models.AccessScope) (*models.EventoCarrera, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	fecha, err := time.Parse(fechaCarrera, req.FechaEfectiva)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrFechaEfectivaInvalida, req.FechaEfectiva)
+	}
+	if fecha.After(time.Now()) {
+		return nil, fmt.Errorf("%w: %s es posterior a hoy", ErrFechaEfectivaInvalida, req.FechaEfectiva)
+	}
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	evento := &models.EventoCarrera{
+		ExpedienteID:      expediente.ID,
+		FechaEfectiva:     fecha,
+		Resolucion:        strings.TrimSpace(req.Resolucion),
+		GradoAnterior:     expediente.Grado,
+		GradoNuevo:        expediente.Grado,
+		SituacionAnterior: expediente.SituacionMilitar,
+		SituacionNueva:    expediente.SituacionMilitar,
+		Observaciones:     strings.TrimSpace(req.Observaciones),
+		UsuarioID:         scope.UserID,
+		Usuario:           scope.Email,
+	}
+	if req.Grado != nil {
+		if !req.Grado.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrGradoInvalido, *req.Grado)
+		}
+		evento.GradoNuevo = *req.Grado
+	}
+	if req.SituacionMilitar != nil {
+		if !req.SituacionMilitar.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrSituacionInvalida, *req.SituacionMilitar)
+		}
+		evento.SituacionNueva = *req.SituacionMilitar
+	}
+	if !evento.CambiaGrado() && !evento.CambiaSituacion() {
+		return nil, ErrEventoSinCambios
+	}
+
+	ultimo, err := s.carreraRepo.GetUltimo(expediente.ID)
+	if err != nil {
+		return nil, err
+	}
+	if ultimo != nil && fecha.Before(ultimo.FechaEfectiva) {
+		return nil, fmt.Errorf("%w: es anterior al último evento registrado (%s)", ErrFechaEfectivaInvalida, ultimo.FechaEfectiva.Format(fechaCarrera))
+	}
+
+	if err := s.carreraRepo.Create(evento); err != nil {
+		return nil, err
+	}
+
+	orden := calculateOrden(evento.GradoNuevo, evento.SituacionNueva)
+	if err := s.expedienteRepo.UpdateCarrera(expediente.ID, evento, orden); err != nil {
+		// Sin aplicar el cambio, el evento no puede quedar en la carrera
+		if delErr := s.carreraRepo.Delete(evento.ID); delErr != nil {
+			log.Printf("⚠️ Error eliminando el evento de carrera %s no aplicado: %v", evento.ID.Hex(), delErr)
+		}
+		return nil, err
+	}
+
+	return evento, nil
+}
+
+// GetCarrera returns the grado and situación of an expediente as of a date, with the events
+// effective up to that date. A zero date means today.
+func (s *CarreraService) GetCarrera(expedienteID string, fecha time.Time, scope models.AccessScope) (*models.CarreraExpediente, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	eventos, err := s.carreraRepo.GetByExpediente(expediente.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if fecha.IsZero() {
+		fecha = time.Now()
+	}
+
+	carrera := &models.CarreraExpediente{
+		Fecha:            fecha,
+		Grado:            expediente.Grado,
+		SituacionMilitar: expediente.SituacionMilitar,
+		Eventos:          []models.EventoCarrera{},
+	}
+
+	// Antes del primer evento rigen los valores de los que partió
+	if len(eventos) > 0 {
+		carrera.Grado = eventos[0].GradoAnterior
+		carrera.SituacionMilitar = eventos[0].SituacionAnterior
+	}
+	for _, evento := range eventos {
+		if evento.FechaEfectiva.After(fecha) {
+			break
+		}
+		carrera.Grado = evento.GradoNuevo
+		carrera.SituacionMilitar = evento.SituacionNueva
+		carrera.Eventos = append(carrera.Eventos, evento)
+	}
+
+	return carrera, nil
+}
+
+// SearchEventos returns the career events matching a report, such as the promotions of a year
+func (s *CarreraService) SearchEventos(params models.EventoCarreraSearchParams, scope models.AccessScope) ([]models.EventoCarreraReporte, error) {
+	for _, grado := range params.Grados {
+		if !grado.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrGradoInvalido, grado)
+		}
+	}
+	if params.Situacion != "" && !params.Situacion.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrSituacionInvalida, params.Situacion)
+	}
+
+	return s.carreraRepo.Search(params, scope)
+}
+
+// PrevisualizarLote reads a resolution file (Excel or CSV) and matches each row by CIP against
+// the current expedientes. The resulting batch is stored so it can be reviewed and applied.
+// fechaDefecto and resolucionDefecto fill the rows that leave those columns empty.
+func (s *CarreraService) PrevisualizarLote(file *multipart.FileHeader, fechaDefecto, resolucionDefecto string, scope models.AccessScope) (*models.LoteResolucion, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid createdBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	rows, err := leerArchivoTabla(file, columnasResolucion, ErrArchivoResolucion)
+	if err != nil {
+		return nil, err
+	}
+
+	filas := []models.FilaResolucion{}
+	vistos := make(map[string]bool)
+	for i, row := range rows[1:] {
+		celda := func(col int) string {
+			if col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+
+		fila := models.FilaResolucion{
+			Fila:       i + 2, // +2 because index starts at 0 and we skip header
+			CIP:        s.cipValidator.Normalizar(celda(0), normalizarGrado(celda(1))),
+			Resolucion: celda(4),
+		}
+		if fila.Resolucion == "" {
+			fila.Resolucion = strings.TrimSpace(resolucionDefecto)
+		}
+		fecha := celda(3)
+		if fecha == "" {
+			fecha = strings.TrimSpace(fechaDefecto)
+		}
+
+		if msg := leerFilaResolucion(&fila, celda(1), celda(2), fecha); msg != "" {
+			fila.Resultado = models.FilaInvalida
+			fila.Error = msg
+		} else if vistos[s.cipValidator.Clave(fila.CIP)] {
+			fila.Resultado = models.FilaDuplicada
+			fila.Error = "el CIP ya aparece en una fila anterior"
+		} else {
+			vistos[s.cipValidator.Clave(fila.CIP)] = true
+		}
+		filas = append(filas, fila)
+	}
+	if len(filas) == 0 {
+		return nil, fmt.Errorf("%w: no contiene filas de datos", ErrArchivoResolucion)
+	}
+
+	return s.CrearLote(file.Filename, filas, scope)
+}
+
+// CrearLote matches rows of new grados and situaciones against the current expedientes and
+// stores them as a batch to be applied. Rows that already carry a result are kept as they are.
+func (s *CarreraService) CrearLote(archivo string, filas []models.FilaResolucion, scope models.AccessScope) (*models.LoteResolucion, error) {
+	scope = scope.WithoutBreakGlass()
+
+	lote := &models.LoteResolucion{
+		Archivo:   archivo,
+		Estado:    models.PlanPropuesto,
+		Filas:     filas,
+		CreadoPor: scope.UserID,
+		CreadoEn:  time.Now(),
+	}
+
+	if err := s.compararLote(lote, scope); err != nil {
+		return nil, err
+	}
+
+	if err := s.carreraRepo.CreateLote(lote); err != nil {
+		return nil, err
+	}
+
+	// Las filas solo encontraron expedientes visibles para quien carga el lote
+	storeClassifiedAccess(s.auditRepo, reservarFilas(lote.Filas, "lote_resolucion", scope))
+	return lote, nil
+}
+
+// leerFilaResolucion parses the new grado, situación and effective date of a row, returning
+// a description of the first problem found
+func leerFilaResolucion(fila *models.FilaResolucion, grado, situacion, fecha string) string {
+	if fila.CIP == "" {
+		return "CIP requerido"
+	}
+
+	if grado != "" {
+		fila.GradoNuevo = normalizarGrado(grado)
+		if !fila.GradoNuevo.IsValid() {
+			return fmt.Sprintf("grado inválido: %s", grado)
+		}
+	}
+	if situacion != "" {
+		fila.SituacionNueva = normalizarSituacion(situacion)
+		if !fila.SituacionNueva.IsValid() {
+			return fmt.Sprintf("situación militar inválida: %s", situacion)
+		}
+	}
+	if fila.GradoNuevo == "" && fila.SituacionNueva == "" {
+		return "debe indicar el nuevo grado o la nueva situación militar"
+	}
+
+	if fecha == "" {
+		return "fecha efectiva requerida"
+	}
+	for _, formato := range formatosFechaResolucion {
+		if parsed, err := time.Parse(formato, fecha); err == nil {
+			fila.FechaEfectiva = &parsed
+			break
+		}
+	}
+	if fila.FechaEfectiva == nil {
+		return fmt.Sprintf("fecha efectiva inválida: %s", fecha)
+	}
+	if fila.FechaEfectiva.After(time.Now()) {
+		return fmt.Sprintf("fecha efectiva posterior a hoy: %s", fecha)
+	}
+
+	if fila.Resolucion == "" {
+		return "número de resolución requerido"
+	}
+
+	return ""
+}
+
+// normalizarGrado reads a grado as typed in a spreadsheet, ignoring case and extra spaces
+func normalizarGrado(grado string) models.Grado {
+	return models.Grado(strings.ToUpper(strings.Join(strings.Fields(grado), " ")))
+}
+
+// normalizarSituacion reads a situación militar ignoring case; unknown values are returned as they are
+func normalizarSituacion(situacion string) models.SituacionMilitar {
+	for _, valida := range []models.SituacionMilitar{models.SituacionActividad, models.SituacionRetiro} {
+		if strings.EqualFold(situacion, string(valida)) {
+			return valida
+		}
+	}
+	return models.SituacionMilitar(situacion)
+}
+
+// compararLote matches the rows of a batch that have no result yet with the current
+// expedientes and classifies them. A row matches whatever leading zeros its CIP was typed
+// with, and takes the canonical CIP of its expediente.
+func (s *CarreraService) compararLote(lote *models.LoteResolucion, scope models.AccessScope) error {
+	var cips []string
+	for _, fila := range lote.Filas {
+		if fila.Resultado == "" {
+			cips = append(cips, s.cipValidator.Candidatos(fila.CIP)...)
+		}
+	}
+
+	var expedientes []*models.Expediente
+	if len(cips) > 0 {
+		var err error
+		if expedientes, err = s.expedienteRepo.GetByCIPs(cips, scope); err != nil {
+			return err
+		}
+	}
+
+	porCIP := make(map[string]*models.Expediente, len(expedientes))
+	ids := make([]primitive.ObjectID, 0, len(expedientes))
+	for _, expediente := range expedientes {
+		porCIP[s.cipValidator.Clave(expediente.CIP)] = expediente
+		ids = append(ids, expediente.ID)
+	}
+
+	ultimas := map[primitive.ObjectID]time.Time{}
+	if len(ids) > 0 {
+		var err error
+		if ultimas, err = s.carreraRepo.GetUltimasFechas(ids); err != nil {
+			return err
+		}
+	}
+
+	for i := range lote.Filas {
+		fila := &lote.Filas[i]
+		if fila.Resultado != "" {
+			continue
+		}
+
+		expediente, ok := porCIP[s.cipValidator.Clave(fila.CIP)]
+		if !ok {
+			fila.Resultado = models.FilaCIPDesconocido
+			continue
+		}
+
+		fila.CIP = expediente.CIP
+		fila.ExpedienteID = &expediente.ID
+		fila.ApellidosNombres = expediente.ApellidosNombres
+		fila.GradoActual = expediente.Grado
+		fila.SituacionActual = expediente.SituacionMilitar
+		fila.Clasificacion = expediente.Clasificacion
+		if fila.GradoNuevo == "" {
+			fila.GradoNuevo = expediente.Grado
+		}
+		if fila.SituacionNueva == "" {
+			fila.SituacionNueva = expediente.SituacionMilitar
+		}
+
+		switch ultima, tiene := ultimas[expediente.ID]; {
+		case fila.GradoNuevo == fila.GradoActual && fila.SituacionNueva == fila.SituacionActual:
+			fila.Resultado = models.FilaSinCambios
+		case tiene && fila.FechaEfectiva.Before(ultima):
+			fila.Resultado = models.FilaInvalida
+			fila.Error = fmt.Sprintf("la fecha efectiva es anterior al último evento registrado (%s)", ultima.Format(fechaCarrera))
+		default:
+			fila.Resultado = models.FilaCambio
+		}
+	}
+
+	lote.Resumen = models.ResumenLote{}
+	for _, fila := range lote.Filas {
+		switch fila.Resultado {
+		case models.FilaCambio:
+			lote.Resumen.Cambios++
+		case models.FilaSinCambios:
+			lote.Resumen.SinCambios++
+		case models.FilaCIPDesconocido:
+			lote.Resumen.CIPDesconocidos++
+		case models.FilaDuplicada:
+			lote.Resumen.Duplicadas++
+		case models.FilaInvalida:
+			lote.Resumen.Invalidas++
+		}
+	}
+
+	return nil
+}
+
+// GetLote returns a resolution batch with the rows above the caller's clearance reserved. The
+// batch was matched at the clearance of whoever uploaded it, which may be higher.
+func (s *CarreraService) GetLote(id string, scope models.AccessScope) (*models.LoteResolucion, error) {
+	lote, err := s.carreraRepo.GetLote(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []primitive.ObjectID
+	for _, fila := range lote.Filas {
+		if fila.ExpedienteID != nil {
+			ids = append(ids, *fila.ExpedienteID)
+		}
+	}
+	clasificaciones, err := clasificacionesActuales(s.expedienteRepo, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range lote.Filas {
+		if id := lote.Filas[i].ExpedienteID; id != nil {
+			lote.Filas[i].Clasificacion = clasificaciones[*id]
+		}
+	}
+
+	storeClassifiedAccess(s.auditRepo, reservarFilas(lote.Filas, "lote_resolucion", scope))
+	return lote, nil
+}
+
+// reservarFilas replaces the CIP, name, grados and situaciones of the rows that matched an
+// expediente above the caller's clearance, which still count in the summary, and returns the
+// access log entries of the classified rows it shows
+func reservarFilas(filas []models.FilaResolucion, operacion string, scope models.AccessScope) []models.AuditLog {
+	var entries []models.AuditLog
+	for i := range filas {
+		fila := &filas[i]
+		if fila.ExpedienteID == nil {
+			continue
+		}
+		if !scope.CanReadExpediente(*fila.ExpedienteID, fila.Clasificacion) {
+			fila.CIP = models.IdentidadReservada
+			fila.ApellidosNombres = models.IdentidadReservada
+			fila.GradoActual, fila.GradoNuevo = "", ""
+			fila.SituacionActual, fila.SituacionNueva = "", ""
+			continue
+		}
+		if entry, ok := classifiedAccessEntry(scope, operacion, *fila.ExpedienteID, fila.CIP, fila.Clasificacion); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// AplicarLote records a career event for every changed row of a batch, all sharing the batch
+// as reference. Either every row is applied or none: if any expediente changed since the
+// preview the batch is rejected, and a change detected while applying is rolled back.
+func (s *CarreraService) AplicarLote(id string, scope models.AccessScope) (*models.LoteResolucion, error) {
+	scope = scope.WithoutBreakGlass()
+
+	lote, err := s.carreraRepo.GetLote(id)
+	if err != nil {
+		return nil, err
+	}
+	if lote.Estado == models.PlanAplicado {
+		return nil, repository.ErrLoteAplicado
+	}
+	if lote.Resumen.Invalidas > 0 {
+		return nil, ErrLoteConErrores
+	}
+	if lote.Resumen.Cambios == 0 {
+		return nil, ErrLoteSinCambios
+	}
+
+	// The batch is only valid for the values it was previewed against
+	actual := &models.LoteResolucion{Filas: make([]models.FilaResolucion, len(lote.Filas))}
+	for i, fila := range lote.Filas {
+		if fila.Resultado == models.FilaCambio {
+			fila.Resultado = ""
+		}
+		actual.Filas[i] = fila
+	}
+	if err := s.compararLote(actual, scope); err != nil {
+		return nil, err
+	}
+	for i, fila := range actual.Filas {
+		if lote.Filas[i].Resultado == models.FilaCambio && (fila.Resultado != models.FilaCambio ||
+			fila.GradoActual != lote.Filas[i].GradoActual || fila.SituacionActual != lote.Filas[i].SituacionActual) {
+			return nil, fmt.Errorf("%w: CIP %s", ErrLoteDesactualizado, fila.CIP)
+		}
+	}
+
+	if err := s.carreraRepo.MarkLoteAplicado(lote.ID, scope.UserID); err != nil {
+		return nil, err
+	}
+
+	eventos := make([]*models.EventoCarrera, 0, lote.Resumen.Cambios)
+	ordenes := make(map[primitive.ObjectID]int, lote.Resumen.Cambios)
+	for _, fila := range lote.Filas {
+		if fila.Resultado != models.FilaCambio {
+			continue
+		}
+		eventos = append(eventos, &models.EventoCarrera{
+			ExpedienteID:      *fila.ExpedienteID,
+			FechaEfectiva:     *fila.FechaEfectiva,
+			Resolucion:        fila.Resolucion,
+			GradoAnterior:     fila.GradoActual,
+			GradoNuevo:        fila.GradoNuevo,
+			SituacionAnterior: fila.SituacionActual,
+			SituacionNueva:    fila.SituacionNueva,
+			LoteID:            &lote.ID,
+			UsuarioID:         scope.UserID,
+			Usuario:           scope.Email,
+		})
+		ordenes[*fila.ExpedienteID] = calculateOrden(fila.GradoNuevo, fila.SituacionNueva)
+	}
+
+	if err := s.carreraRepo.CreateMany(eventos); err != nil {
+		s.deshacerLote(lote.ID, nil)
+		return nil, err
+	}
+
+	aplicados, err := s.expedienteRepo.UpdateCarreras(eventos, ordenes)
+	if err != nil || aplicados != int64(len(eventos)) {
+		s.deshacerLote(lote.ID, eventos)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrLoteDesactualizado
+	}
+
+	log.Printf("🎖️ Lote de resolución %s aplicado por %s: %d eventos de carrera", lote.ID.Hex(), scope.Email, len(eventos))
+	return s.GetLote(id, scope)
+}
+
+// deshacerLote rolls back a partially applied batch: restores the expedientes that were
+// updated, removes its events and returns it to the proposed state
+func (s *CarreraService) deshacerLote(loteID primitive.ObjectID, eventos []*models.EventoCarrera) {
+	if len(eventos) > 0 {
+		inversos := make([]*models.EventoCarrera, len(eventos))
+		ordenes := make(map[primitive.ObjectID]int, len(eventos))
+		for i, evento := range eventos {
+			inverso := *evento
+			inverso.GradoAnterior, inverso.GradoNuevo = evento.GradoNuevo, evento.GradoAnterior
+			inverso.SituacionAnterior, inverso.SituacionNueva = evento.SituacionNueva, evento.SituacionAnterior
+			inversos[i] = &inverso
+			ordenes[evento.ExpedienteID] = calculateOrden(evento.GradoAnterior, evento.SituacionAnterior)
+		}
+		if _, err := s.expedienteRepo.UpdateCarreras(inversos, ordenes); err != nil {
+			log.Printf("⚠️ Error revirtiendo expedientes del lote %s: %v", loteID.Hex(), err)
+		}
+	}
+
+	if err := s.carreraRepo.DeleteByLote(loteID); err != nil {
+		log.Printf("⚠️ Error eliminando eventos del lote %s: %v", loteID.Hex(), err)
+	}
+	if err := s.carreraRepo.ReabrirLote(loteID); err != nil {
+		log.Printf("⚠️ Error reabriendo el lote %s: %v", loteID.Hex(), err)
+	}
+}
+
+// leerArchivoTabla reads the rows of an Excel or CSV file and checks that its header has the
+// given columns, in order. Problems with the file are reported wrapping errArchivo.
+func leerArchivoTabla(file *multipart.FileHeader, columnas []string, errArchivo error) ([][]string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer src.Close()
+
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(file.Filename)) {
+	case ".xlsx", ".xls":
+		excelFile, err := excelize.OpenReader(src)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errArchivo, err)
+		}
+		defer excelFile.Close()
+
+		sheetName := excelFile.GetSheetName(0)
+		if sheetName == "" {
+			return nil, fmt.Errorf("%w: no contiene hojas", errArchivo)
+		}
+		if rows, err = excelFile.GetRows(sheetName); err != nil {
+			return nil, fmt.Errorf("%w: %v", errArchivo, err)
+		}
+	case ".csv":
+		if rows, err = leerCSV(src); err != nil {
+			return nil, fmt.Errorf("%w: %v", errArchivo, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: debe ser Excel (.xlsx) o CSV", errArchivo)
+	}
+
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("%w: debe contener una fila de encabezados y al menos una fila de datos", errArchivo)
+	}
+	for i, columna := range columnas {
+		encabezado := ""
+		if i < len(rows[0]) {
+			encabezado = strings.TrimSpace(strings.TrimPrefix(rows[0][i], "\ufeff"))
+		}
+		if !strings.EqualFold(encabezado, columna) {
+			return nil, fmt.Errorf("%w: la columna %d debe ser '%s', se encontró '%s'", errArchivo, i+1, columna, encabezado)
+		}
+	}
+
+	return rows, nil
+}
+
+// leerCSV reads a CSV file separated by commas or, as Excel exports it in Spanish locales, semicolons
+func leerCSV(src io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+	primeraLinea, _, _ := strings.Cut(string(data), "\n")
+	if strings.Count(primeraLinea, ";") > strings.Count(primeraLinea, ",") {
+		reader.Comma = ';'
+	}
+
+	return reader.ReadAll()
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"expedientes-backend/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReservarFilas(t *testing.T) {
+	reservadoID := primitive.NewObjectID()
+	filasPrueba := func() []models.FilaResolucion {
+		return []models.FilaResolucion{
+			{Fila: 2, CIP: "00099999", Resultado: models.FilaCIPDesconocido},
+			{Fila: 3, CIP: "00012345", ExpedienteID: &reservadoID, ApellidosNombres: "GARCIA PEREZ, Juan",
+				GradoActual: "MAYOR", GradoNuevo: "TENIENTE CORONEL", SituacionActual: models.SituacionActividad,
+				SituacionNueva: models.SituacionActividad, Resultado: models.FilaCambio, Clasificacion: models.ClasificacionReservado},
+		}
+	}
+
+	publico := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionPublico}
+	filas := filasPrueba()
+	if entries := reservarFilas(filas, "lote_resolucion", publico); len(entries) != 0 {
+		t.Errorf("%d accesos registrados, want 0", len(entries))
+	}
+	if filas[0].CIP != "00099999" {
+		t.Errorf("fila sin expediente modificada: %+v", filas[0])
+	}
+	reservada := filas[1]
+	if reservada.CIP != models.IdentidadReservada || reservada.ApellidosNombres != models.IdentidadReservada ||
+		reservada.GradoActual != "" || reservada.GradoNuevo != "" || reservada.SituacionActual != "" || reservada.SituacionNueva != "" {
+		t.Errorf("fila reservada visible con nivel público: %+v", reservada)
+	}
+	if reservada.Resultado != models.FilaCambio {
+		t.Errorf("resultado = %s, want %s", reservada.Resultado, models.FilaCambio)
+	}
+
+	reservadoScope := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionReservado}
+	filas = filasPrueba()
+	entries := reservarFilas(filas, "lote_resolucion", reservadoScope)
+	if filas[1].ApellidosNombres != "GARCIA PEREZ, Juan" || filas[1].GradoNuevo != "TENIENTE CORONEL" {
+		t.Errorf("fila reservada oculta con nivel reservado: %+v", filas[1])
+	}
+	if len(entries) != 1 || entries[0].RecursoID != reservadoID.Hex() || entries[0].Detalles["cip"] != "00012345" {
+		t.Errorf("accesos registrados = %+v, want uno de la fila reservada", entries)
+	}
+}
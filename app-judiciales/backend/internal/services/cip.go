@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// ErrCIPInvalido is returned when a CIP does not follow the rules of its personnel category
+var ErrCIPInvalido = errors.New("CIP inválido")
+
+// CIPRegla is the format of the CIPs of a personnel category
+type CIPRegla struct {
+	// Longitud is the number of characters of a canonical CIP; shorter numeric CIPs are
+	// padded with leading zeros. 0 accepts any length.
+	Longitud int
+	// Patron is the regular expression a canonical CIP must match; empty accepts any
+	Patron string
+}
+
+// CIPRules are the CIP rules of each personnel category. Categories without a rule are
+// only normalised.
+type CIPRules struct {
+	Categorias map[models.CategoriaGrado]CIPRegla
+	// DigitoVerificador requires the last digit to be a Luhn check digit
+	DigitoVerificador bool
+}
+
+// cipRegla is a CIP rule with its pattern compiled
+type cipRegla struct {
+	longitud int
+	patron   *regexp.Regexp
+}
+
+// CIPValidator normalises CIPs to their canonical form and checks them against the rules
+// of the personnel category of their grado
+type CIPValidator struct {
+	reglas            map[models.CategoriaGrado]cipRegla
+	digitoVerificador bool
+}
+
+// NewCIPValidator creates a new CIP validator for the given rules
+func NewCIPValidator(rules CIPRules) (*CIPValidator, error) {
+	reglas := make(map[models.CategoriaGrado]cipRegla, len(rules.Categorias))
+	for categoria, regla := range rules.Categorias {
+		compilada := cipRegla{longitud: regla.Longitud}
+		if patron := strings.TrimSpace(regla.Patron); patron != "" {
+			var err error
+			if compilada.patron, err = regexp.Compile(patron); err != nil {
+				return nil, fmt.Errorf("patrón de CIP inválido para %s: %w", categoria, err)
+			}
+		}
+		reglas[categoria] = compilada
+	}
+
+	return &CIPValidator{
+		reglas:            reglas,
+		digitoVerificador: rules.DigitoVerificador,
+	}, nil
+}
+
+// Normalizar returns the canonical form of a CIP: without spaces, dashes or dots, in
+// uppercase and, when its category has a fixed length, with the leading zeros people drop
+func (v *CIPValidator) Normalizar(cip string, grado models.Grado) string {
+	return v.reglas[grado.Categoria()].ajustar(limpiarCIP(cip))
+}
+
+// Candidatos returns the canonical forms a CIP may have when its grado is unknown, as in
+// the name of a scanned file: the CIP without separators and its form in every category
+// with a fixed length. The grado of the expediente found decides which one applies.
+func (v *CIPValidator) Candidatos(cip string) []string {
+	limpio := limpiarCIP(cip)
+	if limpio == "" {
+		return nil
+	}
+
+	candidatos := []string{limpio}
+	for _, regla := range v.reglas {
+		candidato := regla.ajustar(limpio)
+		repetido := false
+		for _, c := range candidatos {
+			if c == candidato {
+				repetido = true
+				break
+			}
+		}
+		if !repetido {
+			candidatos = append(candidatos, candidato)
+		}
+	}
+	return candidatos
+}
+
+// Clave returns a form of a CIP that does not depend on its category: without separators
+// and, when numeric, without leading zeros. CIPs typed with or without the zeros of any
+// category share their clave, so it matches spreadsheet rows whose grado may be wrong.
+func (v *CIPValidator) Clave(cip string) string {
+	limpio := limpiarCIP(cip)
+	if limpio == "" || !soloDigitos(limpio) {
+		return limpio
+	}
+	if clave := strings.TrimLeft(limpio, "0"); clave != "" {
+		return clave
+	}
+	return "0"
+}
+
+// limpiarCIP removes everything but letters and digits from a CIP, in uppercase
+func limpiarCIP(cip string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(cip) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ajustar pads a numeric CIP with leading zeros to the length of the rule, or removes the
+// extra ones. CIPs with letters and rules without a fixed length are left as they are.
+func (r cipRegla) ajustar(normalizado string) string {
+	if r.longitud == 0 || normalizado == "" || !soloDigitos(normalizado) {
+		return normalizado
+	}
+
+	// Completar los ceros iniciales omitidos, o quitar los que sobran
+	if len(normalizado) < r.longitud {
+		return strings.Repeat("0", r.longitud-len(normalizado)) + normalizado
+	}
+	for len(normalizado) > r.longitud && normalizado[0] == '0' {
+		normalizado = normalizado[1:]
+	}
+	return normalizado
+}
+
+// Validar returns the canonical form of a CIP, or an error wrapping ErrCIPInvalido that
+// explains which rule of the grado's category it breaks
+func (v *CIPValidator) Validar(cip string, grado models.Grado) (string, error) {
+	normalizado := v.Normalizar(cip, grado)
+	if normalizado == "" {
+		return "", fmt.Errorf("%w: el CIP es requerido", ErrCIPInvalido)
+	}
+
+	categoria := grado.Categoria()
+	regla, ok := v.reglas[categoria]
+	if !ok {
+		return normalizado, nil
+	}
+	if regla.longitud > 0 && len(normalizado) != regla.longitud {
+		return "", fmt.Errorf("%w: el CIP de %s debe tener %d caracteres", ErrCIPInvalido, categoria, regla.longitud)
+	}
+	if regla.patron != nil && !regla.patron.MatchString(normalizado) {
+		return "", fmt.Errorf("%w: %s no tiene el formato de CIP de %s", ErrCIPInvalido, normalizado, categoria)
+	}
+	if v.digitoVerificador && !digitoLuhnValido(normalizado) {
+		return "", fmt.Errorf("%w: el dígito verificador de %s no es correcto", ErrCIPInvalido, normalizado)
+	}
+
+	return normalizado, nil
+}
+
+// soloDigitos reports whether a string is made only of ASCII digits
+func soloDigitos(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// digitoLuhnValido checks that the last digit of a number is its Luhn check digit
+func digitoLuhnValido(numero string) bool {
+	if len(numero) < 2 || !soloDigitos(numero) {
+		return false
+	}
+
+	suma := 0
+	for i := len(numero) - 1; i >= 0; i-- {
+		digito := int(numero[i] - '0')
+		// Se duplica uno de cada dos dígitos empezando por el penúltimo
+		if (len(numero)-1-i)%2 == 1 {
+			digito *= 2
+			if digito > 9 {
+				digito -= 9
+			}
+		}
+		suma += digito
+	}
+	return suma%10 == 0
+}
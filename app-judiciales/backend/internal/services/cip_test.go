@@ -0,0 +1,97 @@
+package services
+
+import (
+	"expedientes-backend/internal/models"
+	"slices"
+	"testing"
+)
+
+// nuevoCIPValidatorPrueba returns a validator with eight-digit officer CIPs, nine-digit
+// TCO/SSOO CIPs and tropa CIPs of any length
+func nuevoCIPValidatorPrueba(t *testing.T) *CIPValidator {
+	t.Helper()
+	v, err := NewCIPValidator(CIPRules{
+		Categorias: map[models.CategoriaGrado]CIPRegla{
+			models.CategoriaOficiales: {Longitud: 8},
+			models.CategoriaTcoSsoo:   {Longitud: 9},
+			models.CategoriaTropa:     {Patron: `^[0-9A-Z]+$`},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestCIPValidatorNormalizar(t *testing.T) {
+	v := nuevoCIPValidatorPrueba(t)
+	tests := []struct {
+		nombre string
+		cip    string
+		grado  models.Grado
+		want   string
+	}{
+		{"canónico", "00012345", models.GradoMY, "00012345"},
+		{"sin ceros iniciales", "12345", models.GradoMY, "00012345"},
+		{"ceros de más", "0000012345", models.GradoCAP, "00012345"},
+		{"más largo sin ceros de más", "123456789", models.GradoCAP, "123456789"},
+		{"separadores", " 000-123.45 ", models.GradoTTE, "00012345"},
+		{"otra longitud por categoría", "12345", models.GradoTCO, "000012345"},
+		{"categoría sin longitud", "0012345", models.GradoTropa, "0012345"},
+		{"categoría sin regla", "12-345", models.GradoEC, "12345"},
+		{"grado vacío", "012345", "", "012345"},
+		{"con letras", "ab-123", models.GradoMY, "AB123"},
+		{"solo separadores", " - . ", models.GradoMY, ""},
+		{"vacío", "", models.GradoMY, ""},
+	}
+	for _, tt := range tests {
+		if got := v.Normalizar(tt.cip, tt.grado); got != tt.want {
+			t.Errorf("%s: Normalizar(%q, %q) = %q, want %q", tt.nombre, tt.cip, tt.grado, got, tt.want)
+		}
+	}
+}
+
+func TestCIPValidatorCandidatos(t *testing.T) {
+	v := nuevoCIPValidatorPrueba(t)
+	tests := []struct {
+		nombre string
+		cip    string
+		want   []string
+	}{
+		{"sin ceros iniciales", "12345", []string{"000012345", "00012345", "12345"}},
+		{"forma de oficiales", "00012345", []string{"000012345", "00012345"}},
+		{"separadores", "0000-12345", []string{"000012345", "00012345"}},
+		{"más largo que toda regla", "1234567890", []string{"1234567890"}},
+		{"con letras", "a-12", []string{"A12"}},
+		{"vacío", "", nil},
+		{"solo separadores", " . ", nil},
+	}
+	for _, tt := range tests {
+		// El orden de las formas por categoría no está definido
+		got := v.Candidatos(tt.cip)
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Candidatos(%q) = %q, want %q", tt.nombre, tt.cip, got, tt.want)
+		}
+	}
+}
+
+func TestCIPValidatorClave(t *testing.T) {
+	v := nuevoCIPValidatorPrueba(t)
+	tests := []struct {
+		cip  string
+		want string
+	}{
+		{"00012345", "12345"},
+		{"000012345", "12345"},
+		{"12-345", "12345"},
+		{"000", "0"},
+		{"0a12", "0A12"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := v.Clave(tt.cip); got != tt.want {
+			t.Errorf("Clave(%q) = %q, want %q", tt.cip, got, tt.want)
+		}
+	}
+}
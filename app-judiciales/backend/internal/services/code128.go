@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+)
+
+// code128Patrones holds the bar and space widths of every Code 128 symbol, in modules.
+// Symbols 103–105 are the start codes and 106 is the stop code.
+var code128Patrones = [...]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128StartB = 104
+	code128Stop   = 106
+)
+
+// codificarCode128 encodes printable ASCII text as a Code 128 (code set B) symbol. It returns
+// the widths of the alternating bars and spaces, starting with a bar, without quiet zones.
+func codificarCode128(texto string) ([]int, error) {
+	simbolos := []int{code128StartB}
+	suma := code128StartB
+	for i, r := range texto {
+		if r < 32 || r > 126 {
+			return nil, fmt.Errorf("carácter no codificable en Code 128: %q", r)
+		}
+		valor := int(r) - 32
+		simbolos = append(simbolos, valor)
+		suma += valor * (i + 1)
+	}
+	simbolos = append(simbolos, suma%103, code128Stop)
+
+	anchos := make([]int, 0, len(simbolos)*6+1)
+	for _, simbolo := range simbolos {
+		for _, ancho := range code128Patrones[simbolo] {
+			anchos = append(anchos, int(ancho-'0'))
+		}
+	}
+	return anchos, nil
+}
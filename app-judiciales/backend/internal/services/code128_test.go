@@ -0,0 +1,70 @@
+package services
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCode128Patrones(t *testing.T) {
+	if len(code128Patrones) != 107 {
+		t.Fatalf("len(code128Patrones) = %d, want 107", len(code128Patrones))
+	}
+	for simbolo, patron := range code128Patrones {
+		modulos, barras := 0, 0
+		for i, c := range patron {
+			modulos += int(c - '0')
+			if i%2 == 0 {
+				barras += int(c - '0')
+			}
+		}
+		want := 11
+		if simbolo == code128Stop {
+			want = 13
+		}
+		if modulos != want {
+			t.Errorf("símbolo %d: %d módulos, want %d", simbolo, modulos, want)
+		}
+		// Every symbol has an even number of bar modules, which lets scanners catch misreads
+		if barras%2 != 0 {
+			t.Errorf("símbolo %d: %d módulos de barra, want par", simbolo, barras)
+		}
+	}
+}
+
+func TestCodificarCode128(t *testing.T) {
+	tests := []struct {
+		texto    string
+		simbolos []int
+	}{
+		{"", []int{104, 1, 106}},
+		{"A", []int{104, 33, 34, 106}},
+		{"PJJ123C", []int{104, 48, 42, 42, 17, 18, 19, 35, 55, 106}},
+		{"Wikipedia", []int{104, 55, 73, 75, 73, 80, 69, 68, 73, 65, 88, 106}},
+		{"00012345", []int{104, 16, 16, 16, 17, 18, 19, 20, 21, 59, 106}},
+	}
+	for _, tt := range tests {
+		anchos, err := codificarCode128(tt.texto)
+		if err != nil {
+			t.Errorf("codificarCode128(%q): %v", tt.texto, err)
+			continue
+		}
+
+		var want []int
+		for _, simbolo := range tt.simbolos {
+			for _, c := range code128Patrones[simbolo] {
+				want = append(want, int(c-'0'))
+			}
+		}
+		if !slices.Equal(anchos, want) {
+			t.Errorf("codificarCode128(%q) = %v, want símbolos %v", tt.texto, anchos, tt.simbolos)
+		}
+	}
+}
+
+func TestCodificarCode128CaracterInvalido(t *testing.T) {
+	for _, texto := range []string{"Nº 1", "a\tb", "ñ"} {
+		if _, err := codificarCode128(texto); err == nil {
+			t.Errorf("codificarCode128(%q): expected an error", texto)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Dependencia catalog errors
+var (
+	ErrCompetenciaInvalida = errors.New("competencia inválida. Use civil, penal, laboral, familia o comercial")
+	ErrPersonalInvalido    = errors.New("el usuario asignado no existe o está inactivo")
+	ErrDependenciaInactiva = errors.New("la dependencia está inactiva")
+	ErrDependenciaVacia    = errors.New("el código y el nombre de la dependencia no pueden estar vacíos")
+	ErrDependenciaEnUso    = errors.New("la dependencia tiene expedientes asignados o préstamos registrados; desactívela en lugar de eliminarla")
+)
+
+// DependenciaService handles the catalog of juzgados and offices and the assignment of
+// expedientes to them
+type DependenciaService struct {
+	dependenciaRepo *repository.DependenciaRepository
+	expedienteRepo  *repository.ExpedienteRepository
+	prestamoRepo    *repository.PrestamoRepository
+	userRepo        *repository.UserRepository
+	auditRepo       *repository.AuditRepository
+}
+
+// NewDependenciaService creates a new dependencia service
+func NewDependenciaService(dependenciaRepo *repository.DependenciaRepository, expedienteRepo *repository.ExpedienteRepository, prestamoRepo *repository.PrestamoRepository, userRepo *repository.UserRepository, auditRepo *repository.AuditRepository) *DependenciaService {
+	return &DependenciaService{
+		dependenciaRepo: dependenciaRepo,
+		expedienteRepo:  expedienteRepo,
+		prestamoRepo:    prestamoRepo,
+		userRepo:        userRepo,
+		auditRepo:       auditRepo,
+	}
+}
+
+// GetDependencias returns the catalog ordered by code, optionally only the active dependencias
+func (s *DependenciaService) GetDependencias(soloActivas bool) ([]*models.Dependencia, error) {
+	return s.dependenciaRepo.GetAll(soloActivas)
+}
+
+// GetDependencia returns a dependencia by ID
+func (s *DependenciaService) GetDependencia(id string) (*models.Dependencia, error) {
+	return s.dependenciaRepo.GetByID(id)
+}
+
+// CreateDependencia creates a new active dependencia
+func (s *DependenciaService) CreateDependencia(req *models.CreateDependenciaRequest) (*models.Dependencia, error) {
+	competencias, err := normalizarCompetencias(req.Competencias)
+	if err != nil {
+		return nil, err
+	}
+	personal, err := s.resolverPersonal(req.Personal)
+	if err != nil {
+		return nil, err
+	}
+
+	dependencia := &models.Dependencia{
+		Codigo:       normalizarCodigoDependencia(req.Codigo),
+		Nombre:       strings.TrimSpace(req.Nombre),
+		Tipo:         req.Tipo,
+		Competencias: competencias,
+		Personal:     personal,
+		Direccion:    strings.TrimSpace(req.Direccion),
+		Telefono:     strings.TrimSpace(req.Telefono),
+		Email:        strings.TrimSpace(req.Email),
+		Activa:       true,
+	}
+	if dependencia.Codigo == "" || dependencia.Nombre == "" {
+		return nil, ErrDependenciaVacia
+	}
+
+	if err := s.dependenciaRepo.Create(dependencia); err != nil {
+		return nil, err
+	}
+
+	return dependencia, nil
+}
+
+// UpdateDependencia updates a dependencia; deactivating it keeps its assignments and loans
+func (s *DependenciaService) UpdateDependencia(id string, req *models.UpdateDependenciaRequest) (*models.Dependencia, error) {
+	updates := make(map[string]interface{})
+	if req.Codigo != nil {
+		codigo := normalizarCodigoDependencia(*req.Codigo)
+		if codigo == "" {
+			return nil, ErrDependenciaVacia
+		}
+		updates["codigo"] = codigo
+	}
+	if req.Nombre != nil {
+		nombre := strings.TrimSpace(*req.Nombre)
+		if nombre == "" {
+			return nil, ErrDependenciaVacia
+		}
+		updates["nombre"] = nombre
+	}
+	if req.Tipo != nil {
+		updates["tipo"] = *req.Tipo
+	}
+	if req.Competencias != nil {
+		competencias, err := normalizarCompetencias(*req.Competencias)
+		if err != nil {
+			return nil, err
+		}
+		updates["competencias"] = competencias
+	}
+	if req.Personal != nil {
+		personal, err := s.resolverPersonal(*req.Personal)
+		if err != nil {
+			return nil, err
+		}
+		updates["personal"] = personal
+	}
+	if req.Direccion != nil {
+		updates["direccion"] = strings.TrimSpace(*req.Direccion)
+	}
+	if req.Telefono != nil {
+		updates["telefono"] = strings.TrimSpace(*req.Telefono)
+	}
+	if req.Email != nil {
+		updates["email"] = strings.TrimSpace(*req.Email)
+	}
+	if req.Activa != nil {
+		updates["activa"] = *req.Activa
+	}
+
+	if err := s.dependenciaRepo.Update(id, updates); err != nil {
+		return nil, err
+	}
+
+	return s.dependenciaRepo.GetByID(id)
+}
+
+// DeleteDependencia deletes a dependencia that was never assigned an expediente nor lent a folder
+func (s *DependenciaService) DeleteDependencia(id string) error {
+	dependencia, err := s.dependenciaRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	asignados, err := s.expedienteRepo.CountByDependencia(dependencia.ID)
+	if err != nil {
+		return err
+	}
+	prestamos, err := s.prestamoRepo.CountByDependencia(dependencia.ID)
+	if err != nil {
+		return err
+	}
+	if asignados > 0 || prestamos > 0 {
+		return ErrDependenciaEnUso
+	}
+
+	return s.dependenciaRepo.Delete(dependencia.ID)
+}
+
+// resolverPersonal links the assigned users, ignoring repeated ones. Only active users can be assigned.
+func (s *DependenciaService) resolverPersonal(asignaciones []models.PersonalDependenciaRequest) ([]models.PersonalDependencia, error) {
+	personal := make([]models.PersonalDependencia, 0, len(asignaciones))
+	vistos := make(map[primitive.ObjectID]bool, len(asignaciones))
+	for _, asignacion := range asignaciones {
+		if !primitive.IsValidObjectID(asignacion.UsuarioID) {
+			return nil, fmt.Errorf("%w: %s", ErrPersonalInvalido, asignacion.UsuarioID)
+		}
+		user, err := s.userRepo.GetByID(asignacion.UsuarioID)
+		if err != nil || !user.Activo {
+			return nil, fmt.Errorf("%w: %s", ErrPersonalInvalido, asignacion.UsuarioID)
+		}
+		if vistos[user.ID] {
+			continue
+		}
+		vistos[user.ID] = true
+		personal = append(personal, models.PersonalDependencia{
+			UsuarioID: user.ID,
+			Usuario:   user.Email,
+			Nombre:    strings.TrimSpace(user.Nombre + " " + user.Apellido),
+			Cargo:     strings.TrimSpace(asignacion.Cargo),
+		})
+	}
+	return personal, nil
+}
+
+// normalizarCompetencias validates the competencias of a dependencia, ignoring repeated ones
+func normalizarCompetencias(competencias []models.Competencia) ([]models.Competencia, error) {
+	resultado := make([]models.Competencia, 0, len(competencias))
+	vistas := make(map[models.Competencia]bool, len(competencias))
+	for _, competencia := range competencias {
+		if !models.ValidCompetencia(competencia) {
+			return nil, ErrCompetenciaInvalida
+		}
+		if vistas[competencia] {
+			continue
+		}
+		vistas[competencia] = true
+		resultado = append(resultado, competencia)
+	}
+	return resultado, nil
+}
+
+// normalizarCodigoDependencia stores codes in upper case so they match however they are typed
+func normalizarCodigoDependencia(codigo string) string {
+	return strings.ToUpper(strings.TrimSpace(codigo))
+}
+
+// AsignarExpediente assigns an expediente to an active dependencia, or unassigns it when the
+// request has no dependencia
+func (s *DependenciaService) AsignarExpediente(expedienteID string, req *models.AsignarDependenciaRequest, scope models.AccessScope) (*models.Expediente, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var dependencia *models.Dependencia
+	if id := strings.TrimSpace(req.DependenciaID); id != "" {
+		if dependencia, err = s.dependenciaRepo.GetByID(id); err != nil {
+			return nil, err
+		}
+		if !dependencia.Activa {
+			return nil, ErrDependenciaInactiva
+		}
+	}
+
+	detalles := map[string]interface{}{"dependencia_anterior": "", "dependencia": ""}
+	if expediente.DependenciaID != nil {
+		detalles["dependencia_anterior"] = expediente.DependenciaID.Hex()
+	}
+	var dependenciaID *primitive.ObjectID
+	if dependencia != nil {
+		dependenciaID = &dependencia.ID
+		detalles["dependencia"] = dependencia.ID.Hex()
+		detalles["codigo"] = dependencia.Codigo
+	}
+
+	if err := s.expedienteRepo.SetDependencia(expediente.ID, dependenciaID, scope.UserID); err != nil {
+		return nil, err
+	}
+	expediente.DependenciaID = dependenciaID
+
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionDependenciaAsignacion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles:  detalles,
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de la asignación de %s: %v", expediente.CIP, err)
+	}
+
+	if dependencia != nil {
+		log.Printf("🏛️ Expediente %s asignado a %s por %s", expediente.CIP, dependencia.Codigo, scope.Email)
+	} else {
+		log.Printf("🏛️ Expediente %s sin dependencia asignada por %s", expediente.CIP, scope.Email)
+	}
+
+	return expediente, nil
+}
+
+// GetTenencia returns, for every dependencia, the expedientes assigned to it and the folders it
+// has on loan, counting only expedientes readable within the scope
+func (s *DependenciaService) GetTenencia(scope models.AccessScope) ([]models.TenenciaDependencia, error) {
+	dependencias, err := s.dependenciaRepo.GetAll(false)
+	if err != nil {
+		return nil, err
+	}
+
+	asignados, err := s.expedienteRepo.CountAsignadosPorDependencia(scope)
+	if err != nil {
+		return nil, err
+	}
+	prestados, err := s.prestamoRepo.CountAbiertosPorDependencia(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	tenencia := make([]models.TenenciaDependencia, 0, len(dependencias))
+	for _, dependencia := range dependencias {
+		tenencia = append(tenencia, models.TenenciaDependencia{
+			Dependencia: *dependencia,
+			Asignados:   asignados[dependencia.ID],
+			Prestados:   prestados[dependencia.ID],
+		})
+	}
+
+	return tenencia, nil
+}
@@ -0,0 +1,403 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Digitisation errors
+var (
+	ErrDigitalizacionEstado       = errors.New("estado de digitalización inválido")
+	ErrDigitalizacionTransicion   = errors.New("transición de digitalización no permitida")
+	ErrDigitalizacionSinCambios   = errors.New("indique el estado o las páginas digitalizadas")
+	ErrDigitalizacionTomo         = errors.New("el expediente tiene tomos: indique el tomo al que corresponden las páginas digitalizadas")
+	ErrDigitalizacionPermiso      = errors.New("verificar la digitalización requiere el permiso digitalizacion:verify")
+	ErrDigitalizacionDiscrepancia = errors.New("las páginas digitalizadas no coinciden con las páginas del expediente")
+	ErrDigitalizacionVerificador  = errors.New("la digitalización debe verificarla un usuario distinto del operador asignado")
+	ErrOperadorInvalido           = errors.New("el operador no existe o está inactivo")
+)
+
+// DigitalizacionService tracks the scanning of expedientes: their digitisation state, the
+// digitised pages against the physical ones and the operator each one is assigned to
+type DigitalizacionService struct {
+	expedienteRepo *repository.ExpedienteRepository
+	tomoRepo       *repository.TomoRepository
+	tomoService    *TomoService
+	userRepo       *repository.UserRepository
+	archivoRepo    *repository.ArchivoRepository
+	auditRepo      *repository.AuditRepository
+}
+
+// NewDigitalizacionService creates a new digitisation service
+func NewDigitalizacionService(expedienteRepo *repository.ExpedienteRepository, tomoRepo *repository.TomoRepository, tomoService *TomoService, userRepo *repository.UserRepository, archivoRepo *repository.ArchivoRepository, auditRepo *repository.AuditRepository) *DigitalizacionService {
+	return &DigitalizacionService{
+		expedienteRepo: expedienteRepo,
+		tomoRepo:       tomoRepo,
+		tomoService:    tomoService,
+		userRepo:       userRepo,
+		archivoRepo:    archivoRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// GetDigitalizacion returns the digitisation progress of an expediente and of each of its tomos
+func (s *DigitalizacionService) GetDigitalizacion(expedienteID string, scope models.AccessScope) (*models.ExpedienteDigitalizacion, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.detalle(expediente)
+}
+
+// SearchDigitalizacion returns a page of the digitisation work list
+func (s *DigitalizacionService) SearchDigitalizacion(params models.DigitalizacionSearchParams, scope models.AccessScope) ([]models.ExpedienteDigitalizacion, int64, error) {
+	if params.Estado != "" && !params.Estado.IsValid() {
+		return nil, 0, ErrDigitalizacionEstado
+	}
+	if params.OperadorID != "" && !primitive.IsValidObjectID(params.OperadorID) {
+		return nil, 0, ErrOperadorInvalido
+	}
+
+	expedientes, total, err := s.expedienteRepo.SearchDigitalizacion(params, scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]models.ExpedienteDigitalizacion, 0, len(expedientes))
+	for _, expediente := range expedientes {
+		items = append(items, vistaDigitalizacion(expediente))
+	}
+
+	return items, total, nil
+}
+
+// ActualizarDigitalizacion moves an expediente to another digitisation state and/or records
+// its digitised pages. Recording pages starts a pending expediente and sends a verified one
+// back to en_proceso unless a state is given. Verification requires digitalizacion:verify,
+// matching page counts and a user other than the assigned operator.
+func (s *DigitalizacionService) ActualizarDigitalizacion(expedienteID string, req *models.UpdateDigitalizacionRequest, scope models.AccessScope) (*models.ExpedienteDigitalizacion, error) {
+	if req.Estado == "" && req.Digitalizadas == nil {
+		return nil, ErrDigitalizacionSinCambios
+	}
+	if req.Estado != "" && !req.Estado.IsValid() {
+		return nil, ErrDigitalizacionEstado
+	}
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	desde := expediente.EstadoDigitalizacion()
+	hasta := desde
+	if req.Estado != "" && req.Estado != desde {
+		if !desde.PuedePasarA(req.Estado) {
+			return nil, fmt.Errorf("%w: de %s a %s", ErrDigitalizacionTransicion, desde, req.Estado)
+		}
+		hasta = req.Estado
+	}
+	if hasta == desde && req.Digitalizadas == nil {
+		return s.detalle(expediente)
+	}
+
+	// Páginas resultantes: con tomos se reemplazan las del tomo indicado en la suma
+	digitalizadas := expediente.Digitalizadas
+	var tomo *models.Tomo
+	if req.Digitalizadas != nil {
+		if expediente.Tomos > 0 {
+			if req.Tomo == 0 {
+				return nil, ErrDigitalizacionTomo
+			}
+			if tomo, err = s.tomoRepo.GetByNumero(expediente.ID, req.Tomo); err != nil {
+				return nil, err
+			}
+			digitalizadas += *req.Digitalizadas - tomo.Digitalizadas
+		} else {
+			digitalizadas = *req.Digitalizadas
+		}
+
+		if req.Estado == "" {
+			switch {
+			case desde == models.DigitalizacionPendiente && *req.Digitalizadas > 0:
+				hasta = models.DigitalizacionEnProceso
+			case desde == models.DigitalizacionVerificado && digitalizadas != expediente.Digitalizadas:
+				hasta = models.DigitalizacionEnProceso
+			}
+		}
+	}
+
+	if hasta == models.DigitalizacionVerificado && hasta != desde {
+		if !scope.HasPermission(models.PermissionDigitalizacionVerify) {
+			return nil, ErrDigitalizacionPermiso
+		}
+		if models.DiscrepanciaPaginas(hasta, expediente.NumeroPaginas, digitalizadas) {
+			return nil, fmt.Errorf("%w: %d digitalizadas de %d", ErrDigitalizacionDiscrepancia, digitalizadas, expediente.NumeroPaginas)
+		}
+		if expediente.Digitalizacion != nil && expediente.Digitalizacion.OperadorID != nil && *expediente.Digitalizacion.OperadorID == scope.UserID {
+			return nil, ErrDigitalizacionVerificador
+		}
+	}
+
+	if tomo != nil {
+		if err := s.tomoRepo.Update(tomo.ID, bson.M{"paginas_digitalizadas": *req.Digitalizadas}); err != nil {
+			return nil, err
+		}
+		if err := s.tomoService.actualizarResumen(expediente.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	campos := bson.M{
+		"estado":          hasta,
+		"actualizado_por": scope.Email,
+		"actualizado_en":  now,
+	}
+	var quitar []string
+	if req.Digitalizadas != nil && tomo == nil {
+		campos["paginas_digitalizadas"] = digitalizadas
+	}
+	if hasta == models.DigitalizacionVerificado && hasta != desde {
+		campos["verificado_por"] = scope.Email
+		campos["verificado_en"] = now
+	} else if desde == models.DigitalizacionVerificado && hasta != desde {
+		quitar = []string{"verificado_por", "verificado_en"}
+	}
+	if err := s.expedienteRepo.UpdateDigitalizacion(expediente.ID, desde, campos, quitar); err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.AuditLog, 0, 2)
+	if req.Digitalizadas != nil {
+		detalles := map[string]interface{}{
+			"paginas_digitalizadas_antes":   expediente.Digitalizadas,
+			"paginas_digitalizadas_despues": digitalizadas,
+			"numero_paginas":                expediente.NumeroPaginas,
+			"discrepancia":                  models.DiscrepanciaPaginas(hasta, expediente.NumeroPaginas, digitalizadas),
+		}
+		if tomo != nil {
+			detalles["tomo"] = tomo.Numero
+			detalles["paginas_tomo"] = *req.Digitalizadas
+		}
+		entries = append(entries, s.entradaAuditoria(models.AccionDigitalizacionPaginas, expediente, scope, detalles))
+	}
+	if hasta != desde {
+		entries = append(entries, s.entradaAuditoria(models.AccionDigitalizacionEstado, expediente, scope, map[string]interface{}{
+			"estado_anterior": desde,
+			"estado_nuevo":    hasta,
+		}))
+	}
+	if err := s.auditRepo.LogMany(entries); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de la digitalización de %s: %v", expediente.CIP, err)
+	}
+
+	if hasta != desde {
+		log.Printf("🖨️ Expediente %s: digitalización %s → %s por %s", expediente.CIP, desde, hasta, scope.Email)
+	}
+
+	return s.GetDigitalizacion(expedienteID, scope)
+}
+
+// Asignar assigns an expediente to a digitisation operator, who must be an active user.
+// An empty operator removes the assignment.
+func (s *DigitalizacionService) Asignar(expedienteID string, req *models.AsignarDigitalizacionRequest, scope models.AccessScope) (*models.ExpedienteDigitalizacion, error) {
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	anterior := ""
+	if expediente.Digitalizacion != nil {
+		anterior = expediente.Digitalizacion.Operador
+	}
+
+	estado := expediente.EstadoDigitalizacion()
+	campos := bson.M{"estado": estado}
+	var quitar []string
+	operador := ""
+	if req.OperadorID == "" {
+		quitar = []string{"operador_id", "operador", "asignado_en"}
+	} else {
+		user, err := s.userRepo.GetByID(req.OperadorID)
+		if err != nil || !user.Activo {
+			return nil, ErrOperadorInvalido
+		}
+		operador = user.Email
+		campos["operador_id"] = user.ID
+		campos["operador"] = user.Email
+		campos["asignado_en"] = time.Now()
+	}
+
+	if err := s.expedienteRepo.UpdateDigitalizacion(expediente.ID, estado, campos, quitar); err != nil {
+		return nil, err
+	}
+
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionDigitalizacionAsignacion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles: map[string]interface{}{
+			"operador_anterior": anterior,
+			"operador":          operador,
+		},
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de la asignación de %s: %v", expediente.CIP, err)
+	}
+
+	return s.GetDigitalizacion(expedienteID, scope)
+}
+
+// GetResumen returns the digitisation section of the dashboard: overall progress and progress
+// per division, per grado and per operator
+func (s *DigitalizacionService) GetResumen(scope models.AccessScope) (*models.ResumenDigitalizacion, error) {
+	divisiones, err := s.archivoRepo.GetDivisiones(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resumen, err := s.expedienteRepo.ResumenDigitalizacion(divisiones, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	calcularPorcentaje(&resumen.General)
+	for i := range resumen.PorDivision {
+		calcularPorcentaje(&resumen.PorDivision[i].ProgresoDigitalizacion)
+	}
+	for i := range resumen.PorGrado {
+		calcularPorcentaje(&resumen.PorGrado[i].ProgresoDigitalizacion)
+	}
+	for i := range resumen.PorOperador {
+		calcularPorcentaje(&resumen.PorOperador[i].ProgresoDigitalizacion)
+	}
+	resumen.GeneradoEn = time.Now().UTC()
+
+	return resumen, nil
+}
+
+// registrarEscaneo updates the digitisation state after a scan of the expediente was ingested:
+// a pending expediente starts and a verified one must be reviewed again. A failure is only logged.
+func (s *DigitalizacionService) registrarEscaneo(expediente *models.Expediente, usuario string) {
+	desde := expediente.EstadoDigitalizacion()
+	if desde != models.DigitalizacionPendiente && desde != models.DigitalizacionVerificado {
+		return
+	}
+
+	campos := bson.M{
+		"estado":          models.DigitalizacionEnProceso,
+		"actualizado_por": usuario,
+		"actualizado_en":  time.Now(),
+	}
+	var quitar []string
+	if desde == models.DigitalizacionVerificado {
+		quitar = []string{"verificado_por", "verificado_en"}
+	}
+
+	err := s.expedienteRepo.UpdateDigitalizacion(expediente.ID, desde, campos, quitar)
+	if errors.Is(err, repository.ErrDigitalizacionCambiada) {
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️ Error actualizando la digitalización del expediente %s: %v", expediente.CIP, err)
+		return
+	}
+
+	if err := s.auditRepo.Log(&models.AuditLog{
+		Usuario:   usuario,
+		Accion:    models.AccionDigitalizacionEstado,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		Detalles: map[string]interface{}{
+			"estado_anterior": desde,
+			"estado_nuevo":    models.DigitalizacionEnProceso,
+		},
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de la digitalización de %s: %v", expediente.CIP, err)
+	}
+}
+
+// detalle builds the digitisation progress of an expediente including its tomos
+func (s *DigitalizacionService) detalle(expediente *models.Expediente) (*models.ExpedienteDigitalizacion, error) {
+	vista := vistaDigitalizacion(expediente)
+	if expediente.Tomos == 0 {
+		return &vista, nil
+	}
+
+	tomos, err := s.tomoRepo.GetByExpediente(expediente.ID)
+	if err != nil {
+		return nil, err
+	}
+	vista.DetalleTomos = make([]models.TomoDigitalizacion, 0, len(tomos))
+	for _, tomo := range tomos {
+		vista.DetalleTomos = append(vista.DetalleTomos, models.TomoDigitalizacion{
+			TomoID:        tomo.ID,
+			Numero:        tomo.Numero,
+			NumeroPaginas: tomo.NumeroPaginas,
+			Digitalizadas: tomo.Digitalizadas,
+			Discrepancia:  models.DiscrepanciaPaginas(vista.Estado, tomo.NumeroPaginas, tomo.Digitalizadas),
+		})
+	}
+
+	return &vista, nil
+}
+
+// entradaAuditoria builds an audit entry of a digitisation change made by the caller
+func (s *DigitalizacionService) entradaAuditoria(accion string, expediente *models.Expediente, scope models.AccessScope, detalles map[string]interface{}) models.AuditLog {
+	detalles["cip"] = expediente.CIP
+	return models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    accion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles:  detalles,
+	}
+}
+
+// vistaDigitalizacion summarises the digitisation progress of an expediente
+func vistaDigitalizacion(expediente *models.Expediente) models.ExpedienteDigitalizacion {
+	estado := expediente.EstadoDigitalizacion()
+	return models.ExpedienteDigitalizacion{
+		ExpedienteID:     expediente.ID,
+		CIP:              expediente.CIP,
+		Grado:            expediente.Grado,
+		ApellidosNombres: expediente.ApellidosNombres,
+		Ubicacion:        expediente.Ubicacion,
+		NumeroPaginas:    expediente.NumeroPaginas,
+		Digitalizadas:    expediente.Digitalizadas,
+		Tomos:            expediente.Tomos,
+		Estado:           estado,
+		Digitalizacion:   expediente.Digitalizacion,
+		Diferencia:       expediente.Digitalizadas - expediente.NumeroPaginas,
+		Discrepancia:     models.DiscrepanciaPaginas(estado, expediente.NumeroPaginas, expediente.Digitalizadas),
+	}
+}
+
+// calcularPorcentaje sets the share of physical pages already digitised, rounded to two decimals
+func calcularPorcentaje(progreso *models.ProgresoDigitalizacion) {
+	if progreso.Paginas == 0 {
+		progreso.Porcentaje = 0
+		return
+	}
+	porcentaje := float64(progreso.Digitalizadas) / float64(progreso.Paginas) * 100
+	progreso.Porcentaje = math.Round(porcentaje*100) / 100
+}
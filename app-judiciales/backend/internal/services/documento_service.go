@@ -0,0 +1,661 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/storage"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attachment errors
+var (
+	ErrDocumentosCantidad  = fmt.Errorf("adjunte entre 1 y %d archivos por carga", models.MaxDocumentosPorCarga)
+	ErrDocumentoTamano     = errors.New("el archivo supera el tamaño máximo permitido")
+	ErrDocumentoFormato    = errors.New("el archivo debe ser PDF, DOC, DOCX, JPG o PNG y su contenido debe corresponder a su extensión")
+	ErrEnlaceNoDisponible  = errors.New("el almacenamiento de documentos no genera enlaces de descarga; use la descarga directa")
+	ErrConfidencialPermiso = errors.New("marcar documentos como confidenciales requiere el permiso documento:confidential")
+	ErrVersionNotFound     = errors.New("versión de documento no encontrada")
+	ErrVersionActual       = errors.New("la versión ya es la vigente")
+)
+
+// DocumentoService handles the files attached to expedientes
+type DocumentoService struct {
+	documentoRepo     *repository.DocumentoRepository
+	expedienteRepo    *repository.ExpedienteRepository
+	expedienteService *ExpedienteService
+	textoService      *TextoService
+	auditRepo         *repository.AuditRepository
+	storage           storage.Storage
+	maxTamano         int64
+	duracionEnlace    time.Duration
+}
+
+// NewDocumentoService creates a new documento service; maxTamano is the size limit of each file in
+// bytes and duracionEnlace the lifetime of presigned download URLs (zero disables them)
+func NewDocumentoService(documentoRepo *repository.DocumentoRepository, expedienteRepo *repository.ExpedienteRepository, expedienteService *ExpedienteService, textoService *TextoService, auditRepo *repository.AuditRepository, storage storage.Storage, maxTamano int64, duracionEnlace time.Duration) *DocumentoService {
+	return &DocumentoService{
+		documentoRepo:     documentoRepo,
+		expedienteRepo:    expedienteRepo,
+		expedienteService: expedienteService,
+		textoService:      textoService,
+		auditRepo:         auditRepo,
+		storage:           storage,
+		maxTamano:         maxTamano,
+		duracionEnlace:    duracionEnlace,
+	}
+}
+
+// MaxTamano returns the size limit of each attached file in bytes
+func (s *DocumentoService) MaxTamano() int64 {
+	return s.maxTamano
+}
+
+// formatosPorExtension maps the accepted file extensions to their format
+var formatosPorExtension = map[string]models.FormatoDocumento{
+	".pdf":  models.FormatoDocumentoPDF,
+	".doc":  models.FormatoDocumentoDOC,
+	".docx": models.FormatoDocumentoDOCX,
+	".jpg":  models.FormatoDocumentoJPG,
+	".jpeg": models.FormatoDocumentoJPG,
+	".png":  models.FormatoDocumentoPNG,
+}
+
+// detectarFormato identifies the format of a file from its content. The client's Content-Type
+// is not trusted: a DOCX is a ZIP that must contain word/document.xml, and a DOC is an OLE
+// compound file.
+func detectarFormato(file multipart.File, size int64) (models.FormatoDocumento, bool) {
+	cabecera := make([]byte, 8)
+	n, _ := io.ReadFull(file, cabecera)
+	cabecera = cabecera[:n]
+
+	switch {
+	case bytes.HasPrefix(cabecera, []byte("%PDF-")):
+		return models.FormatoDocumentoPDF, true
+	case bytes.HasPrefix(cabecera, []byte{0xFF, 0xD8, 0xFF}):
+		return models.FormatoDocumentoJPG, true
+	case bytes.HasPrefix(cabecera, []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}):
+		return models.FormatoDocumentoPNG, true
+	case bytes.HasPrefix(cabecera, []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}):
+		return models.FormatoDocumentoDOC, true
+	case bytes.HasPrefix(cabecera, []byte("PK\x03\x04")):
+		archivo, err := zip.NewReader(file, size)
+		if err != nil {
+			return "", false
+		}
+		for _, entrada := range archivo.File {
+			if entrada.Name == "word/document.xml" {
+				return models.FormatoDocumentoDOCX, true
+			}
+		}
+	}
+	return "", false
+}
+
+// validarArchivo checks the size of an uploaded file and that its content matches its extension
+func (s *DocumentoService) validarArchivo(file *multipart.FileHeader) (models.FormatoDocumento, error) {
+	if file.Size > s.maxTamano {
+		return "", fmt.Errorf("%w (%d MB): %s", ErrDocumentoTamano, s.maxTamano/(1024*1024), file.Filename)
+	}
+
+	esperado, ok := formatosPorExtension[strings.ToLower(filepath.Ext(file.Filename))]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrDocumentoFormato, file.Filename)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %w", err)
+	}
+	defer src.Close()
+
+	formato, ok := detectarFormato(src, file.Size)
+	if !ok || formato != esperado {
+		return "", fmt.Errorf("%w: %s", ErrDocumentoFormato, file.Filename)
+	}
+	return formato, nil
+}
+
+// SubirDocumentos attaches up to MaxDocumentosPorCarga files to an expediente, all with the same
+// metadata. Every file is validated before any is stored, so a rejected file uploads nothing.
+func (s *DocumentoService) SubirDocumentos(expedienteID string, files []*multipart.FileHeader, req *models.SubirDocumentosRequest, scope models.AccessScope) ([]models.Documento, error) {
+	// Las emergencias solo otorgan lectura
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope.WithoutBreakGlass())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 || len(files) > models.MaxDocumentosPorCarga {
+		return nil, ErrDocumentosCantidad
+	}
+	if req.Confidencial && !scope.HasPermission(models.PermissionDocumentoConfidential) {
+		return nil, ErrConfidencialPermiso
+	}
+
+	var fecha *time.Time
+	if req.Fecha != "" {
+		parsed, err := time.Parse("2006-01-02", req.Fecha)
+		if err != nil {
+			return nil, fmt.Errorf("fecha inválida: %w", err)
+		}
+		fecha = &parsed
+	}
+
+	formatos := make([]models.FormatoDocumento, len(files))
+	for i, file := range files {
+		if formatos[i], err = s.validarArchivo(file); err != nil {
+			return nil, err
+		}
+	}
+
+	documentos := make([]models.Documento, 0, len(files))
+	for i, file := range files {
+		documento, err := s.guardarArchivo(expediente, file, formatos[i], req, fecha, scope)
+		if err != nil {
+			// Los archivos ya guardados quedan adjuntos; se informa cuáles
+			if len(documentos) > 0 {
+				log.Printf("⚠️ Carga de documentos del expediente %s interrumpida tras %d archivos: %v", expediente.CIP, len(documentos), err)
+			}
+			return documentos, err
+		}
+		documentos = append(documentos, *documento)
+
+		if err := s.auditRepo.Log(&models.AuditLog{
+			UsuarioID: scope.UserID.Hex(),
+			Usuario:   scope.Email,
+			Accion:    models.AccionDocumentoSubida,
+			Recurso:   models.RecursoExpediente,
+			RecursoID: expediente.ID.Hex(),
+			IP:        scope.IP,
+			Detalles: map[string]interface{}{
+				"documento_id": documento.ID.Hex(),
+				"nombre":       documento.Nombre,
+				"formato":      documento.Formato,
+				"tamano":       documento.Tamano,
+				"tipo":         documento.Tipo,
+				"sha256":       documento.SHA256,
+				"confidencial": documento.Confidencial,
+			},
+		}); err != nil {
+			log.Printf("⚠️ Error registrando auditoría del documento %s: %v", documento.ID.Hex(), err)
+		}
+	}
+
+	log.Printf("📎 Expediente %s: %d documentos adjuntados por %s", expediente.CIP, len(documentos), scope.Email)
+	return documentos, nil
+}
+
+// almacenarArchivo stores an uploaded file under the key and returns its SHA-256
+func (s *DocumentoService) almacenarArchivo(clave string, file *multipart.FileHeader, formato models.FormatoDocumento) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("error opening file: %w", err)
+	}
+	defer src.Close()
+
+	return s.almacenarContenido(clave, src, file.Size, filepath.Base(file.Filename), formato)
+}
+
+// almacenarContenido stores a file under the key and returns its SHA-256, computed while the
+// content streams to the storage
+func (s *DocumentoService) almacenarContenido(clave string, src io.Reader, tamano int64, nombre string, formato models.FormatoDocumento) (string, error) {
+	hash := sha256.New()
+	if err := s.storage.Put(clave, io.TeeReader(src, hash), tamano, formato.ContentType()); err != nil {
+		return "", fmt.Errorf("error guardando %s: %w", nombre, err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// nuevaVersion describes a stored file as a version of an attachment
+func nuevaVersion(numero int, clave, nombre string, tamano int64, formato models.FormatoDocumento, sha string, scope models.AccessScope) models.VersionDocumento {
+	return models.VersionDocumento{
+		Version:     numero,
+		Nombre:      nombre,
+		Formato:     formato,
+		ContentType: formato.ContentType(),
+		Tamano:      tamano,
+		SHA256:      sha,
+		Clave:       clave,
+		SubidoPor:   scope.UserID,
+		Usuario:     scope.Email,
+		SubidoEn:    time.Now(),
+		Integridad:  models.IntegridadPendiente,
+	}
+}
+
+// guardarArchivo stores one validated file and its metadata, removing the file if the metadata
+// cannot be saved
+func (s *DocumentoService) guardarArchivo(expediente *models.Expediente, file *multipart.FileHeader, formato models.FormatoDocumento, req *models.SubirDocumentosRequest, fecha *time.Time, scope models.AccessScope) (*models.Documento, error) {
+	id := primitive.NewObjectID()
+	clave := fmt.Sprintf("expedientes/%s/%s.%s", expediente.ID.Hex(), id.Hex(), formato)
+
+	sha, err := s.almacenarArchivo(clave, file, formato)
+	if err != nil {
+		return nil, err
+	}
+
+	version := nuevaVersion(1, clave, filepath.Base(file.Filename), file.Size, formato, sha, scope)
+	documento := &models.Documento{
+		ID:           id,
+		ExpedienteID: expediente.ID,
+		Tipo:         strings.TrimSpace(req.Tipo),
+		Descripcion:  strings.TrimSpace(req.Descripcion),
+		Fecha:        fecha,
+		Confidencial: req.Confidencial,
+		SubidoPor:    scope.UserID,
+		Usuario:      scope.Email,
+		SubidoEn:     version.SubidoEn,
+		Versiones:    []models.VersionDocumento{version},
+		Texto:        models.TextoInicial(formato),
+	}
+	aplicarVersion(documento, version)
+
+	if err := s.documentoRepo.Create(documento); err != nil {
+		if delErr := s.storage.Delete(documento.Clave); delErr != nil {
+			log.Printf("⚠️ Error eliminando el archivo huérfano %s: %v", documento.Clave, delErr)
+		}
+		return nil, err
+	}
+	s.textoService.Nuevo(documento)
+
+	return documento, nil
+}
+
+// GetDocumentos returns the attachments of an expediente readable within the scope
+func (s *DocumentoService) GetDocumentos(expedienteID string, scope models.AccessScope) ([]models.Documento, error) {
+	expediente, err := s.expedienteService.GetExpedienteByID(expedienteID, "documentos", scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.documentoRepo.GetByExpediente(expediente.ID, scope.HasPermission(models.PermissionDocumentoConfidential))
+}
+
+// obtenerDocumento returns an attachment of the expediente; confidential attachments do not
+// exist for callers without documento:confidential
+func (s *DocumentoService) obtenerDocumento(expedienteID primitive.ObjectID, documentoID string, scope models.AccessScope) (*models.Documento, error) {
+	documento, err := s.documentoRepo.GetByID(expedienteID, documentoID)
+	if err != nil {
+		return nil, err
+	}
+	if documento.Confidencial && !scope.HasPermission(models.PermissionDocumentoConfidential) {
+		return nil, repository.ErrDocumentoNotFound
+	}
+	return documento, nil
+}
+
+// AbrirDocumento returns an attachment and its content for download; the caller must close the reader
+func (s *DocumentoService) AbrirDocumento(expedienteID, documentoID string, scope models.AccessScope) (*models.Documento, io.ReadCloser, error) {
+	expediente, err := s.expedienteService.GetExpedienteByID(expedienteID, "descarga_documento", scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contenido, err := s.storage.Get(documento.Clave)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			log.Printf("⚠️ Documento %s sin archivo en el almacenamiento (%s)", documento.ID.Hex(), documento.Clave)
+		}
+		return nil, nil, err
+	}
+
+	return documento, contenido, nil
+}
+
+// EnlaceDocumento returns a short-lived URL that downloads an attachment straight from the
+// object store, for drivers that support it. Access is checked and logged like a download.
+func (s *DocumentoService) EnlaceDocumento(expedienteID, documentoID string, inline bool, scope models.AccessScope) (*models.EnlaceDocumento, error) {
+	presigner, ok := s.storage.(storage.Presigner)
+	if !ok || s.duracionEnlace <= 0 {
+		return nil, ErrEnlaceNoDisponible
+	}
+
+	expediente, err := s.expedienteService.GetExpedienteByID(expedienteID, "descarga_documento", scope)
+	if err != nil {
+		return nil, err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	expiraEn := time.Now().Add(s.duracionEnlace)
+	url, err := presigner.PresignGet(documento.Clave, s.duracionEnlace, documento.Nombre, inline && documento.Formato.Visualizable())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EnlaceDocumento{URL: url, ExpiraEn: expiraEn}, nil
+}
+
+// EliminarDocumento removes an attachment and its file
+func (s *DocumentoService) EliminarDocumento(expedienteID, documentoID string, scope models.AccessScope) error {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope.WithoutBreakGlass())
+	if err != nil {
+		return err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return err
+	}
+
+	if err := s.documentoRepo.Delete(documento.ID); err != nil {
+		return err
+	}
+	s.textoService.Eliminar(documento.ID)
+	// Sin metadatos los archivos ya no son accesibles; un fallo aquí solo deja espacio ocupado
+	for _, clave := range clavesDocumento(documento) {
+		if err := s.storage.Delete(clave); err != nil {
+			log.Printf("⚠️ Error eliminando el archivo %s: %v", clave, err)
+		}
+	}
+
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionDocumentoEliminacion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles: map[string]interface{}{
+			"documento_id": documento.ID.Hex(),
+			"nombre":       documento.Nombre,
+			"formato":      documento.Formato,
+			"tipo":         documento.Tipo,
+			"subido_por":   documento.Usuario,
+			"versiones":    len(versionesDocumento(documento)),
+		},
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría del documento %s: %v", documento.ID.Hex(), err)
+	}
+
+	log.Printf("🗑️ Expediente %s: documento %s eliminado por %s", expediente.CIP, documento.Nombre, scope.Email)
+	return nil
+}
+
+// versionesDocumento returns the versions of an attachment. Attachments stored before
+// versioning have a single implicit version without a recorded hash.
+func versionesDocumento(documento *models.Documento) []models.VersionDocumento {
+	if len(documento.Versiones) > 0 {
+		return documento.Versiones
+	}
+	return []models.VersionDocumento{{
+		Version:     1,
+		Nombre:      documento.Nombre,
+		Formato:     documento.Formato,
+		ContentType: documento.ContentType,
+		Tamano:      documento.Tamano,
+		SHA256:      documento.SHA256,
+		Clave:       documento.Clave,
+		SubidoPor:   documento.SubidoPor,
+		Usuario:     documento.Usuario,
+		SubidoEn:    documento.SubidoEn,
+		Integridad:  models.IntegridadPendiente,
+	}}
+}
+
+// versionActual returns the number of the current version of an attachment
+func versionActual(documento *models.Documento) int {
+	if documento.Version == 0 {
+		return 1
+	}
+	return documento.Version
+}
+
+// aplicarVersion makes a version the current file of an attachment
+func aplicarVersion(documento *models.Documento, version models.VersionDocumento) {
+	documento.Version = version.Version
+	documento.Nombre = version.Nombre
+	documento.Formato = version.Formato
+	documento.ContentType = version.ContentType
+	documento.Tamano = version.Tamano
+	documento.SHA256 = version.SHA256
+	documento.Clave = version.Clave
+	documento.Integridad = integridadDocumento(documento.Versiones)
+}
+
+// integridadDocumento returns the worst integrity state of the versions of an attachment
+func integridadDocumento(versiones []models.VersionDocumento) models.EstadoIntegridad {
+	estado := models.IntegridadOK
+	for _, version := range versiones {
+		if version.Integridad.Gravedad() > estado.Gravedad() {
+			estado = version.Integridad
+		}
+	}
+	return estado
+}
+
+// clavesDocumento returns the distinct storage keys of every version of an attachment
+func clavesDocumento(documento *models.Documento) []string {
+	vistas := make(map[string]bool)
+	var claves []string
+	for _, version := range versionesDocumento(documento) {
+		if !vistas[version.Clave] {
+			vistas[version.Clave] = true
+			claves = append(claves, version.Clave)
+		}
+	}
+	return claves
+}
+
+// buscarVersion returns a version of an attachment by number
+func buscarVersion(documento *models.Documento, numero int) (*models.VersionDocumento, error) {
+	versiones := versionesDocumento(documento)
+	for i := range versiones {
+		if versiones[i].Version == numero {
+			return &versiones[i], nil
+		}
+	}
+	return nil, ErrVersionNotFound
+}
+
+// registrarAuditoriaDocumento logs a change to an attachment
+func (s *DocumentoService) registrarAuditoriaDocumento(accion string, documento *models.Documento, scope models.AccessScope, detalles map[string]interface{}) {
+	detalles["documento_id"] = documento.ID.Hex()
+	detalles["nombre"] = documento.Nombre
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    accion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: documento.ExpedienteID.Hex(),
+		IP:        scope.IP,
+		Detalles:  detalles,
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría del documento %s: %v", documento.ID.Hex(), err)
+	}
+}
+
+// SubirVersion replaces the current file of an attachment with a re-scanned or corrected one.
+// The previous versions are kept and can be restored.
+func (s *DocumentoService) SubirVersion(expedienteID, documentoID string, file *multipart.FileHeader, scope models.AccessScope) (*models.Documento, error) {
+	// Las emergencias solo otorgan lectura
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope.WithoutBreakGlass())
+	if err != nil {
+		return nil, err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	formato, err := s.validarArchivo(file)
+	if err != nil {
+		return nil, err
+	}
+
+	anterior := documento.Version
+	numero := versionActual(documento) + 1
+	clave := fmt.Sprintf("expedientes/%s/%s.v%d.%s", expediente.ID.Hex(), documento.ID.Hex(), numero, formato)
+
+	sha, err := s.almacenarArchivo(clave, file, formato)
+	if err != nil {
+		return nil, err
+	}
+
+	version := nuevaVersion(numero, clave, filepath.Base(file.Filename), file.Size, formato, sha, scope)
+	documento.Versiones = append(versionesDocumento(documento), version)
+	aplicarVersion(documento, version)
+
+	if err := s.documentoRepo.ActualizarVersiones(documento, anterior); err != nil {
+		if delErr := s.storage.Delete(clave); delErr != nil {
+			log.Printf("⚠️ Error eliminando el archivo huérfano %s: %v", clave, delErr)
+		}
+		return nil, err
+	}
+	s.textoService.Reindexar(documento)
+
+	s.registrarAuditoriaDocumento(models.AccionDocumentoVersion, documento, scope, map[string]interface{}{
+		"version":          numero,
+		"version_anterior": numero - 1,
+		"sha256":           sha,
+		"tamano":           version.Tamano,
+	})
+
+	log.Printf("📎 Expediente %s: documento %s en versión %d por %s", expediente.CIP, documento.ID.Hex(), numero, scope.Email)
+	return documento, nil
+}
+
+// GetVersiones returns the versions of an attachment, newest first
+func (s *DocumentoService) GetVersiones(expedienteID, documentoID string, scope models.AccessScope) ([]models.VersionDocumento, error) {
+	expediente, err := s.expedienteService.GetExpedienteByID(expedienteID, "documentos", scope)
+	if err != nil {
+		return nil, err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	versiones := versionesDocumento(documento)
+	resultado := make([]models.VersionDocumento, len(versiones))
+	for i, version := range versiones {
+		resultado[len(versiones)-1-i] = version
+	}
+	return resultado, nil
+}
+
+// AbrirVersion returns a version of an attachment and its content for download; the caller
+// must close the reader
+func (s *DocumentoService) AbrirVersion(expedienteID, documentoID string, numero int, scope models.AccessScope) (*models.VersionDocumento, io.ReadCloser, error) {
+	expediente, err := s.expedienteService.GetExpedienteByID(expedienteID, "descarga_documento", scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	version, err := buscarVersion(documento, numero)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contenido, err := s.storage.Get(version.Clave)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return version, contenido, nil
+}
+
+// RestaurarVersion makes a prior version current again. It is recorded as a new version that
+// reuses the stored file, so the history is never rewritten.
+func (s *DocumentoService) RestaurarVersion(expedienteID, documentoID string, numero int, scope models.AccessScope) (*models.Documento, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope.WithoutBreakGlass())
+	if err != nil {
+		return nil, err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	origen, err := buscarVersion(documento, numero)
+	if err != nil {
+		return nil, err
+	}
+	if origen.Version == versionActual(documento) {
+		return nil, ErrVersionActual
+	}
+
+	anterior := documento.Version
+	version := *origen
+	version.Version = versionActual(documento) + 1
+	version.RestauradaDe = origen.Version
+	version.SubidoPor = scope.UserID
+	version.Usuario = scope.Email
+	version.SubidoEn = time.Now()
+
+	documento.Versiones = append(versionesDocumento(documento), version)
+	aplicarVersion(documento, version)
+
+	if err := s.documentoRepo.ActualizarVersiones(documento, anterior); err != nil {
+		return nil, err
+	}
+	s.textoService.Reindexar(documento)
+
+	s.registrarAuditoriaDocumento(models.AccionDocumentoRestauracion, documento, scope, map[string]interface{}{
+		"version":       version.Version,
+		"restaurada_de": origen.Version,
+		"sha256":        version.SHA256,
+	})
+
+	log.Printf("📎 Expediente %s: documento %s restaurado a la versión %d por %s", expediente.CIP, documento.ID.Hex(), origen.Version, scope.Email)
+	return documento, nil
+}
+
+// SetConfidencial marks or unmarks an attachment as confidential
+func (s *DocumentoService) SetConfidencial(expedienteID, documentoID string, confidencial bool, scope models.AccessScope) (*models.Documento, error) {
+	if !scope.HasPermission(models.PermissionDocumentoConfidential) {
+		return nil, ErrConfidencialPermiso
+	}
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope.WithoutBreakGlass())
+	if err != nil {
+		return nil, err
+	}
+
+	documento, err := s.obtenerDocumento(expediente.ID, documentoID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if documento.Confidencial == confidencial {
+		return documento, nil
+	}
+
+	if err := s.documentoRepo.SetConfidencial(documento.ID, confidencial); err != nil {
+		return nil, err
+	}
+	documento.Confidencial = confidencial
+
+	s.registrarAuditoriaDocumento(models.AccionDocumentoConfidencial, documento, scope, map[string]interface{}{
+		"confidencial": confidencial,
+	})
+
+	return documento, nil
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Merge errors
+var (
+	ErrMergeMismoExpediente = errors.New("el expediente superviviente no puede fusionarse consigo mismo")
+	ErrMergeFuera           = errors.New("solo pueden fusionarse expedientes que están dentro del archivo")
+	ErrMergeTomos           = errors.New("los expedientes a fusionar deben tener tomos todos o ninguno; registre las páginas como tomo primero")
+	ErrUmbralInvalido       = errors.New("el umbral de similitud debe estar entre 0 y 1")
+)
+
+// UmbralDuplicadosDefecto is the name similarity from which two expedientes are reported
+const UmbralDuplicadosDefecto = 0.85
+
+// normalizadorNombres reduces names to unaccented words; Ñ is compared as N
+var normalizadorNombres = NewUbicacionEngine(UbicacionRules{})
+
+// DuplicadoService finds expedientes that likely belong to the same person and merges them
+type DuplicadoService struct {
+	expedienteRepo   *repository.ExpedienteRepository
+	tomoRepo         *repository.TomoRepository
+	estadoRepo       *repository.EstadoRepository
+	carreraRepo      *repository.CarreraRepository
+	prestamoRepo     *repository.PrestamoRepository
+	documentoRepo    *repository.DocumentoRepository
+	movimientoRepo   *repository.MovimientoRepository
+	notificacionRepo *repository.NotificacionRepository
+	auditRepo        *repository.AuditRepository
+}
+
+// NewDuplicadoService creates a new duplicado service
+func NewDuplicadoService(expedienteRepo *repository.ExpedienteRepository, tomoRepo *repository.TomoRepository, estadoRepo *repository.EstadoRepository, carreraRepo *repository.CarreraRepository, prestamoRepo *repository.PrestamoRepository, documentoRepo *repository.DocumentoRepository, movimientoRepo *repository.MovimientoRepository, notificacionRepo *repository.NotificacionRepository, auditRepo *repository.AuditRepository) *DuplicadoService {
+	return &DuplicadoService{
+		expedienteRepo:   expedienteRepo,
+		tomoRepo:         tomoRepo,
+		estadoRepo:       estadoRepo,
+		carreraRepo:      carreraRepo,
+		prestamoRepo:     prestamoRepo,
+		documentoRepo:    documentoRepo,
+		movimientoRepo:   movimientoRepo,
+		notificacionRepo: notificacionRepo,
+		auditRepo:        auditRepo,
+	}
+}
+
+// DetectarDuplicados returns the pairs of expedientes with the same CIP once formatting is
+// removed, or whose names are at least umbral similar ignoring accents and word order.
+// Pairs are sorted from the most to the least similar.
+func (s *DuplicadoService) DetectarDuplicados(umbral float64, scope models.AccessScope) ([]models.ParDuplicado, error) {
+	if umbral <= 0 || umbral > 1 {
+		return nil, ErrUmbralInvalido
+	}
+
+	expedientes, err := s.expedienteRepo.GetIdentidades(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	nombres := make([]string, len(expedientes))
+	porCIP := make(map[string][]int)
+	porPalabra := make(map[string][]int)
+	for i, expediente := range expedientes {
+		palabras := normalizadorNombres.tokens(expediente.ApellidosNombres)
+		sort.Strings(palabras)
+		nombres[i] = strings.Join(palabras, " ")
+
+		if cip := normalizarCIP(expediente.CIP); cip != "" {
+			porCIP[cip] = append(porCIP[cip], i)
+		}
+		// Solo palabras de tres o más letras agrupan candidatos; "DE" o "LA" juntarían a casi todos
+		for _, palabra := range palabras {
+			if len([]rune(palabra)) >= 3 {
+				porPalabra[palabra] = append(porPalabra[palabra], i)
+			}
+		}
+	}
+
+	pares := make(map[[2]int]*models.ParDuplicado)
+	agregar := func(i, j int, similitud float64, motivo string) {
+		clave := [2]int{i, j}
+		par, ok := pares[clave]
+		if !ok {
+			par = &models.ParDuplicado{Expedientes: []*models.Expediente{expedientes[i], expedientes[j]}}
+			pares[clave] = par
+		}
+		par.Motivos = append(par.Motivos, motivo)
+		if similitud > par.Similitud {
+			par.Similitud = similitud
+		}
+	}
+
+	for _, grupo := range porCIP {
+		for a := 0; a < len(grupo); a++ {
+			for b := a + 1; b < len(grupo); b++ {
+				agregar(grupo[a], grupo[b], 1, models.MotivoDuplicadoCIP)
+			}
+		}
+	}
+
+	comparados := make(map[[2]int]bool)
+	for _, grupo := range porPalabra {
+		for a := 0; a < len(grupo); a++ {
+			for b := a + 1; b < len(grupo); b++ {
+				clave := [2]int{grupo[a], grupo[b]}
+				if comparados[clave] {
+					continue
+				}
+				comparados[clave] = true
+
+				if similitud := similitudNombres(nombres[clave[0]], nombres[clave[1]]); similitud >= umbral {
+					agregar(clave[0], clave[1], similitud, models.MotivoDuplicadoNombre)
+				}
+			}
+		}
+	}
+
+	resultado := make([]models.ParDuplicado, 0, len(pares))
+	for _, par := range pares {
+		resultado = append(resultado, *par)
+	}
+	sort.Slice(resultado, func(i, j int) bool {
+		if resultado[i].Similitud != resultado[j].Similitud {
+			return resultado[i].Similitud > resultado[j].Similitud
+		}
+		return resultado[i].Expedientes[0].CIP < resultado[j].Expedientes[0].CIP
+	})
+
+	return resultado, nil
+}
+
+// normalizarCIP removes separators, case and leading zeros from a CIP
+func normalizarCIP(cip string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(cip) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimLeft(b.String(), "0")
+}
+
+// similitudNombres returns 1 minus the edit distance between two names relative to the longest
+func similitudNombres(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	// Distancia de Levenshtein con dos filas
+	anterior := make([]int, len(rb)+1)
+	actual := make([]int, len(rb)+1)
+	for j := range anterior {
+		anterior[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		actual[0] = i
+		for j := 1; j <= len(rb); j++ {
+			costo := 1
+			if ra[i-1] == rb[j-1] {
+				costo = 0
+			}
+			actual[j] = min(anterior[j]+1, actual[j-1]+1, anterior[j-1]+costo)
+		}
+		anterior, actual = actual, anterior
+	}
+
+	return 1 - float64(anterior[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+// Merge combines duplicate expedientes into a surviving one. Their pages or tomos, state
+// history, career events and movements move to the survivor, and the duplicates are soft-deleted with
+// a reference to it. The survivor takes the highest classification among the merged expedientes,
+// so nothing merged into it becomes readable at a lower clearance. Every merged record is
+// recorded in the audit log.
+func (s *DuplicadoService) Merge(req *models.MergeExpedientesRequest, scope models.AccessScope) (*models.ResultadoMerge, error) {
+	scope = scope.WithoutBreakGlass()
+
+	superviviente, err := s.expedienteRepo.GetByID(req.SupervivienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicados []*models.Expediente
+	vistos := map[primitive.ObjectID]bool{superviviente.ID: true}
+	for _, id := range req.DuplicadoIDs {
+		duplicado, err := s.expedienteRepo.GetByID(id, scope)
+		if err != nil {
+			return nil, err
+		}
+		if duplicado.ID == superviviente.ID {
+			return nil, ErrMergeMismoExpediente
+		}
+		if vistos[duplicado.ID] {
+			continue
+		}
+		vistos[duplicado.ID] = true
+
+		if duplicado.Estado != models.EstadoDentro || duplicado.TomosFuera > 0 {
+			return nil, fmt.Errorf("%w: CIP %s está %s", ErrMergeFuera, duplicado.CIP, duplicado.Estado)
+		}
+		if (duplicado.Tomos > 0) != (superviviente.Tomos > 0) {
+			return nil, fmt.Errorf("%w: CIP %s", ErrMergeTomos, duplicado.CIP)
+		}
+		duplicados = append(duplicados, duplicado)
+	}
+
+	// La clasificación se eleva antes de mover nada al superviviente
+	clasificacionAntes := superviviente.Clasificacion
+	if clasificacionAntes == "" {
+		clasificacionAntes = models.ClasificacionPublico
+	}
+	clasificacion := clasificacionAntes
+	for _, duplicado := range duplicados {
+		if duplicado.Clasificacion.Nivel() > clasificacion.Nivel() {
+			clasificacion = duplicado.Clasificacion
+		}
+	}
+	if clasificacion != clasificacionAntes {
+		if err := s.expedienteRepo.Update(superviviente.ID.Hex(), map[string]interface{}{
+			"clasificacion": clasificacion,
+			"updatedBy":     scope.UserID,
+		}); err != nil {
+			return nil, err
+		}
+
+		entry, _ := classifiedAccessEntry(scope, "fusion", superviviente.ID, superviviente.CIP, clasificacion)
+		entry.Accion = models.AccionCambioClasificacion
+		entry.Detalles["anterior"] = clasificacionAntes
+		entry.Detalles["nueva"] = clasificacion
+		entry.Detalles["justificacion"] = req.Justificacion
+		storeClassifiedAccess(s.auditRepo, []models.AuditLog{entry})
+		log.Printf("🔒 Clasificación de expediente %s elevada de %s a %s por la fusión", superviviente.CIP, clasificacionAntes, clasificacion)
+	}
+
+	resultado := &models.ResultadoMerge{Fusionados: []primitive.ObjectID{}}
+	paginasAntes := superviviente.NumeroPaginas
+
+	// Los tomos de cada duplicado se agregan después de los del superviviente
+	var numero, pagina int
+	if superviviente.Tomos > 0 {
+		if numero, pagina, err = s.ultimoTomo(superviviente.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, duplicado := range duplicados {
+		if superviviente.Tomos > 0 {
+			ultimoNumero, ultimaPagina, err := s.ultimoTomo(duplicado.ID)
+			if err != nil {
+				return nil, err
+			}
+			movidos, err := s.tomoRepo.Reasignar(duplicado.ID, superviviente.ID, numero, pagina, scope.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("error moviendo tomos de %s: %w", duplicado.CIP, err)
+			}
+			resultado.TomosMovidos += movidos
+			numero += ultimoNumero
+			pagina += ultimaPagina
+		}
+
+		cambios, err := s.estadoRepo.ReasignarHistorial(duplicado.ID, superviviente.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error moviendo historial de %s: %w", duplicado.CIP, err)
+		}
+		resultado.CambiosEstado += cambios
+
+		eventos, err := s.carreraRepo.Reasignar(duplicado.ID, superviviente.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error moviendo eventos de carrera de %s: %w", duplicado.CIP, err)
+		}
+		resultado.EventosCarrera += eventos
+
+		prestamos, err := s.prestamoRepo.Reasignar(duplicado.ID, superviviente.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error moviendo préstamos de %s: %w", duplicado.CIP, err)
+		}
+		resultado.Prestamos += prestamos
+
+		documentos, err := s.documentoRepo.Reasignar(duplicado.ID, superviviente.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error moviendo documentos de %s: %w", duplicado.CIP, err)
+		}
+		resultado.Documentos += documentos
+
+		// Los movimientos del duplicado se numeran después del último del superviviente
+		ultimo, err := s.movimientoRepo.UltimoNumero(superviviente.ID)
+		if err != nil {
+			return nil, err
+		}
+		movimientos, err := s.movimientoRepo.Reasignar(duplicado.ID, superviviente.ID, ultimo)
+		if err != nil {
+			return nil, fmt.Errorf("error moviendo movimientos de %s: %w", duplicado.CIP, err)
+		}
+		resultado.Movimientos += movimientos
+
+		notificaciones, err := s.notificacionRepo.Reasignar(duplicado.ID, superviviente.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error moviendo notificaciones de %s: %w", duplicado.CIP, err)
+		}
+		resultado.Notificaciones += notificaciones
+	}
+
+	// Las páginas y el resumen cambian solo cuando todo lo de los duplicados ya es del superviviente.
+	// Si algo falla antes, los duplicados siguen vigentes con sus páginas y la fusión puede repetirse
+	// para mover lo que quedó.
+	if superviviente.Tomos > 0 {
+		resumen, err := s.tomoRepo.Resumen(superviviente.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.expedienteRepo.UpdateResumenTomos(superviviente.ID, resumen); err != nil {
+			return nil, err
+		}
+	} else {
+		paginas := superviviente.NumeroPaginas
+		digitalizadas := superviviente.Digitalizadas
+		for _, duplicado := range duplicados {
+			paginas += duplicado.NumeroPaginas
+			digitalizadas += duplicado.Digitalizadas
+		}
+		if err := s.expedienteRepo.Update(superviviente.ID.Hex(), map[string]interface{}{
+			"numero_paginas":        paginas,
+			"paginas_digitalizadas": digitalizadas,
+			"updatedBy":             scope.UserID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, duplicado := range duplicados {
+		if err := s.expedienteRepo.MarkFusionado(duplicado.ID, superviviente.ID, scope.UserID); err != nil {
+			return nil, err
+		}
+		resultado.Fusionados = append(resultado.Fusionados, duplicado.ID)
+	}
+
+	if resultado.Superviviente, err = s.expedienteRepo.GetByID(superviviente.ID.Hex(), scope); err != nil {
+		return nil, err
+	}
+
+	fusionados := make([]string, len(duplicados))
+	entries := make([]models.AuditLog, 0, len(duplicados)+1)
+	for i, duplicado := range duplicados {
+		fusionados[i] = duplicado.CIP
+		entries = append(entries, models.AuditLog{
+			UsuarioID: scope.UserID.Hex(),
+			Usuario:   scope.Email,
+			Accion:    models.AccionFusion,
+			Recurso:   models.RecursoExpediente,
+			RecursoID: duplicado.ID.Hex(),
+			IP:        scope.IP,
+			Detalles: map[string]interface{}{
+				"superviviente_id":  superviviente.ID.Hex(),
+				"cip":               duplicado.CIP,
+				"apellidos_nombres": duplicado.ApellidosNombres,
+				"numero_paginas":    duplicado.NumeroPaginas,
+				"tomos":             duplicado.Tomos,
+				"clasificacion":     duplicado.Clasificacion,
+				"justificacion":     req.Justificacion,
+			},
+		})
+	}
+	entries = append(entries, models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionFusion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: superviviente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles: map[string]interface{}{
+			"fusionados":             fusionados,
+			"numero_paginas_antes":   paginasAntes,
+			"numero_paginas_despues": resultado.Superviviente.NumeroPaginas,
+			"clasificacion_antes":    clasificacionAntes,
+			"clasificacion_despues":  clasificacion,
+			"tomos_movidos":          resultado.TomosMovidos,
+			"cambios_estado":         resultado.CambiosEstado,
+			"eventos_carrera":        resultado.EventosCarrera,
+			"prestamos":              resultado.Prestamos,
+			"documentos":             resultado.Documentos,
+			"movimientos":            resultado.Movimientos,
+			"notificaciones":         resultado.Notificaciones,
+			"justificacion":          req.Justificacion,
+		},
+	})
+	if err := s.auditRepo.LogMany(entries); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de la fusión de %s: %v", superviviente.CIP, err)
+	}
+
+	log.Printf("🔗 Expediente %s: %d duplicados fusionados por %s", superviviente.CIP, len(duplicados), scope.Email)
+	return resultado, nil
+}
+
+// ultimoTomo returns the highest tomo number and last page of an expediente's tomos
+func (s *DuplicadoService) ultimoTomo(expedienteID primitive.ObjectID) (int, int, error) {
+	tomos, err := s.tomoRepo.GetByExpediente(expedienteID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	numero, pagina := 0, 0
+	for _, tomo := range tomos {
+		numero = max(numero, tomo.Numero)
+		pagina = max(pagina, tomo.PaginaHasta)
+	}
+	return numero, pagina, nil
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// State machine errors
+var (
+	ErrEstadoInvalido           = errors.New("estado de expediente inválido")
+	ErrTransicionNoPermitida    = errors.New("la transición de estado no está permitida")
+	ErrPermisoTransicion        = errors.New("no tiene permiso para realizar esta transición de estado")
+	ErrJustificacionRequerida   = errors.New("el cambio de estado requiere una justificación")
+	ErrPermisoInvalido          = errors.New("permiso inválido")
+	ErrEstadoRequiereTransicion = errors.New("el estado solo puede cambiarse mediante una transición con justificación")
+)
+
+// EstadoService applies the configurable lifecycle of expedientes
+type EstadoService struct {
+	estadoRepo     *repository.EstadoRepository
+	expedienteRepo *repository.ExpedienteRepository
+}
+
+// NewEstadoService creates a new estado service
+func NewEstadoService(estadoRepo *repository.EstadoRepository, expedienteRepo *repository.ExpedienteRepository) *EstadoService {
+	return &EstadoService{
+		estadoRepo:     estadoRepo,
+		expedienteRepo: expedienteRepo,
+	}
+}
+
+// CambiarEstado moves an expediente to another state if a transition from its current state is
+// configured, the caller holds the permission guarding it and a justification is given
+func (s *EstadoService) CambiarEstado(id string, req *models.UpdateEstadoRequest, scope models.AccessScope) (*models.CambioEstado, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	if !req.Estado.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrEstadoInvalido, req.Estado)
+	}
+	justificacion := strings.TrimSpace(req.Justificacion)
+	if justificacion == "" {
+		return nil, ErrJustificacionRequerida
+	}
+
+	expediente, err := s.expedienteRepo.GetByID(id, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	desde := expediente.Estado
+	if err := s.AutorizarTransicion(desde, req.Estado, scope); err != nil {
+		return nil, err
+	}
+
+	if err := s.expedienteRepo.UpdateEstado(id, desde, req.Estado, scope.UserID); err != nil {
+		return nil, err
+	}
+
+	cambio := &models.CambioEstado{
+		ExpedienteID:  expediente.ID,
+		Desde:         desde,
+		Hasta:         req.Estado,
+		Justificacion: justificacion,
+		UsuarioID:     scope.UserID,
+		Usuario:       scope.Email,
+	}
+	s.RegistrarCambio(cambio)
+
+	return cambio, nil
+}
+
+// AutorizarTransicion checks that a transition between two states is configured and that
+// the caller holds the permission guarding it
+func (s *EstadoService) AutorizarTransicion(desde, hasta models.EstadoExpediente, scope models.AccessScope) error {
+	transicion, err := s.estadoRepo.FindTransicion(desde, hasta)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransicionNotFound) {
+			return fmt.Errorf("%w: %s -> %s", ErrTransicionNoPermitida, desde, hasta)
+		}
+		return err
+	}
+	if !scope.HasPermission(transicion.Permiso) {
+		return fmt.Errorf("%w: requiere %s", ErrPermisoTransicion, transicion.Permiso)
+	}
+
+	return nil
+}
+
+// RegistrarCambio stores an applied state change in the history. The change already took
+// effect, so a failure is only logged.
+func (s *EstadoService) RegistrarCambio(cambio *models.CambioEstado) {
+	if err := s.estadoRepo.AddHistorial(cambio); err != nil {
+		log.Printf("⚠️ Error registrando el historial de estado del expediente %s: %v", cambio.ExpedienteID.Hex(), err)
+	}
+}
+
+// GetHistorial returns the state history of an expediente visible to the caller
+func (s *EstadoService) GetHistorial(id string, scope models.AccessScope) ([]models.CambioEstado, error) {
+	expediente, err := s.expedienteRepo.GetByID(id, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.estadoRepo.GetHistorial(expediente.ID)
+}
+
+// GetTransiciones returns the configured state machine
+func (s *EstadoService) GetTransiciones() ([]*models.TransicionEstado, error) {
+	return s.estadoRepo.GetTransiciones()
+}
+
+// CreateTransicion allows a new transition between two states
+func (s *EstadoService) CreateTransicion(req *models.CreateTransicionRequest) (*models.TransicionEstado, error) {
+	if !req.Desde.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrEstadoInvalido, req.Desde)
+	}
+	if !req.Hasta.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrEstadoInvalido, req.Hasta)
+	}
+	if req.Desde == req.Hasta {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrTransicionNoPermitida, req.Desde, req.Hasta)
+	}
+	if !models.IsValidPermission(req.Permiso) {
+		return nil, fmt.Errorf("%w: %s", ErrPermisoInvalido, req.Permiso)
+	}
+
+	transicion := &models.TransicionEstado{
+		Desde:   req.Desde,
+		Hasta:   req.Hasta,
+		Permiso: req.Permiso,
+	}
+	if err := s.estadoRepo.CreateTransicion(transicion); err != nil {
+		return nil, err
+	}
+
+	return transicion, nil
+}
+
+// UpdateTransicion changes the permission guarding a transition
+func (s *EstadoService) UpdateTransicion(id string, req *models.UpdateTransicionRequest) (*models.TransicionEstado, error) {
+	if !models.IsValidPermission(req.Permiso) {
+		return nil, fmt.Errorf("%w: %s", ErrPermisoInvalido, req.Permiso)
+	}
+
+	if err := s.estadoRepo.UpdateTransicionPermiso(id, req.Permiso); err != nil {
+		return nil, err
+	}
+
+	return s.estadoRepo.GetTransicion(id)
+}
+
+// DeleteTransicion removes a transition from the state machine
+func (s *EstadoService) DeleteTransicion(id string) error {
+	return s.estadoRepo.DeleteTransicion(id)
+}
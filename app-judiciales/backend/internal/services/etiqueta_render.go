@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"compress/zlib"
+	"expedientes-backend/internal/models"
+	"fmt"
+	"strings"
+)
+
+// etiqueta is the content of one printed label
+type etiqueta struct {
+	codigo string   // Stable identifier encoded in the barcode
+	titulo string   // Large first line, read from a distance
+	lineas []string // Smaller lines below the title
+}
+
+// hojaEtiquetas describes the size of a label and how labels are laid out on an A4 sheet.
+// Lengths are in millimetres and font sizes in points.
+type hojaEtiquetas struct {
+	ancho, alto                     float64
+	columnas, filas                 int
+	margenIzquierdo, margenSuperior float64
+	separacionH, separacionV        float64
+	tamTitulo, tamLinea             float64
+}
+
+var (
+	// hojaCarpetas fits 14 folder labels of 99.1 × 38.1 mm per sheet (the common 2 × 7 layout)
+	hojaCarpetas = hojaEtiquetas{
+		ancho: 99.1, alto: 38.1, columnas: 2, filas: 7,
+		margenIzquierdo: 4.65, margenSuperior: 15.15, separacionH: 2.5,
+		tamTitulo: 18, tamLinea: 9,
+	}
+	// hojaEstantes fits three shelf-end labels of 190 × 90 mm per sheet
+	hojaEstantes = hojaEtiquetas{
+		ancho: 190, alto: 90, columnas: 1, filas: 3,
+		margenIzquierdo: 10, margenSuperior: 9, separacionV: 3,
+		tamTitulo: 48, tamLinea: 16,
+	}
+)
+
+const (
+	puntosPorMM     = 72 / 25.4
+	puntosZPLPorMM  = 8 // Thermal printers at 203 dpi
+	anchoA4, altoA4 = 210.0, 297.0
+	margenEtiqueta  = 3.0  // mm
+	altoBarrasMax   = 12.0 // mm
+	zonaCode128     = 10   // Quiet zone on each side of a Code 128 symbol, in modules
+	zonaQR          = 4    // Quiet zone around a QR symbol, in modules
+)
+
+// lineaEtiqueta is a line of text placed on a label
+type lineaEtiqueta struct {
+	texto   string
+	tam     float64
+	base    float64 // Baseline, measured from the top of the text area
+	negrita bool
+}
+
+// componerTexto fits the title and lines of a label in a text area, truncating long lines and
+// dropping the ones that do not fit. Sizes and positions share the unit of the area.
+func componerTexto(et etiqueta, ancho, alto, tamTitulo, tamLinea float64) []lineaEtiqueta {
+	lineas := []lineaEtiqueta{{texto: recortarTexto(et.titulo, ancho, tamTitulo), tam: tamTitulo, base: tamTitulo * 0.8, negrita: true}}
+	base := tamTitulo*0.8 + tamLinea*0.4
+	for _, texto := range et.lineas {
+		base += tamLinea * 1.2
+		if base > alto {
+			break
+		}
+		lineas = append(lineas, lineaEtiqueta{texto: recortarTexto(texto, ancho, tamLinea), tam: tamLinea, base: base})
+	}
+	return lineas
+}
+
+// recortarTexto shortens a text to the characters that fit in a width, estimating the average
+// width of a Helvetica character at 0.6 of the font size
+func recortarTexto(texto string, ancho, tam float64) string {
+	maximo := int(ancho / (tam * 0.6))
+	runas := []rune(texto)
+	if len(runas) <= maximo {
+		return texto
+	}
+	if maximo <= 3 {
+		return string(runas[:max(maximo, 0)])
+	}
+	return strings.TrimSpace(string(runas[:maximo-3])) + "..."
+}
+
+// renderEtiquetasPDF lays the labels out on A4 pages
+func renderEtiquetasPDF(etiquetas []etiqueta, hoja hojaEtiquetas, simbologia models.SimbologiaEtiqueta) ([]byte, error) {
+	porHoja := hoja.columnas * hoja.filas
+	var paginas [][]byte
+	var pagina bytes.Buffer
+	for i, et := range etiquetas {
+		if i > 0 && i%porHoja == 0 {
+			paginas = append(paginas, bytes.Clone(pagina.Bytes()))
+			pagina.Reset()
+		}
+		posicion := i % porHoja
+		x := hoja.margenIzquierdo + float64(posicion%hoja.columnas)*(hoja.ancho+hoja.separacionH)
+		y := hoja.margenSuperior + float64(posicion/hoja.columnas)*(hoja.alto+hoja.separacionV)
+		if err := dibujarEtiquetaPDF(&pagina, et, hoja, x*puntosPorMM, y*puntosPorMM, simbologia); err != nil {
+			return nil, err
+		}
+	}
+	paginas = append(paginas, pagina.Bytes())
+
+	return escribirPDF(paginas)
+}
+
+// dibujarEtiquetaPDF draws a label whose top left corner is at (x, y) points from the top left of the page
+func dibujarEtiquetaPDF(w *bytes.Buffer, et etiqueta, hoja hojaEtiquetas, x, y float64, simbologia models.SimbologiaEtiqueta) error {
+	ancho, alto := hoja.ancho*puntosPorMM, hoja.alto*puntosPorMM
+	margen := margenEtiqueta * puntosPorMM
+	anchoTexto, altoTexto := ancho-2*margen, alto-2*margen
+
+	switch simbologia {
+	case models.SimbologiaQR:
+		modulos, err := codificarQR([]byte(et.codigo))
+		if err != nil {
+			return err
+		}
+		lado := alto - 2*margen
+		modulo := lado / float64(len(modulos)+2*zonaQR)
+		origenX, origenY := x+ancho-margen-lado+zonaQR*modulo, y+margen+zonaQR*modulo
+		for fila, columnas := range modulos {
+			for col, oscuro := range columnas {
+				if oscuro {
+					rectanguloPDF(w, origenX+float64(col)*modulo, origenY+float64(fila)*modulo, modulo, modulo)
+				}
+			}
+		}
+		anchoTexto -= lado
+	default:
+		anchos, err := codificarCode128(et.codigo)
+		if err != nil {
+			return err
+		}
+		total := 0
+		for _, a := range anchos {
+			total += a
+		}
+		modulo := anchoTexto / float64(total+2*zonaCode128)
+		altoBarras := min(altoBarrasMax*puntosPorMM, alto*0.35)
+		barraX, barraY := x+margen+zonaCode128*modulo, y+alto-margen-altoBarras
+		for i, a := range anchos {
+			if i%2 == 0 {
+				rectanguloPDF(w, barraX, barraY, float64(a)*modulo, altoBarras)
+			}
+			barraX += float64(a) * modulo
+		}
+		altoTexto -= altoBarras + margen/2
+	}
+
+	for _, linea := range componerTexto(et, anchoTexto, altoTexto, hoja.tamTitulo, hoja.tamLinea) {
+		fuente := "F1"
+		if linea.negrita {
+			fuente = "F2"
+		}
+		fmt.Fprintf(w, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n",
+			fuente, linea.tam, x+margen, altoA4*puntosPorMM-(y+margen+linea.base), textoPDF(linea.texto))
+	}
+	return nil
+}
+
+// rectanguloPDF fills a rectangle given from the top left of the page
+func rectanguloPDF(w *bytes.Buffer, x, y, ancho, alto float64) {
+	fmt.Fprintf(w, "%.3f %.3f %.3f %.3f re f\n", x, altoA4*puntosPorMM-y-alto, ancho, alto)
+}
+
+// winAnsiExtra maps the punctuation WinAnsi places outside Latin-1, like the dash of division ranges
+var winAnsiExtra = map[rune]byte{
+	'–': 0x96, '—': 0x97, '…': 0x85, '•': 0x95,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+}
+
+// textoPDF encodes a string for the WinAnsi encoding of the standard fonts, escaping the
+// delimiters of PDF strings. Other characters outside Latin-1 are replaced by "?".
+func textoPDF(texto string) string {
+	var b strings.Builder
+	for _, r := range texto {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case winAnsiExtra[r] != 0:
+			b.WriteByte(winAnsiExtra[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// escribirPDF assembles a PDF document with one A4 page per content stream, using the
+// built-in Helvetica fonts so nothing needs to be embedded
+func escribirPDF(paginas [][]byte) ([]byte, error) {
+	var b bytes.Buffer
+	var offsets []int
+	objeto := func(contenido string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), contenido)
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, len(paginas))
+	for i := range paginas {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objeto("<< /Type /Catalog /Pages 2 0 R >>")
+	objeto(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(paginas)))
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objeto("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, contenido := range paginas {
+		var comprimido bytes.Buffer
+		zw := zlib.NewWriter(&comprimido)
+		if _, err := zw.Write(contenido); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		objeto(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			anchoA4*puntosPorMM, altoA4*puntosPorMM, 6+2*i))
+		objeto(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", comprimido.Len(), comprimido.Bytes()))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return b.Bytes(), nil
+}
+
+// renderEtiquetasZPL writes one ZPL label per etiqueta, sized like the sheet labels
+func renderEtiquetasZPL(etiquetas []etiqueta, hoja hojaEtiquetas, simbologia models.SimbologiaEtiqueta) ([]byte, error) {
+	ancho, alto := int(hoja.ancho*puntosZPLPorMM), int(hoja.alto*puntosZPLPorMM)
+	margen := int(margenEtiqueta * puntosZPLPorMM)
+	// Las fuentes se convierten de puntos a puntos de impresora
+	tamTitulo, tamLinea := hoja.tamTitulo*puntosZPLPorMM/puntosPorMM, hoja.tamLinea*puntosZPLPorMM/puntosPorMM
+
+	var b bytes.Buffer
+	for _, et := range etiquetas {
+		fmt.Fprintf(&b, "^XA\n^CI28\n^PW%d\n^LL%d\n", ancho, alto)
+		anchoTexto, altoTexto := ancho-2*margen, alto-2*margen
+
+		switch simbologia {
+		case models.SimbologiaQR:
+			modulos, err := codificarQR([]byte(et.codigo))
+			if err != nil {
+				return nil, err
+			}
+			aumento := max(1, min(10, (alto-2*margen)/(len(modulos)+2*zonaQR)))
+			lado := (len(modulos) + 2*zonaQR) * aumento
+			fmt.Fprintf(&b, "^FO%d,%d^BQN,2,%d^FDMA,%s^FS\n", ancho-margen-lado+zonaQR*aumento, margen, aumento, et.codigo)
+			anchoTexto -= lado
+		default:
+			anchos, err := codificarCode128(et.codigo)
+			if err != nil {
+				return nil, err
+			}
+			total := 0
+			for _, a := range anchos {
+				total += a
+			}
+			modulo := max(1, min(10, anchoTexto/(total+2*zonaCode128)))
+			altoBarras := min(int(altoBarrasMax*puntosZPLPorMM), alto*35/100)
+			fmt.Fprintf(&b, "^BY%d^FO%d,%d^BCN,%d,N,N,N^FD%s^FS\n", modulo, margen+zonaCode128*modulo, alto-margen-altoBarras, altoBarras, et.codigo)
+			altoTexto -= altoBarras + margen/2
+		}
+
+		for _, linea := range componerTexto(et, float64(anchoTexto), float64(altoTexto), tamTitulo, tamLinea) {
+			tam := int(linea.tam)
+			fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", margen, margen+int(linea.base)-tam*8/10, tam, tam, textoZPL(linea.texto))
+		}
+		b.WriteString("^XZ\n")
+	}
+	return b.Bytes(), nil
+}
+
+// textoZPL removes the characters ZPL reserves for commands from field data
+func textoZPL(texto string) string {
+	return strings.NewReplacer("^", " ", "~", " ").Replace(texto)
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"bytes"
+	"expedientes-backend/internal/extract"
+	"expedientes-backend/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTextoPDF(t *testing.T) {
+	tests := []struct{ texto, want string }{
+		{"GARCÍA PÉREZ, Juan", "GARC\xcdA P\xc9REZ, Juan"},
+		{"Tomo (1) \\ 2", `Tomo \(1\) \\ 2`},
+		{"A–B — “C”…", "A\x96B \x97 \x93C\x94\x85"},
+		{"Ωmega €", "?mega ?"},
+	}
+	for _, tt := range tests {
+		if got := textoPDF(tt.texto); got != tt.want {
+			t.Errorf("textoPDF(%q) = %q, want %q", tt.texto, got, tt.want)
+		}
+	}
+}
+
+func TestRecortarTexto(t *testing.T) {
+	tests := []struct {
+		texto      string
+		ancho, tam float64
+		want       string
+	}{
+		{"corto", 60, 10, "corto"},
+		{"ABCDEFGHIJ", 60, 10, "ABCDEFGHIJ"},
+		{"ABCDEFGHIJK", 60, 10, "ABCDEFG..."},
+		{"ABCDEF GHIJK", 60, 10, "ABCDEF..."},
+		{"ÁÉÍÓÚÑÁÉÍÓÚ", 60, 10, "ÁÉÍÓÚÑÁ..."},
+		{"ABCDEF", 18, 10, "ABC"},
+		{"ABCDEF", 5, 10, ""},
+	}
+	for _, tt := range tests {
+		if got := recortarTexto(tt.texto, tt.ancho, tt.tam); got != tt.want {
+			t.Errorf("recortarTexto(%q, %v, %v) = %q, want %q", tt.texto, tt.ancho, tt.tam, got, tt.want)
+		}
+	}
+}
+
+func TestRenderEtiquetasPDF(t *testing.T) {
+	// 15 folder labels take two sheets of 14
+	var etiquetas []etiqueta
+	for i := 0; i < 15; i++ {
+		etiquetas = append(etiquetas, etiqueta{
+			codigo: fmt.Sprintf("%08d", 12345+i),
+			titulo: fmt.Sprintf("%08d", 12345+i),
+			lineas: []string{fmt.Sprintf("APELLIDO %d, Nombre", i), "Tomo 1 – División A-3"},
+		})
+	}
+
+	for _, simbologia := range []models.SimbologiaEtiqueta{models.SimbologiaCode128, models.SimbologiaQR} {
+		pdf, err := renderEtiquetasPDF(etiquetas, hojaCarpetas, simbologia)
+		if err != nil {
+			t.Fatalf("%s: %v", simbologia, err)
+		}
+		comprobarXrefPDF(t, pdf)
+
+		if n, err := extract.PaginasPDF(pdf); err != nil || n != 2 {
+			t.Errorf("%s: PaginasPDF = %d, %v, want 2 páginas", simbologia, n, err)
+		}
+		paginas, err := extract.PDF(pdf)
+		if err != nil {
+			t.Fatalf("%s: extract.PDF: %v", simbologia, err)
+		}
+		if len(paginas) != 2 {
+			t.Fatalf("%s: %d páginas de texto, want 2", simbologia, len(paginas))
+		}
+		for _, want := range []string{"00012345", "APELLIDO 0, Nombre", "00012358", "Tomo 1 – División A-3"} {
+			if !strings.Contains(paginas[0], want) {
+				t.Errorf("%s: la página 1 no contiene %q:\n%s", simbologia, want, paginas[0])
+			}
+		}
+		if !strings.Contains(paginas[1], "00012359") || strings.Contains(paginas[1], "00012358") {
+			t.Errorf("%s: la página 2 debería tener solo la etiqueta 15:\n%s", simbologia, paginas[1])
+		}
+	}
+}
+
+func TestRenderEtiquetasCodigoInvalido(t *testing.T) {
+	etiquetas := []etiqueta{{codigo: "Nº 1", titulo: "Nº 1"}}
+	if _, err := renderEtiquetasPDF(etiquetas, hojaCarpetas, models.SimbologiaCode128); err == nil {
+		t.Error("renderEtiquetasPDF: expected an error for a code Code 128 cannot encode")
+	}
+	if _, err := renderEtiquetasZPL(etiquetas, hojaCarpetas, models.SimbologiaCode128); err == nil {
+		t.Error("renderEtiquetasZPL: expected an error for a code Code 128 cannot encode")
+	}
+}
+
+func TestRenderEtiquetasZPL(t *testing.T) {
+	etiquetas := []etiqueta{
+		{codigo: "00012345", titulo: "00012345", lineas: []string{"GARCÍA ^PÉREZ~, Juan"}},
+		{codigo: "00012346", titulo: "00012346"},
+	}
+	zpl, err := renderEtiquetasZPL(etiquetas, hojaCarpetas, models.SimbologiaQR)
+	if err != nil {
+		t.Fatal(err)
+	}
+	texto := string(zpl)
+	if n := strings.Count(texto, "^XA"); n != 2 || strings.Count(texto, "^XZ") != 2 {
+		t.Errorf("%d etiquetas ZPL, want 2:\n%s", n, texto)
+	}
+	for _, want := range []string{"^PW792\n", "^LL304\n", "^FDMA,00012345^FS", "^FDGARCÍA  PÉREZ , Juan^FS"} {
+		if !strings.Contains(texto, want) {
+			t.Errorf("el ZPL no contiene %q:\n%s", want, texto)
+		}
+	}
+}
+
+// comprobarXrefPDF checks that startxref points at the cross-reference table and that every
+// entry of the table points at the object it numbers
+func comprobarXrefPDF(t *testing.T, pdf []byte) {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatalf("cabecera o final de PDF inválidos")
+	}
+
+	i := bytes.LastIndex(pdf, []byte("startxref\n"))
+	campos := strings.Fields(string(pdf[i+len("startxref\n"):]))
+	xref, err := strconv.Atoi(campos[0])
+	if err != nil || !bytes.HasPrefix(pdf[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref %q no apunta a la tabla xref", campos[0])
+	}
+
+	lineas := strings.Split(string(pdf[xref:]), "\n")
+	total, err := strconv.Atoi(strings.Fields(lineas[1])[1])
+	if err != nil {
+		t.Fatalf("subsección xref inválida: %q", lineas[1])
+	}
+	for n := 1; n < total; n++ {
+		entrada := lineas[2+n]
+		if len(entrada) != 19 || !strings.HasSuffix(entrada, " 00000 n ") {
+			t.Errorf("entrada xref %d inválida: %q", n, entrada)
+			continue
+		}
+		offset, _ := strconv.Atoi(entrada[:10])
+		if !bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", n))) {
+			t.Errorf("la entrada xref %d apunta a %q", n, pdf[offset:min(offset+10, len(pdf))])
+		}
+	}
+	if !bytes.Contains(pdf, []byte(fmt.Sprintf("/Size %d /Root 1 0 R", total))) {
+		t.Errorf("el trailer no declara /Size %d", total)
+	}
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Label errors
+var (
+	ErrSinEtiquetas       = errors.New("no hay expedientes ni divisiones que etiquetar")
+	ErrFormatoEtiqueta    = errors.New("formato de etiquetas inválido: use pdf o zpl")
+	ErrSimbologiaEtiqueta = errors.New("código de barras inválido: use code128 o qr")
+)
+
+// EtiquetaService generates printable labels for expediente folders and shelf ends
+type EtiquetaService struct {
+	expedienteService *ExpedienteService
+	tomoRepo          *repository.TomoRepository
+	archivoRepo       *repository.ArchivoRepository
+}
+
+// NewEtiquetaService creates a new etiqueta service
+func NewEtiquetaService(expedienteService *ExpedienteService, tomoRepo *repository.TomoRepository, archivoRepo *repository.ArchivoRepository) *EtiquetaService {
+	return &EtiquetaService{
+		expedienteService: expedienteService,
+		tomoRepo:          tomoRepo,
+		archivoRepo:       archivoRepo,
+	}
+}
+
+// EtiquetasExpedientes returns the folder labels of the selected expedientes readable within the scope
+func (s *EtiquetaService) EtiquetasExpedientes(req *models.EtiquetasRequest, scope models.AccessScope) (*models.DocumentoEtiquetas, error) {
+	if err := validarEtiquetas(req.Formato, req.Simbologia); err != nil {
+		return nil, err
+	}
+
+	expedientes, err := s.expedienteService.GetExpedientesByIDs(req.ExpedienteIDs, "etiquetas", scope)
+	if err != nil {
+		return nil, err
+	}
+
+	etiquetas, err := s.etiquetasCarpetas(expedientes)
+	if err != nil {
+		return nil, err
+	}
+	return generarDocumento("etiquetas_expedientes", etiquetas, hojaCarpetas, req.Formato, req.Simbologia)
+}
+
+// EtiquetasDivision returns the folder labels of every expediente stored in a division, so a
+// whole division can be relabelled at once
+func (s *EtiquetaService) EtiquetasDivision(divisionID string, formato models.FormatoEtiqueta, simbologia models.SimbologiaEtiqueta, scope models.AccessScope) (*models.DocumentoEtiquetas, error) {
+	if err := validarEtiquetas(formato, simbologia); err != nil {
+		return nil, err
+	}
+
+	division, err := s.archivoRepo.GetDivision(divisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	expedientes, err := s.expedienteService.GetExpedientesInDivision(division, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	etiquetas, err := s.etiquetasCarpetas(expedientes)
+	if err != nil {
+		return nil, err
+	}
+	return generarDocumento("etiquetas_division_"+division.ID.Hex(), etiquetas, hojaCarpetas, formato, simbologia)
+}
+
+// EtiquetasEstante returns one shelf-end label per division of a shelf
+func (s *EtiquetaService) EtiquetasEstante(estanteID string, formato models.FormatoEtiqueta, simbologia models.SimbologiaEtiqueta) (*models.DocumentoEtiquetas, error) {
+	if err := validarEtiquetas(formato, simbologia); err != nil {
+		return nil, err
+	}
+
+	estante, err := s.archivoRepo.GetEstante(estanteID)
+	if err != nil {
+		return nil, err
+	}
+
+	divisiones, err := s.archivoRepo.GetDivisiones(&estante.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	etiquetas := make([]etiqueta, 0, len(divisiones))
+	for _, division := range divisiones {
+		encabezado := fmt.Sprintf("Estante %d", estante.Numero)
+		if estante.Nombre != "" {
+			encabezado += " · " + estante.Nombre
+		}
+
+		grados := "Todos los grados"
+		if len(division.Grados) > 0 {
+			nombres := make([]string, len(division.Grados))
+			for i, grado := range division.Grados {
+				nombres[i] = string(grado)
+			}
+			grados = strings.Join(nombres, ", ")
+		}
+
+		situacion := "Todas las situaciones"
+		if division.Situacion != "" {
+			situacion = string(division.Situacion)
+		}
+
+		etiquetas = append(etiquetas, etiqueta{
+			codigo: models.CodigoEtiqueta(models.PrefijoEtiquetaDivision, division.ID),
+			titulo: division.Rango(),
+			lineas: []string{encabezado, fmt.Sprintf("División %d", division.Numero), grados, situacion},
+		})
+	}
+
+	return generarDocumento(fmt.Sprintf("etiquetas_estante_%d", estante.Numero), etiquetas, hojaEstantes, formato, simbologia)
+}
+
+// etiquetasCarpetas builds the folder labels of expedientes in the given order. An expediente
+// split into tomos gets one label per tomo, each with the tomo's own location and identifier.
+func (s *EtiquetaService) etiquetasCarpetas(expedientes []*models.Expediente) ([]etiqueta, error) {
+	var conTomos []primitive.ObjectID
+	for _, expediente := range expedientes {
+		if expediente.Tomos > 0 {
+			conTomos = append(conTomos, expediente.ID)
+		}
+	}
+
+	tomos := map[primitive.ObjectID][]models.Tomo{}
+	if len(conTomos) > 0 {
+		var err error
+		if tomos, err = s.tomoRepo.GetByExpedientes(conTomos); err != nil {
+			return nil, err
+		}
+	}
+
+	var etiquetas []etiqueta
+	for _, expediente := range expedientes {
+		identificacion := fmt.Sprintf("CIP %s · %s", expediente.CIP, expediente.Grado)
+
+		tomosExpediente := tomos[expediente.ID]
+		if len(tomosExpediente) == 0 {
+			etiquetas = append(etiquetas, etiqueta{
+				codigo: models.CodigoEtiqueta(models.PrefijoEtiquetaExpediente, expediente.ID),
+				titulo: expediente.Ubicacion,
+				lineas: []string{expediente.ApellidosNombres, identificacion},
+			})
+			continue
+		}
+
+		for _, tomo := range tomosExpediente {
+			etiquetas = append(etiquetas, etiqueta{
+				codigo: models.CodigoEtiqueta(models.PrefijoEtiquetaTomo, tomo.ID),
+				titulo: tomo.Ubicacion,
+				lineas: []string{
+					expediente.ApellidosNombres,
+					identificacion,
+					fmt.Sprintf("Tomo %d de %d · págs. %d-%d", tomo.Numero, len(tomosExpediente), tomo.PaginaDesde, tomo.PaginaHasta),
+				},
+			})
+		}
+	}
+	return etiquetas, nil
+}
+
+// validarEtiquetas checks the requested output format and barcode; empty values take the defaults
+func validarEtiquetas(formato models.FormatoEtiqueta, simbologia models.SimbologiaEtiqueta) error {
+	switch formato {
+	case "", models.FormatoEtiquetaPDF, models.FormatoEtiquetaZPL:
+	default:
+		return ErrFormatoEtiqueta
+	}
+	switch simbologia {
+	case "", models.SimbologiaCode128, models.SimbologiaQR:
+	default:
+		return ErrSimbologiaEtiqueta
+	}
+	return nil
+}
+
+// generarDocumento renders the labels in the requested format, PDF and Code 128 by default
+func generarDocumento(nombre string, etiquetas []etiqueta, hoja hojaEtiquetas, formato models.FormatoEtiqueta, simbologia models.SimbologiaEtiqueta) (*models.DocumentoEtiquetas, error) {
+	if len(etiquetas) == 0 {
+		return nil, ErrSinEtiquetas
+	}
+	if formato == "" {
+		formato = models.FormatoEtiquetaPDF
+	}
+	if simbologia == "" {
+		simbologia = models.SimbologiaCode128
+	}
+
+	documento := &models.DocumentoEtiquetas{
+		Nombre:    fmt.Sprintf("%s_%s.%s", nombre, time.Now().Format("20060102_150405"), formato),
+		Etiquetas: len(etiquetas),
+	}
+
+	var err error
+	if formato == models.FormatoEtiquetaZPL {
+		documento.ContentType = "text/plain; charset=utf-8"
+		documento.Contenido, err = renderEtiquetasZPL(etiquetas, hoja, simbologia)
+	} else {
+		documento.ContentType = "application/pdf"
+		documento.Contenido, err = renderEtiquetasPDF(etiquetas, hoja, simbologia)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🏷️ Etiquetas generadas: %d (%s, %s)", len(etiquetas), formato, simbologia)
+	return documento, nil
+}
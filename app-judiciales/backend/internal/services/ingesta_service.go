@@ -0,0 +1,496 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"expedientes-backend/internal/extract"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrIngestaPatron is returned when the filename pattern of the ingest has no cip group
+var ErrIngestaPatron = errors.New("el patrón de nombres de la ingesta debe tener el grupo (?P<cip>...)")
+
+// usuarioIngesta identifies the hot-folder ingest in the audit log and as uploader of its scans
+const usuarioIngesta = "ingesta-digitalizacion"
+
+// carpetaProceso holds the files being ingested, inside the hot folder so the move is atomic
+const carpetaProceso = ".procesando"
+
+// IngestaConfig configures the hot-folder ingest
+type IngestaConfig struct {
+	Directorio string        // Folder the scanning stations save to
+	Cuarentena string        // Folder for the files that cannot be ingested
+	Patron     string        // Regexp of the filenames, with a cip group and an optional tomo group
+	Intervalo  time.Duration // Time between scans of the folder
+}
+
+// archivoVisto is the size and modification time of a file on the previous scan of the folder
+type archivoVisto struct {
+	tamano     int64
+	modificado time.Time
+}
+
+// IngestaService watches the folder the scanning stations save to and attaches every file to
+// the expediente and tomo its name identifies, updating their digitised page counts. Files
+// that cannot be matched are moved to a quarantine folder; every file is logged.
+type IngestaService struct {
+	documentoService *DocumentoService
+	documentoRepo    *repository.DocumentoRepository
+	expedienteRepo   *repository.ExpedienteRepository
+	tomoRepo         *repository.TomoRepository
+	tomoService      *TomoService
+	digitalizacion   *DigitalizacionService
+	cipValidator     *CIPValidator
+	ingestaRepo      *repository.IngestaRepository
+	auditRepo        *repository.AuditRepository
+
+	config IngestaConfig
+	patron *regexp.Regexp
+	vistos map[string]archivoVisto // Only used by the worker goroutine
+}
+
+// NewIngestaService creates a new ingesta service, creating its folders when missing. Without
+// a folder the ingest is disabled and only its log is available.
+func NewIngestaService(documentoService *DocumentoService, documentoRepo *repository.DocumentoRepository, expedienteRepo *repository.ExpedienteRepository, tomoRepo *repository.TomoRepository, tomoService *TomoService, digitalizacionService *DigitalizacionService, cipValidator *CIPValidator, ingestaRepo *repository.IngestaRepository, auditRepo *repository.AuditRepository, config IngestaConfig) (*IngestaService, error) {
+	patron, err := regexp.Compile(config.Patron)
+	if err != nil {
+		return nil, fmt.Errorf("patrón de nombres de la ingesta inválido: %w", err)
+	}
+	if patron.SubexpIndex("cip") < 0 {
+		return nil, ErrIngestaPatron
+	}
+
+	if config.Directorio != "" {
+		if config.Cuarentena == "" {
+			config.Cuarentena = filepath.Join(config.Directorio, "cuarentena")
+		}
+		for _, dir := range []string{filepath.Join(config.Directorio, carpetaProceso), config.Cuarentena} {
+			if err := os.MkdirAll(dir, 0o750); err != nil {
+				return nil, fmt.Errorf("error creando %s: %w", dir, err)
+			}
+		}
+	}
+
+	return &IngestaService{
+		documentoService: documentoService,
+		documentoRepo:    documentoRepo,
+		expedienteRepo:   expedienteRepo,
+		tomoRepo:         tomoRepo,
+		tomoService:      tomoService,
+		digitalizacion:   digitalizacionService,
+		cipValidator:     cipValidator,
+		ingestaRepo:      ingestaRepo,
+		auditRepo:        auditRepo,
+		config:           config,
+		patron:           patron,
+		vistos:           make(map[string]archivoVisto),
+	}, nil
+}
+
+// Activa reports whether a hot folder and a scan interval are configured
+func (s *IngestaService) Activa() bool {
+	return s.config.Directorio != "" && s.config.Intervalo > 0
+}
+
+// Iniciar scans the folder every interval until the context is cancelled. Files left half
+// processed by a previous run go back to the folder first.
+func (s *IngestaService) Iniciar(ctx context.Context) {
+	s.recuperar()
+
+	ticker := time.NewTicker(s.config.Intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.revisar(ctx)
+		}
+	}
+}
+
+// recuperar moves the files interrupted while being ingested back to the folder
+func (s *IngestaService) recuperar() {
+	proceso := filepath.Join(s.config.Directorio, carpetaProceso)
+	entradas, err := os.ReadDir(proceso)
+	if err != nil {
+		log.Printf("⚠️ Error leyendo %s: %v", proceso, err)
+		return
+	}
+	for _, entrada := range entradas {
+		if err := os.Rename(filepath.Join(proceso, entrada.Name()), filepath.Join(s.config.Directorio, entrada.Name())); err != nil {
+			log.Printf("⚠️ Error recuperando %s: %v", entrada.Name(), err)
+		}
+	}
+}
+
+// revisar processes the files of the folder that have not changed since the previous scan;
+// a file the scanner is still writing grows or is touched in between
+func (s *IngestaService) revisar(ctx context.Context) {
+	entradas, err := os.ReadDir(s.config.Directorio)
+	if err != nil {
+		log.Printf("⚠️ Error leyendo la carpeta de ingesta %s: %v", s.config.Directorio, err)
+		return
+	}
+
+	actuales := make(map[string]archivoVisto)
+	for _, entrada := range entradas {
+		nombre := entrada.Name()
+		// Carpetas, archivos ocultos y temporales de Office no se ingieren
+		if entrada.IsDir() || strings.HasPrefix(nombre, ".") || strings.HasPrefix(nombre, "~$") {
+			continue
+		}
+		info, err := entrada.Info()
+		if err != nil {
+			continue
+		}
+
+		visto := archivoVisto{tamano: info.Size(), modificado: info.ModTime()}
+		if anterior, ok := s.vistos[nombre]; !ok || anterior != visto {
+			actuales[nombre] = visto
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		s.procesar(nombre, visto.tamano)
+	}
+	s.vistos = actuales
+}
+
+// procesar ingests one file of the folder. A file that cannot be matched goes to quarantine;
+// on a database or storage error it goes back to the folder for the next scan.
+func (s *IngestaService) procesar(nombre string, tamano int64) {
+	ruta := filepath.Join(s.config.Directorio, nombre)
+	enProceso := filepath.Join(s.config.Directorio, carpetaProceso, nombre)
+	if err := os.Rename(ruta, enProceso); err != nil {
+		// Otra instancia lo tomó o se borró entre la lectura y el movimiento
+		return
+	}
+
+	ingesta := &models.Ingesta{Archivo: nombre, Tamano: tamano, ProcesadoEn: time.Now()}
+	motivo, err := s.ingresar(enProceso, ingesta)
+	if err != nil {
+		log.Printf("⚠️ Error ingiriendo %s; se reintentará: %v", nombre, err)
+		if err := os.Rename(enProceso, ruta); err != nil {
+			log.Printf("⚠️ Error devolviendo %s a la carpeta de ingesta: %v", nombre, err)
+		}
+		return
+	}
+
+	if motivo != "" {
+		destino := filepath.Join(s.config.Cuarentena, time.Now().Format("20060102-150405")+"_"+nombre)
+		if err := moverArchivo(enProceso, destino); err != nil {
+			log.Printf("⚠️ Error moviendo %s a cuarentena: %v", nombre, err)
+		}
+		ingesta.Estado = models.IngestaCuarentena
+		ingesta.Motivo = motivo
+		ingesta.Cuarentena = filepath.Base(destino)
+		log.Printf("🚫 Ingesta: %s en cuarentena: %s", nombre, motivo)
+	} else if err := os.Remove(enProceso); err != nil {
+		log.Printf("⚠️ Error eliminando %s tras ingerirlo: %v", nombre, err)
+	}
+
+	if err := s.ingestaRepo.Create(ingesta); err != nil {
+		log.Printf("⚠️ Error registrando la ingesta de %s: %v", nombre, err)
+	}
+}
+
+// moverArchivo moves a file, copying it when the destination is on another filesystem
+func moverArchivo(origen, destino string) error {
+	if err := os.Rename(origen, destino); err == nil {
+		return nil
+	}
+
+	src, err := os.Open(origen)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(destino, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(destino)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(destino)
+		return err
+	}
+	return os.Remove(origen)
+}
+
+// ingresar matches a file to its expediente and tomo and attaches it. It returns the reason
+// to quarantine the file, or an error when it should be retried.
+func (s *IngestaService) ingresar(ruta string, ingesta *models.Ingesta) (string, error) {
+	nombre := ingesta.Archivo
+	partes := s.patron.FindStringSubmatch(nombre)
+	if partes == nil {
+		return "el nombre no sigue el patrón configurado", nil
+	}
+	ingesta.CIP = partes[s.patron.SubexpIndex("cip")]
+	if i := s.patron.SubexpIndex("tomo"); i >= 0 && partes[i] != "" {
+		numero, err := strconv.Atoi(partes[i])
+		if err != nil || numero < 1 {
+			return "número de tomo inválido", nil
+		}
+		ingesta.Tomo = numero
+	}
+
+	formato, contenido, motivo, err := s.leerArchivo(ruta, ingesta)
+	if motivo != "" || err != nil {
+		return motivo, err
+	}
+
+	paginas := 0
+	switch formato {
+	case models.FormatoDocumentoPDF:
+		if paginas, err = extract.PaginasPDF(contenido); err != nil {
+			return "el PDF está dañado", nil
+		}
+	case models.FormatoDocumentoJPG, models.FormatoDocumentoPNG:
+		paginas = 1
+	}
+	ingesta.Paginas = paginas
+
+	expediente, err := s.buscarExpediente(ingesta.CIP)
+	if err != nil {
+		return "", err
+	}
+	if expediente == nil {
+		return "no hay expediente con el CIP " + ingesta.CIP, nil
+	}
+	ingesta.CIP = expediente.CIP
+	ingesta.ExpedienteID = &expediente.ID
+
+	var tomo *models.Tomo
+	switch {
+	case expediente.Tomos > 0 && ingesta.Tomo == 0:
+		return fmt.Sprintf("el expediente tiene %d tomos; el nombre debe indicar el tomo", expediente.Tomos), nil
+	case expediente.Tomos > 0:
+		tomo, err = s.tomoRepo.GetByNumero(expediente.ID, ingesta.Tomo)
+		if errors.Is(err, repository.ErrTomoNotFound) {
+			return fmt.Sprintf("el expediente no tiene tomo %d", ingesta.Tomo), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	case ingesta.Tomo > 1:
+		return "el expediente no está dividido en tomos", nil
+	}
+
+	if err := s.adjuntar(expediente, tomo, formato, contenido, ingesta); err != nil {
+		return "", err
+	}
+	if ingesta.Estado != models.IngestaDuplicada && paginas > 0 {
+		s.actualizarDigitalizadas(expediente, tomo, paginas)
+	}
+
+	log.Printf("📥 Ingesta: %s adjuntado al expediente %s (%s, %d páginas)", nombre, expediente.CIP, ingesta.Estado, paginas)
+	return "", nil
+}
+
+// buscarExpediente finds the expediente of the CIP in a filename, which has no grado: it
+// tries the canonical form of every category and keeps the expediente whose own grado
+// gives that form, so `12345_tomo1.pdf` matches a CIP stored as `00012345`
+func (s *IngestaService) buscarExpediente(cip string) (*models.Expediente, error) {
+	for _, candidato := range s.cipValidator.Candidatos(cip) {
+		expediente, err := s.expedienteRepo.GetByCIP(candidato)
+		if err != nil {
+			return nil, err
+		}
+		if expediente != nil && s.cipValidator.Normalizar(cip, expediente.Grado) == expediente.CIP {
+			return expediente, nil
+		}
+	}
+	return nil, nil
+}
+
+// leerArchivo checks the size and format of a file and reads it
+func (s *IngestaService) leerArchivo(ruta string, ingesta *models.Ingesta) (models.FormatoDocumento, []byte, string, error) {
+	esperado, ok := formatosPorExtension[strings.ToLower(filepath.Ext(ruta))]
+	if !ok {
+		return "", nil, "la extensión no es PDF, DOC, DOCX, JPG ni PNG", nil
+	}
+
+	archivo, err := os.Open(ruta)
+	if err != nil {
+		return "", nil, "", err
+	}
+	defer archivo.Close()
+
+	info, err := archivo.Stat()
+	if err != nil {
+		return "", nil, "", err
+	}
+	if info.Size() > s.documentoService.MaxTamano() {
+		return "", nil, fmt.Sprintf("supera el tamaño máximo de %d MB", s.documentoService.MaxTamano()/(1024*1024)), nil
+	}
+
+	formato, ok := detectarFormato(archivo, info.Size())
+	if !ok || formato != esperado {
+		return "", nil, "el contenido no corresponde a la extensión", nil
+	}
+
+	if _, err := archivo.Seek(0, io.SeekStart); err != nil {
+		return "", nil, "", err
+	}
+	contenido, err := io.ReadAll(archivo)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	sum := sha256.Sum256(contenido)
+	ingesta.SHA256 = hex.EncodeToString(sum[:])
+	return formato, contenido, "", nil
+}
+
+// adjuntar stores a scan as a new version of the previous scan of the same tomo, or as a new
+// attachment when there is none. A file identical to the current scan is discarded.
+func (s *IngestaService) adjuntar(expediente *models.Expediente, tomo *models.Tomo, formato models.FormatoDocumento, contenido []byte, ingesta *models.Ingesta) error {
+	scope := models.AccessScope{Email: usuarioIngesta}
+	var tomoID *primitive.ObjectID
+	if tomo != nil {
+		tomoID = &tomo.ID
+	}
+
+	documento, err := s.documentoRepo.FindEscaneo(expediente.ID, tomoID)
+	if err != nil && !errors.Is(err, repository.ErrDocumentoNotFound) {
+		return err
+	}
+
+	if documento != nil && documento.SHA256 == ingesta.SHA256 {
+		ingesta.Estado = models.IngestaDuplicada
+		ingesta.DocumentoID = &documento.ID
+		ingesta.Version = versionActual(documento)
+		return nil
+	}
+
+	if documento != nil {
+		anterior := documento.Version
+		numero := versionActual(documento) + 1
+		clave := fmt.Sprintf("expedientes/%s/%s.v%d.%s", expediente.ID.Hex(), documento.ID.Hex(), numero, formato)
+		if _, err := s.documentoService.almacenarContenido(clave, bytes.NewReader(contenido), int64(len(contenido)), ingesta.Archivo, formato); err != nil {
+			return err
+		}
+
+		version := nuevaVersion(numero, clave, ingesta.Archivo, int64(len(contenido)), formato, ingesta.SHA256, scope)
+		documento.Versiones = append(versionesDocumento(documento), version)
+		aplicarVersion(documento, version)
+		if err := s.documentoRepo.ActualizarVersiones(documento, anterior); err != nil {
+			if delErr := s.documentoService.storage.Delete(clave); delErr != nil {
+				log.Printf("⚠️ Error eliminando el archivo huérfano %s: %v", clave, delErr)
+			}
+			return err
+		}
+		s.documentoService.textoService.Reindexar(documento)
+
+		ingesta.Estado = models.IngestaNuevaVersion
+		s.registrarAuditoria(models.AccionDocumentoVersion, documento, ingesta)
+	} else {
+		id := primitive.NewObjectID()
+		clave := fmt.Sprintf("expedientes/%s/%s.%s", expediente.ID.Hex(), id.Hex(), formato)
+		if _, err := s.documentoService.almacenarContenido(clave, bytes.NewReader(contenido), int64(len(contenido)), ingesta.Archivo, formato); err != nil {
+			return err
+		}
+
+		version := nuevaVersion(1, clave, ingesta.Archivo, int64(len(contenido)), formato, ingesta.SHA256, scope)
+		documento = &models.Documento{
+			ID:           id,
+			ExpedienteID: expediente.ID,
+			Tipo:         models.TipoDocumentoDigitalizacion,
+			Usuario:      usuarioIngesta,
+			SubidoEn:     version.SubidoEn,
+			Versiones:    []models.VersionDocumento{version},
+			Texto:        models.TextoInicial(formato),
+			Origen:       models.OrigenIngesta,
+			TomoID:       tomoID,
+		}
+		if tomo != nil {
+			documento.Descripcion = fmt.Sprintf("Tomo %d", tomo.Numero)
+		}
+		aplicarVersion(documento, version)
+
+		if err := s.documentoRepo.Create(documento); err != nil {
+			if delErr := s.documentoService.storage.Delete(clave); delErr != nil {
+				log.Printf("⚠️ Error eliminando el archivo huérfano %s: %v", clave, delErr)
+			}
+			return err
+		}
+		s.documentoService.textoService.Nuevo(documento)
+
+		ingesta.Estado = models.IngestaImportada
+		s.registrarAuditoria(models.AccionDocumentoSubida, documento, ingesta)
+	}
+
+	ingesta.DocumentoID = &documento.ID
+	ingesta.Version = documento.Version
+	return nil
+}
+
+// registrarAuditoria logs an attachment created or replaced by the ingest
+func (s *IngestaService) registrarAuditoria(accion string, documento *models.Documento, ingesta *models.Ingesta) {
+	if err := s.auditRepo.Log(&models.AuditLog{
+		Usuario:   usuarioIngesta,
+		Accion:    accion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: documento.ExpedienteID.Hex(),
+		Detalles: map[string]interface{}{
+			"documento_id": documento.ID.Hex(),
+			"nombre":       documento.Nombre,
+			"version":      documento.Version,
+			"tamano":       documento.Tamano,
+			"sha256":       documento.SHA256,
+			"tomo":         ingesta.Tomo,
+			"paginas":      ingesta.Paginas,
+			"origen":       models.OrigenIngesta,
+		},
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría del documento %s: %v", documento.ID.Hex(), err)
+	}
+}
+
+// actualizarDigitalizadas records the pages of the latest scan of a tomo, or of an expediente
+// without tomos, and its digitisation state. The file is already attached, so a failure here
+// is only logged.
+func (s *IngestaService) actualizarDigitalizadas(expediente *models.Expediente, tomo *models.Tomo, paginas int) {
+	var err error
+	if tomo != nil {
+		if err = s.tomoRepo.Update(tomo.ID, bson.M{"paginas_digitalizadas": paginas}); err == nil {
+			err = s.tomoService.actualizarResumen(expediente.ID)
+		}
+	} else {
+		err = s.expedienteRepo.SetDigitalizadas(expediente.ID, paginas)
+	}
+	if err != nil {
+		log.Printf("⚠️ Error actualizando las páginas digitalizadas del expediente %s: %v", expediente.CIP, err)
+	}
+
+	s.digitalizacion.registrarEscaneo(expediente, usuarioIngesta)
+}
+
+// GetIngestas returns the ingest log, newest first
+func (s *IngestaService) GetIngestas(estado models.EstadoIngesta, cip string, page, limit int) ([]models.Ingesta, int64, error) {
+	return s.ingestaRepo.List(estado, cip, page, limit)
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/storage"
+	"log"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrVerificacionEnCurso is returned when a fixity run is requested while another is running
+var ErrVerificacionEnCurso = errors.New("ya hay una verificación de integridad en curso")
+
+// usuarioVerificacion identifies the fixity job in the audit log
+const usuarioVerificacion = "verificacion-integridad"
+
+// IntegridadService recomputes the SHA-256 of every stored attachment version and records
+// the files that changed or disappeared
+type IntegridadService struct {
+	documentoRepo     *repository.DocumentoRepository
+	integridadRepo    *repository.IntegridadRepository
+	expedienteService *ExpedienteService
+	auditRepo         *repository.AuditRepository
+	storage           storage.Storage
+
+	enCurso sync.Mutex
+}
+
+// NewIntegridadService creates a new integridad service
+func NewIntegridadService(documentoRepo *repository.DocumentoRepository, integridadRepo *repository.IntegridadRepository, expedienteService *ExpedienteService, auditRepo *repository.AuditRepository, storage storage.Storage) *IntegridadService {
+	return &IntegridadService{
+		documentoRepo:     documentoRepo,
+		integridadRepo:    integridadRepo,
+		expedienteService: expedienteService,
+		auditRepo:         auditRepo,
+		storage:           storage,
+	}
+}
+
+// Iniciar runs the fixity check every intervalo until the context is cancelled
+func (s *IntegridadService) Iniciar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Verificar(); err != nil && !errors.Is(err, ErrVerificacionEnCurso) {
+				log.Printf("⚠️ Error en la verificación de integridad: %v", err)
+			}
+		}
+	}
+}
+
+// VerificarEnSegundoPlano starts a fixity run without waiting for it to finish
+func (s *IntegridadService) VerificarEnSegundoPlano() error {
+	if !s.enCurso.TryLock() {
+		return ErrVerificacionEnCurso
+	}
+
+	go func() {
+		defer s.enCurso.Unlock()
+		if _, err := s.verificar(); err != nil {
+			log.Printf("⚠️ Error en la verificación de integridad: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Verificar recomputes the hash of every version of every attachment. Versions uploaded
+// before versioning have no recorded hash; the first run records it as their baseline.
+func (s *IntegridadService) Verificar() (*models.ResultadoVerificacion, error) {
+	if !s.enCurso.TryLock() {
+		return nil, ErrVerificacionEnCurso
+	}
+	defer s.enCurso.Unlock()
+
+	return s.verificar()
+}
+
+func (s *IntegridadService) verificar() (*models.ResultadoVerificacion, error) {
+	resultado := &models.ResultadoVerificacion{Inicio: time.Now()}
+	log.Printf("🔏 Verificación de integridad iniciada")
+
+	documentos, err := s.documentoRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range documentos {
+		documento := &documentos[i]
+		resultado.Documentos++
+
+		anterior := documento.Version
+		versiones := append([]models.VersionDocumento(nil), versionesDocumento(documento)...)
+		cambio := len(documento.Versiones) == 0
+		for j := range versiones {
+			resultado.Versiones++
+			if s.verificarVersion(documento, &versiones[j], resultado) {
+				cambio = true
+			}
+		}
+
+		if !cambio {
+			continue
+		}
+		actual := versionActual(documento)
+		documento.Versiones = versiones
+		for _, version := range versiones {
+			if version.Version == actual {
+				aplicarVersion(documento, version)
+			}
+		}
+		if err := s.documentoRepo.ActualizarVersiones(documento, anterior); err != nil {
+			// Una versión nueva subida durante la verificación se revisa en la próxima
+			if !errors.Is(err, repository.ErrDocumentoCambiado) {
+				log.Printf("⚠️ Error guardando la integridad del documento %s: %v", documento.ID.Hex(), err)
+			}
+		}
+	}
+
+	resultado.Fin = time.Now()
+	log.Printf("🔏 Verificación de integridad terminada: %d archivos, %d correctos, %d con fallos, %d errores, %d sin hash previo",
+		resultado.Versiones, resultado.Correctas, resultado.Fallidas, resultado.Errores, resultado.LineaBase)
+	return resultado, nil
+}
+
+// verificarVersion checks one stored file and updates its state, recording a failure when it
+// changes into one. It reports whether the version changed.
+func (s *IntegridadService) verificarVersion(documento *models.Documento, version *models.VersionDocumento, resultado *models.ResultadoVerificacion) bool {
+	sha, _, err := storage.Checksum(s.storage, version.Clave)
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		// Un error del almacenamiento no prueba que el archivo cambió
+		resultado.Errores++
+		log.Printf("⚠️ No se pudo verificar %s: %v", version.Clave, err)
+		return false
+	}
+
+	estado := models.IntegridadOK
+	switch {
+	case err != nil:
+		estado = models.IntegridadFaltante
+	case version.SHA256 == "":
+		version.SHA256 = sha
+		resultado.LineaBase++
+	case sha != version.SHA256:
+		estado = models.IntegridadAlterado
+	}
+
+	if estado == models.IntegridadOK {
+		resultado.Correctas++
+	} else {
+		resultado.Fallidas++
+		if version.Integridad != estado {
+			s.registrarFallo(documento, version, estado, sha)
+		}
+	}
+
+	ahora := time.Now()
+	version.Integridad = estado
+	version.VerificadoEn = &ahora
+	return true
+}
+
+// registrarFallo records a file that was found changed or missing
+func (s *IntegridadService) registrarFallo(documento *models.Documento, version *models.VersionDocumento, estado models.EstadoIntegridad, sha string) {
+	fallo := &models.FalloIntegridad{
+		DocumentoID:    documento.ID,
+		ExpedienteID:   documento.ExpedienteID,
+		Version:        version.Version,
+		Nombre:         version.Nombre,
+		Estado:         estado,
+		SHA256Esperado: version.SHA256,
+		SHA256Obtenido: sha,
+		DetectadoEn:    time.Now(),
+	}
+	if err := s.integridadRepo.Create(fallo); err != nil {
+		log.Printf("⚠️ Error registrando el fallo de integridad de %s: %v", version.Clave, err)
+	}
+
+	if err := s.auditRepo.Log(&models.AuditLog{
+		Usuario:   usuarioVerificacion,
+		Accion:    models.AccionDocumentoFalloIntegridad,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: documento.ExpedienteID.Hex(),
+		Detalles: map[string]interface{}{
+			"documento_id":    documento.ID.Hex(),
+			"version":         version.Version,
+			"estado":          estado,
+			"sha256_esperado": version.SHA256,
+			"sha256_obtenido": sha,
+		},
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría del fallo de integridad de %s: %v", version.Clave, err)
+	}
+
+	log.Printf("🚨 Integridad: %s versión %d del documento %s (%s)", estado, version.Version, documento.ID.Hex(), version.Clave)
+}
+
+// GetIntegridadExpediente summarises the fixity of the attachments of an expediente visible
+// to the caller, with their recorded failures
+func (s *IntegridadService) GetIntegridadExpediente(expedienteID string, scope models.AccessScope) (*models.IntegridadExpediente, error) {
+	expediente, err := s.expedienteService.GetExpedienteByID(expedienteID, "integridad_documentos", scope)
+	if err != nil {
+		return nil, err
+	}
+
+	documentos, err := s.documentoRepo.GetByExpediente(expediente.ID, scope.HasPermission(models.PermissionDocumentoConfidential))
+	if err != nil {
+		return nil, err
+	}
+
+	resumen := &models.IntegridadExpediente{
+		ExpedienteID: expediente.ID,
+		Estado:       models.IntegridadOK,
+		Documentos:   len(documentos),
+		Fallos:       []models.FalloIntegridad{},
+	}
+	ids := make([]primitive.ObjectID, 0, len(documentos))
+	for i := range documentos {
+		ids = append(ids, documentos[i].ID)
+		for _, version := range versionesDocumento(&documentos[i]) {
+			resumen.Versiones++
+			switch version.Integridad {
+			case models.IntegridadOK:
+				resumen.Verificadas++
+			case models.IntegridadAlterado, models.IntegridadFaltante:
+				resumen.Fallidas++
+			default:
+				resumen.Pendientes++
+			}
+			if version.Integridad.Gravedad() > resumen.Estado.Gravedad() {
+				resumen.Estado = version.Integridad
+			}
+			if version.VerificadoEn != nil && (resumen.UltimaVerificacion == nil || version.VerificadoEn.After(*resumen.UltimaVerificacion)) {
+				resumen.UltimaVerificacion = version.VerificadoEn
+			}
+		}
+	}
+
+	if len(ids) > 0 {
+		if resumen.Fallos, err = s.integridadRepo.GetByDocumentos(ids, 50); err != nil {
+			return nil, err
+		}
+	}
+
+	return resumen, nil
+}
@@ -0,0 +1,798 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Inventory errors
+var (
+	ErrAlcanceInventario       = errors.New("indique un estante o una división para el inventario, no ambos")
+	ErrInventarioSinDivisiones = errors.New("el estante no tiene divisiones que inventariar")
+	ErrDivisionRequerida       = errors.New("escanee la etiqueta de la división antes de las carpetas")
+	ErrDivisionFueraInventario = errors.New("la división no pertenece a este inventario")
+)
+
+// ordenHallazgos is the order findings are listed in a report
+var ordenHallazgos = map[models.TipoHallazgo]int{
+	models.HallazgoFaltante:      0,
+	models.HallazgoMalUbicado:    1,
+	models.HallazgoFueraPresente: 2,
+	models.HallazgoDesconocido:   3,
+}
+
+// InventarioService runs physical inventory sessions and reconciles the scanned folders with
+// the expedientes and tomos the database places on the inventoried shelves
+type InventarioService struct {
+	inventarioRepo *repository.InventarioRepository
+	archivoRepo    *repository.ArchivoRepository
+	expedienteRepo *repository.ExpedienteRepository
+	tomoRepo       *repository.TomoRepository
+	auditRepo      *repository.AuditRepository
+}
+
+// NewInventarioService creates a new inventario service
+func NewInventarioService(inventarioRepo *repository.InventarioRepository, archivoRepo *repository.ArchivoRepository, expedienteRepo *repository.ExpedienteRepository, tomoRepo *repository.TomoRepository, auditRepo *repository.AuditRepository) *InventarioService {
+	return &InventarioService{
+		inventarioRepo: inventarioRepo,
+		archivoRepo:    archivoRepo,
+		expedienteRepo: expedienteRepo,
+		tomoRepo:       tomoRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// carpetaInventario is a folder known to the database: an expediente without tomos or one of its tomos
+type carpetaInventario struct {
+	expediente models.ExpedienteUbicacion
+	tomo       *models.Tomo
+}
+
+// ubicacion returns where the folder is filed
+func (c *carpetaInventario) ubicacion() string {
+	if c.tomo != nil {
+		return c.tomo.Ubicacion
+	}
+	return c.expediente.Ubicacion
+}
+
+// estado returns the estado of the folder
+func (c *carpetaInventario) estado() models.EstadoExpediente {
+	if c.tomo != nil {
+		return c.tomo.Estado
+	}
+	return c.expediente.Estado
+}
+
+// almacenadaEn reports whether the folder belongs in the division
+func (c *carpetaInventario) almacenadaEn(division *models.Division) bool {
+	return division.Almacena(c.ubicacion(), c.expediente.Grado, c.expediente.SituacionMilitar)
+}
+
+// hallazgo returns a finding of the given kind about the folder
+func (c *carpetaInventario) hallazgo(tipo models.TipoHallazgo) models.Hallazgo {
+	hallazgo := models.Hallazgo{
+		Tipo:             tipo,
+		ExpedienteID:     &c.expediente.ID,
+		CIP:              c.expediente.CIP,
+		ApellidosNombres: c.expediente.ApellidosNombres,
+		Grado:            c.expediente.Grado,
+		Ubicacion:        c.ubicacion(),
+		Estado:           c.estado(),
+	}
+	if c.tomo != nil {
+		hallazgo.TomoID = &c.tomo.ID
+		hallazgo.Tomo = c.tomo.Numero
+	}
+	return hallazgo
+}
+
+// catalogoDivisiones is the current division catalog used to judge where folders belong
+type catalogoDivisiones struct {
+	divisiones []*models.Division
+	porID      map[primitive.ObjectID]*models.Division
+	estantes   map[primitive.ObjectID]int
+}
+
+// describir identifies a division for a report
+func (c *catalogoDivisiones) describir(division *models.Division) *models.DivisionInventario {
+	return &models.DivisionInventario{
+		DivisionID: division.ID,
+		Estante:    c.estantes[division.EstanteID],
+		Numero:     division.Numero,
+		Rango:      division.Rango(),
+	}
+}
+
+// esperada returns the division a folder belongs in, or nil if no division stores it
+func (c *catalogoDivisiones) esperada(carpeta *carpetaInventario) *models.DivisionInventario {
+	for _, division := range c.divisiones {
+		if carpeta.almacenadaEn(division) {
+			return c.describir(division)
+		}
+	}
+	return nil
+}
+
+// evaluar returns the findings of a folder scanned in a division and, when it is misplaced,
+// the division it belongs in
+func (c *catalogoDivisiones) evaluar(carpeta *carpetaInventario, divisionID primitive.ObjectID) ([]models.TipoHallazgo, *models.DivisionInventario) {
+	if carpeta == nil {
+		return []models.TipoHallazgo{models.HallazgoDesconocido}, nil
+	}
+
+	hallazgos := []models.TipoHallazgo{}
+	if carpeta.estado() != models.EstadoDentro {
+		hallazgos = append(hallazgos, models.HallazgoFueraPresente)
+	}
+
+	// Una división eliminada después de la lectura ya no permite juzgar la ubicación
+	var esperada *models.DivisionInventario
+	if division, ok := c.porID[divisionID]; ok && !carpeta.almacenadaEn(division) {
+		hallazgos = append(hallazgos, models.HallazgoMalUbicado)
+		esperada = c.esperada(carpeta)
+	}
+	return hallazgos, esperada
+}
+
+// cargarCatalogo loads every division and the numbers of their shelves
+func (s *InventarioService) cargarCatalogo() (*catalogoDivisiones, error) {
+	divisiones, err := s.archivoRepo.GetDivisiones(nil)
+	if err != nil {
+		return nil, err
+	}
+	estantes, err := s.archivoRepo.GetEstantes()
+	if err != nil {
+		return nil, err
+	}
+
+	catalogo := &catalogoDivisiones{
+		divisiones: divisiones,
+		porID:      make(map[primitive.ObjectID]*models.Division, len(divisiones)),
+		estantes:   make(map[primitive.ObjectID]int, len(estantes)),
+	}
+	for _, division := range divisiones {
+		catalogo.porID[division.ID] = division
+	}
+	for _, estante := range estantes {
+		catalogo.estantes[estante.ID] = estante.Numero
+	}
+	return catalogo, nil
+}
+
+// claveLectura identifies the folder of a scan: its tomo, its expediente or, for unknown
+// codes, the code itself
+func claveLectura(lectura *models.LecturaInventario) string {
+	switch {
+	case lectura.TomoID != nil:
+		return lectura.TomoID.Hex()
+	case lectura.ExpedienteID != nil:
+		return lectura.ExpedienteID.Hex()
+	default:
+		return "?" + strings.ToUpper(lectura.Codigo)
+	}
+}
+
+// cargarCarpetas loads the folders behind scans, by scan key. Scans of expedientes that no
+// longer exist are left out, like unknown codes.
+func (s *InventarioService) cargarCarpetas(lecturas []models.LecturaInventario) (map[string]*carpetaInventario, error) {
+	var ids, conTomo []primitive.ObjectID
+	vistos := make(map[primitive.ObjectID]bool)
+	for _, lectura := range lecturas {
+		if lectura.ExpedienteID == nil {
+			continue
+		}
+		if !vistos[*lectura.ExpedienteID] {
+			vistos[*lectura.ExpedienteID] = true
+			ids = append(ids, *lectura.ExpedienteID)
+		}
+		if lectura.TomoID != nil {
+			conTomo = append(conTomo, *lectura.ExpedienteID)
+		}
+	}
+
+	carpetas := make(map[string]*carpetaInventario)
+	if len(ids) == 0 {
+		return carpetas, nil
+	}
+
+	ubicaciones, err := s.expedienteRepo.GetUbicacionesByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	expedientes := make(map[primitive.ObjectID]models.ExpedienteUbicacion, len(ubicaciones))
+	for _, ubicacion := range ubicaciones {
+		expedientes[ubicacion.ID] = ubicacion
+	}
+
+	tomos := make(map[primitive.ObjectID]*models.Tomo)
+	if len(conTomo) > 0 {
+		porExpediente, err := s.tomoRepo.GetByExpedientes(conTomo)
+		if err != nil {
+			return nil, err
+		}
+		for _, lista := range porExpediente {
+			for i := range lista {
+				tomos[lista[i].ID] = &lista[i]
+			}
+		}
+	}
+
+	for i := range lecturas {
+		lectura := &lecturas[i]
+		if lectura.ExpedienteID == nil {
+			continue
+		}
+		expediente, ok := expedientes[*lectura.ExpedienteID]
+		if !ok {
+			continue
+		}
+		carpeta := &carpetaInventario{expediente: expediente}
+		if lectura.TomoID != nil {
+			if carpeta.tomo, ok = tomos[*lectura.TomoID]; !ok {
+				continue
+			}
+		}
+		carpetas[claveLectura(lectura)] = carpeta
+	}
+	return carpetas, nil
+}
+
+// CrearInventario starts an inventory session over a whole shelf or a single division
+func (s *InventarioService) CrearInventario(req *models.CreateInventarioRequest, scope models.AccessScope) (*models.Inventario, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid createdBy ID")
+	}
+	if (req.EstanteID == "") == (req.DivisionID == "") {
+		return nil, ErrAlcanceInventario
+	}
+
+	inventario := &models.Inventario{
+		Descripcion: strings.TrimSpace(req.Descripcion),
+		Estado:      models.InventarioAbierto,
+		Lecturas:    []models.LecturaInventario{},
+		CreadoPor:   scope.UserID,
+		CreadoEn:    time.Now(),
+	}
+	inventario.ActualizadoEn = inventario.CreadoEn
+
+	var estante *models.Estante
+	var divisiones []*models.Division
+	var err error
+	if req.EstanteID != "" {
+		if estante, err = s.archivoRepo.GetEstante(req.EstanteID); err != nil {
+			return nil, err
+		}
+		if divisiones, err = s.archivoRepo.GetDivisiones(&estante.ID); err != nil {
+			return nil, err
+		}
+		if len(divisiones) == 0 {
+			return nil, ErrInventarioSinDivisiones
+		}
+		inventario.EstanteID = &estante.ID
+		if inventario.Descripcion == "" {
+			inventario.Descripcion = fmt.Sprintf("Estante %d", estante.Numero)
+		}
+	} else {
+		division, err := s.archivoRepo.GetDivision(req.DivisionID)
+		if err != nil {
+			return nil, err
+		}
+		if estante, err = s.archivoRepo.GetEstante(division.EstanteID.Hex()); err != nil {
+			return nil, err
+		}
+		divisiones = []*models.Division{division}
+		inventario.DivisionID = &division.ID
+		if inventario.Descripcion == "" {
+			inventario.Descripcion = fmt.Sprintf("Estante %d, división %d (%s)", estante.Numero, division.Numero, division.Rango())
+		}
+	}
+
+	for _, division := range divisiones {
+		inventario.Divisiones = append(inventario.Divisiones, models.DivisionInventario{
+			DivisionID: division.ID,
+			Estante:    estante.Numero,
+			Numero:     division.Numero,
+			Rango:      division.Rango(),
+		})
+	}
+	// Con una sola división no hace falta escanear su etiqueta
+	if len(divisiones) == 1 {
+		inventario.DivisionActual = &divisiones[0].ID
+	}
+
+	if err := s.inventarioRepo.Create(inventario); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📋 Inventario iniciado: %s (%d divisiones)", inventario.Descripcion, len(inventario.Divisiones))
+	return inventario, nil
+}
+
+// GetInventarios returns the inventory sessions, newest first, optionally only those in a state
+func (s *InventarioService) GetInventarios(estado models.EstadoInventario) ([]models.Inventario, error) {
+	return s.inventarioRepo.List(estado)
+}
+
+// GetInventario returns an inventory session with its scans, so it can be resumed. The findings
+// of a closed session are only served through its report, which applies the caller's clearance.
+func (s *InventarioService) GetInventario(id string) (*models.Inventario, error) {
+	inventario, err := s.inventarioRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	inventario.Hallazgos = nil
+	return inventario, nil
+}
+
+// divisionDeSesion resolves a division ID or label code among the divisions of a session
+func divisionDeSesion(codigo string, inventario *models.Inventario) (primitive.ObjectID, error) {
+	codigo = strings.TrimSpace(codigo)
+	id, err := primitive.ObjectIDFromHex(codigo)
+	if err != nil {
+		prefijo, objID, ok := models.ParseCodigoEtiqueta(codigo)
+		if !ok || prefijo != models.PrefijoEtiquetaDivision {
+			return primitive.NilObjectID, fmt.Errorf("%w: %s", ErrDivisionFueraInventario, codigo)
+		}
+		id = objID
+	}
+
+	for _, division := range inventario.Divisiones {
+		if division.DivisionID == id {
+			return id, nil
+		}
+	}
+	return primitive.NilObjectID, fmt.Errorf("%w: %s", ErrDivisionFueraInventario, codigo)
+}
+
+// divisionLeida returns a division of a session as it was when the session started
+func divisionLeida(inventario *models.Inventario, divisionID primitive.ObjectID) *models.DivisionInventario {
+	for i := range inventario.Divisiones {
+		if inventario.Divisiones[i].DivisionID == divisionID {
+			return &inventario.Divisiones[i]
+		}
+	}
+	return nil
+}
+
+// RegistrarLecturas records scanned or typed codes in an open session and tells right away
+// whether each folder is where it belongs. A division label among the codes sets the division
+// for the codes that follow it; otherwise the folders go to the division given in the request
+// or the last one scanned. Scanning a folder again in the same division changes nothing.
+func (s *InventarioService) RegistrarLecturas(id string, req *models.LecturasInventarioRequest, scope models.AccessScope) ([]models.ResultadoLectura, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+
+	inventario, err := s.inventarioRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if inventario.Estado != models.InventarioAbierto {
+		return nil, repository.ErrInventarioEstado
+	}
+
+	catalogo, err := s.cargarCatalogo()
+	if err != nil {
+		return nil, err
+	}
+	actual := inventario.DivisionActual
+	if strings.TrimSpace(req.Division) != "" {
+		divisionID, err := divisionDeSesion(req.Division, inventario)
+		if err != nil {
+			return nil, err
+		}
+		actual = &divisionID
+	}
+
+	// Primero se interpretan todos los códigos para no guardar una tanda a medias
+	ahora := time.Now()
+	resultados := make([]models.ResultadoLectura, 0, len(req.Codigos))
+	var lecturas []models.LecturaInventario
+	var indices []int
+	for _, codigo := range req.Codigos {
+		codigo = strings.TrimSpace(codigo)
+		if codigo == "" {
+			continue
+		}
+
+		prefijo, objID, ok := models.ParseCodigoEtiqueta(codigo)
+		if ok && prefijo == models.PrefijoEtiquetaDivision {
+			divisionID, err := divisionDeSesion(codigo, inventario)
+			if err != nil {
+				return nil, err
+			}
+			actual = &divisionID
+			resultados = append(resultados, models.ResultadoLectura{
+				Codigo:         codigo,
+				Division:       divisionLeida(inventario, divisionID),
+				CambioDivision: true,
+				Hallazgos:      []models.TipoHallazgo{},
+			})
+			continue
+		}
+		if actual == nil {
+			return nil, ErrDivisionRequerida
+		}
+
+		lectura := models.LecturaInventario{
+			Codigo:     codigo,
+			DivisionID: *actual,
+			Fecha:      ahora,
+			UsuarioID:  scope.UserID,
+		}
+		switch {
+		case ok && prefijo == models.PrefijoEtiquetaTomo:
+			tomo, err := s.tomoRepo.FindByID(objID)
+			if err == nil {
+				lectura.ExpedienteID = &tomo.ExpedienteID
+				lectura.TomoID = &tomo.ID
+			} else if !errors.Is(err, repository.ErrTomoNotFound) {
+				return nil, err
+			}
+		case ok:
+			expedienteID := objID
+			lectura.ExpedienteID = &expedienteID
+		}
+
+		lecturas = append(lecturas, lectura)
+		indices = append(indices, len(resultados))
+		resultados = append(resultados, models.ResultadoLectura{Codigo: codigo})
+	}
+
+	carpetas, err := s.cargarCarpetas(lecturas)
+	if err != nil {
+		return nil, err
+	}
+
+	leidas := make(map[string]primitive.ObjectID, len(inventario.Lecturas))
+	for i := range inventario.Lecturas {
+		leidas[claveLectura(&inventario.Lecturas[i])] = inventario.Lecturas[i].DivisionID
+	}
+
+	var entries []models.AuditLog
+	nuevas := make([]models.LecturaInventario, 0, len(lecturas))
+	for i := range lecturas {
+		lectura := &lecturas[i]
+		clave := claveLectura(lectura)
+		anterior, repetido := leidas[clave]
+		if !repetido || anterior != lectura.DivisionID {
+			nuevas = append(nuevas, *lectura)
+		}
+		leidas[clave] = lectura.DivisionID
+
+		resultado := &resultados[indices[i]]
+		resultado.Division = divisionLeida(inventario, lectura.DivisionID)
+		resultado.Repetido = repetido
+		carpeta := carpetas[clave]
+		resultado.Hallazgos, resultado.DivisionEsperada = catalogo.evaluar(carpeta, lectura.DivisionID)
+		if carpeta != nil {
+			expediente := carpeta.expediente
+			if scope.CanReadExpediente(expediente.ID, expediente.Clasificacion) {
+				resultado.CIP = expediente.CIP
+				resultado.ApellidosNombres = expediente.ApellidosNombres
+				if entry, ok := classifiedAccessEntry(scope, "inventario_lectura", expediente.ID, expediente.CIP, expediente.Clasificacion); ok {
+					entries = append(entries, entry)
+				}
+			} else {
+				resultado.CIP = models.IdentidadReservada
+				resultado.ApellidosNombres = models.IdentidadReservada
+			}
+			resultado.Ubicacion = carpeta.ubicacion()
+			resultado.Estado = carpeta.estado()
+			if carpeta.tomo != nil {
+				resultado.Tomo = carpeta.tomo.Numero
+			}
+		}
+	}
+
+	if err := s.inventarioRepo.AgregarLecturas(inventario.ID, nuevas, actual); err != nil {
+		return nil, err
+	}
+
+	storeClassifiedAccess(s.auditRepo, entries)
+	return resultados, nil
+}
+
+// PausarInventario stops accepting scans until the session is resumed
+func (s *InventarioService) PausarInventario(id string) (*models.Inventario, error) {
+	return s.cambiarEstado(id, models.InventarioAbierto, models.InventarioPausado)
+}
+
+// ReanudarInventario accepts scans again in a paused session
+func (s *InventarioService) ReanudarInventario(id string) (*models.Inventario, error) {
+	return s.cambiarEstado(id, models.InventarioPausado, models.InventarioAbierto)
+}
+
+// cambiarEstado moves a session between the open and paused states and returns it without its scans
+func (s *InventarioService) cambiarEstado(id string, desde, hasta models.EstadoInventario) (*models.Inventario, error) {
+	inventario, err := s.inventarioRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.inventarioRepo.CambiarEstado(inventario.ID, desde, hasta); err != nil {
+		return nil, err
+	}
+
+	inventario.Estado = hasta
+	inventario.Lecturas = nil
+	return inventario, nil
+}
+
+// CerrarInventario finishes a session and stores its report, which no longer changes
+// with later changes to the expedientes. The stored report is complete; the returned one
+// reserves the findings above the caller's clearance.
+func (s *InventarioService) CerrarInventario(id string, scope models.AccessScope) (*models.ReporteInventario, error) {
+	inventario, err := s.inventarioRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if inventario.Estado == models.InventarioCerrado {
+		return nil, repository.ErrInventarioEstado
+	}
+
+	reporte, err := s.calcularReporte(inventario)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.inventarioRepo.Cerrar(inventario.ID, reporte.Resumen, reporte.Hallazgos, scope.UserID); err != nil {
+		return nil, err
+	}
+	reporte.Estado = models.InventarioCerrado
+
+	log.Printf("📋 Inventario cerrado: %s (%d faltantes, %d mal ubicados, %d desconocidos, %d fuera presentes)",
+		inventario.Descripcion, reporte.Resumen.Faltantes, reporte.Resumen.MalUbicados, reporte.Resumen.Desconocidos, reporte.Resumen.FueraPresentes)
+	if err := s.reservarReporte(reporte, "reporte_inventario", scope); err != nil {
+		return nil, err
+	}
+	return reporte, nil
+}
+
+// GetReporte returns the report of a session: the stored one once closed, or one computed
+// from the current scans and expedientes while it is still open or paused. Findings above
+// the caller's clearance still count in the summary but do not show who they belong to.
+func (s *InventarioService) GetReporte(id string, operacion string, scope models.AccessScope) (*models.ReporteInventario, error) {
+	inventario, err := s.inventarioRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if inventario.Estado == models.InventarioCerrado && inventario.Resumen != nil {
+		reporte := &models.ReporteInventario{
+			InventarioID: inventario.ID,
+			Descripcion:  inventario.Descripcion,
+			Estado:       inventario.Estado,
+			Resumen:      *inventario.Resumen,
+			Hallazgos:    inventario.Hallazgos,
+			GeneradoEn:   *inventario.CerradoEn,
+		}
+		if reporte.Hallazgos == nil {
+			reporte.Hallazgos = []models.Hallazgo{}
+		}
+		if err := s.reservarReporte(reporte, operacion, scope); err != nil {
+			return nil, err
+		}
+		return reporte, nil
+	}
+
+	reporte, err := s.calcularReporte(inventario)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reservarReporte(reporte, operacion, scope); err != nil {
+		return nil, err
+	}
+	return reporte, nil
+}
+
+// reservarReporte reads the current classification of the expedientes behind the findings of a
+// report, hides the identities the caller may not see and logs the classified ones it shows
+func (s *InventarioService) reservarReporte(reporte *models.ReporteInventario, operacion string, scope models.AccessScope) error {
+	var ids []primitive.ObjectID
+	for _, hallazgo := range reporte.Hallazgos {
+		if hallazgo.ExpedienteID != nil {
+			ids = append(ids, *hallazgo.ExpedienteID)
+		}
+	}
+	clasificaciones, err := clasificacionesActuales(s.expedienteRepo, ids)
+	if err != nil {
+		return err
+	}
+	for i := range reporte.Hallazgos {
+		if id := reporte.Hallazgos[i].ExpedienteID; id != nil {
+			reporte.Hallazgos[i].Clasificacion = clasificaciones[*id]
+		}
+	}
+
+	storeClassifiedAccess(s.auditRepo, reservarHallazgos(reporte.Hallazgos, operacion, scope))
+	return nil
+}
+
+// reservarHallazgos replaces the CIP, name and grado of the findings above the caller's clearance
+// and returns the access log entries of the classified findings it shows. Unknown codes have no
+// expediente and are left as they are.
+func reservarHallazgos(hallazgos []models.Hallazgo, operacion string, scope models.AccessScope) []models.AuditLog {
+	var entries []models.AuditLog
+	registrados := make(map[primitive.ObjectID]bool)
+	for i := range hallazgos {
+		hallazgo := &hallazgos[i]
+		if hallazgo.ExpedienteID == nil {
+			continue
+		}
+		if !scope.CanReadExpediente(*hallazgo.ExpedienteID, hallazgo.Clasificacion) {
+			hallazgo.CIP = models.IdentidadReservada
+			hallazgo.ApellidosNombres = models.IdentidadReservada
+			hallazgo.Grado = ""
+			continue
+		}
+		// Un expediente con varios tomos o hallazgos se registra una sola vez
+		if registrados[*hallazgo.ExpedienteID] {
+			continue
+		}
+		if entry, ok := classifiedAccessEntry(scope, operacion, *hallazgo.ExpedienteID, hallazgo.CIP, hallazgo.Clasificacion); ok {
+			registrados[*hallazgo.ExpedienteID] = true
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// esperadoInventario is a folder the database places in one of the inventoried divisions
+type esperadoInventario struct {
+	carpeta  *carpetaInventario
+	division *models.Division
+}
+
+// calcularReporte compares the scans of a session with the folders expected in its divisions:
+// expedientes without tomos and tomos that are dentro and whose ubicación falls in the division
+func (s *InventarioService) calcularReporte(inventario *models.Inventario) (*models.ReporteInventario, error) {
+	catalogo, err := s.cargarCatalogo()
+	if err != nil {
+		return nil, err
+	}
+
+	esperados := make(map[string]*esperadoInventario)
+	var ordenEsperados []string
+	for _, sesion := range inventario.Divisiones {
+		division, ok := catalogo.porID[sesion.DivisionID]
+		if !ok {
+			continue
+		}
+
+		ubicaciones, err := s.expedienteRepo.GetUbicacionesByDivision(division)
+		if err != nil {
+			return nil, err
+		}
+
+		var conTomos []primitive.ObjectID
+		for _, ubicacion := range ubicaciones {
+			if ubicacion.Tomos > 0 {
+				conTomos = append(conTomos, ubicacion.ID)
+				continue
+			}
+			if ubicacion.Estado != models.EstadoDentro {
+				continue
+			}
+			clave := ubicacion.ID.Hex()
+			esperados[clave] = &esperadoInventario{carpeta: &carpetaInventario{expediente: ubicacion}, division: division}
+			ordenEsperados = append(ordenEsperados, clave)
+		}
+		if len(conTomos) == 0 {
+			continue
+		}
+
+		tomos, err := s.tomoRepo.GetByExpedientes(conTomos)
+		if err != nil {
+			return nil, err
+		}
+		for _, ubicacion := range ubicaciones {
+			lista := tomos[ubicacion.ID]
+			for i := range lista {
+				carpeta := &carpetaInventario{expediente: ubicacion, tomo: &lista[i]}
+				if carpeta.estado() != models.EstadoDentro || !carpeta.almacenadaEn(division) {
+					continue
+				}
+				clave := lista[i].ID.Hex()
+				esperados[clave] = &esperadoInventario{carpeta: carpeta, division: division}
+				ordenEsperados = append(ordenEsperados, clave)
+			}
+		}
+	}
+
+	// Cuenta la última lectura de cada carpeta: la división donde quedó al final
+	ultimas := make(map[string]models.LecturaInventario)
+	var ordenLecturas []string
+	for _, lectura := range inventario.Lecturas {
+		clave := claveLectura(&lectura)
+		if _, ok := ultimas[clave]; !ok {
+			ordenLecturas = append(ordenLecturas, clave)
+		}
+		ultimas[clave] = lectura
+	}
+	lecturas := make([]models.LecturaInventario, 0, len(ultimas))
+	for _, clave := range ordenLecturas {
+		lecturas = append(lecturas, ultimas[clave])
+	}
+
+	carpetas, err := s.cargarCarpetas(lecturas)
+	if err != nil {
+		return nil, err
+	}
+
+	reporte := &models.ReporteInventario{
+		InventarioID: inventario.ID,
+		Descripcion:  inventario.Descripcion,
+		Estado:       inventario.Estado,
+		Hallazgos:    []models.Hallazgo{},
+		GeneradoEn:   time.Now(),
+	}
+	reporte.Resumen.Esperados = len(ordenEsperados)
+	reporte.Resumen.Leidos = len(lecturas)
+
+	for i := range lecturas {
+		lectura := &lecturas[i]
+		clave := claveLectura(lectura)
+		carpeta := carpetas[clave]
+		tipos, esperada := catalogo.evaluar(carpeta, lectura.DivisionID)
+
+		malUbicado := false
+		for _, tipo := range tipos {
+			hallazgo := models.Hallazgo{Tipo: tipo}
+			if carpeta != nil {
+				hallazgo = carpeta.hallazgo(tipo)
+			}
+			hallazgo.Codigo = lectura.Codigo
+			hallazgo.DivisionLeida = divisionLeida(inventario, lectura.DivisionID)
+			if tipo == models.HallazgoMalUbicado {
+				hallazgo.DivisionEsperada = esperada
+				malUbicado = true
+			}
+			reporte.Hallazgos = append(reporte.Hallazgos, hallazgo)
+		}
+
+		if _, ok := esperados[clave]; ok {
+			delete(esperados, clave)
+			if !malUbicado {
+				reporte.Resumen.Encontrados++
+			}
+		}
+	}
+
+	for _, clave := range ordenEsperados {
+		esperado, ok := esperados[clave]
+		if !ok {
+			continue
+		}
+		hallazgo := esperado.carpeta.hallazgo(models.HallazgoFaltante)
+		hallazgo.DivisionEsperada = catalogo.describir(esperado.division)
+		reporte.Hallazgos = append(reporte.Hallazgos, hallazgo)
+	}
+
+	sort.SliceStable(reporte.Hallazgos, func(i, j int) bool {
+		return ordenHallazgos[reporte.Hallazgos[i].Tipo] < ordenHallazgos[reporte.Hallazgos[j].Tipo]
+	})
+	for _, hallazgo := range reporte.Hallazgos {
+		switch hallazgo.Tipo {
+		case models.HallazgoFaltante:
+			reporte.Resumen.Faltantes++
+		case models.HallazgoMalUbicado:
+			reporte.Resumen.MalUbicados++
+		case models.HallazgoDesconocido:
+			reporte.Resumen.Desconocidos++
+		case models.HallazgoFueraPresente:
+			reporte.Resumen.FueraPresentes++
+		}
+	}
+
+	return reporte, nil
+}
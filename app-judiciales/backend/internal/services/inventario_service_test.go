@@ -0,0 +1,51 @@
+package services
+
+import (
+	"expedientes-backend/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReservarHallazgos(t *testing.T) {
+	publicoID, secretoID := primitive.NewObjectID(), primitive.NewObjectID()
+	hallazgosPrueba := func() []models.Hallazgo {
+		return []models.Hallazgo{
+			{Tipo: models.HallazgoFaltante, ExpedienteID: &publicoID, CIP: "00012345", ApellidosNombres: "GARCIA PEREZ, Juan", Grado: "MAYOR", Clasificacion: models.ClasificacionPublico},
+			{Tipo: models.HallazgoFaltante, ExpedienteID: &secretoID, Tomo: 1, CIP: "00054321", ApellidosNombres: "QUISPE MAMANI, Rosa", Grado: "CORONEL", Clasificacion: models.ClasificacionSecreto},
+			{Tipo: models.HallazgoMalUbicado, ExpedienteID: &secretoID, Tomo: 2, CIP: "00054321", ApellidosNombres: "QUISPE MAMANI, Rosa", Grado: "CORONEL", Clasificacion: models.ClasificacionSecreto},
+			{Tipo: models.HallazgoDesconocido, Codigo: "X-123"},
+		}
+	}
+
+	publico := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionPublico}
+	hallazgos := hallazgosPrueba()
+	entries := reservarHallazgos(hallazgos, "reporte_inventario", publico)
+
+	if hallazgos[0].CIP != "00012345" || hallazgos[0].Grado != "MAYOR" {
+		t.Errorf("hallazgo público reservado: %+v", hallazgos[0])
+	}
+	for _, hallazgo := range hallazgos[1:3] {
+		if hallazgo.CIP != models.IdentidadReservada || hallazgo.ApellidosNombres != models.IdentidadReservada || hallazgo.Grado != "" {
+			t.Errorf("hallazgo secreto visible con nivel público: %+v", hallazgo)
+		}
+	}
+	if hallazgos[3].Codigo != "X-123" {
+		t.Errorf("código desconocido modificado: %+v", hallazgos[3])
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d accesos registrados, want 0", len(entries))
+	}
+
+	secreto := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionSecreto}
+	hallazgos = hallazgosPrueba()
+	entries = reservarHallazgos(hallazgos, "exportacion_reporte_inventario", secreto)
+
+	if hallazgos[2].CIP != "00054321" || hallazgos[2].Grado != "CORONEL" {
+		t.Errorf("hallazgo secreto reservado con nivel secreto: %+v", hallazgos[2])
+	}
+	// Los dos tomos del expediente secreto se registran una sola vez
+	if len(entries) != 1 || entries[0].RecursoID != secretoID.Hex() || entries[0].Detalles["operacion"] != "exportacion_reporte_inventario" {
+		t.Errorf("accesos registrados = %+v, want uno del expediente secreto", entries)
+	}
+}
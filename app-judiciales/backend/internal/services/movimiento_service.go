@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Movement errors
+var (
+	ErrTipoMovimiento      = errors.New("tipo de movimiento inválido. Use ingreso, actuacion, resolucion, notificacion, audiencia o archivo")
+	ErrMovimientoCorregido = errors.New("el movimiento a corregir no existe en el expediente")
+	ErrDocumentoMovimiento = errors.New("el documento no está adjunto al expediente")
+	ErrUsuarioFiltro       = errors.New("usuario_id inválido")
+)
+
+// MovimientoService manages the register of actuaciones of expedientes. The register is
+// append-only: movements are never edited or deleted, corrections are new movements.
+type MovimientoService struct {
+	movimientoRepo *repository.MovimientoRepository
+	expedienteRepo *repository.ExpedienteRepository
+	documentoRepo  *repository.DocumentoRepository
+	auditRepo      *repository.AuditRepository
+}
+
+// NewMovimientoService creates a new movimiento service
+func NewMovimientoService(movimientoRepo *repository.MovimientoRepository, expedienteRepo *repository.ExpedienteRepository, documentoRepo *repository.DocumentoRepository, auditRepo *repository.AuditRepository) *MovimientoService {
+	return &MovimientoService{
+		movimientoRepo: movimientoRepo,
+		expedienteRepo: expedienteRepo,
+		documentoRepo:  documentoRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// Registrar records a movement on an expediente with the next correlative number, the current
+// date and time and the caller as registering user. Attachments must be documents of the
+// expediente visible to the caller.
+func (s *MovimientoService) Registrar(expedienteID string, req *models.CreateMovimientoRequest, scope models.AccessScope) (*models.Movimiento, error) {
+	if !models.ValidTipoMovimiento(req.Tipo) {
+		return nil, ErrTipoMovimiento
+	}
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid createdBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CorrigeA > 0 {
+		if _, err := s.movimientoRepo.GetByNumero(expediente.ID, req.CorrigeA); err != nil {
+			if errors.Is(err, repository.ErrMovimientoNotFound) {
+				return nil, fmt.Errorf("%w: %d", ErrMovimientoCorregido, req.CorrigeA)
+			}
+			return nil, err
+		}
+	}
+
+	documentoIDs, err := s.validarDocumentos(expediente.ID, req.DocumentoIDs, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	movimiento := &models.Movimiento{
+		ExpedienteID: expediente.ID,
+		Tipo:         req.Tipo,
+		Descripcion:  req.Descripcion,
+		DocumentoIDs: documentoIDs,
+		CorrigeA:     req.CorrigeA,
+		UsuarioID:    scope.UserID,
+		Usuario:      scope.Email,
+	}
+	if err := s.movimientoRepo.Create(movimiento); err != nil {
+		return nil, err
+	}
+
+	detalles := map[string]interface{}{
+		"numero":     movimiento.Numero,
+		"tipo":       movimiento.Tipo,
+		"documentos": len(movimiento.DocumentoIDs),
+	}
+	if movimiento.CorrigeA > 0 {
+		detalles["corrige_a"] = movimiento.CorrigeA
+	}
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionMovimiento,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles:  detalles,
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría del movimiento %d de %s: %v", movimiento.Numero, expediente.CIP, err)
+	}
+
+	log.Printf("📜 Expediente %s: movimiento %d (%s) registrado por %s", expediente.CIP, movimiento.Numero, movimiento.Tipo, scope.Email)
+
+	return movimiento, nil
+}
+
+// validarDocumentos parses the attachment IDs of a movement, ignoring repeated ones, and
+// checks that each is a document of the expediente the caller can see
+func (s *MovimientoService) validarDocumentos(expedienteID primitive.ObjectID, ids []string, scope models.AccessScope) ([]primitive.ObjectID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	confidenciales := scope.HasPermission(models.PermissionDocumentoConfidential)
+	vistos := make(map[primitive.ObjectID]bool, len(ids))
+	documentoIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !primitive.IsValidObjectID(id) {
+			return nil, fmt.Errorf("%w: %s", ErrDocumentoMovimiento, id)
+		}
+		documento, err := s.documentoRepo.GetByID(expedienteID, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrDocumentoNotFound) {
+				return nil, fmt.Errorf("%w: %s", ErrDocumentoMovimiento, id)
+			}
+			return nil, err
+		}
+		if documento.Confidencial && !confidenciales {
+			return nil, fmt.Errorf("%w: %s", ErrDocumentoMovimiento, id)
+		}
+		if vistos[documento.ID] {
+			continue
+		}
+		vistos[documento.ID] = true
+		documentoIDs = append(documentoIDs, documento.ID)
+	}
+
+	return documentoIDs, nil
+}
+
+// GetMovimientos returns a page of the movements of an expediente in numbering order, each
+// with the numbers of the movements that correct it
+func (s *MovimientoService) GetMovimientos(expedienteID string, params models.MovimientoSearchParams, scope models.AccessScope) ([]models.Movimiento, int64, error) {
+	if err := validarFiltroMovimientos(params); err != nil {
+		return nil, 0, err
+	}
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	movimientos, total, err := s.movimientoRepo.GetByExpediente(expediente.ID, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	numeros := make([]int, len(movimientos))
+	for i, m := range movimientos {
+		numeros[i] = m.Numero
+	}
+	correcciones, err := s.movimientoRepo.Correcciones(expediente.ID, numeros)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range movimientos {
+		movimientos[i].CorregidoPor = correcciones[movimientos[i].Numero]
+	}
+
+	return movimientos, total, nil
+}
+
+// GetMovimiento returns a movement of an expediente by its number
+func (s *MovimientoService) GetMovimiento(expedienteID string, numero int, scope models.AccessScope) (*models.Movimiento, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	movimiento, err := s.movimientoRepo.GetByNumero(expediente.ID, numero)
+	if err != nil {
+		return nil, err
+	}
+
+	correcciones, err := s.movimientoRepo.Correcciones(expediente.ID, []int{movimiento.Numero})
+	if err != nil {
+		return nil, err
+	}
+	movimiento.CorregidoPor = correcciones[movimiento.Numero]
+
+	return movimiento, nil
+}
+
+// SearchMovimientos returns a page of the movements of every expediente readable within the
+// scope, newest first
+func (s *MovimientoService) SearchMovimientos(params models.MovimientoSearchParams, scope models.AccessScope) ([]models.MovimientoReporte, int64, error) {
+	if err := validarFiltroMovimientos(params); err != nil {
+		return nil, 0, err
+	}
+
+	return s.movimientoRepo.Search(params, scope)
+}
+
+// validarFiltroMovimientos checks the type and user filters of a movement search
+func validarFiltroMovimientos(params models.MovimientoSearchParams) error {
+	if params.Tipo != "" && !models.ValidTipoMovimiento(params.Tipo) {
+		return ErrTipoMovimiento
+	}
+	if params.UsuarioID != "" && !primitive.IsValidObjectID(params.UsuarioID) {
+		return ErrUsuarioFiltro
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Notification errors
+var (
+	ErrMetodoNotificacion  = errors.New("método de notificación inválido. Use cedula, edicto o email")
+	ErrEmailNotificacion   = errors.New("las notificaciones por email requieren el email del receptor")
+	ErrEmailNoDisponible   = errors.New("el envío de notificaciones por email no está configurado (EMAIL_HOST)")
+	ErrFechaNotificacion   = errors.New("la fecha no puede ser futura ni anterior al envío de la notificación")
+	ErrReenvioNotificacion = errors.New("solo se reenvían notificaciones por email pendientes")
+)
+
+// diasPendienteDefault is the age of the pending notifications reported when none is given
+const diasPendienteDefault = 7
+
+// NotificacionService manages the notifications of the movements of expedientes. Email
+// notifications are sent through the mailer configured with the EMAIL_* variables.
+type NotificacionService struct {
+	notificacionRepo *repository.NotificacionRepository
+	movimientoRepo   *repository.MovimientoRepository
+	expedienteRepo   *repository.ExpedienteRepository
+	auditRepo        *repository.AuditRepository
+	mailer           Mailer // nil when no SMTP host is configured
+}
+
+// NewNotificacionService creates a new notificacion service
+func NewNotificacionService(notificacionRepo *repository.NotificacionRepository, movimientoRepo *repository.MovimientoRepository, expedienteRepo *repository.ExpedienteRepository, auditRepo *repository.AuditRepository, mailer Mailer) *NotificacionService {
+	return &NotificacionService{
+		notificacionRepo: notificacionRepo,
+		movimientoRepo:   movimientoRepo,
+		expedienteRepo:   expedienteRepo,
+		auditRepo:        auditRepo,
+		mailer:           mailer,
+	}
+}
+
+// Registrar records a pending notification of a movement. Cédulas and edictos are sent by
+// hand on fecha_envio; email notifications are sent in the background and get their send date
+// once the mailer accepts them.
+func (s *NotificacionService) Registrar(expedienteID string, numero int, req *models.CreateNotificacionRequest, scope models.AccessScope) (*models.NotificacionMovimiento, error) {
+	if !models.ValidMetodoNotificacion(req.Metodo) {
+		return nil, ErrMetodoNotificacion
+	}
+	if req.Metodo == models.NotificacionEmail {
+		if req.Email == "" {
+			return nil, ErrEmailNotificacion
+		}
+		if s.mailer == nil {
+			return nil, ErrEmailNoDisponible
+		}
+	}
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid createdBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	movimiento, err := s.movimientoRepo.GetByNumero(expediente.ID, numero)
+	if err != nil {
+		return nil, err
+	}
+
+	notificacion := &models.Notificacion{
+		ExpedienteID:  expediente.ID,
+		MovimientoID:  movimiento.ID,
+		Receptor:      req.Receptor,
+		Direccion:     req.Direccion,
+		Email:         req.Email,
+		Metodo:        req.Metodo,
+		Estado:        models.NotificacionPendiente,
+		Observaciones: req.Observaciones,
+		UsuarioID:     scope.UserID,
+		Usuario:       scope.Email,
+	}
+	if req.Metodo != models.NotificacionEmail {
+		fecha, err := parseFechaNotificacion(req.FechaEnvio, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		notificacion.FechaEnvio = &fecha
+	}
+
+	if err := s.notificacionRepo.Create(notificacion); err != nil {
+		return nil, err
+	}
+
+	s.registrarAuditoria(scope, expediente, models.AccionNotificacionRegistro, map[string]interface{}{
+		"notificacion_id": notificacion.ID.Hex(),
+		"movimiento":      movimiento.Numero,
+		"metodo":          notificacion.Metodo,
+		"receptor":        notificacion.Receptor,
+	})
+
+	log.Printf("📨 Expediente %s: notificación por %s del movimiento %d a %s registrada por %s", expediente.CIP, notificacion.Metodo, movimiento.Numero, notificacion.Receptor, scope.Email)
+
+	if notificacion.Metodo == models.NotificacionEmail {
+		go s.enviarEmail(*notificacion, expediente, movimiento)
+	}
+
+	return &models.NotificacionMovimiento{
+		Notificacion:     *notificacion,
+		MovimientoNumero: movimiento.Numero,
+		MovimientoTipo:   movimiento.Tipo,
+	}, nil
+}
+
+// enviarEmail sends an email notification and records the outcome. Failures are kept on the
+// notification so it can be sent again.
+func (s *NotificacionService) enviarEmail(notificacion models.Notificacion, expediente *models.Expediente, movimiento *models.Movimiento) {
+	subject := fmt.Sprintf("Notificación del expediente %s - movimiento %d", expediente.CIP, movimiento.Numero)
+	body := fmt.Sprintf(
+		"Sr(a). %s:\n\n"+
+			"Se le notifica el siguiente movimiento del expediente %s.\n\n"+
+			"Movimiento: %d (%s)\nFecha: %s\n\n%s\n",
+		notificacion.Receptor, expediente.CIP,
+		movimiento.Numero, movimiento.Tipo, movimiento.Fecha.Format("02/01/2006 15:04"),
+		movimiento.Descripcion,
+	)
+
+	envioErr := s.mailer.Send([]string{notificacion.Email}, subject, body)
+	if envioErr != nil {
+		log.Printf("⚠️ Error enviando la notificación %s por email a %s: %v", notificacion.ID.Hex(), notificacion.Email, envioErr)
+	} else {
+		log.Printf("📨 Notificación %s del expediente %s enviada a %s", notificacion.ID.Hex(), expediente.CIP, notificacion.Email)
+	}
+
+	if err := s.notificacionRepo.RegistrarEnvio(notificacion.ID, time.Now(), envioErr); err != nil {
+		log.Printf("⚠️ Error registrando el envío de la notificación %s: %v", notificacion.ID.Hex(), err)
+	}
+}
+
+// Reenviar sends again a pending email notification, typically after a mailer failure
+func (s *NotificacionService) Reenviar(expedienteID, notificacionID string, scope models.AccessScope) (*models.Notificacion, error) {
+	if s.mailer == nil {
+		return nil, ErrEmailNoDisponible
+	}
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	notificacion, err := s.notificacionRepo.GetByID(expediente.ID, notificacionID)
+	if err != nil {
+		return nil, err
+	}
+	if notificacion.Metodo != models.NotificacionEmail || notificacion.Estado != models.NotificacionPendiente {
+		return nil, ErrReenvioNotificacion
+	}
+
+	movimiento, err := s.movimientoRepo.GetByID(notificacion.MovimientoID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📨 Expediente %s: reenvío de la notificación %s solicitado por %s", expediente.CIP, notificacion.ID.Hex(), scope.Email)
+	go s.enviarEmail(*notificacion, expediente, movimiento)
+
+	return notificacion, nil
+}
+
+// GetNotificaciones returns the notifications of an expediente, or of one of its movements
+// when numero is positive
+func (s *NotificacionService) GetNotificaciones(expedienteID string, numero int, scope models.AccessScope) ([]models.NotificacionMovimiento, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var movimiento models.Movimiento
+	if numero > 0 {
+		m, err := s.movimientoRepo.GetByNumero(expediente.ID, numero)
+		if err != nil {
+			return nil, err
+		}
+		movimiento = *m
+	}
+
+	return s.notificacionRepo.GetByExpediente(expediente.ID, movimiento.ID)
+}
+
+// ActualizarEstado records the delivery or return of a pending notification on the given date
+func (s *NotificacionService) ActualizarEstado(expedienteID, notificacionID string, req *models.UpdateEstadoNotificacionRequest, scope models.AccessScope) (*models.Notificacion, error) {
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	notificacion, err := s.notificacionRepo.GetByID(expediente.ID, notificacionID)
+	if err != nil {
+		return nil, err
+	}
+	if notificacion.Estado != models.NotificacionPendiente {
+		return nil, repository.ErrNotificacionCambiada
+	}
+
+	var desde time.Time
+	if notificacion.FechaEnvio != nil {
+		desde = *notificacion.FechaEnvio
+	}
+	fecha, err := parseFechaNotificacion(req.Fecha, desde)
+	if err != nil {
+		return nil, err
+	}
+
+	campos := bson.M{"estado": req.Estado, "actualizado_por": scope.Email}
+	if req.Estado == models.NotificacionEntregada {
+		campos["fecha_entrega"] = fecha
+		notificacion.FechaEntrega = &fecha
+	} else {
+		campos["fecha_devolucion"] = fecha
+		notificacion.FechaDevolucion = &fecha
+	}
+	if req.Observaciones != "" {
+		campos["observaciones"] = req.Observaciones
+		notificacion.Observaciones = req.Observaciones
+	}
+	if err := s.notificacionRepo.UpdateEstado(notificacion.ID, campos); err != nil {
+		return nil, err
+	}
+	notificacion.Estado = req.Estado
+	notificacion.ActualizadoPor = scope.Email
+
+	s.registrarAuditoria(scope, expediente, models.AccionNotificacionEstado, map[string]interface{}{
+		"notificacion_id": notificacion.ID.Hex(),
+		"estado":          req.Estado,
+		"fecha":           fecha.Format("2006-01-02"),
+	})
+
+	log.Printf("📨 Expediente %s: notificación %s marcada como %s por %s", expediente.CIP, notificacion.ID.Hex(), req.Estado, scope.Email)
+
+	return notificacion, nil
+}
+
+// GetPendientes returns a page of the notifications pending for at least the given days across
+// the expedientes readable within the scope, oldest first
+func (s *NotificacionService) GetPendientes(params models.NotificacionPendienteParams, scope models.AccessScope) ([]models.NotificacionReporte, int64, error) {
+	if params.Metodo != "" && !models.ValidMetodoNotificacion(params.Metodo) {
+		return nil, 0, ErrMetodoNotificacion
+	}
+	dias := diasPendienteDefault
+	if params.Dias != nil {
+		dias = *params.Dias
+	}
+
+	ahora := time.Now()
+	notificaciones, total, err := s.notificacionRepo.Pendientes(ahora.AddDate(0, 0, -dias), params.Metodo, params.Page, params.Limit, scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for i := range notificaciones {
+		desde := notificaciones[i].CreatedAt
+		if notificaciones[i].FechaEnvio != nil {
+			desde = *notificaciones[i].FechaEnvio
+		}
+		notificaciones[i].DiasPendiente = int(ahora.Sub(desde).Hours() / 24)
+	}
+
+	return notificaciones, total, nil
+}
+
+// registrarAuditoria logs a notification action on the expediente
+func (s *NotificacionService) registrarAuditoria(scope models.AccessScope, expediente *models.Expediente, accion string, detalles map[string]interface{}) {
+	if err := s.auditRepo.Log(&models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    accion,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: expediente.ID.Hex(),
+		IP:        scope.IP,
+		Detalles:  detalles,
+	}); err != nil {
+		log.Printf("⚠️ Error registrando auditoría de notificación de %s: %v", expediente.CIP, err)
+	}
+}
+
+// parseFechaNotificacion parses a YYYY-MM-DD date of a notification, today when empty. The
+// date cannot be in the future nor before the day of desde.
+func parseFechaNotificacion(valor string, desde time.Time) (time.Time, error) {
+	ahora := time.Now()
+	if valor == "" {
+		return ahora, nil
+	}
+
+	fecha, err := time.Parse("2006-01-02", valor)
+	if err != nil {
+		return time.Time{}, ErrFechaNotificacion
+	}
+	if fecha.After(ahora) {
+		return time.Time{}, ErrFechaNotificacion
+	}
+	if !desde.IsZero() {
+		if fecha.Before(desde.UTC().Truncate(24 * time.Hour)) {
+			return time.Time{}, ErrFechaNotificacion
+		}
+	}
+
+	return fecha, nil
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"expedientes-backend/internal/models"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SupervisorNotifier alerts configured supervisors by email and webhook
+type SupervisorNotifier struct {
+	mailer      Mailer
+	supervisors []string
+	webhookURL  string
+	httpClient  *http.Client
+}
+
+// NewSupervisorNotifier creates a new supervisor notifier
+func NewSupervisorNotifier(mailer Mailer, supervisors []string, webhookURL string) *SupervisorNotifier {
+	return &SupervisorNotifier{
+		mailer:      mailer,
+		supervisors: supervisors,
+		webhookURL:  webhookURL,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NotifyBreakGlass sends the alert for a new emergency access through every configured channel.
+// Failures are logged and do not affect the grant.
+func (n *SupervisorNotifier) NotifyBreakGlass(access *models.BreakGlassAccess) {
+	if n == nil {
+		return
+	}
+
+	if n.mailer != nil && len(n.supervisors) > 0 {
+		subject := fmt.Sprintf("[ALERTA] Acceso de emergencia al expediente %s", access.CIP)
+		body := fmt.Sprintf(
+			"Se otorgó un acceso de emergencia (break-glass).\n\n"+
+				"Usuario: %s\nExpediente (CIP): %s\nClasificación: %s\nJustificación: %s\n"+
+				"Otorgado: %s\nExpira: %s\nIP: %s\n\n"+
+				"El acceso queda pendiente de revisión en /api/v1/admin/break-glass.",
+			access.UsuarioEmail, access.CIP, access.Clasificacion, access.Justificacion,
+			access.OtorgadoEn.Format(time.RFC3339), access.ExpiraEn.Format(time.RFC3339), access.IP,
+		)
+		if err := n.mailer.Send(n.supervisors, subject, body); err != nil {
+			log.Printf("⚠️ Error enviando alerta de break-glass por email: %v", err)
+		}
+	}
+
+	if n.webhookURL != "" {
+		if err := n.postWebhook(map[string]interface{}{
+			"evento": models.AccionBreakGlassSolicitud,
+			"acceso": access,
+		}); err != nil {
+			log.Printf("⚠️ Error enviando alerta de break-glass por webhook: %v", err)
+		}
+	}
+}
+
+// postWebhook posts a JSON payload to the configured webhook URL
+func (n *SupervisorNotifier) postWebhook(payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.httpClient.Post(n.webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
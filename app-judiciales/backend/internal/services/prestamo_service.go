@@ -0,0 +1,411 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scan errors
+var (
+	ErrCodigoInvalido       = errors.New("el código escaneado no corresponde a una carpeta")
+	ErrPrestatarioRequerido = errors.New("indique a quién o a qué dependencia se presta la carpeta")
+	ErrPrestamoAjeno        = errors.New("la carpeta ya está prestada a otra persona; regístrela como devuelta antes de prestarla")
+)
+
+// PrestamoService handles loans driven by barcode scans at the loans desk
+type PrestamoService struct {
+	prestamoRepo    *repository.PrestamoRepository
+	expedienteRepo  *repository.ExpedienteRepository
+	tomoRepo        *repository.TomoRepository
+	archivoRepo     *repository.ArchivoRepository
+	dependenciaRepo *repository.DependenciaRepository
+	estadoService   *EstadoService
+	tomoService     *TomoService
+}
+
+// NewPrestamoService creates a new prestamo service
+func NewPrestamoService(prestamoRepo *repository.PrestamoRepository, expedienteRepo *repository.ExpedienteRepository, tomoRepo *repository.TomoRepository, archivoRepo *repository.ArchivoRepository, dependenciaRepo *repository.DependenciaRepository, estadoService *EstadoService, tomoService *TomoService) *PrestamoService {
+	return &PrestamoService{
+		prestamoRepo:    prestamoRepo,
+		expedienteRepo:  expedienteRepo,
+		tomoRepo:        tomoRepo,
+		archivoRepo:     archivoRepo,
+		dependenciaRepo: dependenciaRepo,
+		estadoService:   estadoService,
+		tomoService:     tomoService,
+	}
+}
+
+// carpetaEscaneada is the expediente or tomo a scanned code resolved to
+type carpetaEscaneada struct {
+	expediente *models.Expediente
+	tomo       *models.Tomo
+}
+
+// estado returns the current estado of the scanned folder
+func (c *carpetaEscaneada) estado() models.EstadoExpediente {
+	if c.tomo != nil {
+		return c.tomo.Estado
+	}
+	return c.expediente.Estado
+}
+
+// ubicacion returns where the scanned folder is filed
+func (c *carpetaEscaneada) ubicacion() string {
+	if c.tomo != nil {
+		return c.tomo.Ubicacion
+	}
+	return c.expediente.Ubicacion
+}
+
+// tomoID returns the ID of the scanned tomo, or nil for a whole expediente
+func (c *carpetaEscaneada) tomoID() *primitive.ObjectID {
+	if c.tomo != nil {
+		return &c.tomo.ID
+	}
+	return nil
+}
+
+// Escanear lends or returns the folder behind a scanned label. The estado changes through the
+// configured transitions (dentro -> fuera to lend, fuera -> dentro to return) with a loan record.
+// Scanning a folder that is already in the requested state changes nothing and is reported as
+// repeated, so an accidental double scan is harmless.
+func (s *PrestamoService) Escanear(req *models.EscaneoRequest, scope models.AccessScope) (*models.ResultadoEscaneo, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	prestatario := strings.TrimSpace(req.Prestatario)
+	hasta := models.EstadoDentro
+	var dependencia *models.Dependencia
+	if req.Accion == models.AccionPrestamo {
+		if strings.TrimSpace(req.Dependencia) != "" {
+			var err error
+			if dependencia, err = s.resolverDependencia(req.Dependencia); err != nil {
+				return nil, err
+			}
+			// Without a named person the folder is lent to the dependencia itself
+			if prestatario == "" {
+				prestatario = dependencia.Nombre
+			}
+		}
+		if prestatario == "" {
+			return nil, ErrPrestatarioRequerido
+		}
+		hasta = models.EstadoFuera
+	}
+
+	carpeta, err := s.resolverCodigo(req.Codigo, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	// La división se resuelve antes de cambiar nada para que un código erróneo no deje el cambio a medias
+	var division *models.Division
+	if req.Accion == models.AccionDevolucion && strings.TrimSpace(req.Division) != "" {
+		if division, err = s.resolverDivision(req.Division); err != nil {
+			return nil, err
+		}
+	}
+
+	abierto, err := s.prestamoRepo.GetAbierto(carpeta.expediente.ID, carpeta.tomoID())
+	if err != nil && !errors.Is(err, repository.ErrPrestamoNotFound) {
+		return nil, err
+	}
+
+	resultado := &models.ResultadoEscaneo{
+		Accion:           req.Accion,
+		ExpedienteID:     carpeta.expediente.ID,
+		TomoID:           carpeta.tomoID(),
+		CIP:              carpeta.expediente.CIP,
+		Grado:            carpeta.expediente.Grado,
+		ApellidosNombres: carpeta.expediente.ApellidosNombres,
+		Ubicacion:        carpeta.ubicacion(),
+		Estado:           hasta,
+		Advertencias:     []string{},
+	}
+	if carpeta.tomo != nil {
+		resultado.Tomo = carpeta.tomo.Numero
+	}
+
+	if req.Accion == models.AccionPrestamo {
+		resultado.Prestamo, resultado.Repetido, err = s.prestar(carpeta, abierto, prestatario, dependencia, scope)
+	} else {
+		resultado.Prestamo, resultado.Repetido, err = s.devolver(carpeta, abierto, scope)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if division != nil {
+		if advertencia := s.verificarDivision(carpeta, division); advertencia != "" {
+			resultado.Advertencias = append(resultado.Advertencias, advertencia)
+		}
+	}
+
+	return resultado, nil
+}
+
+// prestar lends a folder, to a dependencia when one is given. The loan is stored before the
+// estado changes and removed again if the change fails, so a folder is never fuera without its loan.
+func (s *PrestamoService) prestar(carpeta *carpetaEscaneada, abierto *models.Prestamo, prestatario string, dependencia *models.Dependencia, scope models.AccessScope) (*models.Prestamo, bool, error) {
+	if carpeta.estado() == models.EstadoFuera && abierto != nil {
+		if !strings.EqualFold(abierto.Prestatario, prestatario) || !mismaDependencia(abierto, dependencia) {
+			return nil, false, fmt.Errorf("%w (%s)", ErrPrestamoAjeno, abierto.Prestatario)
+		}
+		return abierto, true, nil
+	}
+
+	// Una carpeta que ya estaba fuera sin préstamo registrado solo queda registrada con su prestatario
+	yaFuera := carpeta.estado() == models.EstadoFuera
+
+	prestamo := &models.Prestamo{
+		ExpedienteID:    carpeta.expediente.ID,
+		TomoID:          carpeta.tomoID(),
+		Prestatario:     prestatario,
+		Salida:          time.Now(),
+		UsuarioSalidaID: scope.UserID,
+		UsuarioSalida:   scope.Email,
+	}
+	if carpeta.tomo != nil {
+		prestamo.Tomo = carpeta.tomo.Numero
+	}
+	if dependencia != nil {
+		prestamo.DependenciaID = &dependencia.ID
+		prestamo.Dependencia = dependencia.Nombre
+	}
+	if err := s.prestamoRepo.Create(prestamo); err != nil {
+		return nil, false, err
+	}
+	if yaFuera {
+		return prestamo, true, nil
+	}
+
+	repetido, err := s.cambiarEstado(carpeta, models.EstadoFuera, "Préstamo a "+prestatario, scope)
+	if err != nil || repetido {
+		if deleteErr := s.prestamoRepo.Delete(prestamo.ID); deleteErr != nil {
+			log.Printf("⚠️ Error eliminando el préstamo %s no aplicado: %v", prestamo.ID.Hex(), deleteErr)
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if repetido {
+		// Otro escaneo simultáneo prestó la carpeta; se devuelve su préstamo
+		abierto, err := s.prestamoRepo.GetAbierto(carpeta.expediente.ID, carpeta.tomoID())
+		if err != nil && !errors.Is(err, repository.ErrPrestamoNotFound) {
+			return nil, false, err
+		}
+		return abierto, true, nil
+	}
+
+	return prestamo, false, nil
+}
+
+// devolver returns a folder to the archive and closes its open loan, if it has one
+func (s *PrestamoService) devolver(carpeta *carpetaEscaneada, abierto *models.Prestamo, scope models.AccessScope) (*models.Prestamo, bool, error) {
+	repetido := carpeta.estado() == models.EstadoDentro
+	if !repetido {
+		justificacion := "Devolución"
+		if abierto != nil {
+			justificacion = "Devolución del préstamo a " + abierto.Prestatario
+		}
+
+		var err error
+		if repetido, err = s.cambiarEstado(carpeta, models.EstadoDentro, justificacion, scope); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// También se cierra aquí un préstamo que quedó abierto en una devolución anterior interrumpida
+	if abierto != nil {
+		if err := s.prestamoRepo.Cerrar(abierto, scope.UserID, scope.Email); err != nil && !errors.Is(err, repository.ErrPrestamoCerrado) {
+			return nil, false, err
+		}
+	}
+
+	return abierto, repetido, nil
+}
+
+// cambiarEstado moves the folder to another estado through the state machine. It reports
+// true when a concurrent scan already moved the folder to that estado.
+func (s *PrestamoService) cambiarEstado(carpeta *carpetaEscaneada, hasta models.EstadoExpediente, justificacion string, scope models.AccessScope) (bool, error) {
+	req := &models.UpdateEstadoRequest{Estado: hasta, Justificacion: justificacion}
+
+	var err error
+	if carpeta.tomo != nil {
+		_, err = s.tomoService.CambiarEstadoTomo(carpeta.expediente.ID.Hex(), carpeta.tomo.ID.Hex(), req, scope)
+	} else {
+		_, err = s.estadoService.CambiarEstado(carpeta.expediente.ID.Hex(), req, scope)
+	}
+	if !errors.Is(err, repository.ErrEstadoCambiado) {
+		return false, err
+	}
+
+	actual, reloadErr := s.resolverID(carpeta.expediente.ID, carpeta.tomoID(), scope)
+	if reloadErr != nil {
+		return false, err
+	}
+	if actual.estado() != hasta {
+		return false, err
+	}
+	return true, nil
+}
+
+// resolverCodigo finds the expediente or tomo behind a scanned label code
+func (s *PrestamoService) resolverCodigo(codigo string, scope models.AccessScope) (*carpetaEscaneada, error) {
+	prefijo, id, ok := models.ParseCodigoEtiqueta(codigo)
+	if !ok || prefijo == models.PrefijoEtiquetaDivision {
+		return nil, ErrCodigoInvalido
+	}
+
+	if prefijo == models.PrefijoEtiquetaTomo {
+		tomo, err := s.tomoRepo.FindByID(id)
+		if err != nil {
+			return nil, err
+		}
+		return s.resolverID(tomo.ExpedienteID, &tomo.ID, scope)
+	}
+	return s.resolverID(id, nil, scope)
+}
+
+// resolverID loads an expediente readable within the scope and, when tomoID is set, one of its tomos
+func (s *PrestamoService) resolverID(expedienteID primitive.ObjectID, tomoID *primitive.ObjectID, scope models.AccessScope) (*carpetaEscaneada, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID.Hex(), scope)
+	if err != nil {
+		return nil, err
+	}
+
+	carpeta := &carpetaEscaneada{expediente: expediente}
+	if tomoID != nil {
+		if carpeta.tomo, err = s.tomoRepo.GetByID(expediente.ID, tomoID.Hex()); err != nil {
+			return nil, err
+		}
+	}
+	return carpeta, nil
+}
+
+// mismaDependencia reports whether an open loan was made to the given dependencia, or to none
+func mismaDependencia(prestamo *models.Prestamo, dependencia *models.Dependencia) bool {
+	if prestamo.DependenciaID == nil || dependencia == nil {
+		return prestamo.DependenciaID == nil && dependencia == nil
+	}
+	return *prestamo.DependenciaID == dependencia.ID
+}
+
+// resolverDependencia finds an active dependencia by ID or by its code
+func (s *PrestamoService) resolverDependencia(codigo string) (*models.Dependencia, error) {
+	codigo = strings.TrimSpace(codigo)
+
+	var dependencia *models.Dependencia
+	var err error
+	if primitive.IsValidObjectID(codigo) {
+		dependencia, err = s.dependenciaRepo.GetByID(codigo)
+	}
+	if dependencia == nil {
+		dependencia, err = s.dependenciaRepo.GetByCodigo(normalizarCodigoDependencia(codigo))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !dependencia.Activa {
+		return nil, ErrDependenciaInactiva
+	}
+	return dependencia, nil
+}
+
+// resolverDivision finds a division by ID or by the code of its shelf-end label
+func (s *PrestamoService) resolverDivision(codigo string) (*models.Division, error) {
+	prefijo, id, ok := models.ParseCodigoEtiqueta(codigo)
+	if ok && prefijo == models.PrefijoEtiquetaDivision {
+		return s.archivoRepo.GetDivision(id.Hex())
+	}
+	return s.archivoRepo.GetDivision(strings.TrimSpace(codigo))
+}
+
+// verificarDivision returns a warning when a returned folder does not belong in the division it
+// is being shelved in, naming the division it belongs to
+func (s *PrestamoService) verificarDivision(carpeta *carpetaEscaneada, division *models.Division) string {
+	ubicacion := carpeta.ubicacion()
+	if division.Almacena(ubicacion, carpeta.expediente.Grado, carpeta.expediente.SituacionMilitar) {
+		return ""
+	}
+
+	advertencia := fmt.Sprintf("La carpeta (%s, %s) no corresponde a la división %d (%s)", ubicacion, carpeta.expediente.Grado, division.Numero, division.Rango())
+
+	divisiones, err := s.archivoRepo.GetDivisiones(nil)
+	if err != nil {
+		log.Printf("⚠️ Error buscando la división de la carpeta %s: %v", carpeta.expediente.ID.Hex(), err)
+		return advertencia
+	}
+	for _, correcta := range divisiones {
+		if !correcta.Almacena(ubicacion, carpeta.expediente.Grado, carpeta.expediente.SituacionMilitar) {
+			continue
+		}
+		if estante, err := s.archivoRepo.GetEstante(correcta.EstanteID.Hex()); err == nil {
+			return fmt.Sprintf("%s; va en el estante %d, división %d (%s)", advertencia, estante.Numero, correcta.Numero, correcta.Rango())
+		}
+		return fmt.Sprintf("%s; va en la división %d (%s)", advertencia, correcta.Numero, correcta.Rango())
+	}
+	return advertencia + "; ninguna división del catálogo la almacena"
+}
+
+// GetPrestamos returns the loan history of an expediente and its tomos
+func (s *PrestamoService) GetPrestamos(expedienteID string, scope models.AccessScope) ([]models.Prestamo, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.prestamoRepo.GetByExpediente(expediente.ID)
+}
+
+// GetPrestamosAbiertos returns the open loans of the expedientes readable within the scope,
+// optionally only those held by a dependencia, oldest first
+func (s *PrestamoService) GetPrestamosAbiertos(dependenciaID string, scope models.AccessScope) ([]models.PrestamoAbierto, error) {
+	var filtro *primitive.ObjectID
+	if dependenciaID != "" {
+		dependencia, err := s.dependenciaRepo.GetByID(dependenciaID)
+		if err != nil {
+			return nil, err
+		}
+		filtro = &dependencia.ID
+	}
+
+	prestamos, err := s.prestamoRepo.GetAbiertos(filtro)
+	if err != nil {
+		return nil, err
+	}
+	if len(prestamos) == 0 {
+		return []models.PrestamoAbierto{}, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(prestamos))
+	for _, prestamo := range prestamos {
+		ids = append(ids, prestamo.ExpedienteID)
+	}
+	expedientes, err := s.expedienteRepo.GetByIDs(ids, scope)
+	if err != nil {
+		return nil, err
+	}
+	porID := make(map[primitive.ObjectID]*models.Expediente, len(expedientes))
+	for _, expediente := range expedientes {
+		porID[expediente.ID] = expediente
+	}
+
+	abiertos := make([]models.PrestamoAbierto, 0, len(prestamos))
+	for _, prestamo := range prestamos {
+		if expediente, ok := porID[prestamo.ExpedienteID]; ok {
+			abiertos = append(abiertos, models.PrestamoAbierto{Prestamo: prestamo, Expediente: expediente})
+		}
+	}
+	return abiertos, nil
+}
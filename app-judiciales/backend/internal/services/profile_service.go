@@ -55,11 +55,18 @@ func (s *ProfileService) CreateProfile(ctx context.Context, req *models.CreatePr
 		return nil, errors.New("ya existe un perfil con este slug")
 	}
 
+	// Profiles without an explicit clearance can only read public expedientes
+	clearance := req.Clearance
+	if clearance == "" {
+		clearance = models.ClasificacionPublico
+	}
+
 	profile := &models.Profile{
 		Name:        req.Name,
 		Slug:        req.Slug,
 		Description: req.Description,
 		Permissions: validPerms, // Use only valid permissions
+		Clearance:   clearance,
 		IsSystem:    false,
 		Active:      true,
 		CreatedBy:   createdBy,
@@ -167,6 +174,10 @@ func (s *ProfileService) buildUpdateMap(req *models.UpdateProfileRequest) bson.M
 		update["description"] = req.Description
 	}
 
+	if req.Clearance != "" {
+		update["clearance"] = req.Clearance
+	}
+
 	return update
 }
 
@@ -0,0 +1,453 @@
+package services
+
+import (
+	"errors"
+)
+
+// ErrQRDemasiadoLargo is returned when the data does not fit in the QR versions supported for labels
+var ErrQRDemasiadoLargo = errors.New("los datos no caben en un código QR")
+
+// qrBloques describes the Reed-Solomon blocks of a QR version at error correction level M
+type qrBloques struct {
+	ecPorBloque int
+	grupos      [][2]int // {number of blocks, data codewords per block}
+}
+
+// qrNivelM lists the blocks of versions 1–10 at level M, which is enough for label identifiers
+var qrNivelM = [...]qrBloques{
+	{10, [][2]int{{1, 16}}},
+	{16, [][2]int{{1, 28}}},
+	{26, [][2]int{{1, 44}}},
+	{18, [][2]int{{2, 32}}},
+	{24, [][2]int{{2, 43}}},
+	{16, [][2]int{{4, 27}}},
+	{18, [][2]int{{4, 31}}},
+	{22, [][2]int{{2, 38}, {2, 39}}},
+	{22, [][2]int{{3, 36}, {2, 37}}},
+	{26, [][2]int{{4, 43}, {1, 44}}},
+}
+
+// qrAlineacion lists the alignment pattern centres of versions 1–10
+var qrAlineacion = [...][]int{
+	nil,
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// qrMatriz is a QR symbol being built; funcion marks the modules that hold no data
+type qrMatriz struct {
+	tam     int
+	modulos [][]bool
+	funcion [][]bool
+}
+
+// codificarQR encodes bytes as a QR code in byte mode at error correction level M, using the
+// smallest version that fits. It returns the dark modules by row, without the quiet zone.
+func codificarQR(datos []byte) ([][]bool, error) {
+	version := 0
+	for v := 1; v <= len(qrNivelM); v++ {
+		bitsCuenta := 8
+		if v >= 10 {
+			bitsCuenta = 16
+		}
+		if 4+bitsCuenta+8*len(datos) <= 8*qrNivelM[v-1].capacidad() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrQRDemasiadoLargo
+	}
+
+	palabras := qrEntrelazar(qrCodificarDatos(datos, version), qrNivelM[version-1])
+
+	m := newQRMatriz(version)
+	m.colocarDatos(palabras)
+
+	// Se elige la máscara con menor penalización, como indica la norma
+	mejor, mejorPenalizacion := 0, -1
+	for mascara := 0; mascara < 8; mascara++ {
+		m.aplicarMascara(mascara)
+		m.dibujarFormato(mascara)
+		if penalizacion := m.penalizacion(); mejorPenalizacion < 0 || penalizacion < mejorPenalizacion {
+			mejor, mejorPenalizacion = mascara, penalizacion
+		}
+		m.aplicarMascara(mascara)
+	}
+	m.aplicarMascara(mejor)
+	m.dibujarFormato(mejor)
+
+	return m.modulos, nil
+}
+
+// capacidad returns the number of data codewords of the version
+func (b qrBloques) capacidad() int {
+	total := 0
+	for _, grupo := range b.grupos {
+		total += grupo[0] * grupo[1]
+	}
+	return total
+}
+
+// qrCodificarDatos builds the data codewords: byte mode indicator, length, data, terminator and padding
+func qrCodificarDatos(datos []byte, version int) []byte {
+	capacidad := qrNivelM[version-1].capacidad()
+	bitsCuenta := 8
+	if version >= 10 {
+		bitsCuenta = 16
+	}
+
+	var bits []bool
+	agregar := func(valor, longitud int) {
+		for i := longitud - 1; i >= 0; i-- {
+			bits = append(bits, valor>>i&1 == 1)
+		}
+	}
+	agregar(0b0100, 4)
+	agregar(len(datos), bitsCuenta)
+	for _, b := range datos {
+		agregar(int(b), 8)
+	}
+	agregar(0, min(4, capacidad*8-len(bits)))
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	palabras := make([]byte, 0, capacidad)
+	for i := 0; i < len(bits); i += 8 {
+		var palabra byte
+		for _, bit := range bits[i : i+8] {
+			palabra <<= 1
+			if bit {
+				palabra |= 1
+			}
+		}
+		palabras = append(palabras, palabra)
+	}
+	for relleno := byte(0xEC); len(palabras) < capacidad; relleno ^= 0xEC ^ 0x11 {
+		palabras = append(palabras, relleno)
+	}
+	return palabras
+}
+
+// qrEntrelazar splits the data into blocks, adds their error correction codewords and interleaves them
+func qrEntrelazar(datos []byte, bloques qrBloques) []byte {
+	var bloquesDatos, bloquesEC [][]byte
+	for _, grupo := range bloques.grupos {
+		for i := 0; i < grupo[0]; i++ {
+			bloque := datos[:grupo[1]]
+			datos = datos[grupo[1]:]
+			bloquesDatos = append(bloquesDatos, bloque)
+			bloquesEC = append(bloquesEC, reedSolomon(bloque, bloques.ecPorBloque))
+		}
+	}
+
+	var resultado []byte
+	for i := 0; ; i++ {
+		agregado := false
+		for _, bloque := range bloquesDatos {
+			if i < len(bloque) {
+				resultado = append(resultado, bloque[i])
+				agregado = true
+			}
+		}
+		if !agregado {
+			break
+		}
+	}
+	for i := 0; i < bloques.ecPorBloque; i++ {
+		for _, bloque := range bloquesEC {
+			resultado = append(resultado, bloque[i])
+		}
+	}
+	return resultado
+}
+
+// gfMultiplicar multiplies two elements of GF(2^8) with the QR polynomial x^8+x^4+x^3+x^2+1
+func gfMultiplicar(a, b byte) byte {
+	var resultado byte
+	for ; b > 0; b >>= 1 {
+		if b&1 == 1 {
+			resultado ^= a
+		}
+		alto := a & 0x80
+		a <<= 1
+		if alto != 0 {
+			a ^= 0x1D
+		}
+	}
+	return resultado
+}
+
+// reedSolomon returns the error correction codewords of a block
+func reedSolomon(datos []byte, grado int) []byte {
+	// Polinomio generador (x - α^0)(x - α^1)...(x - α^(grado-1)) sin su término principal
+	generador := make([]byte, grado)
+	generador[grado-1] = 1
+	raiz := byte(1)
+	for i := 0; i < grado; i++ {
+		for j := range generador {
+			generador[j] = gfMultiplicar(generador[j], raiz)
+			if j+1 < grado {
+				generador[j] ^= generador[j+1]
+			}
+		}
+		raiz = gfMultiplicar(raiz, 2)
+	}
+
+	resto := make([]byte, grado)
+	for _, b := range datos {
+		factor := b ^ resto[0]
+		copy(resto, resto[1:])
+		resto[grado-1] = 0
+		for j := range resto {
+			resto[j] ^= gfMultiplicar(generador[j], factor)
+		}
+	}
+	return resto
+}
+
+// newQRMatriz creates the matrix of a version with its function patterns drawn and reserved
+func newQRMatriz(version int) *qrMatriz {
+	tam := 17 + 4*version
+	m := &qrMatriz{tam: tam, modulos: make([][]bool, tam), funcion: make([][]bool, tam)}
+	for i := range m.modulos {
+		m.modulos[i] = make([]bool, tam)
+		m.funcion[i] = make([]bool, tam)
+	}
+
+	// Patrones de temporización
+	for i := 0; i < tam; i++ {
+		m.fijar(6, i, i%2 == 0)
+		m.fijar(i, 6, i%2 == 0)
+	}
+
+	// Patrones de posición con su separador
+	for _, esquina := range [][2]int{{3, 3}, {3, tam - 4}, {tam - 4, 3}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				fila, col := esquina[0]+dy, esquina[1]+dx
+				if fila < 0 || fila >= tam || col < 0 || col >= tam {
+					continue
+				}
+				distancia := max(abs(dx), abs(dy))
+				m.fijar(fila, col, distancia != 2 && distancia != 4)
+			}
+		}
+	}
+
+	// Patrones de alineación, salvo los que coinciden con los de posición
+	centros := qrAlineacion[version-1]
+	for i, fila := range centros {
+		for j, col := range centros {
+			ultimo := len(centros) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == ultimo) || (i == ultimo && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					m.fijar(fila+dy, col+dx, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Se reservan las áreas de formato; se dibujan al elegir la máscara
+	m.dibujarFormato(0)
+
+	// Información de versión (versión 7 en adelante)
+	if version >= 7 {
+		resto := version
+		for i := 0; i < 12; i++ {
+			resto = resto<<1 ^ (resto>>11)*0x1F25
+		}
+		bits := version<<12 | resto
+		for i := 0; i < 18; i++ {
+			oscuro := bits>>i&1 == 1
+			a, b := tam-11+i%3, i/3
+			m.fijar(b, a, oscuro)
+			m.fijar(a, b, oscuro)
+		}
+	}
+
+	return m
+}
+
+// fijar sets a function module
+func (m *qrMatriz) fijar(fila, col int, oscuro bool) {
+	m.modulos[fila][col] = oscuro
+	m.funcion[fila][col] = true
+}
+
+// dibujarFormato draws both copies of the format information for level M and a mask
+func (m *qrMatriz) dibujarFormato(mascara int) {
+	datos := mascara // El nivel M se codifica como 00
+	resto := datos
+	for i := 0; i < 10; i++ {
+		resto = resto<<1 ^ (resto>>9)*0x537
+	}
+	bits := (datos<<10 | resto) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		m.fijar(i, 8, bit(i))
+	}
+	m.fijar(7, 8, bit(6))
+	m.fijar(8, 8, bit(7))
+	m.fijar(8, 7, bit(8))
+	for i := 9; i < 15; i++ {
+		m.fijar(8, 14-i, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		m.fijar(8, m.tam-1-i, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		m.fijar(m.tam-15+i, 8, bit(i))
+	}
+	m.fijar(m.tam-8, 8, true)
+}
+
+// colocarDatos places the codewords in the zigzag order of the standard
+func (m *qrMatriz) colocarDatos(palabras []byte) {
+	i := 0
+	for derecha := m.tam - 1; derecha >= 1; derecha -= 2 {
+		if derecha == 6 {
+			derecha = 5
+		}
+		for vertical := 0; vertical < m.tam; vertical++ {
+			for j := 0; j < 2; j++ {
+				col := derecha - j
+				fila := vertical
+				if (derecha+1)&2 == 0 {
+					fila = m.tam - 1 - vertical
+				}
+				if m.funcion[fila][col] || i >= len(palabras)*8 {
+					continue
+				}
+				m.modulos[fila][col] = palabras[i>>3]>>(7-i&7)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// aplicarMascara XORs a mask pattern over the data modules; applying it twice undoes it
+func (m *qrMatriz) aplicarMascara(mascara int) {
+	for fila := 0; fila < m.tam; fila++ {
+		for col := 0; col < m.tam; col++ {
+			if m.funcion[fila][col] {
+				continue
+			}
+			var invertir bool
+			switch mascara {
+			case 0:
+				invertir = (col+fila)%2 == 0
+			case 1:
+				invertir = fila%2 == 0
+			case 2:
+				invertir = col%3 == 0
+			case 3:
+				invertir = (col+fila)%3 == 0
+			case 4:
+				invertir = (col/3+fila/2)%2 == 0
+			case 5:
+				invertir = col*fila%2+col*fila%3 == 0
+			case 6:
+				invertir = (col*fila%2+col*fila%3)%2 == 0
+			case 7:
+				invertir = ((col+fila)%2+col*fila%3)%2 == 0
+			}
+			m.modulos[fila][col] = m.modulos[fila][col] != invertir
+		}
+	}
+}
+
+// penalizacion scores a masked symbol with the four penalty rules of the standard
+func (m *qrMatriz) penalizacion() int {
+	total := 0
+	linea := func(obtener func(i int) bool) {
+		racha, color := 0, false
+		historial := make([]bool, 0, m.tam)
+		for i := 0; i < m.tam; i++ {
+			oscuro := obtener(i)
+			historial = append(historial, oscuro)
+			if i > 0 && oscuro == color {
+				racha++
+			} else {
+				if racha >= 5 {
+					total += racha - 2
+				}
+				racha, color = 1, oscuro
+			}
+		}
+		if racha >= 5 {
+			total += racha - 2
+		}
+
+		// Patrones parecidos a los de posición (1:1:3:1:1 con cuatro módulos claros a un lado)
+		patron := []bool{true, false, true, true, true, false, true}
+		for i := 0; i+7 <= len(historial); i++ {
+			coincide := true
+			for k, v := range patron {
+				if historial[i+k] != v {
+					coincide = false
+					break
+				}
+			}
+			if !coincide {
+				continue
+			}
+			if qrClaro(historial, i-4, i) || qrClaro(historial, i+7, i+11) {
+				total += 40
+			}
+		}
+	}
+
+	oscuros := 0
+	for fila := 0; fila < m.tam; fila++ {
+		linea(func(i int) bool { return m.modulos[fila][i] })
+		linea(func(i int) bool { return m.modulos[i][fila] })
+		for col := 0; col < m.tam; col++ {
+			if m.modulos[fila][col] {
+				oscuros++
+			}
+			if fila+1 < m.tam && col+1 < m.tam {
+				c := m.modulos[fila][col]
+				if c == m.modulos[fila][col+1] && c == m.modulos[fila+1][col] && c == m.modulos[fila+1][col+1] {
+					total += 3
+				}
+			}
+		}
+	}
+
+	modulos := m.tam * m.tam
+	desvio := abs(oscuros*20 - modulos*10)
+	total += ((desvio+modulos-1)/modulos - 1) * 10
+	return total
+}
+
+// qrClaro reports whether the modules of a line in [desde, hasta) are light; outside the symbol counts as light
+func qrClaro(linea []bool, desde, hasta int) bool {
+	for i := desde; i < hasta; i++ {
+		if i >= 0 && i < len(linea) && linea[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// qrFormatoM are the format information bits of level M for masks 0–7, from the table of the standard
+var qrFormatoM = [8]int{0x5412, 0x5125, 0x5E7C, 0x5B4B, 0x45F9, 0x40CE, 0x4F97, 0x4AA0}
+
+// qrInfoVersion are the version information bits of versions 7–10, from the table of the standard
+var qrInfoVersion = map[int]int{7: 0x07C94, 8: 0x085BC, 9: 0x09A99, 10: 0x0A4D3}
+
+func TestGFMultiplicar(t *testing.T) {
+	tests := []struct{ a, b, want byte }{
+		{0, 0x53, 0},
+		{1, 0x53, 0x53},
+		{2, 0x80, 0x1D}, // α^8 = α^4 + α^3 + α^2 + 1
+	}
+	for _, tt := range tests {
+		if got := gfMultiplicar(tt.a, tt.b); got != tt.want {
+			t.Errorf("gfMultiplicar(%#x, %#x) = %#x, want %#x", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	// α has order 255
+	alfa := byte(1)
+	for i := 1; i <= 255; i++ {
+		alfa = gfMultiplicar(alfa, 2)
+		if alfa == 1 && i < 255 {
+			t.Fatalf("α^%d = 1", i)
+		}
+	}
+	if alfa != 1 {
+		t.Errorf("α^255 = %#x, want 1", alfa)
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	tests := []struct {
+		nombre string
+		datos  []byte
+		ec     []byte
+	}{
+		{
+			// "01234567" en versión 1-M, ejemplo del anexo I de ISO/IEC 18004
+			nombre: "01234567",
+			datos:  []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11},
+			ec:     []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55},
+		},
+		{
+			// "HELLO WORLD" en versión 1-M, modo alfanumérico
+			nombre: "HELLO WORLD",
+			datos:  []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17},
+			ec:     []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23},
+		},
+	}
+	for _, tt := range tests {
+		if got := reedSolomon(tt.datos, len(tt.ec)); !bytes.Equal(got, tt.ec) {
+			t.Errorf("%s: reedSolomon = %v, want %v", tt.nombre, got, tt.ec)
+		}
+	}
+}
+
+func TestQRCodificarDatos(t *testing.T) {
+	tests := []struct {
+		datos   string
+		version int
+		want    []byte
+	}{
+		// 0100 | 00000001 | 01000001 | 0000, then the 0xEC 0x11 padding
+		{"A", 1, []byte{0x40, 0x14, 0x10, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC}},
+		// Version 10 uses a 16-bit character count
+		{"A", 10, append([]byte{0x40, 0x00, 0x14, 0x10}, bytes.Repeat([]byte{0xEC, 0x11}, 106)...)},
+		// 14 bytes fill version 1 exactly: 4 + 8 + 112 bits leave room for the terminator only
+		{"ABCDEFGHIJKLMN", 1, []byte{0x40, 0xE4, 0x14, 0x24, 0x34, 0x44, 0x54, 0x64, 0x74, 0x84, 0x94, 0xA4, 0xB4, 0xC4, 0xD4, 0xE0}},
+	}
+	for _, tt := range tests {
+		if got := qrCodificarDatos([]byte(tt.datos), tt.version); !bytes.Equal(got, tt.want) {
+			t.Errorf("qrCodificarDatos(%q, %d) = % x, want % x", tt.datos, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestQRMatrizFormato(t *testing.T) {
+	for mascara, want := range qrFormatoM {
+		m := newQRMatriz(1)
+		m.dibujarFormato(mascara)
+		primera, segunda := leerFormatoQR(m.modulos)
+		if primera != want || segunda != want {
+			t.Errorf("máscara %d: formato %015b y %015b, want %015b", mascara, primera, segunda, want)
+		}
+	}
+}
+
+func TestCodificarQR(t *testing.T) {
+	tests := []struct {
+		datos   string
+		version int
+	}{
+		{"A", 1},
+		{"EXP-00012345", 1},
+		{strings.Repeat("x", 14), 1},
+		{strings.Repeat("x", 15), 2},
+		{"https://archivo.example/expedientes/6553f0c2a1b2c3d4e5f60718", 4},
+		{strings.Repeat("0123456789", 12), 7},
+		{strings.Repeat("Tomo 3 – División A", 6), 8},
+		{strings.Repeat("y", 180), 9},
+		{strings.Repeat("z", 213), 10},
+	}
+	for _, tt := range tests {
+		modulos, err := codificarQR([]byte(tt.datos))
+		if err != nil {
+			t.Errorf("codificarQR(%d bytes): %v", len(tt.datos), err)
+			continue
+		}
+		if tam := 17 + 4*tt.version; len(modulos) != tam {
+			t.Errorf("codificarQR(%d bytes): %d módulos de lado, want %d (versión %d)", len(tt.datos), len(modulos), tam, tt.version)
+			continue
+		}
+		comprobarPatronesQR(t, modulos, tt.version)
+
+		if got, err := leerQR(modulos, tt.version); err != nil {
+			t.Errorf("codificarQR(%d bytes): %v", len(tt.datos), err)
+		} else if string(got) != tt.datos {
+			t.Errorf("codificarQR(%d bytes) decodes to %q", len(tt.datos), got)
+		}
+	}
+}
+
+func TestCodificarQRDemasiadoLargo(t *testing.T) {
+	if _, err := codificarQR(bytes.Repeat([]byte("z"), 214)); !errors.Is(err, ErrQRDemasiadoLargo) {
+		t.Errorf("codificarQR(214 bytes) error = %v, want %v", err, ErrQRDemasiadoLargo)
+	}
+}
+
+func TestQRPenalizacion(t *testing.T) {
+	claro := newQRMatriz(1)
+	ajedrez := newQRMatriz(1)
+	for fila := range claro.modulos {
+		for col := range claro.modulos[fila] {
+			claro.modulos[fila][col] = false
+			ajedrez.modulos[fila][col] = (fila+col)%2 == 0
+		}
+	}
+
+	// All light: a run of 21 in each of the 42 lines (19 each), 400 2×2 blocks (3 each) and
+	// 0% dark modules (90)
+	if got := claro.penalizacion(); got != 42*19+400*3+90 {
+		t.Errorf("penalización de un símbolo claro = %d, want %d", got, 42*19+400*3+90)
+	}
+	// A checkerboard has no runs, no blocks, no finder-like patterns and is balanced
+	if got := ajedrez.penalizacion(); got != 0 {
+		t.Errorf("penalización de un tablero de ajedrez = %d, want 0", got)
+	}
+}
+
+// leerFormatoQR reads both copies of the format information as 15-bit values
+func leerFormatoQR(modulos [][]bool) (primera, segunda int) {
+	tam := len(modulos)
+	bit := func(fila, col, i int) int {
+		if modulos[fila][col] {
+			return 1 << i
+		}
+		return 0
+	}
+	for i := 0; i < 15; i++ {
+		switch {
+		case i < 6:
+			primera |= bit(i, 8, i)
+		case i == 6:
+			primera |= bit(7, 8, i)
+		case i == 7:
+			primera |= bit(8, 8, i)
+		case i == 8:
+			primera |= bit(8, 7, i)
+		default:
+			primera |= bit(8, 14-i, i)
+		}
+		if i < 8 {
+			segunda |= bit(8, tam-1-i, i)
+		} else {
+			segunda |= bit(tam-15+i, 8, i)
+		}
+	}
+	return primera, segunda
+}
+
+// comprobarPatronesQR checks the finder, timing and alignment patterns, the dark module and
+// the version information of a symbol
+func comprobarPatronesQR(t *testing.T, modulos [][]bool, version int) {
+	t.Helper()
+	tam := len(modulos)
+	esperar := func(fila, col int, oscuro bool, que string) {
+		if modulos[fila][col] != oscuro {
+			t.Errorf("versión %d: %s en (%d, %d) es %v, want %v", version, que, fila, col, modulos[fila][col], oscuro)
+		}
+	}
+
+	for _, esquina := range [][2]int{{0, 0}, {0, tam - 7}, {tam - 7, 0}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				anillo := min(dy, dx, 6-dy, 6-dx)
+				esperar(esquina[0]+dy, esquina[1]+dx, anillo != 1, "patrón de posición")
+			}
+		}
+	}
+	for i := 8; i < tam-8; i++ {
+		esperar(6, i, i%2 == 0, "temporización")
+		esperar(i, 6, i%2 == 0, "temporización")
+	}
+	esperar(tam-8, 8, true, "módulo oscuro")
+
+	if version >= 2 {
+		centro := tam - 7
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				esperar(centro+dy, centro+dx, max(abs(dy), abs(dx)) != 1, "patrón de alineación")
+			}
+		}
+	}
+
+	if want, ok := qrInfoVersion[version]; ok {
+		var arriba, abajo int
+		for i := 0; i < 18; i++ {
+			if modulos[i/3][tam-11+i%3] {
+				arriba |= 1 << i
+			}
+			if modulos[tam-11+i%3][i/3] {
+				abajo |= 1 << i
+			}
+		}
+		if arriba != want || abajo != want {
+			t.Errorf("versión %d: información de versión %018b y %018b, want %018b", version, arriba, abajo, want)
+		}
+	}
+
+	primera, segunda := leerFormatoQR(modulos)
+	if primera != segunda || !slices.Contains(qrFormatoM[:], primera) {
+		t.Errorf("versión %d: información de formato %015b y %015b no es de nivel M", version, primera, segunda)
+	}
+}
+
+// leerQR decodes a byte mode symbol at level M: it reads the mask from the format
+// information, unmasks and reads the codewords in zigzag order, checks the Reed-Solomon
+// syndromes of every block and returns the data
+func leerQR(modulos [][]bool, version int) ([]byte, error) {
+	tam := len(modulos)
+	formato, _ := leerFormatoQR(modulos)
+	mascara := slices.Index(qrFormatoM[:], formato)
+	if mascara < 0 {
+		return nil, errors.New("información de formato inválida")
+	}
+	mascaras := [8]func(fila, col int) bool{
+		func(i, j int) bool { return (i+j)%2 == 0 },
+		func(i, j int) bool { return i%2 == 0 },
+		func(i, j int) bool { return j%3 == 0 },
+		func(i, j int) bool { return (i+j)%3 == 0 },
+		func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+		func(i, j int) bool { return i*j%2+i*j%3 == 0 },
+		func(i, j int) bool { return (i*j%2+i*j%3)%2 == 0 },
+		func(i, j int) bool { return (i*j%3+(i+j)%2)%2 == 0 },
+	}
+
+	// Los módulos de datos se recorren en columnas de dos, de derecha a izquierda, alternando
+	// hacia arriba y hacia abajo y saltando la columna de temporización
+	funcion := newQRMatriz(version).funcion
+	var bits []bool
+	subiendo := true
+	for derecha := tam - 1; derecha > 0; derecha -= 2 {
+		if derecha == 6 {
+			derecha--
+		}
+		for k := 0; k < tam; k++ {
+			fila := k
+			if subiendo {
+				fila = tam - 1 - k
+			}
+			for _, col := range []int{derecha, derecha - 1} {
+				if !funcion[fila][col] {
+					bits = append(bits, modulos[fila][col] != mascaras[mascara](fila, col))
+				}
+			}
+		}
+		subiendo = !subiendo
+	}
+	palabras := make([]byte, len(bits)/8)
+	for i := range palabras {
+		for _, bit := range bits[8*i : 8*i+8] {
+			palabras[i] <<= 1
+			if bit {
+				palabras[i] |= 1
+			}
+		}
+	}
+
+	// Se separan los bloques entrelazados
+	bloques := qrNivelM[version-1]
+	var datos [][]byte
+	for _, grupo := range bloques.grupos {
+		for i := 0; i < grupo[0]; i++ {
+			datos = append(datos, make([]byte, 0, grupo[1]+bloques.ecPorBloque))
+		}
+	}
+	n := 0
+	for i := 0; ; i++ {
+		agregado := false
+		for b, grupo := 0, 0; grupo < len(bloques.grupos); grupo++ {
+			for k := 0; k < bloques.grupos[grupo][0]; k, b = k+1, b+1 {
+				if i < bloques.grupos[grupo][1] {
+					datos[b] = append(datos[b], palabras[n])
+					n++
+					agregado = true
+				}
+			}
+		}
+		if !agregado {
+			break
+		}
+	}
+	var mensaje []byte
+	for _, bloque := range datos {
+		mensaje = append(mensaje, bloque...)
+	}
+	for i := 0; i < bloques.ecPorBloque; i++ {
+		for b := range datos {
+			datos[b] = append(datos[b], palabras[n])
+			n++
+		}
+	}
+
+	// Un bloque correcto se anula en α^0 ... α^(ec-1)
+	for b, bloque := range datos {
+		raiz := byte(1)
+		for i := 0; i < bloques.ecPorBloque; i++ {
+			var sindrome byte
+			for _, palabra := range bloque {
+				sindrome = gfMultiplicar(sindrome, raiz) ^ palabra
+			}
+			if sindrome != 0 {
+				return nil, fmt.Errorf("síndrome distinto de cero en el bloque %d", b)
+			}
+			raiz = gfMultiplicar(raiz, 2)
+		}
+	}
+
+	// Modo byte: indicador 0100 y longitud de 8 bits (16 desde la versión 10)
+	leer := func(desde, longitud int) int {
+		valor := 0
+		for i := desde; i < desde+longitud; i++ {
+			valor <<= 1
+			if mensaje[i/8]>>(7-i%8)&1 == 1 {
+				valor |= 1
+			}
+		}
+		return valor
+	}
+	if leer(0, 4) != 0b0100 {
+		return nil, errors.New("el modo no es byte")
+	}
+	bitsCuenta := 8
+	if version >= 10 {
+		bitsCuenta = 16
+	}
+	longitud := leer(4, bitsCuenta)
+	resultado := make([]byte, longitud)
+	for i := range resultado {
+		resultado[i] = byte(leer(4+bitsCuenta+8*i, 8))
+	}
+	return resultado, nil
+}
@@ -0,0 +1,497 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Rebalancing errors
+var (
+	ErrPlanDesactualizado = errors.New("las divisiones cambiaron desde que se generó el plan; genere uno nuevo")
+)
+
+// RebalanceoService proposes and applies new division ranges that even out shelf occupancy
+type RebalanceoService struct {
+	archivoRepo    *repository.ArchivoRepository
+	expedienteRepo *repository.ExpedienteRepository
+	auditRepo      *repository.AuditRepository
+}
+
+// NewRebalanceoService creates a new rebalancing service
+func NewRebalanceoService(archivoRepo *repository.ArchivoRepository, expedienteRepo *repository.ExpedienteRepository, auditRepo *repository.AuditRepository) *RebalanceoService {
+	return &RebalanceoService{
+		archivoRepo:    archivoRepo,
+		expedienteRepo: expedienteRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// cargaUbicacion is the load stored under one ubicación code
+type cargaUbicacion struct {
+	codigo      string
+	expedientes int64
+	paginas     int64
+}
+
+// GenerarPlan computes new ranges for every group of divisions that hold the same grados
+// and situación, and stores the resulting plan with the list of expedientes that move
+func (s *RebalanceoService) GenerarPlan(scope models.AccessScope) (*models.PlanRebalanceo, error) {
+	divisiones, err := s.archivoRepo.GetDivisiones(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &models.PlanRebalanceo{
+		Estado:      models.PlanPropuesto,
+		Divisiones:  []models.RebalanceoDivision{},
+		Movimientos: []models.MovimientoPlan{},
+		CreadoPor:   scope.UserID,
+		CreadoEn:    time.Now(),
+	}
+
+	propuestas := make([]*models.Division, 0, len(divisiones))
+	for _, grupo := range agruparDivisiones(divisiones) {
+		nuevas, err := s.planificarGrupo(grupo, plan)
+		if err != nil {
+			return nil, err
+		}
+		propuestas = append(propuestas, nuevas...)
+	}
+
+	// Groups with partially shared grados must still not overlap once every group moves
+	for i := range propuestas {
+		for j := i + 1; j < len(propuestas); j++ {
+			if divisionesSolapan(propuestas[i], propuestas[j]) {
+				return nil, fmt.Errorf("%w: división %d (%s) y división %d (%s)", ErrRangoSolapado,
+					propuestas[i].Numero, propuestas[i].Rango(), propuestas[j].Numero, propuestas[j].Rango())
+			}
+		}
+	}
+
+	if err := s.archivoRepo.CreatePlan(plan); err != nil {
+		return nil, err
+	}
+
+	log.Printf("🗄️ Plan de rebalanceo %s generado: %d divisiones, %d movimientos", plan.ID.Hex(), len(plan.Divisiones), len(plan.Movimientos))
+	storeClassifiedAccess(s.auditRepo, reservarMovimientos(plan.Movimientos, "plan_rebalanceo", scope))
+	return plan, nil
+}
+
+// agruparDivisiones groups divisions that share the same grados and situación, sorted by range
+func agruparDivisiones(divisiones []*models.Division) [][]*models.Division {
+	grupos := make(map[string][]*models.Division)
+	var claves []string
+	for _, division := range divisiones {
+		grados := make([]string, len(division.Grados))
+		for i, g := range division.Grados {
+			grados[i] = string(g)
+		}
+		sort.Strings(grados)
+		clave := string(division.Situacion) + "|" + strings.Join(grados, ",")
+		if _, ok := grupos[clave]; !ok {
+			claves = append(claves, clave)
+		}
+		grupos[clave] = append(grupos[clave], division)
+	}
+
+	resultado := make([][]*models.Division, 0, len(claves))
+	for _, clave := range claves {
+		grupo := grupos[clave]
+		sort.Slice(grupo, func(i, j int) bool { return models.CompararUbicacion(grupo[i].RangoInicio, grupo[j].RangoInicio) < 0 })
+		resultado = append(resultado, grupo)
+	}
+	return resultado
+}
+
+// planificarGrupo partitions the ubicación codes of a group among its divisions, appends the
+// divisions and moves to the plan and returns the divisions with their proposed ranges
+func (s *RebalanceoService) planificarGrupo(grupo []*models.Division, plan *models.PlanRebalanceo) ([]*models.Division, error) {
+	inicio, fin := grupo[0].RangoInicio, grupo[0].RangoFin
+	for _, division := range grupo {
+		if models.CompararUbicacion(division.RangoFin, fin) > 0 {
+			fin = division.RangoFin
+		}
+	}
+
+	expedientes, err := s.expedienteRepo.GetUbicacionesByDivision(&models.Division{
+		RangoInicio: inicio,
+		RangoFin:    fin,
+		Grados:      grupo[0].Grados,
+		Situacion:   grupo[0].Situacion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cargas := cargasPorCodigo(inicio, fin, expedientes)
+	if len(grupo) < 2 || len(cargas) < len(grupo) {
+		// Nothing to balance: keep the current ranges
+		return copiarDivisiones(grupo), nil
+	}
+
+	inicios := particionar(grupo, cargas)
+	nuevas := make([]*models.Division, len(grupo))
+	indiceCodigo := make(map[string]int, len(cargas))
+	for j, division := range grupo {
+		desde := inicios[j]
+		hasta := len(cargas)
+		if j+1 < len(grupo) {
+			hasta = inicios[j+1]
+		}
+		for k := desde; k < hasta; k++ {
+			indiceCodigo[cargas[k].codigo] = j
+		}
+
+		nueva := *division
+		nueva.RangoInicio = cargas[desde].codigo
+		nueva.RangoFin = cargas[hasta-1].codigo
+		nuevas[j] = &nueva
+	}
+
+	antes := make([]models.OcupacionDivision, len(grupo))
+	despues := make([]models.OcupacionDivision, len(grupo))
+	for _, expediente := range expedientes {
+		origen := -1
+		for j, division := range grupo {
+			if division.Contiene(expediente.Ubicacion) {
+				origen = j
+				break
+			}
+		}
+		destino := indiceCodigo[expediente.Ubicacion]
+
+		if origen >= 0 {
+			antes[origen].Expedientes++
+			antes[origen].Paginas += int64(expediente.NumeroPaginas)
+		}
+		despues[destino].Expedientes++
+		despues[destino].Paginas += int64(expediente.NumeroPaginas)
+
+		if origen == destino {
+			continue
+		}
+		movimiento := models.MovimientoPlan{
+			ExpedienteID:     expediente.ID,
+			CIP:              expediente.CIP,
+			ApellidosNombres: expediente.ApellidosNombres,
+			Grado:            expediente.Grado,
+			Ubicacion:        expediente.Ubicacion,
+			DivisionDestino:  grupo[destino].Numero,
+			Clasificacion:    expediente.Clasificacion,
+		}
+		if origen >= 0 {
+			movimiento.DivisionOrigen = grupo[origen].Numero
+		}
+		plan.Movimientos = append(plan.Movimientos, movimiento)
+	}
+
+	for j, division := range grupo {
+		plan.Divisiones = append(plan.Divisiones, models.RebalanceoDivision{
+			DivisionID:        division.ID,
+			Numero:            division.Numero,
+			Capacidad:         division.Capacidad,
+			UnidadCapacidad:   division.UnidadCapacidad,
+			RangoInicioActual: division.RangoInicio,
+			RangoFinActual:    division.RangoFin,
+			RangoInicioNuevo:  nuevas[j].RangoInicio,
+			RangoFinNuevo:     nuevas[j].RangoFin,
+			ExpedientesActual: antes[j].Expedientes,
+			ExpedientesNuevo:  despues[j].Expedientes,
+			PorcentajeActual:  calcularOcupacion(division, antes[j]).Porcentaje,
+			PorcentajeNuevo:   calcularOcupacion(nuevas[j], despues[j]).Porcentaje,
+		})
+	}
+
+	return nuevas, nil
+}
+
+// copiarDivisiones returns copies of the divisions so proposed ranges never alias the originals
+func copiarDivisiones(divisiones []*models.Division) []*models.Division {
+	copias := make([]*models.Division, len(divisiones))
+	for i, division := range divisiones {
+		copia := *division
+		copias[i] = &copia
+	}
+	return copias
+}
+
+// cargasPorCodigo returns the load of every ubicación code between inicio and fin, sorted.
+// Empty two-letter codes are included so the proposed ranges leave no gaps for future records.
+func cargasPorCodigo(inicio, fin string, expedientes []models.ExpedienteUbicacion) []cargaUbicacion {
+	porCodigo := make(map[string]*cargaUbicacion)
+	for _, codigo := range codigosEntre(inicio, fin) {
+		porCodigo[codigo] = &cargaUbicacion{codigo: codigo}
+	}
+	for _, expediente := range expedientes {
+		carga, ok := porCodigo[expediente.Ubicacion]
+		if !ok {
+			carga = &cargaUbicacion{codigo: expediente.Ubicacion}
+			porCodigo[expediente.Ubicacion] = carga
+		}
+		carga.expedientes++
+		carga.paginas += int64(expediente.NumeroPaginas)
+	}
+
+	cargas := make([]cargaUbicacion, 0, len(porCodigo))
+	for _, carga := range porCodigo {
+		cargas = append(cargas, *carga)
+	}
+	sort.Slice(cargas, func(i, j int) bool { return models.CompararUbicacion(cargas[i].codigo, cargas[j].codigo) < 0 })
+	return cargas
+}
+
+// codigosEntre enumerates the two-letter codes of the archive alphabet between inicio and
+// fin inclusive. Bounds in any other form only contribute themselves.
+func codigosEntre(inicio, fin string) []string {
+	a, b := []rune(inicio), []rune(fin)
+	if len(a) != 2 || len(b) != 2 || !esLetraArchivo(a[0]) || !esLetraArchivo(a[1]) || !esLetraArchivo(b[0]) || !esLetraArchivo(b[1]) {
+		return []string{inicio, fin}
+	}
+
+	var codigos []string
+	for _, primera := range models.AlfabetoArchivo {
+		for _, segunda := range models.AlfabetoArchivo {
+			codigo := string([]rune{primera, segunda})
+			if models.CompararUbicacion(codigo, inicio) >= 0 && models.CompararUbicacion(codigo, fin) <= 0 {
+				codigos = append(codigos, codigo)
+			}
+		}
+	}
+	return codigos
+}
+
+// esLetraArchivo reports whether r is a letter of the archive alphabet
+func esLetraArchivo(r rune) bool {
+	return strings.ContainsRune(models.AlfabetoArchivo, r)
+}
+
+// cargaEn expresses the load of a code in the capacity unit of a division
+func cargaEn(division *models.Division, carga cargaUbicacion) float64 {
+	if division.UnidadCapacidad == models.UnidadCentimetros {
+		return float64(carga.paginas) * models.CentimetrosPorHoja
+	}
+	return float64(carga.expedientes)
+}
+
+// particionar splits the codes into consecutive runs, one per division, and returns the index of
+// the first code of each division. Each division is filled up to the lowest ratio under which the
+// divisions still ahead can hold the rest, so the fullest division is as empty as possible and the
+// remaining load keeps being spread evenly.
+func particionar(divisiones []*models.Division, cargas []cargaUbicacion) []int {
+	inicios := make([]int, len(divisiones))
+	i := 0
+	for j := range divisiones {
+		inicios[j] = i
+		if j+1 < len(divisiones) {
+			resto := asignar(divisiones[j:], cargas[i:], ratioMinimo(divisiones[j:], cargas[i:]))
+			i += resto[1]
+		}
+	}
+	return inicios
+}
+
+// ratioMinimo searches the lowest fill ratio for which asignar succeeds
+func ratioMinimo(divisiones []*models.Division, cargas []cargaUbicacion) float64 {
+	minCapacidad := float64(divisiones[0].Capacidad)
+	for _, division := range divisiones {
+		if float64(division.Capacidad) < minCapacidad {
+			minCapacidad = float64(division.Capacidad)
+		}
+	}
+	total := 0.0
+	for _, carga := range cargas {
+		maxima := 0.0
+		for _, division := range divisiones {
+			if c := cargaEn(division, carga); c > maxima {
+				maxima = c
+			}
+		}
+		total += maxima
+	}
+
+	bajo, alto := 0.0, total/minCapacidad+1
+	for iteracion := 0; iteracion < 60; iteracion++ {
+		medio := (bajo + alto) / 2
+		if asignar(divisiones, cargas, medio) != nil {
+			alto = medio
+		} else {
+			bajo = medio
+		}
+	}
+	return alto
+}
+
+// asignar packs consecutive codes into each division without exceeding the fill ratio, leaving
+// at least one code for every remaining division. It returns nil when the codes do not fit.
+func asignar(divisiones []*models.Division, cargas []cargaUbicacion, ratio float64) []int {
+	inicios := make([]int, len(divisiones))
+	i := 0
+	for j, division := range divisiones {
+		inicios[j] = i
+		limite := ratio * float64(division.Capacidad)
+		restantes := len(divisiones) - j - 1
+		ultima := restantes == 0
+
+		llenado := 0.0
+		tomados := 0
+		for i < len(cargas) && len(cargas)-i > restantes {
+			carga := cargaEn(division, cargas[i])
+			if tomados > 0 && !ultima && llenado+carga > limite {
+				break
+			}
+			llenado += carga
+			tomados++
+			i++
+		}
+
+		if llenado > limite {
+			return nil
+		}
+	}
+	return inicios
+}
+
+// deshacerPlan rolls back a partially applied plan: restores the ranges of the divisions
+// already updated and returns the plan to the proposed state
+func (s *RebalanceoService) deshacerPlan(planID primitive.ObjectID, actualizadas []models.RebalanceoDivision) {
+	for _, propuesta := range actualizadas {
+		if err := s.archivoRepo.UpdateDivision(propuesta.DivisionID.Hex(), map[string]interface{}{
+			"rango_inicio": propuesta.RangoInicioActual,
+			"rango_fin":    propuesta.RangoFinActual,
+		}); err != nil {
+			log.Printf("⚠️ Error restaurando la división %d del plan %s: %v", propuesta.Numero, planID.Hex(), err)
+		}
+	}
+
+	if err := s.archivoRepo.ReabrirPlan(planID); err != nil {
+		log.Printf("⚠️ Error reabriendo el plan %s: %v", planID.Hex(), err)
+	}
+}
+
+// GetPlan returns a rebalancing plan by ID, with the moves above the caller's clearance reserved
+func (s *RebalanceoService) GetPlan(id string, operacion string, scope models.AccessScope) (*models.PlanRebalanceo, error) {
+	plan, err := s.archivoRepo.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reservarPlan(plan, operacion, scope); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// reservarPlan reads the current classification of every moved expediente, hides the identities
+// the caller may not see and logs the classified ones it shows
+func (s *RebalanceoService) reservarPlan(plan *models.PlanRebalanceo, operacion string, scope models.AccessScope) error {
+	ids := make([]primitive.ObjectID, len(plan.Movimientos))
+	for i, movimiento := range plan.Movimientos {
+		ids[i] = movimiento.ExpedienteID
+	}
+	clasificaciones, err := clasificacionesActuales(s.expedienteRepo, ids)
+	if err != nil {
+		return err
+	}
+	for i := range plan.Movimientos {
+		plan.Movimientos[i].Clasificacion = clasificaciones[plan.Movimientos[i].ExpedienteID]
+	}
+
+	storeClassifiedAccess(s.auditRepo, reservarMovimientos(plan.Movimientos, operacion, scope))
+	return nil
+}
+
+// reservarMovimientos replaces the CIP and name of the moves above the caller's clearance, which
+// still count towards the plan, and returns the access log entries of the classified moves it shows
+func reservarMovimientos(movimientos []models.MovimientoPlan, operacion string, scope models.AccessScope) []models.AuditLog {
+	var entries []models.AuditLog
+	for i := range movimientos {
+		movimiento := &movimientos[i]
+		if !scope.CanReadExpediente(movimiento.ExpedienteID, movimiento.Clasificacion) {
+			movimiento.CIP = models.IdentidadReservada
+			movimiento.ApellidosNombres = models.IdentidadReservada
+			continue
+		}
+		if entry, ok := classifiedAccessEntry(scope, operacion, movimiento.ExpedienteID, movimiento.CIP, movimiento.Clasificacion); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// AplicarPlan updates the division ranges of a proposed plan and records the relocation
+// of every moved expediente in the audit log. Marking the plan first keeps it from being
+// applied twice at once; if a range cannot be written, the ranges already changed are
+// restored and the plan is proposed again so it can be retried.
+func (s *RebalanceoService) AplicarPlan(id string, scope models.AccessScope) (*models.PlanRebalanceo, error) {
+	plan, err := s.archivoRepo.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Estado == models.PlanAplicado {
+		return nil, repository.ErrPlanAplicado
+	}
+
+	// The plan is only valid for the ranges it was computed from
+	for _, propuesta := range plan.Divisiones {
+		division, err := s.archivoRepo.GetDivision(propuesta.DivisionID.Hex())
+		if err != nil {
+			if errors.Is(err, repository.ErrDivisionNotFound) {
+				return nil, ErrPlanDesactualizado
+			}
+			return nil, err
+		}
+		if division.RangoInicio != propuesta.RangoInicioActual || division.RangoFin != propuesta.RangoFinActual {
+			return nil, ErrPlanDesactualizado
+		}
+	}
+
+	if err := s.archivoRepo.MarkPlanAplicado(plan.ID, scope.UserID); err != nil {
+		return nil, err
+	}
+
+	var actualizadas []models.RebalanceoDivision
+	for _, propuesta := range plan.Divisiones {
+		if propuesta.RangoInicioNuevo == propuesta.RangoInicioActual && propuesta.RangoFinNuevo == propuesta.RangoFinActual {
+			continue
+		}
+		if err := s.archivoRepo.UpdateDivision(propuesta.DivisionID.Hex(), map[string]interface{}{
+			"rango_inicio": propuesta.RangoInicioNuevo,
+			"rango_fin":    propuesta.RangoFinNuevo,
+		}); err != nil {
+			s.deshacerPlan(plan.ID, actualizadas)
+			return nil, fmt.Errorf("error actualizando división %d: %w", propuesta.Numero, err)
+		}
+		actualizadas = append(actualizadas, propuesta)
+	}
+
+	entries := make([]models.AuditLog, 0, len(plan.Movimientos))
+	for _, movimiento := range plan.Movimientos {
+		entries = append(entries, models.AuditLog{
+			UsuarioID: scope.UserID.Hex(),
+			Usuario:   scope.Email,
+			Accion:    models.AccionReubicacion,
+			Recurso:   models.RecursoExpediente,
+			RecursoID: movimiento.ExpedienteID.Hex(),
+			IP:        scope.IP,
+			Detalles: map[string]interface{}{
+				"plan_id":          plan.ID.Hex(),
+				"cip":              movimiento.CIP,
+				"division_origen":  movimiento.DivisionOrigen,
+				"division_destino": movimiento.DivisionDestino,
+			},
+		})
+	}
+	if err := s.auditRepo.LogMany(entries); err != nil {
+		log.Printf("⚠️ Error registrando reubicaciones del plan %s: %v", plan.ID.Hex(), err)
+	}
+
+	log.Printf("🗄️ Plan de rebalanceo %s aplicado por %s: %d movimientos", plan.ID.Hex(), scope.Email, len(plan.Movimientos))
+	return s.GetPlan(id, "plan_rebalanceo", scope)
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"expedientes-backend/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func movimientosPrueba() []models.MovimientoPlan {
+	return []models.MovimientoPlan{
+		{ExpedienteID: primitive.NewObjectID(), CIP: "00012345", ApellidosNombres: "GARCIA PEREZ, Juan", Clasificacion: models.ClasificacionPublico},
+		{ExpedienteID: primitive.NewObjectID(), CIP: "00054321", ApellidosNombres: "QUISPE MAMANI, Rosa", Clasificacion: models.ClasificacionSecreto},
+	}
+}
+
+func TestReservarMovimientos(t *testing.T) {
+	publico := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionPublico}
+	movimientos := movimientosPrueba()
+	entries := reservarMovimientos(movimientos, "plan_rebalanceo", publico)
+
+	if movimientos[0].CIP != "00012345" || movimientos[0].ApellidosNombres != "GARCIA PEREZ, Juan" {
+		t.Errorf("movimiento público reservado: %+v", movimientos[0])
+	}
+	if movimientos[1].CIP != models.IdentidadReservada || movimientos[1].ApellidosNombres != models.IdentidadReservada {
+		t.Errorf("movimiento secreto visible con nivel público: %+v", movimientos[1])
+	}
+	if len(entries) != 0 {
+		t.Errorf("%d accesos registrados, want 0", len(entries))
+	}
+
+	secreto := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionSecreto}
+	movimientos = movimientosPrueba()
+	entries = reservarMovimientos(movimientos, "exportacion_plan_rebalanceo", secreto)
+
+	if movimientos[1].CIP != "00054321" || movimientos[1].ApellidosNombres != "QUISPE MAMANI, Rosa" {
+		t.Errorf("movimiento secreto reservado con nivel secreto: %+v", movimientos[1])
+	}
+	if len(entries) != 1 || entries[0].RecursoID != movimientos[1].ExpedienteID.Hex() ||
+		entries[0].Detalles["operacion"] != "exportacion_plan_rebalanceo" || entries[0].Detalles["cip"] != "00054321" {
+		t.Errorf("accesos registrados = %+v, want uno del movimiento secreto", entries)
+	}
+}
+
+func TestReservarMovimientosBreakGlass(t *testing.T) {
+	movimientos := movimientosPrueba()
+	grant := models.BreakGlassGrant{AccessID: primitive.NewObjectID(), ExpedienteID: movimientos[1].ExpedienteID}
+	scope := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionPublico, BreakGlass: []models.BreakGlassGrant{grant}}
+
+	entries := reservarMovimientos(movimientos, "plan_rebalanceo", scope)
+	if movimientos[1].CIP != "00054321" {
+		t.Errorf("movimiento con acceso de emergencia reservado: %+v", movimientos[1])
+	}
+	if len(entries) != 1 || entries[0].Detalles["break_glass_id"] != grant.AccessID.Hex() {
+		t.Errorf("accesos registrados = %+v, want uno con el acceso de emergencia", entries)
+	}
+}
@@ -0,0 +1,349 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Reconciliation errors
+var (
+	ErrArchivoNomina           = errors.New("archivo de nómina inválido")
+	ErrDiscrepanciaNoAplicable = errors.New("la discrepancia no puede aplicarse como evento de carrera")
+	ErrDiscrepanciaAplicada    = errors.New("la discrepancia ya fue aplicada")
+)
+
+// columnasNomina are the columns of a personnel roster, in order. An empty situación means Actividad.
+var columnasNomina = []string{"CIP", "ApellidosNombres", "Grado", "SituacionMilitar"}
+
+// ReconciliacionService compares the personnel roster sent by the personnel office with the
+// expedientes and applies the selected differences as career events
+type ReconciliacionService struct {
+	reconciliacionRepo *repository.ReconciliacionRepository
+	expedienteRepo     *repository.ExpedienteRepository
+	auditRepo          *repository.AuditRepository
+	carreraService     *CarreraService
+	cipValidator       *CIPValidator
+}
+
+// NewReconciliacionService creates a new reconciliacion service
+func NewReconciliacionService(reconciliacionRepo *repository.ReconciliacionRepository, expedienteRepo *repository.ExpedienteRepository, auditRepo *repository.AuditRepository, carreraService *CarreraService, cipValidator *CIPValidator) *ReconciliacionService {
+	return &ReconciliacionService{
+		reconciliacionRepo: reconciliacionRepo,
+		expedienteRepo:     expedienteRepo,
+		auditRepo:          auditRepo,
+		carreraService:     carreraService,
+		cipValidator:       cipValidator,
+	}
+}
+
+// filaNomina is a valid row of a personnel roster
+type filaNomina struct {
+	fila             int
+	cip              string
+	apellidosNombres string
+	grado            models.Grado
+	situacion        models.SituacionMilitar
+}
+
+// Reconciliar reads a personnel roster (Excel or CSV) and reports the personnel without an
+// expediente, the expedientes whose grado or situación differ from the roster and the
+// expedientes in Actividad missing from it. The report is stored so it can be downloaded
+// and its discrepancies applied.
+func (s *ReconciliacionService) Reconciliar(file *multipart.FileHeader, scope models.AccessScope) (*models.Reconciliacion, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid createdBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	rows, err := leerArchivoTabla(file, columnasNomina, ErrArchivoNomina)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliacion := &models.Reconciliacion{
+		Archivo:        file.Filename,
+		Discrepancias:  []models.Discrepancia{},
+		FilasInvalidas: []models.FilaNominaInvalida{},
+		CreadoPor:      scope.UserID,
+		CreadoEn:       time.Now(),
+	}
+
+	// Every CIP on the roster counts as present, even on rows that could not be read. CIPs
+	// are compared by their clave, so rows typed without leading zeros still match.
+	enNomina := make(map[string]bool)
+	var filas []filaNomina
+	var cips []string
+	for i, row := range rows[1:] {
+		celda := func(col int) string {
+			if col < len(row) {
+				return strings.TrimSpace(row[col])
+			}
+			return ""
+		}
+		if strings.Join(row, "") == "" {
+			continue
+		}
+
+		fila := filaNomina{
+			fila:             i + 2, // +2 because index starts at 0 and we skip header
+			apellidosNombres: celda(1),
+			grado:            normalizarGrado(celda(2)),
+			situacion:        models.SituacionActividad,
+		}
+		fila.cip = s.cipValidator.Normalizar(celda(0), fila.grado)
+		clave := s.cipValidator.Clave(fila.cip)
+		if situacion := celda(3); situacion != "" {
+			fila.situacion = normalizarSituacion(situacion)
+		}
+
+		var msg string
+		switch {
+		case fila.cip == "":
+			msg = "CIP requerido"
+		case enNomina[clave]:
+			msg = "el CIP ya aparece en una fila anterior"
+		case !fila.grado.IsValid():
+			msg = fmt.Sprintf("grado inválido: %s", celda(2))
+		case !fila.situacion.IsValid():
+			msg = fmt.Sprintf("situación militar inválida: %s", celda(3))
+		}
+		if fila.cip != "" {
+			enNomina[clave] = true
+		}
+		if msg != "" {
+			reconciliacion.FilasInvalidas = append(reconciliacion.FilasInvalidas, models.FilaNominaInvalida{
+				Fila:  fila.fila,
+				CIP:   fila.cip,
+				Error: msg,
+			})
+			continue
+		}
+
+		filas = append(filas, fila)
+		cips = append(cips, s.cipValidator.Candidatos(fila.cip)...)
+	}
+	if len(enNomina) == 0 {
+		return nil, fmt.Errorf("%w: no contiene filas de datos", ErrArchivoNomina)
+	}
+
+	var expedientes []*models.Expediente
+	if len(cips) > 0 {
+		if expedientes, err = s.expedienteRepo.GetByCIPs(cips, scope); err != nil {
+			return nil, err
+		}
+	}
+	porCIP := make(map[string]*models.Expediente, len(expedientes))
+	for _, expediente := range expedientes {
+		porCIP[s.cipValidator.Clave(expediente.CIP)] = expediente
+	}
+
+	for _, fila := range filas {
+		expediente, ok := porCIP[s.cipValidator.Clave(fila.cip)]
+		if !ok {
+			reconciliacion.Discrepancias = append(reconciliacion.Discrepancias, models.Discrepancia{
+				Tipo:             models.DiscrepanciaSinExpediente,
+				CIP:              fila.cip,
+				ApellidosNombres: fila.apellidosNombres,
+				Fila:             fila.fila,
+				GradoNomina:      fila.grado,
+				SituacionNomina:  fila.situacion,
+			})
+			continue
+		}
+
+		if expediente.Grado != fila.grado || expediente.SituacionMilitar != fila.situacion {
+			reconciliacion.Discrepancias = append(reconciliacion.Discrepancias, models.Discrepancia{
+				Tipo:             models.DiscrepanciaCarrera,
+				CIP:              expediente.CIP,
+				ApellidosNombres: expediente.ApellidosNombres,
+				Fila:             fila.fila,
+				ExpedienteID:     &expediente.ID,
+				GradoArchivo:     expediente.Grado,
+				GradoNomina:      fila.grado,
+				SituacionArchivo: expediente.SituacionMilitar,
+				SituacionNomina:  fila.situacion,
+				Clasificacion:    expediente.Clasificacion,
+			})
+		}
+	}
+
+	activos, err := s.expedienteRepo.GetBySituacion(models.SituacionActividad, scope)
+	if err != nil {
+		return nil, err
+	}
+	for _, expediente := range activos {
+		if enNomina[s.cipValidator.Clave(expediente.CIP)] {
+			continue
+		}
+		reconciliacion.Discrepancias = append(reconciliacion.Discrepancias, models.Discrepancia{
+			Tipo:             models.DiscrepanciaFueraNomina,
+			CIP:              expediente.CIP,
+			ApellidosNombres: expediente.ApellidosNombres,
+			ExpedienteID:     &expediente.ID,
+			GradoArchivo:     expediente.Grado,
+			SituacionArchivo: expediente.SituacionMilitar,
+			Clasificacion:    expediente.Clasificacion,
+		})
+	}
+
+	reconciliacion.Resumen = models.ResumenReconciliacion{
+		PersonalNomina: len(enNomina),
+		FilasInvalidas: len(reconciliacion.FilasInvalidas),
+	}
+	for _, discrepancia := range reconciliacion.Discrepancias {
+		switch discrepancia.Tipo {
+		case models.DiscrepanciaSinExpediente:
+			reconciliacion.Resumen.SinExpediente++
+		case models.DiscrepanciaCarrera:
+			reconciliacion.Resumen.Carrera++
+		case models.DiscrepanciaFueraNomina:
+			reconciliacion.Resumen.FueraNomina++
+		}
+	}
+
+	if err := s.reconciliacionRepo.Create(reconciliacion); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📋 Reconciliación de nómina %s: %d discrepancias", reconciliacion.ID.Hex(), len(reconciliacion.Discrepancias))
+	// Las discrepancias solo incluyen expedientes visibles para quien carga la nómina
+	storeClassifiedAccess(s.auditRepo, reservarDiscrepancias(reconciliacion.Discrepancias, "reconciliacion", scope))
+	return reconciliacion, nil
+}
+
+// GetReconciliacion returns a reconciliation report with the discrepancies above the caller's
+// clearance reserved. The report was built at the clearance of whoever uploaded the roster,
+// which may be higher.
+func (s *ReconciliacionService) GetReconciliacion(id string, operacion string, scope models.AccessScope) (*models.Reconciliacion, error) {
+	reconciliacion, err := s.reconciliacionRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []primitive.ObjectID
+	for _, discrepancia := range reconciliacion.Discrepancias {
+		if discrepancia.ExpedienteID != nil {
+			ids = append(ids, *discrepancia.ExpedienteID)
+		}
+	}
+	clasificaciones, err := clasificacionesActuales(s.expedienteRepo, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reconciliacion.Discrepancias {
+		if id := reconciliacion.Discrepancias[i].ExpedienteID; id != nil {
+			reconciliacion.Discrepancias[i].Clasificacion = clasificaciones[*id]
+		}
+	}
+
+	storeClassifiedAccess(s.auditRepo, reservarDiscrepancias(reconciliacion.Discrepancias, operacion, scope))
+	return reconciliacion, nil
+}
+
+// reservarDiscrepancias replaces the CIP, name, grados and situaciones of the discrepancies about
+// expedientes above the caller's clearance, which still count in the summary, and returns the
+// access log entries of the classified ones it shows. Personnel without an expediente only
+// carry roster data and are left as they are.
+func reservarDiscrepancias(discrepancias []models.Discrepancia, operacion string, scope models.AccessScope) []models.AuditLog {
+	var entries []models.AuditLog
+	for i := range discrepancias {
+		discrepancia := &discrepancias[i]
+		if discrepancia.ExpedienteID == nil {
+			continue
+		}
+		if !scope.CanReadExpediente(*discrepancia.ExpedienteID, discrepancia.Clasificacion) {
+			discrepancia.CIP = models.IdentidadReservada
+			discrepancia.ApellidosNombres = models.IdentidadReservada
+			discrepancia.GradoArchivo, discrepancia.GradoNomina = "", ""
+			discrepancia.SituacionArchivo, discrepancia.SituacionNomina = "", ""
+			continue
+		}
+		if entry, ok := classifiedAccessEntry(scope, operacion, *discrepancia.ExpedienteID, discrepancia.CIP, discrepancia.Clasificacion); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// AplicarDiscrepancias brings the selected expedientes in line with the roster through a
+// resolution batch, so the changes are recorded as career events and applied all or nothing.
+// Carrera discrepancies take the grado and situación of the roster; expedientes missing from
+// the roster move to Retiro. Personnel without an expediente cannot be applied.
+func (s *ReconciliacionService) AplicarDiscrepancias(id string, req *models.AplicarReconciliacionRequest, scope models.AccessScope) (*models.LoteResolucion, error) {
+	reconciliacion, err := s.reconciliacionRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	fecha, err := time.Parse(fechaCarrera, req.FechaEfectiva)
+	if err != nil {
+		return nil, ErrFechaEfectivaInvalida
+	}
+
+	porCIP := make(map[string]models.Discrepancia, len(reconciliacion.Discrepancias))
+	for _, discrepancia := range reconciliacion.Discrepancias {
+		porCIP[s.cipValidator.Clave(discrepancia.CIP)] = discrepancia
+	}
+
+	filas := make([]models.FilaResolucion, 0, len(req.CIPs))
+	seleccionados := make(map[string]bool, len(req.CIPs))
+	for _, cip := range req.CIPs {
+		clave := s.cipValidator.Clave(cip)
+		if seleccionados[clave] {
+			continue
+		}
+		seleccionados[clave] = true
+
+		discrepancia, ok := porCIP[clave]
+		if !ok || discrepancia.Tipo == models.DiscrepanciaSinExpediente {
+			return nil, fmt.Errorf("%w: CIP %s", ErrDiscrepanciaNoAplicable, cip)
+		}
+		if discrepancia.LoteID != nil {
+			return nil, fmt.Errorf("%w: CIP %s", ErrDiscrepanciaAplicada, cip)
+		}
+
+		fila := models.FilaResolucion{
+			Fila:           discrepancia.Fila,
+			CIP:            discrepancia.CIP,
+			GradoNuevo:     discrepancia.GradoNomina,
+			SituacionNueva: discrepancia.SituacionNomina,
+			FechaEfectiva:  &fecha,
+			Resolucion:     req.Resolucion,
+		}
+		if discrepancia.Tipo == models.DiscrepanciaFueraNomina {
+			fila.GradoNuevo = discrepancia.GradoArchivo
+			fila.SituacionNueva = models.SituacionRetiro
+		}
+		filas = append(filas, fila)
+	}
+
+	lote, err := s.carreraService.CrearLote(fmt.Sprintf("Reconciliación %s", reconciliacion.Archivo), filas, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	aplicado, err := s.carreraService.AplicarLote(lote.ID.Hex(), scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var aplicados []string
+	for _, fila := range aplicado.Filas {
+		if fila.Resultado == models.FilaCambio {
+			aplicados = append(aplicados, fila.CIP)
+		}
+	}
+	if err := s.reconciliacionRepo.MarkAplicadas(reconciliacion.ID, aplicados, aplicado.ID); err != nil {
+		log.Printf("⚠️ Error marcando discrepancias aplicadas de la reconciliación %s: %v", reconciliacion.ID.Hex(), err)
+	}
+
+	return aplicado, nil
+}
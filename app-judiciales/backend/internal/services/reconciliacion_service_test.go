@@ -0,0 +1,44 @@
+package services
+
+import (
+	"expedientes-backend/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReservarDiscrepancias(t *testing.T) {
+	secretoID := primitive.NewObjectID()
+	discrepanciasPrueba := func() []models.Discrepancia {
+		return []models.Discrepancia{
+			{Tipo: models.DiscrepanciaSinExpediente, CIP: "00099999", ApellidosNombres: "ROJAS DIAZ, Luis", Fila: 2, GradoNomina: "CAPITAN"},
+			{Tipo: models.DiscrepanciaCarrera, CIP: "00054321", ApellidosNombres: "QUISPE MAMANI, Rosa", Fila: 3, ExpedienteID: &secretoID,
+				GradoArchivo: "MAYOR", GradoNomina: "CORONEL", SituacionArchivo: models.SituacionActividad,
+				SituacionNomina: models.SituacionActividad, Clasificacion: models.ClasificacionSecreto},
+		}
+	}
+
+	publico := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionPublico}
+	discrepancias := discrepanciasPrueba()
+	if entries := reservarDiscrepancias(discrepancias, "reconciliacion", publico); len(entries) != 0 {
+		t.Errorf("%d accesos registrados, want 0", len(entries))
+	}
+	if discrepancias[0].CIP != "00099999" || discrepancias[0].ApellidosNombres != "ROJAS DIAZ, Luis" {
+		t.Errorf("personal sin expediente modificado: %+v", discrepancias[0])
+	}
+	secreta := discrepancias[1]
+	if secreta.CIP != models.IdentidadReservada || secreta.ApellidosNombres != models.IdentidadReservada ||
+		secreta.GradoArchivo != "" || secreta.GradoNomina != "" || secreta.SituacionArchivo != "" || secreta.SituacionNomina != "" {
+		t.Errorf("discrepancia secreta visible con nivel público: %+v", secreta)
+	}
+
+	secreto := models.AccessScope{UserID: primitive.NewObjectID(), Clearance: models.ClasificacionSecreto}
+	discrepancias = discrepanciasPrueba()
+	entries := reservarDiscrepancias(discrepancias, "exportacion_reconciliacion", secreto)
+	if discrepancias[1].CIP != "00054321" || discrepancias[1].GradoNomina != "CORONEL" {
+		t.Errorf("discrepancia secreta oculta con nivel secreto: %+v", discrepancias[1])
+	}
+	if len(entries) != 1 || entries[0].RecursoID != secretoID.Hex() || entries[0].Detalles["operacion"] != "exportacion_reconciliacion" {
+		t.Errorf("accesos registrados = %+v, want uno de la discrepancia secreta", entries)
+	}
+}
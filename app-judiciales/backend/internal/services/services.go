@@ -246,21 +246,45 @@ func (s *UserService) InitializeSystemUser(ctx context.Context) error {
 	return nil
 }
 
+// Classification errors
+var (
+	ErrClasificacionSuperior      = errors.New("no puede asignar una clasificación superior a su nivel de acceso")
+	ErrClasificacionJustificacion = errors.New("se requiere una justificación para reducir la clasificación")
+)
+
 // ExpedienteService handles expediente business logic
 type ExpedienteService struct {
 	// Add repository when created
-	expedienteRepo *repository.ExpedienteRepository
+	expedienteRepo  *repository.ExpedienteRepository
+	auditRepo       *repository.AuditRepository
+	ubicacionEngine *UbicacionEngine
+	cipValidator    *CIPValidator
 }
 
 // NewExpedienteService creates a new expediente service
-func NewExpedienteService(expedienteRepo *repository.ExpedienteRepository) *ExpedienteService {
+func NewExpedienteService(expedienteRepo *repository.ExpedienteRepository, auditRepo *repository.AuditRepository, ubicacionEngine *UbicacionEngine, cipValidator *CIPValidator) *ExpedienteService {
 	return &ExpedienteService{
-		expedienteRepo: expedienteRepo,
+		expedienteRepo:  expedienteRepo,
+		auditRepo:       auditRepo,
+		ubicacionEngine: ubicacionEngine,
+		cipValidator:    cipValidator,
 	}
 }
 
 // Create creates a new expediente
-func (s *ExpedienteService) Create(expediente *models.Expediente) error {
+func (s *ExpedienteService) Create(expediente *models.Expediente, scope models.AccessScope) error {
+	// A user cannot create records they would not be allowed to read
+	if !scope.CanRead(expediente.Clasificacion) {
+		return ErrClasificacionSuperior
+	}
+
+	// El CIP se guarda y se busca en su forma canónica
+	cip, err := s.cipValidator.Validar(expediente.CIP, expediente.Grado)
+	if err != nil {
+		return err
+	}
+	expediente.CIP = cip
+
 	// Check if CIP already exists
 	existing, err := s.expedienteRepo.GetByCIP(expediente.CIP)
 	if err != nil {
@@ -271,33 +295,17 @@ func (s *ExpedienteService) Create(expediente *models.Expediente) error {
 	}
 
 	// Auto-calcular ubicación basada en el primer apellido
-	expediente.Ubicacion = s.calculateUbicacion(expediente.ApellidosNombres)
+	expediente.Ubicacion = s.ubicacionEngine.Calcular(expediente.ApellidosNombres)
 
-	return s.expedienteRepo.Create(expediente)
-}
-
-// calculateUbicacion calcula la ubicación basada en las primeras dos letras del primer apellido
-func (s *ExpedienteService) calculateUbicacion(apellidosNombres string) string {
-	if apellidosNombres == "" {
-		return "ZZ" // Default para casos sin apellido
-	}
-
-	// Extraer primer apellido
-	parts := strings.Fields(strings.TrimSpace(apellidosNombres))
-	if len(parts) == 0 {
-		return "ZZ"
-	}
+	// El orden de archivo lo determina el servidor según grado y situación militar
+	expediente.Orden = calculateOrden(expediente.Grado, expediente.SituacionMilitar)
 
-	primerApellido := strings.ToUpper(parts[0])
-	if len(primerApellido) < 2 {
-		return primerApellido + "Z" // Completar con Z si es muy corto
-	}
-
-	return primerApellido[:2]
+	return s.expedienteRepo.Create(expediente)
 }
 
-// calculateOrden calcula el orden según grado y situación militar
-func (s *ExpedienteService) calculateOrden(grado models.Grado, situacion models.SituacionMilitar) int {
+// calculateOrden calcula el orden de archivo según grado y situación militar.
+// Dentro de un mismo orden los expedientes se archivan por apellidos y número de registro.
+func calculateOrden(grado models.Grado, situacion models.SituacionMilitar) int {
 	gradoPriority := map[models.Grado]int{
 		"GRAL":    1,
 		"CRL":     2,
@@ -321,21 +329,283 @@ func (s *ExpedienteService) calculateOrden(grado models.Grado, situacion models.
 	return gradoPriority[grado]*1000 + situacionPriority[situacion]*100
 }
 
+// Reordenar recomputes the filing order of every expediente from its grado and situación
+// and reports the expedientes whose place in the archive changed since the previous
+// reordering: a new orden, or a new surname within the same orden. The full sort key is
+// stored on each run; expedientes never reordered take their current one as reference.
+func (s *ExpedienteService) Reordenar(scope models.AccessScope) (*models.ReordenamientoResult, error) {
+	expedientes, err := s.expedienteRepo.GetOrdenamiento()
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := &models.ReordenamientoResult{
+		Revisados: len(expedientes),
+		Movidos:   []models.CambioOrden{},
+	}
+	nuevas := make(map[primitive.ObjectID]models.PosicionArchivo)
+	for _, expediente := range expedientes {
+		posicion := models.PosicionArchivo{
+			Orden:            calculateOrden(expediente.Grado, expediente.SituacionMilitar),
+			ApellidosNombres: expediente.ApellidosNombres,
+			NumeroRegistro:   expediente.NumeroRegistro,
+		}
+		anterior := models.PosicionArchivo{
+			Orden:            expediente.Orden,
+			ApellidosNombres: expediente.ApellidosNombres,
+			NumeroRegistro:   expediente.NumeroRegistro,
+		}
+		if expediente.Posicion != nil {
+			anterior = *expediente.Posicion
+		}
+		if expediente.Posicion != nil && posicion == anterior && posicion.Orden == expediente.Orden {
+			continue
+		}
+
+		nuevas[expediente.ID] = posicion
+		if posicion != anterior {
+			resultado.Movidos = append(resultado.Movidos, models.CambioOrden{
+				ExpedienteOrden:  expediente,
+				OrdenNuevo:       posicion.Orden,
+				PosicionAnterior: anterior,
+			})
+		}
+	}
+
+	if err := s.expedienteRepo.UpdateOrdenes(nuevas); err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.AuditLog, 0, len(resultado.Movidos))
+	for _, movido := range resultado.Movidos {
+		entries = append(entries, models.AuditLog{
+			UsuarioID: scope.UserID.Hex(),
+			Usuario:   scope.Email,
+			Accion:    models.AccionReordenamiento,
+			Recurso:   models.RecursoExpediente,
+			RecursoID: movido.ID.Hex(),
+			IP:        scope.IP,
+			Detalles: map[string]interface{}{
+				"cip":                  movido.CIP,
+				"orden_anterior":       movido.PosicionAnterior.Orden,
+				"orden_nuevo":          movido.OrdenNuevo,
+				"apellidos_anteriores": movido.PosicionAnterior.ApellidosNombres,
+				"apellidos_nombres":    movido.ApellidosNombres,
+			},
+		})
+	}
+	if err := s.auditRepo.LogMany(entries); err != nil {
+		log.Printf("⚠️ Error registrando el reordenamiento: %v", err)
+	}
+
+	log.Printf("🔢 Orden de archivo recalculado por %s: %d de %d expedientes cambiaron", scope.Email, len(resultado.Movidos), resultado.Revisados)
+	return resultado, nil
+}
+
 // GetExpedientesByDivision obtiene expedientes por división específica
-func (s *ExpedienteService) GetExpedientesByDivision(divisionRange string) ([]*models.Expediente, error) {
+func (s *ExpedienteService) GetExpedientesByDivision(divisionRange string, scope models.AccessScope) ([]*models.Expediente, error) {
+	inicio, fin, ok := models.ParseRango(divisionRange)
+	if !ok {
+		return []*models.Expediente{}, nil
+	}
+
 	// Definir grados de oficiales según especificación
 	gradosOficiales := []models.Grado{"STTE", "TTE", "CAP", "MY", "TTE CRL", "CRL", "GRAL"}
 
-	return s.expedienteRepo.GetByDivision(divisionRange, gradosOficiales, "Actividad")
+	return s.GetExpedientesInDivision(&models.Division{
+		RangoInicio: inicio,
+		RangoFin:    fin,
+		Grados:      gradosOficiales,
+		Situacion:   models.SituacionActividad,
+	}, scope)
+}
+
+// GetExpedientesInDivision obtiene los expedientes almacenados en una división del catálogo
+func (s *ExpedienteService) GetExpedientesInDivision(division *models.Division, scope models.AccessScope) ([]*models.Expediente, error) {
+	expedientes, err := s.expedienteRepo.GetByDivision(division, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logClassifiedReads(scope, "division", expedientes)
+	return expedientes, nil
+}
+
+// GetExpedientesByIDs returns the readable expedientes among the given IDs, in archive order
+func (s *ExpedienteService) GetExpedientesByIDs(ids []string, operacion string, scope models.AccessScope) ([]*models.Expediente, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, errors.New("invalid ID format")
+		}
+		objIDs = append(objIDs, objID)
+	}
+
+	expedientes, err := s.expedienteRepo.GetByIDs(objIDs, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logClassifiedReads(scope, operacion, expedientes)
+	return expedientes, nil
+}
+
+// RecalcularUbicaciones recomputes the ubicación of every expediente with the current rules.
+// Without aplicar it only reports the differences; with aplicar it also stores them and
+// records one audit entry per changed expediente under the given actor name.
+func (s *ExpedienteService) RecalcularUbicaciones(aplicar bool, actor string) (*models.RecalculoUbicaciones, error) {
+	expedientes, err := s.expedienteRepo.GetAllUbicaciones()
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := &models.RecalculoUbicaciones{
+		Revisados: len(expedientes),
+		Cambios:   []models.CambioUbicacion{},
+	}
+	nuevas := make(map[primitive.ObjectID]string)
+	for _, expediente := range expedientes {
+		nueva := s.ubicacionEngine.Calcular(expediente.ApellidosNombres)
+		if nueva == expediente.Ubicacion {
+			continue
+		}
+		nuevas[expediente.ID] = nueva
+		resultado.Cambios = append(resultado.Cambios, models.CambioUbicacion{
+			ExpedienteID:     expediente.ID,
+			CIP:              expediente.CIP,
+			ApellidosNombres: expediente.ApellidosNombres,
+			Anterior:         expediente.Ubicacion,
+			Nueva:            nueva,
+		})
+	}
+
+	if !aplicar || len(nuevas) == 0 {
+		return resultado, nil
+	}
+
+	if err := s.expedienteRepo.UpdateUbicaciones(nuevas); err != nil {
+		return nil, err
+	}
+	resultado.Aplicado = true
+
+	entries := make([]models.AuditLog, 0, len(resultado.Cambios))
+	for _, cambio := range resultado.Cambios {
+		entries = append(entries, models.AuditLog{
+			Usuario:   actor,
+			Accion:    models.AccionRecalculoUbicacion,
+			Recurso:   models.RecursoExpediente,
+			RecursoID: cambio.ExpedienteID.Hex(),
+			Detalles: map[string]interface{}{
+				"cip":      cambio.CIP,
+				"anterior": cambio.Anterior,
+				"nueva":    cambio.Nueva,
+			},
+		})
+	}
+	if err := s.auditRepo.LogMany(entries); err != nil {
+		log.Printf("⚠️ Error registrando el recálculo de ubicaciones: %v", err)
+	}
+
+	log.Printf("🗄️ Ubicaciones recalculadas por %s: %d de %d expedientes", actor, len(resultado.Cambios), resultado.Revisados)
+	return resultado, nil
+}
+
+// RevisarCIPs checks every stored CIP against the current CIP rules. CIPs that only need
+// normalising are rewritten in their canonical form when aplicar is set, unless another
+// expediente already has it; the rest are reported for manual correction.
+func (s *ExpedienteService) RevisarCIPs(aplicar bool, actor string) (*models.RevisionCIPs, error) {
+	expedientes, err := s.expedienteRepo.GetAllUbicaciones()
+	if err != nil {
+		return nil, err
+	}
+
+	resultado := &models.RevisionCIPs{
+		Revisados:     len(expedientes),
+		Normalizables: []models.RevisionCIP{},
+		Invalidos:     []models.RevisionCIP{},
+	}
+
+	existentes := make(map[string]bool, len(expedientes))
+	for _, expediente := range expedientes {
+		existentes[expediente.CIP] = true
+	}
+
+	nuevos := make(map[primitive.ObjectID]string)
+	for _, expediente := range expedientes {
+		revision := models.RevisionCIP{
+			ExpedienteID:     expediente.ID,
+			CIP:              expediente.CIP,
+			Grado:            expediente.Grado,
+			ApellidosNombres: expediente.ApellidosNombres,
+		}
+
+		canonico, err := s.cipValidator.Validar(expediente.CIP, expediente.Grado)
+		switch {
+		case err != nil:
+			revision.Error = err.Error()
+		case canonico == expediente.CIP:
+			continue
+		case existentes[canonico]:
+			revision.Error = fmt.Sprintf("su forma canónica %s ya pertenece a otro expediente", canonico)
+		default:
+			existentes[canonico] = true
+			revision.Canonico = canonico
+			nuevos[expediente.ID] = canonico
+			resultado.Normalizables = append(resultado.Normalizables, revision)
+			continue
+		}
+		resultado.Invalidos = append(resultado.Invalidos, revision)
+	}
+
+	if !aplicar || len(nuevos) == 0 {
+		return resultado, nil
+	}
+
+	if err := s.expedienteRepo.UpdateCIPs(nuevos); err != nil {
+		return nil, err
+	}
+	resultado.Aplicado = true
+
+	entries := make([]models.AuditLog, 0, len(resultado.Normalizables))
+	for _, revision := range resultado.Normalizables {
+		entries = append(entries, models.AuditLog{
+			Usuario:   actor,
+			Accion:    models.AccionNormalizacionCIP,
+			Recurso:   models.RecursoExpediente,
+			RecursoID: revision.ExpedienteID.Hex(),
+			Detalles: map[string]interface{}{
+				"anterior": revision.CIP,
+				"nuevo":    revision.Canonico,
+			},
+		})
+	}
+	if err := s.auditRepo.LogMany(entries); err != nil {
+		log.Printf("⚠️ Error registrando la normalización de CIPs: %v", err)
+	}
+
+	log.Printf("🪪 CIPs normalizados por %s: %d de %d expedientes", actor, len(resultado.Normalizables), resultado.Revisados)
+	return resultado, nil
 }
 
 // GetByID returns an expediente by ID
-func (s *ExpedienteService) GetByID(id string) (*models.Expediente, error) {
-	return s.expedienteRepo.GetByID(id)
+func (s *ExpedienteService) GetByID(id string, scope models.AccessScope) (*models.Expediente, error) {
+	return s.GetExpedienteByID(id, "consulta", scope)
+}
+
+// GetExpedienteByID returns an expediente by ID, logging a classified read under the given operation
+func (s *ExpedienteService) GetExpedienteByID(id string, operacion string, scope models.AccessScope) (*models.Expediente, error) {
+	expediente, err := s.expedienteRepo.GetByID(id, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logClassifiedReads(scope, operacion, []*models.Expediente{expediente})
+	return expediente, nil
 }
 
 // GetAll returns all expedientes with pagination
-func (s *ExpedienteService) GetAll(page, limit int, sortBy, sortOrder string) ([]*models.Expediente, int64, error) {
+func (s *ExpedienteService) GetAll(page, limit int, sortBy, sortOrder string, scope models.AccessScope) ([]*models.Expediente, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -346,11 +616,17 @@ func (s *ExpedienteService) GetAll(page, limit int, sortBy, sortOrder string) ([
 		limit = 100 // Max limit
 	}
 
-	return s.expedienteRepo.GetAll(page, limit, sortBy, sortOrder)
+	expedientes, total, err := s.expedienteRepo.GetAll(page, limit, sortBy, sortOrder, scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.logClassifiedReads(scope, "listado", expedientes)
+	return expedientes, total, nil
 }
 
 // Search searches expedientes with filters
-func (s *ExpedienteService) Search(params models.ExpedienteSearchParams) ([]*models.Expediente, int64, error) {
+func (s *ExpedienteService) Search(params models.ExpedienteSearchParams, scope models.AccessScope) ([]*models.Expediente, int64, error) {
 	if params.Page < 1 {
 		params.Page = 1
 	}
@@ -361,18 +637,201 @@ func (s *ExpedienteService) Search(params models.ExpedienteSearchParams) ([]*mod
 		params.Limit = 100
 	}
 
-	return s.expedienteRepo.Search(params)
+	expedientes, total, err := s.expedienteRepo.Search(params, scope)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.logClassifiedReads(scope, "busqueda", expedientes)
+	return expedientes, total, nil
 }
 
 // ExportAll returns minimal data for all expedientes to be exported
-func (s *ExpedienteService) ExportAll() ([]models.ExpedienteExport, error) {
-	return s.expedienteRepo.GetAllForExport()
+func (s *ExpedienteService) ExportAll(scope models.AccessScope) ([]models.ExpedienteExport, error) {
+	records, err := s.expedienteRepo.GetAllForExport(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []models.AuditLog
+	for _, record := range records {
+		if entry, ok := classifiedAccessEntry(scope, "exportacion", record.ID, record.CIP, record.Clasificacion); ok {
+			entries = append(entries, entry)
+		}
+	}
+	s.storeClassifiedAccess(entries)
+
+	return records, nil
+}
+
+// UpdateClasificacion changes the classification of an expediente.
+// Lowering the level requires a justification, and nobody can classify above their own clearance.
+func (s *ExpedienteService) UpdateClasificacion(id string, req models.UpdateClasificacionRequest, scope models.AccessScope) error {
+	// Emergency grants only allow reading
+	scope = scope.WithoutBreakGlass()
+
+	existing, err := s.expedienteRepo.GetByID(id, scope)
+	if err != nil {
+		return err
+	}
+
+	if !scope.CanRead(req.Clasificacion) {
+		return ErrClasificacionSuperior
+	}
+
+	justificacion := strings.TrimSpace(req.Justificacion)
+	if req.Clasificacion.Nivel() < existing.Clasificacion.Nivel() && justificacion == "" {
+		return ErrClasificacionJustificacion
+	}
+
+	updates := map[string]interface{}{
+		"clasificacion": req.Clasificacion,
+		"updatedBy":     scope.UserID,
+	}
+	if err := s.expedienteRepo.Update(id, updates); err != nil {
+		return err
+	}
+
+	anterior := existing.Clasificacion
+	if anterior == "" {
+		anterior = models.ClasificacionPublico
+	}
+
+	entry, _ := classifiedAccessEntry(scope, "actualizacion", existing.ID, existing.CIP, req.Clasificacion)
+	entry.Accion = models.AccionCambioClasificacion
+	entry.Detalles["anterior"] = anterior
+	entry.Detalles["nueva"] = req.Clasificacion
+	entry.Detalles["justificacion"] = justificacion
+	s.storeClassifiedAccess([]models.AuditLog{entry})
+
+	log.Printf("🔒 Clasificación de expediente %s cambiada de %s a %s por %s", existing.CIP, anterior, req.Clasificacion, scope.Email)
+	return nil
+}
+
+// logClassifiedReads records every classified expediente returned to the caller
+func (s *ExpedienteService) logClassifiedReads(scope models.AccessScope, operacion string, expedientes []*models.Expediente) {
+	var entries []models.AuditLog
+	for _, expediente := range expedientes {
+		if expediente == nil {
+			continue
+		}
+		if entry, ok := classifiedAccessEntry(scope, operacion, expediente.ID, expediente.CIP, expediente.Clasificacion); ok {
+			entries = append(entries, entry)
+		}
+	}
+	s.storeClassifiedAccess(entries)
+}
+
+// storeClassifiedAccess persists classified access entries without failing the read
+func (s *ExpedienteService) storeClassifiedAccess(entries []models.AuditLog) {
+	storeClassifiedAccess(s.auditRepo, entries)
+}
+
+// storeClassifiedAccess persists classified access entries for services outside ExpedienteService
+func storeClassifiedAccess(auditRepo *repository.AuditRepository, entries []models.AuditLog) {
+	if auditRepo == nil || len(entries) == 0 {
+		return
+	}
+
+	if err := auditRepo.LogClassifiedAccess(entries); err != nil {
+		log.Printf("⚠️ Error registrando acceso a expedientes clasificados: %v", err)
+	}
+}
+
+// classifiedAccessEntry builds an access log entry for a classified expediente or one read through
+// a break-glass grant. The second return value is false when the read does not need to be logged.
+func classifiedAccessEntry(scope models.AccessScope, operacion string, id primitive.ObjectID, cip string, clasificacion models.Clasificacion) (models.AuditLog, bool) {
+	grant, breakGlass := scope.BreakGlassFor(id)
+
+	entry := models.AuditLog{
+		UsuarioID: scope.UserID.Hex(),
+		Usuario:   scope.Email,
+		Accion:    models.AccionLecturaClasificada,
+		Recurso:   models.RecursoExpediente,
+		RecursoID: id.Hex(),
+		IP:        scope.IP,
+		Detalles: map[string]interface{}{
+			"operacion":     operacion,
+			"cip":           cip,
+			"clasificacion": clasificacion,
+		},
+		Timestamp: time.Now(),
+	}
+
+	// Every read during an emergency window is tagged with its grant
+	if breakGlass {
+		entry.Detalles["break_glass_id"] = grant.AccessID.Hex()
+	}
+
+	return entry, breakGlass || clasificacion.Nivel() > 0
+}
+
+// clasificacionesActuales returns the current classification of the given expedientes, for
+// stored reports that must apply the reader's clearance. Expedientes deleted since the report
+// was built are reported as secreto, so their identity is never shown below the top clearance.
+func clasificacionesActuales(expedienteRepo *repository.ExpedienteRepository, ids []primitive.ObjectID) (map[primitive.ObjectID]models.Clasificacion, error) {
+	clasificaciones := make(map[primitive.ObjectID]models.Clasificacion, len(ids))
+	if len(ids) == 0 {
+		return clasificaciones, nil
+	}
+	for _, id := range ids {
+		clasificaciones[id] = models.ClasificacionSecreto
+	}
+
+	ubicaciones, err := expedienteRepo.GetUbicacionesByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, ubicacion := range ubicaciones {
+		clasificaciones[ubicacion.ID] = ubicacion.Clasificacion
+	}
+	return clasificaciones, nil
 }
 
 // Update updates an expediente
-func (s *ExpedienteService) Update(id string, updates map[string]interface{}) error {
+func (s *ExpedienteService) Update(id string, updates map[string]interface{}, scope models.AccessScope) error {
+	// Only expedientes visible to the caller can be modified; emergency grants only allow reading
+	scope = scope.WithoutBreakGlass()
+	current, err := s.expedienteRepo.GetByID(id, scope)
+	if err != nil {
+		return err
+	}
+
+	// El estado solo cambia mediante una transición con justificación; se acepta sin cambios
+	if estado, ok := updates["estado"].(models.EstadoExpediente); ok {
+		if estado != current.Estado {
+			return ErrEstadoRequiereTransicion
+		}
+		delete(updates, "estado")
+	}
+
+	// El grado y la situación se derivan de la carrera; se aceptan sin cambios
+	if grado, ok := updates["grado"].(models.Grado); ok {
+		if grado != current.Grado {
+			return ErrCarreraRequiereEvento
+		}
+		delete(updates, "grado")
+	}
+	if situacion, ok := updates["situacion_militar"].(models.SituacionMilitar); ok {
+		if situacion != current.SituacionMilitar {
+			return ErrCarreraRequiereEvento
+		}
+		delete(updates, "situacion_militar")
+	}
+
+	// Las páginas de un expediente dividido en tomos se calculan a partir de sus tomos
+	if _, ok := updates["numero_paginas"]; ok && current.Tomos > 0 {
+		return ErrPaginasPorTomos
+	}
+
 	// If CIP is being updated, check if it already exists
 	if cip, ok := updates["cip"].(string); ok {
+		cip, err := s.cipValidator.Validar(cip, current.Grado)
+		if err != nil {
+			return err
+		}
+		updates["cip"] = cip
+
 		existing, err := s.expedienteRepo.GetByCIP(cip)
 		if err != nil {
 			return err
@@ -384,57 +843,27 @@ func (s *ExpedienteService) Update(id string, updates map[string]interface{}) er
 
 	// Si se actualizan los apellidos, recalcular la ubicación automáticamente
 	if apellidos, ok := updates["apellidos_nombres"].(string); ok {
-		updates["ubicacion"] = s.calculateUbicacion(apellidos)
-	}
-
-	// Si se actualiza grado o situación militar, recalcular orden
-	if grado, gradoOk := updates["grado"]; gradoOk {
-		if situacion, situacionOk := updates["situacion_militar"]; situacionOk {
-			if g, ok := grado.(models.Grado); ok {
-				if sit, ok := situacion.(models.SituacionMilitar); ok {
-					updates["orden"] = s.calculateOrden(g, sit)
-				}
-			}
-		} else {
-			// Si solo se actualiza grado, obtener la situación actual
-			existing, err := s.expedienteRepo.GetByID(id)
-			if err == nil && existing != nil {
-				if g, ok := grado.(models.Grado); ok {
-					updates["orden"] = s.calculateOrden(g, existing.SituacionMilitar)
-				}
-			}
-		}
-	} else if situacion, situacionOk := updates["situacion_militar"]; situacionOk {
-		// Si solo se actualiza situación, obtener el grado actual
-		existing, err := s.expedienteRepo.GetByID(id)
-		if err == nil && existing != nil {
-			if sit, ok := situacion.(models.SituacionMilitar); ok {
-				updates["orden"] = s.calculateOrden(existing.Grado, sit)
-			}
-		}
+		updates["ubicacion"] = s.ubicacionEngine.Calcular(apellidos)
 	}
 
 	return s.expedienteRepo.Update(id, updates)
 }
 
-// UpdateEstado updates the estado of an expediente
-func (s *ExpedienteService) UpdateEstado(id string, estado models.EstadoExpediente, updatedBy string) error {
-	objID, err := primitive.ObjectIDFromHex(updatedBy)
-	if err != nil {
-		return errors.New("invalid updatedBy ID")
-	}
-
-	return s.expedienteRepo.UpdateEstado(id, estado, objID)
-}
-
 // Delete soft-deletes an expediente
-func (s *ExpedienteService) Delete(id string, deletedBy string) error {
-	objID, err := primitive.ObjectIDFromHex(deletedBy)
-	if err != nil {
+func (s *ExpedienteService) Delete(id string, scope models.AccessScope) error {
+	if scope.UserID.IsZero() {
 		return errors.New("invalid deletedBy ID")
 	}
+	scope = scope.WithoutBreakGlass()
 
-	return s.expedienteRepo.Delete(id, objID)
+	if _, err := s.expedienteRepo.GetByID(id, scope); err != nil {
+		if err.Error() == "expediente not found" {
+			return errors.New("expediente not found or already deleted")
+		}
+		return err
+	}
+
+	return s.expedienteRepo.Delete(id, scope.UserID)
 }
 
 // BulkImportFromExcel imports expedientes from an Excel file
@@ -535,6 +964,9 @@ func (s *ExpedienteService) processBulkImport(bulkData []models.BulkImportExpedi
 
 	// First pass: collect all CIPs and check for internal duplicates
 	for i, data := range bulkData {
+		// Los CIP se comparan en su forma canónica
+		data.CIP = s.cipValidator.Normalizar(data.CIP, models.Grado(strings.ToUpper(strings.TrimSpace(data.Grado))))
+		bulkData[i].CIP = data.CIP
 		if data.CIP != "" {
 			allCIPs = append(allCIPs, data.CIP)
 			if existingRow, exists := cipMap[data.CIP]; exists {
@@ -633,7 +1065,8 @@ func (s *ExpedienteService) validateAndConvertBulkRecord(data models.BulkImportE
 	}
 
 	// Validate CIP
-	if strings.TrimSpace(data.CIP) == "" {
+	cip := strings.TrimSpace(data.CIP)
+	if cip == "" {
 		errors = append(errors, models.BulkImportError{
 			Fila:     data.Fila,
 			Campo:    "CIP",
@@ -641,6 +1074,17 @@ func (s *ExpedienteService) validateAndConvertBulkRecord(data models.BulkImportE
 			Error:    "CIP es requerido",
 			Registro: data,
 		})
+	} else if grado != "" {
+		var err error
+		if cip, err = s.cipValidator.Validar(cip, grado); err != nil {
+			errors = append(errors, models.BulkImportError{
+				Fila:     data.Fila,
+				Campo:    "CIP",
+				Valor:    data.CIP,
+				Error:    err.Error(),
+				Registro: data,
+			})
+		}
 	}
 
 	// Validate ApellidosNombres
@@ -718,13 +1162,14 @@ func (s *ExpedienteService) validateAndConvertBulkRecord(data models.BulkImportE
 		ApellidosNombres:   apellidosNombres,
 		NumeroPaginas:      numeroPaginas,
 		SituacionMilitar:   models.SituacionActividad, // Default value
-		CIP:                strings.TrimSpace(data.CIP),
+		CIP:                cip,
 		Estado:             models.EstadoDentro, // Default value
-		Ubicacion:          "Archivo Central",   // Default value
+		Ubicacion:          s.ubicacionEngine.Calcular(apellidosNombres),
 		Ano:                ano,
 		FechaRegistro:      now,
 		FechaActualizacion: now,
-		Orden:              1, // Temporary value, will be updated by repository
+		Orden:              calculateOrden(grado, models.SituacionActividad),
+		Clasificacion:      models.ClasificacionPublico,
 		CreatedAt:          now,
 		UpdatedAt:          now,
 		CreatedBy:          createdBy,
@@ -772,6 +1217,21 @@ func (s *ExpedienteService) filterDuplicateCIPs(expedientes []models.Expediente,
 }
 
 // GetDashboardStats retrieves comprehensive dashboard statistics
-func (s *ExpedienteService) GetDashboardStats() (*models.DashboardStats, error) {
-	return s.expedienteRepo.GetDashboardStats()
+func (s *ExpedienteService) GetDashboardStats(scope models.AccessScope) (*models.DashboardStats, error) {
+	return s.expedienteRepo.GetDashboardStats(scope)
+}
+
+// GetClassifiedAccessLog returns the separate access log for classified expedientes
+func (s *ExpedienteService) GetClassifiedAccessLog(expedienteID string, page, limit int) ([]*models.AuditLog, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return s.auditRepo.GetClassifiedAccess(expedienteID, page, limit)
 }
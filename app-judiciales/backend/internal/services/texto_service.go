@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"expedientes-backend/internal/extract"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"expedientes-backend/internal/storage"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrIndexacionEnCurso is returned when a text extraction run is requested while another is running
+var ErrIndexacionEnCurso = errors.New("ya hay una extracción de texto en curso")
+
+// TextoService extracts the text layer of PDF and DOCX attachments page by page and searches it
+type TextoService struct {
+	documentoRepo     *repository.DocumentoRepository
+	textoRepo         *repository.TextoRepository
+	expedienteService *ExpedienteService
+	storage           storage.Storage
+
+	cola    chan primitive.ObjectID
+	enCurso sync.Mutex
+}
+
+// NewTextoService creates a new texto service
+func NewTextoService(documentoRepo *repository.DocumentoRepository, textoRepo *repository.TextoRepository, expedienteService *ExpedienteService, storage storage.Storage) *TextoService {
+	return &TextoService{
+		documentoRepo:     documentoRepo,
+		textoRepo:         textoRepo,
+		expedienteService: expedienteService,
+		storage:           storage,
+		cola:              make(chan primitive.ObjectID, 256),
+	}
+}
+
+// Iniciar extracts the text of the queued attachments, one at a time, until the context is cancelled
+func (s *TextoService) Iniciar(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.cola:
+			documento, err := s.documentoRepo.FindByID(id)
+			if err != nil {
+				// Un documento eliminado antes de procesarse no deja nada pendiente
+				if !errors.Is(err, repository.ErrDocumentoNotFound) {
+					log.Printf("⚠️ Error leyendo el documento %s para extraer su texto: %v", id.Hex(), err)
+				}
+				continue
+			}
+			s.indexar(documento)
+		}
+	}
+}
+
+// encolar schedules the text extraction of an attachment. With the queue full the attachment
+// stays pending until the next full run.
+func (s *TextoService) encolar(id primitive.ObjectID) {
+	select {
+	case s.cola <- id:
+	default:
+		log.Printf("⚠️ Cola de extracción de texto llena; el documento %s queda pendiente", id.Hex())
+	}
+}
+
+// Nuevo schedules the text extraction of a newly stored attachment
+func (s *TextoService) Nuevo(documento *models.Documento) {
+	if documento.Texto == models.TextoPendiente {
+		s.encolar(documento.ID)
+	}
+}
+
+// Reindexar marks the current version of an attachment as pending after it changed and
+// schedules its extraction
+func (s *TextoService) Reindexar(documento *models.Documento) {
+	documento.Texto = models.TextoInicial(documento.Formato)
+	documento.Paginas = 0
+	if err := s.documentoRepo.SetTexto(documento.ID, documento.Version, documento.Texto, 0); err != nil {
+		log.Printf("⚠️ Error marcando el texto del documento %s como pendiente: %v", documento.ID.Hex(), err)
+	}
+	s.Nuevo(documento)
+}
+
+// Eliminar removes the extracted text of a deleted attachment
+func (s *TextoService) Eliminar(documentoID primitive.ObjectID) {
+	if err := s.textoRepo.DeleteByDocumento(documentoID); err != nil {
+		log.Printf("⚠️ Error eliminando el texto del documento %s: %v", documentoID.Hex(), err)
+	}
+}
+
+// IndexarEnSegundoPlano extracts the text of every attachment still pending, or of all of
+// them when todos is set, without waiting for it to finish
+func (s *TextoService) IndexarEnSegundoPlano(todos bool) error {
+	if !s.enCurso.TryLock() {
+		return ErrIndexacionEnCurso
+	}
+
+	go func() {
+		defer s.enCurso.Unlock()
+
+		documentos, err := s.documentoRepo.GetAll()
+		if err != nil {
+			log.Printf("⚠️ Error en la extracción de texto: %v", err)
+			return
+		}
+
+		log.Printf("🔎 Extracción de texto iniciada")
+		var resultado models.ResultadoIndexacion
+		for i := range documentos {
+			documento := &documentos[i]
+			// Los documentos anteriores a la extracción de texto no tienen estado
+			if !todos && documento.Texto != "" && documento.Texto != models.TextoPendiente {
+				continue
+			}
+			resultado.Documentos++
+			switch s.indexar(documento) {
+			case models.TextoExtraido:
+				resultado.Extraidos++
+			case models.TextoSinTexto:
+				resultado.SinTexto++
+			case models.TextoNoSoportado:
+				resultado.NoSoportado++
+			case models.TextoError:
+				resultado.Errores++
+			}
+		}
+		log.Printf("🔎 Extracción de texto terminada: %d documentos, %d con texto, %d sin capa de texto, %d no soportados, %d con errores",
+			resultado.Documentos, resultado.Extraidos, resultado.SinTexto, resultado.NoSoportado, resultado.Errores)
+	}()
+	return nil
+}
+
+// indexar extracts and stores the text of the current version of an attachment and records
+// the outcome. A version uploaded meanwhile is left for its own extraction.
+func (s *TextoService) indexar(documento *models.Documento) models.EstadoTexto {
+	estado, paginas := s.extraer(documento)
+
+	textos := make(map[int]string, len(paginas))
+	for i, texto := range paginas {
+		if texto != "" {
+			textos[i+1] = texto
+		}
+	}
+	if estado == models.TextoExtraido && len(textos) == 0 {
+		estado = models.TextoSinTexto
+	}
+
+	// Un error de lectura conserva el texto ya extraído
+	guardadas := documento.Paginas
+	if estado != models.TextoError {
+		if err := s.textoRepo.Reemplazar(documento.ID, documento.Version, textos); err != nil {
+			log.Printf("⚠️ Error guardando el texto del documento %s: %v", documento.ID.Hex(), err)
+			estado = models.TextoError
+		} else {
+			guardadas = len(textos)
+		}
+	}
+	if err := s.documentoRepo.SetTexto(documento.ID, documento.Version, estado, guardadas); err != nil {
+		if !errors.Is(err, repository.ErrDocumentoCambiado) {
+			log.Printf("⚠️ Error registrando el texto del documento %s: %v", documento.ID.Hex(), err)
+		}
+	}
+	return estado
+}
+
+// extraer reads the current file of an attachment and returns the text of its pages
+func (s *TextoService) extraer(documento *models.Documento) (models.EstadoTexto, []string) {
+	if !documento.Formato.ConTexto() {
+		return models.TextoNoSoportado, nil
+	}
+
+	contenido, err := s.storage.Get(documento.Clave)
+	if err != nil {
+		log.Printf("⚠️ No se pudo leer %s para extraer su texto: %v", documento.Clave, err)
+		return models.TextoError, nil
+	}
+	data, err := io.ReadAll(contenido)
+	contenido.Close()
+	if err != nil {
+		log.Printf("⚠️ No se pudo leer %s para extraer su texto: %v", documento.Clave, err)
+		return models.TextoError, nil
+	}
+
+	var paginas []string
+	if documento.Formato == models.FormatoDocumentoPDF {
+		paginas, err = extract.PDF(data)
+	} else {
+		paginas, err = extract.DOCX(data)
+	}
+	switch {
+	case errors.Is(err, extract.ErrEncrypted):
+		return models.TextoNoSoportado, nil
+	case err != nil:
+		log.Printf("⚠️ No se pudo extraer el texto de %s: %v", documento.Clave, err)
+		return models.TextoError, nil
+	}
+	return models.TextoExtraido, paginas
+}
+
+// Buscar searches the text of the attachments readable within the scope and returns the
+// matching pages with a highlighted snippet. The paging parameters are normalised in place.
+func (s *TextoService) Buscar(params *models.BusquedaTextoParams, scope models.AccessScope) ([]models.ResultadoBusquedaTexto, int64, error) {
+	if params.Page < 1 {
+		params.Page = 1
+	}
+	if params.Limit < 1 {
+		params.Limit = 10
+	}
+	if params.Limit > 50 {
+		params.Limit = 50
+	}
+
+	consulta := strings.TrimSpace(params.Q)
+	resultados, total, err := s.textoRepo.Buscar(consulta, scope, scope.HasPermission(models.PermissionDocumentoConfidential), params.Page, params.Limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	terminos := terminosBusqueda(consulta)
+	registrados := make(map[primitive.ObjectID]bool)
+	var entries []models.AuditLog
+	for i := range resultados {
+		resultado := &resultados[i]
+		resultado.Fragmento = fragmentoTexto(resultado.Texto, terminos)
+
+		if registrados[resultado.ExpedienteID] {
+			continue
+		}
+		registrados[resultado.ExpedienteID] = true
+		if entry, ok := classifiedAccessEntry(scope, "busqueda_documentos", resultado.ExpedienteID, resultado.CIP, resultado.Clasificacion); ok {
+			entries = append(entries, entry)
+		}
+	}
+	s.expedienteService.storeClassifiedAccess(entries)
+
+	return resultados, total, nil
+}
+
+// terminosBusqueda returns the folded words of a search, leaving out the excluded ones
+func terminosBusqueda(consulta string) []string {
+	var terminos []string
+	for _, campo := range strings.Fields(consulta) {
+		if strings.HasPrefix(campo, "-") {
+			continue
+		}
+		for _, palabra := range strings.FieldsFunc(campo, noAlfanumerico) {
+			terminos = append(terminos, plegar(palabra))
+		}
+	}
+	return terminos
+}
+
+func noAlfanumerico(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// plegar uppercases a word and removes its accents, as the text index compares words
+func plegar(palabra string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(palabra) {
+		switch {
+		case r == 'Ñ':
+			b.WriteRune('N')
+		case letrasSinAcento[r] != 0:
+			b.WriteRune(letrasSinAcento[r])
+		case !unicode.Is(unicode.Mn, r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// coincide reports whether a word of the text matches a search term. The index reduces words
+// to their stem, so a shared beginning that leaves at most a short ending counts as a match.
+func coincide(palabra, termino string) bool {
+	if palabra == termino {
+		return true
+	}
+	comun := 0
+	for comun < len(palabra) && comun < len(termino) && palabra[comun] == termino[comun] {
+		comun++
+	}
+	return comun >= 4 && comun >= min(len(palabra), len(termino))-2
+}
+
+// Longitud del fragmento y contexto previo a la primera coincidencia, en caracteres
+const (
+	largoFragmento    = 240
+	contextoFragmento = 80
+)
+
+// fragmentoTexto cuts a snippet of a page around its first match and splits it into plain
+// and highlighted segments
+func fragmentoTexto(texto string, terminos []string) []models.SegmentoTexto {
+	runas := []rune(strings.ReplaceAll(texto, "\n", " "))
+
+	type palabra struct{ inicio, fin int }
+	var palabras []palabra
+	for i := 0; i < len(runas); {
+		if noAlfanumerico(runas[i]) {
+			i++
+			continue
+		}
+		inicio := i
+		for i < len(runas) && !noAlfanumerico(runas[i]) {
+			i++
+		}
+		palabras = append(palabras, palabra{inicio, i})
+	}
+
+	resaltada := make([]bool, len(palabras))
+	primera := -1
+	for i, p := range palabras {
+		plegada := plegar(string(runas[p.inicio:p.fin]))
+		for _, termino := range terminos {
+			if coincide(plegada, termino) {
+				resaltada[i] = true
+				if primera < 0 {
+					primera = p.inicio
+				}
+				break
+			}
+		}
+	}
+
+	// La ventana empieza y termina en límites de palabra
+	inicio := 0
+	if primera > contextoFragmento {
+		inicio = primera - contextoFragmento
+		for inicio < primera && !noAlfanumerico(runas[inicio-1]) {
+			inicio++
+		}
+	}
+	fin := min(inicio+largoFragmento, len(runas))
+	for fin < len(runas) && fin > inicio && !noAlfanumerico(runas[fin-1]) && !noAlfanumerico(runas[fin]) {
+		fin--
+	}
+
+	var segmentos []models.SegmentoTexto
+	agregar := func(s string, marcado bool) {
+		if s == "" {
+			return
+		}
+		if n := len(segmentos); n > 0 && segmentos[n-1].Resaltado == marcado {
+			segmentos[n-1].Texto += s
+			return
+		}
+		segmentos = append(segmentos, models.SegmentoTexto{Texto: s, Resaltado: marcado})
+	}
+
+	if inicio > 0 {
+		agregar("…", false)
+	}
+	pos := inicio
+	for i, p := range palabras {
+		if p.fin <= inicio || p.inicio >= fin || !resaltada[i] {
+			continue
+		}
+		agregar(string(runas[pos:p.inicio]), false)
+		agregar(string(runas[p.inicio:p.fin]), true)
+		pos = p.fin
+	}
+	agregar(string(runas[pos:fin]), false)
+	if fin < len(runas) {
+		agregar("…", false)
+	}
+
+	if segmentos == nil {
+		return []models.SegmentoTexto{}
+	}
+	return segmentos
+}
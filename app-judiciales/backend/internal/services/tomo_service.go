@@ -0,0 +1,266 @@
+package services
+
+import (
+	"errors"
+	"expedientes-backend/internal/models"
+	"expedientes-backend/internal/repository"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tomo errors
+var (
+	ErrRangoPaginasInvalido = errors.New("la página final del tomo no puede ser menor que la inicial")
+	ErrRangoPaginasSolapado = errors.New("el rango de páginas se superpone con otro tomo del expediente")
+	ErrTomoFuera            = errors.New("solo se puede eliminar un tomo que está dentro del archivo")
+	ErrPaginasPorTomos      = errors.New("el número de páginas de un expediente con tomos se calcula a partir de sus tomos")
+)
+
+// TomoService manages the physical volumes of expedientes and keeps the aggregates of
+// their expediente up to date
+type TomoService struct {
+	tomoRepo       *repository.TomoRepository
+	expedienteRepo *repository.ExpedienteRepository
+	estadoService  *EstadoService
+}
+
+// NewTomoService creates a new tomo service
+func NewTomoService(tomoRepo *repository.TomoRepository, expedienteRepo *repository.ExpedienteRepository, estadoService *EstadoService) *TomoService {
+	return &TomoService{
+		tomoRepo:       tomoRepo,
+		expedienteRepo: expedienteRepo,
+		estadoService:  estadoService,
+	}
+}
+
+// GetTomos returns the tomos of an expediente visible to the caller
+func (s *TomoService) GetTomos(expedienteID string, scope models.AccessScope) ([]models.Tomo, error) {
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tomoRepo.GetByExpediente(expediente.ID)
+}
+
+// CreateTomo adds a tomo to an expediente. It is stored at the location of the expediente
+// unless another one is given, and starts inside the archive.
+func (s *TomoService) CreateTomo(expedienteID string, req *models.CreateTomoRequest, scope models.AccessScope) (*models.Tomo, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid createdBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	existentes, err := s.tomoRepo.GetByExpediente(expediente.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	numero := req.Numero
+	if numero == 0 {
+		numero = 1
+		for _, t := range existentes {
+			if t.Numero >= numero {
+				numero = t.Numero + 1
+			}
+		}
+	}
+
+	if err := validarRangoPaginas(req.PaginaDesde, req.PaginaHasta, primitive.NilObjectID, existentes); err != nil {
+		return nil, err
+	}
+
+	ubicacion := strings.ToUpper(strings.TrimSpace(req.Ubicacion))
+	if ubicacion == "" {
+		ubicacion = expediente.Ubicacion
+	}
+
+	tomo := &models.Tomo{
+		ExpedienteID:  expediente.ID,
+		Numero:        numero,
+		PaginaDesde:   req.PaginaDesde,
+		PaginaHasta:   req.PaginaHasta,
+		NumeroPaginas: req.PaginaHasta - req.PaginaDesde + 1,
+		Ubicacion:     ubicacion,
+		Estado:        models.EstadoDentro,
+		CreatedBy:     scope.UserID,
+		UpdatedBy:     scope.UserID,
+	}
+	if err := s.tomoRepo.Create(tomo); err != nil {
+		return nil, err
+	}
+
+	if err := s.actualizarResumen(expediente.ID); err != nil {
+		return nil, err
+	}
+
+	return tomo, nil
+}
+
+// UpdateTomo changes the page range or location of a tomo
+func (s *TomoService) UpdateTomo(expedienteID, tomoID string, req *models.UpdateTomoRequest, scope models.AccessScope) (*models.Tomo, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	tomo, err := s.tomoRepo.GetByID(expediente.ID, tomoID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := bson.M{"updated_by": scope.UserID}
+	if req.PaginaDesde != nil || req.PaginaHasta != nil {
+		desde, hasta := tomo.PaginaDesde, tomo.PaginaHasta
+		if req.PaginaDesde != nil {
+			desde = *req.PaginaDesde
+		}
+		if req.PaginaHasta != nil {
+			hasta = *req.PaginaHasta
+		}
+
+		existentes, err := s.tomoRepo.GetByExpediente(expediente.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := validarRangoPaginas(desde, hasta, tomo.ID, existentes); err != nil {
+			return nil, err
+		}
+
+		updates["pagina_desde"] = desde
+		updates["pagina_hasta"] = hasta
+		updates["numero_paginas"] = hasta - desde + 1
+	}
+	if req.Ubicacion != nil {
+		updates["ubicacion"] = strings.ToUpper(strings.TrimSpace(*req.Ubicacion))
+	}
+
+	if err := s.tomoRepo.Update(tomo.ID, updates); err != nil {
+		return nil, err
+	}
+
+	if err := s.actualizarResumen(expediente.ID); err != nil {
+		return nil, err
+	}
+
+	return s.tomoRepo.GetByID(expediente.ID, tomoID)
+}
+
+// DeleteTomo removes a tomo that is inside the archive
+func (s *TomoService) DeleteTomo(expedienteID, tomoID string, scope models.AccessScope) error {
+	scope = scope.WithoutBreakGlass()
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return err
+	}
+
+	tomo, err := s.tomoRepo.GetByID(expediente.ID, tomoID)
+	if err != nil {
+		return err
+	}
+	if tomo.Fuera() {
+		return fmt.Errorf("%w: tomo %d está %s", ErrTomoFuera, tomo.Numero, tomo.Estado)
+	}
+
+	if err := s.tomoRepo.Delete(tomo.ID); err != nil {
+		return err
+	}
+
+	return s.actualizarResumen(expediente.ID)
+}
+
+// CambiarEstadoTomo moves a single tomo to another state following the same transitions,
+// permissions and justification rules as the expediente
+func (s *TomoService) CambiarEstadoTomo(expedienteID, tomoID string, req *models.UpdateEstadoRequest, scope models.AccessScope) (*models.CambioEstado, error) {
+	if scope.UserID.IsZero() {
+		return nil, errors.New("invalid updatedBy ID")
+	}
+	scope = scope.WithoutBreakGlass()
+
+	if !req.Estado.IsValid() {
+		return nil, fmt.Errorf("%w: %s", ErrEstadoInvalido, req.Estado)
+	}
+	justificacion := strings.TrimSpace(req.Justificacion)
+	if justificacion == "" {
+		return nil, ErrJustificacionRequerida
+	}
+
+	expediente, err := s.expedienteRepo.GetByID(expedienteID, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	tomo, err := s.tomoRepo.GetByID(expediente.ID, tomoID)
+	if err != nil {
+		return nil, err
+	}
+
+	desde := tomo.Estado
+	if err := s.estadoService.AutorizarTransicion(desde, req.Estado, scope); err != nil {
+		return nil, err
+	}
+
+	if err := s.tomoRepo.UpdateEstado(tomo.ID, desde, req.Estado, scope.UserID); err != nil {
+		return nil, err
+	}
+
+	cambio := &models.CambioEstado{
+		ExpedienteID:  expediente.ID,
+		TomoID:        &tomo.ID,
+		Tomo:          tomo.Numero,
+		Desde:         desde,
+		Hasta:         req.Estado,
+		Justificacion: justificacion,
+		UsuarioID:     scope.UserID,
+		Usuario:       scope.Email,
+	}
+	s.estadoService.RegistrarCambio(cambio)
+
+	if err := s.actualizarResumen(expediente.ID); err != nil {
+		return nil, err
+	}
+
+	return cambio, nil
+}
+
+// actualizarResumen recomputes the tomo aggregates stored on the expediente
+func (s *TomoService) actualizarResumen(expedienteID primitive.ObjectID) error {
+	resumen, err := s.tomoRepo.Resumen(expedienteID)
+	if err != nil {
+		return err
+	}
+
+	return s.expedienteRepo.UpdateResumenTomos(expedienteID, resumen)
+}
+
+// validarRangoPaginas checks a page range and that it does not overlap the other tomos
+func validarRangoPaginas(desde, hasta int, tomoID primitive.ObjectID, existentes []models.Tomo) error {
+	if hasta < desde {
+		return ErrRangoPaginasInvalido
+	}
+
+	for _, t := range existentes {
+		if t.ID == tomoID {
+			continue
+		}
+		if desde <= t.PaginaHasta && t.PaginaDesde <= hasta {
+			return fmt.Errorf("%w: tomo %d (páginas %d-%d)", ErrRangoPaginasSolapado, t.Numero, t.PaginaDesde, t.PaginaHasta)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// UbicacionRules are the archive rules used to derive an ubicación from a name
+type UbicacionRules struct {
+	// Particulas are the leading surname particles that are skipped ("DE", "DEL", "LA", ...)
+	Particulas []string
+	// EneSeparada files Ñ as its own letter after N; otherwise Ñ is filed as N
+	EneSeparada bool
+}
+
+// UbicacionEngine calculates the two-letter ubicación code of an expediente
+type UbicacionEngine struct {
+	particulas  map[string]bool
+	eneSeparada bool
+}
+
+// NewUbicacionEngine creates a new ubicación engine for the given rules
+func NewUbicacionEngine(rules UbicacionRules) *UbicacionEngine {
+	particulas := make(map[string]bool, len(rules.Particulas))
+	for _, particula := range rules.Particulas {
+		if p := strings.ToUpper(strings.TrimSpace(particula)); p != "" {
+			particulas[p] = true
+		}
+	}
+
+	return &UbicacionEngine{
+		particulas:  particulas,
+		eneSeparada: rules.EneSeparada,
+	}
+}
+
+// letrasSinAcento folds accented capitals to the letter they are filed under
+var letrasSinAcento = map[rune]rune{
+	'Á': 'A', 'À': 'A', 'Â': 'A', 'Ä': 'A', 'Ã': 'A',
+	'É': 'E', 'È': 'E', 'Ê': 'E', 'Ë': 'E',
+	'Í': 'I', 'Ì': 'I', 'Î': 'I', 'Ï': 'I',
+	'Ó': 'O', 'Ò': 'O', 'Ô': 'O', 'Ö': 'O', 'Õ': 'O',
+	'Ú': 'U', 'Ù': 'U', 'Û': 'U', 'Ü': 'U',
+	'Ç': 'C',
+}
+
+// Calcular returns the ubicación for a "APELLIDOS NOMBRES" string: the first two letters
+// of the first surname, skipping particles, with accents removed and Ñ filed per the rules
+func (e *UbicacionEngine) Calcular(apellidosNombres string) string {
+	tokens := e.tokens(apellidosNombres)
+
+	// Saltar partículas iniciales ("DE LA CRUZ" se archiva en CR), conservando al menos un token
+	inicio := 0
+	for inicio < len(tokens)-1 && e.particulas[tokens[inicio]] {
+		inicio++
+	}
+
+	// Un apellido de una sola letra se completa con el token siguiente
+	var letras []rune
+	for _, token := range tokens[inicio:] {
+		letras = append(letras, []rune(token)...)
+		if len(letras) >= 2 {
+			break
+		}
+	}
+
+	switch len(letras) {
+	case 0:
+		return "ZZ" // Default para casos sin apellido
+	case 1:
+		return string(letras[0]) + "Z" // Completar con Z si es muy corto
+	}
+	return string(letras[:2])
+}
+
+// tokens splits a name into normalised words. Hyphenated surnames ("GARCIA-LOPEZ")
+// yield one token per part; words without letters are dropped.
+func (e *UbicacionEngine) tokens(apellidosNombres string) []string {
+	campos := strings.FieldsFunc(strings.ToUpper(apellidosNombres), func(r rune) bool {
+		return unicode.IsSpace(r) || r == '-' || r == '\u2010' || r == '\u2013'
+	})
+
+	tokens := make([]string, 0, len(campos))
+	for _, campo := range campos {
+		if token := e.normalizar(campo); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// normalizar removes accents and any non-letter (apostrophes, dots) from a word
+func (e *UbicacionEngine) normalizar(palabra string) string {
+	var b strings.Builder
+	for _, r := range palabra {
+		switch {
+		case r == '\u0303' && strings.HasSuffix(b.String(), "N"):
+			// N + tilde combinante (texto en forma NFD) es una Ñ
+			if e.eneSeparada {
+				s := b.String()
+				b.Reset()
+				b.WriteString(s[:len(s)-1])
+				b.WriteRune('Ñ')
+			}
+		case unicode.Is(unicode.Mn, r):
+			// Otras marcas combinantes (acentos en forma NFD) se descartan
+		case r == 'Ñ':
+			if e.eneSeparada {
+				b.WriteRune('Ñ')
+			} else {
+				b.WriteRune('N')
+			}
+		case letrasSinAcento[r] != 0:
+			b.WriteRune(letrasSinAcento[r])
+		case unicode.IsLetter(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package services
+
+import "testing"
+
+func TestUbicacionEngineCalcular(t *testing.T) {
+	particulas := []string{"DE", "DEL", "LA", "LAS", "LOS"}
+	tests := []struct {
+		nombre           string
+		apellidosNombres string
+		eneSeparada      bool
+		want             string
+	}{
+		{"apellido simple", "GARCIA PEREZ JUAN", true, "GA"},
+		{"minúsculas", "garcia perez juan", true, "GA"},
+		{"Ñ separada", "ÑAHUI QUISPE ROSA", true, "ÑA"},
+		{"Ñ como N", "ÑAHUI QUISPE ROSA", false, "NA"},
+		{"ñ minúscula", "ñahui quispe rosa", true, "ÑA"},
+		{"Ñ en la segunda letra", "MUÑOZ DIAZ ANA", true, "MU"},
+		{"acento", "ÁLVAREZ TORRES LUIS", true, "AL"},
+		{"acento en la segunda letra", "RÍOS LUNA PEDRO", true, "RI"},
+		{"diéresis", "ÜRSULA ARCE", true, "UR"},
+		{"partículas", "DE LA CRUZ MENDOZA CARLOS", true, "CR"},
+		{"partícula DEL", "DEL AGUILA RAMOS MARIA", true, "AG"},
+		{"partículas en minúsculas", "de los rios vega ana", true, "RI"},
+		{"solo partículas", "DE LA", true, "LA"},
+		{"guion", "GARCIA-LOPEZ TORRES JUAN", true, "GA"},
+		{"guion tras partícula", "DE-LA-TORRE PAZ LUIS", true, "TO"},
+		{"apóstrofo", "D'ANGELO RUIZ MARCO", true, "DA"},
+		{"apellido de una letra", "O BRIEN SMITH JOHN", true, "OB"},
+		{"una sola letra", "X", true, "XZ"},
+		{"vacío", "", true, "ZZ"},
+		{"sin letras", "123 -- ...", true, "ZZ"},
+		{"espacios extra", "   PEREZ   LUNA  ", true, "PE"},
+		{"NFD Ñ separada", "N\u0303AHUI QUISPE", true, "ÑA"},
+		{"NFD Ñ como N", "N\u0303AHUI QUISPE", false, "NA"},
+		{"NFD acento", "A\u0301LVAREZ TORRES", true, "AL"},
+		{"NFD acento en la segunda letra", "RI\u0301OS LUNA", false, "RI"},
+	}
+
+	engines := map[bool]*UbicacionEngine{
+		true:  NewUbicacionEngine(UbicacionRules{Particulas: particulas, EneSeparada: true}),
+		false: NewUbicacionEngine(UbicacionRules{Particulas: particulas, EneSeparada: false}),
+	}
+	for _, tt := range tests {
+		if got := engines[tt.eneSeparada].Calcular(tt.apellidosNombres); got != tt.want {
+			t.Errorf("%s: Calcular(%q) con EneSeparada=%v = %q, want %q", tt.nombre, tt.apellidosNombres, tt.eneSeparada, got, tt.want)
+		}
+	}
+}
+
+func TestUbicacionEngineParticulas(t *testing.T) {
+	// Las partículas se normalizan y, sin partículas configuradas, "DE LA CRUZ" se archiva en DE
+	tests := []struct {
+		particulas []string
+		want       string
+	}{
+		{nil, "DE"},
+		{[]string{" de ", "la", ""}, "CR"},
+	}
+	for _, tt := range tests {
+		engine := NewUbicacionEngine(UbicacionRules{Particulas: tt.particulas})
+		if got := engine.Calcular("DE LA CRUZ MENDOZA"); got != tt.want {
+			t.Errorf("Calcular con partículas %q = %q, want %q", tt.particulas, got, tt.want)
+		}
+	}
+}
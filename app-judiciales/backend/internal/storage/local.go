@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// LocalStorage stores files in a directory of the server's filesystem
+type LocalStorage struct {
+	root string
+}
+
+// NewLocalStorage creates a local storage rooted at dir, creating the directory if needed
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio de documentos %s: %w", root, err)
+	}
+	return &LocalStorage{root: root}, nil
+}
+
+// path returns the file path of a key inside the root
+func (s *LocalStorage) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes the content to a temporary file and renames it into place, so a failed upload
+// never leaves a truncated file under the key
+func (s *LocalStorage) Put(key string, r io.Reader, size int64, contentType string) error {
+	destino, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(destino), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(destino), ".subida-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o640); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), destino)
+}
+
+// Get opens the file stored under the key
+func (s *LocalStorage) Get(key string) (io.ReadCloser, error) {
+	origen, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(origen)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file stored under the key
+func (s *LocalStorage) Delete(key string) error {
+	destino, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(destino); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
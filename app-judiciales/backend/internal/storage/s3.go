@@ -0,0 +1,375 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server-side encryption modes accepted by S3Config.SSE
+const (
+	SSENinguna = ""
+	SSEAES256  = "AES256"
+	SSEKMS     = "aws:kms"
+)
+
+// maxTamanoPutS3 is the largest object a single PUT accepts
+const maxTamanoPutS3 = 5 << 30
+
+// unsignedPayload lets uploads stream without hashing the body before sending it
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config holds the connection settings of an S3-compatible object store
+type S3Config struct {
+	Endpoint string // e.g. https://s3.us-east-1.amazonaws.com or http://minio:9000
+	// PublicEndpoint is used for presigned URLs when browsers reach the store at another
+	// address than the backend, as with MinIO inside Docker; defaults to Endpoint
+	PublicEndpoint string
+	Region         string
+	Bucket         string
+	AccessKey      string
+	SecretKey      string
+	PathStyle      bool // Bucket in the path instead of the host name; MinIO needs it
+
+	SSE      string // SSENinguna, SSEAES256 or SSEKMS
+	KMSKeyID string // Optional key for SSEKMS; the bucket's default key is used if empty
+}
+
+// S3Storage stores files as objects of a bucket in an S3-compatible store. Requests are
+// signed with AWS Signature Version 4.
+type S3Storage struct {
+	cfg            S3Config
+	endpoint       *url.URL
+	publicEndpoint *url.URL
+	client         *http.Client
+}
+
+// NewS3Storage creates an object storage driver for the bucket
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	endpoint, err := parseEndpoint(cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	publicEndpoint := endpoint
+	if cfg.PublicEndpoint != "" {
+		if publicEndpoint, err = parseEndpoint(cfg.PublicEndpoint); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.New("el almacenamiento S3 requiere bucket, access key y secret key")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	switch cfg.SSE {
+	case SSENinguna, SSEAES256, SSEKMS:
+	default:
+		return nil, fmt.Errorf("cifrado S3 inválido: %q (use %s o %s)", cfg.SSE, SSEAES256, SSEKMS)
+	}
+	if cfg.KMSKeyID != "" && cfg.SSE != SSEKMS {
+		return nil, fmt.Errorf("la clave KMS requiere el cifrado %s", SSEKMS)
+	}
+
+	return &S3Storage{
+		cfg:            cfg,
+		endpoint:       endpoint,
+		publicEndpoint: publicEndpoint,
+		// Sin timeout global: las descargas grandes se leen mientras el cliente las recibe
+		client: &http.Client{Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			ResponseHeaderTimeout: 30 * time.Second,
+			IdleConnTimeout:       90 * time.Second,
+		}},
+	}, nil
+}
+
+// parseEndpoint validates the base URL of an object store
+func parseEndpoint(raw string) (*url.URL, error) {
+	endpoint, err := url.Parse(strings.TrimRight(raw, "/"))
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("endpoint S3 inválido: %q", raw)
+	}
+	return endpoint, nil
+}
+
+// objectURL returns the URL of the object stored under the key, as seen from the endpoint
+func (s *S3Storage) objectURL(endpoint *url.URL, key string) *url.URL {
+	u := *endpoint
+	if s.cfg.PathStyle {
+		u.Path = u.Path + "/" + s.cfg.Bucket + "/" + key
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+		u.Path = u.Path + "/" + key
+	}
+	u.RawPath = encodePath(u.Path)
+	return &u
+}
+
+// Put streams the content to the object store without buffering it, so large scans are
+// uploaded as they are read. size must be the exact length of the content.
+func (s *S3Storage) Put(key string, r io.Reader, size int64, contentType string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+	if size < 0 || size > maxTamanoPutS3 {
+		return fmt.Errorf("tamaño de archivo no admitido por S3: %d bytes", size)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectURL(s.endpoint, key).String(), io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if s.cfg.SSE != SSENinguna {
+		req.Header.Set("X-Amz-Server-Side-Encryption", s.cfg.SSE)
+		if s.cfg.KMSKeyID != "" {
+			req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", s.cfg.KMSKeyID)
+		}
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get opens the object stored under the key; the content is streamed from the store
+func (s *S3Storage) Get(key string) (io.ReadCloser, error) {
+	if err := validKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.objectURL(s.endpoint, key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object stored under the key; S3 does not report missing objects
+func (s *S3Storage) Delete(key string) error {
+	if err := validKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodDelete, s.objectURL(s.endpoint, key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PresignGet returns a URL that downloads the object without credentials until it expires.
+// filename and inline set the Content-Disposition of the response.
+func (s *S3Storage) PresignGet(key string, expiracion time.Duration, filename string, inline bool) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	segundos := int64(expiracion / time.Second)
+	if segundos < 1 || segundos > 7*24*3600 {
+		return "", fmt.Errorf("expiración de enlace inválida: %s", expiracion)
+	}
+	return s.presignGet(key, segundos, filename, inline, time.Now().UTC()), nil
+}
+
+// presignGet builds the presigned URL of PresignGet as signed at now
+func (s *S3Storage) presignGet(key string, segundos int64, filename string, inline bool, now time.Time) string {
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.credentialScope(now)
+
+	disposicion := "attachment"
+	if inline {
+		disposicion = "inline"
+	}
+
+	u := s.objectURL(s.publicEndpoint, key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.cfg.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.FormatInt(segundos, 10))
+	query.Set("X-Amz-SignedHeaders", "host")
+	query.Set("response-content-disposition", contentDisposition(disposicion, filename))
+
+	canonicalQuery := canonicalQueryString(query)
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		canonicalQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+
+	u.RawQuery = canonicalQuery + "&X-Amz-Signature=" + s.signature(now, amzDate, scope, canonicalRequest)
+	return u.String()
+}
+
+// do signs and sends a request, turning error responses into Go errors
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.sign(req, time.Now().UTC())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error de conexión con el almacenamiento S3: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	var s3Err struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if xml.Unmarshal(body, &s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("almacenamiento S3 respondió %d %s: %s", resp.StatusCode, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("almacenamiento S3 respondió %d", resp.StatusCode)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to the request, signed at now.
+// The payload is sent unsigned so uploads can stream; TLS protects it in transit.
+func (s *S3Storage) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	scope := s.credentialScope(now)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	// Se firman el host y todas las cabeceras propias de la petición
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQueryString(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, s.signature(now, amzDate, scope, canonicalRequest)))
+}
+
+// credentialScope returns the date/region/service scope of a signature
+func (s *S3Storage) credentialScope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.cfg.Region + "/s3/aws4_request"
+}
+
+// signature computes the Signature Version 4 of a canonical request
+func (s *S3Storage) signature(now time.Time, amzDate, scope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// uriEncode percent-encodes everything except the RFC 3986 unreserved characters, as
+// Signature Version 4 requires; slashes are kept when encodeSlash is false
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// encodePath returns the escaped form of an object path
+func encodePath(path string) string {
+	return uriEncode(path, false)
+}
+
+// canonicalQueryString sorts and encodes query parameters for signing
+func canonicalQueryString(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"path"
+	"strings"
+	"time"
+)
+
+// Storage errors
+var (
+	ErrNotFound   = errors.New("archivo no encontrado en el almacenamiento")
+	ErrInvalidKey = errors.New("clave de almacenamiento inválida")
+)
+
+// Storage keeps the files of document attachments. Keys are slash-separated relative paths
+// such as "expedientes/<id>/<documento>.pdf"; drivers map them to their own layout.
+type Storage interface {
+	// Put stores the content under the key, replacing any previous file
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get opens the file stored under the key; the caller must close it
+	Get(key string) (io.ReadCloser, error)
+	// Delete removes the file stored under the key; a missing file is not an error
+	Delete(key string) error
+}
+
+// Presigner is implemented by drivers that can hand out temporary download URLs, so clients
+// fetch large files straight from the store instead of through the API
+type Presigner interface {
+	// PresignGet returns a URL that downloads the file under the key until it expires
+	PresignGet(key string, expiracion time.Duration, filename string, inline bool) (string, error)
+}
+
+// Checksum returns the hex SHA-256 and the size of the file stored under the key
+func Checksum(s Storage, key string) (string, int64, error) {
+	r, err := s.Get(key)
+	if err != nil {
+		return "", 0, err
+	}
+	defer r.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, r)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// contentDisposition formats a Content-Disposition value with the original file name
+func contentDisposition(disposicion, filename string) string {
+	return mime.FormatMediaType(disposicion, map[string]string{"filename": filename})
+}
+
+// validKey rejects keys that are absolute or could escape the storage root
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
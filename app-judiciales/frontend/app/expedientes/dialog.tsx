@@ -58,7 +58,7 @@ export function DeleteDialog({
                         <p className="text-gray-700">
                             ¿Estás seguro de que quieres eliminar el expediente{' '}
                             <span className="font-semibold">
-                                Orden {expedienteToDelete.orden} - {expedienteToDelete.apellidos_nombres}
+                                N° {expedienteToDelete.numero_registro} - {expedienteToDelete.apellidos_nombres}
                             </span>
                             ?
                         </p>
@@ -1,7 +1,7 @@
 'use client'
 
 import { useState, useEffect } from 'react'
-import { Expediente, CreateExpedienteInput, UpdateExpedienteInput, Grado, SituacionMilitar, ExpedienteEstado, GradoLabels, SituacionMilitarLabels, EstadoExpedienteLabels } from '@/lib/types'
+import { Expediente, CreateExpedienteInput, UpdateExpedienteInput, Grado, SituacionMilitar, GradoLabels, SituacionMilitarLabels } from '@/lib/types'
 import { expedienteSchema } from '@/lib/validations'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
@@ -22,14 +22,14 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
         numero_paginas: expediente?.numero_paginas || 1,
         situacion_militar: expediente?.situacion_militar || '' as SituacionMilitar,
         ubicacion: expediente?.ubicacion || '',
-        orden: expediente?.orden || 1,
         ano: expediente?.ano || new Date().getFullYear(), // Año por defecto: año actual
-        ...(expediente && { estado: expediente.estado })
     })
     const [errors, setErrors] = useState<Record<string, string>>({})
     const [isSubmitting, setIsSubmitting] = useState(false)
 
     const isUpdate = !!expediente
+    // Las páginas de un expediente con tomos se calculan a partir de sus tomos
+    const paginasPorTomos = (expediente?.tomos ?? 0) > 0
 
     useEffect(() => {
         if (expediente) {
@@ -40,9 +40,7 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
                 numero_paginas: expediente.numero_paginas,
                 situacion_militar: expediente.situacion_militar,
                 ubicacion: expediente.ubicacion,
-                orden: expediente.orden,
-                ano: expediente.ano,
-                estado: expediente.estado
+                ano: expediente.ano
             })
         }
     }, [expediente])
@@ -86,7 +84,13 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
 
         setIsSubmitting(true)
         try {
-            await onSubmit(formData)
+            if (paginasPorTomos) {
+                // eslint-disable-next-line @typescript-eslint/no-unused-vars
+                const { numero_paginas, ...data } = formData
+                await onSubmit(data)
+            } else {
+                await onSubmit(formData)
+            }
         } finally {
             setIsSubmitting(false)
         }
@@ -94,7 +98,6 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
 
     const gradoOptions: Grado[] = ['GRAL', 'CRL', 'TTE CRL', 'MY', 'CAP', 'TTE', 'STTE', 'TCO', 'SSOO', 'EC', 'TROPA']
     const situacionMilitarOptions: SituacionMilitar[] = ['Actividad', 'Retiro']
-    const estadoOptions: ExpedienteEstado[] = ['dentro', 'fuera']
 
     return (
         <form onSubmit={handleSubmit} className="space-y-6">
@@ -126,7 +129,7 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
                         className={`w-full px-3 py-2 border rounded-md shadow-sm focus:outline-none focus:ring-2 focus:ring-indigo-500 ${
                             errors.grado ? 'border-red-500' : 'border-gray-300'
                         }`}
-                        disabled={isSubmitting}
+                        disabled={isSubmitting || isUpdate}
                     >
                         <option value="">Seleccione un grado</option>
                         {gradoOptions.map((grado) => (
@@ -138,6 +141,9 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
                     {errors.grado && (
                         <p className="mt-1 text-sm text-red-600">{errors.grado}</p>
                     )}
+                    {isUpdate && (
+                        <p className="mt-1 text-xs text-gray-500">Se modifica registrando un evento de carrera</p>
+                    )}
                 </div>
 
                 {/* Situación Militar */}
@@ -152,7 +158,7 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
                         className={`w-full px-3 py-2 border rounded-md shadow-sm focus:outline-none focus:ring-2 focus:ring-indigo-500 ${
                             errors.situacion_militar ? 'border-red-500' : 'border-gray-300'
                         }`}
-                        disabled={isSubmitting}
+                        disabled={isSubmitting || isUpdate}
                     >
                         <option value="">Seleccione situación</option>
                         {situacionMilitarOptions.map((situacion) => (
@@ -164,6 +170,9 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
                     {errors.situacion_militar && (
                         <p className="mt-1 text-sm text-red-600">{errors.situacion_militar}</p>
                     )}
+                    {isUpdate && (
+                        <p className="mt-1 text-xs text-gray-500">Se modifica registrando un evento de carrera</p>
+                    )}
                 </div>
 
                 {/* Apellidos y Nombres */}
@@ -217,32 +226,16 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
                         value={formData.numero_paginas}
                         onChange={(e) => handleChange('numero_paginas', parseInt(e.target.value) || 1)}
                         className={errors.numero_paginas ? 'border-red-500' : ''}
-                        disabled={isSubmitting}
+                        disabled={isSubmitting || paginasPorTomos}
                     />
+                    {paginasPorTomos && (
+                        <p className="mt-1 text-xs text-gray-500">Suma de las páginas de sus {expediente?.tomos} tomos</p>
+                    )}
                     {errors.numero_paginas && (
                         <p className="mt-1 text-sm text-red-600">{errors.numero_paginas}</p>
                     )}
                 </div>
 
-                {/* Orden */}
-                <div>
-                    <label htmlFor="orden" className="block text-sm font-medium text-gray-700 mb-1">
-                        Orden <span className="text-red-500">*</span>
-                    </label>
-                    <Input
-                        id="orden"
-                        type="number"
-                        min="1"
-                        value={formData.orden}
-                        onChange={(e) => handleChange('orden', parseInt(e.target.value) || 1)}
-                        className={errors.orden ? 'border-red-500' : ''}
-                        disabled={isSubmitting}
-                    />
-                    {errors.orden && (
-                        <p className="mt-1 text-sm text-red-600">{errors.orden}</p>
-                    )}
-                </div>
-
                 {/* Año */}
                 <div>
                     <label htmlFor="ano" className="block text-sm font-medium text-gray-700 mb-1">
@@ -264,36 +257,8 @@ export function ExpedienteForm({ expediente, onSubmit, onCancel }: ExpedienteFor
                     )}
                 </div>
 
-                {/* Estado (only for updates) */}
-                {isUpdate && (
-                    <div>
-                        <label htmlFor="estado" className="block text-sm font-medium text-gray-700 mb-1">
-                            Estado
-                        </label>
-                        <select
-                            id="estado"
-                            value={(formData as UpdateExpedienteInput).estado || ''}
-                            onChange={(e) => handleChange('estado', e.target.value)}
-                            className={`w-full px-3 py-2 border rounded-md shadow-sm focus:outline-none focus:ring-2 focus:ring-indigo-500 ${
-                                errors.estado ? 'border-red-500' : 'border-gray-300'
-                            }`}
-                            disabled={isSubmitting}
-                        >
-                            <option value="">Seleccione estado</option>
-                            {estadoOptions.map((estado) => (
-                                <option key={estado} value={estado}>
-                                    {EstadoExpedienteLabels[estado]}
-                                </option>
-                            ))}
-                        </select>
-                        {errors.estado && (
-                            <p className="mt-1 text-sm text-red-600">{errors.estado}</p>
-                        )}
-                    </div>
-                )}
-
                 {/* Ubicación */}
-                <div className={isUpdate ? '' : 'md:col-span-2'}>
+                <div className="md:col-span-2">
                     <label htmlFor="ubicacion" className="block text-sm font-medium text-gray-700 mb-1">
                         Ubicación <span className="text-red-500">*</span>
                     </label>
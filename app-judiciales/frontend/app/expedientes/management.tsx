@@ -1,7 +1,7 @@
 'use client'
 
 import { useState, useEffect, useCallback } from 'react'
-import { Expediente, CreateExpedienteInput, UpdateExpedienteInput, ExpedienteSearchParams, Grado, SituacionMilitar, ExpedienteEstado, GradoLabels, SituacionMilitarLabels, EstadoExpedienteLabels } from '@/lib/types'
+import { Expediente, CreateExpedienteInput, UpdateExpedienteInput, ExpedienteSearchParams, Grado, SituacionMilitar, ExpedienteEstado, EstadoExpedienteValues, GradoLabels, SituacionMilitarLabels, EstadoExpedienteLabels } from '@/lib/types'
 import { getExpedientes, searchExpedientes, createExpediente, updateExpediente, deleteExpediente } from '@/lib/api'
 import { useToast } from '@/contexts/ToastContext'
 import { Button } from '@/components/ui/button'
@@ -493,7 +493,7 @@ export function ExpedientesManagement() {
 
     const gradoOptions: Grado[] = ['GRAL', 'CRL', 'TTE CRL', 'MY', 'CAP', 'TTE', 'STTE', 'TCO', 'SSOO', 'EC', 'TROPA']
     const situacionOptions: SituacionMilitar[] = ['Actividad', 'Retiro']
-    const estadoOptions: ExpedienteEstado[] = ['dentro', 'fuera']
+    const estadoOptions: ExpedienteEstado[] = EstadoExpedienteValues
 
     // Función para obtener todos los expedientes para la visualización de estantes
     const getAllExpedientesForEstantes = async (): Promise<Expediente[]> => {
@@ -54,7 +54,7 @@ export function ExpedientesTable({
                             />
                         </th>
                         <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">
-                            Orden
+                            N° Registro
                         </th>
                         <th className="px-6 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">
                             Año
@@ -108,7 +108,7 @@ export function ExpedientesTable({
                                 </td>
                                 <td className="px-6 py-4 whitespace-nowrap">
                                     <span className="text-sm font-medium text-gray-900">
-                                        #{expediente.orden}
+                                        #{expediente.numero_registro}
                                     </span>
                                 </td>
                                 <td className="px-6 py-4 whitespace-nowrap">
@@ -135,6 +135,9 @@ export function ExpedientesTable({
                                     <span className="text-sm text-gray-900">
                                         {expediente.numero_paginas}
                                     </span>
+                                    {expediente.tomos > 0 && (
+                                        <div className="text-xs text-gray-500">{expediente.tomos} tomos</div>
+                                    )}
                                 </td>
                                 <td className="px-6 py-4 whitespace-nowrap">
                                     <span className={`px-2 py-1 inline-flex text-xs leading-5 font-semibold rounded-full ${
@@ -162,6 +165,11 @@ export function ExpedientesTable({
                                             <span className="text-sm font-medium">{EstadoExpedienteLabels[expediente.estado]}</span>
                                         </div>
                                     )}
+                                    {expediente.parcialmente_fuera && (
+                                        <div className="text-xs text-amber-600">
+                                            {expediente.tomos_fuera} de {expediente.tomos} tomos fuera
+                                        </div>
+                                    )}
                                 </td>
                                 <td className="px-6 py-4 whitespace-nowrap">
                                     <div className="text-sm text-gray-900">
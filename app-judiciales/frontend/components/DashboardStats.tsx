@@ -65,7 +65,8 @@ export default function DashboardStatsComponent() {
           personal_retiro: 240,
           porcentaje_actividad: 79.8,
           porcentaje_retiro: 20.2,
-          ubicaciones_unicas: 15
+          ubicaciones_unicas: 15,
+          expedientes_por_estado: { dentro: 850, fuera: 340 }
         },
         estadisticas_por_estado: [
           { estado: 'Dentro', total: 850, porcentaje: 71.4, total_paginas: 3400 },
@@ -1,11 +1,11 @@
 'use client'
 
 import { useState, useEffect } from 'react'
-import { Expediente, Grado } from '@/lib/types'
+import { Expediente, ArchivoEstante } from '@/lib/types'
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/card'
 import { Button } from '@/components/ui/button'
 import { X, BookOpen, Users } from 'lucide-react'
-import { getExpedientesByDivision } from '@/lib/api'
+import { getArchivoEstantes, getExpedientesByDivisionId } from '@/lib/api'
 
 interface EstantesVisualizationProps {
     onClose: () => void
@@ -13,9 +13,13 @@ interface EstantesVisualizationProps {
 
 interface Division {
     id: number
+    divisionId: string
     range: string
     expedientes: Expediente[]
+    total: number
     maxCapacity: number
+    unidad: string
+    porcentaje: number
     isLoaded: boolean
 }
 
@@ -24,70 +28,28 @@ interface Estante {
     name: string
     divisions: Division[]
     description: string
+    total: number
 }
 
-// Definición de rangos alfabéticos para oficiales en actividad
-const DIVISION_RANGES = [
-    // Estante 1 (divisiones 1-20)
-    { id: 1, range: 'AA–AG' },
-    { id: 2, range: 'AH–AQ' },
-    { id: 3, range: 'AR–AZ' },
-    { id: 4, range: 'BA–BM' },
-    { id: 5, range: 'BN–BZ' },
-    { id: 6, range: 'CA–CC' },
-    { id: 7, range: 'CD–CJ' },
-    { id: 8, range: 'CK–CZ' },
-    { id: 9, range: 'DA–DM' },
-    { id: 10, range: 'DN–DZ' },
-    { id: 11, range: 'EA–EM' },
-    { id: 12, range: 'EN–EZ' },
-    { id: 13, range: 'FA–FM' },
-    { id: 14, range: 'FN–FZ' },
-    { id: 15, range: 'GA–GM' },
-    { id: 16, range: 'GN–GZ' },
-    { id: 17, range: 'HA–HM' },
-    { id: 18, range: 'HN–HZ' },
-    { id: 19, range: 'IA–IZ' },
-    //{ id: 18, range: 'IA–IM' },
-    //{ id: 19, range: 'IN–IZ' },
-    { id: 20, range: 'JA–JZ' },
-    // =============================
-    // Estante 2 (divisiones 21-40)
-    // { id: 21, range: 'KA–KM' },
-    // { id: 22, range: 'KN–KZ' },
-    { id: 21, range: 'KA–KZ' },
-    { id: 22, range: 'LA–LM' },
-    { id: 23, range: 'LN–LZ' },
-    //{ id: 25, range: 'MA–MM' },
-    { id: 24, range: 'MA–MD' },
-    { id: 25, range: 'ME–MM' },
-    { id: 26, range: 'MN–MZ' },
-    { id: 27, range: 'NA–NZ' },
-    { id: 28, range: 'OA–OZ' },
-   // { id: 29, range: 'PA–PZ' },
-    { id: 29, range: 'PA–PF' },
-    { id: 30, range: 'PG–PZ' },
-
-    { id: 31, range: 'QA–QZ' },
-    //{ id: 31, range: 'RA–RZ' },
-    { id: 32, range: 'RA–RJ' },
-    { id: 33, range: 'RK–RZ' },
-    { id: 34, range: 'SA–SD' },
-    { id: 35, range: 'SE–SZ' },
-    { id: 36, range: 'TA–TZ' },
-    { id: 37, range: 'UA–UZ' },
-   // { id: 36, range: 'VA–VZ' },
-    { id: 38, range: 'VA–VD' },
-    { id: 39, range: 'VE–VZ' },
-    //{ id: 37, range: 'WA–WZ' },
-    //{ id: 38, range: 'XA–XZ' },
-    //{ id: 39, range: 'YA–YZ' },
-    //{ id: 40, range: 'ZA–ZZ' }
-    { id: 40, range: 'WA–ZZ' }
-]
-
-// Grados de oficiales
-const GRADOS_OFICIALES: Grado[] = ['GRAL', 'CRL', 'TTE CRL', 'MY', 'CAP', 'TTE', 'STTE']
+// Convierte el catálogo del backend (estantes y divisiones) al formato de la vista
+const toEstantes = (catalogo: ArchivoEstante[]): Estante[] =>
+    catalogo.map(estante => ({
+        id: estante.numero,
+        name: estante.nombre,
+        description: estante.descripcion,
+        total: estante.expedientes,
+        divisions: estante.divisiones.map(division => ({
+            id: division.numero,
+            divisionId: division.id,
+            range: division.rango,
+            expedientes: [],
+            total: division.expedientes,
+            maxCapacity: division.capacidad,
+            unidad: division.unidad_capacidad,
+            porcentaje: division.porcentaje,
+            isLoaded: false
+        }))
+    }))
 
 export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
     const [selectedDivision, setSelectedDivision] = useState<Division | null>(null)
@@ -98,49 +60,22 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
         initializeEstantes()
     }, [])
 
-    const initializeEstantes = () => {
+    const initializeEstantes = async () => {
         console.log('=== INICIALIZANDO ESTANTES ===')
 
-        // Crear divisiones sin expedientes (carga lazy)
-        const divisionesVacias = DIVISION_RANGES.map(divRange => ({
-            id: divRange.id,
-            range: divRange.range,
-            expedientes: [],
-            maxCapacity: 100,
-            isLoaded: false
-        }))
-
-        // Organizar en estantes
-        const estantesData: Estante[] = [
-            {
-                id: 1,
-                name: 'Estante 1',
-                description: 'Oficiales en Actividad (AA-JZ)',
-                divisions: divisionesVacias.slice(0, 20)
-            },
-            {
-                id: 2,
-                name: 'Estante 2',
-                description: 'Oficiales en Actividad (KA-ZZ)',
-                divisions: divisionesVacias.slice(20, 40)
-            },
-            // Estantes 3-11 (para futuras implementaciones)
-            ...Array.from({ length: 9 }, (_, i) => ({
-                id: i + 3,
-                name: `Estante ${i + 3}`,
-                description: 'Reservado para futuras categorías',
-                divisions: Array.from({ length: 20 }, (_, j) => ({
-                    id: (i + 2) * 20 + j + 1,
-                    range: 'Sin asignar',
-                    expedientes: [],
-                    maxCapacity: 100,
-                    isLoaded: true
-                }))
-            }))
-        ]
-
-        setEstantes(estantesData)
-        console.log('Estantes inicializados con carga lazy')
+        try {
+            setLoading(true)
+            // La distribución de estantes y divisiones se obtiene del backend
+            const response = await getArchivoEstantes()
+            if (response.success && response.data) {
+                setEstantes(toEstantes(response.data))
+                console.log(`Estantes inicializados: ${response.data.length}`)
+            }
+        } catch (error) {
+            console.error('Error loading estantes:', error)
+        } finally {
+            setLoading(false)
+        }
     }
 
     const loadDivisionData = async (division: Division) => {
@@ -153,18 +88,17 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
             setLoading(true)
             console.log(`Cargando división ${division.id}: ${division.range}`)
 
-            const response = await getExpedientesByDivision(division.range)
+            const response = await getExpedientesByDivisionId(division.divisionId)
 
             if (response.success && response.data) {
-                const expedientes = Array.isArray(response.data) ? response.data :
-                    (response.data as any).expedientes || []
+                const expedientes = response.data.expedientes || []
 
                 // Actualizar la división en el estado
                 setEstantes(prevEstantes => {
                     return prevEstantes.map(estante => ({
                         ...estante,
                         divisions: estante.divisions.map(div =>
-                            div.id === division.id
+                            div.divisionId === division.divisionId
                                 ? { ...div, expedientes, isLoaded: true }
                                 : div
                         )
@@ -185,9 +119,7 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
     }
 
     const getOccupancyColor = (division: Division): string => {
-        if (!division.isLoaded) return 'bg-blue-50 border-blue-200' // No cargado
-
-        const occupancyPercentage = (division.expedientes.length / division.maxCapacity) * 100
+        const occupancyPercentage = division.porcentaje
 
         if (occupancyPercentage === 0) return 'bg-gray-100 border-gray-200'
         if (occupancyPercentage <= 50) return 'bg-green-100 border-green-300'
@@ -195,17 +127,15 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
         return 'bg-red-100 border-red-300'
     }
 
-    const getTotalExpedientes = (estante: Estante): number => {
-        return estante.divisions
-            .filter(div => div.isLoaded)
-            .reduce((total, div) => total + div.expedientes.length, 0)
-    }
-
     const getDivisionDisplayText = (division: Division): string => {
-        if (!division.isLoaded) {
-            return 'Click para cargar'
+        if (division.unidad === 'cm') {
+            return `${division.porcentaje}%`
         }
-        return division.expedientes.length.toString()
+        return division.total.toString()
+    }
+
+    const getCapacityText = (division: Division): string => {
+        return division.unidad === 'cm' ? `${division.maxCapacity} cm` : `/ ${division.maxCapacity}`
     }
 
     return (
@@ -222,7 +152,7 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
                 </div>
 
                 <div className="p-6 space-y-8">
-                    {estantes.slice(0, 2).map(estante => (
+                    {estantes.map(estante => (
                         <Card key={estante.id} className="border-2">
                             <CardHeader>
                                 <CardTitle className="flex items-center justify-between">
@@ -232,21 +162,24 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
                                     </div>
                                     <div className="text-sm text-gray-600 flex items-center gap-2">
                                         <Users className="h-4 w-4" />
-                                        {getTotalExpedientes(estante)} expedientes cargados
+                                        {estante.total} expedientes
                                     </div>
                                 </CardTitle>
                                 <p className="text-sm text-gray-600">{estante.description}</p>
                             </CardHeader>
                             <CardContent>
+                                {estante.divisions.length === 0 && (
+                                    <div className="text-sm text-gray-400">Sin divisiones asignadas</div>
+                                )}
                                 <div className="grid grid-cols-10 gap-2">
                                     {estante.divisions.map(division => (
                                         <div
-                                            key={division.id}
+                                            key={division.divisionId}
                                             className={`
                                                 p-3 rounded-lg border-2 cursor-pointer transition-all duration-200
                                                 hover:shadow-md hover:scale-105 disabled:cursor-not-allowed
                                                 ${getOccupancyColor(division)}
-                                                ${selectedDivision?.id === division.id ? 'ring-2 ring-indigo-500 ring-offset-2' : ''}
+                                                ${selectedDivision?.divisionId === division.divisionId ? 'ring-2 ring-indigo-500 ring-offset-2' : ''}
                                                 ${loading ? 'opacity-50' : ''}
                                             `}
                                             onClick={() => loadDivisionData(division)}
@@ -262,7 +195,7 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
                                                     {getDivisionDisplayText(division)}
                                                 </div>
                                                 <div className="text-xs text-gray-500">
-                                                    / {division.maxCapacity}
+                                                    {getCapacityText(division)}
                                                 </div>
                                             </div>
                                         </div>
@@ -272,27 +205,6 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
                         </Card>
                     ))}
 
-                    {/* Estantes 3-11 (vista compacta) */}
-                    <Card className="border-2 border-gray-200">
-                        <CardHeader>
-                            <CardTitle className="text-gray-500">Estantes 3-11</CardTitle>
-                            <p className="text-sm text-gray-400">Reservados para futuras implementaciones (TCO, SSOO, EC, TROPA, etc.)</p>
-                        </CardHeader>
-                        <CardContent>
-                            <div className="grid grid-cols-9 gap-4">
-                                {estantes.slice(2).map(estante => (
-                                    <div key={estante.id} className="bg-gray-50 p-3 rounded-lg border border-gray-200">
-                                        <div className="text-center text-gray-400">
-                                            <BookOpen className="h-6 w-6 mx-auto mb-1" />
-                                            <div className="text-sm font-semibold">{estante.name}</div>
-                                            <div className="text-xs">Sin asignar</div>
-                                        </div>
-                                    </div>
-                                ))}
-                            </div>
-                        </CardContent>
-                    </Card>
-
                     {/* Panel de detalles de división seleccionada */}
                     {selectedDivision && (
                         <Card className="border-2 border-indigo-200 bg-indigo-50">
@@ -301,7 +213,7 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
                                     División {selectedDivision.id} - {selectedDivision.range}
                                 </CardTitle>
                                 <p className="text-sm text-indigo-600">
-                                    {selectedDivision.expedientes.length} de {selectedDivision.maxCapacity} expedientes
+                                    {selectedDivision.expedientes.length} expedientes · {selectedDivision.porcentaje}% de ocupación
                                 </p>
                             </CardHeader>
                             <CardContent>
@@ -340,10 +252,6 @@ export function EstantesVisualization({ onClose }: EstantesVisualizationProps) {
                         </CardHeader>
                         <CardContent>
                             <div className="flex gap-6 text-sm">
-                                <div className="flex items-center gap-2">
-                                    <div className="w-4 h-4 bg-blue-50 border border-blue-200 rounded"></div>
-                                    <span>Sin cargar (Click para cargar)</span>
-                                </div>
                                 <div className="flex items-center gap-2">
                                     <div className="w-4 h-4 bg-gray-100 border border-gray-200 rounded"></div>
                                     <span>Vacío (0%)</span>
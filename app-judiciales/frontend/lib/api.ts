@@ -35,10 +35,63 @@ import {
   Expediente,
   CreateExpedienteInput,
   UpdateExpedienteInput,
+  ExpedienteEstado,
+  CambioEstado,
+  Tomo,
+  CreateTomoInput,
+  UpdateTomoInput,
+  EventoCarrera,
+  CreateEventoCarreraInput,
+  CarreraExpediente,
+  LoteResolucion,
+  Reconciliacion,
+  AplicarReconciliacionInput,
+  ParDuplicado,
+  MergeExpedientesInput,
+  ResultadoMerge,
+  FormatoEtiqueta,
+  SimbologiaEtiqueta,
+  EtiquetasInput,
+  EscaneoInput,
+  ResultadoEscaneo,
+  Prestamo,
+  PrestamoAbierto,
+  EstadoInventario,
+  Inventario,
+  ReporteInventario,
+  CreateInventarioInput,
+  LecturasInventarioInput,
+  ResultadoLectura,
+  Documento,
+  SubirDocumentosInput,
+  EnlaceDocumento,
+  VersionDocumento,
+  IntegridadExpediente,
+  BusquedaTextoResultado,
+  Ingesta,
+  EstadoIngesta,
+  ExpedienteDigitalizacion,
+  UpdateDigitalizacionInput,
+  DigitalizacionSearchParams,
+  ResumenDigitalizacion,
+  Movimiento,
+  MovimientoReporte,
+  CreateMovimientoInput,
+  MovimientoSearchParams,
+  Notificacion,
+  NotificacionReporte,
+  CreateNotificacionInput,
+  UpdateEstadoNotificacionInput,
+  NotificacionPendienteParams,
+  Dependencia,
+  CreateDependenciaInput,
+  UpdateDependenciaInput,
+  TenenciaDependencia,
   ExpedienteSearchParams,
   ApiResponse,
   SearchParams,
   DashboardStats,
+  ArchivoEstante,
   LoginCredentials,
   LoginResponse,
 } from './types';
@@ -161,6 +214,10 @@ const DEMO_EXPEDIENTES: Expediente[] = [
     situacion_militar: 'Actividad',
     ubicacion: 'Archivo Central - Estante A1',
     orden: 1,
+    numero_registro: 1,
+    tomos: 0,
+    tomos_fuera: 0,
+    parcialmente_fuera: false,
     ano: 2024,
     estado: 'dentro',
     fecha_registro: '2024-01-15',
@@ -179,6 +236,10 @@ const DEMO_EXPEDIENTES: Expediente[] = [
     situacion_militar: 'Retiro',
     ubicacion: 'Archivo Central - Estante B2',
     orden: 2,
+    numero_registro: 2,
+    tomos: 0,
+    tomos_fuera: 0,
+    parcialmente_fuera: false,
     ano: 2023,
     estado: 'fuera',
     fecha_registro: '2024-02-10',
@@ -197,6 +258,10 @@ const DEMO_EXPEDIENTES: Expediente[] = [
     situacion_militar: 'Actividad',
     ubicacion: 'Archivo Central - Estante C3',
     orden: 3,
+    numero_registro: 3,
+    tomos: 0,
+    tomos_fuera: 0,
+    parcialmente_fuera: false,
     ano: 2024,
     estado: 'dentro',
     fecha_registro: '2024-03-05',
@@ -215,6 +280,10 @@ const DEMO_EXPEDIENTES: Expediente[] = [
     situacion_militar: 'Actividad',
     ubicacion: 'Archivo Central - Estante D4',
     orden: 4,
+    numero_registro: 4,
+    tomos: 0,
+    tomos_fuera: 0,
+    parcialmente_fuera: false,
     ano: 2022,
     estado: 'dentro',
     fecha_registro: '2024-04-12',
@@ -233,6 +302,10 @@ const DEMO_EXPEDIENTES: Expediente[] = [
     situacion_militar: 'Retiro',
     ubicacion: 'Archivo Central - Estante E5',
     orden: 5,
+    numero_registro: 5,
+    tomos: 0,
+    tomos_fuera: 0,
+    parcialmente_fuera: false,
     ano: 2021,
     estado: 'fuera',
     fecha_registro: '2024-05-20',
@@ -684,6 +757,7 @@ export async function searchExpedientes(
   if (params.orden) queryParams.append('orden', params.orden.toString());
   if (params.fecha_inicio) queryParams.append('fecha_inicio', params.fecha_inicio);
   if (params.fecha_fin) queryParams.append('fecha_fin', params.fecha_fin);
+  if (params.dependencia_id) queryParams.append('dependencia_id', params.dependencia_id);
   if (params.sort_by) queryParams.append('sort_by', params.sort_by);
   if (params.sort_order) queryParams.append('sort_order', params.sort_order);
   if (params.page) queryParams.append('page', params.page.toString());
@@ -703,6 +777,22 @@ export async function getExpedientesByDivision(divisionRange: string): Promise<A
   return handleResponse<ApiResponse<Expediente[]>>(response);
 }
 
+// Get the archive shelves with the occupancy of each division
+export async function getArchivoEstantes(): Promise<ApiResponse<ArchivoEstante[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/archivo/estantes`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<ArchivoEstante[]>>(response);
+}
+
+// Get the expedientes stored in a catalog division
+export async function getExpedientesByDivisionId(divisionId: string): Promise<ApiResponse<{ expedientes: Expediente[], total: number, division: string }>> {
+  const response = await safeFetch(`${API_BASE_URL}/archivo/divisiones/${divisionId}/expedientes`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<{ expedientes: Expediente[], total: number, division: string }>>(response);
+}
+
 // Get a single expediente by ID
 export async function getExpediente(id: string): Promise<ApiResponse<Expediente>> {
   const response = await safeFetch(`${API_BASE_URL}/expedientes/${id}/`, {
@@ -772,19 +862,210 @@ export async function updateExpediente(
   return handleResponse<ApiResponse<Expediente>>(response);
 }
 
-// Update expediente estado only
+// Move an expediente to another lifecycle state; the transition must be configured
 export async function updateExpedienteEstado(
   id: string,
-  estado: 'dentro' | 'fuera'
-): Promise<ApiResponse<{ message: string }>> {
+  estado: ExpedienteEstado,
+  justificacion: string
+): Promise<ApiResponse<CambioEstado>> {
   const response = await safeFetch(`${API_BASE_URL}/expedientes/${id}/estado/`, {
     method: 'PUT',
     headers: getAuthHeaders(),
-    body: JSON.stringify({ estado }),
+    body: JSON.stringify({ estado, justificacion }),
+  });
+  return handleResponse<ApiResponse<CambioEstado>>(response);
+}
+
+// Get the state history of an expediente, newest first
+export async function getExpedienteEstadoHistorial(id: string): Promise<ApiResponse<CambioEstado[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${id}/estado/historial`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<CambioEstado[]>>(response);
+}
+
+// Get the physical volumes (tomos) of an expediente
+export async function getTomos(expedienteId: string): Promise<ApiResponse<Tomo[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/tomos`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Tomo[]>>(response);
+}
+
+// Add a tomo to an expediente
+export async function createTomo(expedienteId: string, data: CreateTomoInput): Promise<ApiResponse<Tomo>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/tomos`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Tomo>>(response);
+}
+
+// Update the page range or location of a tomo
+export async function updateTomo(expedienteId: string, tomoId: string, data: UpdateTomoInput): Promise<ApiResponse<Tomo>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/tomos/${tomoId}`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Tomo>>(response);
+}
+
+// Delete a tomo that is inside the archive
+export async function deleteTomo(expedienteId: string, tomoId: string): Promise<ApiResponse<{ message: string }>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/tomos/${tomoId}`, {
+    method: 'DELETE',
+    headers: getAuthHeaders(),
   });
   return handleResponse<ApiResponse<{ message: string }>>(response);
 }
 
+// Move a single tomo to another lifecycle state
+export async function updateTomoEstado(
+  expedienteId: string,
+  tomoId: string,
+  estado: ExpedienteEstado,
+  justificacion: string
+): Promise<ApiResponse<CambioEstado>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/tomos/${tomoId}/estado`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify({ estado, justificacion }),
+  });
+  return handleResponse<ApiResponse<CambioEstado>>(response);
+}
+
+// Get the career of an expediente, optionally as of a past date (YYYY-MM-DD)
+export async function getCarrera(expedienteId: string, fecha?: string): Promise<ApiResponse<CarreraExpediente>> {
+  const query = fecha ? `?fecha=${encodeURIComponent(fecha)}` : '';
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/carrera${query}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<CarreraExpediente>>(response);
+}
+
+// Record a promotion or change of situación; grado and situación are only changed this way
+export async function createEventoCarrera(expedienteId: string, data: CreateEventoCarreraInput): Promise<ApiResponse<EventoCarrera>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/carrera`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<EventoCarrera>>(response);
+}
+
+// Upload a resolution file (Excel or CSV) and preview its promotions and retirements
+export async function previsualizarResolucion(
+  file: File,
+  fechaEfectiva?: string,
+  resolucion?: string
+): Promise<ApiResponse<LoteResolucion>> {
+  const formData = new FormData();
+  formData.append('file', file);
+  // Used for rows that leave these columns empty
+  if (fechaEfectiva) formData.append('fecha_efectiva', fechaEfectiva);
+  if (resolucion) formData.append('resolucion', resolucion);
+
+  // Get only the Authorization header for FormData (don't set Content-Type)
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) {
+    headers.Authorization = `Bearer ${token}`;
+  }
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/carrera/resoluciones`, {
+    method: 'POST',
+    headers,
+    body: formData,
+  });
+  return handleResponse<ApiResponse<LoteResolucion>>(response);
+}
+
+// Get a previewed resolution batch
+export async function getResolucion(id: string): Promise<ApiResponse<LoteResolucion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/carrera/resoluciones/${id}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<LoteResolucion>>(response);
+}
+
+// Apply every changed row of a resolution batch, all or nothing
+export async function aplicarResolucion(id: string): Promise<ApiResponse<LoteResolucion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/carrera/resoluciones/${id}/aplicar`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<LoteResolucion>>(response);
+}
+
+// Upload the personnel roster (Excel or CSV) and compare it with the archive
+export async function reconciliarNomina(file: File): Promise<ApiResponse<Reconciliacion>> {
+  const formData = new FormData();
+  formData.append('file', file);
+
+  // Get only the Authorization header for FormData (don't set Content-Type)
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) {
+    headers.Authorization = `Bearer ${token}`;
+  }
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/reconciliacion`, {
+    method: 'POST',
+    headers,
+    body: formData,
+  });
+  return handleResponse<ApiResponse<Reconciliacion>>(response);
+}
+
+// Get a roster reconciliation report
+export async function getReconciliacion(id: string): Promise<ApiResponse<Reconciliacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/reconciliacion/${id}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Reconciliacion>>(response);
+}
+
+// Apply the selected discrepancies as career events
+export async function aplicarReconciliacion(id: string, data: AplicarReconciliacionInput): Promise<ApiResponse<LoteResolucion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/reconciliacion/${id}/aplicar`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<LoteResolucion>>(response);
+}
+
+// Get the pairs of expedientes that likely belong to the same person
+export async function getDuplicados(umbral?: number): Promise<ApiResponse<{
+  duplicados: ParDuplicado[];
+  total: number;
+  umbral: number;
+}>> {
+  const query = umbral !== undefined ? `?umbral=${umbral}` : '';
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/duplicados${query}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<{ duplicados: ParDuplicado[]; total: number; umbral: number }>>(response);
+}
+
+// Merge duplicate expedientes into a surviving one
+export async function mergeExpedientes(data: MergeExpedientesInput): Promise<ApiResponse<ResultadoMerge>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/merge`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<ResultadoMerge>>(response);
+}
+
 // Delete an expediente (soft delete)
 export async function deleteExpediente(id: string): Promise<ApiResponse<{ message: string }>> {
   const response = await safeFetch(`${API_BASE_URL}/expedientes/${id}/`, {
@@ -872,6 +1153,14 @@ export async function getDashboardStats(): Promise<ApiResponse<DashboardStats>>
   return handleResponse<ApiResponse<DashboardStats>>(response);
 }
 
+// Get the digitisation progress per total, division, grado and operator
+export async function getResumenDigitalizacion(): Promise<ApiResponse<ResumenDigitalizacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/dashboard/digitalizacion`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<ResumenDigitalizacion>>(response);
+}
+
 // Export expedientes as CSV - triggers a file download in the client
 export async function exportExpedientes(): Promise<void> {
   const url = `${API_BASE_URL}/expedientes/export`;
@@ -904,3 +1193,667 @@ export async function exportExpedientes(): Promise<void> {
   window.URL.revokeObjectURL(urlBlob);
 }
 
+
+// Download a roster reconciliation report as Excel - triggers a file download in the client
+export async function exportReconciliacion(id: string): Promise<void> {
+  const url = `${API_BASE_URL}/expedientes/reconciliacion/${id}/excel`;
+
+  // Use auth header but expect a binary response
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) headers.Authorization = `Bearer ${token}`;
+
+  const response = await safeFetch(url, {
+    method: 'GET',
+    headers,
+  });
+
+  if (!response.ok) {
+    const err = await response.json().catch(() => ({ error: 'Export failed' }));
+    throw new Error(err.error || 'Export failed');
+  }
+
+  const blob = await response.blob();
+  const link = document.createElement('a');
+  const urlBlob = window.URL.createObjectURL(blob);
+  link.href = urlBlob;
+  link.download = `reconciliacion_${id}.xlsx`;
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  window.URL.revokeObjectURL(urlBlob);
+}
+
+
+// Download a label file (PDF sheet or ZPL) - triggers a file download in the client
+async function descargarEtiquetas(url: string, init: RequestInit, nombre: string): Promise<void> {
+  // Use auth header but expect a binary response
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: Record<string, string> = { ...(init.headers as Record<string, string>) };
+  if (token) headers.Authorization = `Bearer ${token}`;
+
+  const response = await safeFetch(url, { ...init, headers });
+
+  if (!response.ok) {
+    const err = await response.json().catch(() => ({ error: 'Label generation failed' }));
+    throw new Error(err.error || 'Label generation failed');
+  }
+
+  const blob = await response.blob();
+  const link = document.createElement('a');
+  const urlBlob = window.URL.createObjectURL(blob);
+  link.href = urlBlob;
+  link.download = nombre;
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  window.URL.revokeObjectURL(urlBlob);
+}
+
+// Query string for the label format and barcode
+function etiquetasQuery(formato?: FormatoEtiqueta, simbologia?: SimbologiaEtiqueta): string {
+  const params = new URLSearchParams();
+  if (formato) params.append('formato', formato);
+  if (simbologia) params.append('simbologia', simbologia);
+  const query = params.toString();
+  return query ? `?${query}` : '';
+}
+
+// Download the folder labels of the selected expedientes
+export async function exportEtiquetasExpedientes(data: EtiquetasInput): Promise<void> {
+  return descargarEtiquetas(`${API_BASE_URL}/expedientes/etiquetas`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(data),
+  }, `etiquetas_expedientes.${data.formato ?? 'pdf'}`);
+}
+
+// Download the folder labels of every expediente in a division
+export async function exportEtiquetasDivision(id: string, formato?: FormatoEtiqueta, simbologia?: SimbologiaEtiqueta): Promise<void> {
+  return descargarEtiquetas(`${API_BASE_URL}/archivo/divisiones/${id}/etiquetas${etiquetasQuery(formato, simbologia)}`, {
+    method: 'GET',
+  }, `etiquetas_division_${id}.${formato ?? 'pdf'}`);
+}
+
+// Download the shelf-end labels of the divisions of a shelf
+export async function exportEtiquetasEstante(id: string, formato?: FormatoEtiqueta, simbologia?: SimbologiaEtiqueta): Promise<void> {
+  return descargarEtiquetas(`${API_BASE_URL}/archivo/estantes/${id}/etiquetas${etiquetasQuery(formato, simbologia)}`, {
+    method: 'GET',
+  }, `etiquetas_estante_${id}.${formato ?? 'pdf'}`);
+}
+
+// Lend or return the folder behind a scanned label code
+export async function escanearCarpeta(data: EscaneoInput): Promise<ApiResponse<ResultadoEscaneo>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/escaneo`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<ResultadoEscaneo>>(response);
+}
+
+// Folders currently on loan, oldest first, optionally only those lent to a dependencia
+export async function getPrestamosAbiertos(dependenciaId?: string): Promise<ApiResponse<PrestamoAbierto[]>> {
+  const query = dependenciaId ? `?dependencia_id=${encodeURIComponent(dependenciaId)}` : '';
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/prestamos${query}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<PrestamoAbierto[]>>(response);
+}
+
+// Loan history of an expediente
+export async function getPrestamosExpediente(id: string): Promise<ApiResponse<Prestamo[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${id}/prestamos`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Prestamo[]>>(response);
+}
+
+// Physical inventory sessions, newest first
+export async function getInventarios(estado?: EstadoInventario): Promise<ApiResponse<Inventario[]>> {
+  const query = estado ? `?estado=${estado}` : '';
+  const response = await safeFetch(`${API_BASE_URL}/archivo/inventarios${query}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Inventario[]>>(response);
+}
+
+// Start an inventory of a shelf or a division
+export async function createInventario(data: CreateInventarioInput): Promise<ApiResponse<Inventario>> {
+  const response = await safeFetch(`${API_BASE_URL}/archivo/inventarios`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Inventario>>(response);
+}
+
+// Inventory session with its scans, to resume it
+export async function getInventario(id: string): Promise<ApiResponse<Inventario>> {
+  const response = await safeFetch(`${API_BASE_URL}/archivo/inventarios/${id}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Inventario>>(response);
+}
+
+// Record scanned codes in an open inventory session
+export async function registrarLecturasInventario(id: string, data: LecturasInventarioInput): Promise<ApiResponse<ResultadoLectura[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/archivo/inventarios/${id}/lecturas`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<ResultadoLectura[]>>(response);
+}
+
+// Pause, resume or close an inventory session
+async function accionInventario<T>(id: string, accion: 'pausar' | 'reanudar' | 'cerrar'): Promise<ApiResponse<T>> {
+  const response = await safeFetch(`${API_BASE_URL}/archivo/inventarios/${id}/${accion}`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<T>>(response);
+}
+
+export async function pausarInventario(id: string): Promise<ApiResponse<Inventario>> {
+  return accionInventario<Inventario>(id, 'pausar');
+}
+
+export async function reanudarInventario(id: string): Promise<ApiResponse<Inventario>> {
+  return accionInventario<Inventario>(id, 'reanudar');
+}
+
+export async function cerrarInventario(id: string): Promise<ApiResponse<ReporteInventario>> {
+  return accionInventario<ReporteInventario>(id, 'cerrar');
+}
+
+// Reconciliation report of an inventory session
+export async function getReporteInventario(id: string): Promise<ApiResponse<ReporteInventario>> {
+  const response = await safeFetch(`${API_BASE_URL}/archivo/inventarios/${id}/reporte`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<ReporteInventario>>(response);
+}
+
+// Download the report of an inventory session as Excel - triggers a file download in the client
+export async function exportReporteInventario(id: string): Promise<void> {
+  const url = `${API_BASE_URL}/archivo/inventarios/${id}/excel`;
+
+  // Use auth header but expect a binary response
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) headers.Authorization = `Bearer ${token}`;
+
+  const response = await safeFetch(url, {
+    method: 'GET',
+    headers,
+  });
+
+  if (!response.ok) {
+    const err = await response.json().catch(() => ({ error: 'Export failed' }));
+    throw new Error(err.error || 'Export failed');
+  }
+
+  const blob = await response.blob();
+  const link = document.createElement('a');
+  const urlBlob = window.URL.createObjectURL(blob);
+  link.href = urlBlob;
+  link.download = `inventario_${id}.xlsx`;
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  window.URL.revokeObjectURL(urlBlob);
+}
+
+// Attachments of an expediente, newest first
+export async function getDocumentos(expedienteId: string): Promise<ApiResponse<Documento[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Documento[]>>(response);
+}
+
+// Attach up to five files to an expediente with shared metadata
+export async function subirDocumentos(expedienteId: string, data: SubirDocumentosInput): Promise<ApiResponse<Documento[]>> {
+  const formData = new FormData();
+  data.files.forEach((file) => formData.append('files', file));
+  formData.append('tipo', data.tipo);
+  if (data.descripcion) formData.append('descripcion', data.descripcion);
+  if (data.fecha) formData.append('fecha', data.fecha);
+  if (data.confidencial) formData.append('confidencial', 'true');
+
+  // Get only the Authorization header for FormData (don't set Content-Type)
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) {
+    headers.Authorization = `Bearer ${token}`;
+  }
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos`, {
+    method: 'POST',
+    headers,
+    body: formData,
+  });
+  return handleResponse<ApiResponse<Documento[]>>(response);
+}
+
+// Content of an attachment; vista requests the inline version used for PDF and image previews
+export async function getDocumentoBlob(expedienteId: string, documentoId: string, vista = false): Promise<Blob> {
+  const query = vista ? '?vista=1' : '';
+
+  // Use auth header but expect a binary response
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) headers.Authorization = `Bearer ${token}`;
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}${query}`, {
+    method: 'GET',
+    headers,
+  });
+
+  if (!response.ok) {
+    const err = await response.json().catch(() => ({ error: 'Download failed' }));
+    throw new Error(err.error || 'Download failed');
+  }
+
+  return response.blob();
+}
+
+// Download an attachment with its original file name
+export async function descargarDocumento(expedienteId: string, documento: Documento): Promise<void> {
+  const blob = await getDocumentoBlob(expedienteId, documento.id);
+  const link = document.createElement('a');
+  const urlBlob = window.URL.createObjectURL(blob);
+  link.href = urlBlob;
+  link.download = documento.nombre;
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  window.URL.revokeObjectURL(urlBlob);
+}
+
+// Short-lived URL that downloads an attachment straight from S3 storage (501 with local storage)
+export async function getEnlaceDocumento(expedienteId: string, documentoId: string, vista = false): Promise<ApiResponse<EnlaceDocumento>> {
+  const query = vista ? '?vista=1' : '';
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}/enlace${query}`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<EnlaceDocumento>>(response);
+}
+
+// Remove an attachment and its file
+export async function deleteDocumento(expedienteId: string, documentoId: string): Promise<ApiResponse<void>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}`, {
+    method: 'DELETE',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<void>>(response);
+}
+
+// Upload a new version of an attachment; the previous ones are kept
+export async function subirVersionDocumento(expedienteId: string, documentoId: string, file: File): Promise<ApiResponse<Documento>> {
+  const formData = new FormData();
+  formData.append('file', file);
+
+  // Get only the Authorization header for FormData (don't set Content-Type)
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) {
+    headers.Authorization = `Bearer ${token}`;
+  }
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}/versiones`, {
+    method: 'POST',
+    headers,
+    body: formData,
+  });
+  return handleResponse<ApiResponse<Documento>>(response);
+}
+
+// Versions of an attachment, newest first
+export async function getVersionesDocumento(expedienteId: string, documentoId: string): Promise<ApiResponse<VersionDocumento[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}/versiones`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<VersionDocumento[]>>(response);
+}
+
+// Download a version of an attachment with its original file name
+export async function descargarVersionDocumento(expedienteId: string, documentoId: string, version: VersionDocumento): Promise<void> {
+  const token = typeof window !== 'undefined' ? localStorage.getItem('auth_token') : null;
+  const headers: HeadersInit = {};
+  if (token) headers.Authorization = `Bearer ${token}`;
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}/versiones/${version.version}`, {
+    method: 'GET',
+    headers,
+  });
+
+  if (!response.ok) {
+    const err = await response.json().catch(() => ({ error: 'Download failed' }));
+    throw new Error(err.error || 'Download failed');
+  }
+
+  const blob = await response.blob();
+  const link = document.createElement('a');
+  const urlBlob = window.URL.createObjectURL(blob);
+  link.href = urlBlob;
+  link.download = version.nombre;
+  document.body.appendChild(link);
+  link.click();
+  link.remove();
+  window.URL.revokeObjectURL(urlBlob);
+}
+
+// Make a prior version current again as a new version
+export async function restaurarVersionDocumento(expedienteId: string, documentoId: string, version: number): Promise<ApiResponse<Documento>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}/versiones/${version}/restaurar`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Documento>>(response);
+}
+
+// Mark or unmark an attachment as confidential
+export async function setDocumentoConfidencial(expedienteId: string, documentoId: string, confidencial: boolean): Promise<ApiResponse<Documento>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/${documentoId}/confidencial`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify({ confidencial }),
+  });
+  return handleResponse<ApiResponse<Documento>>(response);
+}
+
+// Integrity status of the attachments of an expediente with their latest failures
+export async function getIntegridadDocumentos(expedienteId: string): Promise<ApiResponse<IntegridadExpediente>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/documentos/integridad`, {
+    method: 'GET',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<IntegridadExpediente>>(response);
+}
+
+// Start a fixity check of every stored attachment (system:admin)
+export async function verificarIntegridadDocumentos(): Promise<ApiResponse<void>> {
+  const response = await safeFetch(`${API_BASE_URL}/admin/documentos/integridad/verificar`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<void>>(response);
+}
+
+// Search the text of the attachments within the user's scope
+export async function buscarTextoDocumentos(
+  q: string,
+  page?: number,
+  limit?: number
+): Promise<ApiResponse<BusquedaTextoResultado>> {
+  const queryParams = new URLSearchParams({ q });
+  if (page) queryParams.append('page', page.toString());
+  if (limit) queryParams.append('limit', limit.toString());
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/documentos/buscar?${queryParams}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<BusquedaTextoResultado>>(response);
+}
+
+// Start the text extraction of pending attachments, or of all of them (system:admin)
+export async function indexarTextoDocumentos(todos = false): Promise<ApiResponse<void>> {
+  const response = await safeFetch(`${API_BASE_URL}/admin/documentos/texto/indexar${todos ? '?todos=true' : ''}`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<void>>(response);
+}
+
+// Get the log of the hot-folder ingest, newest first (system:admin)
+export async function getIngestas(
+  params: { estado?: EstadoIngesta; cip?: string; page?: number; limit?: number } = {}
+): Promise<ApiResponse<{ ingestas: Ingesta[]; total: number; page: number; limit: number; activa: boolean }>> {
+  const queryParams = new URLSearchParams();
+  if (params.estado) queryParams.append('estado', params.estado);
+  if (params.cip) queryParams.append('cip', params.cip);
+  if (params.page) queryParams.append('page', params.page.toString());
+  if (params.limit) queryParams.append('limit', params.limit.toString());
+
+  const response = await safeFetch(`${API_BASE_URL}/admin/ingesta?${queryParams}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<{ ingestas: Ingesta[]; total: number; page: number; limit: number; activa: boolean }>>(response);
+}
+
+// Get the digitisation work list in filing order
+export async function getDigitalizaciones(
+  params: DigitalizacionSearchParams = {}
+): Promise<ApiResponse<{ expedientes: ExpedienteDigitalizacion[]; total: number; page: number; limit: number }>> {
+  const queryParams = new URLSearchParams();
+  if (params.estado) queryParams.append('estado', params.estado);
+  if (params.operador_id) queryParams.append('operador_id', params.operador_id);
+  if (params.grado) queryParams.append('grado', params.grado);
+  if (params.discrepancia) queryParams.append('discrepancia', 'true');
+  if (params.page) queryParams.append('page', params.page.toString());
+  if (params.limit) queryParams.append('limit', params.limit.toString());
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/digitalizacion?${queryParams}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<{ expedientes: ExpedienteDigitalizacion[]; total: number; page: number; limit: number }>>(response);
+}
+
+// Get the digitisation progress of an expediente and its tomos
+export async function getDigitalizacionExpediente(expedienteId: string): Promise<ApiResponse<ExpedienteDigitalizacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/digitalizacion`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<ExpedienteDigitalizacion>>(response);
+}
+
+// Change the digitisation state or record the digitised pages of an expediente
+export async function actualizarDigitalizacion(
+  expedienteId: string,
+  data: UpdateDigitalizacionInput
+): Promise<ApiResponse<ExpedienteDigitalizacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/digitalizacion`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<ExpedienteDigitalizacion>>(response);
+}
+
+// Assign an expediente to a digitisation operator; an empty id removes the assignment
+export async function asignarDigitalizacion(expedienteId: string, operadorId: string): Promise<ApiResponse<ExpedienteDigitalizacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/digitalizacion/asignacion`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify({ operador_id: operadorId }),
+  });
+  return handleResponse<ApiResponse<ExpedienteDigitalizacion>>(response);
+}
+
+// Build the query string of a movement search
+function movimientoQuery(params: MovimientoSearchParams): URLSearchParams {
+  const queryParams = new URLSearchParams();
+  if (params.tipo) queryParams.append('tipo', params.tipo);
+  if (params.fecha_inicio) queryParams.append('fecha_inicio', params.fecha_inicio);
+  if (params.fecha_fin) queryParams.append('fecha_fin', params.fecha_fin);
+  if (params.usuario_id) queryParams.append('usuario_id', params.usuario_id);
+  if (params.page) queryParams.append('page', params.page.toString());
+  if (params.limit) queryParams.append('limit', params.limit.toString());
+  return queryParams;
+}
+
+// Get the movements of an expediente in numbering order
+export async function getMovimientos(
+  expedienteId: string,
+  params: MovimientoSearchParams = {}
+): Promise<ApiResponse<{ movimientos: Movimiento[]; total: number; page: number; limit: number }>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/movimientos?${movimientoQuery(params)}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<{ movimientos: Movimiento[]; total: number; page: number; limit: number }>>(response);
+}
+
+// Get a movement of an expediente by its number
+export async function getMovimiento(expedienteId: string, numero: number): Promise<ApiResponse<Movimiento>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/movimientos/${numero}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Movimiento>>(response);
+}
+
+// Record a movement; corrections are new movements with corrige_a
+export async function registrarMovimiento(expedienteId: string, data: CreateMovimientoInput): Promise<ApiResponse<Movimiento>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/movimientos`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Movimiento>>(response);
+}
+
+// Search the movements of every readable expediente, newest first
+export async function searchMovimientos(
+  params: MovimientoSearchParams = {}
+): Promise<ApiResponse<{ movimientos: MovimientoReporte[]; total: number; page: number; limit: number }>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/movimientos?${movimientoQuery(params)}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<{ movimientos: MovimientoReporte[]; total: number; page: number; limit: number }>>(response);
+}
+
+// Get the notifications of an expediente, or of one of its movements
+export async function getNotificaciones(expedienteId: string, numero?: number): Promise<ApiResponse<Notificacion[]>> {
+  const path = numero ? `movimientos/${numero}/notificaciones` : 'notificaciones';
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/${path}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Notificacion[]>>(response);
+}
+
+// Register a notification of a movement; email notifications are sent by the server
+export async function registrarNotificacion(
+  expedienteId: string,
+  numero: number,
+  data: CreateNotificacionInput
+): Promise<ApiResponse<Notificacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/movimientos/${numero}/notificaciones`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Notificacion>>(response);
+}
+
+// Record the delivery or return of a pending notification
+export async function actualizarEstadoNotificacion(
+  expedienteId: string,
+  notificacionId: string,
+  data: UpdateEstadoNotificacionInput
+): Promise<ApiResponse<Notificacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/notificaciones/${notificacionId}/estado`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Notificacion>>(response);
+}
+
+// Send a pending email notification again
+export async function reenviarNotificacion(expedienteId: string, notificacionId: string): Promise<ApiResponse<Notificacion>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/notificaciones/${notificacionId}/reenviar`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Notificacion>>(response);
+}
+
+// Report the notifications pending for at least `dias` days, oldest first
+export async function getNotificacionesPendientes(
+  params: NotificacionPendienteParams = {}
+): Promise<ApiResponse<{ notificaciones: NotificacionReporte[]; total: number; page: number; limit: number }>> {
+  const queryParams = new URLSearchParams();
+  if (params.dias !== undefined) queryParams.append('dias', params.dias.toString());
+  if (params.metodo) queryParams.append('metodo', params.metodo);
+  if (params.page) queryParams.append('page', params.page.toString());
+  if (params.limit) queryParams.append('limit', params.limit.toString());
+
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/notificaciones/pendientes?${queryParams}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<{ notificaciones: NotificacionReporte[]; total: number; page: number; limit: number }>>(response);
+}
+
+// ========== DEPENDENCIA API FUNCTIONS ==========
+
+// Get the catalog of juzgados and offices, optionally only the active ones
+export async function getDependencias(soloActivas?: boolean): Promise<ApiResponse<Dependencia[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/dependencias${soloActivas ? '?activas=1' : ''}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Dependencia[]>>(response);
+}
+
+// Get a dependencia by ID
+export async function getDependencia(id: string): Promise<ApiResponse<Dependencia>> {
+  const response = await safeFetch(`${API_BASE_URL}/dependencias/${id}`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<Dependencia>>(response);
+}
+
+// Create a dependencia
+export async function createDependencia(data: CreateDependenciaInput): Promise<ApiResponse<Dependencia>> {
+  const response = await safeFetch(`${API_BASE_URL}/dependencias`, {
+    method: 'POST',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Dependencia>>(response);
+}
+
+// Update a dependencia; `activa: false` deactivates it
+export async function updateDependencia(id: string, data: UpdateDependenciaInput): Promise<ApiResponse<Dependencia>> {
+  const response = await safeFetch(`${API_BASE_URL}/dependencias/${id}`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify(data),
+  });
+  return handleResponse<ApiResponse<Dependencia>>(response);
+}
+
+// Delete a dependencia that has no expedientes nor loans
+export async function deleteDependencia(id: string): Promise<ApiResponse<void>> {
+  const response = await safeFetch(`${API_BASE_URL}/dependencias/${id}`, {
+    method: 'DELETE',
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<void>>(response);
+}
+
+// Expedientes assigned to and folders on loan to each dependencia
+export async function getTenenciaDependencias(): Promise<ApiResponse<TenenciaDependencia[]>> {
+  const response = await safeFetch(`${API_BASE_URL}/dependencias/tenencia`, {
+    headers: getAuthHeaders(),
+  });
+  return handleResponse<ApiResponse<TenenciaDependencia[]>>(response);
+}
+
+// Assign an expediente to a dependencia; an empty ID removes the assignment
+export async function asignarDependencia(expedienteId: string, dependenciaId: string): Promise<ApiResponse<Expediente>> {
+  const response = await safeFetch(`${API_BASE_URL}/expedientes/${expedienteId}/dependencia`, {
+    method: 'PUT',
+    headers: getAuthHeaders(),
+    body: JSON.stringify({ dependencia_id: dependenciaId }),
+  });
+  return handleResponse<ApiResponse<Expediente>>(response);
+}
@@ -212,12 +212,19 @@ export const PERMISSIONS_BY_CATEGORY = {
 // Expedientes types - Según Swagger
 export type Grado = 'GRAL' | 'CRL' | 'TTE CRL' | 'MY' | 'CAP' | 'TTE' | 'STTE' | 'TCO' | 'SSOO' | 'EC' | 'TROPA';
 export type SituacionMilitar = 'Actividad' | 'Retiro';
-export type ExpedienteEstado = 'dentro' | 'fuera';
+export type ExpedienteEstado =
+    | 'dentro'
+    | 'fuera'
+    | 'archivado_definitivo'
+    | 'en_digitalizacion'
+    | 'extraviado'
+    | 'transferido'
+    | 'en_restauracion';
 
 // Enum values for dropdowns
 export const GradoValues: Grado[] = ['GRAL', 'CRL', 'TTE CRL', 'MY', 'CAP', 'TTE', 'STTE', 'TCO', 'SSOO', 'EC', 'TROPA'];
 export const SituacionMilitarValues: SituacionMilitar[] = ['Actividad', 'Retiro'];
-export const EstadoExpedienteValues: ExpedienteEstado[] = ['dentro', 'fuera'];
+export const EstadoExpedienteValues: ExpedienteEstado[] = ['dentro', 'fuera', 'archivado_definitivo', 'en_digitalizacion', 'extraviado', 'transferido', 'en_restauracion'];
 
 // Labels for display
 export const GradoLabels: Record<Grado, string> = {
@@ -241,9 +248,43 @@ export const SituacionMilitarLabels: Record<SituacionMilitar, string> = {
 
 export const EstadoExpedienteLabels: Record<ExpedienteEstado, string> = {
     'dentro': 'Dentro',
-    'fuera': 'Fuera'
+    'fuera': 'Fuera',
+    'archivado_definitivo': 'Archivado definitivo',
+    'en_digitalizacion': 'En digitalización',
+    'extraviado': 'Extraviado',
+    'transferido': 'Transferido',
+    'en_restauracion': 'En restauración'
 };
 
+// Archive layout types
+export type UnidadCapacidad = 'carpetas' | 'cm';
+
+export interface ArchivoDivision {
+    id: string;
+    estante_id: string;
+    numero: number;
+    rango_inicio: string;
+    rango_fin: string;
+    rango: string;
+    capacidad: number;
+    unidad_capacidad: UnidadCapacidad;
+    grados: Grado[];
+    situacion?: SituacionMilitar;
+    expedientes: number;
+    paginas: number;
+    ocupado: number;
+    porcentaje: number;
+}
+
+export interface ArchivoEstante {
+    id: string;
+    numero: number;
+    nombre: string;
+    descripcion: string;
+    divisiones: ArchivoDivision[];
+    expedientes: number;
+}
+
 export interface Expediente {
     id: string;
     grado: Grado;
@@ -252,8 +293,15 @@ export interface Expediente {
     numero_paginas: number;
     situacion_militar: SituacionMilitar;
     ubicacion: string;
-    orden: number;
+    orden: number; // Orden de archivo calculado por el servidor según grado y situación
+    numero_registro: number; // Número correlativo de registro
     estado: ExpedienteEstado;
+    tomos: number; // Número de tomos físicos, 0 si no está dividido
+    tomos_fuera: number;
+    parcialmente_fuera: boolean;
+    paginas_digitalizadas: number; // Páginas escaneadas, ingeridas o registradas a mano
+    digitalizacion?: Digitalizacion; // Ausente mientras está pendiente
+    dependencia_id?: string; // Juzgado u oficina asignado
     ano: number; // Año de 4 dígitos
     fecha_registro: string;
     fecha_actualizacion: string;
@@ -263,6 +311,695 @@ export interface Expediente {
     updated_by: string;
 }
 
+export interface Tomo {
+    id: string;
+    expediente_id: string;
+    numero: number;
+    pagina_desde: number;
+    pagina_hasta: number;
+    numero_paginas: number;
+    paginas_digitalizadas: number; // Páginas escaneadas, ingeridas o registradas a mano
+    ubicacion: string;
+    estado: ExpedienteEstado;
+    created_at: string;
+    updated_at: string;
+}
+
+export interface CreateTomoInput {
+    numero?: number; // Siguiente número libre si se omite
+    pagina_desde: number;
+    pagina_hasta: number;
+    ubicacion?: string; // Ubicación del expediente si se omite
+}
+
+export interface UpdateTomoInput {
+    pagina_desde?: number;
+    pagina_hasta?: number;
+    ubicacion?: string;
+}
+
+export interface EventoCarrera {
+    id: string;
+    expediente_id: string;
+    fecha_efectiva: string;
+    resolucion: string;
+    grado_anterior: Grado;
+    grado_nuevo: Grado;
+    situacion_anterior: SituacionMilitar;
+    situacion_nueva: SituacionMilitar;
+    observaciones?: string;
+    lote_id?: string; // Lote de resolución del que se aplicó
+    usuario_id: string;
+    usuario: string;
+    created_at: string;
+}
+
+export interface CreateEventoCarreraInput {
+    fecha_efectiva: string; // YYYY-MM-DD
+    resolucion: string;
+    grado?: Grado; // Sin cambios si se omite
+    situacion_militar?: SituacionMilitar; // Sin cambios si se omite
+    observaciones?: string;
+}
+
+export interface CarreraExpediente {
+    fecha: string;
+    grado: Grado;
+    situacion_militar: SituacionMilitar;
+    eventos: EventoCarrera[];
+}
+
+export type ResultadoFila = 'cambio' | 'sin_cambios' | 'cip_desconocido' | 'duplicada' | 'invalida';
+
+export interface FilaResolucion {
+    fila: number;
+    cip: string;
+    expediente_id?: string;
+    apellidos_nombres?: string;
+    grado_actual?: Grado;
+    grado_nuevo?: Grado;
+    situacion_actual?: SituacionMilitar;
+    situacion_nueva?: SituacionMilitar;
+    fecha_efectiva?: string;
+    resolucion: string;
+    resultado: ResultadoFila;
+    error?: string;
+}
+
+export interface LoteResolucion {
+    id: string;
+    archivo: string;
+    estado: 'propuesto' | 'aplicado';
+    filas: FilaResolucion[];
+    resumen: {
+        cambios: number;
+        sin_cambios: number;
+        cip_desconocidos: number;
+        duplicadas: number;
+        invalidas: number;
+    };
+    creado_por: string;
+    creado_en: string;
+    aplicado_por?: string;
+    aplicado_en?: string;
+}
+
+export type TipoDiscrepancia = 'sin_expediente' | 'carrera' | 'fuera_de_nomina';
+
+export interface Discrepancia {
+    tipo: TipoDiscrepancia;
+    cip: string;
+    apellidos_nombres: string;
+    fila?: number; // Fila de la nómina; ausente si no figura en ella
+    expediente_id?: string;
+    grado_archivo?: Grado;
+    grado_nomina?: Grado;
+    situacion_archivo?: SituacionMilitar;
+    situacion_nomina?: SituacionMilitar;
+    lote_id?: string; // Lote de resolución con el que se aplicó
+}
+
+export interface Reconciliacion {
+    id: string;
+    archivo: string;
+    discrepancias: Discrepancia[];
+    filas_invalidas: Array<{ fila: number; cip: string; error: string }>;
+    resumen: {
+        personal_nomina: number;
+        sin_expediente: number;
+        carrera: number;
+        fuera_de_nomina: number;
+        filas_invalidas: number;
+    };
+    creado_por: string;
+    creado_en: string;
+}
+
+export interface AplicarReconciliacionInput {
+    cips: string[];
+    fecha_efectiva: string; // YYYY-MM-DD
+    resolucion: string;
+}
+
+export interface ParDuplicado {
+    expedientes: Expediente[];
+    similitud: number; // 1 para el mismo CIP o nombre
+    motivos: Array<'cip' | 'nombre'>;
+}
+
+export interface MergeExpedientesInput {
+    superviviente_id: string;
+    duplicado_ids: string[];
+    justificacion: string;
+}
+
+export interface ResultadoMerge {
+    superviviente: Expediente;
+    fusionados: string[];
+    tomos_movidos: number;
+    cambios_estado: number;
+    eventos_carrera: number;
+    prestamos: number;
+    documentos: number;
+    movimientos: number;
+    notificaciones: number;
+}
+
+export type FormatoEtiqueta = 'pdf' | 'zpl';
+export type SimbologiaEtiqueta = 'code128' | 'qr';
+
+export interface EtiquetasInput {
+    expediente_ids: string[];
+    formato?: FormatoEtiqueta;
+    simbologia?: SimbologiaEtiqueta;
+}
+
+export type AccionEscaneo = 'prestamo' | 'devolucion';
+
+export interface EscaneoInput {
+    codigo: string; // Código de la etiqueta (E…, T…) o ID del expediente
+    accion: AccionEscaneo;
+    prestatario?: string; // Obligatorio para prestar
+    division?: string; // ID o código D… de la división donde se devuelve
+    dependencia?: string; // ID o código de la dependencia que recibe la carpeta
+}
+
+export interface Prestamo {
+    id: string;
+    expediente_id: string;
+    tomo_id?: string;
+    tomo?: number;
+    prestatario: string;
+    dependencia_id?: string;
+    dependencia?: string; // Nombre de la dependencia prestataria
+    salida: string;
+    usuario_salida_id: string;
+    usuario_salida: string;
+    devolucion?: string;
+    usuario_devolucion_id?: string;
+    usuario_devolucion?: string;
+}
+
+export interface PrestamoAbierto {
+    prestamo: Prestamo;
+    expediente: Expediente;
+}
+
+export interface ResultadoEscaneo {
+    accion: AccionEscaneo;
+    expediente_id: string;
+    tomo_id?: string;
+    tomo?: number;
+    cip: string;
+    grado: Grado;
+    apellidos_nombres: string;
+    ubicacion: string;
+    estado: ExpedienteEstado;
+    repetido: boolean; // Ya estaba en el estado pedido; no cambió nada
+    prestamo?: Prestamo;
+    advertencias: string[];
+}
+
+export type EstadoInventario = 'abierto' | 'pausado' | 'cerrado';
+export type TipoHallazgo = 'faltante' | 'mal_ubicado' | 'desconocido' | 'fuera_presente';
+
+export interface DivisionInventario {
+    division_id: string;
+    estante: number;
+    numero: number;
+    rango: string;
+}
+
+export interface LecturaInventario {
+    codigo: string;
+    division_id: string;
+    expediente_id?: string;
+    tomo_id?: string;
+    fecha: string;
+    usuario_id: string;
+}
+
+export interface Hallazgo {
+    tipo: TipoHallazgo;
+    codigo?: string; // Vacío para las carpetas faltantes
+    expediente_id?: string;
+    tomo_id?: string;
+    tomo?: number;
+    cip?: string;
+    apellidos_nombres?: string;
+    grado?: Grado;
+    ubicacion?: string;
+    estado?: ExpedienteEstado;
+    division_leida?: DivisionInventario;
+    division_esperada?: DivisionInventario;
+}
+
+export interface ResumenInventario {
+    esperados: number;
+    leidos: number;
+    encontrados: number;
+    faltantes: number;
+    mal_ubicados: number;
+    desconocidos: number;
+    fuera_presentes: number;
+}
+
+export interface Inventario {
+    id: string;
+    estante_id?: string;
+    division_id?: string;
+    divisiones: DivisionInventario[];
+    descripcion: string;
+    estado: EstadoInventario;
+    lecturas?: LecturaInventario[];
+    total_lecturas: number;
+    resumen?: ResumenInventario;
+    hallazgos?: Hallazgo[];
+    creado_por: string;
+    creado_en: string;
+    actualizado_en: string;
+    cerrado_por?: string;
+    cerrado_en?: string;
+    division_actual?: string;
+}
+
+export interface ReporteInventario {
+    inventario_id: string;
+    descripcion: string;
+    estado: EstadoInventario;
+    resumen: ResumenInventario;
+    hallazgos: Hallazgo[];
+    generado_en: string;
+}
+
+export interface CreateInventarioInput {
+    estante_id?: string;
+    division_id?: string;
+    descripcion?: string;
+}
+
+export interface LecturasInventarioInput {
+    codigos: string[]; // Una etiqueta D… cambia la división de los códigos que la siguen
+    division?: string;
+}
+
+export interface ResultadoLectura {
+    codigo: string;
+    division?: DivisionInventario;
+    cambio_division: boolean;
+    hallazgos: TipoHallazgo[];
+    division_esperada?: DivisionInventario;
+    repetido: boolean;
+    cip?: string;
+    apellidos_nombres?: string;
+    ubicacion?: string;
+    estado?: ExpedienteEstado;
+    tomo?: number;
+}
+
+export type FormatoDocumento = 'pdf' | 'doc' | 'docx' | 'jpg' | 'png';
+
+export interface Documento {
+    id: string;
+    expediente_id: string;
+    nombre: string;
+    formato: FormatoDocumento;
+    content_type: string;
+    tamano: number; // Bytes
+    sha256?: string;
+    tipo: string;
+    descripcion?: string;
+    fecha?: string; // Fecha del documento
+    confidencial: boolean; // Solo visible con documento:confidential
+    subido_por: string;
+    usuario: string;
+    subido_en: string;
+    version: number; // Versión actual
+    versiones: VersionDocumento[];
+    integridad: EstadoIntegridad; // Peor estado de sus versiones
+    texto?: EstadoTexto; // Extracción de texto de la versión actual
+    paginas?: number;
+    origen?: 'ingesta'; // Escaneo tomado de la carpeta de ingesta
+    tomo_id?: string; // Tomo que cubre el escaneo
+}
+
+export type EstadoTexto = 'pendiente' | 'extraido' | 'sin_texto' | 'no_soportado' | 'error';
+
+export type EstadoIntegridad = 'pendiente' | 'ok' | 'alterado' | 'faltante';
+
+export interface VersionDocumento {
+    version: number;
+    nombre: string;
+    formato: FormatoDocumento;
+    content_type: string;
+    tamano: number; // Bytes
+    sha256?: string;
+    subido_por: string;
+    usuario: string;
+    subido_en: string;
+    restaurada_de?: number; // Versión cuyo archivo se restauró
+    integridad: EstadoIntegridad;
+    verificado_en?: string;
+}
+
+export interface FalloIntegridad {
+    id: string;
+    documento_id: string;
+    expediente_id: string;
+    version: number;
+    nombre: string;
+    estado: EstadoIntegridad;
+    sha256_esperado: string;
+    sha256_obtenido?: string;
+    detectado_en: string;
+}
+
+export interface IntegridadExpediente {
+    expediente_id: string;
+    estado: EstadoIntegridad;
+    documentos: number;
+    versiones: number;
+    verificadas: number;
+    pendientes: number;
+    fallidas: number;
+    ultima_verificacion?: string;
+    fallos: FalloIntegridad[]; // Más recientes primero
+}
+
+export interface SegmentoTexto {
+    texto: string;
+    resaltado?: boolean; // Término buscado
+}
+
+export interface ResultadoBusquedaTexto {
+    expediente_id: string;
+    cip: string;
+    grado: Grado;
+    apellidos_nombres: string;
+    clasificacion?: string;
+    documento_id: string;
+    nombre: string;
+    tipo: string;
+    version: number;
+    pagina: number;
+    puntaje: number;
+    fragmento: SegmentoTexto[];
+}
+
+export interface BusquedaTextoResultado {
+    resultados: ResultadoBusquedaTexto[];
+    total: number;
+    page: number;
+    limit: number;
+    total_pages: number;
+}
+
+export type EstadoIngesta = 'importado' | 'nueva_version' | 'duplicado' | 'cuarentena';
+
+export interface Ingesta {
+    id: string;
+    archivo: string;
+    cip?: string;
+    tomo?: number;
+    estado: EstadoIngesta;
+    motivo?: string; // Motivo de la cuarentena
+    cuarentena?: string; // Nombre en la carpeta de cuarentena
+    expediente_id?: string;
+    documento_id?: string;
+    version?: number;
+    paginas?: number;
+    tamano: number; // Bytes
+    sha256?: string;
+    procesado_en: string;
+}
+
+export type EstadoDigitalizacion = 'pendiente' | 'en_proceso' | 'completo' | 'verificado';
+
+export interface Digitalizacion {
+    estado: EstadoDigitalizacion;
+    operador_id?: string;
+    operador?: string; // Email del operador asignado
+    asignado_en?: string;
+    verificado_por?: string;
+    verificado_en?: string;
+    actualizado_por?: string;
+    actualizado_en?: string;
+}
+
+export interface UpdateDigitalizacionInput {
+    estado?: EstadoDigitalizacion; // Verificar requiere digitalizacion:verify
+    paginas_digitalizadas?: number;
+    tomo?: number; // Obligatorio con páginas si el expediente tiene tomos
+}
+
+export interface TomoDigitalizacion {
+    tomo_id: string;
+    numero: number;
+    numero_paginas: number;
+    paginas_digitalizadas: number;
+    discrepancia: boolean;
+}
+
+export interface ExpedienteDigitalizacion {
+    expediente_id: string;
+    cip: string;
+    grado: Grado;
+    apellidos_nombres: string;
+    ubicacion: string;
+    numero_paginas: number;
+    paginas_digitalizadas: number;
+    tomos: number;
+    estado: EstadoDigitalizacion;
+    digitalizacion?: Digitalizacion;
+    diferencia: number; // Páginas digitalizadas menos físicas
+    discrepancia: boolean;
+    detalle_tomos?: TomoDigitalizacion[];
+}
+
+export interface DigitalizacionSearchParams {
+    estado?: EstadoDigitalizacion;
+    operador_id?: string;
+    grado?: Grado;
+    discrepancia?: boolean;
+    page?: number;
+    limit?: number;
+}
+
+export interface ProgresoDigitalizacion {
+    expedientes: number;
+    pendientes: number;
+    en_proceso: number;
+    completos: number;
+    verificados: number;
+    paginas: number;
+    paginas_digitalizadas: number; // Limitadas a las páginas físicas de cada expediente
+    porcentaje: number;
+    discrepancias: number;
+}
+
+export interface DivisionDigitalizacion extends ProgresoDigitalizacion {
+    division_id: string;
+    estante_id: string;
+    numero: number;
+    rango: string;
+}
+
+export interface GradoDigitalizacion extends ProgresoDigitalizacion {
+    grado: Grado;
+}
+
+export interface OperadorDigitalizacion extends ProgresoDigitalizacion {
+    operador_id: string;
+    operador: string;
+}
+
+export interface ResumenDigitalizacion {
+    general: ProgresoDigitalizacion;
+    por_division: DivisionDigitalizacion[];
+    por_grado: GradoDigitalizacion[];
+    por_operador: OperadorDigitalizacion[];
+    generado_en: string;
+}
+
+export type TipoMovimiento = 'ingreso' | 'actuacion' | 'resolucion' | 'notificacion' | 'audiencia' | 'archivo';
+
+export interface Movimiento {
+    id: string;
+    expediente_id: string;
+    numero: number; // Correlativo por expediente, sin saltos
+    tipo: TipoMovimiento;
+    descripcion: string;
+    documento_ids?: string[];
+    corrige_a?: number; // Número del movimiento que corrige
+    fecha: string;
+    usuario_id: string;
+    usuario: string;
+    origen?: { expediente_id: string; numero: number }; // Numeración original antes de una fusión
+    corregido_por?: number[];
+}
+
+export interface MovimientoReporte extends Movimiento {
+    cip: string;
+    apellidos_nombres: string;
+}
+
+export interface CreateMovimientoInput {
+    tipo: TipoMovimiento;
+    descripcion: string;
+    documento_ids?: string[]; // Documentos ya adjuntos al expediente
+    corrige_a?: number;
+}
+
+export interface MovimientoSearchParams {
+    tipo?: TipoMovimiento;
+    fecha_inicio?: string; // YYYY-MM-DD
+    fecha_fin?: string; // YYYY-MM-DD, inclusive
+    usuario_id?: string;
+    page?: number;
+    limit?: number;
+}
+
+export type MetodoNotificacion = 'cedula' | 'edicto' | 'email';
+
+export type EstadoNotificacion = 'pendiente' | 'entregado' | 'devuelto';
+
+export interface Notificacion {
+    id: string;
+    expediente_id: string;
+    movimiento_id: string;
+    movimiento_numero: number;
+    movimiento_tipo: TipoMovimiento;
+    receptor: string;
+    direccion?: string;
+    email?: string;
+    metodo: MetodoNotificacion;
+    estado: EstadoNotificacion;
+    fecha_envio?: string; // Email: cuando el servidor SMTP la aceptó
+    fecha_entrega?: string;
+    fecha_devolucion?: string;
+    observaciones?: string;
+    error_envio?: string; // Último error del envío por email
+    usuario_id: string;
+    usuario: string;
+    actualizado_por?: string;
+    created_at: string;
+    updated_at: string;
+}
+
+export interface NotificacionReporte extends Notificacion {
+    cip: string;
+    apellidos_nombres: string;
+    dias_pendiente: number;
+}
+
+export interface CreateNotificacionInput {
+    receptor: string;
+    metodo: MetodoNotificacion;
+    email?: string; // Obligatorio para el método email
+    direccion?: string;
+    fecha_envio?: string; // YYYY-MM-DD, cédula y edicto; hoy si se omite
+    observaciones?: string;
+}
+
+export interface UpdateEstadoNotificacionInput {
+    estado: 'entregado' | 'devuelto';
+    fecha?: string; // YYYY-MM-DD; hoy si se omite
+    observaciones?: string;
+}
+
+export interface NotificacionPendienteParams {
+    dias?: number; // 7 por defecto
+    metodo?: MetodoNotificacion;
+    page?: number;
+    limit?: number;
+}
+
+export type TipoDependencia = 'juzgado' | 'oficina';
+
+export type Competencia = 'civil' | 'penal' | 'laboral' | 'familia' | 'comercial';
+
+export interface PersonalDependencia {
+    usuario_id: string;
+    usuario: string; // Email del usuario
+    nombre: string;
+    cargo: string;
+}
+
+export interface Dependencia {
+    id: string;
+    codigo: string;
+    nombre: string;
+    tipo: TipoDependencia;
+    competencias: Competencia[];
+    personal: PersonalDependencia[];
+    direccion?: string;
+    telefono?: string;
+    email?: string;
+    activa: boolean; // Las inactivas no reciben asignaciones ni préstamos
+    created_at: string;
+    updated_at: string;
+}
+
+export interface PersonalDependenciaInput {
+    usuario_id: string;
+    cargo: string;
+}
+
+export interface CreateDependenciaInput {
+    codigo: string;
+    nombre: string;
+    tipo: TipoDependencia;
+    competencias?: Competencia[];
+    personal?: PersonalDependenciaInput[];
+    direccion?: string;
+    telefono?: string;
+    email?: string;
+}
+
+export interface UpdateDependenciaInput {
+    codigo?: string;
+    nombre?: string;
+    tipo?: TipoDependencia;
+    competencias?: Competencia[]; // Reemplaza la lista actual
+    personal?: PersonalDependenciaInput[]; // Reemplaza la lista actual
+    direccion?: string;
+    telefono?: string;
+    email?: string;
+    activa?: boolean;
+}
+
+export interface TenenciaDependencia {
+    dependencia: Dependencia;
+    asignados: number;
+    prestados: number; // Préstamos abiertos de expedientes o tomos
+}
+
+export interface EnlaceDocumento {
+    url: string; // URL firmada del almacenamiento S3
+    expira_en: string;
+}
+
+export interface SubirDocumentosInput {
+    files: File[]; // Hasta 5 archivos PDF, DOC, DOCX, JPG o PNG
+    tipo: string;
+    descripcion?: string;
+    fecha?: string; // YYYY-MM-DD
+    confidencial?: boolean; // Requiere documento:confidential
+}
+
+export interface CambioEstado {
+    id: string;
+    expediente_id: string;
+    tomo_id?: string;
+    tomo?: number;
+    desde: ExpedienteEstado;
+    hasta: ExpedienteEstado;
+    justificacion: string;
+    usuario_id: string;
+    usuario: string;
+    fecha: string;
+}
+
 export interface CreateExpedienteInput {
     grado: Grado;
     apellidos_nombres: string;
@@ -270,7 +1007,6 @@ export interface CreateExpedienteInput {
     numero_paginas: number;
     situacion_militar: SituacionMilitar;
     ubicacion: string;
-    orden: number;
     ano: number; // Año de 4 dígitos
 }
 
@@ -281,8 +1017,6 @@ export interface UpdateExpedienteInput {
     numero_paginas?: number;
     situacion_militar?: SituacionMilitar;
     ubicacion?: string;
-    orden?: number;
-    estado?: ExpedienteEstado;
     ano?: number; // Año de 4 dígitos
 }
 
@@ -299,6 +1033,7 @@ export interface ExpedienteSearchParams {
     orden?: number;
     fecha_inicio?: string; // ISO date string
     fecha_fin?: string; // ISO date string
+    dependencia_id?: string;
     sort_by?: string;
     sort_order?: 'asc' | 'desc';
 }
@@ -395,6 +1130,7 @@ export interface ResumenGeneral {
     promedio_paginas_por_expediente: number;
     total_paginas: number;
     ubicaciones_unicas: number;
+    expedientes_por_estado: Partial<Record<ExpedienteEstado, number>>;
 }
 
 export interface DashboardStats {